@@ -0,0 +1,202 @@
+// Package main configures the Personal Activity Classifier and prints the
+// confidence it gives every activity, not just the most likely one. The
+// activities to classify go in the sensor-specific word of Set Feature:
+// leaving out states an application never sees, such as cycling for a
+// wrist-worn step counter, stops the classifier confusing them with the
+// ones that matter. The report interval sets how often it decides.
+//
+// Runs over raw SHTP, to set the sensor-specific word. Uses I2C by
+// default, or SPI when built with "-tags bno08x_spi".
+//
+//	only <activity>...   classify only these, e.g. "only still walking running"
+//	all                  classify every activity
+//	interval <ms>        set the report interval
+//	status               show the configuration asked for and granted
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const sensor = bno08x.SensorPersonalActivityClassifier
+
+// session holds the state the commands work on.
+type session struct {
+	link      transport.Transport
+	requested shtpraw.Feature
+	granted   shtpraw.Feature
+	answered  bool
+	acts      sensorinfo.Activities
+	buf       [shtpraw.FeatureLen]byte
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("activity_config")
+	println("=== BNO08x Activity Classifier ===")
+	println()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	s := &session{
+		link: link,
+		requested: shtpraw.Feature{
+			Sensor:   uint8(sensor),
+			Interval: sensorinfo.DefaultIntervalMicros(sensor),
+			Specific: sensorinfo.AllActivities,
+		},
+	}
+	if err := s.apply(); err != nil {
+		println("Failed to enable", sensorinfo.Name(sensor)+":", err.Error())
+		return
+	}
+
+	var sh shell.Shell
+	sh.Register("only", "only <activity>...", "Classify only these activities", s.only)
+	sh.Register("all", "all", "Classify every activity", func(args []string) error {
+		s.requested.Specific = sensorinfo.AllActivities
+		return s.apply()
+	})
+	sh.Register("interval", "interval <ms>", "Set the report interval", s.interval)
+	sh.Register("status", "status", "Show the configuration asked for and granted", func(args []string) error {
+		s.status()
+		return nil
+	})
+
+	println("Activities:", activityList(sensorinfo.AllActivities))
+	println("Type 'only <activity>...' to narrow the classifier down, 'help' for commands")
+	println()
+
+	for {
+		sh.Poll()
+		packet, err := transport.Next(link, 10*time.Millisecond)
+		if err != nil {
+			continue
+		}
+		switch packet.Channel {
+		case shtpraw.ChannelControl:
+			if f, err := shtpraw.ParseFeature(packet.Cargo()); err == nil && f.Sensor == uint8(sensor) {
+				s.granted, s.answered = f, true
+			}
+		case shtpraw.ChannelInputNormal, shtpraw.ChannelInputWake:
+			shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+				if r.ID == uint8(sensor) && s.acts.Add(sensorinfo.DecodeActivity(r.Data[shtpraw.ReportHeaderLen:])) {
+					s.print()
+				}
+			})
+		}
+	}
+}
+
+// apply sends the requested configuration.
+func (s *session) apply() error {
+	s.answered = false
+	return s.link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(s.buf[:0], s.requested))
+}
+
+func (s *session) only(args []string) error {
+	if len(args) == 0 {
+		return shell.ErrUsage
+	}
+	var states []uint8
+	for _, arg := range args {
+		state, ok := parseActivity(arg)
+		if !ok {
+			println("Unknown activity", arg+"; choose from:", activityList(sensorinfo.AllActivities))
+			return nil
+		}
+		states = append(states, state)
+	}
+	s.requested.Specific = sensorinfo.ActivityMask(states...)
+	println("Classifying", activityList(s.requested.Specific))
+	return s.apply()
+}
+
+func (s *session) interval(args []string) error {
+	if len(args) != 1 {
+		return shell.ErrUsage
+	}
+	ms, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil || ms == 0 {
+		return shell.ErrUsage
+	}
+	s.requested.Interval = uint32(ms) * 1000
+	return s.apply()
+}
+
+// status shows the configuration asked for and the one the firmware
+// granted in its Get Feature response.
+func (s *session) status() {
+	println("Asked:  ", s.requested.Interval/1000, "ms,", activityList(s.requested.Specific))
+	if !s.answered {
+		println("Granted: no Get Feature response yet")
+		return
+	}
+	println("Granted:", s.granted.Interval/1000, "ms,", activityList(s.granted.Specific))
+}
+
+// print shows the confidences of the classified activities, most likely
+// first.
+func (s *session) print() {
+	line := "-> " + fmtutil.PadRight(sensorinfo.ActivityName(s.acts.MostLikely), 11)
+	for state, c := range s.acts.Confidence {
+		if s.requested.Specific&(1<<state) == 0 || uint8(state) == s.acts.MostLikely {
+			continue
+		}
+		line += " " + sensorinfo.ActivityName(uint8(state)) + " " + fmtutil.Int(int(c)) + "%"
+	}
+	println(line, "| confidence", s.acts.Confidence[s.acts.MostLikely], "%")
+}
+
+// parseActivity looks up an activity by its name, ignoring case and
+// spaces, or by its number.
+func parseActivity(arg string) (uint8, bool) {
+	if n, err := strconv.ParseUint(arg, 10, 8); err == nil && n < sensorinfo.NumActivities {
+		return uint8(n), true
+	}
+	for state := uint8(0); state < sensorinfo.NumActivities; state++ {
+		name := strings.ReplaceAll(sensorinfo.ActivityName(state), " ", "")
+		if strings.EqualFold(name, arg) {
+			return state, true
+		}
+	}
+	return 0, false
+}
+
+// activityList names the activities in a configuration word.
+func activityList(mask uint32) string {
+	list := ""
+	for state := uint8(0); state < sensorinfo.NumActivities; state++ {
+		if mask&(1<<state) == 0 {
+			continue
+		}
+		if list != "" {
+			list += ", "
+		}
+		list += strings.ToLower(strings.ReplaceAll(sensorinfo.ActivityName(state), " ", ""))
+	}
+	if list == "" {
+		return "none"
+	}
+	return list
+}
@@ -0,0 +1,170 @@
+// Package main turns the board into a USB air mouse. Hold it like a remote,
+// X axis pointing at the screen and Z up: turning left and right moves the
+// pointer across, tilting up and down moves it up and down. The gyroscope's
+// angular velocity, smoothed and scaled by gain, becomes relative mouse
+// movement, so the pointer follows the hand's motion rather than its
+// absolute direction and never drifts.
+//
+// A single tap on the board is a left click and a double tap a right
+// click. Put the board down and the stability classifier reports it on the
+// table, which stops the pointer until it is picked up again.
+package main
+
+import (
+	"machine/usb/hid/mouse"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/filter"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Pointer movement in pixels per radian turned; higher is faster
+	gain = 600
+	// Angular rates below this, in rad/s, are ignored so a still hand
+	// leaves the pointer still
+	deadband = 0.02
+	// Cutoff frequency of the rate smoothing filter; lower is smoother but
+	// lags more
+	smoothingCutoffHz = 8
+	// Gyroscope report rate
+	rateHz = 100
+	// Largest movement of one HID report
+	maxStep = 127
+)
+
+// Stability classifier value for a device lying on a table
+const onTable = 1
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("airmouse")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	err = sensor.EnableReport(bno08x.SensorGyroscope, 1000000/rateHz)
+	if err != nil {
+		println("Failed to enable gyroscope:", err.Error())
+		return
+	}
+	// The tap detector reports on event, the interval is not used
+	err = sensor.EnableReport(bno08x.SensorTapDetector, 0)
+	if err != nil {
+		println("Failed to enable tap detector:", err.Error())
+		return
+	}
+	err = sensor.EnableReport(bno08x.SensorStabilityClassifier, sensorinfo.Interval10Hz)
+	if err != nil {
+		println("Failed to enable stability classifier:", err.Error())
+		return
+	}
+
+	m := mouse.Port()
+	println("Turn to move | Tap: left click | Double tap: right click")
+
+	smooth := filter.EMA3{Alpha: filter.Alpha(smoothingCutoffHz, rateHz)}
+	var taps gesture.TapFilter
+	var restX, restY float32 // Fractions of a pixel carried to the next report
+	parked := false
+
+	for {
+		now := time.Now()
+		kind, _ := taps.Poll(now)
+
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorGyroscope:
+				g := event.Gyroscope()
+				_, gy, gz := smooth.Update(g.X, g.Y, g.Z)
+				if parked {
+					continue
+				}
+				// Turning left is positive about Z and tilting up negative
+				// about Y; screen Y grows downwards
+				dx := -deadzone(gz)*gain/rateHz + restX
+				dy := deadzone(gy)*gain/rateHz + restY
+				ix, fx := step(dx)
+				iy, fy := step(dy)
+				restX, restY = fx, fy
+				if ix != 0 || iy != 0 {
+					m.Move(ix, iy)
+				}
+			case bno08x.SensorTapDetector:
+				if k, _ := taps.Add(gesture.DecodeTap(event.TapDetector().Flags), now); k != gesture.NoTap {
+					kind = k
+				}
+			case bno08x.SensorStabilityClassifier:
+				on := event.StabilityClassifier().Classification == onTable
+				if on != parked {
+					parked = on
+					restX, restY = 0, 0
+					smooth.Reset()
+					if parked {
+						println("On table: pointer stopped")
+					} else {
+						println("Picked up: pointer moving")
+					}
+				}
+			}
+		}
+
+		switch kind {
+		case gesture.SingleTap:
+			m.Click(mouse.Left)
+			println("Left click")
+		case gesture.DoubleTap:
+			m.Click(mouse.Right)
+			println("Right click")
+		}
+
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// deadzone returns the rate with deadband taken off its magnitude.
+func deadzone(rate float32) float32 {
+	switch {
+	case rate > deadband:
+		return rate - deadband
+	case rate < -deadband:
+		return rate + deadband
+	}
+	return 0
+}
+
+// step splits a movement into whole pixels, limited to one HID report,
+// and the fraction left over.
+func step(v float32) (int, float32) {
+	whole := math.Trunc(float64(v))
+	fraction := v - float32(whole)
+	switch {
+	case whole > maxStep:
+		return maxStep, fraction
+	case whole < -maxStep:
+		return -maxStep, fraction
+	}
+	return int(whole), fraction
+}
@@ -1,38 +1,61 @@
 // Package main runs a comprehensive test of all sensors on a BNO08x.
 // It prints product id entries and fields, enables all sensible reports,
-// then counts and prints a summary of received events every 5 seconds.
+// then counts and prints a summary of received events every 5 seconds,
+// together with the latest reading from each sensor.
+//
+// The event loop and the summary do not allocate: counts live in arrays
+// indexed by sensor ID and each line is built in one fixed buffer, so a
+// run of any length leaves the heap where it started. Every summary ends
+// with the heap allocated since the first one and fails the check if it
+// is not zero.
 package main
 
 import (
+	"os"
 	"runtime"
 	"time"
 
-	"machine"
-
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dispatch"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
 	"tinygo.org/x/drivers/bno08x"
 )
 
+// Units used in the summary: units.SI prints m/s², rad/s, µT, °C and meters;
+// units.Imperial prints g, °/s, gauss, °F and feet.
+const unitSystem = units.SI
+
+// Decimal places printed for sensor values
+const decimals = 3
+
+// Longest line printed; the widest, an uncalibrated bias vector, is well
+// under it.
+const maxLine = 160
+
 func main() {
 	m := new(runtime.MemStats)
 	// Small delay for host to be ready
 	time.Sleep(2 * time.Second)
+	buildinfo.Banner("all_sensors")
+	led := statusled.New()
 
 	println("BNO08x Comprehensive Sensor Test")
 	println("================================")
 
-	// Initialize I2C
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{Frequency: 400 * machine.KHz})
+	// Create device and configure (default)
+	sensor, err := transport.OpenSensor()
 	if err != nil {
-		println("I2C configure error:", err.Error())
-		return
+		println("Sensor open error:", err.Error())
+		led.Halt(statusled.BusError)
 	}
-
-	// Create device and configure (default)
-	sensor := bno08x.New(i2c)
 	if err := sensor.Configure(bno08x.Config{}); err != nil {
 		println("Sensor configure error:", err.Error())
-		return
+		led.Halt(statusled.BusError)
 	}
 
 	println("Sensor initialized")
@@ -85,104 +108,70 @@ func main() {
 		bno08x.SensorCircleDetector,
 	}
 
-	// Sensor name mapping (define early for use during enable)
-	sensorNames := map[uint8]string{
-		0x01: "Accelerometer",
-		0x02: "Gyroscope",
-		0x03: "Magnetic Field",
-		0x04: "Linear Acceleration",
-		0x05: "Rotation Vector",
-		0x06: "Gravity",
-		0x07: "Gyroscope Uncalibrated",
-		0x08: "Game Rotation Vector",
-		0x09: "Geomagnetic Rotation Vector",
-		0x0A: "Pressure",
-		0x0B: "Ambient Light",
-		0x0C: "Humidity",
-		0x0D: "Proximity",
-		0x0E: "Temperature",
-		0x0F: "Magnetic Field Uncalibrated",
-		0x10: "Tap Detector",
-		0x11: "Step Counter",
-		0x12: "Significant Motion",
-		0x13: "Stability Classifier",
-		0x14: "Raw Accelerometer",
-		0x15: "Raw Gyroscope",
-		0x16: "Raw Magnetometer",
-		0x18: "Step Detector",
-		0x19: "Shake Detector",
-		0x1A: "Flip Detector",
-		0x1B: "Pickup Detector",
-		0x1C: "Stability Detector",
-		0x1E: "Personal Activity Classifier",
-		0x1F: "Sleep Detector",
-		0x20: "Tilt Detector",
-		0x21: "Pocket Detector",
-		0x22: "Circle Detector",
-	}
-
 	println("Enabling reports (where supported)...")
 	for _, id := range sensors {
 		idByte := uint8(id)
-		name := sensorNames[idByte]
-		if name == "" {
-			name = "Unknown"
-		}
-		// Use 10ms default (100Hz) for most sensors; 0 means disable
-		if err := sensor.EnableReport(id, 10000); err != nil {
-			println(" Enable failed for 0x"+formatHex(idByte)+" ("+name+"):", err.Error())
+		name := sensorinfo.Name(id)
+		// Use the per-sensor default rate (100Hz for motion data); 0 means disable
+		if err := sensor.EnableReport(id, sensorinfo.DefaultIntervalMicros(id)); err != nil {
+			out.begin().str(" Enable failed for 0x").hex(idByte).str(" (").str(name).str("): ").str(err.Error()).print()
 		} else {
-			println(" Enabled 0x" + formatHex(idByte) + " (" + name + ")")
+			out.begin().str(" Enabled 0x").hex(idByte).str(" (").str(name).str(")").print()
 		}
 		// Small pause between requests
 		time.Sleep(20 * time.Millisecond)
 	}
 
-	// Build list of enabled sensors for tracking
-	enabledSensors := make([]uint8, len(sensors))
-	for i, id := range sensors {
-		enabledSensors[i] = uint8(id)
-	}
+	// Dispatcher counts every event and measures per-sensor rates
+	dispatcher := dispatch.New()
 
-	// Counters - initialize all enabled sensors to 0
-	totalEvents := 0
-	counts := make(map[uint8]int)
-	// Track which sensors have received events
-	hasEvents := make(map[uint8]bool)
-	for _, id := range enabledSensors {
-		counts[id] = 0
-		hasEvents[id] = false
-	}
+	// Keep the latest event of each sensor for the summary
+	var last [0x23]bno08x.SensorValue
+	dispatcher.HandleDefault(func(ev *bno08x.SensorValue) {
+		led.Event(ev)
+		if id := uint8(ev.ID()); int(id) < len(last) {
+			last[id] = *ev
+		}
+	})
 
 	lastPrint := time.Now()
 
+	// Heap allocated by the end of the first summary; anything after it
+	// came from the event loop
+	var baseline uint64
+	summaries := 0
+
 	println("Listening for events. Summary every 5s...")
 
 	for {
-		event, ok := sensor.GetSensorEvent()
-		if ok {
-			totalEvents++
-			idByte := uint8(event.ID())
-			counts[idByte]++
-			hasEvents[idByte] = true
-		}
+		dispatcher.Poll(sensor)
+		led.Update(time.Now())
 
 		if time.Since(lastPrint) >= 5*time.Second {
 			println()
 			println("--- Cumulative Summary ---")
-			println("Total events:", totalEvents)
+			println("Total events:", dispatcher.Total())
 			// Print counts for each enabled sensor in order
-			for _, id := range enabledSensors {
-				c := counts[id]
-				name := sensorNames[id]
-				if name == "" {
-					name = "Unknown"
+			for _, id := range sensors {
+				out.begin().str(" 0x").hex(uint8(id)).str(" ").padRight(sensorinfo.Name(id), 28).
+					str(" ").int(int64(dispatcher.Count(id)), 8).
+					str(" ").float(dispatcher.Rate(id), 1, 7).str(" Hz").print()
+				if dispatcher.Count(id) > 0 && int(id) < len(last) {
+					printEventDetails(uint8(id), &last[uint8(id)])
 				}
-				println(" 0x"+formatHex(id)+" ("+name+"):", c)
 			}
 			println("--- End Summary ---")
 			runtime.ReadMemStats(m)
 			println("Alloc =", m.Alloc, "TotalAlloc =", m.TotalAlloc, "Sys =", m.Sys)
+			summaries++
+			if summaries == 1 {
+				baseline = m.TotalAlloc
+				println("Heap baseline recorded; later summaries check it does not grow")
+			} else if growth := m.TotalAlloc - baseline; growth == 0 {
+				println("Heap growth since first summary: 0 bytes over", summaries-1, "summaries: OK")
+			} else {
+				println("Heap growth since first summary:", growth, "bytes over", summaries-1, "summaries: FAIL, the event loop allocates")
+			}
 			lastPrint = time.Now()
 		}
 
@@ -190,10 +179,83 @@ func main() {
 	}
 }
 
-// formatHex formats a byte as a 2-character hex string
-func formatHex(b uint8) string {
-	const hex = "0123456789ABCDEF"
-	return string([]byte{hex[b>>4], hex[b&0x0F]})
+// line builds one line of output in a fixed buffer and writes it to the
+// console, so printing does not allocate as string concatenation and the
+// string forms of fmtutil do.
+type line struct {
+	buf [maxLine]byte
+	b   []byte
+}
+
+// The one line being built
+var out line
+
+// begin starts a new line.
+func (l *line) begin() *line {
+	l.b = l.buf[:0]
+	return l
+}
+
+func (l *line) str(s string) *line {
+	l.b = append(l.b, s...)
+	return l
+}
+
+// padRight appends s left-aligned in width.
+func (l *line) padRight(s string, width int) *line {
+	l.b = fmtutil.AppendPadRight(l.b, s, width)
+	return l
+}
+
+// hex appends b as two upper-case hex digits.
+func (l *line) hex(b uint8) *line {
+	const digits = "0123456789ABCDEF"
+	l.b = append(l.b, digits[b>>4], digits[b&0x0F])
+	return l
+}
+
+// int appends n right-aligned in width; 0 means no padding.
+func (l *line) int(n int64, width int) *line {
+	from := len(l.b)
+	l.b = fmtutil.AlignRight(fmtutil.AppendInt(l.b, n), from, width)
+	return l
+}
+
+// float appends v with the given decimals, right-aligned in width; 0
+// means no padding.
+func (l *line) float(v float32, decimals, width int) *line {
+	from := len(l.b)
+	l.b = fmtutil.AlignRight(fmtutil.AppendFloat(l.b, v, decimals), from, width)
+	return l
+}
+
+// print writes the line and a newline.
+func (l *line) print() {
+	l.b = append(l.b, '\n')
+	os.Stdout.Write(l.b)
+}
+
+// printVector prints a three-axis value after converting each component to
+// the selected unit system. The label prefixes each axis name.
+func printVector(label string, x, y, z float32, convert func(float32) (float32, string)) {
+	x, unit := convert(x)
+	y, _ = convert(y)
+	z, _ = convert(z)
+	out.begin().str("    ").str(label).str("X: ").float(x, decimals, 0).
+		str(" ").str(label).str("Y: ").float(y, decimals, 0).
+		str(" ").str(label).str("Z: ").float(z, decimals, 0).str(" ").str(unit).print()
+}
+
+// printQuaternion prints the components of a rotation vector.
+func printQuaternion(q bno08x.Quaternion) {
+	out.begin().str("    i: ").float(q.I, decimals, 0).str(" j: ").float(q.J, decimals, 0).
+		str(" k: ").float(q.K, decimals, 0).str(" real: ").float(q.Real, decimals, 0).print()
+}
+
+// rawLine begins the line of a raw sensor sample with its ADC counts,
+// for the caller to finish with the fields that sensor has.
+func rawLine(x, y, z int16) *line {
+	return out.begin().str("    X: ").int(int64(x), 0).str(" Y: ").int(int64(y), 0).str(" Z: ").int(int64(z), 0)
 }
 
 // printEventDetails prints human-readable details of the last sensor event
@@ -202,91 +264,71 @@ func printEventDetails(id uint8, ev *bno08x.SensorValue) {
 	// Vector3 sensors (accelerometer, gyro, mag, etc.)
 	case 0x01: // Accelerometer
 		v := ev.Accelerometer()
-		println("    X:", formatFloat(v.X), "Y:", formatFloat(v.Y), "Z:", formatFloat(v.Z), "m/s²")
+		printVector("", v.X, v.Y, v.Z, unitSystem.Acceleration)
 	case 0x02: // Gyroscope
 		v := ev.Gyroscope()
-		println("    X:", formatFloat(v.X), "Y:", formatFloat(v.Y), "Z:", formatFloat(v.Z), "rad/s")
+		printVector("", v.X, v.Y, v.Z, unitSystem.AngularRate)
 	case 0x03: // Magnetic Field
 		v := ev.MagneticField()
-		println("    X:", formatFloat(v.X), "Y:", formatFloat(v.Y), "Z:", formatFloat(v.Z), "µT")
+		printVector("", v.X, v.Y, v.Z, unitSystem.MagneticField)
 	case 0x04: // Linear Acceleration
 		v := ev.LinearAcceleration()
-		println("    X:", formatFloat(v.X), "Y:", formatFloat(v.Y), "Z:", formatFloat(v.Z), "m/s²")
+		printVector("", v.X, v.Y, v.Z, unitSystem.Acceleration)
 	case 0x06: // Gravity
 		v := ev.Gravity()
-		println("    X:", formatFloat(v.X), "Y:", formatFloat(v.Y), "Z:", formatFloat(v.Z), "m/s²")
+		printVector("", v.X, v.Y, v.Z, unitSystem.Acceleration)
 
 	// Quaternion sensors (rotation vectors)
 	case 0x05: // Rotation Vector
-		q := ev.Quaternion()
-		println("    i:", formatFloat(q.I), "j:", formatFloat(q.J), "k:", formatFloat(q.K), "real:", formatFloat(q.Real))
-		println("    Accuracy:", formatFloat(ev.QuaternionAccuracy()), "rad")
+		printQuaternion(ev.Quaternion())
+		out.begin().str("    Accuracy: ").float(ev.QuaternionAccuracy(), decimals, 0).str(" rad").print()
 	case 0x08: // Game Rotation Vector
-		q := ev.Quaternion()
-		println("    i:", formatFloat(q.I), "j:", formatFloat(q.J), "k:", formatFloat(q.K), "real:", formatFloat(q.Real))
+		printQuaternion(ev.Quaternion())
 	case 0x09: // Geomagnetic Rotation Vector
-		q := ev.Quaternion()
-		println("    i:", formatFloat(q.I), "j:", formatFloat(q.J), "k:", formatFloat(q.K), "real:", formatFloat(q.Real))
-		println("    Accuracy:", formatFloat(ev.QuaternionAccuracy()), "rad")
+		printQuaternion(ev.Quaternion())
+		out.begin().str("    Accuracy: ").float(ev.QuaternionAccuracy(), decimals, 0).str(" rad").print()
 
 	// Uncalibrated sensors
 	case 0x07: // Gyroscope Uncalibrated
 		v := ev.GyroscopeUncal()
-		println("    X:", formatFloat(v.X), "Y:", formatFloat(v.Y), "Z:", formatFloat(v.Z), "rad/s")
-		println("    BiasX:", formatFloat(v.BiasX), "BiasY:", formatFloat(v.BiasY), "BiasZ:", formatFloat(v.BiasZ))
+		printVector("", v.X, v.Y, v.Z, unitSystem.AngularRate)
+		printVector("Bias", v.BiasX, v.BiasY, v.BiasZ, unitSystem.AngularRate)
 	case 0x0F: // Magnetic Field Uncalibrated
 		v := ev.MagneticFieldUncal()
-		println("    X:", formatFloat(v.X), "Y:", formatFloat(v.Y), "Z:", formatFloat(v.Z), "µT")
-		println("    BiasX:", formatFloat(v.BiasX), "BiasY:", formatFloat(v.BiasY), "BiasZ:", formatFloat(v.BiasZ))
+		printVector("", v.X, v.Y, v.Z, unitSystem.MagneticField)
+		printVector("Bias", v.BiasX, v.BiasY, v.BiasZ, unitSystem.MagneticField)
 
 	// Raw sensors
 	case 0x14: // Raw Accelerometer
 		v := ev.RawAccelerometer()
-		println("    X:", v.X, "Y:", v.Y, "Z:", v.Z, "Timestamp:", v.Timestamp)
+		rawLine(v.X, v.Y, v.Z).str(" Timestamp: ").int(int64(v.Timestamp), 0).print()
 	case 0x15: // Raw Gyroscope
 		v := ev.RawGyroscope()
-		println("    X:", v.X, "Y:", v.Y, "Z:", v.Z, "Temp:", v.Temperature, "Timestamp:", v.Timestamp)
+		rawLine(v.X, v.Y, v.Z).str(" Temp: ").int(int64(v.Temperature), 0).str(" Timestamp: ").int(int64(v.Timestamp), 0).print()
 	case 0x16: // Raw Magnetometer
 		v := ev.RawMagnetometer()
-		println("    X:", v.X, "Y:", v.Y, "Z:", v.Z, "Timestamp:", v.Timestamp)
+		rawLine(v.X, v.Y, v.Z).str(" Timestamp: ").int(int64(v.Timestamp), 0).print()
 
 	// Environmental sensors
 	case 0x0A: // Pressure
-		println("    Pressure:", formatFloat(ev.Pressure()), "hPa")
+		alt, unit := unitSystem.Altitude(ev.Pressure())
+		out.begin().str("    Pressure: ").float(ev.Pressure(), decimals, 0).str(" hPa Altitude: ").float(alt, decimals, 0).str(" ").str(unit).print()
 	case 0x0B: // Ambient Light
-		println("    Light:", formatFloat(ev.AmbientLight()), "lux")
+		out.begin().str("    Light: ").float(ev.AmbientLight(), decimals, 0).str(" lux").print()
 	case 0x0C: // Humidity
-		println("    Humidity:", formatFloat(ev.Humidity()), "%")
+		out.begin().str("    Humidity: ").float(ev.Humidity(), decimals, 0).str(" %").print()
 	case 0x0D: // Proximity
-		println("    Proximity:", formatFloat(ev.Proximity()), "cm")
+		out.begin().str("    Proximity: ").float(ev.Proximity(), decimals, 0).str(" cm").print()
 	case 0x0E: // Temperature
-		println("    Temperature:", formatFloat(ev.Temperature()), "°C")
+		t, unit := unitSystem.Temperature(ev.Temperature())
+		out.begin().str("    Temperature: ").float(t, decimals, 0).str(" ").str(unit).print()
 
 	// Activity detectors
 	case 0x10: // Tap Detector
 		tap := ev.TapDetector()
-		flags := tap.Flags
-		axis := ""
-		if flags&0x01 != 0 {
-			axis = "X"
-		} else if flags&0x04 != 0 {
-			axis = "Y"
-		} else if flags&0x10 != 0 {
-			axis = "Z"
-		}
-		dir := "+"
-		if flags&0x02 == 0 && flags&0x01 != 0 {
-			dir = "-"
-		} else if flags&0x08 == 0 && flags&0x04 != 0 {
-			dir = "-"
-		} else if flags&0x20 == 0 && flags&0x10 != 0 {
-			dir = "-"
-		}
-		tapType := "Single"
-		if flags&0x40 != 0 {
-			tapType = "Double"
-		}
-		println("    "+tapType+" tap on", axis+dir, "axis (flags:", flags, ")")
+		out.begin().str("    ")
+		out.b = gesture.DecodeTap(tap.Flags).Append(out.b)
+		out.str(" (flags: ").int(int64(tap.Flags), 0).str(")").print()
 
 	case 0x11: // Step Counter
 		sc := ev.StepCounter()
@@ -331,26 +373,7 @@ func printEventDetails(id uint8, ev *bno08x.SensorValue) {
 	case 0x1E: // Personal Activity Classifier
 		pac := ev.PersonalActivityClassifier()
 		activity := pac.MostLikelyState
-		desc := "Unknown"
-		switch activity {
-		case 1:
-			desc = "In Vehicle"
-		case 2:
-			desc = "On Bicycle"
-		case 3:
-			desc = "On Foot"
-		case 4:
-			desc = "Still"
-		case 5:
-			desc = "Tilting"
-		case 6:
-			desc = "Walking"
-		case 7:
-			desc = "Running"
-		case 8:
-			desc = "On Stairs"
-		}
-		println("    Activity:", desc, "(confidence:", pac.Confidence[activity], "%)")
+		println("    Activity:", sensorinfo.ActivityName(activity), "(confidence:", pac.Confidence[activity], "%)")
 
 	case 0x1F: // Sleep Detector
 		println("    Sleep state:", ev.SleepDetector())
@@ -368,58 +391,3 @@ func printEventDetails(id uint8, ev *bno08x.SensorValue) {
 		// Unknown sensor type, don't print details
 	}
 }
-
-// formatFloat formats a float32 with reasonable precision
-func formatFloat(f float32) string {
-	// Simple formatting for embedded systems without fmt
-	val := int32(f * 1000)
-	whole := val / 1000
-	frac := val % 1000
-	if frac < 0 {
-		frac = -frac
-	}
-
-	sign := ""
-	if val < 0 && whole == 0 {
-		sign = "-"
-	}
-
-	return sign + itoa(int(whole)) + "." + itoa3(int(frac))
-}
-
-// itoa converts an integer to string
-func itoa(n int) string {
-	if n == 0 {
-		return "0"
-	}
-
-	negative := n < 0
-	if negative {
-		n = -n
-	}
-
-	// Use fixed-size buffer to avoid allocations
-	var buf [10]byte
-	i := len(buf) - 1
-	for n > 0 {
-		buf[i] = byte('0' + n%10)
-		n /= 10
-		i--
-	}
-
-	if negative {
-		return "-" + string(buf[i+1:])
-	}
-	return string(buf[i+1:])
-}
-
-// itoa3 converts an integer to a 3-digit string (for fractional part)
-func itoa3(n int) string {
-	if n >= 1000 {
-		n = 999
-	}
-	d0 := n / 100
-	d1 := (n / 10) % 10
-	d2 := n % 10
-	return string([]byte{byte('0' + d0), byte('0' + d1), byte('0' + d2)})
-}
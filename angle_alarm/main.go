@@ -0,0 +1,186 @@
+// Package main raises an alarm when the board tilts past a limit, for a
+// gate, a ladder or anything that should stay upright, and sends it to a
+// webhook so it reaches a phone. The tilt is the angle between the board's
+// Z axis and vertical, from the gravity sensor; it must stay past the
+// limit for holdTime to raise the alarm and back inside by hysteresis
+// degrees for holdTime to clear it. The LED is lit while the alarm is
+// raised.
+//
+// Each change posts a JSON event, as described in internal/webhook, to
+// webhookURL: an ntfy topic such as https://ntfy.sh/<topic> pushes it to
+// the ntfy app, and an IFTTT Maker URL ending /json/with/key/<key> passes
+// it on to SMS or anything else IFTTT reaches. Build with "-tags wifi" on
+// a board with a WiFi module supported by tinygo.org/x/drivers/netlink,
+// after setting ssid and passphrase below. Without the tag events are
+// printed on the console instead.
+//
+// Undelivered events are retried with backoff and kept in flash while the
+// network is down, so an alarm raised then is sent once it is back, even
+// after a reset.
+//
+//	status  show the alarm state and webhook queue
+//	test    send a test event
+package main
+
+import (
+	"machine"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"github.com/intermernet/bno08xPrograms/internal/webhook"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Endpoint receiving alarm events
+const webhookURL = "https://ntfy.sh/bno08x-angle-alarm"
+
+// WiFi network to join, in builds with "-tags wifi"
+const (
+	ssid       = ""
+	passphrase = ""
+)
+
+const (
+	// Tilt from vertical that raises the alarm, degrees
+	tiltLimit = 30
+	// Margin inside the limit before the alarm clears, degrees
+	hysteresis = 5
+	// How long the tilt must stay past the limit, or back inside, to
+	// change state
+	holdTime = time.Second
+)
+
+// Decimal places printed for angles
+const decimals = 1
+
+// alarm tracks the debounced alarm state.
+type alarm struct {
+	raised  bool
+	tilt    float32
+	pending time.Time // When the tilt first crossed towards the other state
+}
+
+// update takes a tilt reading and reports whether the alarm state changed.
+func (a *alarm) update(tilt float32, now time.Time) bool {
+	a.tilt = tilt
+	crossing := tilt > tiltLimit
+	if a.raised {
+		crossing = tilt < tiltLimit-hysteresis
+	}
+	if !crossing {
+		a.pending = time.Time{}
+		return false
+	}
+	if a.pending.IsZero() {
+		a.pending = now
+	}
+	if now.Sub(a.pending) < holdTime {
+		return false
+	}
+	a.raised, a.pending = !a.raised, time.Time{}
+	return true
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("angle_alarm")
+	start := time.Now()
+
+	led := board.LEDPin()
+	if led != machine.NoPin {
+		led.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		led.Low()
+	}
+
+	notifier := webhook.New(webhookURL, webhook.Connect(ssid, passphrase), store.New(machine.Flash, "angle_alarm"))
+	if n, err := notifier.Restore(); err != nil {
+		println("Could not restore the webhook queue:", err.Error())
+	} else if n > 0 {
+		println("Restored", n, "undelivered events")
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	err = sensor.EnableReport(bno08x.SensorGravity, 50000) // 20Hz
+	if err != nil {
+		println("Failed to enable gravity:", err.Error())
+		return
+	}
+
+	var a alarm
+	notify := func(kind string) {
+		err := notifier.Notify(webhook.Event{Kind: kind, Value: a.tilt, Limit: tiltLimit, Uptime: time.Since(start)})
+		if err != nil {
+			println("Could not save the webhook queue:", err.Error())
+		}
+	}
+
+	var sh shell.Shell
+	sh.Register("status", "status", "Show the alarm state and webhook queue", func(args []string) error {
+		state := "clear"
+		if a.raised {
+			state = "RAISED"
+		}
+		println("Tilt", fmtutil.Float(a.tilt, decimals), "deg, limit", tiltLimit, "| alarm", state)
+		println("Webhook:", notifier.Pending(), "pending,", notifier.Dropped(), "dropped, retry in",
+			int(notifier.Backoff().Seconds()), "s")
+		return nil
+	})
+	sh.Register("test", "test", "Send a test event", func(args []string) error {
+		notify("test")
+		return nil
+	})
+
+	println("Alarm above", tiltLimit, "degrees of tilt; type 'help' for commands")
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		if event, ok := sensor.GetSensorEvent(); ok && event.ID() == bno08x.SensorGravity {
+			g := event.Gravity()
+			norm := float32(math.Sqrt(float64(g.X*g.X + g.Y*g.Y + g.Z*g.Z)))
+			if norm > 0 {
+				tilt := units.RadiansToDegrees(float32(math.Acos(float64(g.Z / norm))))
+				if a.update(tilt, now) {
+					if a.raised {
+						println("ALARM: tilt", fmtutil.Float(tilt, decimals), "degrees")
+						notify("tilt")
+					} else {
+						println("Alarm cleared: tilt", fmtutil.Float(tilt, decimals), "degrees")
+						notify("tilt_clear")
+					}
+					if led != machine.NoPin {
+						led.Set(a.raised)
+					}
+				}
+			}
+		}
+
+		if err := notifier.Poll(now); err != nil {
+			println("Webhook failed:", err.Error(), "- retrying in", int(notifier.Backoff().Seconds()), "s")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,182 @@
+// Package main finds the fastest report rates a chosen set of sensors can
+// sustain on this particular bus and MCU.
+//
+// Starting every sensor at the bottom of the rate ladder, it raises the
+// slowest sensor one step at a time and measures the result. A step is
+// stable when every sensor still delivers at least minDelivered of its
+// requested rate and Service reports no errors. When a step is unstable
+// that sensor goes back to its last stable rate and is not raised again.
+// The search ends when no sensor can be raised, and prints the maximum
+// stable configuration.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dispatch"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// tuned is a sensor under test and the highest rate it supports according
+// to the BNO08x datasheet.
+type tuned struct {
+	id     bno08x.SensorID
+	maxHz  uint32
+	step   int  // Index into ladder of the current rate
+	stable int  // Index of the last rate measured stable
+	maxed  bool // Cannot be raised further
+}
+
+// Sensors to tune; edit to match what your application enables
+var sensors = []*tuned{
+	{id: bno08x.SensorAccelerometer, maxHz: 500},
+	{id: bno08x.SensorGyroscope, maxHz: 400},
+	{id: bno08x.SensorMagneticField, maxHz: 100},
+	{id: bno08x.SensorGameRotationVector, maxHz: 400},
+	{id: bno08x.SensorRotationVector, maxHz: 400},
+}
+
+// Rates tried, in Hz
+var ladder = []uint32{10, 25, 50, 100, 200, 400, 500}
+
+const (
+	// Time allowed after a rate change before measuring
+	settleTime = 500 * time.Millisecond
+	// Length of each measurement
+	measureWindow = 3 * time.Second
+	// Fraction of the requested rate a sensor must deliver to pass
+	minDelivered = 0.9
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("autotune")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, s := range sensors {
+		if err := sensor.EnableReport(s.id, intervalMicros(ladder[0])); err != nil {
+			println("Failed to enable", sensorinfo.Name(s.id)+":", err.Error())
+			return
+		}
+	}
+
+	d := dispatch.New()
+	println("Measuring baseline at", ladder[0], "Hz...")
+	if !measure(sensor, d) {
+		println("Baseline configuration is not stable, check wiring and bus speed")
+		return
+	}
+
+	for {
+		s := slowest()
+		if s == nil {
+			break
+		}
+		s.step++
+		if err := sensor.EnableReport(s.id, intervalMicros(ladder[s.step])); err != nil {
+			println("Failed to set rate:", err.Error())
+			return
+		}
+		println("Trying", sensorinfo.Name(s.id), "at", ladder[s.step], "Hz...")
+		if measure(sensor, d) {
+			s.stable = s.step
+			continue
+		}
+
+		// Back off and stop raising this sensor
+		s.step = s.stable
+		s.maxed = true
+		if err := sensor.EnableReport(s.id, intervalMicros(ladder[s.step])); err != nil {
+			println("Failed to restore rate:", err.Error())
+			return
+		}
+		println("  Backed off to", ladder[s.step], "Hz")
+	}
+
+	println()
+	println("=== Maximum stable configuration ===")
+	total := uint32(0)
+	for _, s := range sensors {
+		hz := ladder[s.stable]
+		total += hz
+		println(" ", fmtutil.PadRight(sensorinfo.Name(s.id), 28),
+			fmtutil.PadLeft(fmtutil.Int(int(hz)), 4), "Hz",
+			fmtutil.PadLeft(fmtutil.Int(int(intervalMicros(hz))), 7), "us")
+	}
+	println("  Total:", total, "reports/s")
+}
+
+// slowest returns the sensor with the lowest rate that can still be raised,
+// or nil when none can.
+func slowest() *tuned {
+	var best *tuned
+	for _, s := range sensors {
+		if s.maxed || s.step+1 >= len(ladder) || ladder[s.step+1] > s.maxHz {
+			s.maxed = true
+			continue
+		}
+		if best == nil || ladder[s.step] < ladder[best.step] {
+			best = s
+		}
+	}
+	return best
+}
+
+// measure runs the current configuration for measureWindow and reports
+// whether every sensor kept up without bus errors.
+func measure(sensor *bno08x.Device, d *dispatch.Dispatcher) bool {
+	// Drain whatever queued up during the rate change
+	settle := time.Now()
+	for time.Since(settle) < settleTime {
+		d.Poll(sensor)
+	}
+
+	d.Reset()
+	errors := 0
+	start := time.Now()
+	for time.Since(start) < measureWindow {
+		if err := sensor.Service(); err != nil {
+			errors++
+		}
+		d.Poll(sensor)
+	}
+	seconds := float32(time.Since(start).Seconds())
+
+	ok := errors == 0
+	for _, s := range sensors {
+		requested := float32(ladder[s.step])
+		delivered := float32(d.Count(s.id)) / seconds
+		if delivered < requested*minDelivered {
+			ok = false
+			println("  Short:", sensorinfo.Name(s.id), fmtutil.Float(delivered, 1), "of", ladder[s.step], "Hz")
+		}
+	}
+	if errors > 0 {
+		println("  Service errors:", errors)
+	}
+	return ok
+}
+
+// intervalMicros converts a rate in Hz to a report interval.
+func intervalMicros(hz uint32) uint32 {
+	return 1000000 / hz
+}
@@ -0,0 +1,232 @@
+// Package main is a guided check of the sensor axes. It asks for each axis
+// to be pointed straight up in turn, checks that gravity shows up on that
+// axis with the right sign, and prints a verdict on the wiring and
+// mounting. Run it once on a new build: swapped or inverted axes look like
+// bad calibration later and are much harder to spot there.
+//
+// The axes are those printed on the breakout board. Hold the board still
+// with the named axis pointing at the ceiling until the step is accepted;
+// type "skip" to move on.
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/filter"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Share of gravity the up axis must carry; 0.9 allows about 25° of tilt
+	minShare = 0.9
+	// How long the board must be held still in a new position
+	holdTime = 1500 * time.Millisecond
+	// Longest wait for each step before it is skipped
+	stepTimeout = 30 * time.Second
+)
+
+// direction is an axis and sign, e.g. +X.
+type direction struct {
+	axis     int // 0, 1 or 2 for X, Y or Z; -1 if unknown
+	positive bool
+}
+
+var unknown = direction{axis: -1}
+
+func (d direction) String() string {
+	if d.axis < 0 {
+		return "??"
+	}
+	sign := "-"
+	if d.positive {
+		sign = "+"
+	}
+	return sign + string(rune('X'+d.axis))
+}
+
+// steps are the directions asked for, in order.
+var steps = []direction{
+	{0, true}, {0, false},
+	{1, true}, {1, false},
+	{2, true}, {2, false},
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("axis_selftest")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The calibrated accelerometer, not the fused gravity report, so the
+	// fusion cannot hide anything
+	err = sensor.EnableReport(bno08x.SensorAccelerometer, 20000) // 50Hz
+	if err != nil {
+		println("Failed to enable accelerometer:", err.Error())
+		return
+	}
+
+	println()
+	println("=== Axis self-test ===")
+	println("Point each axis up as asked and hold still. Type 'skip' to skip a step.")
+
+	lowpass := filter.EMA3{Alpha: filter.Alpha(2, 50)}
+	var commands shell.LineReader
+	measured := make([]direction, len(steps))
+	previous := unknown
+
+	for i, want := range steps {
+		println()
+		println("Step", i+1, "of", len(steps), ": point", want.String(), "up")
+		measured[i] = waitForPosition(sensor, &lowpass, &commands, previous)
+		if measured[i] == unknown {
+			println("  Skipped")
+			continue
+		}
+		previous = measured[i]
+		if measured[i] == want {
+			println("  OK: gravity on", measured[i].String())
+		} else {
+			println("  MISMATCH: gravity on", measured[i].String(), "instead of", want.String())
+		}
+	}
+
+	printVerdict(measured)
+}
+
+// waitForPosition returns the direction that stays up for holdTime, once
+// it differs from previous so the last position is not counted twice. It
+// returns unknown on timeout or a "skip" command.
+func waitForPosition(sensor *bno08x.Device, lowpass *filter.EMA3, commands *shell.LineReader, previous direction) direction {
+	start := time.Now()
+	candidate := unknown
+	var since time.Time
+	lastHint := start
+
+	for time.Since(start) < stepTimeout {
+		if line, ok := commands.Poll(); ok && line == "skip" {
+			return unknown
+		}
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		if event.ID() != bno08x.SensorAccelerometer {
+			continue
+		}
+		a := event.Accelerometer()
+		x, y, z := lowpass.Update(a.X, a.Y, a.Z)
+		up, share := upAxis(x, y, z)
+
+		now := time.Now()
+		if share < minShare || up == previous {
+			candidate = unknown
+			if now.Sub(lastHint) >= 3*time.Second {
+				lastHint = now
+				println("  Waiting... strongest axis", up.String(), "at", fmtutil.Float(share*100, 0), "% of",
+					fmtutil.Float(magnitude(x, y, z)/units.StandardGravity, 2), "g")
+			}
+			continue
+		}
+		if up != candidate {
+			candidate, since = up, now
+			continue
+		}
+		if now.Sub(since) >= holdTime {
+			return candidate
+		}
+	}
+	return unknown
+}
+
+// upAxis returns the axis carrying most of the acceleration, with its
+// sign, and that axis' share of the total.
+func upAxis(x, y, z float32) (direction, float32) {
+	m := magnitude(x, y, z)
+	if m == 0 {
+		return unknown, 0
+	}
+	v := [3]float32{x, y, z}
+	best := 0
+	for i := 1; i < 3; i++ {
+		if abs(v[i]) > abs(v[best]) {
+			best = i
+		}
+	}
+	// At rest the accelerometer reads +1g on the axis pointing up
+	return direction{axis: best, positive: v[best] > 0}, abs(v[best]) / m
+}
+
+// printVerdict summarises the steps and names the likely cause of any
+// mismatch.
+func printVerdict(measured []direction) {
+	println()
+	println("=== Verdict ===")
+	passed, skipped := 0, 0
+	for i, got := range measured {
+		switch {
+		case got == unknown:
+			skipped++
+		case got == steps[i]:
+			passed++
+		}
+	}
+	if passed == len(steps) {
+		println("PASS: every axis reads as marked on the board")
+		return
+	}
+
+	// Steps come in +/- pairs per axis
+	for axis := 0; axis < 3; axis++ {
+		name := string(rune('X' + axis))
+		plus, minus := measured[2*axis], measured[2*axis+1]
+		switch {
+		case plus == unknown || minus == unknown:
+			println("  " + name + ": not tested")
+		case plus == steps[2*axis] && minus == steps[2*axis+1]:
+			println("  " + name + ": OK")
+		case plus.axis == axis && minus.axis == axis && !plus.positive && minus.positive:
+			println("  " + name + ": inverted; the sensor is mounted flipped or the board is upside down")
+		case plus.axis == minus.axis && plus.positive != minus.positive:
+			println("  "+name+": reads as sensor", plus.String()+"; axes are swapped or the board is rotated")
+		default:
+			println("  "+name+": inconsistent ("+plus.String(), "and", minus.String()+"); hold the board still and retry")
+		}
+	}
+	if skipped > 0 {
+		println("INCOMPLETE:", skipped, "step(s) skipped")
+	} else {
+		println("FAIL: fix the mounting, or remap the axes in software before calibrating")
+	}
+}
+
+func magnitude(x, y, z float32) float32 {
+	return float32(math.Sqrt(float64(x*x + y*y + z*z)))
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
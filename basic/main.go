@@ -1,34 +1,39 @@
 // Package main provides a basic example of using the BNO08x driver
-// to read rotation vector (quaternion) data from the sensor.
+// to read rotation vector (quaternion) data from the sensor. The status
+// LED blinks red if the sensor cannot be reached, blinks yellow until the
+// rotation vector is calibrated and then shows green.
 package main
 
 import (
-	"machine"
 	"time"
 
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 	"tinygo.org/x/drivers/bno08x"
 )
 
+// Heading accuracy estimate, in radians, at or below which the rotation
+// vector counts as calibrated
+const calibratedAccuracy = 0.2
+
 func main() {
 	time.Sleep(2 * time.Second) // Wait for sensor to power up
-	// Initialize I2C bus
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{
-		Frequency: 400 * machine.KHz,
-	})
-	if err != nil {
-		println("Failed to configure I2C:", err.Error())
-		return
-	}
+	buildinfo.Banner("basic")
+	led := statusled.New()
 
 	println("Initializing BNO08x sensor...")
 
 	// Create and configure sensor
-	sensor := bno08x.New(i2c)
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		led.Halt(statusled.BusError)
+	}
 	err = sensor.Configure(bno08x.Config{})
 	if err != nil {
 		println("Failed to configure sensor:", err.Error())
-		return
+		led.Halt(statusled.BusError)
 	}
 
 	println("Sensor initialized successfully")
@@ -37,7 +42,7 @@ func main() {
 	err = sensor.EnableReport(bno08x.SensorRotationVector, 10000)
 	if err != nil {
 		println("Failed to enable sensor rotation vector:", err.Error())
-		return
+		led.Halt(statusled.BusError)
 	}
 
 	println("Reading rotation vectors...")
@@ -52,7 +57,13 @@ func main() {
 		if ok && event.ID() == bno08x.SensorRotationVector {
 			q := event.Quaternion()
 			println(q.Real, q.I, q.J, q.K, event.QuaternionAccuracy())
+			if event.QuaternionAccuracy() <= calibratedAccuracy {
+				led.Set(statusled.Streaming)
+			} else {
+				led.Set(statusled.Calibrating)
+			}
 		}
+		led.Update(time.Now())
 
 		// Arduino uses 10ms delay in loop
 		time.Sleep(10 * time.Millisecond)
@@ -0,0 +1,233 @@
+// Package main demonstrates batching: the accelerometer is enabled with a
+// nonzero batch interval, so the sensor hub keeps its samples in its FIFO
+// and delivers them in bursts instead of raising INT for every one. Each
+// burst is drained and checked: the timestamps rebuilt from the base
+// timestamp, rebase records and per-report delay fields must step by the
+// report interval through the burst and on from the burst before, with no
+// sequence numbers missing, as though every sample had been read the
+// moment it was taken.
+//
+// Timestamps are on the host's clock, taken when each packet is read, so
+// spacings across bursts also carry the read latency and the drift
+// between the two clocks; spacings within a packet come from the sensor's
+// clock alone.
+//
+// Runs over raw SHTP, to set the batch interval. Uses I2C by default, or
+// SPI when built with "-tags bno08x_spi".
+//
+//	flush    ask the hub to send what it has batched now
+//	show     list the delay and timestamp of every report in the next burst
+//	status   show the totals so far
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	sensor = bno08x.SensorAccelerometer
+	// Report interval in microseconds
+	reportInterval = 20000
+	// Longest the hub holds reports before sending them
+	batchInterval = 1 * time.Second
+	// Packets read closer together than this belong to one burst
+	burstGap = 20 * time.Millisecond
+	// Fraction of the interval a spacing may differ by and still pass
+	tolerance = 0.25
+	// Longest to wait for the Get Feature response after enabling
+	featureTimeout = 500 * time.Millisecond
+)
+
+// burst collects the reports of one delivery.
+type burst struct {
+	reports  int
+	packets  int
+	missed   int   // Reports lost by sequence number
+	bad      int   // Spacings outside tolerance
+	oldest   int64 // Timestamp of the first report, µs
+	readTime int64 // When the last packet was read, µs
+	minGap   int64
+	maxGap   int64
+	show     bool
+}
+
+// checker follows the reports across bursts.
+type checker struct {
+	interval int64 // Granted report interval, µs
+	started  bool
+	lastSeq  uint8
+	lastTime int64
+
+	bursts  int
+	reports int
+	missed  int
+	bad     int
+}
+
+// add checks one report against the one before it, which may have come
+// in an earlier burst.
+func (c *checker) add(b *burst, r shtpraw.Report) {
+	if b.show {
+		println("    seq", r.Sequence, "delay", r.DelayMicros(), "us  t", r.Timestamp, "us")
+	}
+	if b.reports == 0 {
+		b.oldest = r.Timestamp
+	}
+	b.reports++
+	if !c.started {
+		c.started = true
+		c.lastSeq, c.lastTime = r.Sequence, r.Timestamp
+		return
+	}
+
+	missed := shtpraw.SequenceGap(c.lastSeq, r.Sequence)
+	b.missed += missed
+	// Spacing per sample, so a lost report does not count twice
+	gap := (r.Timestamp - c.lastTime) / int64(missed+1)
+	if b.minGap == 0 || gap < b.minGap {
+		b.minGap = gap
+	}
+	if gap > b.maxGap {
+		b.maxGap = gap
+	}
+	limit := int64(float32(c.interval) * tolerance)
+	if diff := gap - c.interval; diff > limit || diff < -limit {
+		b.bad++
+	}
+	c.lastSeq, c.lastTime = r.Sequence, r.Timestamp
+}
+
+// finish prints a burst and adds it to the totals.
+func (c *checker) finish(b *burst) {
+	c.bursts++
+	c.reports += b.reports
+	c.missed += b.missed
+	c.bad += b.bad
+
+	verdict := "OK"
+	if b.missed > 0 || b.bad > 0 {
+		verdict = "MISMATCH"
+	}
+	println("Burst", c.bursts, "-", b.reports, "reports in", b.packets, "packets, oldest",
+		fmtutil.Float(float32(b.readTime-b.oldest)/1e6, 2)+"s old, spacing",
+		fmtutil.Float(float32(b.minGap)/1000, 1)+"-"+fmtutil.Float(float32(b.maxGap)/1000, 1), "ms,",
+		b.missed, "missed,", b.bad, "out of tolerance:", verdict)
+}
+
+// print shows the totals so far.
+func (c *checker) print() {
+	println("Bursts:", c.bursts, "| reports:", c.reports, "| missed:", c.missed, "| out of tolerance:", c.bad)
+	if c.bursts > 0 {
+		println("Mean burst:", fmtutil.Float(float32(c.reports)/float32(c.bursts), 1), "reports; expected about",
+			int64(batchInterval/time.Microsecond)/c.interval)
+	}
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("batch_fifo")
+	println("=== BNO08x Batching ===")
+	println()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	requested := shtpraw.Feature{
+		Sensor:        uint8(sensor),
+		Interval:      reportInterval,
+		BatchInterval: uint32(batchInterval.Microseconds()),
+	}
+	var cmd [shtpraw.FeatureLen]byte
+	if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], requested)); err != nil {
+		println("Failed to enable", sensorinfo.Name(sensor)+":", err.Error())
+		return
+	}
+	granted := requested
+	for start := time.Now(); time.Since(start) < featureTimeout; {
+		packet, err := transport.Next(link, featureTimeout-time.Since(start))
+		if err != nil || packet.Channel != shtpraw.ChannelControl {
+			continue
+		}
+		if f, err := shtpraw.ParseFeature(packet.Cargo()); err == nil && f.Sensor == uint8(sensor) {
+			granted = f
+			break
+		}
+	}
+	println(sensorinfo.Name(sensor)+": report interval", granted.Interval, "us, batch interval", granted.BatchInterval, "us")
+	if granted.BatchInterval == 0 {
+		println("The firmware granted no batching; reports will arrive one at a time")
+	}
+	println()
+
+	c := checker{interval: int64(granted.Interval)}
+	if c.interval == 0 {
+		c.interval = reportInterval
+	}
+	showNext := false
+
+	var sh shell.Shell
+	sh.Register("flush", "flush", "Ask the hub to send what it has batched now", func(args []string) error {
+		return link.Write(shtpraw.ChannelControl, shtpraw.AppendForceFlush(cmd[:0], uint8(sensor)))
+	})
+	sh.Register("show", "show", "List the delay and timestamp of every report in the next burst", func(args []string) error {
+		showNext = true
+		return nil
+	})
+	sh.Register("status", "status", "Show the totals so far", func(args []string) error {
+		c.print()
+		return nil
+	})
+
+	start := time.Now()
+	var b *burst
+	for {
+		sh.Poll()
+
+		// A burst ends when no packet follows within burstGap
+		packet, err := transport.Next(link, burstGap)
+		if err != nil {
+			if b != nil {
+				c.finish(b)
+				b = nil
+			}
+			continue
+		}
+		readTime := time.Since(start).Microseconds()
+
+		switch packet.Channel {
+		case shtpraw.ChannelControl:
+			if cargo := packet.Cargo(); len(cargo) > 0 && cargo[0] == shtpraw.ReportFlushCompleted {
+				println("Flush completed")
+			}
+		case shtpraw.ChannelInputNormal:
+			if b == nil {
+				b = &burst{show: showNext}
+				showNext = false
+			}
+			b.packets++
+			b.readTime = readTime
+			shtpraw.DecodeInput(packet.Cargo(), readTime, func(r shtpraw.Report) {
+				if r.ID == uint8(sensor) {
+					c.add(b, r)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,120 @@
+//go:build nrf52840
+
+package main
+
+import (
+	"encoding/binary"
+	"math"
+
+	"tinygo.org/x/bluetooth"
+)
+
+// Name advertised to scanning phones and bike computers
+const localName = "BNO08x Bike"
+
+// UUIDs of the custom ride service and its characteristic
+const (
+	rideServiceUUID = "0b080100-4a5e-4c1b-8d3e-7f6a2b9c1d00"
+	leanUUID        = "0b080101-4a5e-4c1b-8d3e-7f6a2b9c1d00"
+)
+
+// CSC measurement flags and feature bits
+const (
+	cscCrankPresent   = 1 << 1
+	cscCrankSupported = 1 << 1
+)
+
+// blePublisher notifies the CSC measurement and the lean characteristic.
+type blePublisher struct {
+	csc  bluetooth.Characteristic
+	lean bluetooth.Characteristic
+
+	buf [16]byte
+}
+
+func advertise() (publisher, error) {
+	adapter := bluetooth.DefaultAdapter
+	if err := adapter.Enable(); err != nil {
+		return nil, err
+	}
+
+	p := &blePublisher{}
+	csc := bluetooth.Service{
+		UUID: bluetooth.ServiceUUIDCyclingSpeedAndCadence,
+		Characteristics: []bluetooth.CharacteristicConfig{
+			{
+				Handle: &p.csc,
+				UUID:   bluetooth.CharacteristicUUIDCSCMeasurement,
+				Value:  make([]byte, 5),
+				Flags:  bluetooth.CharacteristicNotifyPermission,
+			},
+			{
+				UUID:  bluetooth.CharacteristicUUIDCSCFeature,
+				Value: binary.LittleEndian.AppendUint16(nil, cscCrankSupported),
+				Flags: bluetooth.CharacteristicReadPermission,
+			},
+		},
+	}
+	if err := adapter.AddService(&csc); err != nil {
+		return nil, err
+	}
+	ride := bluetooth.Service{
+		UUID: mustUUID(rideServiceUUID),
+		Characteristics: []bluetooth.CharacteristicConfig{
+			{
+				Handle: &p.lean,
+				UUID:   mustUUID(leanUUID),
+				Value:  make([]byte, 16),
+				Flags:  bluetooth.CharacteristicReadPermission | bluetooth.CharacteristicNotifyPermission,
+			},
+		},
+	}
+	if err := adapter.AddService(&ride); err != nil {
+		return nil, err
+	}
+
+	adv := adapter.DefaultAdvertisement()
+	err := adv.Configure(bluetooth.AdvertisementOptions{
+		LocalName:    localName,
+		ServiceUUIDs: []bluetooth.UUID{csc.UUID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := adv.Start(); err != nil {
+		return nil, err
+	}
+	println("Advertising as", localName)
+	return p, nil
+}
+
+// Publish notifies the CSC measurement and the lean characteristic.
+// Notifying without a subscriber fails harmlessly, so errors are ignored.
+func (p *blePublisher) Publish(r *reading) {
+	b := append(p.buf[:0], cscCrankPresent)
+	b = binary.LittleEndian.AppendUint16(b, r.revolutions)
+	b = binary.LittleEndian.AppendUint16(b, r.lastRevTime)
+	p.csc.Write(b)
+
+	hdg := float32(-1)
+	if r.haveHeading {
+		hdg = r.heading
+	}
+	p.lean.Write(appendFloats(p.buf[:0], r.lean, r.maxLeft, r.maxRight, hdg))
+}
+
+func appendFloats(dst []byte, values ...float32) []byte {
+	for _, v := range values {
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(v))
+	}
+	return dst
+}
+
+// mustUUID parses one of the UUID constants above.
+func mustUUID(s string) bluetooth.UUID {
+	uuid, err := bluetooth.ParseUUID(s)
+	if err != nil {
+		panic("bad UUID " + s)
+	}
+	return uuid
+}
@@ -0,0 +1,40 @@
+//go:build !nrf52840
+
+package main
+
+import (
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/heading"
+)
+
+// consolePublisher prints each reading instead of notifying it, on boards
+// without the nRF52840 radio.
+type consolePublisher struct{}
+
+func advertise() (publisher, error) {
+	println("No BLE radio on this target: values are printed, not notified")
+	return consolePublisher{}, nil
+}
+
+func (consolePublisher) Publish(r *reading) {
+	line := "Cadence: " + fmtutil.PadLeft(fmtutil.Float(r.cadence, 0), 3) + " rpm"
+	if r.haveAttitude {
+		line += " | Lean: " + fmtutil.PadLeft(formatLean(r.lean), 4) +
+			" (max " + formatLean(-r.maxLeft) + " " + formatLean(r.maxRight) + ")"
+	}
+	if r.haveHeading {
+		line += " | Heading: " + fmtutil.Float(r.heading, 0) + " " + heading.Cardinal(r.heading)
+	}
+	println(line)
+}
+
+// formatLean returns a lean angle as degrees and a side.
+func formatLean(deg float32) string {
+	switch {
+	case deg > 0:
+		return fmtutil.Float(deg, 0) + "R"
+	case deg < 0:
+		return fmtutil.Float(-deg, 0) + "L"
+	}
+	return "0"
+}
@@ -0,0 +1,233 @@
+// Package main is a bike computer for a board fixed to the frame, its X
+// axis pointing forward. It reports the pedalling cadence, the lean angle
+// in corners and the heading.
+//
+// Pedalling sways the frame from side to side once per crank revolution,
+// so the cadence is the dominant frequency of the accelerometer: each axis
+// is low-passed, decimated to analysisHz and fed to a dsp.Periodic
+// estimator, and the axis that correlates best wins. Coasting has no
+// rhythm and reads 0 rpm.
+//
+// The lean angle comes from the game rotation vector, which the steel of a
+// frame cannot disturb: it is the angle between the frame's up direction
+// and the vertical, about the forward axis, positive leaning right. Type
+// "zero" with the bike upright if the board is not mounted flat. The
+// heading comes from the rotation vector through heading.Compass, and is
+// only as good as the magnetometer's calibration away from the frame.
+//
+// On an nRF52840 target the board advertises as localName and is a
+// standard BLE Cycling Speed and Cadence sensor that bike apps pair with,
+// its crank revolutions counted from the cadence, plus a custom ride
+// service 0b080100-4a5e-4c1b-8d3e-7f6a2b9c1d00 with one little-endian
+// characteristic:
+//
+//	0b080101-...  lean  lean, max left, max right, heading in degrees as float32  read, notify
+//
+// The heading is -1 until the rotation vector has reported. Other targets
+// print the values on the console instead.
+//
+//	zero    take the current attitude as upright
+//	reset   clear the lean maxima
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dsp"
+	"github.com/intermernet/bno08xPrograms/internal/filter"
+	"github.com/intermernet/bno08xPrograms/internal/heading"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Accelerometer and game rotation vector report rate
+	sensorRate = 100 // Hz
+	// Rate the cadence estimators run at after decimation
+	analysisHz = 25
+	// Low-pass cutoff, below the analysis Nyquist rate
+	cutoffHz = 4
+	// Cadence range searched, rpm
+	minRPM = 30
+	maxRPM = 150
+	// Samples in the cadence window, 8s at analysisHz
+	windowSize = 200
+	// Interval between updates
+	updateInterval = time.Second
+	// Local magnetic declination in degrees, positive east
+	declination = 0
+)
+
+// reading is one update of the bike computer.
+type reading struct {
+	cadence      float32 // Crank rpm, 0 when coasting
+	revolutions  uint16  // Crank revolutions so far, wrapping
+	lastRevTime  uint16  // Time of the last revolution in 1/1024s, wrapping
+	lean         float32 // Degrees, positive right
+	maxLeft      float32 // Largest lean left since reset, degrees
+	maxRight     float32 // Largest lean right since reset, degrees
+	heading      float32 // Degrees from true north
+	haveHeading  bool
+	haveAttitude bool
+}
+
+// publisher sends each reading out.
+type publisher interface {
+	Publish(r *reading)
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("bike_computer")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, r := range []struct {
+		id       bno08x.SensorID
+		interval uint32
+	}{
+		{bno08x.SensorAccelerometer, 1000000 / sensorRate},
+		{bno08x.SensorGameRotationVector, 1000000 / sensorRate},
+		{bno08x.SensorRotationVector, sensorinfo.Interval10Hz},
+	} {
+		if err := sensor.EnableReport(r.id, r.interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(r.id)+":", err.Error())
+			return
+		}
+	}
+
+	pub, err := advertise()
+	if err != nil {
+		println("Failed to start BLE:", err.Error())
+		return
+	}
+
+	lowpass := filter.EMA3{Alpha: filter.Alpha(cutoffHz, sensorRate)}
+	var axes [3]dsp.Periodic
+	for i := range axes {
+		axes[i] = dsp.Periodic{SampleRate: analysisHz, MinHz: minRPM / 60.0, MaxHz: maxRPM / 60.0, Size: windowSize}
+	}
+	compass := heading.Compass{Declination: declination}
+
+	var r reading
+	attitude := quat.Identity
+	// The frame's up direction in sensor coordinates
+	upX, upY, upZ := float32(0), float32(0), float32(1)
+
+	var sh shell.Shell
+	sh.Register("zero", "zero", "Take the current attitude as upright", func(args []string) error {
+		if !r.haveAttitude {
+			println("No attitude yet")
+			return nil
+		}
+		// World up seen from the sensor
+		upX, upY, upZ = quat.Rotate(quat.Conjugate(attitude), 0, 0, 1)
+		println("Upright attitude captured")
+		return nil
+	})
+	sh.Register("reset", "reset", "Clear the lean maxima", func(args []string) error {
+		r.maxLeft, r.maxRight = 0, 0
+		println("Lean maxima cleared")
+		return nil
+	})
+
+	println("Collecting", windowSize/analysisHz, "seconds of pedalling before the first cadence. Type 'help' for commands")
+
+	samples := 0
+	revolutions := float32(0)
+	start := time.Now()
+	lastUpdate := start
+	lastRevs := lastUpdate
+
+	for {
+		sh.Poll()
+
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		switch event.ID() {
+		case bno08x.SensorAccelerometer:
+			a := event.Accelerometer()
+			x, y, z := lowpass.Update(a.X, a.Y, a.Z)
+			samples++
+			if samples%(sensorRate/analysisHz) == 0 {
+				axes[0].Add(x)
+				axes[1].Add(y)
+				axes[2].Add(z)
+			}
+		case bno08x.SensorGameRotationVector:
+			attitude, r.haveAttitude = event.Quaternion(), true
+			r.lean = lean(attitude, upX, upY, upZ)
+			if r.lean > r.maxRight {
+				r.maxRight = r.lean
+			}
+			if -r.lean > r.maxLeft {
+				r.maxLeft = -r.lean
+			}
+		case bno08x.SensorRotationVector:
+			r.heading, r.haveHeading = compass.FromQuaternion(event.Quaternion()), true
+		}
+
+		now := time.Now()
+
+		// Count whole crank revolutions at the current cadence, as the CSC
+		// measurement wants cumulative revolutions and the time of the last
+		revolutions += r.cadence / 60 * float32(now.Sub(lastRevs).Seconds())
+		lastRevs = now
+		if revolutions >= 1 {
+			whole := uint16(revolutions)
+			r.revolutions += whole
+			revolutions -= float32(whole)
+			r.lastRevTime = uint16(now.Sub(start) * 1024 / time.Second)
+		}
+
+		if now.Sub(lastUpdate) < updateInterval {
+			continue
+		}
+		lastUpdate = now
+
+		r.cadence = 0
+		if axes[0].Full() {
+			best := float32(0)
+			for i := range axes {
+				if hz, confidence, ok := axes[i].Estimate(); ok && confidence > best {
+					r.cadence, best = hz*60, confidence
+				}
+			}
+		}
+		pub.Publish(&r)
+	}
+}
+
+// lean returns the angle in degrees between the frame's up direction, up
+// in sensor coordinates, and the vertical, about the forward X axis,
+// positive leaning right.
+func lean(q bno08x.Quaternion, upX, upY, upZ float32) float32 {
+	fx, fy, _ := quat.Rotate(q, 1, 0, 0)
+	ux, uy, uz := quat.Rotate(q, upX, upY, upZ)
+	// The frame's right is forward cross up; its height is all that matters
+	rz := fx*uy - fy*ux
+	return units.RadiansToDegrees(float32(math.Atan2(float64(-rz), float64(uz))))
+}
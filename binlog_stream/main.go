@@ -0,0 +1,110 @@
+// Package main streams every sensor at its fastest rate as binary frames
+// with a CRC, for captures where printing text would drop samples. The
+// frame layout is documented in internal/telemetry and matches
+// pkg/protocol, whose Parser decodes a capture:
+//
+//	stty -F /dev/ttyACM0 raw && cat /dev/ttyACM0 > capture.bin
+//
+// Start-up messages are printed as text before the session header; a
+// parser skips them while hunting for the first sync bytes. After that
+// everything is framed, including the status line sent every
+// statusInterval as a text frame.
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"github.com/intermernet/bno08xPrograms/internal/telemetry"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Report interval requested for the continuous sensors in microseconds.
+// The sensor runs each one at the nearest rate it supports, so asking for
+// 1kHz gets its maximum; detectors report at their sensorinfo defaults.
+const fastest = 1000
+
+// Interval between status text frames
+const statusInterval = 10 * time.Second
+
+// Continuous sensors run at their fastest rate
+var continuous = []bno08x.SensorID{
+	bno08x.SensorAccelerometer,
+	bno08x.SensorGyroscope,
+	bno08x.SensorMagneticField,
+	bno08x.SensorLinearAcceleration,
+	bno08x.SensorRotationVector,
+	bno08x.SensorGravity,
+	bno08x.SensorGyroscopeUncalibrated,
+	bno08x.SensorGameRotationVector,
+	bno08x.SensorGeomagneticRotationVector,
+	bno08x.SensorMagneticFieldUncalibrated,
+	bno08x.SensorRawAccelerometer,
+	bno08x.SensorRawGyroscope,
+	bno08x.SensorRawMagnetometer,
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("binlog_stream")
+	led := statusled.New()
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range sensorinfo.All {
+		interval := sensorinfo.DefaultIntervalMicros(id)
+		for _, c := range continuous {
+			if c == id {
+				interval = fastest
+			}
+		}
+		// Sensors missing from this part just never report
+		if err := sensor.EnableReport(id, interval); err != nil {
+			println("Could not enable", sensorinfo.Name(id)+":", err.Error())
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	println("Starting binary stream")
+	enc := telemetry.New(os.Stdout)
+	enc.Session("binlog_stream", buildinfo.Board, buildinfo.Revision)
+
+	lastStatus := time.Now()
+	var events, lastEvents uint32
+	for {
+		led.Update(time.Now())
+		if event, ok := sensor.GetSensorEvent(); ok {
+			led.Event(&event)
+			enc.Encode(&event)
+			events++
+			continue
+		}
+
+		if elapsed := time.Since(lastStatus); elapsed >= statusInterval {
+			rate := float32(events-lastEvents) / float32(elapsed.Seconds())
+			enc.Text("events " + fmtutil.Int64(int64(events)) + " rate " + fmtutil.Float(rate, 0) +
+				"/s write errors " + fmtutil.Int64(int64(enc.Errors)))
+			lastStatus, lastEvents = time.Now(), events
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
@@ -0,0 +1,126 @@
+//go:build nrf52840
+
+package main
+
+import (
+	"encoding/binary"
+	"math"
+
+	"tinygo.org/x/bluetooth"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// UUIDs of the IMU service and its characteristics
+const (
+	serviceUUID    = "0b080000-4a5e-4c1b-8d3e-7f6a2b9c1d00"
+	quaternionUUID = "0b080001-4a5e-4c1b-8d3e-7f6a2b9c1d00"
+	eulerUUID      = "0b080002-4a5e-4c1b-8d3e-7f6a2b9c1d00"
+	stepsUUID      = "0b080003-4a5e-4c1b-8d3e-7f6a2b9c1d00"
+	intervalUUID   = "0b080004-4a5e-4c1b-8d3e-7f6a2b9c1d00"
+)
+
+// blePublisher notifies the IMU service characteristics.
+type blePublisher struct {
+	quaternion bluetooth.Characteristic
+	euler      bluetooth.Characteristic
+	steps      bluetooth.Characteristic
+	interval   bluetooth.Characteristic
+
+	lastSteps uint32
+	haveSteps bool
+	buf       [16]byte
+}
+
+func advertise() (publisher, error) {
+	adapter := bluetooth.DefaultAdapter
+	if err := adapter.Enable(); err != nil {
+		return nil, err
+	}
+
+	p := &blePublisher{}
+	service := bluetooth.Service{
+		UUID: mustUUID(serviceUUID),
+		Characteristics: []bluetooth.CharacteristicConfig{
+			{
+				Handle: &p.quaternion,
+				UUID:   mustUUID(quaternionUUID),
+				Value:  make([]byte, 16),
+				Flags:  bluetooth.CharacteristicReadPermission | bluetooth.CharacteristicNotifyPermission,
+			},
+			{
+				Handle: &p.euler,
+				UUID:   mustUUID(eulerUUID),
+				Value:  make([]byte, 12),
+				Flags:  bluetooth.CharacteristicReadPermission | bluetooth.CharacteristicNotifyPermission,
+			},
+			{
+				Handle: &p.steps,
+				UUID:   mustUUID(stepsUUID),
+				Value:  make([]byte, 4),
+				Flags:  bluetooth.CharacteristicReadPermission | bluetooth.CharacteristicNotifyPermission,
+			},
+			{
+				Handle: &p.interval,
+				UUID:   mustUUID(intervalUUID),
+				Value:  binary.LittleEndian.AppendUint16(nil, defaultInterval),
+				Flags: bluetooth.CharacteristicReadPermission | bluetooth.CharacteristicWritePermission |
+					bluetooth.CharacteristicWriteWithoutResponsePermission,
+				WriteEvent: func(client bluetooth.Connection, offset int, value []byte) {
+					if offset != 0 || len(value) != 2 {
+						return
+					}
+					setInterval(uint32(binary.LittleEndian.Uint16(value)))
+				},
+			},
+		},
+	}
+	if err := adapter.AddService(&service); err != nil {
+		return nil, err
+	}
+
+	adv := adapter.DefaultAdvertisement()
+	err := adv.Configure(bluetooth.AdvertisementOptions{
+		LocalName:    localName,
+		ServiceUUIDs: []bluetooth.UUID{service.UUID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := adv.Start(); err != nil {
+		return nil, err
+	}
+	println("Advertising as", localName)
+	return p, nil
+}
+
+// Publish notifies the quaternion and Euler angles. Notifying without a
+// subscriber fails harmlessly, so errors are ignored.
+func (p *blePublisher) Publish(q bno08x.Quaternion, roll, pitch, yaw float32) {
+	p.quaternion.Write(appendFloats(p.buf[:0], q.I, q.J, q.K, q.Real))
+	p.euler.Write(appendFloats(p.buf[:0], roll, pitch, yaw))
+}
+
+// PublishSteps notifies the step count if it changed.
+func (p *blePublisher) PublishSteps(steps uint32) {
+	if p.haveSteps && steps == p.lastSteps {
+		return
+	}
+	p.lastSteps, p.haveSteps = steps, true
+	p.steps.Write(binary.LittleEndian.AppendUint32(p.buf[:0], steps))
+}
+
+func appendFloats(dst []byte, values ...float32) []byte {
+	for _, v := range values {
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(v))
+	}
+	return dst
+}
+
+// mustUUID parses one of the UUID constants above.
+func mustUUID(s string) bluetooth.UUID {
+	uuid, err := bluetooth.ParseUUID(s)
+	if err != nil {
+		panic("bad UUID " + s)
+	}
+	return uuid
+}
@@ -0,0 +1,36 @@
+//go:build !nrf52840
+
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Interval between lines printed by consolePublisher
+const printInterval = time.Second
+
+// consolePublisher prints the values instead of notifying them, on boards
+// without the nRF52840 radio.
+type consolePublisher struct {
+	lastPrint time.Time
+}
+
+func advertise() (publisher, error) {
+	println("No BLE radio on this target: values are printed, not notified")
+	return &consolePublisher{}, nil
+}
+
+func (p *consolePublisher) Publish(q bno08x.Quaternion, roll, pitch, yaw float32) {
+	if time.Since(p.lastPrint) < printInterval {
+		return
+	}
+	p.lastPrint = time.Now()
+	println("Roll:", fmtutil.Float(roll, 1), "Pitch:", fmtutil.Float(pitch, 1), "Yaw:", fmtutil.Float(yaw, 1))
+}
+
+func (p *consolePublisher) PublishSteps(steps uint32) {
+	println("Steps:", steps)
+}
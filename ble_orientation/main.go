@@ -0,0 +1,132 @@
+// Package main makes an nRF52840 board, such as the XIAO nRF52840, a BLE
+// peripheral that phone apps read the orientation from. It advertises as
+// localName with one custom IMU service, whose characteristics are all
+// little-endian:
+//
+//	0b080001-...  quaternion  i, j, k, real as float32              read, notify
+//	0b080002-...  Euler       roll, pitch, yaw in degrees as float32  read, notify
+//	0b080003-...  steps       step count as uint32                  read, notify
+//	0b080004-...  interval    notification interval in ms as uint16  read, write
+//
+// Each UUID continues -4a5e-4c1b-8d3e-7f6a2b9c1d00 and the service is
+// 0b080000 with the same tail. Subscribers are notified every interval,
+// defaultInterval at start; writing the interval characteristic changes
+// it, within minInterval and maxInterval. The step count is only notified
+// when it changes.
+//
+// Build for an nRF52840 target, e.g. "tinygo flash -target=xiao-ble
+// ./ble_orientation"; other targets print the values on the console
+// instead.
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Name advertised to scanning phones
+const localName = "BNO08x IMU"
+
+// Notification interval at start and the range the interval
+// characteristic accepts, in milliseconds
+const (
+	defaultInterval = 100 // 10Hz
+	minInterval     = 20
+	maxInterval     = 10000
+)
+
+// intervalMillis is the notification interval. The BLE stack writes it
+// from its own context, so it is only accessed atomically.
+var intervalMillis uint32 = defaultInterval
+
+// setInterval stores a new notification interval, clamped to the
+// accepted range.
+func setInterval(ms uint32) {
+	if ms < minInterval {
+		ms = minInterval
+	}
+	if ms > maxInterval {
+		ms = maxInterval
+	}
+	atomic.StoreUint32(&intervalMillis, ms)
+}
+
+// publisher sends the latest values to subscribers.
+type publisher interface {
+	Publish(q bno08x.Quaternion, roll, pitch, yaw float32)
+	PublishSteps(steps uint32)
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("ble_orientation")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// Sampled at least as fast as the shortest notification interval
+	err = sensor.EnableReport(bno08x.SensorRotationVector, minInterval*1000)
+	if err != nil {
+		println("Failed to enable rotation vector:", err.Error())
+		return
+	}
+	err = sensor.EnableReport(bno08x.SensorStepCounter, sensorinfo.DefaultIntervalMicros(bno08x.SensorStepCounter))
+	if err != nil {
+		println("Failed to enable step counter:", err.Error())
+		return
+	}
+
+	pub, err := advertise()
+	if err != nil {
+		println("Failed to start BLE:", err.Error())
+		return
+	}
+
+	var q bno08x.Quaternion
+	haveQuat := false
+	lastPublish := time.Now()
+
+	for {
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorRotationVector:
+				q, haveQuat = event.Quaternion(), true
+			case bno08x.SensorStepCounter:
+				pub.PublishSteps(uint32(event.StepCounter().Count))
+			}
+		}
+
+		interval := time.Duration(atomic.LoadUint32(&intervalMillis)) * time.Millisecond
+		if haveQuat && time.Since(lastPublish) >= interval {
+			lastPublish = time.Now()
+			roll, pitch, yaw := quat.ToEuler(q)
+			pub.Publish(q, units.RadiansToDegrees(roll), units.RadiansToDegrees(pitch), units.RadiansToDegrees(yaw))
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,240 @@
+// Package main walks through calibrating the BNO08x, one sensor at a time,
+// and saves the result to the sensor's flash so it is loaded at every
+// boot instead of being relearned.
+//
+// The sensor hub calibrates itself while it runs; the steps only give it
+// the motion each sensor needs. The gyroscope learns its bias while the
+// board lies still, the accelerometer from resting on each of its six
+// faces and the magnetometer from a figure-8 that turns the board through
+// every direction. Each step lasts until the accuracy the sensor reports
+// with its samples reaches 3, high, or stepTimeout passes. Once all three
+// are high the program sends Save DCD, which writes the dynamic
+// calibration data to flash, and prints the sensor's answer. The status
+// LED blinks yellow while calibrating and turns green once the
+// calibration is saved.
+//
+// Runs over raw SHTP, to see the accuracy of every report and the answer
+// to the command. Uses I2C by default, or SPI when built with
+// "-tags bno08x_spi".
+package main
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sh2cmd"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Report interval of the calibrated sensors, in microseconds
+	interval = 20000 // 50Hz
+	// Longest a step waits for high accuracy
+	stepTimeout = 3 * time.Minute
+	// How long the board must rest on a face to count it
+	faceHold = time.Second
+	// Share of gravity along one axis that counts as resting on a face
+	faceLevel = 0.9
+	// Interval between progress lines
+	progressInterval = 500 * time.Millisecond
+	// Longest to wait for the answer to Save DCD
+	responseTimeout = time.Second
+)
+
+// Highest accuracy a report can carry
+const highAccuracy = 3
+
+// Standard gravity in m/s²
+const gravity = 9.80665
+
+// step is one stage of the calibration.
+type step struct {
+	sensor bno08x.SensorID
+	title  string
+	how    string
+}
+
+var steps = []step{
+	{bno08x.SensorGyroscope, "Gyroscope", "Set the board down on a steady surface and leave it still."},
+	{bno08x.SensorAccelerometer, "Accelerometer", "Rest the board on each of its six faces in turn, a couple of seconds each."},
+	{bno08x.SensorMagneticField, "Magnetometer", "Sweep the board through a slow figure-8, turning it over as you go, away from metal."},
+}
+
+// Faces, by the axis pointing up and its sign
+var faceNames = [6]string{"+X", "-X", "+Y", "-Y", "+Z", "-Z"}
+
+// progress is what has been seen of the calibration so far.
+type progress struct {
+	accuracy [4]uint8 // By sensor ID; only 1 to 3 are used
+	reported [4]bool
+
+	faces     [6]bool // Faces the board has rested on
+	face      int     // Face up now, or -1
+	faceSince time.Time
+}
+
+// update records an input report.
+func (p *progress) update(r shtpraw.Report, now time.Time) {
+	if r.ID < 1 || int(r.ID) >= len(p.accuracy) {
+		return
+	}
+	p.accuracy[r.ID], p.reported[r.ID] = r.Accuracy(), true
+	if bno08x.SensorID(r.ID) != bno08x.SensorAccelerometer || len(r.Data) < 10 {
+		return
+	}
+
+	// Accelerometer in m/s², Q8
+	var a [3]float32
+	for i := range a {
+		a[i] = float32(int16(binary.LittleEndian.Uint16(r.Data[4+2*i:]))) / 256
+	}
+	face := -1
+	for i, v := range a {
+		switch {
+		case v > faceLevel*gravity:
+			face = 2 * i
+		case v < -faceLevel*gravity:
+			face = 2*i + 1
+		}
+	}
+	if face != p.face {
+		p.face, p.faceSince = face, now
+	}
+	if face >= 0 && now.Sub(p.faceSince) >= faceHold {
+		p.faces[face] = true
+	}
+}
+
+// input records the reports of an input packet.
+func (p *progress) input(packet shtpraw.Packet) {
+	if packet.Channel != shtpraw.ChannelInputNormal && packet.Channel != shtpraw.ChannelInputWake {
+		return
+	}
+	now := time.Now()
+	shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) { p.update(r, now) })
+}
+
+// high reports whether the sensor's accuracy is high.
+func (p *progress) high(id bno08x.SensorID) bool {
+	return p.reported[id] && p.accuracy[id] >= highAccuracy
+}
+
+// line formats the accuracy of every sensor, and the faces seen while the
+// accelerometer is calibrated.
+func (p *progress) line(current bno08x.SensorID) string {
+	line := " "
+	for _, s := range steps {
+		line += " " + s.title + " " + meter(p.accuracy[s.sensor], p.reported[s.sensor])
+	}
+	if current == bno08x.SensorAccelerometer {
+		line += " | faces"
+		for i, seen := range p.faces {
+			if seen {
+				line += " " + faceNames[i]
+			} else {
+				line += " --"
+			}
+		}
+	}
+	return line
+}
+
+// meter draws an accuracy as a three-step bar.
+func meter(accuracy uint8, reported bool) string {
+	if !reported {
+		return "[   ] -"
+	}
+	bar := []byte("[   ]")
+	for i := 0; i < int(accuracy) && i < highAccuracy; i++ {
+		bar[1+i] = '#'
+	}
+	return string(bar) + " " + fmtutil.Int(int(accuracy))
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("calibrate")
+	println("=== BNO08x Calibration ===")
+	println()
+	led := statusled.New()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+
+	var cmd [shtpraw.FeatureLen]byte
+	for _, s := range steps {
+		f := shtpraw.Feature{Sensor: uint8(s.sensor), Interval: interval}
+		if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], f)); err != nil {
+			println("Failed to enable", sensorinfo.Name(s.sensor)+":", err.Error())
+			led.Halt(statusled.BusError)
+		}
+	}
+
+	led.Set(statusled.Calibrating)
+	p := progress{face: -1}
+	for i, s := range steps {
+		println()
+		println("Step", i+1, "of", fmtutil.Int(len(steps))+":", s.title)
+		println("  " + s.how)
+		start, lastLine := time.Now(), time.Time{}
+		for !p.high(s.sensor) && time.Since(start) < stepTimeout {
+			pump(link, &p, progressInterval/5)
+			led.Update(time.Now())
+			if time.Since(lastLine) >= progressInterval {
+				lastLine = time.Now()
+				println(p.line(s.sensor))
+			}
+		}
+		if p.high(s.sensor) {
+			println("  " + s.title + " calibrated")
+		} else {
+			println("  " + s.title + " did not reach high accuracy, moving on")
+		}
+	}
+
+	println()
+	println("Final:" + p.line(0))
+	for _, s := range steps {
+		if !p.high(s.sensor) {
+			println(s.title, "is not at high accuracy; calibration not saved. Run again to retry.")
+			led.Halt(statusled.Calibrating)
+		}
+	}
+
+	println("Saving calibration to the sensor's flash...")
+	switch err := sh2cmd.SaveDCD(link, 1, responseTimeout, p.input); err {
+	case nil:
+		println("Calibration saved; it will be loaded at every boot")
+		led.Halt(statusled.Streaming)
+	case sh2cmd.ErrTimeout:
+		println("No answer to Save DCD within", int(responseTimeout/time.Millisecond), "ms")
+	default:
+		println("Save DCD failed:", err.Error())
+	}
+	led.Halt(statusled.BusError)
+}
+
+// pump reads packets for up to d, recording input reports.
+func pump(link transport.Transport, p *progress, d time.Duration) {
+	start := time.Now()
+	for time.Since(start) < d {
+		if packet, err := transport.Next(link, d-time.Since(start)); err == nil {
+			p.input(packet)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package main publishes the orientation and acceleration on a CAN bus
+// through an MCP2515 controller on board.CANSPI, so the BNO08x can feed a
+// vehicle network as an attitude source. Two standard 11-bit frames are
+// sent at fixed cycle times, every field a little-endian (Intel) int16:
+//
+//	quatID   every quatCycle   bytes 0-7  i, j, k, real, scaled by 2^14
+//	accelID  every accelCycle  bytes 0-5  x, y, z in 0.01 m/s²
+//	                           byte 6     counter, incremented per frame
+//	                           byte 7     0, reserved
+//
+// The counter lets receivers spot dropped or frozen frames. The bit rate
+// and the crystal on the MCP2515 module, usually 8MHz, are set below. The
+// bus must be terminated and have at least one other node to acknowledge
+// frames.
+package main
+
+import (
+	"encoding/binary"
+	"machine"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+	"tinygo.org/x/drivers/mcp2515"
+)
+
+// CAN identifiers
+const (
+	quatID  = 0x100
+	accelID = 0x101
+)
+
+// Frame cycle times
+const (
+	quatCycle  = 10 * time.Millisecond // 100Hz
+	accelCycle = 20 * time.Millisecond // 50Hz
+)
+
+// Bus bit rate and MCP2515 crystal
+const (
+	bitRate = mcp2515.CAN500kBps
+	crystal = mcp2515.Clock8MHz
+)
+
+// Scale factors of the frame fields
+const (
+	quatScale  = 1 << 14
+	accelScale = 100
+)
+
+// Interval between status lines
+const statusInterval = 10 * time.Second
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("can_imu")
+
+	spi, spiConfig, cs := board.CANSPI()
+	if spi == nil {
+		println("No CAN controller assigned for the " + board.Name + " board, see internal/board")
+		return
+	}
+	spiConfig.Frequency = 8 * machine.MHz
+	if err := spi.Configure(spiConfig); err != nil {
+		println("Failed to configure SPI:", err.Error())
+		return
+	}
+	can := mcp2515.New(spi, cs)
+	// Standard 11-bit identifiers; the bit rate and crystal go to Begin
+	can.Configure(mcp2515.Configuration{Extended: false})
+	if err := can.Begin(bitRate, crystal); err != nil {
+		println("Failed to start MCP2515:", err.Error())
+		return
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// Sampled at twice the frame rate so every frame carries a fresh value
+	err = sensor.EnableReport(bno08x.SensorRotationVector, uint32(quatCycle/time.Microsecond/2))
+	if err != nil {
+		println("Failed to enable rotation vector:", err.Error())
+		return
+	}
+	err = sensor.EnableReport(bno08x.SensorAccelerometer, uint32(accelCycle/time.Microsecond/2))
+	if err != nil {
+		println("Failed to enable accelerometer:", err.Error())
+		return
+	}
+
+	var q bno08x.Quaternion
+	var accel bno08x.Vector3
+	haveQuat, haveAccel := false, false
+	var counter uint8
+	var frame [8]byte
+	var sent, failed uint32
+
+	now := time.Now()
+	nextQuat, nextAccel, nextStatus := now, now, now.Add(statusInterval)
+
+	send := func(id uint32, data []byte) {
+		if err := can.Tx(id, uint8(len(data)), data); err != nil {
+			failed++
+			return
+		}
+		sent++
+	}
+
+	println("Sending on CAN IDs 0x"+strconv.FormatUint(quatID, 16), "and 0x"+strconv.FormatUint(accelID, 16))
+
+	for {
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorRotationVector:
+				q, haveQuat = event.Quaternion(), true
+			case bno08x.SensorAccelerometer:
+				accel, haveAccel = event.Accelerometer(), true
+			}
+		}
+
+		now := time.Now()
+		if haveQuat && !now.Before(nextQuat) {
+			nextQuat = next(nextQuat, now, quatCycle)
+			b := frame[:0]
+			for _, v := range [4]float32{q.I, q.J, q.K, q.Real} {
+				b = binary.LittleEndian.AppendUint16(b, uint16(scale(v, quatScale)))
+			}
+			send(quatID, b)
+		}
+		if haveAccel && !now.Before(nextAccel) {
+			nextAccel = next(nextAccel, now, accelCycle)
+			b := frame[:0]
+			for _, v := range [3]float32{accel.X, accel.Y, accel.Z} {
+				b = binary.LittleEndian.AppendUint16(b, uint16(scale(v, accelScale)))
+			}
+			b = append(b, counter, 0)
+			counter++
+			send(accelID, b)
+		}
+		if !now.Before(nextStatus) {
+			nextStatus = now.Add(statusInterval)
+			println("Frames sent:", sent, "failed:", failed)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// next returns the time the following frame is due, keeping a fixed cycle
+// but skipping missed slots rather than sending a burst after a stall.
+func next(due, now time.Time, cycle time.Duration) time.Time {
+	due = due.Add(cycle)
+	if now.Sub(due) >= cycle {
+		return now.Add(cycle)
+	}
+	return due
+}
+
+// scale returns v times factor, rounded and saturated to the int16 range.
+func scale(v, factor float32) int16 {
+	s := math.Round(float64(v * factor))
+	switch {
+	case s > math.MaxInt16:
+		return math.MaxInt16
+	case s < math.MinInt16:
+		return math.MinInt16
+	}
+	return int16(s)
+}
@@ -1,89 +1,77 @@
 // Package main - Debug channel mapping and data flow
+//
+// Runs over I2C by default, or over SPI when built with "-tags bno08x_spi".
 package main
 
 import (
-	"encoding/binary"
-	"machine"
 	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/hexdump"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 )
 
+// Sniffer filters for step 5. Set a field to shtpraw.Any to see everything,
+// e.g. SensorID: 0x08 to follow only Game Rotation Vector reports.
+var filter = shtpraw.Filter{
+	Channel:  shtpraw.Any,
+	ReportID: shtpraw.Any,
+	SensorID: shtpraw.Any,
+}
+
+// Set to true to print every matching packet as a full hex+ASCII dump
+// instead of only the first payload bytes.
+const fullDump = false
+
 func main() {
 	time.Sleep(2 * time.Second)
+	buildinfo.Banner("channel_debug")
 	println("=== BNO08x Channel Debug ===")
 	println()
 
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{Frequency: 400 * machine.KHz})
+	link, err := transport.Open()
 	if err != nil {
 		println("FAILED:", err.Error())
 		return
 	}
 
-	addr := uint16(0x4A)
-	seq := [6]uint8{0, 0, 0, 0, 0, 0}
-
-	// Soft reset
-	println("1. Soft reset")
-	softReset := []byte{5, 0, 1, 0, 1}
-	i2c.Tx(addr, softReset, nil)
+	// Reset: RST pulse if wired, otherwise a soft reset
+	println("1. Reset")
+	link.Reset()
 	time.Sleep(300 * time.Millisecond)
 	println("   Done")
 	println()
 
 	// Read advertisement and parse channel assignments
 	println("2. Reading advertisement")
-	header := make([]byte, 4)
-	i2c.Tx(addr, nil, header)
-	advertLen := binary.LittleEndian.Uint16(header[0:2]) & 0x7FFF
-	println("   Length:", advertLen, "Channel:", header[2])
-
-	if advertLen > 4 && advertLen < 500 {
-		advert := make([]byte, advertLen)
-		i2c.Tx(addr, nil, advert)
+	advert, err := transport.Next(link, 500*time.Millisecond)
+	if err != nil {
+		println("   Read error:", err.Error())
+	} else {
+		println("   Length:", advert.Length, "Channel:", advert.Channel)
 
 		// Parse advertisement tags to find channel assignments
 		// Advertisement format: [header(4)] [reportID(1)] [tags...]
 		println("   Parsing channel assignments:")
-		cursor := 4 // Skip 4-byte header (length is re-read in full packet)
-		reportID := advert[cursor]
-		println("   Report ID:", reportID)
-		cursor++ // Skip report ID
-
-		channels := make(map[string]uint8)
-		currentChan := uint8(0)
-
-		for cursor < int(advertLen) {
-			if cursor+1 >= int(advertLen) {
-				break
-			}
-			tag := advert[cursor]
-			length := advert[cursor+1]
-			cursor += 2
-
-			if cursor+int(length) > int(advertLen) {
-				break
-			}
-
-			// TAG_NORMAL_CHANNEL = 4
-			if tag == 4 && length == 1 {
-				currentChan = advert[cursor]
-				println("     Normal channel:", currentChan)
-			}
-			// TAG_CHANNEL_NAME = 8
-			if tag == 8 && length > 0 && currentChan > 0 {
-				name := string(advert[cursor : cursor+int(length)])
-				channels[name] = currentChan
-				println("     Channel", currentChan, "=", name)
+		adv, err := shtpraw.ParseAdvertisement(advert.Cargo())
+		if err != nil {
+			println("   Parse error:", err.Error())
+		}
+		for _, ch := range adv.Channels {
+			if ch.Wake {
+				println("     Wake channel:", ch.Number)
+			} else {
+				println("     Normal channel:", ch.Number)
 			}
-
-			cursor += int(length)
+			println("     Channel", ch.Number, "=", ch.Name)
 		}
 		println()
 
 		// Show what we found
 		println("   Channel map:")
-		for name, ch := range channels {
-			println("    ", name, "->", ch)
+		for _, ch := range adv.Channels {
+			println("    ", ch.App+"/"+ch.Name, "->", ch.Number)
 		}
 	}
 	println()
@@ -91,7 +79,7 @@ func main() {
 	// Send initialize command (channel 2 = control)
 	println("3. Initialize command")
 	initCmd := []byte{0x02} // COMMAND_INITIALIZE
-	sendOnChannel(i2c, addr, &seq, 2, initCmd)
+	link.Write(shtpraw.ChannelControl, initCmd)
 	time.Sleep(100 * time.Millisecond)
 	println("   Sent")
 	println()
@@ -107,7 +95,7 @@ func main() {
 		0x00, 0x00, 0x00, 0x00, // Batch interval
 		0x00, 0x00, 0x00, 0x00, // Sensor specific
 	}
-	sendOnChannel(i2c, addr, &seq, 2, setFeature)
+	link.Write(shtpraw.ChannelControl, setFeature)
 	time.Sleep(100 * time.Millisecond)
 	println("   Sent")
 	println()
@@ -115,71 +103,63 @@ func main() {
 	// Poll and show ALL data on ALL channels
 	println("5. Polling all channels (100 attempts, 10ms between each)")
 	channelCounts := make(map[uint8]int)
+	var quarantine shtpraw.Quarantine
+	start := time.Now()
 
 	for i := 0; i < 100; i++ {
-		header := make([]byte, 4)
-		err = i2c.Tx(addr, nil, header)
+		// Skips empty reads and continuation packets
+		packet, err := link.Read()
+		readTime := time.Since(start).Microseconds()
 		if err != nil {
 			time.Sleep(10 * time.Millisecond)
 			continue
 		}
 
-		length := binary.LittleEndian.Uint16(header[0:2])
-
-		// Check continuation bit
-		if length&0x8000 != 0 {
-			time.Sleep(10 * time.Millisecond)
-			continue
-		}
-
-		if length == 0 {
-			time.Sleep(10 * time.Millisecond)
-			continue
-		}
-
-		length &= 0x7FFF
-
-		if length > 4 && length < 500 {
-			// Re-read full packet
-			packet := make([]byte, length)
-			err = i2c.Tx(addr, nil, packet)
-			if err != nil {
+		if packet.Length > shtpraw.HeaderLen {
+			channel := packet.Channel
+			channelCounts[channel]++
+			if !filter.Match(packet) {
 				time.Sleep(10 * time.Millisecond)
 				continue
 			}
 
-			channel := packet[2]
-			channelCounts[channel]++
-
-			println("   Packet on channel", channel, "length:", length, "seq:", packet[3])
-			if length > 4 {
+			println("   Packet on channel", channel, "length:", packet.Length, "seq:", packet.Sequence, "t:", readTime, "us")
+			if fullDump {
+				hexdump.Print(packet.Cargo())
+			} else {
 				print("     Payload bytes:")
-				for j := 4; j < int(length) && j < 12; j++ {
-					print(" ", packet[j])
+				for j := shtpraw.HeaderLen; j < int(packet.Length) && j < 12; j++ {
+					print(" ", packet.Data[j])
 				}
 				println()
 			}
+
+			// Input reports may carry several batched samples, each timed
+			// relative to the base timestamp and any rebase records
+			if channel == shtpraw.ChannelInputNormal || channel == shtpraw.ChannelInputWake {
+				err := quarantine.DecodeInput(packet.Cargo(), readTime, printReport)
+				if err != nil {
+					println("     Decode error:", err.Error())
+				}
+			}
 		}
 
 		time.Sleep(10 * time.Millisecond)
 	}
 
 	println()
-	println("Summary - packets per channel:")
-	for ch := uint8(0); ch < 6; ch++ {
+	println("Summary - packets per channel (before filtering):")
+	for ch := uint8(0); ch < shtpraw.NumChannels; ch++ {
 		if count, ok := channelCounts[ch]; ok {
 			println("  Channel", ch, ":", count, "packets")
 		}
 	}
+	quarantine.Print()
 }
 
-func sendOnChannel(i2c *machine.I2C, addr uint16, seq *[6]uint8, channel uint8, payload []byte) {
-	frameLen := 4 + len(payload)
-	frame := make([]byte, frameLen)
-	binary.LittleEndian.PutUint16(frame[0:2], uint16(frameLen))
-	frame[2] = channel
-	frame[3] = seq[channel]
-	seq[channel]++
-	copy(frame[4:], payload)
-	i2c.Tx(addr, frame, nil)
+// printReport prints one decoded input report with its reconstructed
+// sample time in microseconds since polling started.
+func printReport(r shtpraw.Report) {
+	println("     Report", r.ID, "seq:", r.Sequence, "accuracy:", r.Accuracy(),
+		"delay:", r.Delay, "t:", r.Timestamp, "us")
 }
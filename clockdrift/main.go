@@ -0,0 +1,94 @@
+// Package main measures how fast the BNO08x clock runs relative to the MCU
+// clock, in parts per million.
+//
+// Raw accelerometer reports carry the sensor's own 32-bit microsecond
+// timestamp. Each one is paired with the MCU time it was read and fed to
+// timestamps.DriftEstimator. Leave it running: the estimate settles after
+// a few minutes and keeps improving for hours. Every minute the program
+// prints the estimate and how far the sensor clock has wandered from the
+// MCU clock, both as read and after the drift correction is applied by
+// timestamps.Timebase. Put the final figure in Timebase.DriftPPM to keep
+// long logs aligned.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/timestamps"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Interval between progress reports
+const reportInterval = time.Minute
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("clockdrift")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	err = sensor.EnableReport(bno08x.SensorRawAccelerometer, 10000) // 100Hz
+	if err != nil {
+		println("Failed to enable raw accelerometer:", err.Error())
+		return
+	}
+
+	println("Measuring clock drift, first estimate in one minute...")
+	println("Format: Minutes | Samples | Drift (ppm) | Offset raw / corrected (ms)")
+
+	var unwrap timestamps.Unwrapper
+	var estimator timestamps.DriftEstimator
+	var timebase timestamps.Timebase
+	start := time.Now()
+	lastReport := start
+
+	for {
+		event, ok := sensor.GetSensorEvent()
+		if !ok || event.ID() != bno08x.SensorRawAccelerometer {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+
+		host := time.Since(start).Microseconds()
+		sensorTime := unwrap.Unwrap(uint32(event.RawAccelerometer().Timestamp))
+		if !timebase.Synced() {
+			timebase.Sync(host, sensorTime)
+		}
+		estimator.Add(host, sensorTime)
+
+		if time.Since(lastReport) < reportInterval {
+			continue
+		}
+		lastReport = time.Now()
+
+		// Offset of the sensor clock from the MCU clock since the start,
+		// uncorrected and with the current drift estimate applied
+		timebase.DriftPPM = 0
+		raw := timebase.Host(sensorTime) - host
+		timebase.DriftPPM = estimator.PPM()
+		corrected := timebase.Host(sensorTime) - host
+
+		println(fmtutil.Int(int(host/60000000)),
+			"|", estimator.Samples(),
+			"|", fmtutil.Float(float32(estimator.PPM()), 2),
+			"|", fmtutil.Float(float32(raw)/1000, 1),
+			"/", fmtutil.Float(float32(corrected)/1000, 1))
+	}
+}
@@ -0,0 +1,376 @@
+// Command flashtool lists the programs in this repository and builds or
+// flashes one with TinyGo, adding the build tags, board target and
+// buildinfo linker flags that are otherwise typed by hand:
+//
+//	go run ./cmd/flashtool list
+//	go run ./cmd/flashtool flash -target pico diagnostic
+//	go run ./cmd/flashtool build -target xiao-ble -spi -o diag.uf2 diagnostic
+//	go run ./cmd/flashtool flash -n -target feather-rp2040 euler
+//	go run ./cmd/flashtool flash -scheduler cores json_stream
+//	go run ./cmd/flashtool dfu -port /dev/ttyACM0 firmware.bin
+//
+// -n prints the tinygo command line without running it. dfu streams a
+// BNO08x firmware image to a board running dfu_update. It runs on the
+// host, not on a board, so it uses the standard library freely.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"hash/crc32"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const modulePath = "github.com/intermernet/bno08xPrograms"
+
+// Targets with a preset in internal/board. Others build against the
+// generic preset, which leaves the optional pins unassigned.
+var boardTargets = map[string]bool{
+	"pico":           true,
+	"feather-rp2040": true,
+	"xiao-ble":       true,
+}
+
+// program is one flashable directory.
+type program struct {
+	name      string
+	synopsis  string
+	transport bool // Imports internal/transport, so -spi applies
+	spiMode   bool // Has files built only with bno08x_spi
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	root, err := findRoot()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "flashtool:", err)
+		os.Exit(1)
+	}
+
+	switch cmd := os.Args[1]; cmd {
+	case "list":
+		err = list(root)
+	case "build", "flash":
+		err = run(root, cmd, os.Args[2:])
+	case "dfu":
+		err = sendFirmware(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		usage()
+	default:
+		fmt.Fprintln(os.Stderr, "flashtool: unknown command", cmd)
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "flashtool:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage:
+  flashtool list
+  flashtool build [flags] <program>
+  flashtool flash [flags] <program>
+  flashtool dfu -port <port> <image>
+
+Run "flashtool build -h" for the flags.`)
+}
+
+// list prints every program with the first sentence of its package doc.
+func list(root string) error {
+	programs, err := findPrograms(root)
+	if err != nil {
+		return err
+	}
+	width := 0
+	for _, p := range programs {
+		if len(p.name) > width {
+			width = len(p.name)
+		}
+	}
+	for _, p := range programs {
+		note := ""
+		if p.transport || p.spiMode {
+			note = " [spi]"
+		}
+		fmt.Printf("  %-*s  %s%s\n", width, p.name, p.synopsis, note)
+	}
+	fmt.Println()
+	fmt.Println("Programs marked [spi] also run on a sensor strapped for SPI (-spi).")
+	return nil
+}
+
+// run builds or flashes one program.
+func run(root, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	target := fs.String("target", "pico", "TinyGo target; pico, feather-rp2040 and xiao-ble have board presets")
+	spi := fs.Bool("spi", false, "talk to the sensor over SPI (adds the bno08x_spi tag)")
+	tags := fs.String("tags", "", "extra build tags, space separated")
+	scheduler := fs.String("scheduler", "", "TinyGo scheduler, e.g. cores to run goroutines on both RP2040 cores")
+	port := fs.String("port", "", "serial port for flashing, if TinyGo cannot find it")
+	output := fs.String("o", "", "output file for build (default <program>.uf2)")
+	dryRun := fs.Bool("n", false, "print the tinygo command without running it")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%s needs exactly one program", cmd)
+	}
+
+	name := strings.Trim(filepath.ToSlash(fs.Arg(0)), "./")
+	programs, err := findPrograms(root)
+	if err != nil {
+		return err
+	}
+	var prog *program
+	for i := range programs {
+		if programs[i].name == name {
+			prog = &programs[i]
+		}
+	}
+	if prog == nil {
+		return fmt.Errorf("no program %q; run flashtool list", name)
+	}
+
+	var buildTags []string
+	if *spi {
+		if !prog.transport && !prog.spiMode {
+			return fmt.Errorf("%s does not reach the sensor through internal/transport; -spi does not apply", name)
+		}
+		buildTags = append(buildTags, "bno08x_spi")
+	}
+	buildTags = append(buildTags, strings.Fields(*tags)...)
+	if !boardTargets[*target] {
+		fmt.Fprintf(os.Stderr, "flashtool: no board preset for %s; using the generic pins in internal/board\n", *target)
+	}
+
+	tinygoArgs := []string{cmd, "-target=" + *target}
+	if len(buildTags) > 0 {
+		tinygoArgs = append(tinygoArgs, "-tags="+strings.Join(buildTags, " "))
+	}
+	if *scheduler != "" {
+		tinygoArgs = append(tinygoArgs, "-scheduler="+*scheduler)
+	}
+	tinygoArgs = append(tinygoArgs, "-ldflags="+ldflags(root))
+	switch cmd {
+	case "build":
+		out := *output
+		if out == "" {
+			out = name + ".uf2"
+		}
+		tinygoArgs = append(tinygoArgs, "-o", out)
+	case "flash":
+		if *port != "" {
+			tinygoArgs = append(tinygoArgs, "-port="+*port)
+		}
+	}
+	tinygoArgs = append(tinygoArgs, "./"+name)
+
+	fmt.Println("tinygo", quoteArgs(tinygoArgs))
+	if *dryRun {
+		return nil
+	}
+	c := exec.Command("tinygo", tinygoArgs...)
+	c.Dir = root
+	c.Stdout, c.Stderr, c.Stdin = os.Stdout, os.Stderr, os.Stdin
+	return c.Run()
+}
+
+// sendFirmware streams a firmware image to dfu_update over a serial port:
+// it sends the size and CRC-32, answers each "NEXT <offset> <length>"
+// with that slice of the image, and prints the board's other output
+// until "DONE" or "FAIL".
+func sendFirmware(args []string) error {
+	fs := flag.NewFlagSet("dfu", flag.ExitOnError)
+	port := fs.String("port", "", "serial port of the board running dfu_update")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *port == "" {
+		fs.Usage()
+		return fmt.Errorf("dfu needs -port and exactly one image")
+	}
+	image, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(image) == 0 {
+		return fmt.Errorf("%s is empty", fs.Arg(0))
+	}
+
+	// The board must see the image bytes unchanged; USB serial ignores
+	// the baud rate
+	if err := rawMode(*port); err != nil {
+		fmt.Fprintln(os.Stderr, "flashtool: could not set raw mode, continuing:", err)
+	}
+	f, err := os.OpenFile(*port, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "size %d %08x\n", len(image), crc32.ChecksumIEEE(image)); err != nil {
+		return err
+	}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[0] == "NEXT":
+			var offset, n int
+			if _, err := fmt.Sscan(fields[1]+" "+fields[2], &offset, &n); err != nil || offset < 0 || offset+n > len(image) {
+				return fmt.Errorf("bad request from board: %q", line)
+			}
+			if _, err := f.Write(image[offset : offset+n]); err != nil {
+				return err
+			}
+		case line == "DONE":
+			fmt.Println("Firmware update complete")
+			return nil
+		case strings.HasPrefix(line, "FAIL"):
+			return fmt.Errorf("board reported: %s", strings.TrimSpace(strings.TrimPrefix(line, "FAIL")))
+		case line != "":
+			fmt.Println(line)
+		}
+	}
+}
+
+// rawMode turns off echo and line editing on a serial port with stty,
+// whose device flag differs between Linux and the BSDs.
+func rawMode(port string) error {
+	err := exec.Command("stty", "-F", port, "raw", "-echo").Run()
+	if err != nil {
+		err = exec.Command("stty", "-f", port, "raw", "-echo").Run()
+	}
+	return err
+}
+
+// ldflags sets the buildinfo revision and build time, as in the README.
+func ldflags(root string) string {
+	revision := "unknown"
+	if out, err := exec.Command("git", "-C", root, "rev-parse", "--short", "HEAD").Output(); err == nil {
+		revision = strings.TrimSpace(string(out))
+	}
+	pkg := modulePath + "/internal/buildinfo"
+	return "-X " + pkg + ".Revision=" + revision +
+		" -X " + pkg + ".BuildTime=" + time.Now().UTC().Format(time.RFC3339)
+}
+
+// findRoot walks up from the working directory to the repository root,
+// recognised by internal/board.
+func findRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "internal", "board")); err == nil && info.IsDir() {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("not inside the bno08xPrograms repository")
+		}
+		dir = parent
+	}
+}
+
+// findPrograms returns the top-level directories holding a main package,
+// sorted by name.
+func findPrograms(root string) ([]program, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var programs []program
+	for _, e := range entries {
+		switch name := e.Name(); {
+		case !e.IsDir(), strings.HasPrefix(name, "."), name == "cmd", name == "internal", name == "pkg":
+			continue
+		}
+		if p, ok := inspect(filepath.Join(root, e.Name())); ok {
+			p.name = e.Name()
+			programs = append(programs, p)
+		}
+	}
+	sort.Slice(programs, func(i, j int) bool { return programs[i].name < programs[j].name })
+	return programs, nil
+}
+
+// inspect parses the package clause, doc comment, imports and build
+// constraints of the Go files in dir.
+func inspect(dir string) (program, bool) {
+	var p program
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	fset := token.NewFileSet()
+	isMain := false
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly|parser.ParseComments)
+		if err != nil || f.Name.Name != "main" {
+			continue
+		}
+		isMain = true
+		if f.Doc != nil && p.synopsis == "" {
+			p.synopsis = synopsis(f.Doc.Text())
+		}
+		for _, imp := range f.Imports {
+			if strings.Trim(imp.Path.Value, `"`) == modulePath+"/internal/transport" {
+				p.transport = true
+			}
+		}
+		// Build constraints are not part of the parsed comment text
+		if data, err := os.ReadFile(file); err == nil && strings.HasPrefix(string(data), "//go:build bno08x_spi") {
+			p.spiMode = true
+		}
+	}
+	return p, isMain
+}
+
+// synopsis returns the first sentence of a package doc comment without
+// the "Package main" or "Command x" lead-in.
+func synopsis(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i >= 0 {
+		doc = doc[:i+1]
+	}
+	for _, prefix := range []string{"Package main - ", "Package main is ", "Package main ", "An example of "} {
+		if strings.HasPrefix(doc, prefix) {
+			doc = doc[len(prefix):]
+			break
+		}
+	}
+	if doc != "" {
+		doc = strings.ToUpper(doc[:1]) + doc[1:]
+	}
+	return doc
+}
+
+// quoteArgs joins args for display, quoting any containing spaces.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if strings.ContainsAny(a, " \t") {
+			if k := strings.Index(a, "="); k >= 0 && strings.HasPrefix(a, "-") {
+				a = a[:k+1] + `"` + a[k+1:] + `"`
+			} else {
+				a = `"` + a + `"`
+			}
+		}
+		quoted[i] = a
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,127 @@
+// Package main turns the BNO08x into a tilt-compensated compass, printing
+// the true-north heading from the rotation vector alongside the heading
+// computed from the raw magnetometer and gravity vectors.
+//
+// Set the local magnetic declination in the constant below, or at runtime by
+// typing e.g. "decl 11.5" followed by Enter on the serial console. Typing
+// "selftest" runs the bus and sensor health checks in place.
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/heading"
+	"github.com/intermernet/bno08xPrograms/internal/selftest"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Magnetic declination in degrees, east positive
+const declination = 0.0
+
+// Decimal places printed for headings
+const decimals = 1
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("compass")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, bus, err := transport.OpenSensorBus()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The rotation vector is referenced to magnetic north; magnetometer and
+	// gravity feed the independent tilt-compensated calculation
+	sensors := []bno08x.SensorID{
+		bno08x.SensorRotationVector,
+		bno08x.SensorMagneticField,
+		bno08x.SensorGravity,
+	}
+	for _, id := range sensors {
+		err = sensor.EnableReport(id, 20000) // 50Hz
+		if err != nil {
+			println("Failed to enable sensor:", uint8(id), err.Error())
+			return
+		}
+	}
+
+	compass := heading.Compass{Declination: declination}
+
+	println("Reading heading...")
+	println("Format: Heading (true) Direction | Magnetometer heading | Accuracy (rad)")
+	println("Type 'decl <degrees>' to set the magnetic declination, 'selftest' to check health")
+
+	var mx, my, mz float32
+	var gx, gy, gz float32
+	var commands shell.LineReader
+	lastPrint := time.Now()
+
+	for {
+		// Handle serial commands
+		if line, ok := commands.Poll(); ok {
+			if line == selftest.Command {
+				result := selftest.Run(bus, sensor, 2*time.Second)
+				result.Print()
+			} else {
+				handleCommand(line, &compass)
+			}
+		}
+
+		event, ok := sensor.GetSensorEvent()
+		if ok {
+			switch event.ID() {
+			case bno08x.SensorMagneticField:
+				m := event.MagneticField()
+				mx, my, mz = m.X, m.Y, m.Z
+			case bno08x.SensorGravity:
+				g := event.Gravity()
+				gx, gy, gz = g.X, g.Y, g.Z
+			case bno08x.SensorRotationVector:
+				if time.Since(lastPrint) < 250*time.Millisecond {
+					break
+				}
+				lastPrint = time.Now()
+				h := compass.FromQuaternion(event.Quaternion())
+				hm := compass.FromMagnetometer(mx, my, mz, gx, gy, gz)
+				println("Heading:", fmtutil.Float(h, decimals), heading.Cardinal(h),
+					"| Mag:", fmtutil.Float(hm, decimals),
+					"| Accuracy:", event.QuaternionAccuracy())
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// handleCommand parses a serial command line.
+func handleCommand(line string, compass *heading.Compass) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 || fields[0] != "decl" {
+		println("Unknown command. Usage: decl <degrees> | selftest")
+		return
+	}
+	d, err := strconv.ParseFloat(fields[1], 32)
+	if err != nil {
+		println("Invalid declination:", fields[1])
+		return
+	}
+	compass.Declination = float32(d)
+	println("Declination set to", fmtutil.Float(compass.Declination, decimals), "degrees")
+}
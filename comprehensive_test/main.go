@@ -2,31 +2,33 @@
 package main
 
 import (
-	"encoding/binary"
 	"machine"
 	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
 )
 
 func main() {
 	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("comprehensive_test")
 	println("=== Comprehensive BNO08x Test (Following Adafruit Exactly) ===")
 	println()
 
-	i2c := machine.I2C0
+	i2c := board.IMUBus()
 	err := i2c.Configure(machine.I2CConfig{Frequency: 400 * machine.KHz})
 	if err != nil {
 		println("FAILED:", err.Error())
 		return
 	}
 
-	addr := uint16(0x4A)
-	seq := [6]uint8{0, 0, 0, 0, 0, 0} // Sequence counters for channels 0-5
+	conn := shtpraw.New(i2c, shtpraw.DefaultAddress) // Tracks sequence counters for channels 0-5
 
 	// Step 1: Soft reset (from i2chal_open)
 	println("Step 1: Soft reset")
-	softReset := []byte{5, 0, 1, 0, 1}
 	for attempt := 0; attempt < 5; attempt++ {
-		err = i2c.Tx(addr, softReset, nil)
+		err = conn.SoftReset()
 		if err == nil {
 			break
 		}
@@ -39,15 +41,9 @@ func main() {
 	// Step 2: Drain/read advertisement
 	println("Step 2: Reading advertisement")
 	for i := 0; i < 10; i++ {
-		header := make([]byte, 4)
-		i2c.Tx(addr, nil, header)
-		length := binary.LittleEndian.Uint16(header[0:2])
-		if length > 0 && length < 1000 && (length&0x8000) == 0 {
-			println("  Got advertisement, length:", length, "channel:", header[2])
-			if length > 4 {
-				payload := make([]byte, length-4)
-				i2c.Tx(addr, nil, payload)
-			}
+		packet, err := conn.ReadPacket()
+		if err == nil {
+			println("  Got advertisement, length:", packet.Length, "channel:", packet.Channel)
 			break
 		}
 		time.Sleep(50 * time.Millisecond)
@@ -57,13 +53,12 @@ func main() {
 	// Step 3: Initialize command (from _init -> sh2_open)
 	println("Step 3: Sending Initialize command")
 	initCmd := []byte{0xF2, 0, 0x04, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0}
-	sendOnChannel(i2c, addr, &seq, 2, initCmd)
+	conn.SendOnChannel(shtpraw.ChannelControl, initCmd)
 	time.Sleep(100 * time.Millisecond)
 
 	// Drain responses
 	for i := 0; i < 5; i++ {
-		header := make([]byte, 4)
-		i2c.Tx(addr, nil, header)
+		conn.ReadHeader()
 		time.Sleep(20 * time.Millisecond)
 	}
 	println("  Done")
@@ -72,22 +67,16 @@ func main() {
 	// Step 4: Request Product IDs (from _init -> sh2_getProdIds)
 	println("Step 4: Requesting Product IDs")
 	prodIDReq := []byte{0xF9, 0x00}
-	sendOnChannel(i2c, addr, &seq, 2, prodIDReq)
+	conn.SendOnChannel(shtpraw.ChannelControl, prodIDReq)
 	time.Sleep(100 * time.Millisecond)
 
 	// Read product ID response
 	for i := 0; i < 10; i++ {
-		header := make([]byte, 4)
-		i2c.Tx(addr, nil, header)
-		length := binary.LittleEndian.Uint16(header[0:2])
-		if length > 0 && length < 1000 && (length&0x8000) == 0 {
-			println("  Got response, length:", length, "channel:", header[2])
-			if length > 4 {
-				payload := make([]byte, length-4)
-				i2c.Tx(addr, nil, payload)
-				if len(payload) > 0 {
-					println("  Response ID:", payload[0])
-				}
+		packet, err := conn.ReadPacket()
+		if err == nil {
+			println("  Got response, length:", packet.Length, "channel:", packet.Channel)
+			if packet.Length > shtpraw.HeaderLen {
+				println("  Response ID:", packet.Cargo()[0])
 			}
 		}
 		time.Sleep(20 * time.Millisecond)
@@ -105,7 +94,7 @@ func main() {
 		0x00, 0x00, 0x00, 0x00, // Batch interval
 		0x00, 0x00, 0x00, 0x00, // Sensor specific
 	}
-	sendOnChannel(i2c, addr, &seq, 2, setFeature)
+	conn.SendOnChannel(shtpraw.ChannelControl, setFeature)
 	println("  Command sent")
 	println()
 
@@ -117,52 +106,30 @@ func main() {
 	println("Step 6: Polling for sensor data (100 attempts, 10ms between each)")
 	reportCount := 0
 	for i := 0; i < 100; i++ {
-		// First, read header to get packet length
-		header := make([]byte, 4)
-		err = i2c.Tx(addr, nil, header)
+		// Read header to get packet length, then re-read the FULL packet
+		// including header (Arduino's approach). Skips empty reads and
+		// continuation packets.
+		packet, err := conn.ReadPacket()
 		if err != nil {
 			time.Sleep(10 * time.Millisecond)
 			continue
 		}
 
-		length := binary.LittleEndian.Uint16(header[0:2])
-
-		// Skip if no data (continuation bit set)
-		if length&0x8000 != 0 {
-			time.Sleep(10 * time.Millisecond)
-			continue
-		}
+		length := packet.Length
+		channel := packet.Channel
+		fullPacket := packet.Data
 
-		if length == 0 {
-			time.Sleep(10 * time.Millisecond)
-			continue
-		}
-
-		length &= ^uint16(0x8000) // Mask off continuation bit
-
-		if length > 0 && length < 500 {
-			// Now re-read the FULL packet including header (Arduino's approach)
-			fullPacket := make([]byte, length)
-			err = i2c.Tx(addr, nil, fullPacket)
-			if err != nil {
-				time.Sleep(10 * time.Millisecond)
-				continue
+		// Check if it's a sensor report channel (3, 4, or 5)
+		if channel == shtpraw.ChannelInputNormal || channel == shtpraw.ChannelInputWake || channel == shtpraw.ChannelGyroRV {
+			reportCount++
+			println("  Report", reportCount, "- Length:", length, "Channel:", channel)
+			if length > 6 {
+				println("    Sensor ID:", fullPacket[4], "Seq:", fullPacket[5], "Status:", fullPacket[6])
 			}
-
-			channel := fullPacket[2]
-
-			// Check if it's a sensor report channel (3, 4, or 5)
-			if channel == 3 || channel == 4 || channel == 5 {
-				reportCount++
-				println("  Report", reportCount, "- Length:", length, "Channel:", channel)
-				if length > 4 {
-					println("    Sensor ID:", fullPacket[4], "Seq:", fullPacket[5], "Status:", fullPacket[6])
-				}
-			} else if channel == 2 {
-				// Control channel response
-				if length > 4 {
-					println("  Control response, ID:", fullPacket[4])
-				}
+		} else if channel == shtpraw.ChannelControl {
+			// Control channel response
+			if length > 4 {
+				println("  Control response, ID:", fullPacket[4])
 			}
 		}
 
@@ -181,14 +148,3 @@ func main() {
 		println("  - Sensor needs additional undocumented initialization")
 	}
 }
-
-func sendOnChannel(i2c *machine.I2C, addr uint16, seq *[6]uint8, channel uint8, payload []byte) {
-	frameLen := 4 + len(payload)
-	frame := make([]byte, frameLen)
-	binary.LittleEndian.PutUint16(frame[0:2], uint16(frameLen))
-	frame[2] = channel
-	frame[3] = seq[channel]
-	seq[channel]++
-	copy(frame[4:], payload)
-	i2c.Tx(addr, frame, nil)
-}
@@ -0,0 +1,222 @@
+// Package main keeps the sensor's calibration across power cycles by
+// saving it to the sensor's flash every saveInterval while it is good.
+// The sensor hub learns its calibration afresh after each boot unless a
+// saved copy, the dynamic calibration data or DCD, is there to load.
+//
+// At each save point the accuracy of the latest accelerometer, gyroscope
+// and magnetometer reports is checked; if all are at least minAccuracy
+// the program sends Save DCD and prints the sensor's answer, otherwise it
+// skips the save so a poor calibration never replaces a good one. Use it
+// as it is on a board that stays powered, or copy the save logic into a
+// program of your own.
+//
+// The status LED blinks yellow while the calibration is below minAccuracy
+// and turns green once it is good. Shaking the board three times within
+// two seconds clears the calibration learnt so far and resets the sensor
+// to learn it again, for when it has settled on a poor one.
+//
+
+// Runs over raw SHTP, to see the accuracy of every report and the answer
+// to the command. Uses I2C by default, or SPI when built with
+// "-tags bno08x_spi".
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sh2cmd"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Interval between save points
+	saveInterval = 5 * time.Minute
+	// Lowest accuracy, 0 to 3, of every sensor for a save
+	minAccuracy = 3
+	// Report interval of the calibrated sensors, in microseconds
+	interval = sensorinfo.Interval10Hz
+	// Interval between accuracy lines
+	statusInterval = 30 * time.Second
+	// Longest to wait for the answer to Save DCD
+	responseTimeout = time.Second
+)
+
+// Sensors whose accuracy decides a save
+var calibrated = []bno08x.SensorID{
+	bno08x.SensorAccelerometer,
+	bno08x.SensorGyroscope,
+	bno08x.SensorMagneticField,
+}
+
+// accuracies holds the accuracy of the latest report of each sensor.
+type accuracies struct {
+	accuracy [4]uint8 // By sensor ID; only 1 to 3 are used
+	reported [4]bool
+	shakes   int // Shake detector reports
+}
+
+// input records the accuracy of the reports in an input packet.
+func (a *accuracies) input(packet shtpraw.Packet) {
+	if packet.Channel != shtpraw.ChannelInputNormal && packet.Channel != shtpraw.ChannelInputWake {
+		return
+	}
+	shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+		switch {
+		case int(r.ID) < len(a.accuracy):
+			a.accuracy[r.ID], a.reported[r.ID] = r.Accuracy(), true
+		case r.ID == uint8(bno08x.SensorShakeDetector):
+			a.shakes++
+		}
+	})
+}
+
+// lowest returns the lowest accuracy of the calibrated sensors, 0 until
+// all have reported.
+func (a *accuracies) lowest() uint8 {
+	lowest := uint8(minAccuracy)
+	for _, id := range calibrated {
+		if !a.reported[id] {
+			return 0
+		}
+		lowest = min(lowest, a.accuracy[id])
+	}
+	return lowest
+}
+
+// good reports whether every calibrated sensor is at minAccuracy or above.
+func (a *accuracies) good() bool {
+	for _, id := range calibrated {
+		if !a.reported[id] || a.accuracy[id] < minAccuracy {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *accuracies) String() string {
+	line := ""
+	for i, id := range calibrated {
+		if i > 0 {
+			line += " | "
+		}
+		line += sensorinfo.Name(id) + " "
+		if a.reported[id] {
+			line += fmtutil.Int(int(a.accuracy[id]))
+		} else {
+			line += "-"
+		}
+	}
+	return line
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("dcd_autosave")
+	led := statusled.New()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+	if err := enable(link); err != nil {
+		led.Halt(statusled.BusError)
+	}
+	led.Set(statusled.Calibrating)
+
+	println("Saving the calibration every", int(saveInterval/time.Minute), "minutes while every accuracy is", minAccuracy)
+	println("Shake the board", gesture.DefaultShakes, "times to clear the calibration and start again")
+
+	var acc accuracies
+	var shakes gesture.ShakeCount
+	var seq uint8
+	saves := 0
+	start := time.Now()
+	lastSave, lastStatus := start, start
+	var req [sh2cmd.RequestLen]byte
+
+	for {
+		packet, err := transport.Next(link, 20*time.Millisecond)
+		switch err {
+		case nil:
+			seen := acc.shakes
+			acc.input(packet)
+			led.Set(statusled.ForAccuracy(acc.lowest()))
+			if acc.shakes > seen && shakes.Shake(time.Now()) {
+				println(fmtutil.Elapsed(time.Since(start)), "Triple shake: clearing the calibration and resetting the sensor")
+				seq++
+				if err := link.Write(shtpraw.ChannelControl, sh2cmd.AppendClearDCDReset(req[:0], seq)); err != nil {
+					println("Clear DCD and Reset failed:", err.Error())
+				}
+				transport.Drain(link)
+				acc = accuracies{}
+				if err := enable(link); err != nil {
+					led.Set(statusled.BusError)
+				} else {
+					led.Set(statusled.Calibrating)
+				}
+			}
+		case shtpraw.ErrNoData:
+		default:
+			led.Set(statusled.BusError)
+		}
+		now := time.Now()
+		led.Update(now)
+
+		if now.Sub(lastStatus) >= statusInterval {
+			lastStatus = now
+			println(fmtutil.Elapsed(now.Sub(start)), "Accuracy:", acc.String())
+		}
+
+		if now.Sub(lastSave) < saveInterval {
+			continue
+		}
+		lastSave = now
+		if !acc.good() {
+			println(fmtutil.Elapsed(now.Sub(start)), "Save skipped, accuracy too low:", acc.String())
+			continue
+		}
+		seq++
+		switch err := sh2cmd.SaveDCD(link, seq, responseTimeout, acc.input); err {
+		case nil:
+			saves++
+			println(fmtutil.Elapsed(now.Sub(start)), "Calibration saved, sensor answered OK (save", saves, "this run)")
+		case sh2cmd.ErrTimeout:
+			println(fmtutil.Elapsed(now.Sub(start)), "No answer to Save DCD within", int(responseTimeout/time.Millisecond), "ms")
+		default:
+			println(fmtutil.Elapsed(now.Sub(start)), "Save DCD failed:", err.Error())
+		}
+	}
+}
+
+// enable turns on the calibrated sensors and the shake detector.
+func enable(link transport.Transport) error {
+	var cmd [shtpraw.FeatureLen]byte
+	on := func(id bno08x.SensorID) error {
+		f := shtpraw.Feature{Sensor: uint8(id), Interval: interval}
+		err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], f))
+		if err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+		}
+		return err
+	}
+	for _, id := range calibrated {
+		if err := on(id); err != nil {
+			return err
+		}
+	}
+	return on(bno08x.SensorShakeDetector)
+}
@@ -0,0 +1,190 @@
+// Package main estimates position by dead reckoning: linear acceleration is
+// rotated into the world frame with the rotation vector and integrated
+// twice, to velocity and then to position.
+//
+// This is a worked example of why dead reckoning from a consumer IMU alone
+// does not give a usable position for more than a few seconds. Any error in
+// the acceleration, a bias of a few mg or a degree of tilt error leaking
+// gravity into the horizontal axes, integrates into a velocity error that
+// grows linearly and a position error that grows with the square of time:
+// 10mg held for 10 seconds is already 5 metres. Nothing here measures
+// position directly, so nothing pulls the error back.
+//
+// Zero-velocity updates (ZUPT) limit the damage. Whenever the stability
+// classifier reports the sensor at rest the velocity must be zero, so it is
+// reset; the velocity left over at that moment is the integrated error of
+// the movement just ended. Assuming that error came from a constant bias,
+// the position error it caused is half the residual velocity times the
+// time moving, and the sum of those is printed as the drift estimate. It is
+// an estimate, not a bound: errors that cancel out over a movement are not
+// seen. Position is only trustworthy for short movements between rests,
+// such as a foot on each step or a tool moved and set down.
+//
+// The world frame is X east, Y north and Z up, as the rotation vector uses
+// the magnetometer for heading.
+//
+//	reset   zero the position and the drift estimate
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Rotation vector and linear acceleration report rate
+	rateHz = 100
+	// Interval between position readings
+	printInterval = 500 * time.Millisecond
+)
+
+// Stability classifier values counted as at rest
+const (
+	stabilityOnTable    = 1
+	stabilityStationary = 2
+)
+
+// Integration time step; the reports' nominal interval is steadier than
+// timing their arrival, which is bunched by the I2C polling
+const dt = float32(1) / rateHz
+
+// tracker integrates world-frame acceleration into velocity and position.
+type tracker struct {
+	vel, pos [3]float32 // m/s and m, world frame
+	moving   float32    // Time since the last zero-velocity update, s
+	drift    float32    // Estimated position error of the movements so far, m
+	bias     float32    // Acceleration bias estimated at the last update, m/s²
+}
+
+// step integrates one world-frame acceleration sample.
+func (t *tracker) step(a [3]float32) {
+	for i := range a {
+		// Trapezoidal position step from the old and new velocity
+		v := t.vel[i] + a[i]*dt
+		t.pos[i] += (t.vel[i] + v) / 2 * dt
+		t.vel[i] = v
+	}
+	t.moving += dt
+}
+
+// zeroVelocity applies a zero-velocity update: the sensor is at rest, so
+// whatever velocity was integrated is error.
+func (t *tracker) zeroVelocity() {
+	if t.moving == 0 {
+		return
+	}
+	residual := length(t.vel)
+	// A constant bias b over time T leaves b·T of velocity and b·T²/2 of
+	// position error
+	t.drift += residual * t.moving / 2
+	t.bias = residual / t.moving
+	t.vel = [3]float32{}
+	t.moving = 0
+}
+
+// uncertainty returns the estimated position error now: the drift of past
+// movements plus what the last estimated bias adds over the current one.
+func (t *tracker) uncertainty() float32 {
+	return t.drift + t.bias*t.moving*t.moving/2
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("deadreckon")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, r := range []struct {
+		id       bno08x.SensorID
+		interval uint32
+	}{
+		{bno08x.SensorRotationVector, 1000000 / rateHz},
+		{bno08x.SensorLinearAcceleration, 1000000 / rateHz},
+		{bno08x.SensorStabilityClassifier, sensorinfo.Interval10Hz},
+	} {
+		if err := sensor.EnableReport(r.id, r.interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(r.id)+":", err.Error())
+			return
+		}
+	}
+
+	var t tracker
+	var sh shell.Shell
+	sh.Register("reset", "reset", "Zero the position and the drift estimate", func(args []string) error {
+		t = tracker{}
+		println("Position reset")
+		return nil
+	})
+
+	println("Format: X Y Z (m, east north up) | speed (m/s) | drift estimate (m) | state")
+	println("Dead reckoning drifts; see the program's documentation. Type 'help' for commands")
+
+	orientation := quat.Identity
+	haveOrientation := false
+	still := false
+	lastPrint := time.Now()
+
+	for {
+		sh.Poll()
+
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		switch event.ID() {
+		case bno08x.SensorRotationVector:
+			orientation, haveOrientation = event.Quaternion(), true
+		case bno08x.SensorStabilityClassifier:
+			c := event.StabilityClassifier().Classification
+			still = c == stabilityOnTable || c == stabilityStationary
+			if still {
+				t.zeroVelocity()
+			}
+		case bno08x.SensorLinearAcceleration:
+			if !haveOrientation || still {
+				break
+			}
+			a := event.LinearAcceleration()
+			x, y, z := quat.Rotate(orientation, a.X, a.Y, a.Z)
+			t.step([3]float32{x, y, z})
+		}
+
+		if time.Since(lastPrint) >= printInterval {
+			lastPrint = time.Now()
+			state := "moving"
+			if still {
+				state = "still"
+			}
+			println(fmtutil.FloatWidth(t.pos[0], 2, 8), fmtutil.FloatWidth(t.pos[1], 2, 8), fmtutil.FloatWidth(t.pos[2], 2, 8),
+				"|", fmtutil.FloatWidth(length(t.vel), 2, 6), "| ±"+fmtutil.Float(t.uncertainty(), 2), "|", state)
+		}
+	}
+}
+
+// length returns the magnitude of v.
+func length(v [3]float32) float32 {
+	return float32(math.Sqrt(float64(v[0]*v[0] + v[1]*v[1] + v[2]*v[2])))
+}
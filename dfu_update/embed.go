@@ -0,0 +1,72 @@
+//go:build dfu_embed
+
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"hash/crc32"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+)
+
+// The firmware image, copied to dfu_update/firmware.bin before building
+//
+//go:embed firmware.bin
+var image []byte
+
+var errEmptyImage = errors.New("firmware.bin is empty")
+
+// embedSource sends the image built into the program, once the user
+// confirms by typing "update".
+type embedSource struct {
+	lines  shell.LineReader
+	offset int
+}
+
+func newSource() source {
+	return &embedSource{}
+}
+
+func (s *embedSource) open() (uint32, error) {
+	println("Embedded image:", len(image), "bytes, CRC-32", hex32(crc32.ChecksumIEEE(image)))
+	println("Type 'update' to write it to the sensor")
+	for {
+		if line, ok := s.lines.Poll(); ok && line == "update" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(image) == 0 {
+		return 0, errEmptyImage
+	}
+	s.offset = 0
+	return uint32(len(image)), nil
+}
+
+func (s *embedSource) next(p []byte) error {
+	s.offset += copy(p, image[s.offset:])
+	return nil
+}
+
+// verify has nothing to check: the bytes sent are the image itself.
+func (s *embedSource) verify(crc uint32) error {
+	return nil
+}
+
+func (s *embedSource) finish(err error) {
+	if err == nil {
+		println("Update complete")
+	}
+}
+
+func hex32(v uint32) string {
+	const digits = "0123456789abcdef"
+	var b [8]byte
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = digits[v&0x0F]
+		v >>= 4
+	}
+	return string(b[:])
+}
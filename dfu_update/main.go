@@ -0,0 +1,202 @@
+// Package main replaces the BNO08x's application firmware through its
+// bootloader, for parts stuck on a release with known bugs. It holds
+// BOOTN low through a reset to start the bootloader, streams the image to
+// it a packet at a time with progress, then restarts the sensor and reads
+// back the product ID to show the new version running.
+//
+// The image is the application binary of a firmware release. By default
+// it is received over USB serial from the host:
+//
+//	go run ./cmd/flashtool dfu -port /dev/ttyACM0 firmware.bin
+//
+// or, built with "-tags dfu_embed", it is embedded from
+// dfu_update/firmware.bin and sent after typing "update". Either way an
+// interrupted update leaves the sensor in its bootloader; run the update
+// again to recover.
+//
+// The bootloader only speaks I2C. Wire BOOTN to board.BootPin() and RST
+// to board.ResetPin(), or hold BOOTN low and reset the sensor by hand
+// when asked.
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dfu"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+)
+
+const (
+	// Image packet length; the bootloader takes up to dfu.MaxPacketLen
+	packetLen = dfu.MaxPacketLen
+	// Time BOOTN is held low after RST is released
+	bootHold = 100 * time.Millisecond
+	// Longest to wait for the new application to answer after the update
+	appTimeout = 3 * time.Second
+)
+
+var errNoBootloader = errors.New("no bootloader at I2C address 0x28")
+
+// source supplies the firmware image a packet at a time.
+type source interface {
+	// open waits until an image is ready and returns its size.
+	open() (uint32, error)
+	// next fills p with the next bytes of the image.
+	next(p []byte) error
+	// verify checks the image read against what the source expected.
+	verify(crc uint32) error
+	// finish reports the outcome to whoever sent the image.
+	finish(err error)
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("dfu_update")
+	println("=== BNO08x Firmware Update ===")
+	println()
+
+	bus := board.IMUBus()
+	if err := bus.Configure(machine.I2CConfig{Frequency: 400 * machine.KHz}); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	src := newSource()
+	for {
+		err := update(bus, src)
+		src.finish(err)
+		if err != nil {
+			println("Update failed:", err.Error())
+			println("The sensor may be left in its bootloader; run the update again")
+		}
+		println()
+	}
+}
+
+// update runs one firmware update from src.
+func update(bus *machine.I2C, src source) error {
+	size, err := src.open()
+	if err != nil {
+		return err
+	}
+	println("Image:", size, "bytes")
+
+	if err := enterBootloader(bus); err != nil {
+		return err
+	}
+	println("Bootloader answered; sending", (size+packetLen-1)/packetLen, "packets")
+
+	u := dfu.Updater{Bus: bus}
+	if err := u.Start(size, packetLen); err != nil {
+		return err
+	}
+	var buf [packetLen]byte
+	crc := uint32(0)
+	start := time.Now()
+	lastPercent := -1
+	for !u.Done() {
+		p := buf[:min(packetLen, int(size-u.Sent()))]
+		if err := src.next(p); err != nil {
+			return err
+		}
+		crc = crc32.Update(crc, crc32.IEEETable, p)
+		if err := u.Write(p); err != nil {
+			return errors.New("packet at " + fmtutil.Int(int(u.Sent())) + ": " + err.Error())
+		}
+		if percent := int(u.Sent() * 100 / size); percent/10 != lastPercent/10 {
+			lastPercent = percent
+			println(fmtutil.PadLeft(fmtutil.Int(percent), 3)+"%", u.Sent(), "of", size, "bytes,",
+				fmtutil.Elapsed(time.Since(start)))
+		}
+	}
+	if err := src.verify(crc); err != nil {
+		return err
+	}
+
+	println("Image stored; starting the new firmware...")
+	part, version, err := restart(bus)
+	if err != nil {
+		return errors.New("new firmware did not start: " + err.Error())
+	}
+	println("Running software part", part, "version", version)
+	return nil
+}
+
+// enterBootloader resets the sensor with BOOTN low, or asks for it to be
+// done by hand, and checks that the bootloader answers.
+func enterBootloader(bus *machine.I2C) error {
+	boot, rst := board.BootPin(), board.ResetPin()
+	if boot == machine.NoPin || rst == machine.NoPin {
+		println("No BOOTN or RST pin set for the " + board.Name + " board in internal/board:")
+		println("hold BOOTN low, reset the sensor, then release BOOTN")
+		for start := time.Now(); time.Since(start) < 30*time.Second; {
+			if bootloaderPresent(bus) {
+				return nil
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		return errNoBootloader
+	}
+
+	boot.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	rst.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	boot.Low()
+	rst.Low()
+	time.Sleep(10 * time.Millisecond)
+	rst.High()
+	time.Sleep(bootHold)
+	boot.High()
+	time.Sleep(100 * time.Millisecond)
+	if !bootloaderPresent(bus) {
+		return errNoBootloader
+	}
+	return nil
+}
+
+// bootloaderPresent reports whether anything answers the bootloader's
+// address.
+func bootloaderPresent(bus *machine.I2C) bool {
+	var b [1]byte
+	return bus.Tx(dfu.Address, nil, b[:]) == nil
+}
+
+// restart resets the sensor into its application and reads the product
+// ID of the firmware now running.
+func restart(bus *machine.I2C) (uint32, string, error) {
+	link := transport.NewI2C(bus, shtpraw.DefaultAddress, board.IntPin(), board.ResetPin())
+	if board.ResetPin() == machine.NoPin {
+		// The bootloader takes no commands, so there is no soft reset
+		println("Reset the sensor by hand to start the new firmware")
+		if _, err := transport.Next(link, 30*time.Second); err != nil {
+			return 0, "", err
+		}
+		transport.Drain(link)
+	} else if err := transport.ResetAndDrain(link); err != nil {
+		return 0, "", err
+	}
+	if err := link.Write(shtpraw.ChannelControl, []byte{0xF9, 0x00}); err != nil {
+		return 0, "", err
+	}
+	start := time.Now()
+	for time.Since(start) < appTimeout {
+		packet, err := transport.Next(link, appTimeout-time.Since(start))
+		if err != nil || packet.Channel != shtpraw.ChannelControl {
+			continue
+		}
+		cargo := packet.Cargo()
+		if len(cargo) >= 16 && cargo[0] == 0xF8 {
+			version := fmtutil.Int(int(cargo[2])) + "." + fmtutil.Int(int(cargo[3])) + "." +
+				fmtutil.Int(int(binary.LittleEndian.Uint16(cargo[12:14])))
+			return binary.LittleEndian.Uint32(cargo[4:8]), version, nil
+		}
+	}
+	return 0, "", errors.New("no product ID")
+}
@@ -0,0 +1,97 @@
+//go:build !dfu_embed
+
+package main
+
+import (
+	"errors"
+	"machine"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+)
+
+// Longest to wait for the host to send a requested packet
+const chunkTimeout = 5 * time.Second
+
+var (
+	errHostTimeout = errors.New("host stopped sending the image")
+	errTransfer    = errors.New("image corrupted on the way from the host")
+)
+
+// serialSource receives the image from the host over USB serial. The host
+// sends "size <bytes> <crc32 hex>" on a line; the program then asks for
+// each packet with "NEXT <offset> <length>", and the host answers with
+// exactly that many raw bytes. The outcome ends with "DONE" or
+// "FAIL <reason>".
+type serialSource struct {
+	lines  shell.LineReader
+	offset uint32
+	crc    uint32
+}
+
+func newSource() source {
+	return &serialSource{}
+}
+
+func (s *serialSource) open() (uint32, error) {
+	println("Waiting for an image: run 'go run ./cmd/flashtool dfu -port <port> <image>' on the host")
+	for {
+		line, ok := s.lines.Poll()
+		if !ok {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "size" {
+			println("FAIL expected 'size <bytes> <crc32>'")
+			continue
+		}
+		size, err1 := strconv.ParseUint(fields[1], 10, 32)
+		crc, err2 := strconv.ParseUint(fields[2], 16, 32)
+		if err1 != nil || err2 != nil || size == 0 {
+			println("FAIL bad size line")
+			continue
+		}
+		s.offset, s.crc = 0, uint32(crc)
+		return uint32(size), nil
+	}
+}
+
+func (s *serialSource) next(p []byte) error {
+	println("NEXT", s.offset, len(p))
+	start := time.Now()
+	for n := 0; n < len(p); {
+		if machine.Serial.Buffered() == 0 {
+			if time.Since(start) > chunkTimeout {
+				return errHostTimeout
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		c, err := machine.Serial.ReadByte()
+		if err != nil {
+			continue
+		}
+		p[n] = c
+		n++
+	}
+	s.offset += uint32(len(p))
+	return nil
+}
+
+func (s *serialSource) verify(crc uint32) error {
+	if crc != s.crc {
+		return errTransfer
+	}
+	return nil
+}
+
+func (s *serialSource) finish(err error) {
+	if err != nil {
+		println("FAIL", err.Error())
+		return
+	}
+	println("DONE")
+}
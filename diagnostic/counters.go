@@ -0,0 +1,110 @@
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sh2cmd"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Reports the hub may have sent but the host not yet read when the
+	// counters are taken, without counting as lost
+	counterSlack = 2
+	// Longest to wait for the answer to Get Counts
+	counterTimeout = time.Second
+)
+
+// counterResult compares the hub's counters for one sensor with the
+// events the host received while they ran.
+type counterResult struct {
+	Sensor   bno08x.SensorID
+	Counts   sh2cmd.Counts
+	Received int
+}
+
+// lost returns how many reports the hub sent that the host never saw.
+func (r counterResult) lost() int {
+	return int(r.Counts.Attempted) - r.Received
+}
+
+// counterSeq numbers the Counter commands sent.
+var counterSeq uint8
+
+// clearCounters zeroes the hub's counters for ids, so a later
+// readCounters covers only what follows.
+func clearCounters(link transport.Transport, ids []bno08x.SensorID) error {
+	var req [sh2cmd.RequestLen]byte
+	for _, id := range ids {
+		counterSeq++
+		if err := link.Write(shtpraw.ChannelControl, sh2cmd.AppendClearCounts(req[:0], counterSeq, uint8(id))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCounters gets the hub's counters for ids and compares them with
+// received, the events of each the host got since clearCounters. Reports
+// that arrive meanwhile are added to received. It prints where samples
+// stopped and reports whether the host saw every report the hub sent.
+func readCounters(link transport.Transport, ids []bno08x.SensorID, received []int) ([]counterResult, bool) {
+	late := func(packet shtpraw.Packet) {
+		if packet.Channel != shtpraw.ChannelInputNormal && packet.Channel != shtpraw.ChannelInputWake {
+			return
+		}
+		shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+			for i, id := range ids {
+				if bno08x.SensorID(r.ID) == id {
+					received[i]++
+				}
+			}
+		})
+	}
+
+	var results []counterResult
+	ok := true
+	println("  " + fmtutil.PadRight("Sensor", 26) + "  Offered  Accepted        On  Attempted  Received")
+	for i, id := range ids {
+		counterSeq++
+		counts, err := sh2cmd.GetCounts(link, counterSeq, uint8(id), counterTimeout, late)
+		if err != nil {
+			println("  " + fmtutil.PadRight(sensorinfo.Name(id), 26) + "  Get Counts failed: " + err.Error())
+			ok = false
+			continue
+		}
+		results = append(results, counterResult{Sensor: id, Counts: counts, Received: received[i]})
+		println("  " + fmtutil.PadRight(sensorinfo.Name(id), 26) +
+			fmtutil.PadLeft(fmtutil.Int(int(counts.Offered)), 9) +
+			fmtutil.PadLeft(fmtutil.Int(int(counts.Accepted)), 10) +
+			fmtutil.PadLeft(fmtutil.Int(int(counts.On)), 10) +
+			fmtutil.PadLeft(fmtutil.Int(int(counts.Attempted)), 11) +
+			fmtutil.PadLeft(fmtutil.Int(received[i]), 10))
+	}
+
+	for _, r := range results {
+		name := sensorinfo.Name(r.Sensor)
+		switch {
+		case r.Counts.Offered == 0:
+			println("  " + name + ": no samples from the sensing element; the sensor itself is not running")
+			ok = false
+		case r.Counts.On == 0:
+			println("  " + name + ": samples were made but none while the report was on; it is not enabled")
+			ok = false
+		case r.Counts.Attempted == 0:
+			println("  " + name + ": the hub sent no reports; they stop inside the hub")
+			ok = false
+		case r.lost() > counterSlack:
+			println("  "+name+":", r.lost(), "of", r.Counts.Attempted, "reports sent were never received;",
+				"they are lost on the bus or read too slowly by the host")
+			ok = false
+		default:
+			println("  " + name + ": every report the hub sent was received")
+		}
+	}
+	return results, ok
+}
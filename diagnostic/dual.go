@@ -0,0 +1,91 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// How long both sensors stream together in the dual-sensor check
+const dualWindow = 10 * time.Second
+
+// dualSensor is one of two BNO08x parts sharing the bus.
+type dualSensor struct {
+	addr     uint16
+	device   *bno08x.Device
+	events   int
+	interval int // Events since the last stats line
+	errors   int
+}
+
+// checkDualSensors initializes a BNO08x at each of addrs, prints their
+// product IDs and streams the game rotation vector from both at once,
+// printing interleaved per-device rates every second. It returns whether
+// both sensors streamed.
+func checkDualSensors(i2c *machine.I2C, addrs []uint16) bool {
+	sensors := make([]dualSensor, len(addrs))
+	for i, addr := range addrs {
+		s := &sensors[i]
+		s.addr = addr
+		s.device = bno08x.NewI2C(i2c)
+		println("  Initializing 0x" + formatHex(uint8(addr)) + "...")
+		err := s.device.Configure(bno08x.Config{Address: addr, StartupDelay: 200 * time.Millisecond})
+		if err != nil {
+			println("    FAILED:", err.Error())
+			return false
+		}
+		ids := s.device.ProductIDs()
+		if ids.NumEntries > 0 {
+			id := ids.Entries[0]
+			println("    Part", id.PartNumber, "version", id.VersionMajor, ".", id.VersionMinor, ".", id.VersionPatch, "build", id.BuildNumber)
+		}
+		if err := s.device.EnableReport(bno08x.SensorGameRotationVector, 100000); err != nil { // 10 Hz
+			println("    FAILED to enable report:", err.Error())
+			return false
+		}
+	}
+
+	println("  Streaming from both for", int(dualWindow.Seconds()), "seconds...")
+	start := time.Now()
+	lastPrint := start
+	for time.Since(start) < dualWindow {
+		for i := range sensors {
+			s := &sensors[i]
+			if err := s.device.Service(); err != nil {
+				s.errors++
+			}
+			if event, ok := s.device.GetSensorEvent(); ok && event.ID() == bno08x.SensorGameRotationVector {
+				s.events++
+				s.interval++
+			}
+		}
+		if time.Since(lastPrint) >= time.Second {
+			seconds := float32(time.Since(lastPrint).Seconds())
+			lastPrint = time.Now()
+			line := "   "
+			for i := range sensors {
+				s := &sensors[i]
+				line += " 0x" + formatHex(uint8(s.addr)) + ": " + fmtutil.Float(float32(s.interval)/seconds, 1) + "/s"
+				s.interval = 0
+			}
+			println(line)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ok := true
+	for _, s := range sensors {
+		println("  0x"+formatHex(uint8(s.addr))+":", s.events, "events,", s.errors, "errors")
+		if s.events == 0 {
+			ok = false
+		}
+	}
+	if ok {
+		println("  SUCCESS: Both sensors stream simultaneously")
+	} else {
+		println("  FAILED: Not every sensor streamed; check the DI/SA0 strapping and INT wiring of each")
+	}
+	return ok
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/intermernet/bno08xPrograms/internal/firmware"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// checkFirmware compares the application firmware, the first product ID
+// entry, against the known firmware table and lists the features it is
+// not known to support. It returns a warning per problem found.
+func checkFirmware(ids bno08x.ProductIDs) []string {
+	if ids.NumEntries == 0 {
+		return nil
+	}
+	id := ids.Entries[0]
+	v := firmware.FromProductID(id)
+	release := firmware.Lookup(id.PartNumber, v)
+
+	var warnings []string
+	name := "Firmware " + fmtutil.Int(int(id.PartNumber)) + " " + v.String()
+	switch release.Status {
+	case firmware.Good:
+		println("  " + name + " is known good")
+	case firmware.Unknown:
+		println("  " + name + " is not in the known firmware table")
+	default:
+		warnings = append(warnings, name+": "+release.Note)
+	}
+	for _, f := range firmware.Features {
+		if !firmware.Supports(v, f) {
+			warnings = append(warnings, f.String()+" needs firmware "+firmware.MinFirmware(f).String())
+		}
+	}
+	for _, w := range warnings {
+		println("  WARNING:", w)
+	}
+	return warnings
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"machine"
+	"sync/atomic"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// intEdges counts falling edges on the INT pin; written from the interrupt
+// handler.
+var intEdges uint32
+
+// checkIntPin counts falling edges on the INT pin while reading events for
+// the given window. The BNO08x pulls INT low whenever it has a packet
+// waiting and releases it once the packet is read, so with reports enabled
+// there should be roughly one edge per packet. It returns the edge count
+// and whether the pin behaved as expected.
+func checkIntPin(sensor *bno08x.Device, pin machine.Pin, window time.Duration) (uint32, bool) {
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	atomic.StoreUint32(&intEdges, 0)
+	err := pin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		atomic.AddUint32(&intEdges, 1)
+	})
+	if err != nil {
+		println("  Could not enable pin interrupt:", err.Error())
+		return 0, false
+	}
+	defer pin.SetInterrupt(0, nil)
+
+	events := 0
+	start := time.Now()
+	for time.Since(start) < window {
+		if _, ok := sensor.GetSensorEvent(); ok {
+			events++
+		}
+		time.Sleep(time.Millisecond)
+	}
+	edges := atomic.LoadUint32(&intEdges)
+	rate := float32(edges) / float32(window.Seconds())
+
+	println("  INT edges:", edges, "("+fmtutil.Float(rate, 1), "per second)")
+	println("  Events read:", events)
+	switch {
+	case edges == 0 && events > 0:
+		println("  FAILED: Data arrives but INT never falls")
+		println("  Check the INT wire and that the pin matches board.IntPin()")
+		return edges, false
+	case edges == 0:
+		println("  FAILED: No INT edges and no data")
+		return edges, false
+	case !pin.Get() && events == 0:
+		println("  WARNING: INT is stuck low, the sensor may be waiting to be read")
+		return edges, false
+	}
+	println("  SUCCESS: Sensor is asserting data-ready")
+	return edges, true
+}
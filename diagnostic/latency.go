@@ -0,0 +1,100 @@
+package main
+
+import (
+	"machine"
+	"sync/atomic"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Latency samples collected
+	latencySamples = 1000
+	// Longest the latency measurement may run
+	latencyTimeout = 30 * time.Second
+	// Report interval during the measurement, so it finishes quickly
+	latencyInterval = 10000 // 100Hz
+)
+
+// Time of the last INT falling edge in microseconds since latencyStart,
+// plus one so zero means no edge is waiting; written from the interrupt
+// handler.
+var (
+	latencyStart time.Time
+	latencyEdge  uint32
+)
+
+// latencyResult summarises the INT to event latencies.
+type latencyResult struct {
+	samples       int
+	min, avg, max time.Duration
+}
+
+// measureLatency timestamps each INT falling edge in the interrupt handler
+// and the moment GetSensorEvent next returns a report, and summarises the
+// difference over latencySamples reports. This is the delay a closed-loop
+// controller sees on top of the sensor's own processing. The game rotation
+// vector runs at 100Hz during the measurement and is put back to interval
+// afterwards.
+func measureLatency(sensor *bno08x.Device, pin machine.Pin, interval uint32) (latencyResult, bool) {
+	var r latencyResult
+	if err := sensor.EnableReport(bno08x.SensorGameRotationVector, latencyInterval); err != nil {
+		println("  Could not raise report rate:", err.Error())
+		return r, false
+	}
+	defer sensor.EnableReport(bno08x.SensorGameRotationVector, interval)
+
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	latencyStart = time.Now()
+	atomic.StoreUint32(&latencyEdge, 0)
+	err := pin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		atomic.StoreUint32(&latencyEdge, uint32(time.Since(latencyStart).Microseconds())+1)
+	})
+	if err != nil {
+		println("  Could not enable pin interrupt:", err.Error())
+		return r, false
+	}
+	defer pin.SetInterrupt(0, nil)
+
+	var total time.Duration
+	start := time.Now()
+	for r.samples < latencySamples && time.Since(start) < latencyTimeout {
+		// Poll flat out; any sleep here would be measured as latency
+		if _, ok := sensor.GetSensorEvent(); !ok {
+			continue
+		}
+		now := uint32(time.Since(latencyStart).Microseconds()) + 1
+		edge := atomic.SwapUint32(&latencyEdge, 0)
+		if edge == 0 || edge > now {
+			// Several reports per packet: only the first follows an edge
+			continue
+		}
+		d := time.Duration(now-edge) * time.Microsecond
+		if r.samples == 0 || d < r.min {
+			r.min = d
+		}
+		if d > r.max {
+			r.max = d
+		}
+		total += d
+		r.samples++
+	}
+	if r.samples == 0 {
+		println("  FAILED: No reports followed an INT edge")
+		return r, false
+	}
+	r.avg = total / time.Duration(r.samples)
+
+	micros := func(d time.Duration) string {
+		return fmtutil.Int(int(d.Microseconds())) + "us"
+	}
+	println("  Samples:", r.samples)
+	println("  Latency min", micros(r.min), "avg", micros(r.avg), "max", micros(r.max))
+	if r.samples < latencySamples {
+		println("  WARNING: Timed out before", latencySamples, "samples")
+		return r, false
+	}
+	return r, true
+}
@@ -1,53 +1,129 @@
 // Package main provides a diagnostic tool to test BNO08x I2C connectivity
 // and help troubleshoot "operation timed out" errors.
+//
+// The last line printed is a JSON summary of every step, for host scripts
+// that need a machine-readable pass/fail.
+//
+// Build with "-tags bno08x_spi" to test a sensor strapped for SPI instead,
+// using SPI0 and the CS, INT, RST and WAKE pins from internal/board.
 package main
 
 import (
 	"machine"
 	"time"
 
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 	"tinygo.org/x/drivers/bno08x"
 )
 
+// Set to true to retry the init sequence at 100kHz, 400kHz and 1MHz, and
+// continue at the fastest speed that worked reliably. Useful with long
+// wires or weak pull-ups.
+const clockSweep = false
+
+// Set to true to follow Step 8 with a soak test: hours of polling with
+// event rates and error counts printed every minute. Typing "soak" during
+// Step 8 does the same.
+const soakTest = false
+
+// Set to true to measure the delivered rate and jitter of a few sensors at
+// 100Hz, 50Hz and 10Hz after Step 8, showing which rates the firmware
+// honors on this part. Takes about a minute and a half.
+const rateCheck = false
+
+// Set above zero to finish by hard power-cycling the sensor that many
+// times through a MOSFET on board.PowerPin(), timing how long each cycle
+// takes to deliver data again.
+const powerCycles = 0
+
 func main() {
 	time.Sleep(2 * time.Second)
+	buildinfo.Banner("diagnostic")
+
+	report := DiagnosticReport{
+		Board:     board.Name,
+		Revision:  buildinfo.Revision,
+		Transport: "i2c",
+	}
+	defer report.Print()
+
+	if spiMode {
+		report.Transport = "spi"
+		runSPIDiagnostic(&report)
+		return
+	}
 	println("=== BNO08x I2C Diagnostic Tool ===")
 	println()
 
 	// Initialize I2C bus
 	println("Step 1: Initializing I2C bus...")
-	i2c := machine.I2C0
+	i2c := board.IMUBus()
 	err := i2c.Configure(machine.I2CConfig{
 		Frequency: 400 * machine.KHz,
 	})
 	if err != nil {
 		println("FAILED: Could not configure I2C:", err.Error())
+		report.Failure = "bus_configure"
 		return
 	}
+	report.BusOK = true
+	report.BusFrequency = 400 * machine.KHz
 	println("SUCCESS: I2C configured at 400 KHz")
 	println()
 
-	// Test I2C connectivity
-	println("Step 2: Testing I2C connectivity...")
-	addresses := []uint16{0x4A, 0x4B}
-	foundAddress := uint16(0)
+	// Scan the whole bus, not just the BNO08x addresses, so address
+	// conflicts and sensors wired to the wrong bus show up
+	println("Step 2: Scanning I2C bus (0x08-0x77)...")
+	found := scanBus(i2c)
+	if len(found) == 0 && sdaStuck(i2c, report.BusFrequency) {
+		println("  SDA is held low: a device is stuck mid-transfer")
+		if recoverBus(i2c, report.BusFrequency) {
+			found = scanBus(i2c)
+		}
+		reportRecovery(&report, len(found) > 0)
+	}
+	report.Devices = found
+	printScanGrid(found)
+	println()
+	if len(found) > 0 {
+		println("  Found", len(found), "device(s):")
+		for _, addr := range found {
+			println("    0x"+formatHex(uint8(addr)), "-", guessDevice(uint8(addr)))
+		}
+	}
 
-	for _, addr := range addresses {
-		println("  Trying address 0x", formatHex(uint8(addr)), "...")
-		buf := make([]byte, 4)
-		err := i2c.Tx(addr, nil, buf)
-		if err == nil {
-			println("  FOUND: Device responds at 0x", formatHex(uint8(addr)))
+	foundAddress := uint16(0)
+	for _, addr := range []uint16{0x4A, 0x4B} {
+		if contains(found, addr) {
+			println("  FOUND: BNO08x candidate at 0x", formatHex(uint8(addr)))
 			foundAddress = addr
 			break
-		} else {
-			println("  No response:", err.Error())
 		}
 	}
 
 	if foundAddress == 0 {
+		println()
+		println("No BNO08x on I2C, checking for UART-RVC output instead...")
+		report.Failure = "no_sensor"
+		if checkRVC(2 * time.Second) {
+			report.RVC = true
+			println()
+			println("=== SENSOR IS IN UART-RVC MODE ===")
+			println("The BNO08x is strapped for UART-RVC and streaming valid frames.")
+			println("Use rvc_reader to read it, or strap PS0 and PS1 low for I2C.")
+			return
+		}
 		println()
 		println("ERROR: No BNO08x device found on I2C bus")
+		if len(found) > 0 {
+			println("Other devices answer, so the bus itself works. Check the BNO08x")
+			println("is wired to this bus (" + board.Name + " board preset) and that its")
+			println("PS0/PS1 pins select I2C mode.")
+		}
 		println("Troubleshooting:")
 		println("  1. Check wiring (SDA, SCL, VCC, GND)")
 		println("  2. Verify 3.3V power supply")
@@ -55,11 +131,46 @@ func main() {
 		println("  4. Try different I2C pins if available")
 		return
 	}
+	report.Address = foundAddress
+	println()
+
+	if clockSweep {
+		println("Step 2b: Sweeping I2C clock speed...")
+		best := sweepClock(i2c, foundAddress)
+		if best == 0 {
+			println("ERROR: No bus speed worked reliably")
+			println("  Check pull-ups (2.2K - 4.7K for long wires) and cable length")
+			report.Failure = "clock_sweep"
+			return
+		}
+		report.BusFrequency = best
+		println("  Continuing at", best/1000, "kHz")
+		println()
+	}
+
+	// A second sensor on the other address is easy to miss, since
+	// everything else here talks to the first one only
+	if contains(found, 0x4A) && contains(found, 0x4B) {
+		println("Step 2c: Checking both BNO08x sensors...")
+		report.Dual = resultOf(checkDualSensors(i2c, []uint16{0x4A, 0x4B}))
+		println()
+	}
+
+	// Pulse RST and time the boot; without a wired RST the advice below is
+	// all that can be offered
+	println("Step 3: Exercising reset pin...")
+	if rst := board.ResetPin(); rst == machine.NoPin {
+		println("  Skipped: no reset pin set for the " + board.Name + " board in internal/board")
+	} else {
+		booted, ok := checkResetPin(i2c, foundAddress, rst)
+		report.ResetPin = resultOf(ok)
+		report.ResetMillis = booted.Milliseconds()
+	}
 	println()
 
 	// Initialize sensor
-	println("Step 3: Initializing BNO08x sensor...")
-	sensor := bno08x.New(i2c)
+	println("Step 4: Initializing BNO08x sensor...")
+	sensor := bno08x.NewI2C(i2c)
 
 	config := bno08x.Config{
 		Address:      foundAddress,
@@ -68,22 +179,31 @@ func main() {
 
 	println("  Using extended startup delay (200ms)...")
 	err = sensor.Configure(config)
+	if err != nil && isBusError(err) {
+		println("  Bus error:", err.Error())
+		if recoverBus(i2c, report.BusFrequency) {
+			err = sensor.Configure(config)
+		}
+		reportRecovery(&report, err == nil)
+	}
 	if err != nil {
 		println("FAILED:", err.Error())
 		println()
 		println("Troubleshooting:")
 		println("  1. The sensor may need a hardware reset")
 		println("  2. Try connecting RST pin and add to config:")
-		println("     config.ResetPin = machine.D2  // or your chosen pin")
+		println("     config.ResetPin = board.ResetPin()  // see internal/board")
 		println("  3. Power cycle the sensor")
 		println("  4. Increase StartupDelay to 500ms or 1s")
+		report.Failure = "sensor_configure"
 		return
 	}
+	report.SensorOK = true
 	println("SUCCESS: Sensor initialized")
 	println()
 
 	// Get product IDs
-	println("Step 4: Reading product information...")
+	println("Step 5: Reading product information...")
 	ids := sensor.ProductIDs()
 	if ids.NumEntries > 0 {
 		id := ids.Entries[0]
@@ -93,23 +213,38 @@ func main() {
 	} else {
 		println("  No product IDs available")
 	}
+	for i := 0; i < int(ids.NumEntries); i++ {
+		id := ids.Entries[i]
+		report.ProductIDs = append(report.ProductIDs, productID{
+			Part:  uint32(id.PartNumber),
+			Major: uint32(id.VersionMajor),
+			Minor: uint32(id.VersionMinor),
+			Patch: uint32(id.VersionPatch),
+			Build: uint32(id.BuildNumber),
+		})
+	}
+	report.Warnings = checkFirmware(ids)
 	println()
 
 	// Enable a test sensor
-	println("Step 5: Enabling sensors...")
+	println("Step 6: Enabling sensors...")
 	println("  Enabling Game Rotation Vector at 10Hz...")
 	// Game rotation vector doesn't need magnetometer, often more reliable
 	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 100000) // 10 Hz
 	if err != nil {
 		println("FAILED:", err.Error())
+		report.Failure = "enable_report"
 		return
 	}
+	report.Reports = append(report.Reports, uint8(bno08x.SensorGameRotationVector))
 	println("  Enabling Raw Accelerometer at 10Hz...")
 	err = sensor.EnableReport(bno08x.SensorRawAccelerometer, 100000) // 10 Hz
 	if err != nil {
 		println("FAILED:", err.Error())
+		report.Failure = "enable_report"
 		return
 	}
+	report.Reports = append(report.Reports, uint8(bno08x.SensorRawAccelerometer))
 	println("SUCCESS: Sensors enabled")
 	println()
 
@@ -117,16 +252,51 @@ func main() {
 	println("Waiting for sensor to start producing data...")
 	time.Sleep(2 * time.Second)
 
+	// A missing INT line is a common cause of "no data" in interrupt-driven
+	// programs, even though polling still works
+	println("Step 7: Checking INT pin...")
+	if intPin := board.IntPin(); intPin == machine.NoPin {
+		println("  Skipped: no INT pin set for the " + board.Name + " board in internal/board")
+	} else {
+		edges, ok := checkIntPin(sensor, intPin, 5*time.Second)
+		report.IntPin = resultOf(ok)
+		report.IntEdges = edges
+		if ok {
+			println()
+			println("Step 7b: Measuring INT to event latency...")
+			latency, ok := measureLatency(sensor, intPin, 100000) // Back to 10Hz after
+			report.Latency = resultOf(ok)
+			report.LatencySamples = latency.samples
+			report.LatencyMicros = [3]int64{latency.min.Microseconds(), latency.avg.Microseconds(), latency.max.Microseconds()}
+		}
+	}
+	println()
+
+	// Zero the hub's counters for the Step 6 reports, to compare with what
+	// arrives in Step 8. Commands go over raw SHTP alongside the driver,
+	// which has no API for them
+	link := transport.NewI2C(i2c, foundAddress, machine.NoPin, machine.NoPin)
+	counted := []bno08x.SensorID{bno08x.SensorGameRotationVector, bno08x.SensorRawAccelerometer}
+	received := make([]int, len(counted))
+	countersCleared := clearCounters(link, counted) == nil
+
 	// Read a few samples
-	println("Step 6: Reading sensor data...")
-	println("(Polling for 10 seconds...)")
+	println("Step 8: Reading sensor data...")
+	println("(Polling for 10 seconds, type '" + soakCommand + "' to soak test afterwards...)")
 	successCount := 0
 	startTime := time.Now()
 	attempts := 0
 	serviceErrors := 0
+	busErrors := 0 // Consecutive
+	soak := soakTest
+	var commands shell.LineReader
 
 	for time.Since(startTime) < 10*time.Second {
 		attempts++
+		if line, ok := commands.Poll(); ok && line == soakCommand {
+			println("  Soak test queued")
+			soak = true
+		}
 
 		// Service the sensor to poll for data
 		err := sensor.Service()
@@ -136,26 +306,46 @@ func main() {
 				println("  Service error:", err.Error())
 			}
 		}
+		switch {
+		case err == nil:
+			busErrors = 0
+		case isBusError(err):
+			busErrors++
+		}
+
+		// Repeated timeouts usually mean a stuck bus; try once to free it
+		if busErrors >= recoveryThreshold && report.Recoveries == 0 {
+			println("  ", busErrors, "bus errors in a row")
+			restored := recoverBus(i2c, report.BusFrequency) && sensor.Service() == nil
+			reportRecovery(&report, restored)
+			busErrors = 0
+		}
 
 		// Try to get an event
 		event, ok := sensor.GetSensorEvent()
 		if ok {
 			if event.ID() == bno08x.SensorGameRotationVector {
 				successCount++
+				received[0]++
 				q := event.Quaternion()
 				println("  GRV Sample", successCount, ": Q =", q.Real, q.I, q.J, q.K)
 			} else if event.ID() == bno08x.SensorRawAccelerometer {
 				successCount++
+				received[1]++
 				a := event.RawAccelerometer()
 				println("  Raw Accel Sample", successCount, ": X=", a.X, "Y=", a.Y, "Z=", a.Z)
 			} else {
-				println("  Received unexpected sensor type:", uint8(event.ID()))
+				println("  Received unexpected sensor type:", uint8(event.ID()), sensorinfo.Name(event.ID()))
 			}
 		}
 
 		time.Sleep(10 * time.Millisecond)
 	}
 
+	report.Events = successCount
+	report.EventsPerSec = float32(successCount) / 10
+	report.ServiceErrors = serviceErrors
+
 	println()
 	println("Polling complete: Made", attempts, "attempts")
 	println()
@@ -168,6 +358,67 @@ func main() {
 		println("=== WARNING ===")
 		println("Sensor initialized but no data received")
 		println("This may indicate a sensor configuration issue")
+		report.Failure = "no_data"
+	}
+	println()
+
+	// Compare the hub's counters with the events received, to show whether
+	// missing data was never made, held in the hub or lost on the way
+	println("Step 8b: Comparing on-chip counters with events received...")
+	if !countersCleared {
+		println("  Skipped: could not clear the counters")
+	} else {
+		counters, ok := readCounters(link, counted, received)
+		report.Counters = resultOf(ok)
+		report.CounterResults = counters
+	}
+	println()
+
+	if rateCheck && successCount > 0 {
+		println("Step 8c: Measuring report rate accuracy...")
+		rates, ok := checkRates(sensor)
+		report.Rates = resultOf(ok)
+		report.RateResults = rates
+		// Put the Step 6 reports back for the steps that follow
+		sensor.EnableReport(bno08x.SensorGameRotationVector, 100000)
+		sensor.EnableReport(bno08x.SensorRawAccelerometer, 100000)
+		println()
+	}
+
+	if soak && successCount > 0 {
+		println("Step 9: Soak testing for", int(soakDuration.Hours()), "hours...")
+		stats := runSoak(sensor, soakDuration)
+		report.SoakMinutes = stats.minutes
+		report.SoakEvents = stats.events
+		report.SoakErrors = stats.serviceErrors
+		report.SoakBusErrors = stats.busErrors
+		report.SoakResets = stats.resets
+		report.SoakStalls = stats.stalls
+		if stats.resets > 0 || stats.stalls > 0 {
+			report.Failure = "soak"
+		}
+		println("Soak test complete:", stats.events, "events,", stats.serviceErrors, "errors,",
+			stats.resets, "resets,", stats.stalls, "stalls")
+		println()
+	}
+
+	if powerCycles > 0 && successCount > 0 {
+		println("Step 10: Power cycling the sensor", powerCycles, "times...")
+		if pin := board.PowerPin(); pin == machine.NoPin {
+			println("  Skipped: no power pin set for the " + board.Name + " board in internal/board")
+		} else {
+			stats := runPowerCycles(sensor, config, pin, powerCycles)
+			report.PowerCycles = stats.cycles
+			report.PowerFailures = stats.failures
+			report.PowerReadyMillis = [3]int64{stats.min.Milliseconds(), stats.avg.Milliseconds(), stats.max.Milliseconds()}
+			if stats.failures > 0 {
+				report.Failure = "power_cycle"
+			}
+			println("Power-cycle test complete:", stats.cycles-stats.failures, "of", stats.cycles, "cycles passed,",
+				"ready in", stats.min.Milliseconds(), "/", stats.avg.Milliseconds(), "/", stats.max.Milliseconds(),
+				"ms (min/avg/max)")
+		}
+		println()
 	}
 }
 
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Level on board.PowerPin() that switches the sensor on: true for an
+	// N-channel low-side MOSFET, false for a P-channel high-side one
+	powerActiveHigh = true
+	// How long the supply stays off, so the decoupling capacitors drain
+	powerOffTime = 500 * time.Millisecond
+	// Longest a cycle may take from power-on to the first sensor event
+	powerReadyTimeout = 3 * time.Second
+)
+
+// powerStats totals a power-cycle endurance run.
+type powerStats struct {
+	cycles, failures int
+	min, avg, max    time.Duration // Time to ready of the cycles that passed
+}
+
+// runPowerCycles hard power-cycles the sensor cycles times through the
+// MOSFET on pin. After each cycle it re-initializes the sensor with config,
+// enables the game rotation vector and times the first event, so marginal
+// supplies and boot problems show up as failures or slow cycles. The SDA
+// and SCL pull-ups can back-power the sensor through its I/O pins; if
+// cycles never fail even with the supply unplugged, power the pull-ups from
+// the switched rail too.
+func runPowerCycles(sensor *bno08x.Device, config bno08x.Config, pin machine.Pin, cycles int) powerStats {
+	var stats powerStats
+	pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	power := func(on bool) {
+		pin.Set(on == powerActiveHigh)
+	}
+
+	var total time.Duration
+	for i := 1; i <= cycles; i++ {
+		power(false)
+		time.Sleep(powerOffTime)
+		power(true)
+		start := time.Now()
+
+		ready, err := powerUp(sensor, config, start)
+		stats.cycles++
+		line := "  [" + fmtutil.PadLeft(fmtutil.Int(i), 4) + "/" + fmtutil.Int(cycles) + "]"
+		if err != "" {
+			stats.failures++
+			println(line, "FAILED:", err)
+			continue
+		}
+		if stats.cycles-stats.failures == 1 || ready < stats.min {
+			stats.min = ready
+		}
+		if ready > stats.max {
+			stats.max = ready
+		}
+		total += ready
+		println(line, "ready after", ready.Milliseconds(), "ms")
+	}
+	// Leave the sensor powered
+	power(true)
+
+	if passed := stats.cycles - stats.failures; passed > 0 {
+		stats.avg = total / time.Duration(passed)
+	}
+	return stats
+}
+
+// powerUp re-initializes the sensor after power-on at start and waits for
+// its first event. It returns the time to that event, or a description of
+// the step that failed.
+func powerUp(sensor *bno08x.Device, config bno08x.Config, start time.Time) (time.Duration, string) {
+	if err := sensor.Configure(config); err != nil {
+		return 0, "configure: " + err.Error()
+	}
+	if err := sensor.EnableReport(bno08x.SensorGameRotationVector, 10000); err != nil {
+		return 0, "enable report: " + err.Error()
+	}
+	for time.Since(start) < powerReadyTimeout {
+		if _, ok := sensor.GetSensorEvent(); ok {
+			return time.Since(start), ""
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return 0, "no event within " + fmtutil.Int(int(powerReadyTimeout.Milliseconds())) + "ms"
+}
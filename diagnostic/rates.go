@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// How long each sensor and interval is measured
+	rateWindow = 10 * time.Second
+	// Time after enabling a report before measuring, while it settles
+	rateSettle = 500 * time.Millisecond
+	// Largest difference between requested and achieved rate counted as
+	// honored, as a fraction of the requested rate
+	rateTolerance = 0.1
+)
+
+// Sensors and report intervals in microseconds measured by checkRates.
+var (
+	rateSensors = []bno08x.SensorID{
+		bno08x.SensorGameRotationVector,
+		bno08x.SensorAccelerometer,
+		bno08x.SensorGyroscope,
+	}
+	rateIntervals = []uint32{10000, 20000, 100000}
+)
+
+// rateResult is the delivered rate of one sensor at one interval.
+type rateResult struct {
+	Sensor   bno08x.SensorID
+	Interval uint32 // Requested, microseconds
+	Events   int
+	Hz       float32
+	Jitter   time.Duration // Standard deviation of the time between events
+}
+
+// honored reports whether the achieved rate is within rateTolerance of the
+// requested one.
+func (r rateResult) honored() bool {
+	want := 1e6 / float32(r.Interval)
+	return r.Events > 1 && r.Hz >= want*(1-rateTolerance) && r.Hz <= want*(1+rateTolerance)
+}
+
+// checkRates enables each of rateSensors alone at each of rateIntervals
+// and measures the rate and jitter of the reports that arrive, so users see
+// which rates the firmware really runs. Every other report is turned off
+// meanwhile; the caller re-enables what it needs afterwards.
+func checkRates(sensor *bno08x.Device) ([]rateResult, bool) {
+	for _, id := range []bno08x.SensorID{bno08x.SensorGameRotationVector, bno08x.SensorRawAccelerometer} {
+		sensor.EnableReport(id, 0)
+	}
+
+	var results []rateResult
+	ok := true
+	println("  Sensor                      Requested   Achieved    Jitter")
+	for _, id := range rateSensors {
+		for _, interval := range rateIntervals {
+			r, err := measureRate(sensor, id, interval)
+			if err != nil {
+				println("  Could not enable", sensorinfo.Name(id)+":", err.Error())
+				ok = false
+				continue
+			}
+			results = append(results, r)
+			verdict := "ok"
+			if !r.honored() {
+				verdict = "NOT HONORED"
+				ok = false
+			}
+			println("  "+fmtutil.PadRight(sensorinfo.Name(id), 28)+
+				fmtutil.FloatWidth(1e6/float32(interval), 1, 7)+"Hz"+
+				fmtutil.FloatWidth(r.Hz, 1, 9)+"Hz"+
+				fmtutil.PadLeft(fmtutil.Int(int(r.Jitter.Microseconds())), 8)+"us", verdict)
+		}
+	}
+	return results, ok
+}
+
+// measureRate enables sensor id at interval and times its reports over
+// rateWindow, polling without sleeping so the timestamps are as tight as
+// the bus allows. Reports delivered in one packet arrive together, which
+// shows up as jitter.
+func measureRate(sensor *bno08x.Device, id bno08x.SensorID, interval uint32) (rateResult, error) {
+	r := rateResult{Sensor: id, Interval: interval}
+	if err := sensor.EnableReport(id, interval); err != nil {
+		return r, err
+	}
+	defer sensor.EnableReport(id, 0)
+
+	// Let the new rate take effect and drop reports queued before it
+	settle := time.Now()
+	for time.Since(settle) < rateSettle {
+		sensor.GetSensorEvent()
+	}
+
+	var first, last time.Duration
+	var sum, sumSquares float64 // Microseconds between events
+	start := time.Now()
+	for time.Since(start) < rateWindow {
+		event, ok := sensor.GetSensorEvent()
+		if !ok || event.ID() != id {
+			continue
+		}
+		now := time.Since(start)
+		if r.Events == 0 {
+			first = now
+		} else {
+			d := float64((now - last).Microseconds())
+			sum += d
+			sumSquares += d * d
+		}
+		last = now
+		r.Events++
+	}
+	if r.Events < 2 {
+		return r, nil
+	}
+	n := float64(r.Events - 1)
+	r.Hz = float32(n / (last - first).Seconds())
+	mean := sum / n
+	r.Jitter = time.Duration(math.Sqrt(math.Max(sumSquares/n-mean*mean, 0))) * time.Microsecond
+	return r, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+)
+
+const (
+	// Consecutive bus errors in Step 8 that trigger a recovery
+	recoveryThreshold = 5
+	// Half period of the bit-banged clock, 100kHz
+	recoveryHalfClock = 5 * time.Microsecond
+)
+
+// sdaStuck reports whether SDA is held low with the bus idle, the sign of a
+// device stuck in the middle of a byte. Reading the pin takes it from the
+// I2C peripheral, so the bus is reconfigured afterwards.
+func sdaStuck(bus *machine.I2C, freq uint32) bool {
+	sda, scl := board.IMUPins()
+	if sda == machine.NoPin || scl == machine.NoPin {
+		return false
+	}
+	release(sda)
+	stuck := !sda.Get()
+	bus.Configure(machine.I2CConfig{Frequency: freq, SDA: sda, SCL: scl})
+	return stuck
+}
+
+// recoverBus frees a bus held by a device that was interrupted mid-byte,
+// typically by a reset of the host, and is still driving SDA low waiting
+// for clocks. It clocks SCL by hand until SDA is released, at most nine
+// times, sends a STOP and reconfigures the I2C peripheral. It returns
+// whether the bus ended up idle; callers check that communication works.
+func recoverBus(bus *machine.I2C, freq uint32) bool {
+	sda, scl := board.IMUPins()
+	if sda == machine.NoPin || scl == machine.NoPin {
+		println("  Skipped recovery: no SDA/SCL pins set for the " + board.Name + " board in internal/board")
+		return false
+	}
+	println("  Recovering I2C bus...")
+
+	// Open-drain by hand: released lines float high on the pull-ups
+	release(sda)
+	release(scl)
+	time.Sleep(recoveryHalfClock)
+	pulses := 0
+	for pulses < 9 && !sda.Get() {
+		driveLow(scl)
+		time.Sleep(recoveryHalfClock)
+		release(scl)
+		time.Sleep(recoveryHalfClock)
+		pulses++
+	}
+	idle := sda.Get()
+	if idle {
+		println("    SDA released after", pulses, "clock pulses")
+	} else {
+		println("    SDA still low after 9 clock pulses")
+	}
+
+	// START then STOP, SDA falling and rising while SCL is high, resets
+	// the bus state of every device
+	driveLow(sda)
+	time.Sleep(recoveryHalfClock)
+	release(scl)
+	time.Sleep(recoveryHalfClock)
+	release(sda)
+	time.Sleep(recoveryHalfClock)
+
+	if err := bus.Configure(machine.I2CConfig{Frequency: freq, SDA: sda, SCL: scl}); err != nil {
+		println("    FAILED: Could not reconfigure I2C:", err.Error())
+		return false
+	}
+	return idle
+}
+
+// reportRecovery records and prints whether a recovery restored
+// communication.
+func reportRecovery(report *DiagnosticReport, restored bool) {
+	report.Recoveries++
+	report.Recovery = resultOf(restored)
+	if restored {
+		println("  SUCCESS: Bus recovery restored communication")
+	} else {
+		println("  FAILED: Bus recovery did not restore communication")
+	}
+}
+
+func release(pin machine.Pin) {
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+}
+
+func driveLow(pin machine.Pin) {
+	pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	pin.Low()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/jsonout"
+)
+
+// checkResult is the outcome of an optional step.
+type checkResult uint8
+
+const (
+	checkSkipped checkResult = iota
+	checkPassed
+	checkFailed
+)
+
+func (c checkResult) String() string {
+	switch c {
+	case checkPassed:
+		return "pass"
+	case checkFailed:
+		return "fail"
+	}
+	return "skipped"
+}
+
+// resultOf converts a step's success flag to a checkResult.
+func resultOf(ok bool) checkResult {
+	if ok {
+		return checkPassed
+	}
+	return checkFailed
+}
+
+// productID is one product ID entry reported by the sensor.
+type productID struct {
+	Part                uint32
+	Major, Minor, Patch uint32
+	Build               uint32
+}
+
+// DiagnosticReport collects the outcome of every step so the run can be
+// summarised as one JSON line at the end, for host scripts in hardware CI
+// rigs. Failure names the step that stopped the run, or is empty if every
+// step ran.
+type DiagnosticReport struct {
+	Board            string
+	Revision         string
+	Transport        string
+	BusOK            bool
+	BusFrequency     uint32
+	Devices          []uint16
+	Recoveries       int
+	Recovery         checkResult // Outcome of the last bus recovery
+	Address          uint16
+	Dual             checkResult // Both 0x4A and 0x4B streaming together
+	RVC              bool
+	ResetPin         checkResult
+	ResetMillis      int64
+	SensorOK         bool
+	ProductIDs       []productID
+	Warnings         []string
+	Reports          []uint8
+	IntPin           checkResult
+	IntEdges         uint32
+	Latency          checkResult
+	LatencySamples   int
+	LatencyMicros    [3]int64    // Min, average and max
+	Counters         checkResult // Every report the hub sent was received
+	CounterResults   []counterResult
+	Rates            checkResult // Every measured rate within tolerance
+	RateResults      []rateResult
+	Events           int
+	EventsPerSec     float32
+	ServiceErrors    int
+	SoakMinutes      int
+	SoakEvents       uint32
+	SoakErrors       int
+	SoakBusErrors    int
+	SoakResets       int
+	SoakStalls       int
+	PowerCycles      int
+	PowerFailures    int
+	PowerReadyMillis [3]int64 // Min, average and max time to first event
+	Failure          string
+}
+
+// Passed reports whether every step ran and sensor data was received.
+func (r *DiagnosticReport) Passed() bool {
+	return r.Failure == "" && r.Events > 0
+}
+
+// Print writes the report as a single line of JSON.
+func (r *DiagnosticReport) Print() {
+	var w jsonout.Writer
+	w.Open()
+	w.String("board", r.Board)
+	w.String("revision", r.Revision)
+	w.String("transport", r.Transport)
+	w.Bool("pass", r.Passed())
+	w.String("failure", r.Failure)
+	w.Bool("bus_ok", r.BusOK)
+	w.Int("bus_hz", int64(r.BusFrequency))
+	w.Array("devices", len(r.Devices), func(i int) {
+		w.Number(int64(r.Devices[i]))
+	})
+	w.Int("address", int64(r.Address))
+	w.String("dual", r.Dual.String())
+	w.Int("recoveries", int64(r.Recoveries))
+	w.String("recovery", r.Recovery.String())
+	w.Bool("rvc", r.RVC)
+	w.String("reset_pin", r.ResetPin.String())
+	w.Int("reset_ms", r.ResetMillis)
+	w.Bool("sensor_ok", r.SensorOK)
+	w.Array("product_ids", len(r.ProductIDs), func(i int) {
+		id := r.ProductIDs[i]
+		w.Open()
+		w.Int("part", int64(id.Part))
+		w.String("version", fmtutil.Int(int(id.Major))+"."+fmtutil.Int(int(id.Minor))+"."+fmtutil.Int(int(id.Patch)))
+		w.Int("build", int64(id.Build))
+		w.Close()
+	})
+	w.Array("warnings", len(r.Warnings), func(i int) {
+		w.Quote(r.Warnings[i])
+	})
+	w.Array("reports", len(r.Reports), func(i int) {
+		w.Number(int64(r.Reports[i]))
+	})
+	w.String("int_pin", r.IntPin.String())
+	w.Int("int_edges", int64(r.IntEdges))
+	w.String("latency", r.Latency.String())
+	w.Int("latency_samples", int64(r.LatencySamples))
+	w.Array("latency_us", len(r.LatencyMicros), func(i int) {
+		w.Number(int64(r.LatencyMicros[i]))
+	})
+	w.String("counters", r.Counters.String())
+	w.Array("counter_results", len(r.CounterResults), func(i int) {
+		c := r.CounterResults[i]
+		w.Open()
+		w.Int("id", int64(c.Sensor))
+		w.Int("offered", int64(c.Counts.Offered))
+		w.Int("accepted", int64(c.Counts.Accepted))
+		w.Int("on", int64(c.Counts.On))
+		w.Int("attempted", int64(c.Counts.Attempted))
+		w.Int("received", int64(c.Received))
+		w.Close()
+	})
+	w.String("rates", r.Rates.String())
+	w.Array("rate_results", len(r.RateResults), func(i int) {
+		rate := r.RateResults[i]
+		w.Open()
+		w.Int("id", int64(rate.Sensor))
+		w.Int("interval_us", int64(rate.Interval))
+		w.Int("events", int64(rate.Events))
+		w.Float("hz", rate.Hz, 1)
+		w.Int("jitter_us", rate.Jitter.Microseconds())
+		w.Close()
+	})
+	w.Int("events", int64(r.Events))
+	w.Float("events_per_sec", r.EventsPerSec, 1)
+	w.Int("service_errors", int64(r.ServiceErrors))
+	if r.SoakMinutes > 0 {
+		w.Int("soak_minutes", int64(r.SoakMinutes))
+		w.Int("soak_events", int64(r.SoakEvents))
+		w.Int("soak_errors", int64(r.SoakErrors))
+		w.Int("soak_bus_errors", int64(r.SoakBusErrors))
+		w.Int("soak_resets", int64(r.SoakResets))
+		w.Int("soak_stalls", int64(r.SoakStalls))
+	}
+	if r.PowerCycles > 0 {
+		w.Int("power_cycles", int64(r.PowerCycles))
+		w.Int("power_failures", int64(r.PowerFailures))
+		w.Array("power_ready_ms", len(r.PowerReadyMillis), func(i int) {
+			w.Number(int64(r.PowerReadyMillis[i]))
+		})
+	}
+	w.Close()
+	println(string(w.Bytes()))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+)
+
+// Longest the sensor may take to boot and advertise after reset. The
+// datasheet gives about 100ms; anything near a second points at a problem.
+const resetTimeout = time.Second
+
+// checkResetPin pulses RST, then times the first packet after reset and
+// the arrival of the advertisement on channel 0, which the sensor sends
+// once it has booted. It returns the time to the advertisement and
+// whether it arrived.
+func checkResetPin(bus *machine.I2C, addr uint16, rst machine.Pin) (time.Duration, bool) {
+	link := transport.NewI2C(bus, addr, machine.NoPin, rst)
+	if err := link.Reset(); err != nil {
+		println("  Reset failed:", err.Error())
+		return 0, false
+	}
+	start := time.Now()
+
+	var firstPacket time.Duration
+	for time.Since(start) < resetTimeout {
+		// The sensor does not acknowledge while it boots, so errors are
+		// expected until it is up
+		packet, err := link.Read()
+		if err != nil {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		if firstPacket == 0 {
+			firstPacket = time.Since(start)
+			println("  First packet after", firstPacket.Milliseconds(), "ms on channel", packet.Channel)
+		}
+		if packet.Channel == shtpraw.ChannelCommand {
+			booted := time.Since(start)
+			println("  Advertisement after", booted.Milliseconds(), "ms")
+			println("  SUCCESS: Reset pin works")
+			return booted, true
+		}
+	}
+
+	if firstPacket == 0 {
+		println("  FAILED: No packet within", resetTimeout.Milliseconds(), "ms of reset")
+		println("  Check the RST wire and that the pin matches board.ResetPin()")
+	} else {
+		println("  FAILED: Packets arrived but no advertisement")
+	}
+	return 0, false
+}
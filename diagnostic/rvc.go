@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/rvc"
+)
+
+// checkRVC listens for UART-RVC frames. A sensor strapped for RVC (PS0
+// high, PS1 low) ignores I2C, so this runs when the bus scan finds no
+// BNO08x. It reports whether valid frames arrived.
+func checkRVC(listen time.Duration) bool {
+	uart, config := board.RVCUART()
+	if uart == nil {
+		println("  No RVC UART assigned for the " + board.Name + " board, skipping")
+		return false
+	}
+	config.BaudRate = rvc.BaudRate
+	if err := uart.Configure(config); err != nil {
+		println("  Could not configure UART:", err.Error())
+		return false
+	}
+
+	var parser rvc.Parser
+	received := 0
+	start := time.Now()
+	for time.Since(start) < listen {
+		for uart.Buffered() > 0 {
+			b, err := uart.ReadByte()
+			if err != nil {
+				break
+			}
+			received++
+			frame, ok := parser.Feed(b)
+			if !ok || parser.Frames > 5 {
+				continue
+			}
+			println("  Frame", frame.Index,
+				"yaw", fmtutil.Float(frame.Yaw, 2),
+				"pitch", fmtutil.Float(frame.Pitch, 2),
+				"roll", fmtutil.Float(frame.Roll, 2),
+				"accel", fmtutil.Float(frame.AccelX, 2), fmtutil.Float(frame.AccelY, 2), fmtutil.Float(frame.AccelZ, 2))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	println("  Bytes:", received, "frames:", parser.Frames,
+		"bad checksums:", parser.BadChecksums, "dropped:", parser.Dropped)
+	switch {
+	case parser.Frames > 0:
+		return true
+	case received > 0:
+		println("  Data received but no valid frames: check the baud rate is 115200")
+	default:
+		println("  No data on RX")
+	}
+	return false
+}
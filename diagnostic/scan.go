@@ -0,0 +1,114 @@
+package main
+
+import "machine"
+
+// First and last addresses probed by the bus scan; the rest are reserved
+// by the I2C specification.
+const (
+	scanFirst = 0x08
+	scanLast  = 0x77
+)
+
+// knownDevice is a common part found at an I2C address. Many addresses
+// are shared, so the name is only a guess.
+type knownDevice struct {
+	addr uint8
+	name string
+}
+
+// knownDevices lists common breakout-board parts, sorted by address.
+var knownDevices = []knownDevice{
+	{0x0C, "AK8963/AK09916 magnetometer"},
+	{0x10, "VEML7700 light sensor"},
+	{0x18, "LIS3DH accelerometer / MCP9808 temperature"},
+	{0x19, "LIS3DH/LSM303 accelerometer"},
+	{0x1C, "LIS3MDL magnetometer / MMA8452 accelerometer"},
+	{0x1D, "ADXL345/MMA8452 accelerometer"},
+	{0x1E, "HMC5883L/LIS3MDL/LSM303 magnetometer"},
+	{0x23, "BH1750 light sensor"},
+	{0x28, "BNO055 IMU"},
+	{0x29, "BNO055 IMU / VL53L0X ToF / TSL2591 light"},
+	{0x38, "AHT20 humidity / FT6206 touch"},
+	{0x39, "APDS9960 gesture / TSL2561 light"},
+	{0x3C, "SSD1306/SH1106 OLED"},
+	{0x3D, "SSD1306/SH1106 OLED"},
+	{0x40, "INA219 power / HTU21D humidity / PCA9685 PWM"},
+	{0x41, "INA219 power / PCA9685 PWM"},
+	{0x44, "SHT3x/SHT4x humidity"},
+	{0x45, "SHT3x humidity"},
+	{0x48, "ADS1115 ADC / TMP102 temperature"},
+	{0x49, "ADS1115 ADC / TSL2561 light"},
+	{0x4A, "BNO08x IMU (default) / ADS1115 ADC"},
+	{0x4B, "BNO08x IMU (DI pin high) / ADS1115 ADC"},
+	{0x50, "AT24Cxx EEPROM"},
+	{0x51, "AT24Cxx EEPROM / PCF8563 RTC"},
+	{0x52, "AT24Cxx EEPROM / Nunchuck"},
+	{0x53, "ADXL345 accelerometer / AT24Cxx EEPROM"},
+	{0x57, "MAX30102 pulse oximeter / AT24Cxx EEPROM"},
+	{0x5A, "MLX90614 IR thermometer / CCS811 gas / DRV2605 haptic"},
+	{0x5B, "CCS811 gas"},
+	{0x5C, "AM2320 humidity / LPS22 pressure"},
+	{0x5D, "LPS22/LPS25 pressure"},
+	{0x60, "MPL3115A2 pressure / Si1145 UV / ATECC608 crypto"},
+	{0x62, "SCD40 CO2"},
+	{0x68, "MPU6050/ICM20948 IMU / DS3231 RTC"},
+	{0x69, "MPU6050/ICM20948 IMU"},
+	{0x6A, "LSM6DS IMU"},
+	{0x6B, "LSM6DS/LSM9DS1 IMU"},
+	{0x70, "TCA9548A I2C mux / HT16K33 LED driver"},
+	{0x76, "BME280/BMP280/BMP388 pressure"},
+	{0x77, "BME280/BMP280/BMP388 pressure / MS5611"},
+}
+
+// guessDevice returns the likely device type at addr, or "unknown".
+func guessDevice(addr uint8) string {
+	for _, d := range knownDevices {
+		if d.addr == addr {
+			return d.name
+		}
+	}
+	return "unknown"
+}
+
+// scanBus probes every 7-bit address with a one-byte read and returns the
+// addresses that acknowledge.
+func scanBus(i2c *machine.I2C) []uint16 {
+	var found []uint16
+	buf := make([]byte, 1)
+	for addr := uint16(scanFirst); addr <= scanLast; addr++ {
+		if i2c.Tx(addr, nil, buf) == nil {
+			found = append(found, addr)
+		}
+	}
+	return found
+}
+
+// printScanGrid prints the scan result as a grid in the style of
+// i2cdetect, one row per 16 addresses.
+func printScanGrid(found []uint16) {
+	println("       0  1  2  3  4  5  6  7  8  9  A  B  C  D  E  F")
+	for row := 0; row < 0x80; row += 16 {
+		line := "  " + formatHex(uint8(row)) + ":"
+		for col := 0; col < 16; col++ {
+			addr := uint16(row + col)
+			switch {
+			case addr < scanFirst || addr > scanLast:
+				line += "   "
+			case contains(found, addr):
+				line += " " + formatHex(uint8(addr))
+			default:
+				line += " --"
+			}
+		}
+		println(line)
+	}
+}
+
+func contains(list []uint16, v uint16) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
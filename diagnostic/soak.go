@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/dispatch"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Serial command that starts a soak test after Step 8
+	soakCommand = "soak"
+	// How long a soak test runs; reset the board to stop it early
+	soakDuration = 8 * time.Hour
+	// Interval between rolling statistics lines
+	soakInterval = time.Minute
+)
+
+// soakStats totals a soak test run.
+type soakStats struct {
+	minutes       int
+	events        uint32
+	serviceErrors int
+	busErrors     int // Service errors that came from the I2C bus
+	resets        int // Resets the sensor announced
+	stalls        int // Intervals without a single event
+}
+
+// runSoak polls the sensor for duration with the reports already enabled,
+// printing event rates per sensor and error counts every soakInterval, so
+// a board can be qualified for stability over hours.
+func runSoak(sensor *bno08x.Device, duration time.Duration) soakStats {
+	var stats soakStats
+	events := dispatch.New()
+	var last [256]uint32
+	sensor.WasReset() // Clear the reset Configure saw
+	intervalErrors := 0
+
+	start := time.Now()
+	lastPrint := start
+	for time.Since(start) < duration {
+		if err := sensor.Service(); err != nil {
+			stats.serviceErrors++
+			intervalErrors++
+			if isBusError(err) {
+				stats.busErrors++
+			}
+		}
+		if !events.Poll(sensor) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if time.Since(lastPrint) < soakInterval {
+			continue
+		}
+		lastPrint = time.Now()
+		stats.minutes++
+
+		// The driver latches the sensor's reset notice until asked
+		if sensor.WasReset() {
+			stats.resets++
+		}
+
+		line := "  [" + fmtutil.PadLeft(fmtutil.Int(stats.minutes), 4) + "m]"
+		seen := uint32(0)
+		events.ForEach(func(id bno08x.SensorID, count uint32) {
+			n := count - last[uint8(id)]
+			last[uint8(id)] = count
+			seen += n
+			line += " " + sensorinfo.Name(id) + " " + fmtutil.Float(float32(n)/float32(soakInterval.Seconds()), 1) + "/s"
+		})
+		if seen == 0 {
+			stats.stalls++
+			line += " NO EVENTS"
+		}
+		println(line, "| errors", intervalErrors, "(total", stats.serviceErrors, "bus", stats.busErrors,
+			") | resets", stats.resets, "| stalls", stats.stalls)
+		intervalErrors = 0
+	}
+	stats.events = events.Total()
+	return stats
+}
+
+// isBusError reports whether err came from the I2C peripheral rather than
+// the SHTP layer. The machine package has no common type for bus errors
+// across targets, so they are told apart by message.
+func isBusError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "i2c") || strings.Contains(msg, "timed out") || strings.Contains(msg, "timeout")
+}
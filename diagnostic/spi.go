@@ -0,0 +1,182 @@
+//go:build bno08x_spi
+
+package main
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// spiMode selects the SPI diagnostic. Build with "-tags bno08x_spi" for
+// boards whose PS0 and PS1 pins are strapped high.
+const spiMode = true
+
+// runSPIDiagnostic checks an SPI-strapped BNO08x with the same steps as the
+// I2C diagnostic, talking SHTP through the SPI transport since the driver
+// only speaks I2C.
+func runSPIDiagnostic(report *DiagnosticReport) {
+	println("=== BNO08x SPI Diagnostic ===")
+	println()
+
+	println("Step 1: Initializing SPI0...")
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED: Could not open SPI transport:", err.Error())
+		if err == transport.ErrNotWired {
+			println("  Set CSPin, IntPin, ResetPin and WakePin for the " + board.Name + " board in internal/board")
+		}
+		report.Failure = "bus_configure"
+		return
+	}
+	report.BusOK = true
+	report.BusFrequency = transport.SPIFrequency
+	println("SUCCESS: SPI configured at 3 MHz, mode 3")
+	println()
+
+	println("Step 2: Resetting sensor and waiting for advertisement...")
+	link.Reset()
+	advertised := false
+	start := time.Now()
+	for !advertised && time.Since(start) < time.Second {
+		pkt, err := transport.Next(link, time.Second)
+		if err != nil {
+			continue
+		}
+		println("  Packet on channel", pkt.Header.Channel, "length", pkt.Header.Length)
+		if pkt.Header.Channel == shtpraw.ChannelCommand {
+			adv, err := shtpraw.ParseAdvertisement(pkt.Cargo())
+			if err != nil {
+				println("  Advertisement parse error:", err.Error())
+				continue
+			}
+			println("  SHTP version:", adv.SHTPVersion, "channels:", len(adv.Channels))
+			advertised = true
+		}
+	}
+	if !advertised {
+		println("FAILED: No advertisement after reset")
+		println("Troubleshooting:")
+		println("  1. Check PS0 (WAKE) and PS1 are both high at reset for SPI mode")
+		println("  2. Check SCK, MOSI/SDI, MISO/SDO and CS wiring")
+		println("  3. Check INT is wired and pulled up")
+		report.Failure = "no_sensor"
+		return
+	}
+	// Let the reset-complete and initial control messages drain
+	for {
+		if _, err := transport.Next(link, 100*time.Millisecond); err != nil {
+			break
+		}
+	}
+	report.SensorOK = true
+	println("SUCCESS: Sensor booted in SPI mode")
+	println()
+
+	println("Step 3: Reading product information...")
+	if err := link.Write(shtpraw.ChannelControl, []byte{0xF9, 0x00}); err != nil {
+		println("FAILED: Product ID request:", err.Error())
+		report.Failure = "product_ids"
+		return
+	}
+	entries := 0
+	start = time.Now()
+	for entries == 0 && time.Since(start) < time.Second {
+		pkt, err := transport.Next(link, time.Second)
+		if err != nil || pkt.Header.Channel != shtpraw.ChannelControl {
+			continue
+		}
+		// Each product ID response is 16 bytes; several may share a cargo
+		cargo := pkt.Cargo()
+		for len(cargo) >= 16 && cargo[0] == 0xF8 {
+			println("  Part Number:", binary.LittleEndian.Uint32(cargo[4:8]))
+			println("  Build Number:", binary.LittleEndian.Uint32(cargo[8:12]))
+			println("  Version:", cargo[2], ".", cargo[3], ".", binary.LittleEndian.Uint16(cargo[12:14]))
+			report.ProductIDs = append(report.ProductIDs, productID{
+				Part:  binary.LittleEndian.Uint32(cargo[4:8]),
+				Major: uint32(cargo[2]),
+				Minor: uint32(cargo[3]),
+				Patch: uint32(binary.LittleEndian.Uint16(cargo[12:14])),
+				Build: binary.LittleEndian.Uint32(cargo[8:12]),
+			})
+			entries++
+			cargo = cargo[16:]
+		}
+	}
+	if entries == 0 {
+		println("FAILED: No product ID response")
+		report.Failure = "product_ids"
+		return
+	}
+	println()
+
+	println("Step 4: Enabling Game Rotation Vector at 10Hz...")
+	setFeature := make([]byte, 17)
+	setFeature[0] = 0xFD // Set Feature command
+	setFeature[1] = uint8(bno08x.SensorGameRotationVector)
+	binary.LittleEndian.PutUint32(setFeature[5:9], 100000) // 10 Hz
+	if err := link.Write(shtpraw.ChannelControl, setFeature); err != nil {
+		println("FAILED: Set Feature:", err.Error())
+		report.Failure = "enable_report"
+		return
+	}
+	report.Reports = append(report.Reports, uint8(bno08x.SensorGameRotationVector))
+	println("SUCCESS: Report enabled")
+	println()
+
+	counted := []bno08x.SensorID{bno08x.SensorGameRotationVector}
+	countersCleared := clearCounters(link, counted) == nil
+
+	println("Step 5: Reading sensor data...")
+	println("(Polling for 5 seconds...)")
+	samples := 0
+	var quarantine shtpraw.Quarantine
+	start = time.Now()
+	for time.Since(start) < 5*time.Second {
+		pkt, err := transport.Next(link, 500*time.Millisecond)
+		if err != nil || pkt.Header.Channel != shtpraw.ChannelInputNormal {
+			continue
+		}
+		err = quarantine.DecodeInput(pkt.Cargo(), time.Since(start).Microseconds(), func(r shtpraw.Report) {
+			samples++
+			if samples <= 5 {
+				println("  Report", sensorinfo.Name(bno08x.SensorID(r.ID)), "seq", r.Sequence, "t", r.Timestamp, "us")
+			}
+		})
+		if err != nil {
+			println("  Decode error:", err.Error())
+			report.ServiceErrors++
+		}
+	}
+	report.Events = samples
+	report.EventsPerSec = float32(samples) / 5
+	if quarantine.Total() > 0 {
+		quarantine.Print()
+	}
+	println()
+
+	println("Step 5b: Comparing on-chip counters with reports received...")
+	if !countersCleared {
+		println("  Skipped: could not clear the counters")
+	} else {
+		counters, ok := readCounters(link, counted, []int{samples})
+		report.Counters = resultOf(ok)
+		report.CounterResults = counters
+	}
+	println()
+
+	if samples > 0 {
+		println("=== DIAGNOSTIC PASSED ===")
+		println("Received", samples, "reports over SPI")
+	} else {
+		println("=== WARNING ===")
+		println("Sensor answered over SPI but sent no input reports")
+		report.Failure = "no_data"
+	}
+	println()
+}
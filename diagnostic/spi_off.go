@@ -0,0 +1,8 @@
+//go:build !bno08x_spi
+
+package main
+
+// spiMode is false unless built with "-tags bno08x_spi".
+const spiMode = false
+
+func runSPIDiagnostic(report *DiagnosticReport) {}
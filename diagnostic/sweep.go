@@ -0,0 +1,101 @@
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Bus speeds tried by the clock sweep, slowest first.
+var sweepFrequencies = []uint32{100 * machine.KHz, 400 * machine.KHz, 1000 * machine.KHz}
+
+const (
+	// Init sequences attempted at each speed
+	sweepAttempts = 3
+	// Time spent reading events after each successful init
+	sweepWindow = 2 * time.Second
+)
+
+// sweepResult is the outcome of the init sequence at one bus speed.
+type sweepResult struct {
+	frequency uint32
+	inits     int // Successful init sequences out of sweepAttempts
+	events    int
+	errors    int // Service errors while reading
+}
+
+// reliable reports whether every init succeeded and data flowed cleanly.
+func (r sweepResult) reliable() bool {
+	return r.inits == sweepAttempts && r.errors == 0 && r.events > 0
+}
+
+// sweepClock runs the init sequence at each bus speed and returns the
+// fastest that worked every time, or 0 if none did. The bus is left
+// configured at that speed.
+func sweepClock(i2c *machine.I2C, addr uint16) uint32 {
+	var results []sweepResult
+	for _, freq := range sweepFrequencies {
+		println("  Trying", freq/1000, "kHz...")
+		if err := i2c.Configure(machine.I2CConfig{Frequency: freq}); err != nil {
+			println("    Configure failed:", err.Error())
+			results = append(results, sweepResult{frequency: freq})
+			continue
+		}
+		results = append(results, sweepOnce(i2c, addr, freq))
+	}
+
+	println()
+	println("    Speed  Inits  Events/s  Errors")
+	best := uint32(0)
+	for _, r := range results {
+		rate := float32(0)
+		if r.inits > 0 {
+			rate = float32(r.events) / float32(sweepWindow.Seconds()*float64(r.inits))
+		}
+		verdict := "FAIL"
+		if r.reliable() {
+			verdict = "OK"
+			best = r.frequency
+		}
+		println(" ", fmtutil.PadLeft(fmtutil.Int(int(r.frequency/1000)), 5), "kHz",
+			fmtutil.PadLeft(fmtutil.Int(r.inits), 2)+"/"+fmtutil.Int(sweepAttempts),
+			fmtutil.FloatWidth(rate, 1, 9),
+			fmtutil.PadLeft(fmtutil.Int(r.errors), 7), " ", verdict)
+	}
+
+	if best != 0 {
+		i2c.Configure(machine.I2CConfig{Frequency: best})
+	}
+	return best
+}
+
+// sweepOnce runs the driver's init sequence sweepAttempts times at the
+// current bus speed, reading Game Rotation Vector events after each.
+func sweepOnce(i2c *machine.I2C, addr uint16, freq uint32) sweepResult {
+	r := sweepResult{frequency: freq}
+	for attempt := 0; attempt < sweepAttempts; attempt++ {
+		sensor := bno08x.NewI2C(i2c)
+		if err := sensor.Configure(bno08x.Config{Address: addr}); err != nil {
+			r.errors++
+			continue
+		}
+		if err := sensor.EnableReport(bno08x.SensorGameRotationVector, 10000); err != nil {
+			r.errors++
+			continue
+		}
+		r.inits++
+
+		start := time.Now()
+		for time.Since(start) < sweepWindow {
+			if err := sensor.Service(); err != nil {
+				r.errors++
+			}
+			if _, ok := sensor.GetSensorEvent(); ok {
+				r.events++
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"machine"
+	"time"
+)
+
+// DMX512 line timing, ANSI E1.11
+const (
+	dmxBaudRate    = 250000
+	breakTime      = 176 * time.Microsecond // At least 92µs
+	markAfterBreak = 16 * time.Microsecond  // At least 12µs
+	slotTime       = 44 * time.Microsecond  // Start bit, eight data bits, two stop bits
+	// Bytes the UART may still be shifting out when Write returns, the
+	// RP2040's transmit FIFO
+	txFIFO = 32
+)
+
+// Number of channel slots in a universe
+const numChannels = 512
+
+// dmxPort sends DMX512 frames on a UART through an RS-485 transceiver.
+// UARTs cannot send a break of the length DMX needs, so the TX pin is
+// switched to a plain output to hold the line low and handed back to the
+// UART for the slots.
+type dmxPort struct {
+	uart   *machine.UART
+	config machine.UARTConfig
+	frame  [1 + numChannels]byte // Start code 0, then the channel slots
+}
+
+func newDMXPort(uart *machine.UART, config machine.UARTConfig) (*dmxPort, error) {
+	config.BaudRate = dmxBaudRate
+	p := &dmxPort{uart: uart, config: config}
+	return p, p.configure()
+}
+
+// configure sets the UART up for the slots: 250kbit/s, 8N2 where the UART
+// supports choosing the stop bits. Otherwise it sends 8N1, which most
+// fixtures accept.
+func (p *dmxPort) configure() error {
+	if err := p.uart.Configure(p.config); err != nil {
+		return err
+	}
+	type formatter interface {
+		SetFormat(databits, stopbits uint8, parity machine.UARTParity) error
+	}
+	if f, ok := any(p.uart).(formatter); ok {
+		return f.SetFormat(8, 2, machine.ParityNone)
+	}
+	return nil
+}
+
+// Set sets the level of a channel, numbered from 1 as on fixtures.
+// Channels outside 1 to 512 are ignored.
+func (p *dmxPort) Set(channel int, level uint8) {
+	if channel >= 1 && channel <= numChannels {
+		p.frame[channel] = level
+	}
+}
+
+// Set16 sets a 16-bit level on a coarse channel and the fine channel
+// after it, as moving heads use for pan and tilt.
+func (p *dmxPort) Set16(channel int, level uint16) {
+	p.Set(channel, uint8(level>>8))
+	p.Set(channel+1, uint8(level))
+}
+
+// Send sends a frame: break, mark after break, the start code and every
+// channel slot. It returns once the last slots are queued, about 23ms
+// later; frames must not be sent more often than that.
+func (p *dmxPort) Send() error {
+	// Let the end of the previous frame leave the FIFO before the break
+	time.Sleep(txFIFO * slotTime)
+
+	tx := p.config.TX
+	tx.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	tx.Low()
+	time.Sleep(breakTime)
+	tx.High()
+	time.Sleep(markAfterBreak)
+	if err := p.configure(); err != nil {
+		return err
+	}
+	_, err := p.uart.Write(p.frame[:])
+	return err
+}
@@ -0,0 +1,169 @@
+// Package main controls stage lighting from the BNO08x's orientation over
+// DMX512, the way the led example drives a NeoPixel. Roll, pitch and yaw
+// set the red, green and blue channels of an RGB fixture, or, with
+// movingHead set, yaw and pitch aim a moving head through its 16-bit pan
+// and tilt channels.
+//
+// DMX is sent from the TX pin of board.LinkUART, which must drive an
+// RS-485 transceiver such as a MAX485 with its driver enabled; the
+// transceiver's A and B lines go to the fixture's DMX input. Set
+// startAddress to the fixture's DMX address and check its channel layout
+// against the offsets below.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/filter"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// DMX address of the fixture's first channel
+const startAddress = 1
+
+// Aim a moving head instead of mixing the colour of an RGB fixture
+const movingHead = false
+
+// Channel offsets from startAddress of an RGB fixture
+const (
+	redOffset   = 0
+	greenOffset = 1
+	blueOffset  = 2
+)
+
+// Channel offsets from startAddress of a moving head, each a coarse channel
+// followed by its fine channel, and the travel of its pan and tilt
+const (
+	panOffset  = 0
+	tiltOffset = 2
+	panRange   = 540 // Degrees
+	tiltRange  = 270 // Degrees
+)
+
+// Frames per second; a full universe takes about 23ms to send
+const frameRate = 30
+
+// Cutoff frequency of the angle smoothing filter; lower is smoother but lags more
+const smoothingCutoffHz = 2
+
+// Interval between lines on the console
+const printInterval = 500 * time.Millisecond
+
+// Decimal places printed for the angles
+const decimals = 1
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("dmx_lighting")
+
+	uart, config := board.LinkUART()
+	if uart == nil {
+		println("No link UART assigned for the " + board.Name + " board, see internal/board")
+		return
+	}
+	dmx, err := newDMXPort(uart, config)
+	if err != nil {
+		println("Failed to configure UART:", err.Error())
+		return
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// Enable Game Rotation Vector reports at 50Hz (20000 microseconds)
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 20000)
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	if movingHead {
+		println("Yaw -> pan, Pitch -> tilt, from DMX address", startAddress)
+	} else {
+		println("Roll -> Red, Pitch -> Green, Yaw -> Blue, from DMX address", startAddress)
+	}
+
+	// Smooth the angles at the 50Hz report rate; yaw wraps at ±180°
+	smooth := filter.EulerEMA{Alpha: filter.Alpha(smoothingCutoffHz, 50)}
+	var roll, pitch, yaw float32
+	var failed uint32
+	nextFrame := time.Now()
+	lastPrint := time.Now()
+
+	for {
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			if event.ID() == bno08x.SensorGameRotationVector {
+				r, p, y := smooth.Update(quat.ToEuler(event.Quaternion()))
+				roll, pitch, yaw = units.RadiansToDegrees(r), units.RadiansToDegrees(p), units.RadiansToDegrees(y)
+			}
+		}
+
+		if now := time.Now(); !now.Before(nextFrame) {
+			nextFrame = now.Add(time.Second / frameRate)
+			if movingHead {
+				dmx.Set16(startAddress+panOffset, level16(yaw, panRange))
+				dmx.Set16(startAddress+tiltOffset, level16(pitch, tiltRange))
+			} else {
+				dmx.Set(startAddress+redOffset, level8(roll))
+				dmx.Set(startAddress+greenOffset, level8(pitch))
+				dmx.Set(startAddress+blueOffset, level8(yaw))
+			}
+			if err := dmx.Send(); err != nil {
+				failed++
+			}
+		}
+
+		if time.Since(lastPrint) >= printInterval {
+			lastPrint = time.Now()
+			println("Roll:", fmtutil.Float(roll, decimals), "Pitch:", fmtutil.Float(pitch, decimals),
+				"Yaw:", fmtutil.Float(yaw, decimals), "Failed frames:", failed)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// level8 maps -90° to +90° onto a channel level of 0 to 255, clamping
+// angles outside that range, as the led example does for its colours.
+func level8(degrees float32) uint8 {
+	return uint8(clamp01((degrees+90)/180)*255 + 0.5)
+}
+
+// level16 maps an angle onto a 16-bit pan or tilt level for a fixture
+// whose travel is travel degrees, 0° being the middle of the travel.
+func level16(degrees, travel float32) uint16 {
+	return uint16(clamp01(degrees/travel+0.5)*65535 + 0.5)
+}
+
+func clamp01(v float32) float32 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
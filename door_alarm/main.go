@@ -0,0 +1,289 @@
+// Package main is a low-power door or window alarm. Mount the board on the
+// door or sash, close it and type "arm": the closed orientation is kept in
+// flash, and the alarm pin goes high whenever the orientation later turns
+// more than openAngle away from it, until "reset".
+//
+// Between events the sensor does the watching. Only the significant
+// motion and tilt detectors run, at long report intervals, and the
+// microcontroller waits for the BNO08x to pull its INT pin low, checking
+// a flag set by the pin interrupt every idleCheck and otherwise idling in
+// the scheduler's sleep. When either detector fires, or every checkEvery
+// as a backstop for a push too gentle to fire them, the rotation vector is
+// turned on for checkTime, averaged and compared with the closed
+// orientation, then turned off again. The rotation vector's magnetometer
+// heading sees a door swing about its vertical hinge, which leaves the
+// tilt unchanged; a tilting window changes both.
+//
+// The alarm pin is board.LEDPin, standing in for a siren or relay. On
+// boards without an INT pin wired the sensor is polled every idleCheck
+// instead.
+//
+// Raising the alarm also posts a "door_open" event, as internal/webhook
+// describes, to webhookURL. Build with "-tags wifi" on a board with a WiFi
+// module, after setting ssid and passphrase below; without the tag the
+// event is printed on the console. The program's flash record holds the
+// closed orientation, so undelivered events wait in RAM only and a reset
+// loses them. Keeping WiFi joined costs far more power than the sensor,
+// so battery builds should leave the tag off.
+//
+//	arm     take the current orientation as closed
+//	status  show the alarm state, the last angle measured and the webhook queue
+//	reset   lower the alarm pin
+package main
+
+import (
+	"encoding/binary"
+	"machine"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"github.com/intermernet/bno08xPrograms/internal/webhook"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Endpoint receiving alarm events
+const webhookURL = "https://ntfy.sh/bno08x-door-alarm"
+
+// WiFi network to join, in builds with "-tags wifi"
+const (
+	ssid       = ""
+	passphrase = ""
+)
+
+const (
+	// Turn away from the closed orientation that raises the alarm, degrees
+	openAngle = 10
+	// Report interval of the detectors in microseconds; they report on
+	// event, so a long interval only saves power
+	detectorInterval = 1000000 // 1s
+	// Rotation vector report interval while checking, in microseconds
+	checkInterval = 50000 // 20Hz
+	// Time the rotation vector runs for a check; the first half lets the
+	// fusion settle and is not averaged
+	checkTime = 2 * time.Second
+	// Interval between checks when no detector has fired
+	checkEvery = 10 * time.Minute
+	// Interval at which the interrupt flag and the console are looked at
+	idleCheck = 100 * time.Millisecond
+)
+
+// intPending is set by the INT pin interrupt when the sensor has data.
+var intPending uint32
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("door_alarm")
+	start := time.Now()
+
+	alarmPin := board.LEDPin()
+	if alarmPin != machine.NoPin {
+		alarmPin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		alarmPin.Low()
+	}
+
+	settings := store.New(machine.Flash, "door_alarm")
+	closed, armed := loadClosed(settings)
+	notifier := webhook.New(webhookURL, webhook.Connect(ssid, passphrase), nil)
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range []bno08x.SensorID{bno08x.SensorSignificantMotion, bno08x.SensorTiltDetector} {
+		if err := sensor.EnableReport(id, detectorInterval); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	intPin := board.IntPin()
+	if intPin != machine.NoPin {
+		intPin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+		err := intPin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+			atomic.StoreUint32(&intPending, 1)
+		})
+		if err != nil {
+			println("Could not enable INT interrupt, polling instead:", err.Error())
+			intPin = machine.NoPin
+		}
+	}
+
+	alarm := false
+	lastAngle := float32(-1)
+	var avg quat.Averager
+	var checkStart time.Time
+	checking, arming := false, false
+	startCheck := func(now time.Time) {
+		if checking {
+			return
+		}
+		if err := sensor.EnableReport(bno08x.SensorRotationVector, checkInterval); err != nil {
+			println("Failed to enable rotation vector:", err.Error())
+			return
+		}
+		checking, checkStart = true, now
+		avg.Reset()
+	}
+
+	var sh shell.Shell
+	sh.Register("arm", "arm", "Take the current orientation as closed", func(args []string) error {
+		arming = true
+		startCheck(time.Now())
+		println("Measuring the closed orientation, keep it shut...")
+		return nil
+	})
+	sh.Register("status", "status", "Show the alarm state, the last angle measured and the webhook queue", func(args []string) error {
+		println("Armed:", armed, "| alarm:", alarm, "| checking:", checking)
+		if lastAngle >= 0 {
+			println("Last angle from closed:", fmtutil.Float(lastAngle, 1), "degrees, limit", openAngle)
+		}
+		println("Webhook:", notifier.Pending(), "pending,", notifier.Dropped(), "dropped, retry in",
+			int(notifier.Backoff().Seconds()), "s")
+		return nil
+	})
+	sh.Register("reset", "reset", "Lower the alarm pin", func(args []string) error {
+		alarm = false
+		if alarmPin != machine.NoPin {
+			alarmPin.Low()
+		}
+		println("Alarm reset")
+		return nil
+	})
+
+	if armed {
+		println("Armed with the closed orientation from flash")
+	} else {
+		println("Not armed: close the door and type 'arm'")
+	}
+
+	lastCheck := time.Now()
+
+	for {
+		sh.Poll()
+		now := time.Now()
+
+		if armed && !checking && now.Sub(lastCheck) >= checkEvery {
+			startCheck(now)
+		}
+
+		if err := notifier.Poll(now); err != nil {
+			println("Webhook failed:", err.Error(), "- retrying in", int(notifier.Backoff().Seconds()), "s")
+		}
+
+		// Sleep until the sensor has something to say, or between polls
+		// without an INT pin; a check reads the rotation vector as it comes
+		switch {
+		case checking:
+			time.Sleep(5 * time.Millisecond)
+		case intPin == machine.NoPin:
+			time.Sleep(idleCheck)
+		case atomic.SwapUint32(&intPending, 0) == 0:
+			time.Sleep(idleCheck)
+			continue
+		}
+
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorSignificantMotion:
+				// Significant motion is one-shot and must be re-armed
+				sensor.EnableReport(bno08x.SensorSignificantMotion, detectorInterval)
+				println("Significant motion")
+				if armed {
+					startCheck(now)
+				}
+			case bno08x.SensorTiltDetector:
+				println("Tilt")
+				if armed {
+					startCheck(now)
+				}
+			case bno08x.SensorRotationVector:
+				if checking && now.Sub(checkStart) >= checkTime/2 {
+					avg.Add(event.Quaternion())
+				}
+			}
+		}
+		// INT stays low if data arrived while draining; no new edge comes
+		if intPin != machine.NoPin && !intPin.Get() {
+			atomic.StoreUint32(&intPending, 1)
+		}
+
+		if checking && now.Sub(checkStart) >= checkTime {
+			checking, lastCheck = false, now
+			sensor.EnableReport(bno08x.SensorRotationVector, 0)
+			if avg.Count() == 0 {
+				println("No rotation vector during the check")
+				arming = false
+				continue
+			}
+			q := avg.Mean()
+			if arming {
+				arming, armed, closed = false, true, q
+				if err := saveClosed(settings, closed); err != nil {
+					println("Failed to save closed orientation:", err.Error())
+				}
+				println("Armed")
+				continue
+			}
+			lastAngle = units.RadiansToDegrees(quat.Angle(closed, q))
+			if lastAngle > openAngle && !alarm {
+				alarm = true
+				if alarmPin != machine.NoPin {
+					alarmPin.High()
+				}
+				println("ALARM: opened", fmtutil.Float(lastAngle, 1), "degrees")
+				notifier.Notify(webhook.Event{Kind: "door_open", Value: lastAngle, Limit: openAngle, Uptime: now.Sub(start)})
+			} else {
+				println("Checked:", fmtutil.Float(lastAngle, 1), "degrees from closed")
+			}
+		}
+	}
+}
+
+// loadClosed returns the closed orientation stored in flash.
+func loadClosed(settings *store.Store) (bno08x.Quaternion, bool) {
+	var buf [16]byte
+	n, err := settings.Load(buf[:])
+	if err != nil || n != len(buf) {
+		if err != nil && err != store.ErrEmpty {
+			println("Stored orientation unusable:", err.Error())
+		}
+		return quat.Identity, false
+	}
+	f := func(i int) float32 { return math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:])) }
+	return bno08x.Quaternion{Real: f(0), I: f(1), J: f(2), K: f(3)}, true
+}
+
+// saveClosed stores the closed orientation in flash.
+func saveClosed(settings *store.Store, q bno08x.Quaternion) error {
+	var buf [16]byte
+	for i, v := range [...]float32{q.Real, q.I, q.J, q.K} {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return settings.Save(buf[:])
+}
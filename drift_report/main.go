@@ -0,0 +1,163 @@
+// Package main is a yaw drift acceptance test. Leave the board completely
+// still: it records the yaw of the rotation vector and of the game
+// rotation vector once a second for reportDuration, printing each sample
+// as a CSV line, then prints the drift in degrees per hour.
+//
+// The rotation vector is corrected by the magnetometer and should hold its
+// heading; the game rotation vector has gyro and accelerometer only, so
+// its yaw drift is the figure to compare between units. Capture the serial
+// output to a file and strip the lines starting with '#' to plot it.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Length of the test
+	reportDuration = 4 * time.Hour
+	// Interval between samples
+	sampleInterval = time.Second
+	// Interval between interim drift figures
+	summaryInterval = 10 * time.Minute
+)
+
+// Decimal places printed for angles and drift
+const decimals = 3
+
+// yawTrack unwraps a yaw angle and fits a line through it against time by
+// least squares, whose slope is the drift.
+type yawTrack struct {
+	primed    bool
+	last      float32 // Last raw yaw, degrees
+	unwrapped float64 // Continuous yaw, degrees
+	n         float64
+	st, sy    float64
+	stt, sty  float64
+}
+
+// add records the yaw in degrees at t seconds from the start.
+func (y *yawTrack) add(t float64, yaw float32) {
+	if !y.primed {
+		y.primed, y.last, y.unwrapped = true, yaw, float64(yaw)
+	}
+	d := yaw - y.last
+	for d > 180 {
+		d -= 360
+	}
+	for d < -180 {
+		d += 360
+	}
+	y.last = yaw
+	y.unwrapped += float64(d)
+
+	y.n++
+	y.st += t
+	y.sy += y.unwrapped
+	y.stt += t * t
+	y.sty += t * y.unwrapped
+}
+
+// perHour returns the fitted drift in degrees per hour.
+func (y *yawTrack) perHour() (float32, bool) {
+	den := y.n*y.stt - y.st*y.st
+	if y.n < 2 || den == 0 {
+		return 0, false
+	}
+	slope := (y.n*y.sty - y.st*y.sy) / den // Degrees per second
+	return float32(slope * 3600), true
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("drift_report")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// Reports at 10Hz; only the latest of each is sampled every second
+	for _, id := range []bno08x.SensorID{bno08x.SensorRotationVector, bno08x.SensorGameRotationVector} {
+		err = sensor.EnableReport(id, 100000)
+		if err != nil {
+			println("Failed to enable sensor:", uint8(id), err.Error())
+			return
+		}
+	}
+
+	println("# Keep the board still for", int(reportDuration.Minutes()), "minutes")
+	println("elapsed_s,rv_yaw_deg,grv_yaw_deg,rv_accuracy_deg")
+
+	var rv, grv yawTrack
+	var rvYaw, grvYaw, rvAccuracy float32
+	haveRV, haveGRV := false, false
+
+	start := time.Now()
+	nextSample := start.Add(sampleInterval)
+	nextSummary := start.Add(summaryInterval)
+	for time.Since(start) < reportDuration {
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorRotationVector:
+				_, _, yaw := quat.ToEuler(event.Quaternion())
+				rvYaw, haveRV = units.RadiansToDegrees(yaw), true
+				rvAccuracy = units.RadiansToDegrees(event.QuaternionAccuracy())
+			case bno08x.SensorGameRotationVector:
+				_, _, yaw := quat.ToEuler(event.Quaternion())
+				grvYaw, haveGRV = units.RadiansToDegrees(yaw), true
+			}
+		} else {
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		now := time.Now()
+		if now.Before(nextSample) || !haveRV || !haveGRV {
+			continue
+		}
+		nextSample = nextSample.Add(sampleInterval)
+		t := now.Sub(start).Seconds()
+		rv.add(t, rvYaw)
+		grv.add(t, grvYaw)
+		println(fmtutil.Int(int(t)) + "," + fmtutil.Float(rvYaw, decimals) + "," +
+			fmtutil.Float(grvYaw, decimals) + "," + fmtutil.Float(rvAccuracy, 1))
+
+		if now.After(nextSummary) {
+			nextSummary = nextSummary.Add(summaryInterval)
+			printDrift("# Interim", &rv, &grv)
+		}
+	}
+
+	println("# === Drift report ===")
+	println("# Samples:", int(grv.n), "over", int(reportDuration.Minutes()), "minutes")
+	printDrift("# Final", &rv, &grv)
+}
+
+// printDrift prints the fitted drift of both rotation vectors.
+func printDrift(label string, rv, grv *yawTrack) {
+	rvDrift, ok1 := rv.perHour()
+	grvDrift, ok2 := grv.perHour()
+	if !ok1 || !ok2 {
+		return
+	}
+	println(label, "drift: rotation vector", fmtutil.Float(rvDrift, decimals), "deg/h | game rotation vector",
+		fmtutil.Float(grvDrift, decimals), "deg/h")
+}
@@ -0,0 +1,223 @@
+// Package main turns the sensor hub's dynamic calibration on and off at
+// run time with the SH-2 ME Calibration command. The hub keeps learning
+// the accelerometer, gyroscope and magnetometer calibration while it runs,
+// which also lets a magnetic disturbance or a knock slowly pull a good
+// calibration off; once the accuracy is high, "freeze" stops the learning
+// and keeps the calibration as it is until "enable" or a reset.
+//
+// "status" asks the hub which calibrations are running and prints the
+// accuracy of each sensor's latest report, 0 (unreliable) to 3 (high).
+// "save" stores the current calibration in the sensor's flash with Save
+// DCD, to be loaded at the next boot.
+//
+// Runs over raw SHTP, to send the commands. Uses I2C by default, or SPI
+// when built with "-tags bno08x_spi".
+//
+//	status                 show the calibrations running and the accuracies
+//	enable <cal>...        start calibrations: accel gyro mag planar table all
+//	disable <cal>...       stop them
+//	freeze                 stop accel, gyro and mag calibration
+//	save                   store the calibration in the sensor's flash
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sh2cmd"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Report interval of the calibrated sensors, in microseconds
+	interval = sensorinfo.Interval10Hz
+	// Longest to wait for the answer to a command
+	responseTimeout = time.Second
+)
+
+// Sensors whose accuracy is shown
+var calibrated = []bno08x.SensorID{
+	bno08x.SensorAccelerometer,
+	bno08x.SensorGyroscope,
+	bno08x.SensorMagneticField,
+}
+
+var errFailed = errors.New("the sensor refused the command")
+
+// session holds the state the commands work on.
+type session struct {
+	link     transport.Transport
+	seq      uint8
+	buf      [shtpraw.FeatureLen]byte
+	accuracy [4]uint8 // Latest accuracy by sensor ID; only 1 to 3 are used
+	reported [4]bool
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("dyncal")
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	s := &session{link: link}
+	for _, id := range calibrated {
+		f := shtpraw.Feature{Sensor: uint8(id), Interval: interval}
+		if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(s.buf[:0], f)); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	var sh shell.Shell
+	sh.Register("status", "status", "Show the calibrations running and the accuracies", s.status)
+	sh.Register("enable", "enable <cal>...", "Start calibrations: accel gyro mag planar table all", func(args []string) error {
+		return s.change(args, true)
+	})
+	sh.Register("disable", "disable <cal>...", "Stop calibrations", func(args []string) error {
+		return s.change(args, false)
+	})
+	sh.Register("freeze", "freeze", "Stop accel, gyro and mag calibration", func(args []string) error {
+		return s.change([]string{"accel", "gyro", "mag"}, false)
+	})
+	sh.Register("save", "save", "Store the calibration in the sensor's flash", s.save)
+
+	println("Type 'status' to see the calibration, 'freeze' to keep it, 'help' for commands")
+
+	for {
+		sh.Poll()
+		if packet, err := transport.Next(link, 10*time.Millisecond); err == nil {
+			s.input(packet)
+		}
+	}
+}
+
+func (s *session) status(args []string) error {
+	c, err := s.get()
+	if err != nil {
+		return err
+	}
+	printCalibration(c)
+	line := "Accuracy:"
+	for _, id := range calibrated {
+		line += " " + sensorinfo.Name(id) + " "
+		if s.reported[id] {
+			line += fmtutil.Int(int(s.accuracy[id]))
+		} else {
+			line += "-"
+		}
+	}
+	println(line)
+	return nil
+}
+
+// change turns the named calibrations on or off.
+func (s *session) change(names []string, on bool) error {
+	if len(names) == 0 {
+		return shell.ErrUsage
+	}
+	c, err := s.get()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		switch name {
+		case "accel":
+			c.Accel = on
+		case "gyro":
+			c.Gyro = on
+		case "mag":
+			c.Mag = on
+		case "planar":
+			c.Planar = on
+		case "table":
+			c.OnTable = on
+		case "all":
+			c = sh2cmd.MECalibration{Accel: on, Gyro: on, Mag: on, Planar: on, OnTable: on}
+		default:
+			return errors.New("unknown calibration " + name)
+		}
+	}
+	s.seq++
+	r, err := s.exchange(sh2cmd.AppendConfigureMECalibration(s.buf[:0], s.seq, c))
+	if err != nil {
+		return err
+	}
+	if r.Status() != sh2cmd.StatusOK {
+		return errFailed
+	}
+	// Read back what the hub now runs
+	if c, err = s.get(); err != nil {
+		return err
+	}
+	printCalibration(c)
+	return nil
+}
+
+func (s *session) save(args []string) error {
+	s.seq++
+	if err := sh2cmd.SaveDCD(s.link, s.seq, responseTimeout, s.input); err != nil {
+		return err
+	}
+	println("Calibration saved; it will be loaded at the next boot")
+	return nil
+}
+
+// get asks the hub which calibrations run.
+func (s *session) get() (sh2cmd.MECalibration, error) {
+	s.seq++
+	r, err := s.exchange(sh2cmd.AppendGetMECalibration(s.buf[:0], s.seq))
+	if err != nil {
+		return sh2cmd.MECalibration{}, err
+	}
+	c, ok := sh2cmd.ParseMECalibration(r)
+	if !ok {
+		return c, errFailed
+	}
+	return c, nil
+}
+
+// exchange sends a Command Request and waits for its response, recording
+// the input reports that arrive meanwhile.
+func (s *session) exchange(req []byte) (sh2cmd.Response, error) {
+	return sh2cmd.Exchange(s.link, req, responseTimeout, s.input)
+}
+
+// input records the accuracy of the calibrated sensors' reports.
+func (s *session) input(packet shtpraw.Packet) {
+	if packet.Channel != shtpraw.ChannelInputNormal && packet.Channel != shtpraw.ChannelInputWake {
+		return
+	}
+	shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+		if int(r.ID) < len(s.accuracy) {
+			s.accuracy[r.ID], s.reported[r.ID] = r.Accuracy(), true
+		}
+	})
+}
+
+func printCalibration(c sh2cmd.MECalibration) {
+	println("Calibration: accel", onOff(c.Accel), "| gyro", onOff(c.Gyro), "| mag", onOff(c.Mag),
+		"| planar", onOff(c.Planar), "| on table", onOff(c.OnTable))
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
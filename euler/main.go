@@ -1,30 +1,54 @@
 // Package main demonstrates converting quaternion data to Euler angles
 // (roll, pitch, yaw) for easier visualization of sensor orientation.
+// Typing "selftest" on the serial console runs the health checks in place.
+// Set csvOutput to print timestamped CSV rows for spreadsheets instead, or
+// teleplotOutput to plot the angles live in the Teleplot VS Code extension.
+//
+// The angles are always printed as the rotations about X, Y and Z, but the
+// order they are applied in is selectable, to match the software they are
+// fed to: "order zyx" is the aerospace yaw, pitch, roll sequence and the
+// default, "order xyz" the robotics one; yxz, yzx, zxy and xzy also work.
 package main
 
 import (
-	"machine"
 	"math"
 	"time"
 
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/csvout"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/selftest"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/teleplot"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 	"tinygo.org/x/drivers/bno08x"
 )
 
+// Set to true to print each sample as a CSV row with a timestamp and sensor
+// ID, after a header row
+const csvOutput = false
+
+// Set to true to print the angles in the Teleplot format; csvOutput takes
+// precedence
+const teleplotOutput = false
+
+// Decimal places in CSV rows
+const decimals = 3
+
+// Euler order used until changed with the "order" command
+const defaultOrder = quat.ZYX
+
 func main() {
-	// Initialize I2C bus
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{
-		Frequency: 400 * machine.KHz,
-	})
-	if err != nil {
-		println("Failed to configure I2C:", err.Error())
-		return
-	}
+	buildinfo.Banner("euler")
 
 	println("Initializing BNO08x sensor...")
 
 	// Create and configure sensor
-	sensor := bno08x.New(i2c)
+	sensor, bus, err := transport.OpenSensorBus()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
 	err = sensor.Configure(bno08x.Config{})
 	if err != nil {
 		println("Failed to configure sensor:", err.Error())
@@ -40,51 +64,62 @@ func main() {
 		return
 	}
 
-	println("Reading orientation data...")
-	println("Format: Roll Pitch Yaw (degrees)")
+	csv := csvout.New(decimals, "roll_deg", "pitch_deg", "yaw_deg")
+	plot := teleplot.New(decimals)
+	if csvOutput {
+		csv.Header()
+	} else if !teleplotOutput {
+		println("Reading orientation data...")
+		println("Format: Roll Pitch Yaw (degrees)")
+	}
+
+	order := defaultOrder
+	var sh shell.Shell
+	sh.Register(selftest.Command, selftest.Command, "Run the sensor health checks", func(args []string) error {
+		result := selftest.Run(bus, sensor, 2*time.Second)
+		result.Print()
+		return nil
+	})
+	sh.Register("order", "order <zyx|xyz|yxz|yzx|zxy|xzy>", "Set the Euler rotation order", func(args []string) error {
+		if len(args) != 1 {
+			return shell.ErrUsage
+		}
+		o, ok := quat.ParseEulerOrder(args[0])
+		if !ok {
+			return shell.ErrUsage
+		}
+		order = o
+		if !csvOutput {
+			println("Euler order", order.String())
+		}
+		return nil
+	})
 
 	// Main loop - read quaternions and convert to Euler angles
 	for {
+		sh.Poll()
+
 		event, ok := sensor.GetSensorEvent()
 		if ok && event.ID() == bno08x.SensorRotationVector {
 			q := event.Quaternion()
 
-			// Convert quaternion to Euler angles
-			roll, pitch, yaw := quaternionToEuler(q)
+			// Convert quaternion to Euler angles about X, Y and Z
+			roll, pitch, yaw := quat.ToEulerOrder(q, order)
 
 			// Convert radians to degrees
 			rollDeg := roll * 180.0 / math.Pi
 			pitchDeg := pitch * 180.0 / math.Pi
 			yawDeg := yaw * 180.0 / math.Pi
 
-			println(rollDeg, pitchDeg, yawDeg)
+			if csvOutput {
+				csv.Row(event.ID(), rollDeg, pitchDeg, yawDeg)
+			} else if teleplotOutput {
+				plot.Euler(rollDeg, pitchDeg, yawDeg)
+			} else {
+				println(rollDeg, pitchDeg, yawDeg)
+			}
 		}
 
 		time.Sleep(100 * time.Millisecond)
 	}
 }
-
-// quaternionToEuler converts a quaternion to Euler angles (roll, pitch, yaw).
-// Roll is rotation around X axis, Pitch around Y axis, Yaw around Z axis.
-// All angles are returned in radians.
-func quaternionToEuler(q bno08x.Quaternion) (roll, pitch, yaw float32) {
-	// Roll (x-axis rotation)
-	sinr_cosp := 2.0 * (q.Real*q.I + q.J*q.K)
-	cosr_cosp := 1.0 - 2.0*(q.I*q.I+q.J*q.J)
-	roll = float32(math.Atan2(float64(sinr_cosp), float64(cosr_cosp)))
-
-	// Pitch (y-axis rotation)
-	sinp := 2.0 * (q.Real*q.J - q.K*q.I)
-	if math.Abs(float64(sinp)) >= 1 {
-		pitch = float32(math.Copysign(math.Pi/2, float64(sinp)))
-	} else {
-		pitch = float32(math.Asin(float64(sinp)))
-	}
-
-	// Yaw (z-axis rotation)
-	siny_cosp := 2.0 * (q.Real*q.K + q.I*q.J)
-	cosy_cosp := 1.0 - 2.0*(q.J*q.J+q.K*q.K)
-	yaw = float32(math.Atan2(float64(siny_cosp), float64(cosy_cosp)))
-
-	return roll, pitch, yaw
-}
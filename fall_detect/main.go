@@ -0,0 +1,341 @@
+// Package main detects falls of the person wearing the board and sounds an
+// alert. A fall has three phases, each scored towards a confidence out of
+// 100:
+//
+//   - free fall: the accelerometer magnitude drops towards zero while the
+//     body drops, worth up to 30 points for a long enough fall;
+//   - impact: a spike in linear acceleration as the body lands, worth up
+//     to 40 points by its size;
+//   - lying still: after the body settles, the stability classifier
+//     reporting it on the ground rather than moving, worth up to 30
+//     points by the share of the watch spent still.
+//
+// A hard impact alone also counts, since stumbles often have little free
+// fall. Once the score reaches cfg.AlertScore the alert pin, the board LED
+// standing in for a buzzer, beeps until "ok" is typed. Every scored event
+// is printed, so thresholds in cfg can be tuned against real trials.
+//
+// A detected fall is also posted as a "fall" event, with the confidence
+// as its value, to webhookURL as internal/webhook describes, so it
+// reaches a carer's phone. Build with "-tags wifi" on a board with a WiFi
+// module, after setting ssid and passphrase below; without the tag the
+// event is printed on the console. Undelivered events are kept in flash
+// while the network is down and sent once it is back, even after a reset.
+//
+//	ok      silence the alert
+//	status  show the detector state, thresholds and webhook queue
+package main
+
+import (
+	"machine"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"github.com/intermernet/bno08xPrograms/internal/webhook"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Endpoint receiving fall events
+const webhookURL = "https://ntfy.sh/bno08x-fall-detect"
+
+// WiFi network to join, in builds with "-tags wifi"
+const (
+	ssid       = ""
+	passphrase = ""
+)
+
+// config holds the detection thresholds.
+type config struct {
+	FreeFallG     float32       // Accelerometer magnitude below which the body is falling, g
+	FreeFallTime  time.Duration // Shortest free fall counted
+	FullFreeFall  time.Duration // Free fall scoring full points, about a 40cm drop
+	ImpactG       float32       // Linear acceleration counted as an impact, g
+	SevereImpactG float32       // Impact scoring full points, g
+	LoneImpactG   float32       // Impact that starts a check without a free fall, g
+	ImpactWindow  time.Duration // Longest wait for the impact after a free fall
+	SettleTime    time.Duration // Ignored after the impact while the body comes to rest
+	WatchTime     time.Duration // How long the body is watched for stillness
+	AlertScore    int           // Confidence from which a fall is flagged
+}
+
+var cfg = config{
+	FreeFallG:     0.4,
+	FreeFallTime:  80 * time.Millisecond,
+	FullFreeFall:  300 * time.Millisecond,
+	ImpactG:       2.0,
+	SevereImpactG: 6.0,
+	LoneImpactG:   3.5,
+	ImpactWindow:  time.Second,
+	SettleTime:    2 * time.Second,
+	WatchTime:     5 * time.Second,
+	AlertScore:    60,
+}
+
+// Points of each phase
+const (
+	freeFallPoints = 30
+	impactPoints   = 40
+	stillPoints    = 30
+)
+
+// Stability classifier values counted as lying still
+const (
+	stabilityOnTable    = 1
+	stabilityStationary = 2
+)
+
+// Accelerometer and linear acceleration report interval in microseconds
+const interval = 10000 // 100Hz
+
+// Beep pattern of the alert
+const (
+	beepOn  = 200 * time.Millisecond
+	beepOff = 300 * time.Millisecond
+)
+
+// phase is the state of the fall detector.
+type phase uint8
+
+const (
+	idle     phase = iota // Waiting for a free fall or hard impact
+	falling               // Accelerometer below FreeFallG
+	awaiting              // Free fall over, waiting for the impact
+	settling              // Impact seen, waiting for the body to come to rest
+	watching              // Counting stability reports
+)
+
+var phaseNames = [...]string{"idle", "falling", "awaiting impact", "settling", "watching"}
+
+// detector scores a possible fall.
+type detector struct {
+	phase      phase
+	since      time.Time     // Start of the current phase
+	freeFall   time.Duration // Length of the free fall, 0 if none
+	peakImpact float32       // Largest linear acceleration of the impact, g
+	still, all int           // Stability reports while watching
+}
+
+// accel feeds an accelerometer magnitude in g.
+func (d *detector) accel(g float32, now time.Time) {
+	switch d.phase {
+	case idle:
+		if g < cfg.FreeFallG {
+			*d = detector{phase: falling, since: now}
+		}
+	case falling:
+		if g >= cfg.FreeFallG {
+			if fall := now.Sub(d.since); fall >= cfg.FreeFallTime {
+				d.phase, d.since, d.freeFall = awaiting, now, fall
+			} else {
+				d.phase = idle
+			}
+		}
+	}
+}
+
+// linear feeds a linear acceleration magnitude in g.
+func (d *detector) linear(g float32, now time.Time) {
+	switch d.phase {
+	case idle:
+		if g >= cfg.LoneImpactG {
+			*d = detector{phase: settling, since: now, peakImpact: g}
+		}
+	case falling, awaiting:
+		if g >= cfg.ImpactG {
+			if d.phase == falling {
+				d.freeFall = now.Sub(d.since)
+			}
+			d.phase, d.since, d.peakImpact = settling, now, g
+		}
+	case settling:
+		// The impact can span several samples
+		if g > d.peakImpact && now.Sub(d.since) < cfg.SettleTime/4 {
+			d.peakImpact = g
+		}
+	}
+}
+
+// stability feeds a stability classifier report.
+func (d *detector) stability(class uint8) {
+	if d.phase != watching {
+		return
+	}
+	d.all++
+	if class == stabilityOnTable || class == stabilityStationary {
+		d.still++
+	}
+}
+
+// poll applies the timeouts and returns the confidence once a check is
+// complete, or -1.
+func (d *detector) poll(now time.Time) int {
+	switch d.phase {
+	case awaiting:
+		if now.Sub(d.since) >= cfg.ImpactWindow {
+			d.phase = idle
+		}
+	case settling:
+		if now.Sub(d.since) >= cfg.SettleTime {
+			d.phase, d.since = watching, now
+		}
+	case watching:
+		if now.Sub(d.since) >= cfg.WatchTime {
+			d.phase = idle
+			return d.score()
+		}
+	}
+	return -1
+}
+
+// score returns the confidence of the check just completed, out of 100.
+func (d *detector) score() int {
+	s := freeFallPoints * clamp01(float32(d.freeFall)/float32(cfg.FullFreeFall))
+	s += impactPoints * clamp01((d.peakImpact-cfg.ImpactG)/(cfg.SevereImpactG-cfg.ImpactG))
+	if d.all > 0 {
+		s += stillPoints * float32(d.still) / float32(d.all)
+	}
+	return int(s + 0.5)
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("fall_detect")
+	start := time.Now()
+
+	alertPin := board.LEDPin()
+	if alertPin != machine.NoPin {
+		alertPin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		alertPin.Low()
+	}
+
+	notifier := webhook.New(webhookURL, webhook.Connect(ssid, passphrase), store.New(machine.Flash, "fall_detect"))
+	if n, err := notifier.Restore(); err != nil {
+		println("Could not restore the webhook queue:", err.Error())
+	} else if n > 0 {
+		println("Restored", n, "undelivered events")
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, r := range []struct {
+		id       bno08x.SensorID
+		interval uint32
+	}{
+		{bno08x.SensorAccelerometer, interval},
+		{bno08x.SensorLinearAcceleration, interval},
+		{bno08x.SensorStabilityClassifier, sensorinfo.Interval10Hz},
+	} {
+		if err := sensor.EnableReport(r.id, r.interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(r.id)+":", err.Error())
+			return
+		}
+	}
+
+	var d detector
+	alerting := false
+	var alertStart time.Time
+
+	var sh shell.Shell
+	sh.Register("ok", "ok", "Silence the alert", func(args []string) error {
+		if alerting {
+			alerting = false
+			println("Alert silenced")
+		}
+		return nil
+	})
+	sh.Register("status", "status", "Show the detector state, thresholds and webhook queue", func(args []string) error {
+		println("Detector:", phaseNames[d.phase], "| alert:", alerting)
+		println("Free fall below", fmtutil.Float(cfg.FreeFallG, 2), "g for", cfg.FreeFallTime.Milliseconds(), "ms")
+		println("Impact from", fmtutil.Float(cfg.ImpactG, 1), "g, alone from", fmtutil.Float(cfg.LoneImpactG, 1), "g")
+		println("Alert from confidence", cfg.AlertScore)
+		println("Webhook:", notifier.Pending(), "pending,", notifier.Dropped(), "dropped, retry in",
+			int(notifier.Backoff().Seconds()), "s")
+		return nil
+	})
+
+	println("Watching for falls. Type 'help' for commands")
+
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorAccelerometer:
+				d.accel(magnitudeG(event.Accelerometer()), now)
+			case bno08x.SensorLinearAcceleration:
+				d.linear(magnitudeG(event.LinearAcceleration()), now)
+			case bno08x.SensorStabilityClassifier:
+				d.stability(event.StabilityClassifier().Classification)
+			}
+		}
+
+		if score := d.poll(now); score >= 0 {
+			println("Event: free fall", d.freeFall.Milliseconds(), "ms, impact", fmtutil.Float(d.peakImpact, 1),
+				"g, still", d.still, "of", d.all, "-> confidence", score)
+			if score >= cfg.AlertScore {
+				println("FALL DETECTED (confidence", score, ") - type 'ok' to silence")
+				alerting, alertStart = true, now
+				err := notifier.Notify(webhook.Event{Kind: "fall", Value: float32(score), Limit: float32(cfg.AlertScore), Uptime: now.Sub(start)})
+				if err != nil {
+					println("Could not save the webhook queue:", err.Error())
+				}
+			}
+		}
+
+		if err := notifier.Poll(now); err != nil {
+			println("Webhook failed:", err.Error(), "- retrying in", int(notifier.Backoff().Seconds()), "s")
+		}
+
+		if alertPin != machine.NoPin {
+			on := false
+			if alerting {
+				on = now.Sub(alertStart)%(beepOn+beepOff) < beepOn
+			}
+			alertPin.Set(on)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// magnitudeG returns the length of v in g.
+func magnitudeG(v bno08x.Vector3) float32 {
+	return units.MetersPerSecondSquaredToG(float32(math.Sqrt(float64(v.X*v.X + v.Y*v.Y + v.Z*v.Z))))
+}
+
+func clamp01(v float32) float32 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
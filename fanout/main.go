@@ -0,0 +1,115 @@
+// Package main drives several outputs from one sensor stream, each at its
+// own rate: orientation on the serial console at 5Hz and a tilt alarm on
+// the status LED checked at 20Hz. Once a second it prints how many events
+// each output was sent and how many its rate limit skipped.
+//
+// Each output is an output.Sink added to one output.Mux. A radio or MQTT
+// link is added the same way, with no change to the others.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dispatch"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/output"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Output intervals
+const (
+	consoleInterval = 200 * time.Millisecond // 5Hz
+	ledInterval     = 50 * time.Millisecond  // 20Hz
+	summaryInterval = time.Second
+)
+
+// Tilt from level, in degrees, that lights the LED
+const tiltAlarm = 30
+
+// Decimal places printed for the angles
+const decimals = 1
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("fanout")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 10000) // 100Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	var mux output.Mux
+	console := mux.Add(output.SinkFunc(printOrientation), consoleInterval, bno08x.SensorGameRotationVector)
+	led := mux.Add(newTiltLED(board.LEDPin()), ledInterval, bno08x.SensorGameRotationVector)
+
+	events := dispatch.New()
+	events.HandleDefault(mux.Send)
+	lastSummary := time.Now()
+
+	for {
+		if !events.Poll(sensor) {
+			time.Sleep(2 * time.Millisecond)
+		}
+		if time.Since(lastSummary) < summaryInterval {
+			continue
+		}
+		lastSummary = time.Now()
+		consoleSent, consoleSkipped := mux.Stats(console)
+		ledSent, ledSkipped := mux.Stats(led)
+		println("Events:", events.Total(), "| console sent", consoleSent, "skipped", consoleSkipped,
+			"| LED sent", ledSent, "skipped", ledSkipped)
+	}
+}
+
+// printOrientation prints the roll, pitch and yaw of a rotation vector.
+func printOrientation(event *bno08x.SensorValue) {
+	roll, pitch, yaw := quat.ToEuler(event.Quaternion())
+	println("Roll:", fmtutil.Float(units.RadiansToDegrees(roll), decimals),
+		"Pitch:", fmtutil.Float(units.RadiansToDegrees(pitch), decimals),
+		"Yaw:", fmtutil.Float(units.RadiansToDegrees(yaw), decimals))
+}
+
+// tiltLED lights an LED while the board is tilted past tiltAlarm.
+type tiltLED struct {
+	pin machine.Pin
+}
+
+func newTiltLED(pin machine.Pin) *tiltLED {
+	if pin != machine.NoPin {
+		pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	}
+	return &tiltLED{pin: pin}
+}
+
+// Send implements output.Sink.
+func (t *tiltLED) Send(event *bno08x.SensorValue) {
+	if t.pin == machine.NoPin {
+		return
+	}
+	roll, pitch, _ := quat.ToEuler(event.Quaternion())
+	r, p := units.RadiansToDegrees(roll), units.RadiansToDegrees(pitch)
+	t.pin.Set(r > tiltAlarm || r < -tiltAlarm || p > tiltAlarm || p < -tiltAlarm)
+}
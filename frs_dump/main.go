@@ -0,0 +1,176 @@
+// Package main reads the BNO08x's Flash Record System records over raw
+// SHTP and prints them: the mounting orientations, the fusion rate limit,
+// the AR/VR stabilisation settings and the serial number decoded, and the
+// calibration and detector configuration records as words in hex, since
+// their layouts are not published. Run it to see how a part has been set
+// up before changing any of it.
+//
+// Runs over I2C by default, or over SPI when built with "-tags bno08x_spi".
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/frs"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+)
+
+// Longest to wait for the whole of one record
+const readTimeout = time.Second
+
+var errTimeout = errors.New("no complete answer in time")
+
+// Records read, in the order printed
+var records = []uint16{
+	frs.SerialNumber,
+	frs.SystemOrientation,
+	frs.AccelOrientation,
+	frs.GyroOrientation,
+	frs.MagOrientation,
+	frs.MaxFusionPeriod,
+	frs.ARVRStabilizationRV,
+	frs.ARVRStabilizationGRV,
+	frs.StaticCalibrationAGM,
+	frs.NominalCalibrationAGM,
+	frs.DynamicCalibration,
+	frs.MEPowerManagement,
+	frs.GyroIntegratedRVConf,
+	frs.TapConfig,
+	frs.SigMotionConfig,
+	frs.ShakeConfig,
+	frs.PickupConfig,
+	frs.FlipConfig,
+	frs.StabilityConfig,
+	frs.ActivityConfig,
+	frs.SleepConfig,
+	frs.TiltConfig,
+	frs.PocketConfig,
+	frs.CircleConfig,
+	frs.UserRecord,
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("frs_dump")
+	println("=== BNO08x FRS Records ===")
+	println()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	var a frs.Assembler
+	present := 0
+	for _, t := range records {
+		println()
+		println(hex(uint32(t), 4), frs.Name(t))
+		if err := read(link, &a, t); err != nil {
+			println("  Read failed:", err.Error())
+			continue
+		}
+		if a.Empty || len(a.Words) == 0 {
+			println("  Empty")
+			continue
+		}
+		present++
+		printRecord(t, a.Words)
+	}
+
+	println()
+	println(present, "of", len(records), "records present")
+}
+
+// read fetches the whole of a record into a.
+func read(link transport.Transport, a *frs.Assembler, recordType uint16) error {
+	a.Start(recordType)
+	var req [frs.ReadRequestLen]byte
+	if err := link.Write(shtpraw.ChannelControl, frs.AppendReadRequest(req[:0], recordType, 0, 0)); err != nil {
+		return err
+	}
+	start := time.Now()
+	done := false
+	for !done && time.Since(start) < readTimeout {
+		packet, err := transport.Next(link, readTimeout-time.Since(start))
+		if err != nil || packet.Channel != shtpraw.ChannelControl {
+			continue
+		}
+		frs.ParseReadResponses(packet.Cargo(), func(r frs.ReadResponse) {
+			done = a.Add(r) || done
+		})
+	}
+	if !done {
+		return errTimeout
+	}
+	return a.Err()
+}
+
+// printRecord prints a record, decoded if its layout is known.
+func printRecord(t uint16, words []uint32) {
+	switch t {
+	case frs.SystemOrientation, frs.AccelOrientation, frs.GyroOrientation, frs.MagOrientation:
+		if q, ok := frs.Quaternion(words); ok {
+			roll, pitch, yaw := quat.ToEuler(q)
+			println("  Quaternion w", fmtutil.Float(q.Real, 4), "x", fmtutil.Float(q.I, 4),
+				"y", fmtutil.Float(q.J, 4), "z", fmtutil.Float(q.K, 4))
+			println("  Roll", fmtutil.Float(units.RadiansToDegrees(roll), 1),
+				"pitch", fmtutil.Float(units.RadiansToDegrees(pitch), 1),
+				"yaw", fmtutil.Float(units.RadiansToDegrees(yaw), 1), "degrees")
+			return
+		}
+	case frs.MaxFusionPeriod:
+		if period := words[0]; period != 0 {
+			println("  Period", period, "us, fusion at most", fmtutil.Float(1e6/float32(period), 1), "Hz")
+			return
+		}
+	case frs.ARVRStabilizationRV, frs.ARVRStabilizationGRV:
+		if len(words) >= 4 {
+			println("  Scaling", fmtutil.Float(frs.Q30(words[0]), 3),
+				"| max rotation", fmtutil.Float(units.RadiansToDegrees(frs.Q29(words[1])), 2), "degrees",
+				"| max error", fmtutil.Float(units.RadiansToDegrees(frs.Q29(words[2])), 2), "degrees",
+				"| stability", fmtutil.Float(frs.Q29(words[3]), 3))
+			return
+		}
+	case frs.SerialNumber:
+		println("  Serial number", words[0])
+		return
+	}
+	printWords(words)
+}
+
+// printWords prints words in hex, four to a line.
+func printWords(words []uint32) {
+	println(" ", len(words), "words:")
+	for i := 0; i < len(words); i += 4 {
+		line := "  " + fmtutil.PadLeft(fmtutil.Int(i), 3) + ":"
+		for _, w := range words[i:min(i+4, len(words))] {
+			line += " " + hex(w, 8)
+		}
+		println(line)
+	}
+}
+
+// hex formats v as "0x" and its lowest digits hex digits.
+func hex(v uint32, digits int) string {
+	const hexDigits = "0123456789ABCDEF"
+	b := make([]byte, 2+digits)
+	b[0], b[1] = '0', 'x'
+	for i := len(b) - 1; i >= 2; i-- {
+		b[i] = hexDigits[v&0x0F]
+		v >>= 4
+	}
+	return string(b)
+}
@@ -0,0 +1,249 @@
+// Package main compares a flight-controller style attitude filter, running
+// on the microcontroller, with the BNO08x's own fusion. The uncalibrated
+// gyroscope and the accelerometer, the inputs a quadcopter's IMU would
+// give, go through a Madgwick or Mahony filter from internal/ahrs, and its
+// quaternion is printed beside the game rotation vector with how far the
+// two diverge:
+//
+//	filter w x y z | bno w x y z | tilt yaw total
+//
+// Tilt is the angle between the two gravity directions, yaw the difference
+// in heading and total the whole rotation between them, all in degrees.
+// Neither uses the magnetometer, so their headings start unrelated: after
+// settleTime the filter is aligned to the BNO08x's heading once, and yaw
+// from then on shows how differently the two drift. Type "align" to align
+// again and restart the statistics.
+//
+//	align            align the headings again
+//	filter <name>    switch to the madgwick or mahony filter
+//
+// The raw sensor reports would be closer still to a flight controller's
+// input, but they are ADC counts whose scale depends on the part, so the
+// uncalibrated gyroscope in rad/s is used: the hub has not taken its bias
+// out, so the filter has to cope with it as a flight controller's would.
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/ahrs"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Gyroscope and accelerometer rate, and so the filter update rate
+	filterRateHz = 200
+	// Game rotation vector report interval in microseconds
+	referenceInterval = 10000 // 100Hz
+	// Time the filter is given to converge before the headings are aligned
+	settleTime = 3 * time.Second
+	// Interval between comparison lines
+	printInterval = 200 * time.Millisecond
+	// Interval between divergence summaries
+	summaryInterval = 10 * time.Second
+)
+
+// Decimal places printed for quaternion components and angles
+const (
+	quatDecimals  = 4
+	angleDecimals = 2
+)
+
+// stats accumulates the total divergence since the last alignment.
+type stats struct {
+	n          int
+	sumSquares float64
+	max        float32
+}
+
+func (s *stats) add(deg float32) {
+	s.n++
+	s.sumSquares += float64(deg) * float64(deg)
+	if deg > s.max {
+		s.max = deg
+	}
+}
+
+func (s *stats) rms() float32 {
+	if s.n == 0 {
+		return 0
+	}
+	return float32(math.Sqrt(s.sumSquares / float64(s.n)))
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("fusion_compare")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	reports := []struct {
+		id       bno08x.SensorID
+		name     string
+		interval uint32
+	}{
+		{bno08x.SensorGyroscopeUncalibrated, "uncalibrated gyroscope", 1000000 / filterRateHz},
+		{bno08x.SensorAccelerometer, "accelerometer", 1000000 / filterRateHz},
+		{bno08x.SensorGameRotationVector, "game rotation vector", referenceInterval},
+	}
+	for _, r := range reports {
+		if err := sensor.EnableReport(r.id, r.interval); err != nil {
+			println("Failed to enable", r.name+":", err.Error())
+			return
+		}
+	}
+
+	var filter ahrs.Filter = ahrs.NewMadgwick()
+	filterName := "madgwick"
+	var offset bno08x.Quaternion // Heading correction applied to the filter
+	aligned := false
+	start := time.Now()
+	var sum stats
+
+	var accel bno08x.Vector3
+	haveAccel := false
+	var bno bno08x.Quaternion
+
+	var sh shell.Shell
+	sh.Register("align", "align", "Align the headings again", func(args []string) error {
+		aligned, start = false, time.Now().Add(-settleTime)
+		return nil
+	})
+	sh.Register("filter", "filter <madgwick|mahony>", "Switch filter", func(args []string) error {
+		if len(args) != 1 {
+			return shell.ErrUsage
+		}
+		switch args[0] {
+		case "madgwick":
+			filter = ahrs.NewMadgwick()
+		case "mahony":
+			filter = ahrs.NewMahony()
+		default:
+			return shell.ErrUsage
+		}
+		filterName = args[0]
+		aligned, start = false, time.Now()
+		println("Using", filterName, "filter, aligning in", int(settleTime.Seconds()), "seconds")
+		return nil
+	})
+
+	println("Using", filterName, "filter, aligning in", int(settleTime.Seconds()), "seconds")
+	println("# filter w x y z | bno w x y z | tilt yaw total (deg)")
+	lastPrint, lastSummary := time.Now(), time.Now()
+
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		fresh := false
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorAccelerometer:
+				accel, haveAccel = event.Accelerometer(), true
+			case bno08x.SensorGyroscopeUncalibrated:
+				if haveAccel {
+					g := event.GyroscopeUncal()
+					filter.Update(g.X, g.Y, g.Z, accel.X, accel.Y, accel.Z, 1.0/filterRateHz)
+				}
+			case bno08x.SensorGameRotationVector:
+				bno, fresh = event.Quaternion(), true
+			}
+		}
+		if !fresh || !haveAccel {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+
+		// Compared at the game rotation vector's rate
+		est := filter.Quaternion()
+		if !aligned && now.Sub(start) >= settleTime {
+			_, _, yawBNO := quat.ToEuler(bno)
+			_, _, yawEst := quat.ToEuler(est)
+			offset = headingOnly(yawBNO - yawEst)
+			aligned, sum = true, stats{}
+			println("# Aligned headings")
+		}
+		if !aligned {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		est = quat.Multiply(offset, est)
+
+		tilt, yaw, total := divergence(est, bno)
+		sum.add(total)
+
+		if now.Sub(lastPrint) >= printInterval {
+			lastPrint = now
+			println(quatString(est), "|", quatString(bno), "|", fmtutil.Float(tilt, angleDecimals),
+				fmtutil.Float(yaw, angleDecimals), fmtutil.Float(total, angleDecimals))
+		}
+		if now.Sub(lastSummary) >= summaryInterval {
+			lastSummary = now
+			println("#", filterName, "divergence RMS", fmtutil.Float(sum.rms(), angleDecimals), "max",
+				fmtutil.Float(sum.max, angleDecimals), "deg over", sum.n, "samples")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// divergence returns the tilt, heading and total angle between two
+// orientations, in degrees.
+func divergence(a, b bno08x.Quaternion) (tilt, yaw, total float32) {
+	ax, ay, az := quat.Rotate(quat.Conjugate(a), 0, 0, 1)
+	bx, by, bz := quat.Rotate(quat.Conjugate(b), 0, 0, 1)
+	dot := float64(ax*bx + ay*by + az*bz)
+	tilt = units.RadiansToDegrees(float32(math.Acos(math.Max(-1, math.Min(1, dot)))))
+
+	_, _, yawA := quat.ToEuler(a)
+	_, _, yawB := quat.ToEuler(b)
+	d := units.RadiansToDegrees(yawA - yawB)
+	for d > 180 {
+		d -= 360
+	}
+	for d < -180 {
+		d += 360
+	}
+	return tilt, d, units.RadiansToDegrees(quat.Angle(a, b))
+}
+
+// headingOnly returns the rotation by yaw radians about the vertical.
+func headingOnly(yaw float32) bno08x.Quaternion {
+	s, c := math.Sincos(float64(yaw) / 2)
+	return bno08x.Quaternion{Real: float32(c), K: float32(s)}
+}
+
+// quatString formats q with a non-negative real part, so the two columns
+// agree in sign when the orientations agree.
+func quatString(q bno08x.Quaternion) string {
+	if q.Real < 0 {
+		q = bno08x.Quaternion{Real: -q.Real, I: -q.I, J: -q.J, K: -q.K}
+	}
+	return fmtutil.Float(q.Real, quatDecimals) + " " + fmtutil.Float(q.I, quatDecimals) + " " +
+		fmtutil.Float(q.J, quatDecimals) + " " + fmtutil.Float(q.K, quatDecimals)
+}
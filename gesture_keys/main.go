@@ -0,0 +1,125 @@
+// Package main is a desk gadget that types keyboard shortcuts over USB when
+// the BNO08x's activity detectors fire: shake the board to mute, flip it to
+// go to the next slide, pick it up to play or pause, and draw a circle to
+// go back a slide. Edit bindings to change the shortcuts; each is a list of
+// keys held down together, modifiers first, such as
+//
+//	{keyboard.KeyModifierCtrl, keyboard.KeyModifierAlt, keyboard.KeyD}
+//
+// A gesture that fires again within cooldown is ignored, as the detectors
+// can report one movement more than once.
+package main
+
+import (
+	"machine/usb/hid/keyboard"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Shortest time between two shortcuts from the same gesture
+const cooldown = time.Second
+
+// binding ties a detector to the shortcut it types.
+type binding struct {
+	sensor bno08x.SensorID
+	name   string
+	keys   []keyboard.Keycode
+	last   time.Time
+}
+
+// keyboardPort is the USB keyboard; TinyGo does not export its type.
+type keyboardPort interface {
+	Down(keyboard.Keycode) error
+	Up(keyboard.Keycode) error
+}
+
+// Shortcut of each gesture
+var bindings = []binding{
+	{sensor: bno08x.SensorShakeDetector, name: "Shake", keys: []keyboard.Keycode{keyboard.KeyMediaMute}},
+	{sensor: bno08x.SensorFlipDetector, name: "Flip", keys: []keyboard.Keycode{keyboard.KeyRight}},
+	{sensor: bno08x.SensorPickupDetector, name: "Pickup", keys: []keyboard.Keycode{keyboard.KeyMediaPlayPause}},
+	{sensor: bno08x.SensorCircleDetector, name: "Circle", keys: []keyboard.Keycode{keyboard.KeyLeft}},
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("gesture_keys")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, b := range bindings {
+		err = sensor.EnableReport(b.sensor, sensorinfo.DefaultIntervalMicros(b.sensor))
+		if err != nil {
+			println("Failed to enable", sensorinfo.Name(b.sensor)+":", err.Error())
+			return
+		}
+	}
+
+	kb := keyboard.Port()
+	println("Shake: mute | Flip: next slide | Pickup: play/pause | Circle: previous slide")
+
+	for {
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		for i := range bindings {
+			b := &bindings[i]
+			if event.ID() != b.sensor {
+				continue
+			}
+			now := time.Now()
+			if !b.last.IsZero() && now.Sub(b.last) < cooldown {
+				break
+			}
+			b.last = now
+			if err := press(kb, b.keys); err != nil {
+				println(b.name+": failed to send shortcut:", err.Error())
+				break
+			}
+			println(b.name)
+			break
+		}
+	}
+}
+
+// press holds keys down in order and releases them in reverse, so
+// modifiers listed first wrap the key they modify.
+func press(kb keyboardPort, keys []keyboard.Keycode) error {
+	for i, k := range keys {
+		if err := kb.Down(k); err != nil {
+			release(kb, keys[:i])
+			return err
+		}
+	}
+	return release(kb, keys)
+}
+
+func release(kb keyboardPort, keys []keyboard.Keycode) error {
+	var err error
+	for i := len(keys) - 1; i >= 0; i-- {
+		if e := kb.Up(keys[i]); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,331 @@
+// Package main is a gesture password lock. Perform the configured sequence
+// of gestures in order, each within stepTimeout of the last, and the lock
+// opens for unlockTime: the board LED lights, standing in for a relay on
+// the same pin. A wrong gesture starts the sequence over, and maxFailures
+// wrong attempts in a row lock the board out for lockoutTime.
+//
+// The sequence is kept in flash and changed over the serial console:
+//
+//	set flip double circle   store a new sequence
+//	sequence                 print the current one
+//	default                  go back to the built-in sequence
+//	lock                     lock again at once
+package main
+
+import (
+	"errors"
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Longest pause allowed between two gestures of the sequence
+	stepTimeout = 5 * time.Second
+	// How long the lock stays open
+	unlockTime = 5 * time.Second
+	// Wrong attempts in a row before a lockout
+	maxFailures = 3
+	// How long gestures are ignored after maxFailures
+	lockoutTime = 30 * time.Second
+	// Longest sequence that can be stored
+	maxSteps = 8
+)
+
+// step is one gesture of the sequence, stored in flash as a byte.
+type step uint8
+
+const (
+	noStep step = iota
+	stepTap
+	stepDoubleTap
+	stepFlip
+	stepCircle
+	stepShake
+)
+
+var stepNames = [...]string{
+	stepTap:       "tap",
+	stepDoubleTap: "double",
+	stepFlip:      "flip",
+	stepCircle:    "circle",
+	stepShake:     "shake",
+}
+
+func (s step) String() string {
+	if int(s) < len(stepNames) && stepNames[s] != "" {
+		return stepNames[s]
+	}
+	return "?"
+}
+
+// parseStep returns the step called name, or noStep.
+func parseStep(name string) step {
+	for i, n := range stepNames {
+		if n != "" && n == name {
+			return step(i)
+		}
+	}
+	return noStep
+}
+
+// Sequence used until another is stored
+var defaultSequence = []step{stepFlip, stepDoubleTap, stepCircle}
+
+// lockState is the state of the lock state machine.
+type lockState uint8
+
+const (
+	locked    lockState = iota // Waiting for the first gesture
+	entering                   // Part of the sequence matched
+	unlocked                   // Sequence complete, relay on
+	lockedOut                  // Too many failures, gestures ignored
+)
+
+// lock matches gestures against the sequence. Gestures move it from locked
+// through entering to unlocked; a wrong gesture or a pause longer than
+// stepTimeout sends it back to locked, and maxFailures of those in a row
+// to lockedOut. unlocked and lockedOut end after their time is up.
+type lock struct {
+	sequence []step
+	state    lockState
+	progress int       // Steps matched while entering
+	failures int       // Wrong attempts in a row
+	deadline time.Time // When entering, unlocked or lockedOut times out
+}
+
+// gesture feeds a gesture performed at now to the state machine.
+func (l *lock) gesture(s step, now time.Time) {
+	switch l.state {
+	case unlocked, lockedOut:
+		return
+	case locked:
+		l.progress = 0
+	}
+	if s != l.sequence[l.progress] {
+		l.fail(now)
+		return
+	}
+	l.progress++
+	println("  Step", l.progress, "of", len(l.sequence), "ok:", s.String())
+	if l.progress < len(l.sequence) {
+		l.state, l.deadline = entering, now.Add(stepTimeout)
+		return
+	}
+	l.state, l.deadline, l.failures = unlocked, now.Add(unlockTime), 0
+	println("UNLOCKED")
+}
+
+// poll applies the timeouts.
+func (l *lock) poll(now time.Time) {
+	if l.state == locked || now.Before(l.deadline) {
+		return
+	}
+	switch l.state {
+	case entering:
+		println("  Too slow")
+		l.fail(now)
+	case unlocked:
+		println("Locked")
+		l.state = locked
+	case lockedOut:
+		println("Lockout over")
+		l.state = locked
+	}
+}
+
+// fail records a wrong attempt.
+func (l *lock) fail(now time.Time) {
+	l.failures++
+	l.state = locked
+	if l.failures >= maxFailures {
+		println("  Wrong sequence,", l.failures, "failures: locked out for", int(lockoutTime.Seconds()), "seconds")
+		l.state, l.deadline, l.failures = lockedOut, now.Add(lockoutTime), 0
+		return
+	}
+	println("  Wrong sequence, start again")
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("gesture_lock")
+
+	relay := board.LEDPin()
+	if relay != machine.NoPin {
+		relay.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		relay.Low()
+	}
+
+	settings := store.New(machine.Flash, "gesture_lock")
+	l := &lock{sequence: loadSequence(settings)}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	detectors := []bno08x.SensorID{
+		bno08x.SensorTapDetector,
+		bno08x.SensorFlipDetector,
+		bno08x.SensorCircleDetector,
+		bno08x.SensorShakeDetector,
+	}
+	for _, id := range detectors {
+		err = sensor.EnableReport(id, sensorinfo.DefaultIntervalMicros(id))
+		if err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	var sh shell.Shell
+	sh.Register("set", "set <gesture>...", "Store a new sequence (tap, double, flip, circle, shake)", func(args []string) error {
+		seq, err := parseSequence(args)
+		if err != nil {
+			return err
+		}
+		if err := saveSequence(settings, seq); err != nil {
+			return err
+		}
+		l.sequence, l.state = seq, locked
+		printSequence(seq)
+		return nil
+	})
+	sh.Register("sequence", "sequence", "Print the current sequence", func(args []string) error {
+		printSequence(l.sequence)
+		return nil
+	})
+	sh.Register("default", "default", "Forget the stored sequence", func(args []string) error {
+		if err := settings.Clear(); err != nil {
+			return err
+		}
+		l.sequence, l.state = defaultSequence, locked
+		printSequence(l.sequence)
+		return nil
+	})
+	sh.Register("lock", "lock", "Lock at once", func(args []string) error {
+		l.state = locked
+		println("Locked")
+		return nil
+	})
+
+	printSequence(l.sequence)
+	println("Locked. Type 'help' for commands.")
+
+	var taps gesture.TapFilter
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		// A single tap is only known once the double tap window has passed
+		if kind, _ := taps.Poll(now); kind == gesture.SingleTap {
+			l.gesture(stepTap, now)
+		}
+		performed := noStep
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorTapDetector:
+				switch kind, _ := taps.Add(gesture.DecodeTap(event.TapDetector().Flags), now); kind {
+				case gesture.DoubleTap:
+					performed = stepDoubleTap
+				case gesture.SingleTap:
+					performed = stepTap
+				}
+			case bno08x.SensorFlipDetector:
+				performed = stepFlip
+			case bno08x.SensorCircleDetector:
+				performed = stepCircle
+			case bno08x.SensorShakeDetector:
+				performed = stepShake
+			}
+		}
+
+		if performed != noStep {
+			l.gesture(performed, now)
+		}
+		l.poll(now)
+		if relay != machine.NoPin {
+			relay.Set(l.state == unlocked)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// loadSequence returns the sequence stored in flash, or defaultSequence if
+// there is none or it is damaged.
+func loadSequence(settings *store.Store) []step {
+	var buf [maxSteps]byte
+	n, err := settings.Load(buf[:])
+	if err != nil {
+		if err != store.ErrEmpty {
+			println("Stored sequence unusable:", err.Error())
+		}
+		return defaultSequence
+	}
+	seq := make([]step, n)
+	for i := range seq {
+		if seq[i] = step(buf[i]); seq[i].String() == "?" {
+			println("Stored sequence unusable: unknown gesture", buf[i])
+			return defaultSequence
+		}
+	}
+	if n == 0 {
+		return defaultSequence
+	}
+	return seq
+}
+
+// saveSequence stores seq in flash.
+func saveSequence(settings *store.Store, seq []step) error {
+	var buf [maxSteps]byte
+	for i, s := range seq {
+		buf[i] = byte(s)
+	}
+	return settings.Save(buf[:len(seq)])
+}
+
+// parseSequence parses gesture names into a sequence.
+func parseSequence(args []string) ([]step, error) {
+	if len(args) == 0 {
+		return nil, shell.ErrUsage
+	}
+	if len(args) > maxSteps {
+		return nil, errors.New("sequence longer than " + fmtutil.Int(maxSteps) + " gestures")
+	}
+	seq := make([]step, len(args))
+	for i, arg := range args {
+		if seq[i] = parseStep(arg); seq[i] == noStep {
+			return nil, errors.New("unknown gesture " + arg)
+		}
+	}
+	return seq, nil
+}
+
+func printSequence(seq []step) {
+	line := "Sequence:"
+	for _, s := range seq {
+		line += " " + s.String()
+	}
+	println(line)
+}
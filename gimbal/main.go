@@ -0,0 +1,276 @@
+// Package main stabilises a camera on a two- or three-axis hobby servo
+// gimbal. The BNO08x sits on the handle, and each servo is driven opposite
+// to the handle's roll, pitch and, with stabilizeYaw set, yaw, so the
+// camera stays level and pointed while the handle moves. Servos are
+// slew-limited so a sudden jolt cannot slam them into their end stops, and
+// go through the safety interlock: tilting the handle past maxTilt,
+// swinging it faster than maxRate or losing sensor data freezes them until
+// "reset" is typed. The interlock starts faulted, so nothing moves until
+// the first "reset" either.
+//
+// Roll uses board.RollServoPWM, pitch the tilt pin of board.ServoPWM and
+// yaw its pan pin. At start-up the camera is held level and facing the
+// handle's heading. Hold the handle in its neutral pose and type "center"
+// to make that pose the reference, which also trims out a sensor mounted
+// slightly crooked; "trim" then fine-tunes each servo's centre for a camera
+// that is not quite level on its plate.
+//
+//	center                       make the current pose the reference
+//	trim [roll|pitch|yaw <deg>]  set or show the servo centre offsets
+//	reset                        clear a safety fault
+//
+// Reverse an axis below if its servo turns the wrong way.
+package main
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/safety"
+	"github.com/intermernet/bno08xPrograms/internal/servo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Drive a third servo to hold the heading as well
+	stabilizeYaw = false
+	// Fastest the servos are commanded to move, degrees/second
+	maxSlew = 300
+	// Handle tilt beyond which the servos cannot compensate, degrees
+	maxTilt = 60
+	// Handle rotation rate beyond which stabilising stops, degrees/second
+	maxRate = 720
+	// Longest gap in sensor data before the servos freeze
+	dataTimeout = 100 * time.Millisecond
+	// Largest trim that can be set, degrees
+	maxTrim = 20
+	// Interval between status lines
+	statusInterval = 500 * time.Millisecond
+)
+
+// Servos turning the opposite way to the angle they correct
+const (
+	reverseRoll  = false
+	reversePitch = false
+	reverseYaw   = false
+)
+
+// Decimal places printed for angles
+const decimals = 1
+
+// axis is one stabilised axis.
+type axis struct {
+	name    string
+	servo   *servo.Servo
+	slew    safety.RateLimiter
+	trim    float32 // Servo angle with the handle at the reference, degrees
+	reverse bool
+}
+
+// drive turns the servo to counter the handle's angle, in degrees from the
+// reference.
+func (a *axis) drive(angle float32, now time.Time) {
+	target := a.trim - angle
+	if a.reverse {
+		target = a.trim + angle
+	}
+	a.servo.SetAngle(a.slew.Step(target, now))
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("gimbal")
+
+	pwm, panPin, tiltPin := board.ServoPWM()
+	rollPWM, rollPin := board.RollServoPWM()
+	if pwm == nil || rollPWM == nil {
+		println("No servo PWM set for the " + board.Name + " board in internal/board")
+		return
+	}
+	if err := servo.Configure(pwm); err != nil {
+		println("Failed to configure PWM:", err.Error())
+		return
+	}
+	if rollPWM != pwm {
+		if err := servo.Configure(rollPWM); err != nil {
+			println("Failed to configure PWM:", err.Error())
+			return
+		}
+	}
+
+	roll := &axis{name: "roll", reverse: reverseRoll, slew: safety.RateLimiter{MaxRate: maxSlew}}
+	pitch := &axis{name: "pitch", reverse: reversePitch, slew: safety.RateLimiter{MaxRate: maxSlew}}
+	yaw := &axis{name: "yaw", reverse: reverseYaw, slew: safety.RateLimiter{MaxRate: maxSlew}}
+	axes := []*axis{roll, pitch}
+	if stabilizeYaw {
+		axes = append(axes, yaw)
+	}
+
+	var err error
+	if roll.servo, err = servo.New(rollPWM, rollPin); err != nil {
+		println("Failed to set up roll servo:", err.Error())
+		return
+	}
+	if pitch.servo, err = servo.New(pwm, tiltPin); err != nil {
+		println("Failed to set up pitch servo:", err.Error())
+		return
+	}
+	if stabilizeYaw {
+		if yaw.servo, err = servo.New(pwm, panPin); err != nil {
+			println("Failed to set up yaw servo:", err.Error())
+			return
+		}
+	}
+	for _, a := range axes {
+		a.servo.SetAngle(0)
+		a.slew.Hold(0, time.Now())
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The game rotation vector ignores the magnetometer, which the servo
+	// motors would disturb, so the output never jumps when a magnetic
+	// correction lands. Its slow yaw drift only matters with stabilizeYaw.
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 5000) // 200Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+	err = sensor.EnableReport(bno08x.SensorGyroscope, 5000)
+	if err != nil {
+		println("Failed to enable gyroscope:", err.Error())
+		return
+	}
+
+	interlock := safety.New(safety.Limits{MaxTilt: maxTilt, MaxRate: maxRate, DataTimeout: dataTimeout})
+
+	var q, ref bno08x.Quaternion
+	haveQuat := false
+
+	var sh shell.Shell
+	sh.Register("center", "center", "Make the current pose the reference", func(args []string) error {
+		if len(args) != 0 {
+			return shell.ErrUsage
+		}
+		if !haveQuat {
+			println("No orientation yet")
+			return nil
+		}
+		ref = q
+		println("Centered")
+		return nil
+	})
+	sh.Register("trim", "trim [roll|pitch|yaw <deg>]", "Set or show the servo centre offsets", func(args []string) error {
+		switch len(args) {
+		case 0:
+		case 2:
+			var a *axis
+			for _, c := range axes {
+				if c.name == args[0] {
+					a = c
+				}
+			}
+			d, err := strconv.ParseFloat(args[1], 32)
+			if a == nil || err != nil || d < -maxTrim || d > maxTrim {
+				return shell.ErrUsage
+			}
+			a.trim = float32(d)
+		default:
+			return shell.ErrUsage
+		}
+		for _, a := range axes {
+			println("  "+fmtutil.PadRight(a.name, 5), fmtutil.Float(a.trim, decimals), "deg")
+		}
+		return nil
+	})
+	sh.Register("reset", "reset", "Clear a safety fault", func(args []string) error {
+		if interlock.Reset() {
+			println("Safety interlock cleared")
+		} else {
+			println("Still outside limits:", interlock.Fault().String())
+		}
+		return nil
+	})
+
+	println("Type 'center' with the handle in its neutral pose, then 'reset' to start; 'help' for commands")
+	lastStatus := time.Now()
+	var r, p, y, rate float32
+
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorGameRotationVector:
+				q = event.Quaternion()
+				if !haveQuat {
+					// Level, facing the handle's heading at start-up
+					_, _, yaw0 := quat.ToEuler(q)
+					ref = headingOnly(yaw0)
+					haveQuat = true
+				}
+				r, p, y = quat.ToEuler(quat.Relative(ref, q))
+				r, p, y = units.RadiansToDegrees(r), units.RadiansToDegrees(p), units.RadiansToDegrees(y)
+				if interlock.Update(r, p, rate, now) {
+					roll.drive(r, now)
+					pitch.drive(p, now)
+					if stabilizeYaw {
+						yaw.drive(y, now)
+					}
+				}
+			case bno08x.SensorGyroscope:
+				g := event.Gyroscope()
+				rate = units.RadiansToDegrees(float32(math.Sqrt(float64(g.X*g.X + g.Y*g.Y + g.Z*g.Z))))
+			}
+		}
+
+		if !interlock.Check(now) {
+			// Hold still; resume from the current position once cleared
+			for _, a := range axes {
+				a.slew.Hold(a.servo.Angle(), now)
+			}
+		}
+
+		if now.Sub(lastStatus) >= statusInterval && haveQuat {
+			lastStatus = now
+			status := "ok"
+			if interlock.Faulted() {
+				status = "FAULT " + interlock.Fault().String() + " (type 'reset')"
+			}
+			println("Handle roll", fmtutil.Float(r, decimals), "pitch", fmtutil.Float(p, decimals),
+				"yaw", fmtutil.Float(y, decimals), "| servos", fmtutil.Float(roll.servo.Angle(), decimals),
+				fmtutil.Float(pitch.servo.Angle(), decimals), "|", status)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// headingOnly returns the rotation by yaw radians about the vertical.
+func headingOnly(yaw float32) bno08x.Quaternion {
+	s, c := math.Sincos(float64(yaw) / 2)
+	return bno08x.Quaternion{Real: float32(c), K: float32(s)}
+}
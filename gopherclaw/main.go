@@ -4,11 +4,13 @@
 package main
 
 import (
-	"machine"
 	"machine/usb/adc/midi"
 	"math"
 	"time"
 
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 	"tinygo.org/x/drivers/bno08x"
 )
 
@@ -35,21 +37,16 @@ var (
 
 func main() {
 	time.Sleep(2 * time.Second) // Wait for sensor to power up
-
-	// Initialize I2C bus
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{
-		Frequency: 400 * machine.KHz,
-	})
-	if err != nil {
-		println("Failed to configure I2C:", err.Error())
-		return
-	}
+	buildinfo.Banner("gopherclaw")
 
 	println("Initializing BNO08x sensor...")
 
 	// Create and configure sensor
-	sensor := bno08x.New(i2c)
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
 	err = sensor.Configure(bno08x.Config{})
 	if err != nil {
 		println("Failed to configure sensor:", err.Error())
@@ -75,7 +72,7 @@ func main() {
 			q := event.Quaternion()
 
 			// Convert quaternion to Euler angles (radians)
-			roll, pitch, yaw := quaternionToEuler(q)
+			roll, pitch, yaw := quat.ToEuler(q)
 
 			// Convert angles to MIDI CC values (0-127)
 			// Map -180° to +180° range to 0-127
@@ -104,31 +101,6 @@ func main() {
 	}
 }
 
-// quaternionToEuler converts a quaternion to Euler angles (roll, pitch, yaw).
-// Roll is rotation around X axis, Pitch around Y axis, Yaw around Z axis.
-// All angles are returned in radians.
-func quaternionToEuler(q bno08x.Quaternion) (roll, pitch, yaw float32) {
-	// Roll (x-axis rotation)
-	sinr_cosp := 2.0 * (q.Real*q.I + q.J*q.K)
-	cosr_cosp := 1.0 - 2.0*(q.I*q.I+q.J*q.J)
-	roll = float32(math.Atan2(float64(sinr_cosp), float64(cosr_cosp)))
-
-	// Pitch (y-axis rotation)
-	sinp := 2.0 * (q.Real*q.J - q.K*q.I)
-	if math.Abs(float64(sinp)) >= 1 {
-		pitch = float32(math.Copysign(math.Pi/2, float64(sinp)))
-	} else {
-		pitch = float32(math.Asin(float64(sinp)))
-	}
-
-	// Yaw (z-axis rotation)
-	siny_cosp := 2.0 * (q.Real*q.K + q.I*q.J)
-	cosy_cosp := 1.0 - 2.0*(q.J*q.J+q.K*q.K)
-	yaw = float32(math.Atan2(float64(siny_cosp), float64(cosy_cosp)))
-
-	return roll, pitch, yaw
-}
-
 // angleToMIDI converts an angle in radians to a MIDI CC value (0-127)
 // Maps -90° to +90° to the full 0-127 range, clamping values outside this range
 func angleToMIDI(angle float32) uint8 {
@@ -0,0 +1,132 @@
+// Package main turns the board into a wireless head tracker for flight and
+// racing simulators, sending the orientation to OpenTrack's "UDP over
+// network" input. Each datagram is six little-endian float64: x, y and z
+// in centimetres, always zero as the sensor has no position, then yaw,
+// pitch and roll in degrees.
+//
+// Mount the board on headphones with its X axis pointing forward and Z
+// up. The pose at start-up is the centre; look at the screen and type
+// "center" to re-center, and invert axes in OpenTrack's mapping if one
+// turns the wrong way.
+//
+// Build with "-tags wifi" for a board with a netdev WiFi driver, such as
+// the Pico W, after setting the network and the OpenTrack address in
+// wifi.go. Other builds print the angles on the console instead.
+//
+//	center  make the current pose the centre
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// OpenTrack's default UDP input port
+const openTrackPort = 4242
+
+// Length of an OpenTrack datagram
+const packetLen = 6 * 8
+
+var errNoOrientation = errors.New("no rotation vector received yet")
+
+// sender delivers datagrams to OpenTrack.
+type sender interface {
+	Send(packet []byte) error
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("headtracker_udp")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The game rotation vector ignores the magnetometer, which speakers
+	// and desk metal would disturb; its slow yaw drift is what "center"
+	// is for
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 10000) // 100Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	out, err := dial()
+	if err != nil {
+		println("Failed to open UDP socket:", err.Error())
+		return
+	}
+
+	var q, center bno08x.Quaternion
+	haveQuat, centered := false, false
+
+	var sh shell.Shell
+	sh.Register("center", "center", "Make the current pose the centre", func(args []string) error {
+		if len(args) != 0 {
+			return shell.ErrUsage
+		}
+		if !haveQuat {
+			return errNoOrientation
+		}
+		center, centered = q, true
+		println("Centered")
+		return nil
+	})
+
+	var packet [packetLen]byte
+	var sendErrors uint32
+
+	for {
+		sh.Poll()
+
+		event, ok := sensor.GetSensorEvent()
+		if !ok || event.ID() != bno08x.SensorGameRotationVector {
+			time.Sleep(2 * time.Millisecond)
+			continue
+		}
+		q, haveQuat = event.Quaternion(), true
+		if !centered {
+			center, centered = q, true
+			println("Centered at start-up pose")
+		}
+
+		roll, pitch, yaw := quat.ToEuler(quat.Relative(center, q))
+		b := appendPacket(packet[:0], units.RadiansToDegrees(yaw), units.RadiansToDegrees(pitch), units.RadiansToDegrees(roll))
+		if err := out.Send(b); err != nil {
+			sendErrors++
+			// Report the first failure and then every hundredth
+			if sendErrors%100 == 1 {
+				println("Send failed:", err.Error(), "-", sendErrors, "failures so far")
+			}
+		}
+	}
+}
+
+// appendPacket appends an OpenTrack datagram with zero position.
+func appendPacket(dst []byte, yaw, pitch, roll float32) []byte {
+	for _, v := range [6]float32{0, 0, 0, yaw, pitch, roll} {
+		dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(float64(v)))
+	}
+	return dst
+}
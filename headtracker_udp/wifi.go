@@ -0,0 +1,46 @@
+//go:build wifi
+
+package main
+
+import (
+	"net"
+	"strconv"
+
+	"tinygo.org/x/drivers/netlink"
+	"tinygo.org/x/drivers/netlink/probe"
+)
+
+// WiFi network to join, and the address of the PC running OpenTrack. The
+// subnet's broadcast address reaches OpenTrack without knowing the PC's
+// address, where the network allows it.
+const (
+	ssid       = ""
+	passphrase = ""
+	openTrack  = "192.168.1.255"
+)
+
+// udpSender sends datagrams on a connected UDP socket.
+type udpSender struct {
+	conn net.Conn
+}
+
+func (u *udpSender) Send(packet []byte) error {
+	_, err := u.conn.Write(packet)
+	return err
+}
+
+func dial() (sender, error) {
+	link, _ := probe.Probe()
+	println("Joining WiFi network", ssid+"...")
+	err := link.NetConnect(&netlink.ConnectParams{Ssid: ssid, Passphrase: passphrase})
+	if err != nil {
+		return nil, err
+	}
+	addr := net.JoinHostPort(openTrack, strconv.Itoa(openTrackPort))
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	println("Sending to OpenTrack at", addr)
+	return &udpSender{conn: conn}, nil
+}
@@ -0,0 +1,38 @@
+//go:build !wifi
+
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+)
+
+// Interval between lines printed by consoleSender
+const printInterval = time.Second
+
+// consoleSender prints the angles of each datagram instead of sending it,
+// on boards without WiFi or builds without "-tags wifi".
+type consoleSender struct {
+	lastPrint time.Time
+}
+
+func (c *consoleSender) Send(packet []byte) error {
+	if time.Since(c.lastPrint) < printInterval {
+		return nil
+	}
+	c.lastPrint = time.Now()
+	angle := func(i int) string {
+		v := math.Float64frombits(binary.LittleEndian.Uint64(packet[i*8:]))
+		return fmtutil.Float(float32(v), 1)
+	}
+	println("Yaw:", angle(3), "Pitch:", angle(4), "Roll:", angle(5))
+	return nil
+}
+
+func dial() (sender, error) {
+	println("Built without -tags wifi: angles are printed, not sent")
+	return &consoleSender{}, nil
+}
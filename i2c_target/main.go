@@ -0,0 +1,171 @@
+// Package main re-exports the BNO08x's fused data as a simple I2C target on
+// a second bus, board.TargetI2C, for legacy hosts that expect a BNO055
+// register interface and cannot speak SHTP. The host addresses the board
+// at targetAddress, writes a register number and reads from there on, the
+// address advancing with each byte:
+//
+//	0x00        CHIP_ID, 0xA0 as on a BNO055
+//	0x08-0x0D   accelerometer X, Y, Z, 100 LSB per m/s²
+//	0x14-0x19   gyroscope X, Y, Z, 16 LSB per °/s
+//	0x1A-0x1F   Euler heading, roll, pitch, 16 LSB per degree
+//	0x20-0x27   quaternion W, X, Y, Z, 2^14 LSB per unit
+//	0x28-0x2D   linear acceleration X, Y, Z, 100 LSB per m/s²
+//	0x2E-0x33   gravity X, Y, Z, 100 LSB per m/s²
+//	0x35        CALIB_STAT, always 0xFF
+//	0x39        SYS_STATUS, 5 (fusion running)
+//	0x3D        OPR_MODE, 0x0C (NDOF) until written
+//
+// Values are little-endian int16. The heading follows the compass, 0 to
+// 360 degrees clockwise from magnetic north; roll and pitch are those of
+// internal/quat and may differ in sign from a BNO055 mounted the same way.
+// Other registers read 0. A read never mixes two updates of a value.
+package main
+
+import (
+	"machine"
+	"sync"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Target address, the BNO055 default
+const targetAddress = 0x28
+
+// Report interval of every exported sensor in microseconds
+const interval = 10000 // 100Hz, the BNO055 fusion rate
+
+// Interval between status lines
+const statusInterval = 10 * time.Second
+
+// Sensors exported through the register map
+var sensors = []bno08x.SensorID{
+	bno08x.SensorAccelerometer,
+	bno08x.SensorGyroscope,
+	bno08x.SensorLinearAcceleration,
+	bno08x.SensorGravity,
+	bno08x.SensorRotationVector,
+}
+
+// target serves the register map to the controller on the target bus.
+type target struct {
+	bus *machine.I2C
+
+	mu    sync.Mutex // Guards regs
+	regs  registerMap
+	reads uint32
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("i2c_target")
+
+	bus, config := board.TargetI2C()
+	if bus == nil {
+		println("No target I2C bus assigned for the " + board.Name + " board, see internal/board")
+		return
+	}
+	config.Mode = machine.I2CModeTarget
+	if err := bus.Configure(config); err != nil {
+		println("Failed to configure target I2C:", err.Error())
+		return
+	}
+	if err := bus.Listen(targetAddress); err != nil {
+		println("Failed to listen on target I2C:", err.Error())
+		return
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range sensors {
+		if err := sensor.EnableReport(id, interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	t := &target{bus: bus}
+	t.regs.reset()
+	go t.serve()
+	println("Answering as a BNO055 at address", targetAddress)
+
+	var updates uint32
+	lastStatus := time.Now()
+
+	for {
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			t.mu.Lock()
+			t.regs.update(&event)
+			t.mu.Unlock()
+			updates++
+		}
+
+		if time.Since(lastStatus) >= statusInterval {
+			lastStatus = time.Now()
+			t.mu.Lock()
+			reads := t.reads
+			t.mu.Unlock()
+			println("Updates:", updates, "reads:", reads)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+// serve answers the controller: a write sets the register pointer and
+// stores any following bytes, and a read returns the registers from the
+// pointer on.
+func (t *target) serve() {
+	var buf [16]byte
+	var reply [numRegs]byte
+	ptr := 0
+	for {
+		event, n, err := t.bus.WaitForEvent(buf[:])
+		if err != nil {
+			continue
+		}
+		switch event {
+		case machine.I2CReceive:
+			if n == 0 {
+				continue
+			}
+			ptr = int(buf[0]) % numRegs
+			t.mu.Lock()
+			for i := 1; i < n && ptr+i-1 < numRegs; i++ {
+				if reg := ptr + i - 1; writable(reg) {
+					t.regs[reg] = buf[i]
+				}
+			}
+			t.mu.Unlock()
+		case machine.I2CRequest:
+			// Copy under the lock so a multi-byte value is never torn
+			t.mu.Lock()
+			m := copy(reply[:], t.regs[ptr:])
+			t.reads++
+			t.mu.Unlock()
+			t.bus.Reply(reply[:m])
+		}
+	}
+}
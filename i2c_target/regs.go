@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/intermernet/bno08xPrograms/internal/heading"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Register addresses, a subset of BNO055 page 0. Multi-byte values are
+// little-endian int16, LSB first, as on the BNO055.
+const (
+	regChipID     = 0x00 // Reads chipID
+	regPageID     = 0x07
+	regAccel      = 0x08 // X, Y, Z; 100 LSB per m/s²
+	regGyro       = 0x14 // X, Y, Z; 16 LSB per °/s
+	regEuler      = 0x1A // Heading, roll, pitch; 16 LSB per degree
+	regQuaternion = 0x20 // W, X, Y, Z; 2^14 LSB per unit
+	regLinear     = 0x28 // X, Y, Z; 100 LSB per m/s²
+	regGravity    = 0x2E // X, Y, Z; 100 LSB per m/s²
+	regCalibStat  = 0x35 // System, gyro, accel and mag, two bits each
+	regSysStatus  = 0x39
+	regOprMode    = 0x3D
+
+	numRegs = 0x80
+)
+
+// Register values
+const (
+	chipID        = 0xA0 // BNO055 chip ID, which legacy hosts check
+	sysFusion     = 5    // SYS_STATUS: fusion algorithm running
+	oprModeNDOF   = 0x0C // OPR_MODE: nine-axis fusion
+	calibrated    = 0xFF // CALIB_STAT: everything fully calibrated
+	accelLSB      = 100
+	gyroLSB       = 16
+	eulerLSB      = 16
+	quaternionLSB = 1 << 14
+)
+
+// registerMap is the register image a controller reads.
+type registerMap [numRegs]byte
+
+// reset sets the fixed registers.
+func (r *registerMap) reset() {
+	*r = registerMap{}
+	r[regChipID] = chipID
+	r[regSysStatus] = sysFusion
+	r[regOprMode] = oprModeNDOF
+	r[regCalibStat] = calibrated
+}
+
+// writable reports whether a controller's write to reg is kept. Hosts
+// switch OPR_MODE to configure a BNO055 and read it back, so it and
+// PAGE_ID hold what was written, but nothing changes: the BNO08x always
+// runs its fusion. Writes to other registers, such as SYS_TRIGGER, are
+// ignored.
+func writable(reg int) bool {
+	return reg == regPageID || reg == regOprMode
+}
+
+// update stores the value of event in its registers.
+func (r *registerMap) update(event *bno08x.SensorValue) {
+	switch event.ID() {
+	case bno08x.SensorAccelerometer:
+		r.vector(regAccel, event.Accelerometer(), accelLSB)
+	case bno08x.SensorGyroscope:
+		g := event.Gyroscope()
+		r.put(regGyro, units.RadiansToDegrees(g.X)*gyroLSB, units.RadiansToDegrees(g.Y)*gyroLSB,
+			units.RadiansToDegrees(g.Z)*gyroLSB)
+	case bno08x.SensorLinearAcceleration:
+		r.vector(regLinear, event.LinearAcceleration(), accelLSB)
+	case bno08x.SensorGravity:
+		r.vector(regGravity, event.Gravity(), accelLSB)
+	case bno08x.SensorRotationVector:
+		q := event.Quaternion()
+		r.put(regQuaternion, q.Real*quaternionLSB, q.I*quaternionLSB, q.J*quaternionLSB, q.K*quaternionLSB)
+		roll, pitch, _ := quat.ToEuler(q)
+		r.put(regEuler, heading.Magnetic(q)*eulerLSB, units.RadiansToDegrees(roll)*eulerLSB,
+			units.RadiansToDegrees(pitch)*eulerLSB)
+	}
+}
+
+func (r *registerMap) vector(reg int, v bno08x.Vector3, lsb float32) {
+	r.put(reg, v.X*lsb, v.Y*lsb, v.Z*lsb)
+}
+
+// put stores values as consecutive int16 registers from reg, rounded and
+// saturated.
+func (r *registerMap) put(reg int, values ...float32) {
+	for i, v := range values {
+		s := math.Round(float64(v))
+		switch {
+		case s > math.MaxInt16:
+			s = math.MaxInt16
+		case s < math.MinInt16:
+			s = math.MinInt16
+		}
+		binary.LittleEndian.PutUint16(r[reg+2*i:], uint16(int16(s)))
+	}
+}
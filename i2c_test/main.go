@@ -5,15 +5,19 @@ import (
 	"encoding/binary"
 	"machine"
 	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
 )
 
 func main() {
 	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("i2c_test")
 	println("=== BNO08x Minimal I2C Test ===")
 	println()
 
 	// Initialize I2C
-	i2c := machine.I2C0
+	i2c := board.IMUBus()
 	err := i2c.Configure(machine.I2CConfig{
 		Frequency: 400 * machine.KHz,
 	})
@@ -0,0 +1,144 @@
+// Package main is a two-axis inclinometer printing roll and pitch from the
+// game rotation vector.
+//
+// Serial commands:
+//
+//	hires  toggle high-resolution mode, where each reading is the average of
+//	       many samples (a proper quaternion mean, not per-angle averaging)
+//	zero   capture the current orientation, averaged, as the reference; later
+//	       angles are reported relative to it
+//	clear  drop the reference and report absolute angles again
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Samples averaged into one reading in high-resolution mode (0.5s at 100Hz)
+	hiresSamples = 50
+	// Samples averaged when capturing the reference orientation (2s at 100Hz)
+	referenceSamples = 200
+	// Interval between readings in normal mode
+	printInterval = 100 * time.Millisecond
+	// Decimal places printed for the angles
+	decimals = 2
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("inclinometer")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// Game rotation vector: no magnetometer, so no heading jumps near metal
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 10000) // 100Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	println("Format: Roll Pitch Tilt (degrees)")
+	println("Commands: hires | zero | clear")
+
+	var commands shell.LineReader
+	var avg, refAvg quat.Averager
+	hires := false
+	capturing := false
+	reference := quat.Identity
+	lastPrint := time.Now()
+
+	for {
+		if line, ok := commands.Poll(); ok {
+			switch line {
+			case "hires":
+				hires = !hires
+				avg.Reset()
+				println("High-resolution mode:", hires)
+			case "zero":
+				capturing = true
+				refAvg.Reset()
+				println("Capturing reference, hold still...")
+			case "clear":
+				reference = quat.Identity
+				println("Reference cleared")
+			default:
+				println("Unknown command. Commands: hires | zero | clear")
+			}
+		}
+
+		event, ok := sensor.GetSensorEvent()
+		if !ok || event.ID() != bno08x.SensorGameRotationVector {
+			time.Sleep(2 * time.Millisecond)
+			continue
+		}
+		q := event.Quaternion()
+
+		if capturing {
+			refAvg.Add(q)
+			if refAvg.Count() >= referenceSamples {
+				reference = refAvg.Mean()
+				capturing = false
+				println("Reference captured from", refAvg.Count(), "samples")
+			}
+			continue
+		}
+
+		if hires {
+			avg.Add(q)
+			if avg.Count() < hiresSamples {
+				continue
+			}
+			q = avg.Mean()
+			avg.Reset()
+		} else if time.Since(lastPrint) < printInterval {
+			continue
+		}
+		lastPrint = time.Now()
+
+		rel := quat.Relative(reference, q)
+		roll, pitch, _ := quat.ToEuler(rel)
+		tilt := tiltAngle(rel)
+		println(fmtutil.Float(toDegrees(roll), decimals), fmtutil.Float(toDegrees(pitch), decimals), fmtutil.Float(toDegrees(tilt), decimals))
+	}
+}
+
+// tiltAngle returns the angle in radians between the sensor's Z axis and
+// the reference Z axis, independent of heading.
+func tiltAngle(q bno08x.Quaternion) float32 {
+	// Z component of the rotated Z axis (third row, third column)
+	cz := 1 - 2*(q.I*q.I+q.J*q.J)
+	if cz > 1 {
+		cz = 1
+	} else if cz < -1 {
+		cz = -1
+	}
+	return float32(math.Acos(float64(cz)))
+}
+
+// toDegrees converts radians to degrees
+func toDegrees(rad float32) float32 {
+	return rad * 180.0 / math.Pi
+}
@@ -0,0 +1,186 @@
+// Package ahrs estimates orientation from gyroscope and accelerometer
+// samples with the two filters common on flight controllers, Madgwick's
+// gradient descent filter and Mahony's complementary filter, for comparing
+// against the BNO08x's own fusion. Neither uses a magnetometer, so like the
+// game rotation vector their yaw starts at zero and drifts.
+//
+// Samples use the sensor's axes: angular rates in rad/s and acceleration in
+// any unit, as only its direction is used. The world frame has Z up, so a
+// board lying flat reads the identity once the filter has settled.
+package ahrs
+
+import (
+	"math"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Filter is an attitude estimator.
+type Filter interface {
+	// Update advances the estimate by dt seconds of rotation at the
+	// angular rate g and corrects it towards the gravity direction a.
+	Update(gx, gy, gz, ax, ay, az, dt float32)
+	// Quaternion returns the current estimate.
+	Quaternion() bno08x.Quaternion
+	// Reset forgets the estimate; the next Update starts from the tilt of
+	// its accelerometer sample.
+	Reset()
+}
+
+// Default gains, the values of the reference implementations.
+const (
+	DefaultBeta = 0.1
+	DefaultKp   = 1.0
+	DefaultKi   = 0.0
+)
+
+// Madgwick is Madgwick's filter. Beta sets how strongly the accelerometer
+// corrects the integrated gyroscope; higher converges faster but lets more
+// vibration through.
+type Madgwick struct {
+	Beta   float32
+	q      bno08x.Quaternion
+	primed bool
+}
+
+// NewMadgwick returns a Madgwick filter with DefaultBeta.
+func NewMadgwick() *Madgwick {
+	return &Madgwick{Beta: DefaultBeta}
+}
+
+func (f *Madgwick) Update(gx, gy, gz, ax, ay, az, dt float32) {
+	if !f.primed {
+		f.q, f.primed = fromTilt(ax, ay, az), true
+		return
+	}
+	q0, q1, q2, q3 := f.q.Real, f.q.I, f.q.J, f.q.K
+
+	// Rate of change of the quaternion from the gyroscope
+	qd0 := 0.5 * (-q1*gx - q2*gy - q3*gz)
+	qd1 := 0.5 * (q0*gx + q2*gz - q3*gy)
+	qd2 := 0.5 * (q0*gy - q1*gz + q3*gx)
+	qd3 := 0.5 * (q0*gz + q1*gy - q2*gx)
+
+	// Gradient descent step towards the measured gravity direction,
+	// skipped in free fall when there is none
+	if n := norm3(ax, ay, az); n > 0 {
+		ax, ay, az = ax/n, ay/n, az/n
+		f1 := 2*(q1*q3-q0*q2) - ax
+		f2 := 2*(q0*q1+q2*q3) - ay
+		f3 := 1 - 2*(q1*q1+q2*q2) - az
+		s0 := -2*q2*f1 + 2*q1*f2
+		s1 := 2*q3*f1 + 2*q0*f2 - 4*q1*f3
+		s2 := -2*q0*f1 + 2*q3*f2 - 4*q2*f3
+		s3 := 2*q1*f1 + 2*q2*f2
+		if n := norm4(s0, s1, s2, s3); n > 0 {
+			qd0 -= f.Beta * s0 / n
+			qd1 -= f.Beta * s1 / n
+			qd2 -= f.Beta * s2 / n
+			qd3 -= f.Beta * s3 / n
+		}
+	}
+
+	f.q = normalize(q0+qd0*dt, q1+qd1*dt, q2+qd2*dt, q3+qd3*dt)
+}
+
+func (f *Madgwick) Quaternion() bno08x.Quaternion {
+	return f.q
+}
+
+func (f *Madgwick) Reset() {
+	f.primed = false
+}
+
+// Mahony is Mahony's filter. Kp sets how strongly the accelerometer
+// corrects the gyroscope, and Ki how fast a gyroscope bias is learnt;
+// zero Ki leaves any bias uncorrected.
+type Mahony struct {
+	Kp, Ki     float32
+	q          bno08x.Quaternion
+	ix, iy, iz float32 // Integral of the error, the learnt bias
+	primed     bool
+}
+
+// NewMahony returns a Mahony filter with DefaultKp and DefaultKi.
+func NewMahony() *Mahony {
+	return &Mahony{Kp: DefaultKp, Ki: DefaultKi}
+}
+
+func (f *Mahony) Update(gx, gy, gz, ax, ay, az, dt float32) {
+	if !f.primed {
+		f.q, f.primed = fromTilt(ax, ay, az), true
+		return
+	}
+	q0, q1, q2, q3 := f.q.Real, f.q.I, f.q.J, f.q.K
+
+	if n := norm3(ax, ay, az); n > 0 {
+		ax, ay, az = ax/n, ay/n, az/n
+		// Gravity direction predicted by the estimate
+		vx := 2 * (q1*q3 - q0*q2)
+		vy := 2 * (q0*q1 + q2*q3)
+		vz := q0*q0 - q1*q1 - q2*q2 + q3*q3
+		// The error is the rotation between measured and predicted
+		ex := ay*vz - az*vy
+		ey := az*vx - ax*vz
+		ez := ax*vy - ay*vx
+		if f.Ki > 0 {
+			f.ix += f.Ki * ex * dt
+			f.iy += f.Ki * ey * dt
+			f.iz += f.Ki * ez * dt
+		}
+		gx += f.Kp*ex + f.ix
+		gy += f.Kp*ey + f.iy
+		gz += f.Kp*ez + f.iz
+	}
+
+	gx, gy, gz = gx*dt/2, gy*dt/2, gz*dt/2
+	f.q = normalize(
+		q0-q1*gx-q2*gy-q3*gz,
+		q1+q0*gx+q2*gz-q3*gy,
+		q2+q0*gy-q1*gz+q3*gx,
+		q3+q0*gz+q1*gy-q2*gx,
+	)
+}
+
+func (f *Mahony) Quaternion() bno08x.Quaternion {
+	return f.q
+}
+
+func (f *Mahony) Reset() {
+	f.ix, f.iy, f.iz = 0, 0, 0
+	f.primed = false
+}
+
+// fromTilt returns the orientation with zero yaw whose gravity direction
+// is a, or the identity if a is zero.
+func fromTilt(ax, ay, az float32) bno08x.Quaternion {
+	if norm3(ax, ay, az) == 0 {
+		return bno08x.Quaternion{Real: 1}
+	}
+	roll := math.Atan2(float64(ay), float64(az))
+	pitch := math.Atan2(float64(-ax), math.Hypot(float64(ay), float64(az)))
+	sr, cr := math.Sincos(roll / 2)
+	sp, cp := math.Sincos(pitch / 2)
+	return bno08x.Quaternion{
+		Real: float32(cr * cp),
+		I:    float32(sr * cp),
+		J:    float32(cr * sp),
+		K:    float32(-sr * sp),
+	}
+}
+
+func normalize(q0, q1, q2, q3 float32) bno08x.Quaternion {
+	n := norm4(q0, q1, q2, q3)
+	if n == 0 {
+		return bno08x.Quaternion{Real: 1}
+	}
+	return bno08x.Quaternion{Real: q0 / n, I: q1 / n, J: q2 / n, K: q3 / n}
+}
+
+func norm3(x, y, z float32) float32 {
+	return float32(math.Sqrt(float64(x*x + y*y + z*z)))
+}
+
+func norm4(a, b, c, d float32) float32 {
+	return float32(math.Sqrt(float64(a*a + b*b + c*c + d*d)))
+}
@@ -0,0 +1,23 @@
+// Package board describes how the BNO08x is wired on each supported
+// development board, so that the example programs run unchanged on any of
+// them. The definitions are selected by the build tags TinyGo sets for the
+// target, e.g. "tinygo flash -target=pico" picks board_pico.go.
+//
+// Boards without a dedicated file fall back to board_generic.go, which uses
+// the target's default I2C0 pins and reports every optional pin as
+// machine.NoPin. Edit the file for your board if your wiring differs.
+//
+// Pins returned as machine.NoPin are not wired; callers must check before
+// configuring them.
+package board
+
+import "machine"
+
+// PWM is the part of a machine PWM peripheral used to drive servos. The
+// concrete PWM types differ between targets, so ServoPWM returns this.
+type PWM interface {
+	Configure(config machine.PWMConfig) error
+	Channel(pin machine.Pin) (uint8, error)
+	Top() uint32
+	Set(channel uint8, value uint32)
+}
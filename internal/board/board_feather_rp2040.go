@@ -0,0 +1,108 @@
+//go:build feather_rp2040
+
+package board
+
+import "machine"
+
+// Name identifies the board in startup banners and logs.
+const Name = "feather-rp2040"
+
+// IMUBus returns the I2C bus the BNO08x is attached to. On the Feather
+// RP2040 the STEMMA QT connector is I2C1, SDA=GPIO2 and SCL=GPIO3.
+func IMUBus() *machine.I2C {
+	return machine.I2C1
+}
+
+// IMUPins returns the SDA and SCL pins of IMUBus, for bit-banged bus
+// recovery.
+func IMUPins() (sda, scl machine.Pin) {
+	return machine.GPIO2, machine.GPIO3
+}
+
+// ResetPin returns the GPIO wired to the BNO08x RST pin (D4).
+func ResetPin() machine.Pin {
+	return machine.GPIO6
+}
+
+// PowerPin returns the GPIO driving a MOSFET that switches the BNO08x
+// supply (GPIO8), for the diagnostic power-cycle test.
+func PowerPin() machine.Pin {
+	return machine.GPIO8
+}
+
+// IntPin returns the GPIO wired to the BNO08x INT pin (D5).
+func IntPin() machine.Pin {
+	return machine.GPIO7
+}
+
+// LEDPin returns the on-board red status LED.
+func LEDPin() machine.Pin {
+	return machine.LED
+}
+
+// NeoPixelPin returns the data pin of the on-board WS2812 LED.
+func NeoPixelPin() machine.Pin {
+	return machine.WS2812
+}
+
+// CSPin returns the SPI chip select wired to the BNO08x CS pin (D9),
+// used when the sensor is strapped for SPI.
+func CSPin() machine.Pin {
+	return machine.GPIO9
+}
+
+// WakePin returns the GPIO wired to the BNO08x PS0/WAKE pin (D10), used
+// when the sensor is strapped for SPI.
+func WakePin() machine.Pin {
+	return machine.GPIO10
+}
+
+// RVCUART returns the UART receiving the BNO08x UART-RVC stream and its
+// pins: UART0 on the TX and RX pins. Wire the sensor's SDA/TX pin to RX.
+func RVCUART() (*machine.UART, machine.UARTConfig) {
+	return machine.UART0, machine.UARTConfig{TX: machine.GPIO0, RX: machine.GPIO1}
+}
+
+// LinkUART returns the UART carrying data to another device, such as a
+// flight controller or telemetry radio, and its pins: UART0 on the TX and
+// RX pins, shared with RVCUART.
+func LinkUART() (*machine.UART, machine.UARTConfig) {
+	return machine.UART0, machine.UARTConfig{TX: machine.GPIO0, RX: machine.GPIO1}
+}
+
+// ServoPWM returns the PWM peripheral and the pins driving a pan and a
+// tilt servo: PWM6 on GPIO12 (D12) and GPIO29 (A3). GPIO13 shares the
+// slice but drives the red LED.
+func ServoPWM() (pwm PWM, pan, tilt machine.Pin) {
+	return machine.PWM6, machine.GPIO12, machine.GPIO29
+}
+
+// CANSPI returns the SPI bus and chip select of an MCP2515 CAN controller:
+// SPI0 on the SCK, MO and MI pins, and CS on D11.
+func CANSPI() (*machine.SPI, machine.SPIConfig, machine.Pin) {
+	return machine.SPI0, machine.SPIConfig{SCK: machine.GPIO18, SDO: machine.GPIO19, SDI: machine.GPIO20}, machine.GPIO11
+}
+
+// TargetI2C returns the I2C bus on which the board acts as a target for
+// another controller, and its pins: I2C0 with SDA on D24 and SCL on D25.
+func TargetI2C() (*machine.I2C, machine.I2CConfig) {
+	return machine.I2C0, machine.I2CConfig{SDA: machine.GPIO24, SCL: machine.GPIO25}
+}
+
+// RollServoPWM returns the PWM peripheral and the pin driving a third
+// servo, the roll axis of a gimbal: PWM5 on GPIO26 (A0).
+func RollServoPWM() (pwm PWM, pin machine.Pin) {
+	return machine.PWM5, machine.GPIO26
+}
+
+// ButtonPin returns the GPIO of a push button wired to ground on GPIO27
+// (A1), read with the internal pull-up. The BOOT button is not readable.
+func ButtonPin() machine.Pin {
+	return machine.GPIO27
+}
+
+// BootPin returns the GPIO wired to the BNO08x BOOTN pin (A2), held low
+// through a reset to start the bootloader for a firmware update.
+func BootPin() machine.Pin {
+	return machine.GPIO28
+}
@@ -0,0 +1,108 @@
+//go:build !pico && !feather_rp2040 && !xiao_ble
+
+package board
+
+import "machine"
+
+// Name identifies the board in startup banners and logs.
+const Name = "generic"
+
+// IMUBus returns the I2C bus the BNO08x is attached to, I2C0 on the
+// target's default pins.
+func IMUBus() *machine.I2C {
+	return machine.I2C0
+}
+
+// IMUPins returns the SDA and SCL pins of IMUBus, for bit-banged bus
+// recovery: the target's default I2C pins.
+func IMUPins() (sda, scl machine.Pin) {
+	return machine.SDA_PIN, machine.SCL_PIN
+}
+
+// ResetPin returns the GPIO wired to the BNO08x RST pin, or machine.NoPin.
+func ResetPin() machine.Pin {
+	return machine.NoPin
+}
+
+// PowerPin returns the GPIO driving a MOSFET that switches the BNO08x
+// supply, or machine.NoPin.
+func PowerPin() machine.Pin {
+	return machine.NoPin
+}
+
+// IntPin returns the GPIO wired to the BNO08x INT pin, or machine.NoPin.
+func IntPin() machine.Pin {
+	return machine.NoPin
+}
+
+// LEDPin returns the on-board status LED, or machine.NoPin.
+func LEDPin() machine.Pin {
+	return machine.NoPin
+}
+
+// NeoPixelPin returns the data pin of a WS2812 LED, or machine.NoPin.
+func NeoPixelPin() machine.Pin {
+	return machine.NoPin
+}
+
+// CSPin returns the SPI chip select wired to the BNO08x CS pin, or
+// machine.NoPin.
+func CSPin() machine.Pin {
+	return machine.NoPin
+}
+
+// WakePin returns the GPIO wired to the BNO08x PS0/WAKE pin, or
+// machine.NoPin.
+func WakePin() machine.Pin {
+	return machine.NoPin
+}
+
+// RVCUART returns the UART receiving the BNO08x UART-RVC stream and its
+// pins, or nil if none is assigned.
+func RVCUART() (*machine.UART, machine.UARTConfig) {
+	return nil, machine.UARTConfig{}
+}
+
+// LinkUART returns the UART carrying data to another device, such as a
+// flight controller or telemetry radio, and its pins, or nil if none is
+// assigned.
+func LinkUART() (*machine.UART, machine.UARTConfig) {
+	return nil, machine.UARTConfig{}
+}
+
+// ServoPWM returns the PWM peripheral and the pins driving a pan and a
+// tilt servo, or nil and machine.NoPin if none are assigned.
+func ServoPWM() (pwm PWM, pan, tilt machine.Pin) {
+	return nil, machine.NoPin, machine.NoPin
+}
+
+// CANSPI returns the SPI bus and chip select of an MCP2515 CAN controller,
+// or nil if none is assigned.
+func CANSPI() (*machine.SPI, machine.SPIConfig, machine.Pin) {
+	return nil, machine.SPIConfig{}, machine.NoPin
+}
+
+// TargetI2C returns the I2C bus on which the board acts as a target for
+// another controller, and its pins, or nil if none is assigned.
+func TargetI2C() (*machine.I2C, machine.I2CConfig) {
+	return nil, machine.I2CConfig{}
+}
+
+// RollServoPWM returns the PWM peripheral and the pin driving a third
+// servo, the roll axis of a gimbal, or nil and machine.NoPin if none is
+// assigned.
+func RollServoPWM() (pwm PWM, pin machine.Pin) {
+	return nil, machine.NoPin
+}
+
+// ButtonPin returns the GPIO of a push button wired to ground, or
+// machine.NoPin if none is assigned.
+func ButtonPin() machine.Pin {
+	return machine.NoPin
+}
+
+// BootPin returns the GPIO wired to the BNO08x BOOTN pin, or
+// machine.NoPin if none is assigned.
+func BootPin() machine.Pin {
+	return machine.NoPin
+}
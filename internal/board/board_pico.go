@@ -0,0 +1,109 @@
+//go:build pico
+
+package board
+
+import "machine"
+
+// Name identifies the board in startup banners and logs.
+const Name = "pico"
+
+// IMUBus returns the I2C bus the BNO08x is attached to. On the Pico this is
+// I2C0 on its default pins, SDA=GP4 and SCL=GP5.
+func IMUBus() *machine.I2C {
+	return machine.I2C0
+}
+
+// IMUPins returns the SDA and SCL pins of IMUBus, for bit-banged bus
+// recovery.
+func IMUPins() (sda, scl machine.Pin) {
+	return machine.GPIO4, machine.GPIO5
+}
+
+// ResetPin returns the GPIO wired to the BNO08x RST pin.
+func ResetPin() machine.Pin {
+	return machine.GPIO6
+}
+
+// PowerPin returns the GPIO driving a MOSFET that switches the BNO08x
+// supply (GP22), for the diagnostic power-cycle test.
+func PowerPin() machine.Pin {
+	return machine.GPIO22
+}
+
+// IntPin returns the GPIO wired to the BNO08x INT pin.
+func IntPin() machine.Pin {
+	return machine.GPIO7
+}
+
+// LEDPin returns the on-board status LED.
+func LEDPin() machine.Pin {
+	return machine.LED
+}
+
+// NeoPixelPin returns the data pin of an on-board WS2812 LED. The Pico has
+// none, so wire one to GP16 if you want to run the NeoPixel examples.
+func NeoPixelPin() machine.Pin {
+	return machine.GPIO16
+}
+
+// CSPin returns the SPI chip select wired to the BNO08x CS pin (GP17),
+// used when the sensor is strapped for SPI. SPI0 then uses SCK=GP18,
+// SDO=GP19 and SDI=GP16, so the NeoPixel examples cannot run alongside.
+func CSPin() machine.Pin {
+	return machine.GPIO17
+}
+
+// WakePin returns the GPIO wired to the BNO08x PS0/WAKE pin (GP8), used
+// when the sensor is strapped for SPI.
+func WakePin() machine.Pin {
+	return machine.GPIO8
+}
+
+// RVCUART returns the UART receiving the BNO08x UART-RVC stream and its
+// pins: UART1 with RX on GP21 (TX, unused, on GP20). Wire the sensor's SDA/TX pin to RX.
+func RVCUART() (*machine.UART, machine.UARTConfig) {
+	return machine.UART1, machine.UARTConfig{TX: machine.GPIO20, RX: machine.GPIO21}
+}
+
+// LinkUART returns the UART carrying data to another device, such as a
+// flight controller or telemetry radio, and its pins: UART0 with TX on GP0
+// and RX on GP1.
+func LinkUART() (*machine.UART, machine.UARTConfig) {
+	return machine.UART0, machine.UARTConfig{TX: machine.GPIO0, RX: machine.GPIO1}
+}
+
+// ServoPWM returns the PWM peripheral and the pins driving a pan and a
+// tilt servo: PWM5 on GP10 and GP11.
+func ServoPWM() (pwm PWM, pan, tilt machine.Pin) {
+	return machine.PWM5, machine.GPIO10, machine.GPIO11
+}
+
+// CANSPI returns the SPI bus and chip select of an MCP2515 CAN controller:
+// SPI1 with SCK on GP14, SDO on GP15 and SDI on GP12, and CS on GP13.
+func CANSPI() (*machine.SPI, machine.SPIConfig, machine.Pin) {
+	return machine.SPI1, machine.SPIConfig{SCK: machine.GPIO14, SDO: machine.GPIO15, SDI: machine.GPIO12}, machine.GPIO13
+}
+
+// TargetI2C returns the I2C bus on which the board acts as a target for
+// another controller, and its pins: I2C1 with SDA on GP2 and SCL on GP3.
+func TargetI2C() (*machine.I2C, machine.I2CConfig) {
+	return machine.I2C1, machine.I2CConfig{SDA: machine.GPIO2, SCL: machine.GPIO3}
+}
+
+// RollServoPWM returns the PWM peripheral and the pin driving a third
+// servo, the roll axis of a gimbal: PWM4 on GP9.
+func RollServoPWM() (pwm PWM, pin machine.Pin) {
+	return machine.PWM4, machine.GPIO9
+}
+
+// ButtonPin returns the GPIO of a push button wired to ground on GP28,
+// read with the internal pull-up.
+func ButtonPin() machine.Pin {
+	return machine.GPIO28
+}
+
+// BootPin returns the GPIO wired to the BNO08x BOOTN pin (GP27), held low
+// through a reset to start the bootloader for a firmware update.
+func BootPin() machine.Pin {
+	return machine.GPIO27
+}
@@ -0,0 +1,110 @@
+//go:build xiao_ble
+
+package board
+
+import "machine"
+
+// Name identifies the board in startup banners and logs.
+const Name = "xiao-ble"
+
+// IMUBus returns the I2C bus the BNO08x is attached to. On the XIAO
+// nRF52840 this is I2C0 on its default pins, SDA=D4 and SCL=D5.
+func IMUBus() *machine.I2C {
+	return machine.I2C0
+}
+
+// IMUPins returns the SDA and SCL pins of IMUBus, for bit-banged bus
+// recovery.
+func IMUPins() (sda, scl machine.Pin) {
+	return machine.D4, machine.D5
+}
+
+// ResetPin returns the GPIO wired to the BNO08x RST pin.
+func ResetPin() machine.Pin {
+	return machine.D2
+}
+
+// PowerPin returns the GPIO driving a MOSFET that switches the BNO08x
+// supply (D0), for the diagnostic power-cycle test.
+func PowerPin() machine.Pin {
+	return machine.D0
+}
+
+// IntPin returns the GPIO wired to the BNO08x INT pin.
+func IntPin() machine.Pin {
+	return machine.D3
+}
+
+// LEDPin returns the on-board status LED.
+func LEDPin() machine.Pin {
+	return machine.LED
+}
+
+// NeoPixelPin returns the data pin of an external WS2812 LED on D6.
+func NeoPixelPin() machine.Pin {
+	return machine.D6
+}
+
+// CSPin returns the SPI chip select wired to the BNO08x CS pin (D7),
+// used when the sensor is strapped for SPI.
+func CSPin() machine.Pin {
+	return machine.D7
+}
+
+// WakePin returns the GPIO wired to the BNO08x PS0/WAKE pin (D1), used
+// when the sensor is strapped for SPI.
+func WakePin() machine.Pin {
+	return machine.D1
+}
+
+// RVCUART returns the UART receiving the BNO08x UART-RVC stream and its
+// pins: UART0 with TX on D6 and RX on D7. Wire the sensor's SDA/TX pin to RX.
+func RVCUART() (*machine.UART, machine.UARTConfig) {
+	return machine.UART0, machine.UARTConfig{TX: machine.D6, RX: machine.D7}
+}
+
+// LinkUART returns the UART carrying data to another device, such as a
+// flight controller or telemetry radio, and its pins: UART0 with TX on D6
+// and RX on D7, shared with RVCUART.
+func LinkUART() (*machine.UART, machine.UARTConfig) {
+	return machine.UART0, machine.UARTConfig{TX: machine.D6, RX: machine.D7}
+}
+
+// ServoPWM returns the PWM peripheral and the pins driving a pan and a
+// tilt servo: PWM0 on D8 and D9.
+func ServoPWM() (pwm PWM, pan, tilt machine.Pin) {
+	return machine.PWM0, machine.D8, machine.D9
+}
+
+// CANSPI returns the SPI bus and chip select of an MCP2515 CAN controller:
+// SPI0 with SCK on D8, SDI on D9 and SDO on D10, shared with ServoPWM,
+// and CS on D1, shared with WakePin.
+func CANSPI() (*machine.SPI, machine.SPIConfig, machine.Pin) {
+	return machine.SPI0, machine.SPIConfig{SCK: machine.D8, SDO: machine.D10, SDI: machine.D9}, machine.D1
+}
+
+// TargetI2C returns the I2C bus on which the board acts as a target for
+// another controller, and its pins: I2C1 with SDA on D6 and SCL on D7,
+// shared with LinkUART.
+func TargetI2C() (*machine.I2C, machine.I2CConfig) {
+	return machine.I2C1, machine.I2CConfig{SDA: machine.D6, SCL: machine.D7}
+}
+
+// RollServoPWM returns the PWM peripheral and the pin driving a third
+// servo, the roll axis of a gimbal: PWM0 on D10, shared with CANSPI.
+func RollServoPWM() (pwm PWM, pin machine.Pin) {
+	return machine.PWM0, machine.D10
+}
+
+// ButtonPin returns the GPIO of a push button wired to ground on D0,
+// shared with PowerPin, read with the internal pull-up.
+func ButtonPin() machine.Pin {
+	return machine.D0
+}
+
+// BootPin returns the GPIO wired to the BNO08x BOOTN pin, held low
+// through a reset to start the bootloader for a firmware update: D1,
+// shared with WakePin, since the bootloader only speaks I2C.
+func BootPin() machine.Pin {
+	return machine.D1
+}
@@ -0,0 +1,27 @@
+// Package buildinfo identifies the binary running on a board. The revision
+// and build time are injected at link time, for example:
+//
+//	tinygo flash -target=pico -ldflags="\
+//	  -X github.com/intermernet/bno08xPrograms/internal/buildinfo.Revision=$(git rev-parse --short HEAD) \
+//	  -X github.com/intermernet/bno08xPrograms/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+//	  ./basic
+//
+// Every program prints Banner at startup so that serial logs and issue
+// reports say exactly which binary produced them.
+package buildinfo
+
+import "github.com/intermernet/bno08xPrograms/internal/board"
+
+// Set with -ldflags "-X". Left at their defaults for plain builds.
+var (
+	Revision  = "unknown"
+	BuildTime = "unknown"
+	Board     = board.Name
+)
+
+// Banner prints the standard startup banner for the named program.
+func Banner(program string) {
+	println("=== " + program + " ===")
+	println("rev:", Revision, "built:", BuildTime, "board:", Board)
+	println()
+}
@@ -0,0 +1,54 @@
+// Package csvout prints sensor samples as CSV rows on the serial console,
+// so a captured log opens directly in a spreadsheet or pandas. Every row
+// starts with a monotonic timestamp in microseconds since the writer was
+// created and the sensor ID, followed by the sample values:
+//
+//	t_us,sensor,roll_deg,pitch_deg,yaw_deg
+//	20013,5,1.250,-0.375,87.500
+//
+// Programs that mix sensors share one set of value columns and tell rows
+// apart by the sensor column.
+package csvout
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Writer prints rows with a fixed set of value columns.
+type Writer struct {
+	columns  []string
+	decimals int
+	start    time.Time
+}
+
+// New returns a writer for the named value columns, printing values with
+// the given number of decimal places. Timestamps count from now.
+func New(decimals int, columns ...string) *Writer {
+	return &Writer{columns: columns, decimals: decimals, start: time.Now()}
+}
+
+// Header prints the header row. Call it once before the first row.
+func (w *Writer) Header() {
+	line := "t_us,sensor"
+	for _, c := range w.columns {
+		line += "," + c
+	}
+	println(line)
+}
+
+// Row prints one sample from sensor id, timestamped now. Missing values
+// are left empty and extra values are dropped, so every row has the same
+// number of fields.
+func (w *Writer) Row(id bno08x.SensorID, values ...float32) {
+	line := fmtutil.Int64(time.Since(w.start).Microseconds()) + "," + fmtutil.Int(int(id))
+	for i := range w.columns {
+		line += ","
+		if i < len(values) {
+			line += fmtutil.Float(values[i], w.decimals)
+		}
+	}
+	println(line)
+}
@@ -0,0 +1,157 @@
+// Package dfu speaks the BNO08x bootloader's device firmware update
+// protocol over I2C, to replace the sensor's application firmware.
+//
+// The bootloader runs instead of the application when BOOTN is held low
+// through a reset, and answers on its own I2C address. The host sends the
+// image size, then the packet length, then the image a packet at a time.
+// Every message ends with a CRC-16 over its bytes, sent big-endian, and
+// the bootloader answers each with a single Ack byte once it has checked
+// and stored it; a message that is not acknowledged is sent again. When
+// the last packet is stored, a reset with BOOTN high starts the new
+// application.
+package dfu
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+)
+
+// Address is the bootloader's I2C address.
+const Address = 0x28
+
+// Ack is the byte the bootloader answers a message it accepted with.
+const Ack = 's'
+
+// MaxPacketLen is the longest image packet the bootloader takes.
+const MaxPacketLen = 64
+
+const (
+	// Times a message is sent before giving up
+	attempts = 5
+	// Longest to wait for the Ack; the bootloader stores each packet in
+	// flash before answering
+	ackTimeout = 200 * time.Millisecond
+)
+
+var (
+	ErrNoAck        = errors.New("dfu: message not acknowledged")
+	ErrPacketLen    = errors.New("dfu: packet length must be 1 to MaxPacketLen")
+	ErrNotStarted   = errors.New("dfu: update not started")
+	ErrImageTooLong = errors.New("dfu: more data than the image size given")
+)
+
+// CRC16 continues the CRC-16/CCITT of crc over b. Start from 0xFFFF.
+func CRC16(crc uint16, b []byte) uint16 {
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Updater sends an image to the bootloader.
+type Updater struct {
+	Bus     shtpraw.Bus
+	Address uint16 // Address if zero
+
+	size      uint32
+	packetLen int
+	sent      uint32
+	started   bool
+	buf       [MaxPacketLen + 2]byte
+}
+
+// Start opens an update of an image size bytes long, to be sent in
+// packets of packetLen bytes.
+func (u *Updater) Start(size uint32, packetLen int) error {
+	if packetLen < 1 || packetLen > MaxPacketLen {
+		return ErrPacketLen
+	}
+	u.size, u.packetLen, u.sent, u.started = size, packetLen, 0, false
+	if err := u.send([]byte{byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)}); err != nil {
+		return err
+	}
+	if err := u.send([]byte{byte(packetLen)}); err != nil {
+		return err
+	}
+	u.started = true
+	return nil
+}
+
+// Write sends the next packet of the image. Every packet but the last
+// must be the packet length given to Start.
+func (u *Updater) Write(p []byte) error {
+	switch {
+	case !u.started:
+		return ErrNotStarted
+	case len(p) > u.packetLen:
+		return ErrPacketLen
+	case u.sent+uint32(len(p)) > u.size:
+		return ErrImageTooLong
+	}
+	if err := u.send(p); err != nil {
+		return err
+	}
+	u.sent += uint32(len(p))
+	return nil
+}
+
+// Sent returns the bytes of the image stored so far.
+func (u *Updater) Sent() uint32 {
+	return u.sent
+}
+
+// Done reports whether the whole image has been stored.
+func (u *Updater) Done() bool {
+	return u.started && u.sent == u.size
+}
+
+// send writes msg with its CRC and waits for the Ack, retrying up to
+// attempts times.
+func (u *Updater) send(msg []byte) error {
+	addr := u.Address
+	if addr == 0 {
+		addr = Address
+	}
+	n := copy(u.buf[:], msg)
+	crc := CRC16(0xFFFF, msg)
+	u.buf[n], u.buf[n+1] = byte(crc>>8), byte(crc)
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = u.Bus.Tx(addr, u.buf[:n+2], nil); err != nil {
+			continue
+		}
+		if err = u.waitAck(addr); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// waitAck reads the answer to a message until it comes or ackTimeout
+// passes. The bootloader does not answer its address while busy.
+func (u *Updater) waitAck(addr uint16) error {
+	var ack [1]byte
+	err := ErrNoAck
+	start := time.Now()
+	for time.Since(start) < ackTimeout {
+		time.Sleep(2 * time.Millisecond)
+		if err = u.Bus.Tx(addr, nil, ack[:]); err != nil {
+			continue
+		}
+		if ack[0] == Ack {
+			return nil
+		}
+		return ErrNoAck
+	}
+	return err
+}
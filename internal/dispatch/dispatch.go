@@ -0,0 +1,123 @@
+// Package dispatch routes BNO08x sensor events to per-sensor callbacks and
+// keeps event counters and rate statistics, so an example's main loop only
+// has to call Poll.
+package dispatch
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// RateWindow is the period over which event rates are measured.
+const RateWindow = time.Second
+
+// Handler is called with each event for the sensor it was registered for.
+type Handler func(*bno08x.SensorValue)
+
+// Source is anything that yields sensor events, normally *bno08x.Device.
+type Source interface {
+	GetSensorEvent() (bno08x.SensorValue, bool)
+}
+
+// Dispatcher holds the registered handlers and statistics. All tables are
+// indexed by sensor ID, so dispatching does not allocate.
+type Dispatcher struct {
+	handlers    [256]Handler
+	fallback    Handler
+	counts      [256]uint32
+	window      [256]uint32
+	rates       [256]float32
+	total       uint32
+	windowStart time.Time
+}
+
+// New returns a dispatcher with no handlers registered.
+func New() *Dispatcher {
+	return &Dispatcher{windowStart: time.Now()}
+}
+
+// Handle registers h for events from sensor id, replacing any previous
+// handler. A nil handler only counts the events.
+func (d *Dispatcher) Handle(id bno08x.SensorID, h Handler) {
+	d.handlers[uint8(id)] = h
+}
+
+// HandleDefault registers h for events from sensors without a handler.
+func (d *Dispatcher) HandleDefault(h Handler) {
+	d.fallback = h
+}
+
+// Poll fetches at most one event from src, updates the statistics and calls
+// the matching handler. It reports whether an event was dispatched.
+func (d *Dispatcher) Poll(src Source) bool {
+	event, ok := src.GetSensorEvent()
+	d.updateRates(time.Now())
+	if !ok {
+		return false
+	}
+	d.Dispatch(&event)
+	return true
+}
+
+// Dispatch counts an event obtained elsewhere and calls its handler.
+func (d *Dispatcher) Dispatch(event *bno08x.SensorValue) {
+	id := uint8(event.ID())
+	d.total++
+	d.counts[id]++
+	d.window[id]++
+	if h := d.handlers[id]; h != nil {
+		h(event)
+	} else if d.fallback != nil {
+		d.fallback(event)
+	}
+}
+
+// updateRates closes the current rate window once RateWindow has elapsed.
+func (d *Dispatcher) updateRates(now time.Time) {
+	elapsed := now.Sub(d.windowStart)
+	if elapsed < RateWindow {
+		return
+	}
+	seconds := float32(elapsed.Seconds())
+	for i := range d.window {
+		d.rates[i] = float32(d.window[i]) / seconds
+		d.window[i] = 0
+	}
+	d.windowStart = now
+}
+
+// Total returns the number of events dispatched.
+func (d *Dispatcher) Total() uint32 {
+	return d.total
+}
+
+// Count returns the number of events received from sensor id.
+func (d *Dispatcher) Count(id bno08x.SensorID) uint32 {
+	return d.counts[uint8(id)]
+}
+
+// Rate returns the event rate of sensor id in Hz over the last complete
+// RateWindow.
+func (d *Dispatcher) Rate(id bno08x.SensorID) float32 {
+	return d.rates[uint8(id)]
+}
+
+// ForEach calls fn for every sensor that has produced at least one event,
+// in order of sensor ID.
+func (d *Dispatcher) ForEach(fn func(id bno08x.SensorID, count uint32)) {
+	for i, c := range d.counts {
+		if c > 0 {
+			fn(bno08x.SensorID(i), c)
+		}
+	}
+}
+
+// Reset clears all counters and rates but keeps the handlers.
+func (d *Dispatcher) Reset() {
+	d.counts = [256]uint32{}
+	d.window = [256]uint32{}
+	d.rates = [256]float32{}
+	d.total = 0
+	d.windowStart = time.Now()
+}
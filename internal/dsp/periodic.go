@@ -0,0 +1,166 @@
+// Package dsp holds signal analysis that works over a window of samples
+// rather than sample by sample like internal/filter.
+package dsp
+
+// MaxPeriodicWindow is the most samples a Periodic estimator keeps, e.g.
+// 25.6s at 10Hz.
+const MaxPeriodicWindow = 256
+
+// Defaults for Periodic fields left at zero.
+const (
+	DefaultMinHz         = 0.1
+	DefaultMaxHz         = 2
+	DefaultMinConfidence = 0.3
+)
+
+// Periodic estimates the dominant frequency of slow periodic motion such
+// as rocking, breathing or walking cadence, by autocorrelation of a window
+// of samples.
+//
+// Feed it a low-passed signal at a steady SampleRate, ideally decimated to
+// a few times MaxHz; the cost of Estimate grows with the window size times
+// the number of lags searched. A window should hold at least two periods
+// of the slowest motion of interest.
+type Periodic struct {
+	SampleRate    float32 // Hz at which Add is called
+	MinHz, MaxHz  float32 // Frequency range searched
+	MinConfidence float32 // Correlation needed to report a frequency
+	Size          int     // Samples in the window; MaxPeriodicWindow if zero
+
+	buf     [MaxPeriodicWindow]float32
+	scratch [MaxPeriodicWindow]float32
+	n       int
+	next    int
+}
+
+// Add appends a sample to the window, dropping the oldest once it is full.
+func (p *Periodic) Add(x float32) {
+	size := p.size()
+	p.buf[p.next] = x
+	p.next = (p.next + 1) % size
+	if p.n < size {
+		p.n++
+	}
+}
+
+// Full reports whether the window holds Size samples.
+func (p *Periodic) Full() bool {
+	return p.n == p.size()
+}
+
+// Reset empties the window.
+func (p *Periodic) Reset() {
+	p.n, p.next = 0, 0
+}
+
+// Estimate returns the dominant frequency in Hz and the normalised
+// autocorrelation at its period, from 0 to 1, as a confidence. ok is false
+// if the window is too short for MinHz, the signal is flat, or no period
+// correlates better than MinConfidence.
+func (p *Periodic) Estimate() (hz, confidence float32, ok bool) {
+	if p.SampleRate <= 0 || p.n < 4 {
+		return 0, 0, false
+	}
+	minHz, maxHz, minConfidence := p.MinHz, p.MaxHz, p.MinConfidence
+	if minHz <= 0 {
+		minHz = DefaultMinHz
+	}
+	if maxHz <= 0 {
+		maxHz = DefaultMaxHz
+	}
+	if minConfidence <= 0 {
+		minConfidence = DefaultMinConfidence
+	}
+
+	// Lags to search; the longest must leave half the window to correlate
+	minLag := int(p.SampleRate / maxHz)
+	maxLag := int(p.SampleRate/minHz) + 1
+	if minLag < 1 {
+		minLag = 1
+	}
+	if maxLag > p.n/2 {
+		maxLag = p.n / 2
+	}
+	if maxLag <= minLag+1 {
+		return 0, 0, false
+	}
+
+	// Unroll the ring oldest first with the mean removed
+	n := p.n
+	start := (p.next - n + p.size()) % p.size()
+	var mean float32
+	for i := 0; i < n; i++ {
+		mean += p.buf[(start+i)%p.size()]
+	}
+	mean /= float32(n)
+	var energy float32
+	x := p.scratch[:n]
+	for i := range x {
+		x[i] = p.buf[(start+i)%p.size()] - mean
+		energy += x[i] * x[i]
+	}
+	if energy == 0 {
+		return 0, 0, false
+	}
+
+	var r [MaxPeriodicWindow/2 + 2]float32
+	for lag := minLag - 1; lag <= maxLag+1 && lag < n; lag++ {
+		r[lag] = autocorrelation(x, lag, energy)
+	}
+
+	// The first peak close to the best one is the fundamental; multiples
+	// of the period correlate almost as well
+	best := float32(0)
+	for lag := minLag; lag <= maxLag; lag++ {
+		if _, v, isPeak := peak(&r, lag); isPeak && v > best {
+			best = v
+		}
+	}
+	if best < minConfidence {
+		return 0, 0, false
+	}
+	for lag := minLag; lag <= maxLag; lag++ {
+		if period, v, isPeak := peak(&r, lag); isPeak && v >= 0.9*best {
+			if v > 1 {
+				v = 1
+			}
+			return p.SampleRate / period, v, true
+		}
+	}
+	return 0, 0, false
+}
+
+// peak reports whether r has a local maximum at lag and, if so, fits a
+// parabola through it to find the period and correlation between samples.
+// Slow sampling puts the true peak well off the nearest lag.
+func peak(r *[MaxPeriodicWindow/2 + 2]float32, lag int) (period, value float32, ok bool) {
+	prev, v, next := r[lag-1], r[lag], r[lag+1]
+	if v < prev || v < next {
+		return 0, 0, false
+	}
+	period, value = float32(lag), v
+	if den := prev - 2*v + next; den < 0 {
+		offset := 0.5 * (prev - next) / den
+		period += offset
+		value -= 0.25 * (prev - next) * offset
+	}
+	return period, value, true
+}
+
+func (p *Periodic) size() int {
+	if p.Size <= 0 || p.Size > MaxPeriodicWindow {
+		return MaxPeriodicWindow
+	}
+	return p.Size
+}
+
+// autocorrelation returns the correlation of x with itself shifted by lag,
+// scaled so a perfectly periodic window gives close to 1. Each product sum
+// is scaled up for the samples lost to the shift.
+func autocorrelation(x []float32, lag int, energy float32) float32 {
+	var sum float32
+	for i := 0; i+lag < len(x); i++ {
+		sum += x[i] * x[i+lag]
+	}
+	return sum / energy * float32(len(x)) / float32(len(x)-lag)
+}
@@ -0,0 +1,91 @@
+// Package eventq passes sensor events from one goroutine to another
+// through a fixed ring buffer with no locks, for programs that service
+// the sensor on one core of an RP2040 and use the events on the other.
+//
+// A Queue has exactly one producer, which calls Push, and one consumer,
+// which calls Pop. Each side only writes its own index, so neither waits
+// for the other: a slow consumer costs events, counted by Dropped, but
+// never holds up the bus.
+//
+//	var q eventq.Queue
+//	go func() { // Producer, e.g. on core1
+//		for {
+//			if event, ok := sensor.GetSensorEvent(); ok {
+//				q.Push(&event)
+//			}
+//		}
+//	}()
+//	var event bno08x.SensorValue
+//	for {
+//		if q.Pop(&event) {
+//			// ...
+//		}
+//	}
+package eventq
+
+import (
+	"sync/atomic"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Size is the number of events a Queue holds. It is a power of two so the
+// free-running indices wrap cleanly.
+const Size = 64
+
+// Queue is a single-producer, single-consumer ring of events. The zero
+// value is empty and ready to use.
+type Queue struct {
+	events  [Size]bno08x.SensorValue
+	head    uint32 // Next slot to read; written only by Pop
+	tail    uint32 // Next slot to write; written only by Push
+	dropped uint32
+	most    uint32
+}
+
+// Push copies event into the queue and reports whether there was room.
+// An event that finds the queue full is dropped and counted.
+func (q *Queue) Push(event *bno08x.SensorValue) bool {
+	tail := atomic.LoadUint32(&q.tail)
+	n := tail - atomic.LoadUint32(&q.head)
+	if n >= Size {
+		atomic.AddUint32(&q.dropped, 1)
+		return false
+	}
+	q.events[tail%Size] = *event
+	// Publish the slot only once it is written
+	atomic.StoreUint32(&q.tail, tail+1)
+	if n+1 > atomic.LoadUint32(&q.most) {
+		atomic.StoreUint32(&q.most, n+1)
+	}
+	return true
+}
+
+// Pop copies the oldest event into event and reports whether there was
+// one.
+func (q *Queue) Pop(event *bno08x.SensorValue) bool {
+	head := atomic.LoadUint32(&q.head)
+	if head == atomic.LoadUint32(&q.tail) {
+		return false
+	}
+	*event = q.events[head%Size]
+	// Free the slot only once it is read
+	atomic.StoreUint32(&q.head, head+1)
+	return true
+}
+
+// Len returns the number of events waiting.
+func (q *Queue) Len() int {
+	return int(atomic.LoadUint32(&q.tail) - atomic.LoadUint32(&q.head))
+}
+
+// Dropped returns the number of events Push found no room for.
+func (q *Queue) Dropped() int {
+	return int(atomic.LoadUint32(&q.dropped))
+}
+
+// HighWater returns the most events that have waited at once, which shows
+// how close the consumer came to falling behind.
+func (q *Queue) HighWater() int {
+	return int(atomic.LoadUint32(&q.most))
+}
@@ -0,0 +1,126 @@
+// Package filter smooths noisy sensor values: exponential moving averages,
+// a first-order low-pass configured by cutoff frequency, and a median filter
+// for rejecting single-sample spikes. Each comes in a scalar form and a
+// three-axis form for vectors; EulerEMA handles angles that wrap at ±π.
+//
+// All filters are fixed-size values with no allocation, and pass the first
+// sample through unchanged so there is no start-up ramp from zero.
+package filter
+
+import "math"
+
+// Alpha returns the smoothing factor of a first-order low-pass filter with
+// the given cutoff frequency, updated at the given sample rate. Use it to
+// configure an EMA by cutoff rather than by feel:
+//
+//	f := filter.EMA{Alpha: filter.Alpha(2, 50)} // 2Hz cutoff at 50Hz
+func Alpha(cutoffHz, sampleRateHz float32) float32 {
+	if cutoffHz <= 0 || sampleRateHz <= 0 {
+		return 1
+	}
+	dt := 1 / sampleRateHz
+	rc := 1 / (2 * math.Pi * cutoffHz)
+	return dt / (rc + dt)
+}
+
+// EMA is an exponential moving average. Alpha in (0, 1] sets how much
+// each new sample counts: 1 passes samples through, smaller values smooth
+// more and respond more slowly.
+type EMA struct {
+	Alpha  float32
+	value  float32
+	primed bool
+}
+
+// Update adds a sample and returns the filtered value.
+func (f *EMA) Update(x float32) float32 {
+	if !f.primed {
+		f.value, f.primed = x, true
+	} else {
+		f.value += f.Alpha * (x - f.value)
+	}
+	return f.value
+}
+
+// Value returns the current filtered value.
+func (f *EMA) Value() float32 {
+	return f.value
+}
+
+// Reset forgets the history; the next sample is passed through.
+func (f *EMA) Reset() {
+	f.value, f.primed = 0, false
+}
+
+// EMA3 is an exponential moving average of a three-axis vector.
+type EMA3 struct {
+	Alpha   float32
+	x, y, z EMA
+}
+
+// Update adds a sample and returns the filtered vector.
+func (f *EMA3) Update(x, y, z float32) (float32, float32, float32) {
+	f.x.Alpha, f.y.Alpha, f.z.Alpha = f.Alpha, f.Alpha, f.Alpha
+	return f.x.Update(x), f.y.Update(y), f.z.Update(z)
+}
+
+// Reset forgets the history.
+func (f *EMA3) Reset() {
+	f.x.Reset()
+	f.y.Reset()
+	f.z.Reset()
+}
+
+// AngleEMA is an exponential moving average of an angle in radians that
+// wraps at ±π. Averaging 179° and -179° gives 180°, not 0°.
+type AngleEMA struct {
+	Alpha  float32
+	value  float32
+	primed bool
+}
+
+// Update adds a sample and returns the filtered angle in [-π, π].
+func (f *AngleEMA) Update(a float32) float32 {
+	if !f.primed {
+		f.value, f.primed = wrap(a), true
+	} else {
+		f.value = wrap(f.value + f.Alpha*wrap(a-f.value))
+	}
+	return f.value
+}
+
+// Reset forgets the history.
+func (f *AngleEMA) Reset() {
+	f.value, f.primed = 0, false
+}
+
+// EulerEMA smooths roll, pitch and yaw in radians, handling wraparound on
+// each axis.
+type EulerEMA struct {
+	Alpha            float32
+	roll, pitch, yaw AngleEMA
+}
+
+// Update adds a sample and returns the filtered angles.
+func (f *EulerEMA) Update(roll, pitch, yaw float32) (float32, float32, float32) {
+	f.roll.Alpha, f.pitch.Alpha, f.yaw.Alpha = f.Alpha, f.Alpha, f.Alpha
+	return f.roll.Update(roll), f.pitch.Update(pitch), f.yaw.Update(yaw)
+}
+
+// Reset forgets the history.
+func (f *EulerEMA) Reset() {
+	f.roll.Reset()
+	f.pitch.Reset()
+	f.yaw.Reset()
+}
+
+// wrap maps an angle into [-π, π].
+func wrap(a float32) float32 {
+	for a > math.Pi {
+		a -= 2 * math.Pi
+	}
+	for a < -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
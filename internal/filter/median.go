@@ -0,0 +1,71 @@
+package filter
+
+// MaxMedianWindow is the largest window a Median filter supports.
+const MaxMedianWindow = 15
+
+// Median returns the median of the last Size samples, which removes
+// isolated spikes without smearing edges the way an average does. Size is
+// clamped to [1, MaxMedianWindow]; odd sizes give a true middle value.
+type Median struct {
+	Size int
+	buf  [MaxMedianWindow]float32
+	n    int
+	next int
+}
+
+// Update adds a sample and returns the median of the current window.
+func (f *Median) Update(x float32) float32 {
+	size := f.size()
+	f.buf[f.next] = x
+	f.next = (f.next + 1) % size
+	if f.n < size {
+		f.n++
+	}
+
+	// Insertion sort into a scratch copy; windows are tiny
+	var sorted [MaxMedianWindow]float32
+	for i := 0; i < f.n; i++ {
+		v := f.buf[i]
+		j := i
+		for j > 0 && sorted[j-1] > v {
+			sorted[j] = sorted[j-1]
+			j--
+		}
+		sorted[j] = v
+	}
+	return sorted[f.n/2]
+}
+
+// Reset empties the window.
+func (f *Median) Reset() {
+	f.n, f.next = 0, 0
+}
+
+func (f *Median) size() int {
+	if f.Size < 1 {
+		return 1
+	}
+	if f.Size > MaxMedianWindow {
+		return MaxMedianWindow
+	}
+	return f.Size
+}
+
+// Median3 applies a median filter to each axis of a vector.
+type Median3 struct {
+	Size    int
+	x, y, z Median
+}
+
+// Update adds a sample and returns the per-axis medians.
+func (f *Median3) Update(x, y, z float32) (float32, float32, float32) {
+	f.x.Size, f.y.Size, f.z.Size = f.Size, f.Size, f.Size
+	return f.x.Update(x), f.y.Update(y), f.z.Update(z)
+}
+
+// Reset empties the windows.
+func (f *Median3) Reset() {
+	f.x.Reset()
+	f.y.Reset()
+	f.z.Reset()
+}
@@ -0,0 +1,174 @@
+// Package firmware holds what is known about BNO08x firmware releases, so
+// the diagnostic can warn about suspect versions and programs can check
+// that a feature they rely on is supported before enabling it.
+package firmware
+
+import (
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Version is a firmware version as reported in a product ID response.
+type Version struct {
+	Major, Minor uint8
+	Patch        uint16
+}
+
+// FromProductID returns the version of a product ID entry.
+func FromProductID(id bno08x.ProductID) Version {
+	return Version{id.VersionMajor, id.VersionMinor, id.VersionPatch}
+}
+
+// Less reports whether v is older than o.
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// String formats the version as "major.minor.patch".
+func (v Version) String() string {
+	return fmtutil.Int(int(v.Major)) + "." + fmtutil.Int(int(v.Minor)) + "." + fmtutil.Int(int(v.Patch))
+}
+
+// Status rates a firmware release.
+type Status uint8
+
+const (
+	Unknown Status = iota // Not in the table
+	Good                  // Tested with these programs
+	Caution               // Works, with known problems
+	Bad                   // Known not to work with these programs
+)
+
+func (s Status) String() string {
+	switch s {
+	case Good:
+		return "good"
+	case Caution:
+		return "caution"
+	case Bad:
+		return "bad"
+	}
+	return "unknown"
+}
+
+// Release is one entry of the known firmware table. Releases with a zero
+// Part apply to every part number; Below marks an entry covering every
+// version older than Version instead of Version alone.
+type Release struct {
+	Part    uint32
+	Version Version
+	Below   bool
+	Status  Status
+	Note    string
+}
+
+// Known lists the firmware releases seen with these programs. Lookup takes
+// the first match, so exact versions go before ranges. Add an entry when a
+// board turns up with a version not listed here.
+var Known = []Release{
+	{
+		Part:    10003608,
+		Version: Version{3, 2, 7},
+		Status:  Good,
+		Note:    "Common BNO085 release; the reference for every program here",
+	},
+	{
+		Version: Version{3, 0, 0},
+		Below:   true,
+		Status:  Caution,
+		Note:    "Pre-3.0 BNO080 firmware: tap, step and activity detectors are unreliable",
+	},
+}
+
+// Parts maps the software part number of the application firmware, the
+// first product ID entry, to the chip it ships on. Add an entry when a
+// board turns up with a part number not listed here.
+var Parts = map[uint32]string{
+	10003606: "BNO080",
+	10003608: "BNO085",
+}
+
+// PartName returns the chip a software part number ships on, or "" if it
+// is not in Parts.
+func PartName(part uint32) string {
+	return Parts[part]
+}
+
+// Lookup returns the entry of Known matching part and version, or a
+// Release with Status Unknown if there is none.
+func Lookup(part uint32, v Version) Release {
+	for _, r := range Known {
+		if r.Part != 0 && r.Part != part {
+			continue
+		}
+		if r.Below && v.Less(r.Version) || !r.Below && v == r.Version {
+			return r
+		}
+	}
+	return Release{Part: part, Version: v}
+}
+
+// Feature is a capability that not every firmware release has.
+type Feature uint8
+
+const (
+	TapDetector Feature = iota
+	StepCounter
+	ActivityClassifier
+	GyroIntegratedRV
+	DynamicCalibrationSave
+	numFeatures
+)
+
+// Features lists every Feature.
+var Features = []Feature{
+	TapDetector,
+	StepCounter,
+	ActivityClassifier,
+	GyroIntegratedRV,
+	DynamicCalibrationSave,
+}
+
+var featureNames = [numFeatures]string{
+	TapDetector:            "tap detector",
+	StepCounter:            "step counter",
+	ActivityClassifier:     "activity classifier",
+	GyroIntegratedRV:       "gyro-integrated rotation vector",
+	DynamicCalibrationSave: "dynamic calibration save",
+}
+
+// minimum is the oldest version each feature has been verified on. These
+// are not vendor guarantees; older firmware may work too.
+var minimum = [numFeatures]Version{
+	TapDetector:            {3, 0, 0},
+	StepCounter:            {3, 0, 0},
+	ActivityClassifier:     {3, 0, 0},
+	GyroIntegratedRV:       {3, 2, 0},
+	DynamicCalibrationSave: {3, 0, 0},
+}
+
+func (f Feature) String() string {
+	if f >= numFeatures {
+		return "unknown feature"
+	}
+	return featureNames[f]
+}
+
+// MinFirmware returns the oldest firmware version known to support f.
+func MinFirmware(f Feature) Version {
+	if f >= numFeatures {
+		return Version{}
+	}
+	return minimum[f]
+}
+
+// Supports reports whether firmware version v is known to support f.
+func Supports(v Version, f Feature) bool {
+	return f < numFeatures && !v.Less(minimum[f])
+}
@@ -0,0 +1,214 @@
+// Package fmtutil formats numbers for the serial console without fmt,
+// which is too large for most TinyGo targets and whose float output the
+// println builtin replaces with scientific notation.
+//
+// Floats are rounded, not truncated, to a chosen number of decimal places
+// and never use an exponent, so small values print as 0.000 instead of
+// 1.2e-05 and -0.0004 does not become "-0.000". The padding helpers line
+// values up in columns, and Elapsed prints durations as a stopwatch would.
+// The Append forms build lines in a caller's buffer, for output paths
+// that must not allocate.
+package fmtutil
+
+import (
+	"math"
+	"time"
+)
+
+// MaxDecimals is the largest number of decimal places Float produces.
+const MaxDecimals = 9
+
+var pow10 = [MaxDecimals + 1]float64{1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9}
+
+// Float formats v in fixed-point notation with the given number of decimal
+// places, clamped to [0, MaxDecimals]. NaN and infinities are written as
+// "NaN", "+Inf" and "-Inf".
+func Float(v float32, decimals int) string {
+	var buf [52]byte
+	return string(AppendFloat(buf[:0], v, decimals))
+}
+
+// AppendFloat appends v formatted as by Float to dst, for output paths
+// that must not allocate.
+func AppendFloat(dst []byte, v float32, decimals int) []byte {
+	f := float64(v)
+	switch {
+	case math.IsNaN(f):
+		return append(dst, "NaN"...)
+	case math.IsInf(f, 1):
+		return append(dst, "+Inf"...)
+	case math.IsInf(f, -1):
+		return append(dst, "-Inf"...)
+	}
+	if decimals < 0 {
+		decimals = 0
+	} else if decimals > MaxDecimals {
+		decimals = MaxDecimals
+	}
+
+	neg := f < 0
+	if neg {
+		f = -f
+	}
+	scaled := math.Floor(f*pow10[decimals] + 0.5)
+
+	// float32 has at most 39 integer digits; add decimals, point and sign
+	var buf [52]byte
+	i := len(buf)
+	for d := 0; ; d++ {
+		if d == decimals && decimals > 0 {
+			i--
+			buf[i] = '.'
+		}
+		i--
+		if scaled < 1<<63 {
+			// Exact integer arithmetic for everything but huge values
+			n := uint64(scaled)
+			buf[i] = byte('0' + n%10)
+			scaled = float64(n / 10)
+		} else {
+			buf[i] = byte('0' + int(math.Mod(scaled, 10)))
+			scaled = math.Floor(scaled / 10)
+		}
+		if d >= decimals && scaled == 0 {
+			break
+		}
+	}
+
+	// Only signed if something non-zero survived rounding
+	if neg && !allZero(buf[i:]) {
+		i--
+		buf[i] = '-'
+	}
+	return append(dst, buf[i:]...)
+}
+
+// Int formats an integer in decimal.
+func Int(n int) string {
+	return Int64(int64(n))
+}
+
+// Int64 formats a 64-bit integer in decimal, for counts such as
+// microsecond timestamps that overflow int on 32-bit targets.
+func Int64(n int64) string {
+	var buf [20]byte
+	return string(AppendInt(buf[:0], n))
+}
+
+// AppendInt appends n in decimal to dst.
+func AppendInt(dst []byte, n int64) []byte {
+	var buf [20]byte
+	i := len(buf)
+	u := uint64(n)
+	if n < 0 {
+		u = uint64(-n)
+	}
+	for {
+		i--
+		buf[i] = byte('0' + u%10)
+		u /= 10
+		if u == 0 {
+			break
+		}
+	}
+	if n < 0 {
+		i--
+		buf[i] = '-'
+	}
+	return append(dst, buf[i:]...)
+}
+
+// PadLeft right-aligns s in a field of the given width by prepending
+// spaces. Strings already at least width bytes long are returned as is.
+func PadLeft(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return spaces(width-len(s)) + s
+}
+
+// PadRight left-aligns s in a field of the given width by appending
+// spaces.
+func PadRight(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return s + spaces(width-len(s))
+}
+
+// FloatWidth formats v like Float and right-aligns it to width, for
+// tables of numbers.
+func FloatWidth(v float32, decimals, width int) string {
+	return PadLeft(Float(v, decimals), width)
+}
+
+// AppendPadRight appends s to dst left-aligned in a field of the given
+// width, as PadRight does, without allocating.
+func AppendPadRight(dst []byte, s string, width int) []byte {
+	dst = append(dst, s...)
+	for n := len(s); n < width; n++ {
+		dst = append(dst, ' ')
+	}
+	return dst
+}
+
+// AlignRight right-aligns the field dst[from:], just appended, in the
+// given width by moving it right and filling with spaces, so a number
+// from AppendInt or AppendFloat lines up as PadLeft would align it.
+func AlignRight(dst []byte, from, width int) []byte {
+	n := len(dst) - from
+	if n >= width {
+		return dst
+	}
+	for i := n; i < width; i++ {
+		dst = append(dst, ' ')
+	}
+	copy(dst[from+width-n:], dst[from:from+n])
+	for i := from; i < from+width-n; i++ {
+		dst[i] = ' '
+	}
+	return dst
+}
+
+func spaces(n int) string {
+	const blank = "                                "
+	if n <= len(blank) {
+		return blank[:n]
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = ' '
+	}
+	return string(b)
+}
+
+func allZero(digits []byte) bool {
+	for _, c := range digits {
+		if c >= '1' && c <= '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Elapsed formats a duration as a stopwatch reading, m:ss.cc, with hours
+// prepended as h:mm:ss.cc once it reaches an hour. Negative durations are
+// shown as zero.
+func Elapsed(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := int(d / (10 * time.Millisecond))
+	h, cs := cs/360000, cs%360000
+	m, cs := cs/6000, cs%6000
+	s, cs := cs/100, cs%100
+	out := Int(m) + ":" + twoDigits(s) + "." + twoDigits(cs)
+	if h > 0 {
+		out = Int(h) + ":" + twoDigits(m) + ":" + twoDigits(s) + "." + twoDigits(cs)
+	}
+	return out
+}
+
+func twoDigits(n int) string {
+	return string([]byte{byte('0' + n/10), byte('0' + n%10)})
+}
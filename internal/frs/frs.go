@@ -0,0 +1,264 @@
+// Package frs reads and writes records of the BNO08x's Flash Record
+// System, where the sensor hub keeps its configuration, calibration and
+// sensor metadata, over raw SHTP on the control channel.
+//
+// A record is a list of 32-bit words identified by a 16-bit type. An FRS
+// Read Request asks for a record, or a block of it; the hub answers with
+// a stream of FRS Read Responses carrying up to two words each, which an
+// Assembler puts back together.
+//
+// A write starts with an FRS Write Request giving the record's length;
+// the hub answers each step with an FRS Write Response, and a Writer
+// sends the words two at a time in reply until the hub reports the write
+// complete. Most records are read at boot, so a change takes effect after
+// the next reset.
+//
+// Each sensor also has a read-only metadata record giving its range,
+// resolution, current draw and report interval limits on this part;
+// MetadataRecord finds it and ParseMetadata decodes it.
+package frs
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Control channel report IDs for FRS reads.
+const (
+	ReportReadResponse = 0xF3
+	ReportReadRequest  = 0xF4
+)
+
+// Lengths of an FRS Read Request and an FRS Read Response.
+const (
+	ReadRequestLen  = 8
+	ReadResponseLen = 16
+)
+
+// MaxWords is the longest record an Assembler holds.
+const MaxWords = 64
+
+// Record types, from the SH-2 reference manual.
+const (
+	StaticCalibrationAGM  = 0x7979
+	NominalCalibrationAGM = 0x4D4D
+	StaticCalibrationSRA  = 0x8A8A
+	NominalCalibrationSRA = 0x4E4E
+	DynamicCalibration    = 0x1F1F
+	MEPowerManagement     = 0xD3E2
+	SystemOrientation     = 0x2D3E
+	AccelOrientation      = 0x2D41
+	GyroOrientation       = 0x2D46
+	MagOrientation        = 0x2D4C
+	ARVRStabilizationRV   = 0x3E2D
+	ARVRStabilizationGRV  = 0x3E2E
+	TapConfig             = 0xC269
+	SigMotionConfig       = 0xC274
+	ShakeConfig           = 0x7D7D
+	MaxFusionPeriod       = 0xD7D7
+	SerialNumber          = 0x4B4B
+	PickupConfig          = 0x1B2A
+	FlipConfig            = 0xFC94
+	StabilityConfig       = 0xED85
+	ActivityConfig        = 0xED88
+	SleepConfig           = 0xED87
+	TiltConfig            = 0xED89
+	PocketConfig          = 0xEF27
+	CircleConfig          = 0xEE51
+	UserRecord            = 0x74B4
+	GyroIntegratedRVConf  = 0xA1A2
+)
+
+// Read Response status codes, in the low nibble of the second byte.
+const (
+	StatusOK               = 0
+	StatusUnknownType      = 1
+	StatusBusy             = 2
+	StatusRecordDone       = 3
+	StatusOffsetOutOfRange = 4
+	StatusRecordEmpty      = 5
+	StatusBlockDone        = 6
+	StatusBlockRecordDone  = 7
+	StatusDeviceError      = 8
+)
+
+var (
+	ErrNotReadResponse = errors.New("frs: not an FRS Read Response")
+	ErrUnknownType     = errors.New("frs: record type not recognised")
+	ErrBusy            = errors.New("frs: busy")
+	ErrOffset          = errors.New("frs: offset out of range")
+	ErrDevice          = errors.New("frs: device error")
+	ErrTooLong         = errors.New("frs: record longer than MaxWords")
+)
+
+var names = map[uint16]string{
+	StaticCalibrationAGM:  "Static calibration AGM",
+	NominalCalibrationAGM: "Nominal calibration AGM",
+	StaticCalibrationSRA:  "Static calibration SRA",
+	NominalCalibrationSRA: "Nominal calibration SRA",
+	DynamicCalibration:    "Dynamic calibration",
+	MEPowerManagement:     "ME power management",
+	SystemOrientation:     "System orientation",
+	AccelOrientation:      "Accelerometer orientation",
+	GyroOrientation:       "Gyroscope orientation",
+	MagOrientation:        "Magnetometer orientation",
+	ARVRStabilizationRV:   "AR/VR stabilisation RV",
+	ARVRStabilizationGRV:  "AR/VR stabilisation GRV",
+	TapConfig:             "Tap detector config",
+	SigMotionConfig:       "Significant motion config",
+	ShakeConfig:           "Shake detector config",
+	MaxFusionPeriod:       "Maximum fusion period",
+	SerialNumber:          "Serial number",
+	PickupConfig:          "Pickup detector config",
+	FlipConfig:            "Flip detector config",
+	StabilityConfig:       "Stability detector config",
+	ActivityConfig:        "Activity tracker config",
+	SleepConfig:           "Sleep detector config",
+	TiltConfig:            "Tilt detector config",
+	PocketConfig:          "Pocket detector config",
+	CircleConfig:          "Circle detector config",
+	UserRecord:            "User record",
+	GyroIntegratedRVConf:  "Gyro-integrated RV config",
+}
+
+// Name returns the name of a record type, or "" if it is not known.
+func Name(recordType uint16) string {
+	return names[recordType]
+}
+
+// AppendReadRequest appends an FRS Read Request for a record to b.
+// offset is the first word wanted and blockSize the number of words, 0
+// for the rest of the record.
+func AppendReadRequest(b []byte, recordType, offset, blockSize uint16) []byte {
+	var req [ReadRequestLen]byte
+	req[0] = ReportReadRequest
+	binary.LittleEndian.PutUint16(req[2:], offset)
+	binary.LittleEndian.PutUint16(req[4:], recordType)
+	binary.LittleEndian.PutUint16(req[6:], blockSize)
+	return append(b, req[:]...)
+}
+
+// ReadResponse is a decoded FRS Read Response.
+type ReadResponse struct {
+	Type   uint16
+	Status uint8
+	Offset uint16    // Word offset of Data[0]
+	Len    uint8     // Words of Data in use
+	Data   [2]uint32 // Record words
+}
+
+// Done reports whether the response is the last of its read.
+func (r ReadResponse) Done() bool {
+	return r.Status != StatusOK && r.Status != StatusBlockDone
+}
+
+// Err returns the error a failure status stands for, or nil.
+func (r ReadResponse) Err() error {
+	switch r.Status {
+	case StatusUnknownType:
+		return ErrUnknownType
+	case StatusBusy:
+		return ErrBusy
+	case StatusOffsetOutOfRange:
+		return ErrOffset
+	case StatusDeviceError:
+		return ErrDevice
+	}
+	return nil
+}
+
+// ParseReadResponses decodes every FRS Read Response in control channel
+// cargo, which can carry several back to back, calling fn for each. It
+// returns ErrNotReadResponse if the cargo holds none.
+func ParseReadResponses(cargo []byte, fn func(ReadResponse)) error {
+	if len(cargo) < ReadResponseLen || cargo[0] != ReportReadResponse {
+		return ErrNotReadResponse
+	}
+	for ; len(cargo) >= ReadResponseLen && cargo[0] == ReportReadResponse; cargo = cargo[ReadResponseLen:] {
+		fn(ReadResponse{
+			Len:    cargo[1] >> 4,
+			Status: cargo[1] & 0x0F,
+			Offset: binary.LittleEndian.Uint16(cargo[2:]),
+			Data:   [2]uint32{binary.LittleEndian.Uint32(cargo[4:]), binary.LittleEndian.Uint32(cargo[8:])},
+			Type:   binary.LittleEndian.Uint16(cargo[12:]),
+		})
+	}
+	return nil
+}
+
+// Assembler puts a record back together from the responses to one read.
+type Assembler struct {
+	Type  uint16
+	Words []uint32 // The record so far
+	Empty bool     // The hub holds no such record
+
+	buf  [MaxWords]uint32
+	done bool
+	err  error
+}
+
+// Start readies the assembler for a read of recordType.
+func (a *Assembler) Start(recordType uint16) {
+	*a = Assembler{Type: recordType}
+	a.Words = a.buf[:0]
+}
+
+// Add takes a response, ignoring those for other records, and reports
+// whether the read is over.
+func (a *Assembler) Add(r ReadResponse) bool {
+	if a.done || r.Type != a.Type && r.Status != StatusUnknownType {
+		return a.done
+	}
+	if a.err = r.Err(); a.err != nil {
+		a.done = true
+		return true
+	}
+	for i := 0; i < int(r.Len) && i < len(r.Data); i++ {
+		at := int(r.Offset) + i
+		if at >= MaxWords {
+			a.err, a.done = ErrTooLong, true
+			return true
+		}
+		if at >= len(a.Words) {
+			a.Words = a.buf[:at+1]
+		}
+		a.Words[at] = r.Data[i]
+	}
+	if r.Status == StatusRecordEmpty {
+		a.Empty = true
+	}
+	a.done = r.Done()
+	return a.done
+}
+
+// Err returns the error that ended the read, or nil.
+func (a *Assembler) Err() error {
+	return a.err
+}
+
+// Quaternion decodes an orientation record: X, Y, Z and W as signed
+// fixed point with 30 fractional bits. It returns false if words is too
+// short.
+func Quaternion(words []uint32) (bno08x.Quaternion, bool) {
+	if len(words) < 4 {
+		return bno08x.Quaternion{}, false
+	}
+	return bno08x.Quaternion{
+		I:    Q30(words[0]),
+		J:    Q30(words[1]),
+		K:    Q30(words[2]),
+		Real: Q30(words[3]),
+	}, true
+}
+
+// Q30 converts a word holding signed fixed point with 30 fractional bits.
+func Q30(w uint32) float32 {
+	return float32(int32(w)) / (1 << 30)
+}
+
+// Q29 converts a word holding signed fixed point with 29 fractional bits.
+func Q29(w uint32) float32 {
+	return float32(int32(w)) / (1 << 29)
+}
@@ -0,0 +1,48 @@
+package frs
+
+import "testing"
+
+// TestRecordTypes pins the record types to the FRS table of the SH-2
+// reference manual, listed under the manual's names.
+func TestRecordTypes(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		got  uint16
+		want uint16
+	}{
+		{"Static calibration – AGM", StaticCalibrationAGM, 0x7979},
+		{"Nominal calibration – AGM", NominalCalibrationAGM, 0x4D4D},
+		{"Static calibration – SRA", StaticCalibrationSRA, 0x8A8A},
+		{"Nominal calibration – SRA", NominalCalibrationSRA, 0x4E4E},
+		{"Dynamic calibration", DynamicCalibration, 0x1F1F},
+		{"MotionEngine power management", MEPowerManagement, 0xD3E2},
+		{"System orientation", SystemOrientation, 0x2D3E},
+		{"Primary accelerometer orientation", AccelOrientation, 0x2D41},
+		{"Gyroscope orientation", GyroOrientation, 0x2D46},
+		{"Magnetometer orientation", MagOrientation, 0x2D4C},
+		{"AR/VR stabilization – rotation vector", ARVRStabilizationRV, 0x3E2D},
+		{"AR/VR stabilization – game rotation vector", ARVRStabilizationGRV, 0x3E2E},
+		{"Tap detector configuration", TapConfig, 0xC269},
+		{"Significant motion detector configuration", SigMotionConfig, 0xC274},
+		{"Shake detector configuration", ShakeConfig, 0x7D7D},
+		{"Maximum fusion period", MaxFusionPeriod, 0xD7D7},
+		{"Serial number", SerialNumber, 0x4B4B},
+		{"Pickup detector configuration", PickupConfig, 0x1B2A},
+		{"Flip detector configuration", FlipConfig, 0xFC94},
+		{"Stability detector configuration", StabilityConfig, 0xED85},
+		{"Activity tracker configuration", ActivityConfig, 0xED88},
+		{"Sleep detector configuration", SleepConfig, 0xED87},
+		{"Tilt detector configuration", TiltConfig, 0xED89},
+		{"Pocket detector configuration", PocketConfig, 0xEF27},
+		{"Circle detector configuration", CircleConfig, 0xEE51},
+		{"User record", UserRecord, 0x74B4},
+		{"Gyro-integrated rotation vector configuration", GyroIntegratedRVConf, 0xA1A2},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s: 0x%04X, want 0x%04X", tt.name, tt.got, tt.want)
+		}
+		if Name(tt.want) == "" {
+			t.Errorf("%s: 0x%04X has no name", tt.name, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package frs
+
+// metadataRecords maps a sensor ID to the record type of its metadata.
+// The record types do not follow the sensor IDs, so each is listed.
+var metadataRecords = map[uint8]uint16{
+	0x01: 0xE302, // Accelerometer
+	0x02: 0xE306, // Gyroscope
+	0x03: 0xE309, // Magnetic field
+	0x04: 0xE303, // Linear acceleration
+	0x05: 0xE30B, // Rotation vector
+	0x06: 0xE304, // Gravity
+	0x07: 0xE307, // Gyroscope uncalibrated
+	0x08: 0xE30C, // Game rotation vector
+	0x09: 0xE30D, // Geomagnetic rotation vector
+	0x0A: 0xE30E, // Pressure
+	0x0B: 0xE30F, // Ambient light
+	0x0C: 0xE310, // Humidity
+	0x0D: 0xE311, // Proximity
+	0x0E: 0xE312, // Temperature
+	0x0F: 0xE30A, // Magnetic field uncalibrated
+	0x10: 0xE313, // Tap detector
+	0x11: 0xE315, // Step counter
+	0x12: 0xE316, // Significant motion
+	0x13: 0xE317, // Stability classifier
+	0x14: 0xE301, // Raw accelerometer
+	0x15: 0xE305, // Raw gyroscope
+	0x16: 0xE308, // Raw magnetometer
+	0x18: 0xE314, // Step detector
+	0x19: 0xE318, // Shake detector
+	0x1A: 0xE319, // Flip detector
+	0x1B: 0xE31A, // Pickup detector
+	0x1C: 0xE31B, // Stability detector
+	0x1E: 0xE31C, // Personal activity classifier
+	0x1F: 0xE31D, // Sleep detector
+	0x20: 0xE31E, // Tilt detector
+	0x21: 0xE31F, // Pocket detector
+	0x22: 0xE320, // Circle detector
+	0x23: 0xE321, // Heart rate monitor
+	0x28: 0xE322, // AR/VR stabilised rotation vector
+	0x29: 0xE323, // AR/VR stabilised game rotation vector
+	0x2A: 0xE324, // Gyro-integrated rotation vector
+}
+
+// MetadataRecord returns the type of the metadata record of a sensor, or
+// false if it has none.
+func MetadataRecord(sensor uint8) (uint16, bool) {
+	t, ok := metadataRecords[sensor]
+	return t, ok
+}
+
+// Metadata is a decoded sensor metadata record: what the part's firmware
+// says one of its sensors can do. Range and Resolution are fixed point
+// with QPoint1 fractional bits, in the sensor's own units.
+type Metadata struct {
+	MEVersion, MHVersion, SHVersion uint8 // Motion engine, hub and sensor hub versions
+	Range                           uint32
+	Resolution                      uint32
+	Power                           uint16 // Current drawn, mA with 10 fractional bits
+	Revision                        uint16 // Layout revision of the record
+	MinPeriod                       uint32 // Shortest report interval, microseconds
+	MaxPeriod                       uint32 // Longest report interval, microseconds; 0 if not given
+	FIFOReserved, FIFOMax           uint16 // Batch FIFO entries kept for the sensor, and at most
+	BatchBufferBytes                uint16
+	QPoint1, QPoint2, QPoint3       uint16
+	VendorID                        string
+}
+
+// RangeValue returns the full scale range in the sensor's units.
+func (m Metadata) RangeValue() float32 {
+	return float32(m.Range) / float32(uint32(1)<<min(m.QPoint1, 31))
+}
+
+// ResolutionValue returns the smallest step in the sensor's units.
+func (m Metadata) ResolutionValue() float32 {
+	return float32(m.Resolution) / float32(uint32(1)<<min(m.QPoint1, 31))
+}
+
+// PowerMilliamps returns the current drawn with the sensor on.
+func (m Metadata) PowerMilliamps() float32 {
+	return float32(m.Power) / (1 << 10)
+}
+
+// ParseMetadata decodes a sensor metadata record. Fields the record's
+// revision does not carry are left zero. It returns false if words is
+// too short.
+func ParseMetadata(words []uint32) (Metadata, bool) {
+	if len(words) < 7 {
+		return Metadata{}, false
+	}
+	m := Metadata{
+		MEVersion:        uint8(words[0]),
+		MHVersion:        uint8(words[0] >> 8),
+		SHVersion:        uint8(words[0] >> 16),
+		Range:            words[1],
+		Resolution:       words[2],
+		Power:            uint16(words[3]),
+		Revision:         uint16(words[3] >> 16),
+		MinPeriod:        words[4],
+		FIFOReserved:     uint16(words[5]),
+		FIFOMax:          uint16(words[5] >> 16),
+		BatchBufferBytes: uint16(words[6]),
+	}
+	vendorLen := int(words[6] >> 16)
+
+	// Later revisions fill word 7 and add sensor-specific bytes ahead of
+	// the vendor ID
+	vendor := 8
+	if m.Revision >= 1 && len(words) > 7 {
+		m.QPoint1, m.QPoint2 = uint16(words[7]), uint16(words[7]>>16)
+	}
+	if m.Revision >= 2 && len(words) > 8 {
+		specific := int(uint16(words[8]))
+		if m.Revision >= 3 {
+			m.QPoint3 = uint16(words[8] >> 16)
+		}
+		vendor = 9
+		if m.Revision >= 4 && len(words) > 9 {
+			m.MaxPeriod = words[9]
+			vendor = 10
+		}
+		vendor += (specific + 3) / 4
+	}
+
+	var b []byte
+	for i := vendor; i < len(words) && len(b) < vendorLen; i++ {
+		for shift := 0; shift < 32 && len(b) < vendorLen; shift += 8 {
+			b = append(b, byte(words[i]>>shift))
+		}
+	}
+	// The ID is zero terminated within its length
+	for i, c := range b {
+		if c == 0 {
+			b = b[:i]
+			break
+		}
+	}
+	m.VendorID = string(b)
+	return m, true
+}
@@ -0,0 +1,178 @@
+package frs
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Control channel report IDs for FRS writes.
+const (
+	ReportWriteResponse    = 0xF5
+	ReportWriteDataRequest = 0xF6
+	ReportWriteRequest     = 0xF7
+)
+
+// Lengths of the FRS write reports.
+const (
+	WriteRequestLen     = 6
+	WriteDataRequestLen = 12
+	WriteResponseLen    = 4
+)
+
+// Write Response status codes.
+const (
+	WriteWordsReceived  = 0
+	WriteUnknownType    = 1
+	WriteBusy           = 2
+	WriteCompleted      = 3
+	WriteReady          = 4
+	WriteFailed         = 5
+	WriteNotInWriteMode = 6
+	WriteInvalidLength  = 7
+	WriteRecordValid    = 8
+	WriteRecordInvalid  = 9
+	WriteDeviceError    = 10
+	WriteRecordReadOnly = 11
+)
+
+var (
+	ErrNotWriteResponse = errors.New("frs: not an FRS Write Response")
+	ErrWriteFailed      = errors.New("frs: write failed")
+	ErrInvalidLength    = errors.New("frs: invalid record length")
+	ErrRecordInvalid    = errors.New("frs: record rejected as invalid")
+	ErrReadOnly         = errors.New("frs: record is read only")
+	ErrWriteSequence    = errors.New("frs: data sent outside a write")
+)
+
+// AppendWriteRequest appends an FRS Write Request to b, opening a write
+// of a record words long. A length of 0 deletes the record, returning it
+// to its default.
+func AppendWriteRequest(b []byte, recordType, words uint16) []byte {
+	var req [WriteRequestLen]byte
+	req[0] = ReportWriteRequest
+	binary.LittleEndian.PutUint16(req[2:], words)
+	binary.LittleEndian.PutUint16(req[4:], recordType)
+	return append(b, req[:]...)
+}
+
+// AppendWriteData appends an FRS Write Data Request to b carrying the two
+// words at offset.
+func AppendWriteData(b []byte, offset uint16, d0, d1 uint32) []byte {
+	var req [WriteDataRequestLen]byte
+	req[0] = ReportWriteDataRequest
+	binary.LittleEndian.PutUint16(req[2:], offset)
+	binary.LittleEndian.PutUint32(req[4:], d0)
+	binary.LittleEndian.PutUint32(req[8:], d1)
+	return append(b, req[:]...)
+}
+
+// WriteResponse is a decoded FRS Write Response.
+type WriteResponse struct {
+	Status uint8
+	Offset uint16 // Word offset the status refers to
+}
+
+// Err returns the error a failure status stands for, or nil.
+func (r WriteResponse) Err() error {
+	switch r.Status {
+	case WriteUnknownType:
+		return ErrUnknownType
+	case WriteBusy:
+		return ErrBusy
+	case WriteFailed:
+		return ErrWriteFailed
+	case WriteNotInWriteMode:
+		return ErrWriteSequence
+	case WriteInvalidLength:
+		return ErrInvalidLength
+	case WriteRecordInvalid:
+		return ErrRecordInvalid
+	case WriteDeviceError:
+		return ErrDevice
+	case WriteRecordReadOnly:
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// ParseWriteResponse decodes an FRS Write Response from control channel
+// cargo.
+func ParseWriteResponse(cargo []byte) (WriteResponse, error) {
+	if len(cargo) < WriteResponseLen || cargo[0] != ReportWriteResponse {
+		return WriteResponse{}, ErrNotWriteResponse
+	}
+	return WriteResponse{Status: cargo[1], Offset: binary.LittleEndian.Uint16(cargo[2:])}, nil
+}
+
+// Writer steps through writing a record. Start returns the Write Request
+// to send; each Write Response passed to Next then returns the next
+// request to send, if any, until the write is done.
+type Writer struct {
+	Type  uint16
+	words []uint32
+	next  int
+	done  bool
+}
+
+// Start begins a write of words to recordType and appends the Write
+// Request to b. words must stay unchanged until the write is done; an
+// empty record deletes it.
+func (w *Writer) Start(b []byte, recordType uint16, words []uint32) []byte {
+	*w = Writer{Type: recordType, words: words}
+	return AppendWriteRequest(b, recordType, uint16(len(words)))
+}
+
+// Next takes a Write Response and appends the request to send next to b,
+// returning b unchanged if there is none. It reports whether the write
+// is over, with the error that ended it if it failed.
+func (w *Writer) Next(b []byte, r WriteResponse) ([]byte, bool, error) {
+	if w.done {
+		return b, true, nil
+	}
+	if err := r.Err(); err != nil {
+		w.done = true
+		return b, true, err
+	}
+	switch r.Status {
+	case WriteReady, WriteWordsReceived:
+		if w.next >= len(w.words) {
+			return b, false, nil
+		}
+		var d1 uint32
+		if w.next+1 < len(w.words) {
+			d1 = w.words[w.next+1]
+		}
+		b = AppendWriteData(b, uint16(w.next), w.words[w.next], d1)
+		w.next += 2
+		return b, false, nil
+	case WriteCompleted:
+		w.done = true
+		return b, true, nil
+	}
+	// Record valid, or a status this side does not know, changes nothing
+	return b, false, nil
+}
+
+// QuaternionWords encodes q as an orientation record: X, Y, Z and W as
+// signed fixed point with 30 fractional bits.
+func QuaternionWords(q bno08x.Quaternion) [4]uint32 {
+	return [4]uint32{toQ30(q.I), toQ30(q.J), toQ30(q.K), toQ30(q.Real)}
+}
+
+// toQ30 converts v to fixed point with 30 fractional bits, saturating
+// outside ±2.
+func toQ30(v float32) uint32 {
+	f := float64(v) * (1 << 30)
+	switch {
+	case f >= 1<<31-1:
+		return 1<<31 - 1
+	case f <= -(1 << 31):
+		return 1 << 31
+	}
+	if f < 0 {
+		return uint32(int32(f - 0.5))
+	}
+	return uint32(int32(f + 0.5))
+}
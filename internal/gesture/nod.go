@@ -0,0 +1,169 @@
+package gesture
+
+import "time"
+
+// HeadGesture is a yes or no head movement.
+type HeadGesture uint8
+
+const (
+	NoHeadGesture HeadGesture = iota
+	Nod                       // Pitch going up and down: yes
+	Shake                     // Yaw going side to side: no
+)
+
+func (g HeadGesture) String() string {
+	switch g {
+	case Nod:
+		return "yes"
+	case Shake:
+		return "no"
+	}
+	return "none"
+}
+
+// Defaults for NodDetector fields left at zero.
+const (
+	DefaultSwingThreshold = 10 // Degrees
+	DefaultNodWindow      = 1500 * time.Millisecond
+	DefaultMinSwings      = 3
+)
+
+// maxSwings bounds how many swings per axis are remembered.
+const maxSwings = 8
+
+// NodDetector classifies nods and head shakes from the pitch and yaw of a
+// head-mounted sensor.
+//
+// Each axis is reduced to swings: reversals of direction by at least
+// Threshold degrees, which ignores tremor and slow drift. A gesture is
+// reported when one axis makes MinSwings swings within Window and more
+// than the other axis did, so a diagonal wobble is not taken for either.
+type NodDetector struct {
+	Threshold float32       // DefaultSwingThreshold if zero
+	Window    time.Duration // DefaultNodWindow if zero
+	MinSwings int           // DefaultMinSwings if zero, at most 8
+
+	pitch, yaw swings
+	lastYaw    float32
+	yawTotal   float32
+	primed     bool
+}
+
+// Add records pitch and yaw in degrees, sampled at now, and returns the
+// gesture they complete, if any. Both axes start over after a gesture so
+// one movement is reported once.
+func (d *NodDetector) Add(pitch, yaw float32, now time.Time) HeadGesture {
+	threshold, window, minSwings := d.settings()
+
+	// Yaw wraps at ±180; follow it continuously so a shake facing south
+	// does not look like a full turn
+	if !d.primed {
+		d.lastYaw, d.yawTotal, d.primed = yaw, yaw, true
+		d.pitch.reset(pitch)
+		d.yaw.reset(yaw)
+	}
+	d.yawTotal += wrapDegrees(yaw - d.lastYaw)
+	d.lastYaw = yaw
+
+	d.pitch.add(pitch, threshold, now)
+	d.yaw.add(d.yawTotal, threshold, now)
+
+	nods := d.pitch.count(now, window)
+	shakes := d.yaw.count(now, window)
+	var gesture HeadGesture
+	switch {
+	case nods >= minSwings && nods > shakes:
+		gesture = Nod
+	case shakes >= minSwings && shakes > nods:
+		gesture = Shake
+	default:
+		return NoHeadGesture
+	}
+	d.pitch.reset(pitch)
+	d.yaw.reset(d.yawTotal)
+	return gesture
+}
+
+// Reset forgets all swings, e.g. after the sensor was put down.
+func (d *NodDetector) Reset() {
+	d.primed = false
+}
+
+func (d *NodDetector) settings() (float32, time.Duration, int) {
+	threshold, window, minSwings := d.Threshold, d.Window, d.MinSwings
+	if threshold <= 0 {
+		threshold = DefaultSwingThreshold
+	}
+	if window <= 0 {
+		window = DefaultNodWindow
+	}
+	if minSwings <= 0 {
+		minSwings = DefaultMinSwings
+	}
+	if minSwings > maxSwings {
+		minSwings = maxSwings
+	}
+	return threshold, window, minSwings
+}
+
+// swings tracks direction reversals of one angle.
+type swings struct {
+	low, high float32 // Range seen since the last reversal
+	dir       int8    // +1 rising, -1 falling, 0 not yet moved
+	times     [maxSwings]time.Time
+	next      int
+}
+
+func (s *swings) reset(angle float32) {
+	*s = swings{low: angle, high: angle}
+}
+
+// add follows the angle and records a swing when it turns back from its
+// furthest point by at least threshold.
+func (s *swings) add(angle, threshold float32, now time.Time) {
+	if angle > s.high {
+		s.high = angle
+	}
+	if angle < s.low {
+		s.low = angle
+	}
+	switch {
+	case s.dir >= 0 && s.high-angle >= threshold:
+		if s.dir > 0 {
+			s.record(now)
+		}
+		s.dir, s.low, s.high = -1, angle, angle
+	case s.dir <= 0 && angle-s.low >= threshold:
+		if s.dir < 0 {
+			s.record(now)
+		}
+		s.dir, s.low, s.high = 1, angle, angle
+	}
+}
+
+func (s *swings) record(now time.Time) {
+	s.times[s.next] = now
+	s.next = (s.next + 1) % maxSwings
+}
+
+// count returns the number of swings within window of now.
+func (s *swings) count(now time.Time, window time.Duration) int {
+	n := 0
+	for _, t := range s.times {
+		if !t.IsZero() && now.Sub(t) <= window {
+			n++
+		}
+	}
+	return n
+}
+
+// wrapDegrees maps an angle into [-180, 180].
+func wrapDegrees(a float32) float32 {
+	for a > 180 {
+		a -= 360
+	}
+	for a < -180 {
+		a += 360
+	}
+	return a
+}
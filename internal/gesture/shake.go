@@ -0,0 +1,61 @@
+package gesture
+
+import "time"
+
+// Defaults for ShakeCount fields left at zero.
+const (
+	DefaultShakes      = 3
+	DefaultShakeWindow = 2 * time.Second
+)
+
+// Most shakes a ShakeCount can ask for
+const maxShakes = 8
+
+// ShakeCount spots a deliberate run of shakes in shake detector reports,
+// such as the triple shake that asks a headless board to recalibrate. A
+// single shake happens by accident when the board is knocked; several
+// within a couple of seconds rarely do.
+type ShakeCount struct {
+	Shakes int           // Shakes in a run, up to 8
+	Window time.Duration // Longest a run may take
+
+	times [maxShakes]time.Time
+	next  int
+}
+
+// Shake feeds a shake detector report received at now and reports whether
+// it completed a run. The shakes of a run are forgotten once it completes,
+// so the next run starts afresh.
+func (c *ShakeCount) Shake(now time.Time) bool {
+	shakes, window := c.settings()
+	c.times[c.next] = now
+	c.next = (c.next + 1) % shakes
+
+	// With the ring full, the slot to be written next holds the oldest
+	oldest := c.times[c.next]
+	if oldest.IsZero() || now.Sub(oldest) > window {
+		return false
+	}
+	c.Reset()
+	return true
+}
+
+// Reset forgets the shakes seen so far.
+func (c *ShakeCount) Reset() {
+	c.times = [maxShakes]time.Time{}
+	c.next = 0
+}
+
+func (c *ShakeCount) settings() (int, time.Duration) {
+	shakes, window := c.Shakes, c.Window
+	if shakes <= 0 {
+		shakes = DefaultShakes
+	}
+	if shakes > maxShakes {
+		shakes = maxShakes
+	}
+	if window <= 0 {
+		window = DefaultShakeWindow
+	}
+	return shakes, window
+}
@@ -0,0 +1,124 @@
+// Package gesture turns BNO08x detector reports into gestures that
+// programs can act on.
+package gesture
+
+import "time"
+
+// Tap detector flag bits from the SH-2 tap report.
+const (
+	TapX         = 0x01
+	TapXPositive = 0x02
+	TapY         = 0x04
+	TapYPositive = 0x08
+	TapZ         = 0x10
+	TapZPositive = 0x20
+	TapDouble    = 0x40
+)
+
+// Tap is a decoded tap detector report.
+type Tap struct {
+	Axis     byte // 'X', 'Y' or 'Z', or 0 if no axis flag was set
+	Positive bool
+	Double   bool
+}
+
+// DecodeTap decodes the flags of a tap detector report. If several axes
+// are flagged the first of X, Y, Z wins.
+func DecodeTap(flags uint8) Tap {
+	t := Tap{Double: flags&TapDouble != 0}
+	switch {
+	case flags&TapX != 0:
+		t.Axis, t.Positive = 'X', flags&TapXPositive != 0
+	case flags&TapY != 0:
+		t.Axis, t.Positive = 'Y', flags&TapYPositive != 0
+	case flags&TapZ != 0:
+		t.Axis, t.Positive = 'Z', flags&TapZPositive != 0
+	}
+	return t
+}
+
+// String describes the tap, e.g. "Double tap on Z+ axis".
+func (t Tap) String() string {
+	var buf [24]byte
+	return string(t.Append(buf[:0]))
+}
+
+// Append appends the description String gives to dst, without
+// allocating.
+func (t Tap) Append(dst []byte) []byte {
+	if t.Double {
+		dst = append(dst, "Double tap"...)
+	} else {
+		dst = append(dst, "Single tap"...)
+	}
+	if t.Axis == 0 {
+		return dst
+	}
+	dst = append(dst, " on "...)
+	dst = append(dst, t.Axis)
+	if t.Positive {
+		dst = append(dst, '+')
+	} else {
+		dst = append(dst, '-')
+	}
+	return append(dst, " axis"...)
+}
+
+// TapKind is the gesture a tap resolves to.
+type TapKind uint8
+
+const (
+	NoTap TapKind = iota
+	SingleTap
+	DoubleTap
+)
+
+// DefaultDoubleTapWindow is how long a single tap is held back waiting for
+// the report that turns it into a double tap.
+const DefaultDoubleTapWindow = 500 * time.Millisecond
+
+// TapFilter separates single and double taps. The sensor reports the first
+// tap of a double tap as a single tap, then a second report with the
+// double flag, so acting on every report would see both. The filter holds
+// each single tap for Window and drops it if a double tap follows.
+type TapFilter struct {
+	Window  time.Duration // DefaultDoubleTapWindow if zero
+	pending bool
+	at      time.Time
+}
+
+// Add records a tap report received at now. Double taps are returned at
+// once, with the time of their first tap; single taps are returned later
+// by Poll.
+func (f *TapFilter) Add(t Tap, now time.Time) (TapKind, time.Time) {
+	if t.Double {
+		at := now
+		if f.pending {
+			at = f.at
+		}
+		f.pending = false
+		return DoubleTap, at
+	}
+	if f.pending {
+		// A second single tap outside a double: flush the first
+		at := f.at
+		f.at = now
+		return SingleTap, at
+	}
+	f.pending, f.at = true, now
+	return NoTap, time.Time{}
+}
+
+// Poll returns a held single tap, with the time it happened, once the
+// double tap window has passed.
+func (f *TapFilter) Poll(now time.Time) (TapKind, time.Time) {
+	window := f.Window
+	if window == 0 {
+		window = DefaultDoubleTapWindow
+	}
+	if f.pending && now.Sub(f.at) >= window {
+		f.pending = false
+		return SingleTap, f.at
+	}
+	return NoTap, time.Time{}
+}
@@ -0,0 +1,128 @@
+package gesture
+
+import (
+	"math"
+	"time"
+)
+
+// WakeEvent is a change of a wrist-worn device's display state.
+type WakeEvent uint8
+
+const (
+	NoWakeChange WakeEvent = iota
+	Wake
+	Sleep
+)
+
+func (e WakeEvent) String() string {
+	switch e {
+	case Wake:
+		return "wake"
+	case Sleep:
+		return "sleep"
+	}
+	return "none"
+}
+
+// Defaults for WristRaise fields left at zero.
+const (
+	DefaultFaceUpAngle  = 35 // Degrees
+	DefaultLoweredAngle = 60 // Degrees
+	DefaultRaiseTime    = time.Second
+	DefaultAwakeTime    = 5 * time.Second
+)
+
+// WristRaise is smartwatch-style raise-to-wake. The board is worn like a
+// watch face with its Z axis pointing out of the face.
+//
+// It wakes when the face turns up within RaiseTime of having been lowered
+// (arm hanging or held sideways), or on a pickup detector report, and
+// sleeps when the face is lowered again or after AwakeTime. Holding the
+// board face up on a desk does not wake it, since there is no raise.
+type WristRaise struct {
+	FaceUpAngle  float32       // Tilt from vertical counted as facing the wearer
+	LoweredAngle float32       // Tilt from vertical counted as lowered
+	RaiseTime    time.Duration // Longest lowered to face-up movement
+	AwakeTime    time.Duration // How long to stay awake after waking
+
+	awake     bool
+	lowered   bool
+	loweredAt time.Time
+	wokeAt    time.Time
+}
+
+// Awake reports whether the display should be on.
+func (w *WristRaise) Awake() bool {
+	return w.awake
+}
+
+// Gravity feeds a gravity vector report, in any unit, received at now.
+func (w *WristRaise) Gravity(x, y, z float32, now time.Time) WakeEvent {
+	g := float32(math.Sqrt(float64(x*x + y*y + z*z)))
+	if g == 0 {
+		return NoWakeChange
+	}
+	faceUp, lowered := w.angles()
+	up := z / g // Cosine of the tilt from vertical
+
+	switch {
+	case up < lowered:
+		w.lowered, w.loweredAt = true, now
+		if w.awake {
+			w.awake = false
+			return Sleep
+		}
+	case up > faceUp && !w.awake && w.lowered:
+		w.lowered = false
+		raise := w.RaiseTime
+		if raise <= 0 {
+			raise = DefaultRaiseTime
+		}
+		if now.Sub(w.loweredAt) <= raise {
+			return w.wake(now)
+		}
+	}
+	return NoWakeChange
+}
+
+// Pickup feeds a pickup detector report received at now.
+func (w *WristRaise) Pickup(now time.Time) WakeEvent {
+	if w.awake {
+		return NoWakeChange
+	}
+	return w.wake(now)
+}
+
+// Poll puts the display to sleep once it has been awake for AwakeTime.
+func (w *WristRaise) Poll(now time.Time) WakeEvent {
+	awake := w.AwakeTime
+	if awake <= 0 {
+		awake = DefaultAwakeTime
+	}
+	if w.awake && now.Sub(w.wokeAt) >= awake {
+		w.awake = false
+		return Sleep
+	}
+	return NoWakeChange
+}
+
+func (w *WristRaise) wake(now time.Time) WakeEvent {
+	w.awake, w.wokeAt = true, now
+	return Wake
+}
+
+// angles returns the cosines of the face-up and lowered tilt limits.
+func (w *WristRaise) angles() (faceUp, lowered float32) {
+	up, down := w.FaceUpAngle, w.LoweredAngle
+	if up <= 0 {
+		up = DefaultFaceUpAngle
+	}
+	if down <= 0 {
+		down = DefaultLoweredAngle
+	}
+	return cosDegrees(up), cosDegrees(down)
+}
+
+func cosDegrees(a float32) float32 {
+	return float32(math.Cos(float64(a) * math.Pi / 180))
+}
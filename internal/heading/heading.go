@@ -0,0 +1,95 @@
+// Package heading turns BNO08x orientation data into a compass heading in
+// degrees clockwise from north, corrected for magnetic declination.
+//
+// The BNO08x world frame is East-North-Up, and the heading is the direction
+// the sensor's +X axis points, projected onto the horizontal plane. Both the
+// rotation vector and the magnetometer paths are tilt compensated.
+package heading
+
+import (
+	"math"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Compass converts orientation data to true-north headings.
+type Compass struct {
+	// Declination is the local magnetic declination in degrees, positive
+	// when magnetic north is east of true north. Look it up for your
+	// location, e.g. on the NOAA magnetic field calculator.
+	Declination float32
+}
+
+// FromQuaternion returns the true heading from a rotation vector (not a game
+// rotation vector, which has no magnetic reference).
+func (c *Compass) FromQuaternion(q bno08x.Quaternion) float32 {
+	return c.True(Magnetic(q))
+}
+
+// FromMagnetometer returns the true heading from a calibrated magnetometer
+// reading and a gravity or accelerometer reading taken at the same time,
+// both in sensor coordinates.
+func (c *Compass) FromMagnetometer(mx, my, mz, gx, gy, gz float32) float32 {
+	return c.True(MagneticTiltCompensated(mx, my, mz, gx, gy, gz))
+}
+
+// True applies the declination to a magnetic heading.
+func (c *Compass) True(magnetic float32) float32 {
+	return Normalize(magnetic + c.Declination)
+}
+
+// Magnetic returns the magnetic heading of the sensor's +X axis from a
+// rotation vector quaternion.
+func Magnetic(q bno08x.Quaternion) float32 {
+	// First column of the rotation matrix: the +X axis in world coordinates
+	east := 1 - 2*(q.J*q.J+q.K*q.K)
+	north := 2 * (q.I*q.J + q.Real*q.K)
+	return Normalize(degrees(math.Atan2(float64(east), float64(north))))
+}
+
+// MagneticTiltCompensated returns the magnetic heading of the sensor's +X
+// axis from magnetometer and gravity vectors in sensor coordinates. The
+// gravity vector must point up, as the accelerometer reports it at rest.
+func MagneticTiltCompensated(mx, my, mz, gx, gy, gz float32) float32 {
+	// East is perpendicular to both the field and up: E = M x G
+	ex := my*gz - mz*gy
+	ey := mz*gx - mx*gz
+	ez := mx*gy - my*gx
+	// North completes the frame: N = G x E
+	nx := gy*ez - gz*ey
+	// Only the X components are needed for the +X axis heading, but E
+	// and N must share a scale, so normalize both by their lengths.
+	eLen := float32(math.Sqrt(float64(ex*ex + ey*ey + ez*ez)))
+	gLen := float32(math.Sqrt(float64(gx*gx + gy*gy + gz*gz)))
+	if eLen == 0 || gLen == 0 {
+		return 0
+	}
+	return Normalize(degrees(math.Atan2(float64(ex/eLen), float64(nx/(eLen*gLen)))))
+}
+
+// Normalize wraps an angle in degrees into [0, 360).
+func Normalize(deg float32) float32 {
+	for deg < 0 {
+		deg += 360
+	}
+	for deg >= 360 {
+		deg -= 360
+	}
+	return deg
+}
+
+// cardinals are the 16 compass points starting at north.
+var cardinals = [16]string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// Cardinal returns the 16-point compass direction for a heading.
+func Cardinal(deg float32) string {
+	return cardinals[int(Normalize(deg)/22.5+0.5)%16]
+}
+
+// degrees converts radians to degrees.
+func degrees(rad float64) float32 {
+	return float32(rad * 180 / math.Pi)
+}
@@ -0,0 +1,61 @@
+// Package hexdump prints byte slices as compact hex and ASCII lines over
+// the serial console, without fmt and without allocating per byte.
+package hexdump
+
+// BytesPerLine is the number of bytes shown on each dump line.
+const BytesPerLine = 16
+
+const hex = "0123456789abcdef"
+
+// lineLen is the width of one line: indent, 4-digit offset, hex and ASCII.
+const lineLen = 4 + 4 + 2 + BytesPerLine*3 + 1 + BytesPerLine + 1
+
+// Print writes data as offset, hex and ASCII columns, one line per
+// BytesPerLine bytes. Non-printable bytes are shown as '.'.
+func Print(data []byte) {
+	var line [lineLen]byte
+	for offset := 0; offset < len(data); offset += BytesPerLine {
+		end := offset + BytesPerLine
+		if end > len(data) {
+			end = len(data)
+		}
+		n := formatLine(line[:], offset, data[offset:end])
+		println(string(line[:n]))
+	}
+}
+
+// formatLine fills buf with one dump line and returns its length.
+func formatLine(buf []byte, offset int, chunk []byte) int {
+	n := copy(buf, "    ")
+	buf[n] = hex[(offset>>12)&0xF]
+	buf[n+1] = hex[(offset>>8)&0xF]
+	buf[n+2] = hex[(offset>>4)&0xF]
+	buf[n+3] = hex[offset&0xF]
+	n += 4
+	buf[n] = ' '
+	buf[n+1] = ' '
+	n += 2
+	for i := 0; i < BytesPerLine; i++ {
+		if i < len(chunk) {
+			buf[n] = hex[chunk[i]>>4]
+			buf[n+1] = hex[chunk[i]&0xF]
+		} else {
+			buf[n] = ' '
+			buf[n+1] = ' '
+		}
+		buf[n+2] = ' '
+		n += 3
+	}
+	buf[n] = '|'
+	n++
+	for _, b := range chunk {
+		if b >= 0x20 && b < 0x7F {
+			buf[n] = b
+		} else {
+			buf[n] = '.'
+		}
+		n++
+	}
+	buf[n] = '|'
+	return n + 1
+}
@@ -0,0 +1,189 @@
+// Package irqpoll services the sensor when its INT line says data is
+// ready, instead of sleeping a fixed interval between polls. A loop that
+// sleeps 10ms finds each report up to 10ms late, and if reports pile up
+// faster than it drains them the sensor drops some; waking on INT finds
+// each one within a fraction of a millisecond.
+//
+// TinyGo cannot send on a channel from an interrupt handler, so the
+// handler sets a flag and Wait checks it every checkInterval, sleeping in
+// between; the core still wakes ten thousand times a second, but only for
+// a moment. On a board without INT wired Wait falls back to sleeping
+// PollInterval, as the programs did before.
+//
+// The handler timestamps each INT edge, so Latency measures how long data
+// waited to be read. SetPolling makes Wait sleep PollInterval even with
+// INT wired, timing the reads the same way, so the two can be compared on
+// the same board.
+//
+//	p, err := irqpoll.New(board.IntPin())
+//	if err != nil {
+//		println("Polling instead of waiting for INT:", err.Error())
+//	}
+//	for {
+//		if !p.Wait(100 * time.Millisecond) {
+//			continue
+//		}
+//		for event, ok := sensor.GetSensorEvent(); ok; event, ok = sensor.GetSensorEvent() {
+//			// ...
+//		}
+//		p.Done()
+//	}
+package irqpoll
+
+import (
+	"errors"
+	"machine"
+	"sync/atomic"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+)
+
+// PollInterval is how often Wait returns without an INT interrupt.
+const PollInterval = 10 * time.Millisecond
+
+// Interval at which Wait looks at the flag the interrupt sets
+const checkInterval = 100 * time.Microsecond
+
+var ErrNoPin = errors.New("irqpoll: no INT pin assigned in internal/board")
+
+// Latency summarises how long after the INT edge Wait returned.
+type Latency struct {
+	Samples       int
+	Min, Avg, Max time.Duration
+	Polled        bool // Wait slept PollInterval rather than waking on INT
+}
+
+// String summarises the latencies and how Wait was waiting.
+func (l Latency) String() string {
+	mode := "waking on INT"
+	if l.Polled {
+		mode = "polling every " + fmtutil.Int(int(PollInterval/time.Millisecond)) + "ms"
+	}
+	if l.Samples == 0 {
+		return "no INT edges, " + mode
+	}
+	micros := func(d time.Duration) string {
+		return fmtutil.Int64(d.Microseconds()) + "us"
+	}
+	return "INT to read latency min " + micros(l.Min) + " avg " + micros(l.Avg) + " max " + micros(l.Max) +
+		" over " + fmtutil.Int(l.Samples) + " reads, " + mode
+}
+
+// Poller waits for the sensor's INT line.
+type Poller struct {
+	pin     machine.Pin
+	start   time.Time
+	edge    uint32 // Microseconds since start plus one; zero when no edge waits
+	polling bool
+
+	samples  int
+	min, max time.Duration
+	total    time.Duration
+}
+
+// New configures pin as the INT input with an interrupt on its falling
+// edge. If pin is machine.NoPin or the interrupt cannot be enabled it
+// returns the error with a Poller that polls every PollInterval, so the
+// program can say so and carry on.
+func New(pin machine.Pin) (*Poller, error) {
+	p := &Poller{pin: machine.NoPin, start: time.Now()}
+	if pin == machine.NoPin {
+		return p, ErrNoPin
+	}
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	err := pin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		// Keep the first edge of data not yet read
+		atomic.CompareAndSwapUint32(&p.edge, 0, uint32(time.Since(p.start).Microseconds())+1)
+	})
+	if err != nil {
+		return p, err
+	}
+	p.pin = pin
+	return p, nil
+}
+
+// Interrupts reports whether the Poller wakes on INT rather than polling.
+func (p *Poller) Interrupts() bool {
+	return p.pin != machine.NoPin
+}
+
+// SetPolling makes Wait sleep PollInterval between reads even with INT
+// wired, still timing each read from the INT edge, or wake on INT again.
+// It starts a new Latency summary. Without INT wired Wait always polls.
+func (p *Poller) SetPolling(on bool) {
+	p.polling = on
+	p.Latency()
+}
+
+// Wait waits up to timeout for the sensor to have data and reports
+// whether it has. Read everything waiting, then call Done.
+func (p *Poller) Wait(timeout time.Duration) bool {
+	if p.pin == machine.NoPin {
+		time.Sleep(min(PollInterval, timeout))
+		return true
+	}
+	if p.polling {
+		time.Sleep(min(PollInterval, timeout))
+		p.take()
+		return true
+	}
+	start := time.Now()
+	for {
+		if p.take() {
+			return true
+		}
+		// Low without a new edge: data arrived while the last was read
+		if !p.pin.Get() {
+			return true
+		}
+		if time.Since(start) >= timeout {
+			return false
+		}
+		time.Sleep(checkInterval)
+	}
+}
+
+// Done is called once the data Wait announced has been read. An edge
+// that came while reading belongs to data already read if INT is high
+// again, and is forgotten so it does not count as a late wake; if INT is
+// still low the next Wait returns at once.
+func (p *Poller) Done() {
+	if p.pin != machine.NoPin && p.pin.Get() {
+		atomic.StoreUint32(&p.edge, 0)
+	}
+}
+
+// Latency returns the latencies recorded since the last call and starts
+// a new summary.
+func (p *Poller) Latency() Latency {
+	l := Latency{Samples: p.samples, Min: p.min, Max: p.max, Polled: p.polling || p.pin == machine.NoPin}
+	if p.samples > 0 {
+		l.Avg = p.total / time.Duration(p.samples)
+	}
+	p.samples, p.min, p.max, p.total = 0, 0, 0, 0
+	return l
+}
+
+// take records the latency of a waiting INT edge and clears it,
+// reporting whether there was one.
+func (p *Poller) take() bool {
+	edge := atomic.SwapUint32(&p.edge, 0)
+	if edge == 0 {
+		return false
+	}
+	now := uint32(time.Since(p.start).Microseconds()) + 1
+	p.record(time.Duration(now-edge) * time.Microsecond)
+	return true
+}
+
+func (p *Poller) record(d time.Duration) {
+	if p.samples == 0 || d < p.min {
+		p.min = d
+	}
+	if d > p.max {
+		p.max = d
+	}
+	p.total += d
+	p.samples++
+}
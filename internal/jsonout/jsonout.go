@@ -0,0 +1,84 @@
+// Package jsonout writes sensor events as compact JSON lines, one object
+// per event, for host tools that read the serial stream:
+//
+//	{"t":1200345,"id":1,"x":0.012,"y":-0.034,"z":9.807}
+//	{"t":1200391,"id":8,"i":0.010,"j":0.021,"k":0.707,"r":0.707}
+//
+// Every line has "t", microseconds since the encoder was created, and
+// "id", the sensor ID. The remaining keys depend on the sensor: "x", "y"
+// and "z" for vectors, with "bx", "by" and "bz" for the bias of the
+// uncalibrated ones; "i", "j", "k" and "r" for rotation vectors, with
+// "acc" for the heading accuracy in radians where the sensor reports it;
+// "v" for single values; and named counts such as "steps" for detectors,
+// as sensorinfo.Values reads them.
+//
+// Lines are built in a fixed buffer with no reflection, unlike
+// encoding/json, so encoding an event does not allocate. Writer, which
+// builds them, also serves programs writing JSON documents of their own.
+package jsonout
+
+import (
+	"io"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// maxLine bounds one encoded line; the longest, an uncalibrated vector at
+// the largest decimals, is well under it.
+const maxLine = 256
+
+// Encoder writes events to w.
+type Encoder struct {
+	w        io.Writer
+	decimals int
+	start    time.Time
+	buf      [maxLine]byte
+	line     []byte
+	doc      Writer
+	values   sensorinfo.Values
+}
+
+// New returns an encoder writing to w, printing floats with the given
+// number of decimal places. Timestamps count from now. w may be nil if
+// only Append is used.
+func New(w io.Writer, decimals int) *Encoder {
+	return &Encoder{w: w, decimals: decimals, start: time.Now()}
+}
+
+// Encode writes the line for event. Events that carry no value, such as
+// significant motion or a flip, are written with only "t" and "id".
+func (e *Encoder) Encode(event *bno08x.SensorValue) error {
+	e.line = append(e.Append(e.buf[:0], event), '\n')
+	_, err := e.w.Write(e.line)
+	return err
+}
+
+// Append appends the object for event to dst without the newline, for
+// messages that carry one object each, such as MQTT payloads.
+func (e *Encoder) Append(dst []byte, event *bno08x.SensorValue) []byte {
+	e.doc.Reset(dst)
+	e.doc.Open()
+	e.doc.Int("t", time.Since(e.start).Microseconds())
+	e.doc.Int("id", int64(event.ID()))
+
+	e.values.Read(event)
+	for _, v := range e.values.Slice() {
+		if v.Integer {
+			e.doc.Int(v.Key, v.Int)
+		} else {
+			e.doc.Float(v.Key, v.Float, e.decimals)
+		}
+	}
+
+	e.doc.Close()
+	e.line = e.doc.Bytes()
+	return e.line
+}
+
+// Send encodes event, dropping write errors, so an Encoder is an
+// output.Sink.
+func (e *Encoder) Send(event *bno08x.SensorValue) {
+	e.Encode(event)
+}
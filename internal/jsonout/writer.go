@@ -0,0 +1,120 @@
+package jsonout
+
+import (
+	"math"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+)
+
+// Writer builds a JSON document without reflection, which encoding/json
+// needs and TinyGo supports poorly. Members are written in the order the
+// methods are called, and the caller keeps objects and arrays balanced.
+// The zero value appends to a new slice.
+type Writer struct {
+	buf   []byte
+	comma bool
+}
+
+// Reset starts a new document appended to dst.
+func (w *Writer) Reset(dst []byte) {
+	w.buf, w.comma = dst, false
+}
+
+// Bytes returns the document written so far.
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+// Open starts an object, as a value or an array item.
+func (w *Writer) Open() {
+	w.buf = append(w.buf, '{')
+	w.comma = false
+}
+
+// Close ends the object started by Open.
+func (w *Writer) Close() {
+	w.buf = append(w.buf, '}')
+	w.comma = true
+}
+
+func (w *Writer) key(name string) {
+	if w.comma {
+		w.buf = append(w.buf, ',')
+	}
+	w.comma = true
+	w.Quote(name)
+	w.buf = append(w.buf, ':')
+}
+
+// Array writes the member name as an array of n items, each written by
+// item with Number, Quote or Open and Close.
+func (w *Writer) Array(name string, n int, item func(i int)) {
+	w.key(name)
+	w.buf = append(w.buf, '[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			w.buf = append(w.buf, ',')
+		}
+		w.comma = false
+		item(i)
+	}
+	w.buf = append(w.buf, ']')
+	w.comma = true
+}
+
+// Number writes a bare integer, for array items.
+func (w *Writer) Number(v int64) {
+	w.buf = fmtutil.AppendInt(w.buf, v)
+}
+
+// String writes a string member.
+func (w *Writer) String(name, value string) {
+	w.key(name)
+	w.Quote(value)
+}
+
+// Int writes an integer member.
+func (w *Writer) Int(name string, value int64) {
+	w.key(name)
+	w.buf = fmtutil.AppendInt(w.buf, value)
+}
+
+// Float writes a number member with the given decimal places, or null
+// for NaN and infinities, which JSON cannot represent.
+func (w *Writer) Float(name string, value float32, decimals int) {
+	w.key(name)
+	if math.IsNaN(float64(value)) || math.IsInf(float64(value), 0) {
+		w.buf = append(w.buf, "null"...)
+		return
+	}
+	w.buf = fmtutil.AppendFloat(w.buf, value, decimals)
+}
+
+// Bool writes a boolean member.
+func (w *Writer) Bool(name string, value bool) {
+	w.key(name)
+	if value {
+		w.buf = append(w.buf, "true"...)
+	} else {
+		w.buf = append(w.buf, "false"...)
+	}
+}
+
+// Quote writes s as a JSON string, escaping quotes, backslashes and
+// control characters.
+func (w *Writer) Quote(s string) {
+	const hex = "0123456789abcdef"
+	w.buf = append(w.buf, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			w.buf = append(w.buf, '\\', c)
+		case c < 0x20:
+			w.buf = append(w.buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0x0F])
+		default:
+			w.buf = append(w.buf, c)
+		}
+	}
+	w.buf = append(w.buf, '"')
+}
@@ -0,0 +1,40 @@
+package jsonout
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var w Writer
+	w.Open()
+	w.String("board", "pico \"w\"\n")
+	w.Bool("pass", true)
+	w.Int("bus_hz", 400000)
+	w.Float("hz", 99.96, 1)
+	w.Float("bad", float32(math.NaN()), 1)
+	w.Array("ids", 2, func(i int) {
+		w.Open()
+		w.Int("id", int64(i+1))
+		w.Close()
+	})
+	w.Array("latency_us", 3, func(i int) {
+		w.Number(int64(-i))
+	})
+	w.Array("warnings", 0, func(i int) {})
+	w.Close()
+
+	want := `{"board":"pico \"w\"\u000a","pass":true,"bus_hz":400000,"hz":100.0,"bad":null,` +
+		`"ids":[{"id":1},{"id":2}],"latency_us":[0,-1,-2],"warnings":[]}`
+	if got := string(w.Bytes()); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	w.Reset([]byte("x"))
+	w.Open()
+	w.Int("a", 1)
+	w.Close()
+	if got := string(w.Bytes()); got != `x{"a":1}` {
+		t.Errorf("after Reset: %s", got)
+	}
+}
@@ -0,0 +1,169 @@
+// Package mavlink encodes the few MAVLink v2 messages an IMU sends to a
+// ground station or flight controller: HEARTBEAT, ATTITUDE_QUATERNION and
+// HIGHRES_IMU from the common message set. It writes packets only;
+// nothing is parsed, and packets are unsigned.
+//
+// Packet layout, multi-byte fields little-endian:
+//
+//	0     0xFD start marker
+//	1     payload length n
+//	2     incompatibility flags, 0
+//	3     compatibility flags, 0
+//	4     sequence
+//	5     system ID
+//	6     component ID
+//	7-9   message ID
+//	10..  payload, trailing zero bytes removed
+//	10+n  CRC-16/MCRF4XX of bytes 1 to 9+n and the message's CRC extra
+//
+// Payload fields are in MAVLink wire order, largest type first, which is
+// not the order the message definitions list them in.
+package mavlink
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	// Magic starts every MAVLink v2 packet.
+	Magic = 0xFD
+	// HeaderLen is the length of a packet before its payload.
+	HeaderLen = 10
+	// MaxPacketLen bounds the packets this package writes.
+	MaxPacketLen = HeaderLen + 64 + 2
+)
+
+// Message IDs and the CRC extra bytes that seed each message's checksum
+// with a hash of its definition.
+const (
+	MsgHeartbeat          = 0
+	MsgAttitudeQuaternion = 31
+	MsgHighresIMU         = 105
+
+	crcExtraHeartbeat          = 50
+	crcExtraAttitudeQuaternion = 246
+	crcExtraHighresIMU         = 93
+)
+
+// Values for Heartbeat fields.
+const (
+	TypeGeneric      = 0   // MAV_TYPE_GENERIC
+	AutopilotInvalid = 8   // MAV_AUTOPILOT_INVALID, not a flight controller
+	StateActive      = 4   // MAV_STATE_ACTIVE
+	ComponentIMU     = 200 // MAV_COMP_ID_IMU
+	ProtocolVersion  = 3
+)
+
+// HighresIMU.FieldsUpdated bits.
+const (
+	UpdatedAccel       = 0x0007
+	UpdatedGyro        = 0x0038
+	UpdatedMag         = 0x01C0
+	UpdatedPressure    = 0x0200
+	UpdatedTemperature = 0x1000
+)
+
+// Heartbeat announces the component, normally once a second. Ground
+// stations list nothing until they hear one.
+type Heartbeat struct {
+	CustomMode   uint32
+	Type         uint8
+	Autopilot    uint8
+	BaseMode     uint8
+	SystemStatus uint8
+}
+
+// AttitudeQuaternion is the orientation of the body frame, forward, right,
+// down, in the local north, east, down frame, with the body rates.
+type AttitudeQuaternion struct {
+	TimeBootMs                      uint32
+	Q1, Q2, Q3, Q4                  float32 // w, x, y, z
+	RollSpeed, PitchSpeed, YawSpeed float32 // rad/s
+}
+
+// HighresIMU is an IMU sample in SI units, body frame forward, right,
+// down. FieldsUpdated says which fields hold new data.
+type HighresIMU struct {
+	TimeUsec            uint64
+	XAcc, YAcc, ZAcc    float32 // m/s²
+	XGyro, YGyro, ZGyro float32 // rad/s
+	XMag, YMag, ZMag    float32 // gauss
+	AbsPressure         float32 // hPa
+	DiffPressure        float32 // hPa
+	PressureAlt         float32
+	Temperature         float32 // °C
+	FieldsUpdated       uint16
+}
+
+// Encoder numbers and frames packets from one system and component.
+type Encoder struct {
+	System    uint8
+	Component uint8
+
+	sequence uint8
+	payload  [64]byte
+}
+
+// AppendHeartbeat appends a HEARTBEAT packet to dst.
+func (e *Encoder) AppendHeartbeat(dst []byte, m Heartbeat) []byte {
+	p := binary.LittleEndian.AppendUint32(e.payload[:0], m.CustomMode)
+	p = append(p, m.Type, m.Autopilot, m.BaseMode, m.SystemStatus, ProtocolVersion)
+	return e.appendPacket(dst, MsgHeartbeat, crcExtraHeartbeat, p)
+}
+
+// AppendAttitudeQuaternion appends an ATTITUDE_QUATERNION packet to dst.
+// The repr_offset_q extension is left out, which receivers read as zero.
+func (e *Encoder) AppendAttitudeQuaternion(dst []byte, m AttitudeQuaternion) []byte {
+	p := binary.LittleEndian.AppendUint32(e.payload[:0], m.TimeBootMs)
+	p = appendFloats(p, m.Q1, m.Q2, m.Q3, m.Q4, m.RollSpeed, m.PitchSpeed, m.YawSpeed)
+	return e.appendPacket(dst, MsgAttitudeQuaternion, crcExtraAttitudeQuaternion, p)
+}
+
+// AppendHighresIMU appends a HIGHRES_IMU packet to dst, leaving out the
+// id extension.
+func (e *Encoder) AppendHighresIMU(dst []byte, m HighresIMU) []byte {
+	p := binary.LittleEndian.AppendUint64(e.payload[:0], m.TimeUsec)
+	p = appendFloats(p, m.XAcc, m.YAcc, m.ZAcc, m.XGyro, m.YGyro, m.ZGyro, m.XMag, m.YMag, m.ZMag,
+		m.AbsPressure, m.DiffPressure, m.PressureAlt, m.Temperature)
+	p = binary.LittleEndian.AppendUint16(p, m.FieldsUpdated)
+	return e.appendPacket(dst, MsgHighresIMU, crcExtraHighresIMU, p)
+}
+
+func (e *Encoder) appendPacket(dst []byte, msgID uint32, crcExtra uint8, payload []byte) []byte {
+	// MAVLink 2 drops trailing zeros, keeping at least one byte
+	for len(payload) > 1 && payload[len(payload)-1] == 0 {
+		payload = payload[:len(payload)-1]
+	}
+	start := len(dst)
+	dst = append(dst, Magic, uint8(len(payload)), 0, 0, e.sequence, e.System, e.Component,
+		uint8(msgID), uint8(msgID>>8), uint8(msgID>>16))
+	dst = append(dst, payload...)
+	crc := CRC(dst[start+1:])
+	crc = crcAccumulate(crc, crcExtra)
+	e.sequence++
+	return binary.LittleEndian.AppendUint16(dst, crc)
+}
+
+// CRC returns the CRC-16/MCRF4XX of b, the X.25 checksum MAVLink uses,
+// without the CRC extra byte.
+func CRC(b []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, c := range b {
+		crc = crcAccumulate(crc, c)
+	}
+	return crc
+}
+
+func crcAccumulate(crc uint16, b uint8) uint16 {
+	tmp := b ^ uint8(crc)
+	tmp ^= tmp << 4
+	return crc>>8 ^ uint16(tmp)<<8 ^ uint16(tmp)<<3 ^ uint16(tmp)>>4
+}
+
+func appendFloats(dst []byte, values ...float32) []byte {
+	for _, v := range values {
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(v))
+	}
+	return dst
+}
@@ -0,0 +1,311 @@
+// Package mqtt is a small MQTT 3.1.1 client for publishing telemetry to a
+// broker such as Mosquitto. It sends QoS 0 messages, registers a retained
+// last will so subscribers see when the board drops off, keeps the
+// connection alive with pings and reconnects with exponential backoff. It
+// does not subscribe.
+//
+// The package does not open connections itself: programs pass a Dialer,
+// which on WiFi boards wraps net.Dial.
+package mqtt
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// Reconnect delays after a failed connection, doubling up to MaxBackoff.
+const (
+	MinBackoff = 2 * time.Second
+	MaxBackoff = 5 * time.Minute
+)
+
+// MaxPacketLen bounds an encoded packet; longer topics and payloads are
+// refused with ErrTooLong.
+const MaxPacketLen = 512
+
+// ackTimeout bounds the wait for the broker's CONNACK.
+const ackTimeout = 5 * time.Second
+
+// Packet types, shifted into the top bits of the first byte.
+const (
+	packetConnect    = 0x10
+	packetConnack    = 0x20
+	packetPublish    = 0x30
+	packetPingreq    = 0xC0
+	packetDisconnect = 0xE0
+)
+
+// CONNECT flags.
+const (
+	flagCleanSession = 0x02
+	flagWill         = 0x04
+	flagWillRetain   = 0x20
+)
+
+var (
+	ErrNotConnected = errors.New("mqtt: not connected")
+	ErrTooLong      = errors.New("mqtt: packet too long")
+	ErrBadAck       = errors.New("mqtt: unexpected reply to CONNECT")
+	ErrRefused      = errors.New("mqtt: connection refused by broker")
+)
+
+// Conn is a connection to the broker; a net.Conn is one.
+type Conn interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+}
+
+// Dialer opens a new connection to the broker.
+type Dialer func() (Conn, error)
+
+// Options configure the session.
+type Options struct {
+	ClientID  string
+	KeepAlive time.Duration // Ping interval the broker enforces, at least a second
+
+	// WillTopic, if set, receives WillMessage, retained, when the broker
+	// loses the connection without a DISCONNECT.
+	WillTopic   string
+	WillMessage string
+}
+
+// Client publishes messages, connecting on Poll.
+type Client struct {
+	opts     Options
+	dial     Dialer
+	conn     Conn
+	backoff  time.Duration
+	next     time.Time
+	lastSend time.Time
+	buf      [MaxPacketLen]byte
+	scratch  [16]byte
+
+	// Connects counts successful connections, Published messages sent and
+	// Dropped messages published while disconnected or lost to a failed
+	// write.
+	Connects  uint32
+	Published uint32
+	Dropped   uint32
+}
+
+// New returns a client connecting through dial.
+func New(dial Dialer, opts Options) *Client {
+	if opts.KeepAlive < time.Second {
+		opts.KeepAlive = time.Second
+	}
+	return &Client{opts: opts, dial: dial}
+}
+
+// Connected reports whether the client has a session with the broker.
+func (c *Client) Connected() bool {
+	return c.conn != nil
+}
+
+// Backoff returns the delay before the next connection attempt, zero
+// while connected or before the first failure.
+func (c *Client) Backoff() time.Duration {
+	return c.backoff
+}
+
+// Poll keeps the session going: it connects once the backoff has passed,
+// pings the broker within the keep-alive interval and discards what the
+// broker sends. Call it from the main loop. It reports whether a new
+// session started, so the caller can publish retained state, and returns
+// the error of a failed attempt or a lost connection.
+func (c *Client) Poll(now time.Time) (bool, error) {
+	if c.conn == nil {
+		if now.Before(c.next) {
+			return false, nil
+		}
+		if err := c.connect(now); err != nil {
+			c.fail(now)
+			return false, err
+		}
+		return true, nil
+	}
+
+	if err := c.drain(now); err != nil {
+		c.drop(now)
+		return false, err
+	}
+	if now.Sub(c.lastSend) >= c.opts.KeepAlive/2 {
+		if err := c.write(now, append(c.buf[:0], packetPingreq, 0)); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Publish sends payload to topic at QoS 0, retained if retain is set.
+// Messages published while disconnected are dropped.
+func (c *Client) Publish(topic string, payload []byte, retain bool) error {
+	if c.conn == nil {
+		c.Dropped++
+		return ErrNotConnected
+	}
+	b, err := AppendPublish(c.buf[:0], topic, payload, retain)
+	if err != nil {
+		c.Dropped++
+		return err
+	}
+	if err := c.write(time.Now(), b); err != nil {
+		c.Dropped++
+		return err
+	}
+	c.Published++
+	return nil
+}
+
+// Close ends the session cleanly, so the broker does not send the will.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	c.conn.Write(append(c.buf[:0], packetDisconnect, 0))
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) connect(now time.Time) error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	b, err := AppendConnect(c.buf[:0], c.opts)
+	if err == nil {
+		_, err = conn.Write(b)
+	}
+	if err == nil {
+		err = readConnack(conn, c.scratch[:4], now.Add(ackTimeout))
+	}
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	c.conn = conn
+	c.lastSend = now
+	c.backoff = 0
+	c.Connects++
+	return nil
+}
+
+func readConnack(conn Conn, ack []byte, deadline time.Time) error {
+	conn.SetReadDeadline(deadline)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		return err
+	}
+	if ack[0] != packetConnack || ack[1] != 2 {
+		return ErrBadAck
+	}
+	if ack[3] != 0 {
+		return ErrRefused
+	}
+	return nil
+}
+
+// drain reads and discards whatever the broker has sent, ping responses
+// in practice, so its replies never fill the socket buffer.
+func (c *Client) drain(now time.Time) error {
+	c.conn.SetReadDeadline(now.Add(time.Millisecond))
+	for {
+		_, err := c.conn.Read(c.scratch[:])
+		if err == nil {
+			continue
+		}
+		if t, ok := err.(interface{ Timeout() bool }); ok && t.Timeout() {
+			return nil
+		}
+		return err
+	}
+}
+
+func (c *Client) write(now time.Time, b []byte) error {
+	if _, err := c.conn.Write(b); err != nil {
+		c.drop(now)
+		return err
+	}
+	c.lastSend = now
+	return nil
+}
+
+// drop closes a broken connection and schedules a reconnect.
+func (c *Client) drop(now time.Time) {
+	c.conn.Close()
+	c.conn = nil
+	c.fail(now)
+}
+
+func (c *Client) fail(now time.Time) {
+	switch {
+	case c.backoff == 0:
+		c.backoff = MinBackoff
+	case c.backoff < MaxBackoff:
+		c.backoff *= 2
+		if c.backoff > MaxBackoff {
+			c.backoff = MaxBackoff
+		}
+	}
+	c.next = now.Add(c.backoff)
+}
+
+// AppendConnect appends a CONNECT packet for a clean session to dst.
+func AppendConnect(dst []byte, opts Options) ([]byte, error) {
+	flags := uint8(flagCleanSession)
+	length := 10 + 2 + len(opts.ClientID)
+	if opts.WillTopic != "" {
+		flags |= flagWill | flagWillRetain
+		length += 2 + len(opts.WillTopic) + 2 + len(opts.WillMessage)
+	}
+	if length+5 > MaxPacketLen {
+		return dst, ErrTooLong
+	}
+
+	dst = append(dst, packetConnect)
+	dst = appendLength(dst, length)
+	dst = appendString(dst, "MQTT")
+	dst = append(dst, 4, flags) // Protocol level 4 is MQTT 3.1.1
+	dst = binary.BigEndian.AppendUint16(dst, uint16(opts.KeepAlive/time.Second))
+	dst = appendString(dst, opts.ClientID)
+	if opts.WillTopic != "" {
+		dst = appendString(dst, opts.WillTopic)
+		dst = appendString(dst, opts.WillMessage)
+	}
+	return dst, nil
+}
+
+// AppendPublish appends a QoS 0 PUBLISH packet to dst.
+func AppendPublish(dst []byte, topic string, payload []byte, retain bool) ([]byte, error) {
+	length := 2 + len(topic) + len(payload)
+	if length+5 > MaxPacketLen {
+		return dst, ErrTooLong
+	}
+	first := uint8(packetPublish)
+	if retain {
+		first |= 0x01
+	}
+	dst = append(dst, first)
+	dst = appendLength(dst, length)
+	dst = appendString(dst, topic)
+	return append(dst, payload...), nil
+}
+
+// appendLength appends the remaining length, seven bits per byte, low
+// bits first, with the top bit set on all but the last.
+func appendLength(dst []byte, n int) []byte {
+	for {
+		b := uint8(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			return append(dst, b)
+		}
+		dst = append(dst, b|0x80)
+	}
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(s)))
+	return append(dst, s...)
+}
@@ -0,0 +1,65 @@
+// Package nmea builds NMEA 0183 sentences, so the sensor can stand in for
+// an instrument on a chartplotter or autopilot:
+//
+//	$HEHDT,274.5,T*2B
+//
+// A sentence is "$", the talker and sentence type, comma-separated fields,
+// then "*", a two-digit hex checksum and CR LF. The checksum is the XOR of
+// every byte between "$" and "*".
+package nmea
+
+import "github.com/intermernet/bno08xPrograms/internal/fmtutil"
+
+// BaudRate is the standard NMEA 0183 line speed.
+const BaudRate = 4800
+
+// MaxLen is the longest sentence the standard allows, including "$" and
+// CR LF.
+const MaxLen = 82
+
+const hex = "0123456789ABCDEF"
+
+// Append appends the sentence with the given address, e.g. "HEHDT", and
+// fields to dst.
+func Append(dst []byte, address string, fields ...string) []byte {
+	start := len(dst)
+	dst = append(dst, '$')
+	dst = append(dst, address...)
+	for _, f := range fields {
+		dst = append(dst, ',')
+		dst = append(dst, f...)
+	}
+	sum := Checksum(dst[start+1:])
+	return append(dst, '*', hex[sum>>4], hex[sum&0x0F], '\r', '\n')
+}
+
+// Checksum returns the XOR of the bytes of body, the part of a sentence
+// between "$" and "*".
+func Checksum(body []byte) uint8 {
+	var sum uint8
+	for _, c := range body {
+		sum ^= c
+	}
+	return sum
+}
+
+// AppendHDT appends a true heading sentence from talker, e.g. "HE" for a
+// gyro compass.
+func AppendHDT(dst []byte, talker string, heading float32) []byte {
+	return Append(dst, talker+"HDT", headingField(heading), "T")
+}
+
+// AppendHDM appends a magnetic heading sentence from talker, e.g. "HC" for
+// a magnetic compass.
+func AppendHDM(dst []byte, talker string, heading float32) []byte {
+	return Append(dst, talker+"HDM", headingField(heading), "M")
+}
+
+// headingField formats a heading in [0, 360) with one decimal; one
+// that would round up to 360.0 is sent as 0.0.
+func headingField(heading float32) string {
+	if heading >= 359.95 {
+		heading = 0
+	}
+	return fmtutil.Float(heading, 1)
+}
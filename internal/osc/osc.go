@@ -0,0 +1,70 @@
+// Package osc encodes Open Sound Control 1.0 messages and bundles, the
+// UDP protocol of TouchDesigner, Max/MSP, SuperCollider and most other
+// creative-coding tools. Only float32 arguments are supported, which is
+// all sensor data needs.
+//
+// A message is its address pattern, e.g. "/imu/quat", then a type tag
+// string such as ",ffff", then the arguments as big-endian float32. Both
+// strings are NUL-terminated and padded with NULs to a multiple of four
+// bytes. A bundle is "#bundle", a 64-bit NTP time tag and its messages,
+// each preceded by its length, so receivers handle them together.
+package osc
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Immediately is the time tag of a bundle to be acted on as it arrives.
+const Immediately uint64 = 1
+
+const bundleTag = "#bundle"
+
+// AppendMessage appends a message with the given address and float32
+// arguments to dst.
+func AppendMessage(dst []byte, address string, args ...float32) []byte {
+	dst = appendString(dst, address)
+	start := len(dst)
+	dst = append(dst, ',')
+	for range args {
+		dst = append(dst, 'f')
+	}
+	dst = pad(append(dst, 0), start)
+	for _, v := range args {
+		dst = binary.BigEndian.AppendUint32(dst, math.Float32bits(v))
+	}
+	return dst
+}
+
+// AppendBundle appends the header of a bundle with the given time tag to
+// dst. Add its messages with AppendBundleMessage.
+func AppendBundle(dst []byte, timeTag uint64) []byte {
+	dst = appendString(dst, bundleTag)
+	return binary.BigEndian.AppendUint64(dst, timeTag)
+}
+
+// AppendBundleMessage appends a message to the bundle at the end of dst,
+// prefixed with its length.
+func AppendBundleMessage(dst []byte, address string, args ...float32) []byte {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	dst = AppendMessage(dst, address, args...)
+	binary.BigEndian.PutUint32(dst[start:], uint32(len(dst)-start-4))
+	return dst
+}
+
+// appendString appends s NUL-terminated and padded.
+func appendString(dst []byte, s string) []byte {
+	start := len(dst)
+	dst = append(dst, s...)
+	return pad(append(dst, 0), start)
+}
+
+// pad appends NULs to dst until the string from start is a multiple of
+// four bytes long.
+func pad(dst []byte, start int) []byte {
+	for (len(dst)-start)%4 != 0 {
+		dst = append(dst, 0)
+	}
+	return dst
+}
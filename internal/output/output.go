@@ -0,0 +1,102 @@
+// Package output fans one stream of sensor events out to several
+// destinations at once, such as a console format, the status LED and a
+// radio link, each at its own rate. Programs then differ only in which
+// sinks they add, instead of forking one example per destination.
+package output
+
+import (
+	"time"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// maxSensorID bounds the sensor IDs rate limited per sink. SH-2 report IDs
+// stay below it; events from higher IDs are never limited.
+const maxSensorID = 0x40
+
+// Sink is a destination for sensor events.
+type Sink interface {
+	Send(event *bno08x.SensorValue)
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(event *bno08x.SensorValue)
+
+// Send calls f(event).
+func (f SinkFunc) Send(event *bno08x.SensorValue) {
+	f(event)
+}
+
+// route is one sink and its rate limit.
+type route struct {
+	sink     Sink
+	interval time.Duration
+	sensors  []bno08x.SensorID // Empty for every sensor
+	last     [maxSensorID]time.Duration
+	sent     uint32
+	skipped  uint32
+}
+
+// accepts reports whether the route wants events from id.
+func (r *route) accepts(id bno08x.SensorID) bool {
+	if len(r.sensors) == 0 {
+		return true
+	}
+	for _, s := range r.sensors {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Mux sends each event to every sink that wants it, at most once per the
+// sink's interval for each sensor. A Mux is itself a Sink, so muxes nest,
+// and its Send fits a dispatch.Handler.
+type Mux struct {
+	routes []route
+	start  time.Time
+}
+
+// Add registers a sink receiving events from the given sensors, or from
+// every sensor if none are given, at most once per interval per sensor.
+// An interval of zero passes every event. It returns the sink's index
+// for Stats.
+func (m *Mux) Add(s Sink, interval time.Duration, sensors ...bno08x.SensorID) int {
+	if m.start.IsZero() {
+		m.start = time.Now()
+	}
+	m.routes = append(m.routes, route{sink: s, interval: interval, sensors: sensors})
+	return len(m.routes) - 1
+}
+
+// Send passes event on to the sinks that are due for it.
+func (m *Mux) Send(event *bno08x.SensorValue) {
+	id := event.ID()
+	now := time.Since(m.start)
+	for i := range m.routes {
+		r := &m.routes[i]
+		if !r.accepts(id) {
+			continue
+		}
+		if r.interval > 0 && uint8(id) < maxSensorID {
+			// Zero means no event sent yet
+			if last := r.last[id]; last != 0 && now-last < r.interval {
+				r.skipped++
+				continue
+			}
+			r.last[id] = now
+		}
+		r.sent++
+		r.sink.Send(event)
+	}
+}
+
+// Stats returns how many events sink i was sent and how many its rate
+// limit dropped.
+func (m *Mux) Stats(i int) (sent, skipped uint32) {
+	if i < 0 || i >= len(m.routes) {
+		return 0, 0
+	}
+	return m.routes[i].sent, m.routes[i].skipped
+}
@@ -0,0 +1,128 @@
+package quat
+
+import (
+	"math"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Averager computes the mean of many orientation samples without storing
+// them, using the eigenvector method of Markley et al., "Averaging
+// Quaternions" (2007). The mean is the eigenvector with the largest
+// eigenvalue of the accumulated matrix M = Σ q·qᵀ.
+//
+// Unlike averaging the four components, the result does not depend on the
+// sign of each sample (q and -q are the same rotation) and stays a proper
+// rotation even when the samples are spread out.
+type Averager struct {
+	m      [4][4]float64
+	first  bno08x.Quaternion // First sample with a positive weight
+	seeded bool
+	n      int
+}
+
+// Add accumulates one sample. Samples need not be pre-aligned in sign.
+func (a *Averager) Add(q bno08x.Quaternion) {
+	a.AddWeighted(q, 1)
+}
+
+// AddWeighted accumulates one sample with the given weight.
+func (a *Averager) AddWeighted(q bno08x.Quaternion, w float32) {
+	q = Normalize(q)
+	if w > 0 && !a.seeded {
+		a.first, a.seeded = q, true
+	}
+	v := [4]float64{float64(q.Real), float64(q.I), float64(q.J), float64(q.K)}
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			a.m[r][c] += float64(w) * v[r] * v[c]
+		}
+	}
+	a.n++
+}
+
+// Count returns the number of samples accumulated.
+func (a *Averager) Count() int {
+	return a.n
+}
+
+// Reset discards all samples.
+func (a *Averager) Reset() {
+	*a = Averager{}
+}
+
+// Mean returns the average orientation, with its sign chosen to be on the
+// same hemisphere as the first sample with a positive weight. It returns
+// Identity if no such sample has been added.
+func (a *Averager) Mean() bno08x.Quaternion {
+	if !a.seeded {
+		return Identity
+	}
+
+	// Power iteration, seeded with the first weighted sample so the seed
+	// is never orthogonal to M's range, converges quickly when the largest eigenvalue
+	// (about n for clustered samples) dominates the others (near 0). For
+	// spread-out samples the two largest draw closer, so M is first
+	// squared a few times, raising the ratio between them to the 16th
+	// power; dividing by the trace keeps the entries in range.
+	m := a.m
+	for i := 0; i < 4; i++ {
+		var sq [4][4]float64
+		trace := 0.0
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				for k := 0; k < 4; k++ {
+					sq[r][c] += m[r][k] * m[k][c]
+				}
+			}
+			trace += sq[r][r]
+		}
+		if trace == 0 {
+			break
+		}
+		for r := range sq {
+			for c := range sq[r] {
+				sq[r][c] /= trace
+			}
+		}
+		m = sq
+	}
+
+	v := [4]float64{float64(a.first.Real), float64(a.first.I), float64(a.first.J), float64(a.first.K)}
+	for iter := 0; iter < 64; iter++ {
+		var next [4]float64
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				next[r] += m[r][c] * v[c]
+			}
+		}
+		norm := math.Sqrt(next[0]*next[0] + next[1]*next[1] + next[2]*next[2] + next[3]*next[3])
+		if norm == 0 {
+			return a.first
+		}
+		delta := 0.0
+		for i := range next {
+			next[i] /= norm
+			delta += math.Abs(next[i] - v[i])
+		}
+		v = next
+		if delta < 1e-9 {
+			break
+		}
+	}
+
+	mean := bno08x.Quaternion{Real: float32(v[0]), I: float32(v[1]), J: float32(v[2]), K: float32(v[3])}
+	if Dot(mean, a.first) < 0 {
+		mean = bno08x.Quaternion{Real: -mean.Real, I: -mean.I, J: -mean.J, K: -mean.K}
+	}
+	return mean
+}
+
+// Average returns the mean orientation of the given samples.
+func Average(samples []bno08x.Quaternion) bno08x.Quaternion {
+	var a Averager
+	for _, q := range samples {
+		a.Add(q)
+	}
+	return a.Mean()
+}
@@ -0,0 +1,116 @@
+package quat
+
+import (
+	"math"
+	"testing"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// axisAngle returns the rotation by angle radians about the unit axis
+// (x, y, z).
+func axisAngle(x, y, z, angle float64) bno08x.Quaternion {
+	s, c := math.Sincos(angle / 2)
+	return bno08x.Quaternion{Real: float32(c), I: float32(x * s), J: float32(y * s), K: float32(z * s)}
+}
+
+func near(a, b bno08x.Quaternion, tolerance float32) bool {
+	d := [4]float32{a.Real - b.Real, a.I - b.I, a.J - b.J, a.K - b.K}
+	for _, v := range d {
+		if v > tolerance || v < -tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAverageIdentical(t *testing.T) {
+	q := Normalize(bno08x.Quaternion{Real: 0.9, I: 0.1, J: -0.3, K: 0.2})
+	var a Averager
+	for i := 0; i < 5; i++ {
+		a.Add(q)
+	}
+	if a.Count() != 5 {
+		t.Errorf("Count = %d, want 5", a.Count())
+	}
+	if got := a.Mean(); !near(got, q, 1e-6) {
+		t.Errorf("Mean = %+v, want %+v", got, q)
+	}
+}
+
+func TestAverageSignAmbiguity(t *testing.T) {
+	// q and -q are the same rotation; averaging the components would
+	// shrink the result towards zero
+	q := axisAngle(0, 1, 0, 0.7)
+	neg := bno08x.Quaternion{Real: -q.Real, I: -q.I, J: -q.J, K: -q.K}
+	got := Average([]bno08x.Quaternion{q, neg, neg, q})
+	if !near(got, q, 1e-6) {
+		t.Errorf("Average(q, -q, -q, q) = %+v, want %+v", got, q)
+	}
+
+	// The sign follows the first sample
+	got = Average([]bno08x.Quaternion{neg, q})
+	if !near(got, neg, 1e-6) {
+		t.Errorf("Average(-q, q) = %+v, want %+v", got, neg)
+	}
+}
+
+func TestAverageOppositeRotations(t *testing.T) {
+	// Beyond ±90° the two are nearer each other through 180° than through
+	// the identity, and the mean is the half turn
+	for _, deg := range []float64{5, 30, 60, 85} {
+		theta := deg * math.Pi / 180
+		got := Average([]bno08x.Quaternion{axisAngle(0, 0, 1, theta), axisAngle(0, 0, 1, -theta)})
+		if !near(got, Identity, 1e-6) {
+			t.Errorf("±%v° about Z: mean = %+v, want identity", deg, got)
+		}
+	}
+	got := Average([]bno08x.Quaternion{axisAngle(0, 0, 1, 2*math.Pi/3), axisAngle(0, 0, 1, -2*math.Pi/3)})
+	if Angle(got, axisAngle(0, 0, 1, math.Pi)) > 1e-3 {
+		t.Errorf("±120° about Z: mean = %+v, want the half turn", got)
+	}
+}
+
+func TestAverageWeighted(t *testing.T) {
+	// Identity with weight 3 and 90° about Z with weight 1. In the (w, z)
+	// plane M = [[3 + c², c·s], [c·s, s²]] with c = cos 45°, s = sin 45°,
+	// whose largest eigenvector lies at φ with
+	// tan 2φ = w₂ sin θ / (w₁ + w₂ cos θ) = 1/3, so the mean is the
+	// rotation by 2φ = atan(1/3) = 18.4349° about Z:
+	// (cos 9.2175°, 0, 0, sin 9.2175°) = (0.987087, 0, 0, 0.160182).
+	var a Averager
+	a.AddWeighted(Identity, 3)
+	a.AddWeighted(axisAngle(0, 0, 1, math.Pi/2), 1)
+	want := bno08x.Quaternion{Real: 0.987087, K: 0.160182}
+	if got := a.Mean(); !near(got, want, 1e-5) {
+		t.Errorf("Mean = %+v, want %+v", got, want)
+	}
+
+	// A weight of zero leaves the sample out
+	a.Reset()
+	a.AddWeighted(axisAngle(1, 0, 0, 0.4), 1)
+	a.AddWeighted(axisAngle(0, 1, 0, 1.2), 0)
+	if got := a.Mean(); !near(got, axisAngle(1, 0, 0, 0.4), 1e-6) {
+		t.Errorf("Mean with a zero weight = %+v", got)
+	}
+
+	// Even when it comes first, and is orthogonal to the others
+	a.Reset()
+	a.AddWeighted(Identity, 0)
+	a.AddWeighted(axisAngle(1, 0, 0, math.Pi), 1)
+	if got := a.Mean(); !near(got, axisAngle(1, 0, 0, math.Pi), 1e-6) {
+		t.Errorf("Mean after a leading zero weight = %+v", got)
+	}
+	a.Reset()
+	a.AddWeighted(Identity, 0)
+	if got := a.Mean(); got != Identity {
+		t.Errorf("Mean of only a zero weight = %+v, want identity", got)
+	}
+}
+
+func TestAverageEmpty(t *testing.T) {
+	var a Averager
+	if got := a.Mean(); got != Identity {
+		t.Errorf("Mean of nothing = %+v, want identity", got)
+	}
+}
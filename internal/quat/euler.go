@@ -0,0 +1,106 @@
+package quat
+
+import (
+	"math"
+	"strings"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// EulerOrder is the sequence of axes an Euler decomposition rotates about,
+// each rotation about the axes as moved by the ones before (intrinsic).
+// ZYX, yaw then pitch then roll, is the aerospace convention and the one
+// ToEuler uses; XYZ is common in robotics and CAD software.
+type EulerOrder uint8
+
+const (
+	ZYX EulerOrder = iota
+	XYZ
+	YXZ
+	YZX
+	ZXY
+	XZY
+)
+
+var eulerOrderNames = [...]string{ZYX: "zyx", XYZ: "xyz", YXZ: "yxz", YZX: "yzx", ZXY: "zxy", XZY: "xzy"}
+
+// eulerAxes holds the axis indices of each order, first rotation first.
+var eulerAxes = [...][3]int{
+	ZYX: {2, 1, 0},
+	XYZ: {0, 1, 2},
+	YXZ: {1, 0, 2},
+	YZX: {1, 2, 0},
+	ZXY: {2, 0, 1},
+	XZY: {0, 2, 1},
+}
+
+// String returns the order in lower case, e.g. "zyx".
+func (o EulerOrder) String() string {
+	if int(o) < len(eulerOrderNames) {
+		return eulerOrderNames[o]
+	}
+	return "unknown"
+}
+
+// ParseEulerOrder returns the order named s, in either case.
+func ParseEulerOrder(s string) (EulerOrder, bool) {
+	s = strings.ToLower(s)
+	for i, name := range eulerOrderNames {
+		if s == name {
+			return EulerOrder(i), true
+		}
+	}
+	return ZYX, false
+}
+
+// ToEulerOrder converts a quaternion to Euler angles for the given order.
+// Whatever the order, the angles are returned as the rotations about the
+// X, Y and Z axes, in radians, so ToEulerOrder(q, ZYX) gives the roll,
+// pitch and yaw of ToEuler. The middle rotation of the sequence lies in
+// [-π/2, π/2] and the others in [-π, π]. When the middle rotation reaches
+// ±90° the other two turn about the same axis; the last is then reported
+// as zero and the first carries their combined rotation.
+func ToEulerOrder(q bno08x.Quaternion, order EulerOrder) (x, y, z float32) {
+	if int(order) >= len(eulerAxes) {
+		order = ZYX
+	}
+	axes := eulerAxes[order]
+	i, j, k := axes[0], axes[1], axes[2]
+	r := matrix(q)
+
+	// s is +1 for the cyclic orders XYZ, YZX and ZXY, -1 for the others
+	s := float64(1)
+	if (j-i+3)%3 != 1 {
+		s = -1
+	}
+
+	var first, second, third float64
+	sinSecond := s * float64(r[i][k])
+	switch {
+	case sinSecond >= 0.99999:
+		second = math.Pi / 2
+		first = math.Atan2(s*float64(r[k][j]), float64(r[j][j]))
+	case sinSecond <= -0.99999:
+		second = -math.Pi / 2
+		first = math.Atan2(s*float64(r[k][j]), float64(r[j][j]))
+	default:
+		second = math.Asin(sinSecond)
+		first = math.Atan2(-s*float64(r[j][k]), float64(r[k][k]))
+		third = math.Atan2(-s*float64(r[i][j]), float64(r[i][i]))
+	}
+
+	var angles [3]float32
+	angles[i], angles[j], angles[k] = float32(first), float32(second), float32(third)
+	return angles[0], angles[1], angles[2]
+}
+
+// matrix returns the rotation matrix of a unit quaternion, rows indexed
+// X, Y, Z.
+func matrix(q bno08x.Quaternion) [3][3]float32 {
+	w, x, y, z := q.Real, q.I, q.J, q.K
+	return [3][3]float32{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
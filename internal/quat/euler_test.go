@@ -0,0 +1,106 @@
+package quat
+
+import (
+	"math"
+	"testing"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const deg = math.Pi / 180
+
+// compose returns the rotation with the given angles about X, Y and Z, in
+// radians, applied intrinsically in the sequence of order: for ZYX,
+// yaw about Z, then pitch about the new Y, then roll about the newest X.
+func compose(order EulerOrder, x, y, z float64) bno08x.Quaternion {
+	angles := [3]float64{x, y, z}
+	axes := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	q := Identity
+	for _, a := range eulerAxes[order] {
+		q = Multiply(q, axisAngle(axes[a][0], axes[a][1], axes[a][2], angles[a]))
+	}
+	return q
+}
+
+func TestToEulerOrder(t *testing.T) {
+	// Angles about X, Y and Z in degrees; the middle rotation of each
+	// order is kept inside ±90°
+	tests := []struct {
+		order   EulerOrder
+		x, y, z float64
+	}{
+		{ZYX, 10, 20, 30},
+		{ZYX, -170, -45, 135},
+		{ZYX, 0, 0, -90},
+		{XYZ, 30, -60, 10},
+		{XYZ, 120, 45, -150},
+		{YXZ, 50, -100, 25},
+		{YXZ, -20, 170, -80},
+		{YZX, -35, 15, 70},
+		{YZX, 160, -120, -10},
+		{ZXY, 60, 5, -40},
+		{ZXY, -75, -135, 100},
+		{XZY, 15, -30, 80},
+		{XZY, -140, 95, -60},
+	}
+	for _, tt := range tests {
+		q := compose(tt.order, tt.x*deg, tt.y*deg, tt.z*deg)
+		x, y, z := ToEulerOrder(q, tt.order)
+		got := [3]float64{float64(x) / deg, float64(y) / deg, float64(z) / deg}
+		want := [3]float64{tt.x, tt.y, tt.z}
+		for i := range got {
+			if math.Abs(got[i]-want[i]) > 0.01 {
+				t.Errorf("%v %v: got %.3f°", tt.order, want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestToEulerOrderMatchesToEuler(t *testing.T) {
+	q := compose(ZYX, 12*deg, -34*deg, 56*deg)
+	roll, pitch, yaw := ToEuler(q)
+	x, y, z := ToEulerOrder(q, ZYX)
+	if math.Abs(float64(roll-x)) > 1e-5 || math.Abs(float64(pitch-y)) > 1e-5 || math.Abs(float64(yaw-z)) > 1e-5 {
+		t.Errorf("ToEulerOrder(ZYX) = %v %v %v, ToEuler = %v %v %v", x, y, z, roll, pitch, yaw)
+	}
+}
+
+func TestToEulerOrderGimbalLock(t *testing.T) {
+	// With the middle rotation at or near ±90° the first and last axes
+	// line up: the last angle is reported as zero and the first carries
+	// both, so the angles still compose to the same rotation
+	for order := ZYX; order <= XZY; order++ {
+		middle := eulerAxes[order][1]
+		for _, m := range []float64{89.9, -89.9, 90, -90} {
+			angles := [3]float64{25, 25, 25}
+			angles[middle] = m
+			q := compose(order, angles[0]*deg, angles[1]*deg, angles[2]*deg)
+
+			x, y, z := ToEulerOrder(q, order)
+			got := [3]float32{x, y, z}
+			if d := math.Abs(float64(got[middle])/deg - m); d > 0.2 {
+				t.Errorf("%v middle %v°: middle angle %.3f°", order, m, float64(got[middle])/deg)
+			}
+			back := compose(order, float64(x), float64(y), float64(z))
+			if a := Angle(back, q); a > 0.2*deg {
+				t.Errorf("%v middle %v°: angles %.3f° recompose %.3f° away", order, m,
+					[3]float64{float64(x) / deg, float64(y) / deg, float64(z) / deg}, a/deg)
+			}
+		}
+	}
+}
+
+func TestParseEulerOrder(t *testing.T) {
+	for order := ZYX; order <= XZY; order++ {
+		if got, ok := ParseEulerOrder(order.String()); !ok || got != order {
+			t.Errorf("ParseEulerOrder(%q) = %v, %v", order.String(), got, ok)
+		}
+	}
+	if got, ok := ParseEulerOrder("XYZ"); !ok || got != XYZ {
+		t.Errorf("ParseEulerOrder(XYZ) = %v, %v", got, ok)
+	}
+	if _, ok := ParseEulerOrder("xyx"); ok {
+		t.Error("ParseEulerOrder(xyx) accepted a proper Euler sequence")
+	}
+}
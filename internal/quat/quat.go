@@ -0,0 +1,112 @@
+// Package quat provides the quaternion math shared by the example
+// programs: normalization, composition, Euler angle conversion, rotating
+// vectors between the body and world frames, and proper averaging of
+// orientation samples.
+package quat
+
+import (
+	"math"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Identity is the quaternion representing no rotation.
+var Identity = bno08x.Quaternion{Real: 1}
+
+// Dot returns the 4D dot product of two quaternions.
+func Dot(a, b bno08x.Quaternion) float32 {
+	return a.Real*b.Real + a.I*b.I + a.J*b.J + a.K*b.K
+}
+
+// Normalize scales q to unit length. A zero quaternion becomes Identity.
+func Normalize(q bno08x.Quaternion) bno08x.Quaternion {
+	n := float32(math.Sqrt(float64(Dot(q, q))))
+	if n == 0 {
+		return Identity
+	}
+	return bno08x.Quaternion{Real: q.Real / n, I: q.I / n, J: q.J / n, K: q.K / n}
+}
+
+// Conjugate returns the inverse rotation of a unit quaternion.
+func Conjugate(q bno08x.Quaternion) bno08x.Quaternion {
+	return bno08x.Quaternion{Real: q.Real, I: -q.I, J: -q.J, K: -q.K}
+}
+
+// Multiply returns the Hamilton product a*b, the rotation b followed by a.
+func Multiply(a, b bno08x.Quaternion) bno08x.Quaternion {
+	return bno08x.Quaternion{
+		Real: a.Real*b.Real - a.I*b.I - a.J*b.J - a.K*b.K,
+		I:    a.Real*b.I + a.I*b.Real + a.J*b.K - a.K*b.J,
+		J:    a.Real*b.J - a.I*b.K + a.J*b.Real + a.K*b.I,
+		K:    a.Real*b.K + a.I*b.J - a.J*b.I + a.K*b.Real,
+	}
+}
+
+// Relative returns the rotation that takes ref to q, expressed in the
+// reference frame: conj(ref)*q. It is the identity when q equals ref.
+func Relative(ref, q bno08x.Quaternion) bno08x.Quaternion {
+	return Multiply(Conjugate(ref), q)
+}
+
+// Angle returns the rotation angle in radians between two orientations,
+// in the range [0, π].
+func Angle(a, b bno08x.Quaternion) float32 {
+	d := Dot(a, b)
+	if d < 0 {
+		d = -d
+	}
+	if d > 1 {
+		d = 1
+	}
+	return float32(2 * math.Acos(float64(d)))
+}
+
+// ToEuler converts a quaternion to Euler angles (roll, pitch, yaw).
+// Roll is rotation around X axis, Pitch around Y axis, Yaw around Z axis.
+// All angles are returned in radians.
+func ToEuler(q bno08x.Quaternion) (roll, pitch, yaw float32) {
+	// Roll (x-axis rotation)
+	sinr_cosp := 2.0 * (q.Real*q.I + q.J*q.K)
+	cosr_cosp := 1.0 - 2.0*(q.I*q.I+q.J*q.J)
+	roll = float32(math.Atan2(float64(sinr_cosp), float64(cosr_cosp)))
+
+	// Pitch (y-axis rotation)
+	sinp := 2.0 * (q.Real*q.J - q.K*q.I)
+	if math.Abs(float64(sinp)) >= 1 {
+		pitch = float32(math.Copysign(math.Pi/2, float64(sinp)))
+	} else {
+		pitch = float32(math.Asin(float64(sinp)))
+	}
+
+	// Yaw (z-axis rotation)
+	siny_cosp := 2.0 * (q.Real*q.K + q.I*q.J)
+	cosy_cosp := 1.0 - 2.0*(q.J*q.J+q.K*q.K)
+	yaw = float32(math.Atan2(float64(siny_cosp), float64(cosy_cosp)))
+
+	return roll, pitch, yaw
+}
+
+// Rotate rotates the vector (x, y, z) by the unit quaternion q. With q
+// from a rotation vector report this takes a body-frame vector into the
+// world frame; use Conjugate(q) to go the other way.
+func Rotate(q bno08x.Quaternion, x, y, z float32) (rx, ry, rz float32) {
+	// v' = v + 2w(u×v) + 2u×(u×v), with u the vector part of q
+	tx := 2 * (q.J*z - q.K*y)
+	ty := 2 * (q.K*x - q.I*z)
+	tz := 2 * (q.I*y - q.J*x)
+	rx = x + q.Real*tx + (q.J*tz - q.K*ty)
+	ry = y + q.Real*ty + (q.K*tx - q.I*tz)
+	rz = z + q.Real*tz + (q.I*ty - q.J*tx)
+	return rx, ry, rz
+}
+
+// WorldAngularVelocity converts a gyroscope reading, which is measured
+// about the sensor's own axes, into angular velocity about the world axes
+// given the current orientation q. The Z component is the turn rate about
+// the vertical, whatever way the sensor is mounted or tilted.
+//
+// Reading the gyroscope Z axis directly only gives the turn rate when the
+// sensor is level; tilt mixes the body X and Y rates into it.
+func WorldAngularVelocity(q bno08x.Quaternion, gx, gy, gz float32) (wx, wy, wz float32) {
+	return Rotate(q, gx, gy, gz)
+}
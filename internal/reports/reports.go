@@ -0,0 +1,143 @@
+// Package reports remembers which reports a program has enabled, so they
+// can be quiesced while another device on the same I2C bus, such as a
+// display or an EEPROM, is busy, and restored afterwards. The same record
+// restores the reports after a sensor reset.
+//
+//	set.Suspend(reports.Off)
+//	display.Display() // long transfer on the shared bus
+//	set.Resume()
+//
+// The BNO08x keeps sampling while the host is not reading, and a full
+// output queue makes it drop reports or stall the next read, so turning
+// reports off or slowing them down first keeps the bus free and the
+// sensor's queue short.
+package reports
+
+import "tinygo.org/x/drivers/bno08x"
+
+// SlowInterval is the report interval used by Slow, in microseconds.
+const SlowInterval = 1000000 // 1Hz
+
+// Mode says how Suspend quiesces the reports.
+type Mode uint8
+
+const (
+	// Off disables every report. Orientation outputs restart their fusion
+	// when enabled again, which can take a moment to settle.
+	Off Mode = iota
+	// Slow drops every report faster than SlowInterval to SlowInterval, so
+	// fusion keeps running at the cost of a little bus traffic.
+	Slow
+)
+
+// Sensor is the part of *bno08x.Device used by Set.
+type Sensor interface {
+	EnableReport(id bno08x.SensorID, interval uint32) error
+}
+
+// Set tracks the enabled reports of one sensor.
+type Set struct {
+	sensor    Sensor
+	intervals [256]uint32 // Requested interval by sensor ID, 0 if off
+	suspended bool
+}
+
+// New returns an empty set for sensor.
+func New(sensor Sensor) *Set {
+	return &Set{sensor: sensor}
+}
+
+// Enable enables a report and records its interval. While the set is
+// suspended the interval is only recorded, and takes effect on Resume.
+func (s *Set) Enable(id bno08x.SensorID, interval uint32) error {
+	if !s.suspended {
+		if err := s.sensor.EnableReport(id, interval); err != nil {
+			return err
+		}
+	}
+	s.intervals[uint8(id)] = interval
+	return nil
+}
+
+// Disable turns a report off and forgets it.
+func (s *Set) Disable(id bno08x.SensorID) error {
+	// An interval of zero turns the report off
+	if err := s.sensor.EnableReport(id, 0); err != nil {
+		return err
+	}
+	s.intervals[uint8(id)] = 0
+	return nil
+}
+
+// Interval returns the recorded interval of a report, or 0 if it is off.
+func (s *Set) Interval(id bno08x.SensorID) uint32 {
+	return s.intervals[uint8(id)]
+}
+
+// Suspended reports whether Suspend has been called without Resume.
+func (s *Set) Suspended() bool {
+	return s.suspended
+}
+
+// Suspend quiesces every recorded report. It carries on past a failed
+// report and returns the first error.
+func (s *Set) Suspend(mode Mode) error {
+	var first error
+	for i, interval := range s.intervals {
+		if interval == 0 {
+			continue
+		}
+		quiet := uint32(0)
+		if mode == Slow {
+			if interval >= SlowInterval {
+				continue
+			}
+			quiet = SlowInterval
+		}
+		if err := s.sensor.EnableReport(bno08x.SensorID(i), quiet); err != nil && first == nil {
+			first = err
+		}
+	}
+	s.suspended = true
+	return first
+}
+
+// Resume ends a suspension, enabling every recorded report again at its
+// interval. It carries on past a failed report and returns the first
+// error.
+func (s *Set) Resume() error {
+	s.suspended = false
+	_, err := s.Restore()
+	return err
+}
+
+// Restore enables every recorded report again, as after the sensor has
+// been reset, returning the number restored and the first error.
+func (s *Set) Restore() (int, error) {
+	var first error
+	restored := 0
+	for i, interval := range s.intervals {
+		if interval == 0 {
+			continue
+		}
+		if err := s.sensor.EnableReport(bno08x.SensorID(i), interval); err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		restored++
+	}
+	return restored, first
+}
+
+// While suspends the reports, runs fn and resumes them, for a transfer
+// that must not share the bus with sensor traffic.
+func (s *Set) While(mode Mode, fn func()) error {
+	if err := s.Suspend(mode); err != nil {
+		s.Resume()
+		return err
+	}
+	fn()
+	return s.Resume()
+}
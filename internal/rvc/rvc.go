@@ -0,0 +1,135 @@
+// Package rvc decodes the BNO08x UART-RVC ("robot vacuum cleaner") output.
+//
+// With PS0 high and PS1 low at reset the sensor skips SHTP entirely and
+// streams a fixed 19-byte frame at 100Hz on its TX pin (the SDA pin in
+// other modes) at 115200 baud, 8N1:
+//
+//	0-1   0xAA 0xAA header
+//	2     index, incremented every frame
+//	3-8   yaw, pitch, roll as int16 in 0.01°
+//	9-14  X, Y, Z acceleration as int16 in mg
+//	15-17 reserved
+//	18    checksum, the sum of bytes 2-17
+package rvc
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/intermernet/bno08xPrograms/internal/units"
+)
+
+const (
+	// BaudRate is the fixed UART-RVC baud rate.
+	BaudRate = 115200
+	// FrameLen is the length of one frame including header and checksum.
+	FrameLen = 19
+	// HeaderByte is repeated twice at the start of each frame.
+	HeaderByte = 0xAA
+)
+
+var (
+	ErrShortFrame = errors.New("rvc: frame too short")
+	ErrHeader     = errors.New("rvc: missing 0xAAAA header")
+	ErrChecksum   = errors.New("rvc: checksum mismatch")
+)
+
+// Frame is one decoded UART-RVC frame.
+type Frame struct {
+	Index            uint8
+	Yaw, Pitch, Roll float32 // Degrees
+	AccelX           float32 // m/s²
+	AccelY           float32
+	AccelZ           float32
+}
+
+// Decode parses a complete frame starting with the header.
+func Decode(b []byte) (Frame, error) {
+	if len(b) < FrameLen {
+		return Frame{}, ErrShortFrame
+	}
+	if b[0] != HeaderByte || b[1] != HeaderByte {
+		return Frame{}, ErrHeader
+	}
+	var sum uint8
+	for _, c := range b[2:18] {
+		sum += c
+	}
+	if sum != b[18] {
+		return Frame{}, ErrChecksum
+	}
+	field := func(i int) float32 {
+		return float32(int16(binary.LittleEndian.Uint16(b[i:])))
+	}
+	const mg = units.StandardGravity / 1000
+	return Frame{
+		Index:  b[2],
+		Yaw:    field(3) / 100,
+		Pitch:  field(5) / 100,
+		Roll:   field(7) / 100,
+		AccelX: field(9) * mg,
+		AccelY: field(11) * mg,
+		AccelZ: field(13) * mg,
+	}, nil
+}
+
+// Parser finds frames in a byte stream, resynchronising on the header
+// after noise or a bad checksum, and keeps statistics for diagnostics.
+type Parser struct {
+	buf [FrameLen]byte
+	n   int
+
+	// Frames counts valid frames and BadChecksums rejected ones.
+	Frames       uint32
+	BadChecksums uint32
+	// Dropped counts frames missing from the index sequence.
+	Dropped uint32
+
+	lastIndex uint8
+}
+
+// Feed adds one received byte and returns a frame when it completes one.
+func (p *Parser) Feed(c byte) (Frame, bool) {
+	// Hunt for the two header bytes before buffering the rest
+	if p.n < 2 {
+		if c == HeaderByte {
+			p.buf[p.n] = c
+			p.n++
+		} else {
+			p.n = 0
+		}
+		return Frame{}, false
+	}
+	p.buf[p.n] = c
+	p.n++
+	if p.n < FrameLen {
+		return Frame{}, false
+	}
+	p.n = 0
+
+	f, err := Decode(p.buf[:])
+	if err != nil {
+		p.BadChecksums++
+		// The real header may start inside the rejected bytes
+		rest := p.buf[1:]
+		p.n = copy(p.buf[:], rest[nextHeader(rest):])
+		return Frame{}, false
+	}
+	if p.Frames > 0 {
+		p.Dropped += uint32(f.Index - p.lastIndex - 1)
+	}
+	p.lastIndex = f.Index
+	p.Frames++
+	return f, true
+}
+
+// nextHeader returns the offset in b of the next possible frame start: a
+// 0xAA 0xAA pair, or a lone 0xAA at the very end.
+func nextHeader(b []byte) int {
+	for i := range b {
+		if b[i] == HeaderByte && (i+1 == len(b) || b[i+1] == HeaderByte) {
+			return i
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,180 @@
+// Package safety provides tilt and rotation-rate limits with latched fault
+// outputs for the examples that drive servos or motors from orientation data.
+// Once a limit is exceeded the interlock stays faulted until Reset is called
+// and the inputs are back inside their limits, so a glitch in the sensor data
+// can never command actuator motion on its own.
+package safety
+
+import (
+	"math"
+	"time"
+)
+
+// Fault is a bit set describing which limits have been exceeded.
+type Fault uint8
+
+const (
+	FaultNone  Fault = 0
+	FaultTilt  Fault = 1 << 0 // Roll or pitch beyond MaxTilt
+	FaultRate  Fault = 1 << 1 // Angular rate beyond MaxRate
+	FaultStale Fault = 1 << 2 // No update within DataTimeout
+	FaultInput Fault = 1 << 3 // Roll, pitch or rate not a finite number
+)
+
+// String returns a short human-readable description of the fault bits.
+func (f Fault) String() string {
+	if f == FaultNone {
+		return "none"
+	}
+	s := ""
+	if f&FaultTilt != 0 {
+		s += "tilt "
+	}
+	if f&FaultRate != 0 {
+		s += "rate "
+	}
+	if f&FaultStale != 0 {
+		s += "stale "
+	}
+	if f&FaultInput != 0 {
+		s += "input "
+	}
+	return s[:len(s)-1]
+}
+
+// Limits configures the interlock thresholds. Angles are in degrees and rates
+// in degrees per second. A zero value disables the corresponding check.
+type Limits struct {
+	MaxTilt     float32       // Maximum absolute roll or pitch
+	MaxRate     float32       // Maximum angular rate magnitude
+	DataTimeout time.Duration // Maximum time between updates
+}
+
+// Interlock latches a fault whenever the orientation or rotation rate leaves
+// the configured limits.
+type Interlock struct {
+	limits     Limits
+	latched    Fault // Faults seen since the last successful Reset
+	current    Fault // Faults present in the most recent update
+	lastUpdate time.Time
+}
+
+// New returns an interlock using the given limits. The interlock starts
+// faulted with FaultStale so that nothing moves before the first good sample
+// arrives and the user explicitly calls Reset.
+func New(limits Limits) *Interlock {
+	return &Interlock{
+		limits:  limits,
+		latched: FaultStale,
+		current: FaultStale,
+	}
+}
+
+// Update checks a new sample against the limits and reports whether actuator
+// output is permitted. Roll and pitch are in degrees, rate is the angular
+// rate magnitude in degrees per second. A NaN or infinite input fails
+// every comparison, so it latches FaultInput whatever the limits.
+func (il *Interlock) Update(roll, pitch, rate float32, now time.Time) bool {
+	il.current = FaultNone
+	if !finite(roll) || !finite(pitch) || !finite(rate) {
+		il.current |= FaultInput
+	}
+	if il.limits.MaxTilt > 0 && (abs(roll) > il.limits.MaxTilt || abs(pitch) > il.limits.MaxTilt) {
+		il.current |= FaultTilt
+	}
+	if il.limits.MaxRate > 0 && abs(rate) > il.limits.MaxRate {
+		il.current |= FaultRate
+	}
+	il.lastUpdate = now
+	il.latched |= il.current
+	return il.latched == FaultNone
+}
+
+// Check reports whether actuator output is permitted at time now, latching
+// FaultStale if no update has arrived within DataTimeout. Call it from the
+// control loop even when no new sensor event was received.
+func (il *Interlock) Check(now time.Time) bool {
+	if il.limits.DataTimeout > 0 && now.Sub(il.lastUpdate) > il.limits.DataTimeout {
+		il.current |= FaultStale
+		il.latched |= FaultStale
+	}
+	return il.latched == FaultNone
+}
+
+// Fault returns the latched fault bits.
+func (il *Interlock) Fault() Fault {
+	return il.latched
+}
+
+// Faulted reports whether any fault is latched.
+func (il *Interlock) Faulted() bool {
+	return il.latched != FaultNone
+}
+
+// Reset clears the latched faults, but only if the most recent update was
+// inside all limits. It returns true if the interlock is now clear.
+func (il *Interlock) Reset() bool {
+	if il.current != FaultNone {
+		return false
+	}
+	il.latched = FaultNone
+	return true
+}
+
+// RateLimiter limits how fast a commanded angle may change, so that even
+// valid orientation data cannot slam an actuator from one end stop to the
+// other. The zero value starts from 0 at the first Step; call Hold first
+// to start from anywhere else, such as the actuator's present position.
+type RateLimiter struct {
+	MaxRate float32 // Maximum slew in degrees per second
+
+	value  float32
+	last   time.Time
+	primed bool
+}
+
+// Step moves the output towards target by no more than MaxRate times the
+// time elapsed since the previous step, and returns the new output. The
+// first Step after neither Step nor Hold only starts the clock, returning
+// Value unchanged, so no target is ever passed through unlimited.
+func (r *RateLimiter) Step(target float32, now time.Time) float32 {
+	if !r.primed {
+		r.last = now
+		r.primed = true
+		return r.value
+	}
+	maxStep := r.MaxRate * float32(now.Sub(r.last).Seconds())
+	r.last = now
+	delta := target - r.value
+	if delta > maxStep {
+		delta = maxStep
+	} else if delta < -maxStep {
+		delta = -maxStep
+	}
+	r.value += delta
+	return r.value
+}
+
+// Value returns the current limited output.
+func (r *RateLimiter) Value() float32 {
+	return r.value
+}
+
+// Hold resets the limiter so that the next Step starts from value.
+func (r *RateLimiter) Hold(value float32, now time.Time) {
+	r.value = value
+	r.last = now
+	r.primed = true
+}
+
+// abs returns the absolute value of a float32
+func finite(x float32) bool {
+	return !math.IsNaN(float64(x)) && !math.IsInf(float64(x), 0)
+}
+
+func abs(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
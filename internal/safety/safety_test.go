@@ -0,0 +1,92 @@
+package safety
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterFirstStep(t *testing.T) {
+	now := time.Now()
+	r := RateLimiter{MaxRate: 90}
+	// The first step starts the clock at Value, not at the target
+	if got := r.Step(170, now); got != 0 {
+		t.Errorf("first Step(170) = %v, want 0", got)
+	}
+	if got := r.Step(170, now.Add(time.Second)); got != 90 {
+		t.Errorf("Step(170) a second later = %v, want 90", got)
+	}
+	if got := r.Step(170, now.Add(2*time.Second)); got != 170 {
+		t.Errorf("Step(170) two seconds later = %v, want 170", got)
+	}
+}
+
+func TestRateLimiterHold(t *testing.T) {
+	now := time.Now()
+	r := RateLimiter{MaxRate: 10}
+	r.Hold(45, now)
+	if got := r.Step(-45, now.Add(500*time.Millisecond)); got != 40 {
+		t.Errorf("Step(-45) 0.5s after Hold(45) = %v, want 40", got)
+	}
+	if r.Value() != 40 {
+		t.Errorf("Value = %v, want 40", r.Value())
+	}
+
+	// Hold restarts the clock, so time spent held is not saved up
+	r.Hold(0, now.Add(time.Minute))
+	if got := r.Step(90, now.Add(time.Minute+time.Second)); got != 10 {
+		t.Errorf("Step(90) 1s after Hold(0) = %v, want 10", got)
+	}
+}
+
+func TestInterlock(t *testing.T) {
+	now := time.Now()
+	il := New(Limits{MaxTilt: 30, MaxRate: 100, DataTimeout: time.Second})
+	if il.Fault() != FaultStale || il.Update(0, 0, 0, now) {
+		t.Fatalf("new interlock permits output, fault %v", il.Fault())
+	}
+	if !il.Reset() || !il.Check(now) {
+		t.Fatalf("Reset after a good update failed, fault %v", il.Fault())
+	}
+
+	// A fault latches until Reset, which only succeeds once it has cleared
+	if il.Update(40, 0, 0, now) {
+		t.Error("output permitted beyond MaxTilt")
+	}
+	if il.Update(0, 0, 0, now) {
+		t.Error("tilt fault cleared without Reset")
+	}
+	il.Update(0, 0, 150, now)
+	if il.Reset() {
+		t.Error("Reset succeeded while over MaxRate")
+	}
+	if got := il.Fault(); got != FaultTilt|FaultRate || got.String() != "tilt rate" {
+		t.Errorf("Fault = %v, want tilt rate", got)
+	}
+	il.Update(0, 0, 0, now)
+	if !il.Reset() {
+		t.Error("Reset failed with the inputs back in limits")
+	}
+
+	if il.Check(now.Add(2*time.Second)) || il.Fault() != FaultStale {
+		t.Errorf("Check after DataTimeout permits output, fault %v", il.Fault())
+	}
+}
+
+func TestInterlockNonFinite(t *testing.T) {
+	now := time.Now()
+	nan := float32(math.NaN())
+	inf := float32(math.Inf(1))
+	for _, in := range [][3]float32{{nan, 0, 0}, {0, nan, 0}, {0, 0, nan}, {-inf, 0, 0}, {0, 0, inf}} {
+		// Limits off, so only the input check can trip
+		il := New(Limits{})
+		il.Update(0, 0, 0, now)
+		il.Reset()
+		if il.Update(in[0], in[1], in[2], now) || il.Fault() != FaultInput {
+			t.Errorf("Update(%v, %v, %v) permits output, fault %v", in[0], in[1], in[2], il.Fault())
+		}
+		if il.Reset() {
+			t.Errorf("Reset succeeded after Update(%v, %v, %v)", in[0], in[1], in[2])
+		}
+	}
+}
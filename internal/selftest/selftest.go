@@ -0,0 +1,92 @@
+// Package selftest is a lightweight version of the diagnostic program that
+// can be embedded in long-running examples and triggered over serial, so
+// bus and sensor health can be checked in place without reflashing.
+//
+// json_stream and binlog_stream leave it out: their console is the data
+// stream, which a typed command and the printed report would corrupt. Run
+// the diagnostic program on those boards instead.
+package selftest
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Command is the serial command that examples use to trigger Run.
+const Command = "selftest"
+
+// Bus is the subset of *machine.I2C used for the bus check.
+type Bus interface {
+	Tx(addr uint16, w, r []byte) error
+}
+
+// Result holds the outcome of each check.
+type Result struct {
+	BusOK        bool
+	BusError     error
+	ProductIDs   int    // Number of product ID entries read at init
+	PartNumber   uint32 // Part number of the first entry
+	Events       uint32 // Events received during the window
+	Window       time.Duration
+	ServiceError error // Last Service error during the window
+}
+
+// Passed reports whether every check succeeded.
+func (r *Result) Passed() bool {
+	return r.BusOK && r.ProductIDs > 0 && r.Events > 0
+}
+
+// Run checks that the sensor acknowledges its address, that product IDs
+// were read during initialization, and that enabled reports keep arriving
+// for the given window. The bus check only reads an SHTP header, which does
+// not consume the pending packet. Events received during the window are
+// counted and discarded.
+func Run(bus Bus, sensor *bno08x.Device, window time.Duration) Result {
+	var r Result
+	var hdr [shtpraw.HeaderLen]byte
+	r.BusError = bus.Tx(shtpraw.DefaultAddress, nil, hdr[:])
+	r.BusOK = r.BusError == nil
+
+	ids := sensor.ProductIDs()
+	r.ProductIDs = int(ids.NumEntries)
+	if ids.NumEntries > 0 {
+		r.PartNumber = ids.Entries[0].PartNumber
+	}
+
+	r.Window = window
+	start := time.Now()
+	for time.Since(start) < window {
+		if err := sensor.Service(); err != nil {
+			r.ServiceError = err
+		}
+		if _, ok := sensor.GetSensorEvent(); ok {
+			r.Events++
+			continue
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return r
+}
+
+// Print writes the result to the serial console.
+func (r *Result) Print() {
+	println("--- Self test ---")
+	if r.BusOK {
+		println("Bus:        OK")
+	} else {
+		println("Bus:        FAIL", r.BusError.Error())
+	}
+	println("Product IDs:", r.ProductIDs, "part", r.PartNumber)
+	println("Events:     ", r.Events, "in", r.Window.Milliseconds(), "ms")
+	if r.ServiceError != nil {
+		println("Service:    ", r.ServiceError.Error())
+	}
+	if r.Passed() {
+		println("Result:      PASS")
+	} else {
+		println("Result:      FAIL")
+	}
+	println("-----------------")
+}
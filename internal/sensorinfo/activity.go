@@ -0,0 +1,66 @@
+package sensorinfo
+
+import "tinygo.org/x/drivers/bno08x"
+
+// AllActivities is the Personal Activity Classifier configuration that
+// classifies every state.
+const AllActivities = 1<<NumActivities - 1
+
+// ActivityMask returns the Personal Activity Classifier configuration that
+// classifies only the given states. It goes in the sensor-specific word of
+// Set Feature; the classifier leaves the other states at zero confidence.
+func ActivityMask(states ...uint8) uint32 {
+	var mask uint32
+	for _, s := range states {
+		if s < NumActivities {
+			mask |= 1 << s
+		}
+	}
+	return mask
+}
+
+// Bit of PersonalActivityClassifier.Page set on the last page; the rest
+// is the page number
+const lastPage = 0x80
+
+// DecodeActivity decodes the body of a Personal Activity Classifier
+// report, the bytes after the 4-byte report header, as the driver does
+// for its own events: Page keeps the last-page bit.
+func DecodeActivity(body []byte) bno08x.PersonalActivityClassifier {
+	var pac bno08x.PersonalActivityClassifier
+	if len(body) < 2+len(pac.Confidence) {
+		return pac
+	}
+	pac.Page = body[0]
+	pac.MostLikelyState = body[1]
+	copy(pac.Confidence[:], body[2:])
+	return pac
+}
+
+// Activities puts together the confidence of every classifier state. A
+// report holds the confidences of ten states as one page; should the
+// classifier know more states than fit, they follow on later pages, and
+// only the last page completes the set.
+type Activities struct {
+	Confidence [NumActivities]uint8 // Percent, by state
+	MostLikely uint8
+
+	pending [NumActivities]uint8
+}
+
+// Add takes one page and reports whether it completed a new set of
+// confidences.
+func (a *Activities) Add(pac bno08x.PersonalActivityClassifier) bool {
+	first := int(pac.Page&^lastPage) * len(pac.Confidence)
+	for i, c := range pac.Confidence {
+		if first+i < len(a.pending) {
+			a.pending[first+i] = c
+		}
+	}
+	if pac.Page&lastPage == 0 {
+		return false
+	}
+	a.Confidence, a.MostLikely = a.pending, pac.MostLikelyState
+	a.pending = [NumActivities]uint8{}
+	return true
+}
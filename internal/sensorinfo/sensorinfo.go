@@ -0,0 +1,156 @@
+// Package sensorinfo provides human-readable names, units and sensible
+// default report intervals for the BNO08x sensor IDs, so every program
+// prints the same names and nobody has to keep a private copy of the table.
+package sensorinfo
+
+import "tinygo.org/x/drivers/bno08x"
+
+// Common report intervals in microseconds.
+const (
+	Interval100Hz = 10000
+	Interval10Hz  = 100000
+	Interval1Hz   = 1000000
+)
+
+type info struct {
+	name     string
+	unit     string
+	interval uint32
+}
+
+// table is indexed by sensor ID.
+var table = [256]info{
+	0x01: {"Accelerometer", "m/s²", Interval100Hz},
+	0x02: {"Gyroscope", "rad/s", Interval100Hz},
+	0x03: {"Magnetic Field", "µT", Interval100Hz},
+	0x04: {"Linear Acceleration", "m/s²", Interval100Hz},
+	0x05: {"Rotation Vector", "", Interval100Hz},
+	0x06: {"Gravity", "m/s²", Interval100Hz},
+	0x07: {"Gyroscope Uncalibrated", "rad/s", Interval100Hz},
+	0x08: {"Game Rotation Vector", "", Interval100Hz},
+	0x09: {"Geomagnetic Rotation Vector", "", Interval100Hz},
+	0x0A: {"Pressure", "hPa", Interval10Hz},
+	0x0B: {"Ambient Light", "lux", Interval10Hz},
+	0x0C: {"Humidity", "%", Interval10Hz},
+	0x0D: {"Proximity", "cm", Interval10Hz},
+	0x0E: {"Temperature", "°C", Interval10Hz},
+	0x0F: {"Magnetic Field Uncalibrated", "µT", Interval100Hz},
+	0x10: {"Tap Detector", "", Interval10Hz},
+	0x11: {"Step Counter", "steps", Interval10Hz},
+	0x12: {"Significant Motion", "", Interval10Hz},
+	0x13: {"Stability Classifier", "", Interval10Hz},
+	0x14: {"Raw Accelerometer", "ADC", Interval100Hz},
+	0x15: {"Raw Gyroscope", "ADC", Interval100Hz},
+	0x16: {"Raw Magnetometer", "ADC", Interval100Hz},
+	0x18: {"Step Detector", "", Interval10Hz},
+	0x19: {"Shake Detector", "", Interval10Hz},
+	0x1A: {"Flip Detector", "", Interval10Hz},
+	0x1B: {"Pickup Detector", "", Interval10Hz},
+	0x1C: {"Stability Detector", "", Interval10Hz},
+	0x1E: {"Personal Activity Classifier", "%", Interval1Hz},
+	0x1F: {"Sleep Detector", "", Interval1Hz},
+	0x20: {"Tilt Detector", "", Interval10Hz},
+	0x21: {"Pocket Detector", "", Interval10Hz},
+	0x22: {"Circle Detector", "", Interval10Hz},
+}
+
+// All lists every sensor ID in the table in ascending order.
+var All = []bno08x.SensorID{
+	bno08x.SensorAccelerometer,
+	bno08x.SensorGyroscope,
+	bno08x.SensorMagneticField,
+	bno08x.SensorLinearAcceleration,
+	bno08x.SensorRotationVector,
+	bno08x.SensorGravity,
+	bno08x.SensorGyroscopeUncalibrated,
+	bno08x.SensorGameRotationVector,
+	bno08x.SensorGeomagneticRotationVector,
+	bno08x.SensorPressure,
+	bno08x.SensorAmbientLight,
+	bno08x.SensorHumidity,
+	bno08x.SensorProximity,
+	bno08x.SensorTemperature,
+	bno08x.SensorMagneticFieldUncalibrated,
+	bno08x.SensorTapDetector,
+	bno08x.SensorStepCounter,
+	bno08x.SensorSignificantMotion,
+	bno08x.SensorStabilityClassifier,
+	bno08x.SensorRawAccelerometer,
+	bno08x.SensorRawGyroscope,
+	bno08x.SensorRawMagnetometer,
+	bno08x.SensorStepDetector,
+	bno08x.SensorShakeDetector,
+	bno08x.SensorFlipDetector,
+	bno08x.SensorPickupDetector,
+	bno08x.SensorStabilityDetector,
+	bno08x.SensorPersonalActivityClassifier,
+	bno08x.SensorSleepDetector,
+	bno08x.SensorTiltDetector,
+	bno08x.SensorPocketDetector,
+	bno08x.SensorCircleDetector,
+}
+
+// Known reports whether id is in the table.
+func Known(id bno08x.SensorID) bool {
+	return table[uint8(id)].name != ""
+}
+
+// Name returns the human-readable name of a sensor, or "Unknown".
+func Name(id bno08x.SensorID) string {
+	if name := table[uint8(id)].name; name != "" {
+		return name
+	}
+	return "Unknown"
+}
+
+// Unit returns the unit of the sensor's primary value, or "" for unitless
+// values such as quaternions and detector events.
+func Unit(id bno08x.SensorID) string {
+	return table[uint8(id)].unit
+}
+
+// DefaultIntervalMicros returns a sensible report interval for the sensor
+// in microseconds: 100Hz for motion data, 10Hz for environmental sensors
+// and detectors, 1Hz for the slow classifiers. Unknown sensors get 10Hz.
+func DefaultIntervalMicros(id bno08x.SensorID) uint32 {
+	if interval := table[uint8(id)].interval; interval != 0 {
+		return interval
+	}
+	return Interval10Hz
+}
+
+// Personal activity classifier states, the index of
+// PersonalActivityClassifier.MostLikelyState and Confidence.
+const (
+	ActivityUnknown = iota
+	ActivityInVehicle
+	ActivityOnBicycle
+	ActivityOnFoot
+	ActivityStill
+	ActivityTilting
+	ActivityWalking
+	ActivityRunning
+	ActivityOnStairs
+	NumActivities
+)
+
+var activityNames = [NumActivities]string{
+	ActivityUnknown:   "Unknown",
+	ActivityInVehicle: "In Vehicle",
+	ActivityOnBicycle: "On Bicycle",
+	ActivityOnFoot:    "On Foot",
+	ActivityStill:     "Still",
+	ActivityTilting:   "Tilting",
+	ActivityWalking:   "Walking",
+	ActivityRunning:   "Running",
+	ActivityOnStairs:  "On Stairs",
+}
+
+// ActivityName returns the name of a personal activity classifier state,
+// or "Unknown".
+func ActivityName(state uint8) string {
+	if int(state) < len(activityNames) {
+		return activityNames[state]
+	}
+	return "Unknown"
+}
@@ -0,0 +1,159 @@
+package sensorinfo
+
+import "tinygo.org/x/drivers/bno08x"
+
+// MaxValues is the most values an event has: an uncalibrated vector with
+// its bias.
+const MaxValues = 6
+
+// Value is one reading carried by an event.
+type Value struct {
+	Key     string  // Short name, the key in internal/jsonout
+	Float   float32 // The measurement, unless Integer
+	Int     int64   // The count or ADC reading, if Integer
+	Integer bool
+
+	// Extra marks values beyond a sensor's main reading: the bias of an
+	// uncalibrated sensor, the heading accuracy of a rotation vector, the
+	// timestamp of a raw sample and the classifier's confidence. Formats
+	// short of room leave them out.
+	Extra bool
+}
+
+// Values holds the readings of one event in the order the stream formats
+// send them. Filling one does not allocate.
+type Values struct {
+	V [MaxValues]Value
+	N int
+}
+
+// Read fills v with the readings of event: x, y, z for vectors, then bx,
+// by, bz for the uncalibrated ones; i, j, k, r for rotation vectors, then
+// acc, the heading accuracy in radians, for those that report it; x, y, z
+// and ts, the sensor timestamp, for raw sensors; v for single values; and
+// named counts for detectors. Events that carry no value, such as
+// significant motion, leave v empty.
+//
+// It is the one place that knows which accessor each sensor has, so the
+// encoders iterate it and a new sensor needs only a case here.
+func (v *Values) Read(event *bno08x.SensorValue) {
+	v.N = 0
+	switch event.ID() {
+	case bno08x.SensorAccelerometer:
+		v.vector(event.Accelerometer())
+	case bno08x.SensorGyroscope:
+		v.vector(event.Gyroscope())
+	case bno08x.SensorMagneticField:
+		v.vector(event.MagneticField())
+	case bno08x.SensorLinearAcceleration:
+		v.vector(event.LinearAcceleration())
+	case bno08x.SensorGravity:
+		v.vector(event.Gravity())
+	case bno08x.SensorGyroscopeUncalibrated:
+		g := event.GyroscopeUncal()
+		v.uncalibrated(g.X, g.Y, g.Z, g.BiasX, g.BiasY, g.BiasZ)
+	case bno08x.SensorMagneticFieldUncalibrated:
+		m := event.MagneticFieldUncal()
+		v.uncalibrated(m.X, m.Y, m.Z, m.BiasX, m.BiasY, m.BiasZ)
+	case bno08x.SensorRotationVector, bno08x.SensorGeomagneticRotationVector:
+		v.quaternion(event.Quaternion())
+		v.float("acc", event.QuaternionAccuracy(), true)
+	case bno08x.SensorGameRotationVector:
+		v.quaternion(event.Quaternion())
+	case bno08x.SensorRawAccelerometer:
+		r := event.RawAccelerometer()
+		v.raw(r.X, r.Y, r.Z, r.Timestamp)
+	case bno08x.SensorRawGyroscope:
+		r := event.RawGyroscope()
+		v.raw(r.X, r.Y, r.Z, r.Timestamp)
+	case bno08x.SensorRawMagnetometer:
+		r := event.RawMagnetometer()
+		v.raw(r.X, r.Y, r.Z, r.Timestamp)
+	case bno08x.SensorPressure:
+		v.float("v", event.Pressure(), false)
+	case bno08x.SensorAmbientLight:
+		v.float("v", event.AmbientLight(), false)
+	case bno08x.SensorHumidity:
+		v.float("v", event.Humidity(), false)
+	case bno08x.SensorProximity:
+		v.float("v", event.Proximity(), false)
+	case bno08x.SensorTemperature:
+		v.float("v", event.Temperature(), false)
+	case bno08x.SensorTapDetector:
+		v.integer("flags", int64(event.TapDetector().Flags), false)
+	case bno08x.SensorStepCounter:
+		sc := event.StepCounter()
+		v.integer("steps", int64(sc.Count), false)
+		v.integer("latency", int64(sc.Latency), false)
+	case bno08x.SensorStepDetector:
+		v.integer("latency", int64(event.StepDetector().Latency), false)
+	case bno08x.SensorShakeDetector:
+		v.integer("shake", int64(event.ShakeDetector().Shake), false)
+	case bno08x.SensorStabilityClassifier:
+		v.integer("class", int64(event.StabilityClassifier().Classification), false)
+	case bno08x.SensorStabilityDetector:
+		v.integer("v", int64(event.StabilityDetector()), false)
+	case bno08x.SensorPersonalActivityClassifier:
+		pac := event.PersonalActivityClassifier()
+		confidence := uint8(0)
+		if int(pac.MostLikelyState) < len(pac.Confidence) {
+			confidence = pac.Confidence[pac.MostLikelyState]
+		}
+		v.integer("state", int64(pac.MostLikelyState), false)
+		v.integer("confidence", int64(confidence), true)
+	case bno08x.SensorSleepDetector:
+		v.integer("v", int64(event.SleepDetector()), false)
+	case bno08x.SensorPocketDetector:
+		v.integer("v", int64(event.PocketDetector()), false)
+	case bno08x.SensorCircleDetector:
+		v.integer("v", int64(event.CircleDetector()), false)
+	}
+}
+
+// Slice returns the values filled in.
+func (v *Values) Slice() []Value {
+	return v.V[:v.N]
+}
+
+func (v *Values) float(key string, f float32, extra bool) {
+	if v.N < len(v.V) {
+		v.V[v.N] = Value{Key: key, Float: f, Extra: extra}
+		v.N++
+	}
+}
+
+func (v *Values) integer(key string, n int64, extra bool) {
+	if v.N < len(v.V) {
+		v.V[v.N] = Value{Key: key, Int: n, Integer: true, Extra: extra}
+		v.N++
+	}
+}
+
+func (v *Values) vector(vec bno08x.Vector3) {
+	v.float("x", vec.X, false)
+	v.float("y", vec.Y, false)
+	v.float("z", vec.Z, false)
+}
+
+func (v *Values) uncalibrated(x, y, z, bx, by, bz float32) {
+	v.float("x", x, false)
+	v.float("y", y, false)
+	v.float("z", z, false)
+	v.float("bx", bx, true)
+	v.float("by", by, true)
+	v.float("bz", bz, true)
+}
+
+func (v *Values) quaternion(q bno08x.Quaternion) {
+	v.float("i", q.I, false)
+	v.float("j", q.J, false)
+	v.float("k", q.K, false)
+	v.float("r", q.Real, false)
+}
+
+func (v *Values) raw(x, y, z int16, timestamp uint32) {
+	v.integer("x", int64(x), false)
+	v.integer("y", int64(y), false)
+	v.integer("z", int64(z), false)
+	v.integer("ts", int64(timestamp), true)
+}
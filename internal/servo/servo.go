@@ -0,0 +1,83 @@
+// Package servo drives hobby servos from a PWM peripheral with the usual
+// 50Hz frame, mapping angles in degrees onto pulse widths. It does no
+// limiting of its own; programs pass commands through internal/safety
+// first.
+package servo
+
+import (
+	"machine"
+	"time"
+)
+
+// Frame is the servo PWM period.
+const Frame = 20 * time.Millisecond
+
+// Defaults for a standard servo: 1ms to 2ms pulses over ±90°.
+const (
+	DefaultMinPulse = 1000 * time.Microsecond
+	DefaultMaxPulse = 2000 * time.Microsecond
+	DefaultRange    = 180
+)
+
+// PWM is the part of a machine PWM peripheral used by Servo.
+type PWM interface {
+	Configure(config machine.PWMConfig) error
+	Channel(pin machine.Pin) (uint8, error)
+	Top() uint32
+	Set(channel uint8, value uint32)
+}
+
+// Configure sets pwm to the servo frame rate. Call it once before New for
+// every servo on the peripheral.
+func Configure(pwm PWM) error {
+	return pwm.Configure(machine.PWMConfig{Period: uint64(Frame.Nanoseconds())})
+}
+
+// Servo is one servo on a PWM channel. Zero fields take the defaults.
+type Servo struct {
+	MinPulse time.Duration // Pulse at -Range/2, DefaultMinPulse if zero
+	MaxPulse time.Duration // Pulse at +Range/2, DefaultMaxPulse if zero
+	Range    float32       // Travel in degrees, DefaultRange if zero
+
+	pwm     PWM
+	channel uint8
+	angle   float32
+}
+
+// New returns a servo on pin, which must be an output of pwm.
+func New(pwm PWM, pin machine.Pin) (*Servo, error) {
+	ch, err := pwm.Channel(pin)
+	if err != nil {
+		return nil, err
+	}
+	return &Servo{pwm: pwm, channel: ch}, nil
+}
+
+// SetAngle moves the servo to deg, 0 being centre, clamped to its travel.
+func (s *Servo) SetAngle(deg float32) {
+	travel := s.Range
+	if travel == 0 {
+		travel = DefaultRange
+	}
+	minPulse, maxPulse := s.MinPulse, s.MaxPulse
+	if minPulse == 0 {
+		minPulse = DefaultMinPulse
+	}
+	if maxPulse == 0 {
+		maxPulse = DefaultMaxPulse
+	}
+
+	if deg > travel/2 {
+		deg = travel / 2
+	} else if deg < -travel/2 {
+		deg = -travel / 2
+	}
+	s.angle = deg
+	pulse := float32(minPulse) + (deg/travel+0.5)*float32(maxPulse-minPulse)
+	s.pwm.Set(s.channel, uint32(float32(s.pwm.Top())*pulse/float32(Frame)))
+}
+
+// Angle returns the last commanded angle after clamping.
+func (s *Servo) Angle() float32 {
+	return s.angle
+}
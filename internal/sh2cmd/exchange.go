@@ -0,0 +1,94 @@
+package sh2cmd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+)
+
+var (
+	ErrNotRequest = errors.New("sh2cmd: not a Command Request")
+	ErrTimeout    = errors.New("sh2cmd: no response in time")
+	ErrFailed     = errors.New("sh2cmd: command failed")
+)
+
+// Exchange sends the Command Request req over link and waits up to
+// timeout for the response to it, matched by command and sequence
+// number. Every other packet read meanwhile is passed to other, if not
+// nil, so input reports are not lost while waiting.
+func Exchange(link transport.Transport, req []byte, timeout time.Duration, other func(shtpraw.Packet)) (Response, error) {
+	if len(req) < RequestLen || req[0] != ReportCommandRequest {
+		return Response{}, ErrNotRequest
+	}
+	command, seq := req[2], req[1]
+	if err := link.Write(shtpraw.ChannelControl, req); err != nil {
+		return Response{}, err
+	}
+	start := time.Now()
+	for time.Since(start) < timeout {
+		packet, err := transport.Next(link, timeout-time.Since(start))
+		if err != nil {
+			continue
+		}
+		if packet.Channel == shtpraw.ChannelControl {
+			r, err := ParseResponse(packet.Cargo())
+			if err == nil && r.Command == command && r.CommandSeq == seq {
+				return r, nil
+			}
+		}
+		if other != nil {
+			other(packet)
+		}
+	}
+	return Response{}, ErrTimeout
+}
+
+// SaveDCD sends Save DCD over link and waits up to timeout for the
+// answer, returning ErrFailed if the sensor could not save. other is
+// passed the packets read meanwhile, as by Exchange.
+func SaveDCD(link transport.Transport, seq uint8, timeout time.Duration, other func(shtpraw.Packet)) error {
+	var req [RequestLen]byte
+	r, err := Exchange(link, AppendSaveDCD(req[:0], seq), timeout, other)
+	if err != nil {
+		return err
+	}
+	if r.Status() != StatusOK {
+		return ErrFailed
+	}
+	return nil
+}
+
+// GetCounts asks for the counters of sensor over link and waits up to
+// timeout for both responses. other is passed the packets read
+// meanwhile, as by Exchange.
+func GetCounts(link transport.Transport, seq, sensor uint8, timeout time.Duration, other func(shtpraw.Packet)) (Counts, error) {
+	var req [RequestLen]byte
+	if err := link.Write(shtpraw.ChannelControl, AppendGetCounts(req[:0], seq, sensor)); err != nil {
+		return Counts{}, err
+	}
+	var c Counts
+	var got [2]bool
+	start := time.Now()
+	for time.Since(start) < timeout {
+		packet, err := transport.Next(link, timeout-time.Since(start))
+		if err != nil {
+			continue
+		}
+		if packet.Channel == shtpraw.ChannelControl {
+			r, err := ParseResponse(packet.Cargo())
+			if err == nil && r.CommandSeq == seq && r.ResponseSeq < 2 && c.Add(r) {
+				got[r.ResponseSeq] = true
+				if got[0] && got[1] {
+					return c, nil
+				}
+				continue
+			}
+		}
+		if other != nil {
+			other(packet)
+		}
+	}
+	return c, ErrTimeout
+}
@@ -0,0 +1,202 @@
+// Package sh2cmd encodes SH-2 Command Requests and decodes the Command
+// Responses the sensor hub answers them with, for programs that send
+// commands over raw SHTP on the control channel.
+//
+// A Command Request carries a sequence number chosen by the host, a
+// command and nine parameter bytes. Commands that answer do so with a
+// Command Response echoing the command and the request's sequence number,
+// followed by up to eleven result bytes.
+package sh2cmd
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Control channel report IDs for commands.
+const (
+	ReportCommandResponse = 0xF1
+	ReportCommandRequest  = 0xF2
+)
+
+// Lengths of a Command Request and a Command Response.
+const (
+	RequestLen  = 12
+	ResponseLen = 16
+)
+
+// Commands, from the SH-2 reference manual.
+const (
+	CommandErrors          = 0x01 // Report the error queue
+	CommandCounter         = 0x02 // Get or clear per-sensor counters
+	CommandTare            = 0x03 // Tare now, persist or set reorientation
+	CommandInitialize      = 0x04 // Reinitialise the hub or a subsystem
+	CommandSaveDCD         = 0x06 // Save dynamic calibration data to flash
+	CommandMECalibration   = 0x07 // Configure or query motion engine calibration
+	CommandDCDPeriodicSave = 0x09 // Turn periodic saving of the DCD on or off
+	CommandOscillator      = 0x0A // Report the oscillator type
+	CommandClearDCDReset   = 0x0B // Clear the DCD and reset
+)
+
+// StatusOK is the first result byte of a successful Save DCD or ME
+// Calibration response.
+const StatusOK = 0
+
+var ErrNotResponse = errors.New("sh2cmd: not a Command Response")
+
+// AppendRequest appends a Command Request to b. params fills the
+// parameter bytes in order; those not given are zero.
+func AppendRequest(b []byte, seq, command uint8, params ...byte) []byte {
+	var req [RequestLen]byte
+	req[0] = ReportCommandRequest
+	req[1] = seq
+	req[2] = command
+	copy(req[3:], params)
+	return append(b, req[:]...)
+}
+
+// AppendSaveDCD appends a Save DCD request to b. The sensor writes its
+// current calibration to flash, to be loaded at the next boot, and
+// answers with StatusOK or a failure code.
+func AppendSaveDCD(b []byte, seq uint8) []byte {
+	return AppendRequest(b, seq, CommandSaveDCD)
+}
+
+// AppendClearDCDReset appends a request to b that clears the dynamic
+// calibration data the hub holds in RAM and resets it, so the calibration
+// learnt since boot is dropped rather than carried on or saved. There is
+// no response; the hub announces itself with an advertisement once it has
+// restarted.
+func AppendClearDCDReset(b []byte, seq uint8) []byte {
+	return AppendRequest(b, seq, CommandClearDCDReset)
+}
+
+// Response is a decoded Command Response.
+type Response struct {
+	Seq         uint8 // Response sequence number, counting responses
+	Command     uint8 // Command answered
+	CommandSeq  uint8 // Sequence number of the request answered
+	ResponseSeq uint8 // Index of this response for commands answering with several
+	Result      [11]byte
+}
+
+// Status returns the first result byte, a status code for most commands.
+func (r Response) Status() uint8 {
+	return r.Result[0]
+}
+
+// ParseResponse decodes a Command Response from control channel cargo.
+// The top bit of the command byte, set on responses the hub sends
+// unprompted, is cleared.
+func ParseResponse(cargo []byte) (Response, error) {
+	if len(cargo) < ResponseLen || cargo[0] != ReportCommandResponse {
+		return Response{}, ErrNotResponse
+	}
+	r := Response{
+		Seq:         cargo[1],
+		Command:     cargo[2] &^ 0x80,
+		CommandSeq:  cargo[3],
+		ResponseSeq: cargo[4],
+	}
+	copy(r.Result[:], cargo[5:ResponseLen])
+	return r, nil
+}
+
+// ME Calibration subcommands
+const (
+	subConfigureMECalibration = 0x00
+	subGetMECalibration       = 0x01
+)
+
+// MECalibration says which of the motion engine's dynamic calibrations
+// run. The accelerometer, gyroscope and magnetometer calibrations learn
+// continuously while enabled; turning one off freezes it at its current
+// values. Planar calibrates the accelerometer assuming motion in a plane,
+// and OnTable the gyroscope while the board lies on a table.
+type MECalibration struct {
+	Accel   bool
+	Gyro    bool
+	Mag     bool
+	Planar  bool
+	OnTable bool
+}
+
+// AppendConfigureMECalibration appends a request to b setting which
+// calibrations run. The response's status is StatusOK on success.
+func AppendConfigureMECalibration(b []byte, seq uint8, c MECalibration) []byte {
+	return AppendRequest(b, seq, CommandMECalibration,
+		flag(c.Accel), flag(c.Gyro), flag(c.Mag), subConfigureMECalibration, flag(c.Planar), flag(c.OnTable))
+}
+
+// AppendGetMECalibration appends a request to b asking which calibrations
+// run; ParseMECalibration decodes the answer.
+func AppendGetMECalibration(b []byte, seq uint8) []byte {
+	return AppendRequest(b, seq, CommandMECalibration, 0, 0, 0, subGetMECalibration)
+}
+
+// ParseMECalibration decodes the calibrations enabled from the response
+// to an ME Calibration command. It reports false if r answers another
+// command or failed.
+func ParseMECalibration(r Response) (MECalibration, bool) {
+	if r.Command != CommandMECalibration || r.Status() != StatusOK {
+		return MECalibration{}, false
+	}
+	return MECalibration{
+		Accel:   r.Result[1] != 0,
+		Gyro:    r.Result[2] != 0,
+		Mag:     r.Result[3] != 0,
+		Planar:  r.Result[4] != 0,
+		OnTable: r.Result[5] != 0,
+	}, true
+}
+
+func flag(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// Counter subcommands
+const (
+	subGetCounts   = 0x00
+	subClearCounts = 0x01
+)
+
+// Counts are the hub's sample counters for one sensor, kept since it was
+// enabled or its counts were last cleared. Each stage passes on part of
+// what the one before it saw, so comparing them shows where samples stop.
+type Counts struct {
+	Offered   uint32 // Samples offered to the sensor by its source
+	Accepted  uint32 // Offered samples the sensor took in
+	On        uint32 // Accepted samples that arrived while the report was enabled
+	Attempted uint32 // Reports the hub tried to send to the host
+}
+
+// AppendGetCounts appends a request to b for the counters of sensor. The
+// hub answers with two responses; pass both to Counts.Add.
+func AppendGetCounts(b []byte, seq, sensor uint8) []byte {
+	return AppendRequest(b, seq, CommandCounter, subGetCounts, sensor)
+}
+
+// AppendClearCounts appends a request to b zeroing the counters of
+// sensor. The hub does not answer it.
+func AppendClearCounts(b []byte, seq, sensor uint8) []byte {
+	return AppendRequest(b, seq, CommandCounter, subClearCounts, sensor)
+}
+
+// Add takes one of the two responses to Get Counts into c: the first
+// carries Offered and Accepted, the second On and Attempted. It reports
+// whether r was a Counter response.
+func (c *Counts) Add(r Response) bool {
+	if r.Command != CommandCounter {
+		return false
+	}
+	a, b := binary.LittleEndian.Uint32(r.Result[3:]), binary.LittleEndian.Uint32(r.Result[7:])
+	if r.ResponseSeq == 0 {
+		c.Offered, c.Accepted = a, b
+	} else {
+		c.On, c.Attempted = a, b
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package shell
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrUsage is returned by a command given the wrong arguments; the shell
+// then prints the command's usage.
+var ErrUsage = errors.New("shell: usage")
+
+// Func runs a command. args holds the words after the command name.
+type Func func(args []string) error
+
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   Func
+}
+
+// Shell dispatches command lines read from the serial console to
+// registered commands. "help" is built in and lists them.
+type Shell struct {
+	reader   LineReader
+	commands []command
+}
+
+// Register adds a command. usage shows its arguments, e.g.
+// "enable <id> <interval_us>", and help is a one-line description.
+func (s *Shell) Register(name, usage, help string, run Func) {
+	s.commands = append(s.commands, command{name: name, usage: usage, help: help, run: run})
+}
+
+// Poll reads any pending input and runs a completed command line. It
+// reports whether a line was handled. Call it from the main loop.
+func (s *Shell) Poll() bool {
+	line, ok := s.reader.Poll()
+	if !ok {
+		return false
+	}
+	s.Run(line)
+	return true
+}
+
+// Run executes one command line, printing an error for unknown commands
+// or bad arguments.
+func (s *Shell) Run(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+	name, args := fields[0], fields[1:]
+	if name == "help" {
+		s.Help()
+		return
+	}
+	for _, c := range s.commands {
+		if c.name != name {
+			continue
+		}
+		switch err := c.run(args); {
+		case err == ErrUsage:
+			println("Usage:", c.usage)
+		case err != nil:
+			println("Error:", err.Error())
+		}
+		return
+	}
+	println("Unknown command:", name, "(type 'help')")
+}
+
+// Help prints every command with its usage and description.
+func (s *Shell) Help() {
+	width := 0
+	for _, c := range s.commands {
+		if len(c.usage) > width {
+			width = len(c.usage)
+		}
+	}
+	for _, c := range s.commands {
+		println("  " + c.usage + strings.Repeat(" ", width-len(c.usage)) + "  " + c.help)
+	}
+}
+
+// ParseUint parses a command argument as an unsigned number, decimal or
+// with a 0x prefix for hex, as sensor IDs are usually written.
+func ParseUint(arg string, bits int) (uint64, error) {
+	return strconv.ParseUint(arg, 0, bits)
+}
@@ -0,0 +1,40 @@
+// Package shell reads line-based commands from the USB serial console
+// without blocking the sensor loop. LineReader returns raw lines; Shell
+// adds a table of named commands with usage and help on top.
+package shell
+
+import "machine"
+
+// MaxLine is the longest command line accepted; extra bytes are dropped.
+const MaxLine = 64
+
+// LineReader accumulates bytes from machine.Serial into lines.
+type LineReader struct {
+	buf [MaxLine]byte
+	n   int
+}
+
+// Poll consumes any buffered serial input and returns a complete line,
+// without its terminator, once Enter has been received. Empty lines are
+// skipped. The returned string is a copy and stays valid.
+func (r *LineReader) Poll() (string, bool) {
+	for machine.Serial.Buffered() > 0 {
+		c, err := machine.Serial.ReadByte()
+		if err != nil {
+			break
+		}
+		if c == '\r' || c == '\n' {
+			if r.n == 0 {
+				continue
+			}
+			line := string(r.buf[:r.n])
+			r.n = 0
+			return line, true
+		}
+		if r.n < len(r.buf) {
+			r.buf[r.n] = c
+			r.n++
+		}
+	}
+	return "", false
+}
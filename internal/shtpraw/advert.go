@@ -0,0 +1,149 @@
+package shtpraw
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Advertisement TLV tags from the SHTP specification. Tags at or above
+// 0x80 are application specific and interpreted per application.
+const (
+	TagNull                    = 0
+	TagGUID                    = 1
+	TagMaxCargoPlusHeaderWrite = 2
+	TagMaxCargoPlusHeaderRead  = 3
+	TagMaxTransferWrite        = 4
+	TagMaxTransferRead         = 5
+	TagNormalChannel           = 6
+	TagWakeChannel             = 7
+	TagAppName                 = 8
+	TagChannelName             = 9
+	TagAdvertCount             = 10
+	TagAppVersion              = 0x80 // SHTP or SH-2 version string
+	TagSH2ReportLengths        = 0x81 // Pairs of report ID and length
+)
+
+// advertisementID is the first cargo byte of an advertisement response.
+const advertisementID = 0x00
+
+var ErrNotAdvertisement = errors.New("shtpraw: cargo is not an advertisement")
+
+// ChannelInfo describes one channel listed in the advertisement.
+type ChannelInfo struct {
+	App    string // Owning application, e.g. "sensorhub"
+	Name   string // Channel name, e.g. "inputNormal"
+	Number uint8
+	Wake   bool
+}
+
+// AdvertisedReport is an SH-2 report ID and its length in bytes as advertised
+// by the sensor hub.
+type AdvertisedReport struct {
+	ID     uint8
+	Length uint8
+}
+
+// Advertisement holds the fields of an SHTP advertisement that the
+// diagnostics care about.
+type Advertisement struct {
+	Channels         []ChannelInfo
+	SHTPVersion      string
+	SH2Version       string
+	MaxCargoWrite    uint16
+	MaxCargoRead     uint16
+	MaxTransferWrite uint16
+	MaxTransferRead  uint16
+	ReportLengths    []AdvertisedReport
+}
+
+// Channel returns the number of the named channel and whether it was found.
+func (a *Advertisement) Channel(name string) (uint8, bool) {
+	for _, ch := range a.Channels {
+		if ch.Name == name {
+			return ch.Number, true
+		}
+	}
+	return 0, false
+}
+
+// ParseAdvertisement decodes the cargo of an advertisement packet received
+// on ChannelCommand. The cargo starts with the advertisement response ID
+// followed by Tag-Length-Value entries.
+func ParseAdvertisement(cargo []byte) (Advertisement, error) {
+	var adv Advertisement
+	if len(cargo) < 1 || cargo[0] != advertisementID {
+		return adv, ErrNotAdvertisement
+	}
+
+	app := ""
+	cursor := 1
+	for cursor+2 <= len(cargo) {
+		tag := cargo[cursor]
+		length := int(cargo[cursor+1])
+		cursor += 2
+		if cursor+length > len(cargo) {
+			break
+		}
+		value := cargo[cursor : cursor+length]
+		cursor += length
+
+		switch tag {
+		case TagGUID:
+			app = ""
+		case TagAppName:
+			app = cstring(value)
+		case TagNormalChannel, TagWakeChannel:
+			if length >= 1 {
+				adv.Channels = append(adv.Channels, ChannelInfo{
+					App:    app,
+					Number: value[0],
+					Wake:   tag == TagWakeChannel,
+				})
+			}
+		case TagChannelName:
+			if n := len(adv.Channels); n > 0 {
+				adv.Channels[n-1].Name = cstring(value)
+			}
+		case TagMaxCargoPlusHeaderWrite:
+			adv.MaxCargoWrite = u16(value)
+		case TagMaxCargoPlusHeaderRead:
+			adv.MaxCargoRead = u16(value)
+		case TagMaxTransferWrite:
+			adv.MaxTransferWrite = u16(value)
+		case TagMaxTransferRead:
+			adv.MaxTransferRead = u16(value)
+		case TagAppVersion:
+			if app == "sensorhub" {
+				adv.SH2Version = cstring(value)
+			} else {
+				adv.SHTPVersion = cstring(value)
+			}
+		case TagSH2ReportLengths:
+			for i := 0; i+1 < length; i += 2 {
+				adv.ReportLengths = append(adv.ReportLengths, AdvertisedReport{ID: value[i], Length: value[i+1]})
+			}
+		}
+	}
+	return adv, nil
+}
+
+// cstring converts a possibly NUL-terminated byte slice to a string.
+func cstring(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// u16 decodes a little-endian value of up to two bytes.
+func u16(b []byte) uint16 {
+	switch len(b) {
+	case 0:
+		return 0
+	case 1:
+		return uint16(b[0])
+	}
+	return binary.LittleEndian.Uint16(b)
+}
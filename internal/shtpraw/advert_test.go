@@ -0,0 +1,120 @@
+package shtpraw_test
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport/sim"
+)
+
+// advert is the advertisement a BNO085 sends on the command channel after
+// reset, with the list of report lengths cut to the first six.
+var advert = strings.Join([]string{
+	"00",                         // Advertisement response
+	"01 04 00 00 00 00",          // GUID 0: SHTP
+	"80 06 31 2E 30 2E 30 00",    // Version "1.0.0"
+	"02 02 00 01", "03 02 FF 7F", // Max cargo plus header write, read
+	"04 02 00 01", "05 02 FF 7F", // Max transfer write, read
+	"08 05 53 48 54 50 00",                               // App "SHTP"
+	"06 01 00 09 08 63 6F 6E 74 72 6F 6C 00",             // Channel 0 "control"
+	"01 04 01 00 00 00",                                  // GUID 1: executable
+	"08 0B 65 78 65 63 75 74 61 62 6C 65 00",             // App "executable"
+	"06 01 01 09 07 64 65 76 69 63 65 00",                // Channel 1 "device"
+	"01 04 02 00 00 00",                                  // GUID 2: sensor hub
+	"08 0A 73 65 6E 73 6F 72 68 75 62 00",                // App "sensorhub"
+	"06 01 02 09 08 63 6F 6E 74 72 6F 6C 00",             // Channel 2 "control"
+	"06 01 03 09 0C 69 6E 70 75 74 4E 6F 72 6D 61 6C 00", // Channel 3 "inputNormal"
+	"07 01 04 09 0A 69 6E 70 75 74 57 61 6B 65 00",       // Channel 4 "inputWake"
+	"06 01 05 09 0C 69 6E 70 75 74 47 79 72 6F 52 76 00", // Channel 5 "inputGyroRv"
+	"80 06 31 2E 30 2E 31 00",                            // SH-2 version "1.0.1"
+	"81 0C 01 0A 02 0A 03 0A 04 0A 05 0E 06 0A",          // Report lengths
+}, " ")
+
+func advertCargo(t *testing.T) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(advert, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseAdvertisement(t *testing.T) {
+	var link sim.Transport
+	link.Queue(shtpraw.ChannelCommand, advertCargo(t))
+	packet, err := link.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packet.Channel != shtpraw.ChannelCommand {
+		t.Fatalf("advertisement on channel %d", packet.Channel)
+	}
+
+	adv, err := shtpraw.ParseAdvertisement(packet.Cargo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := shtpraw.Advertisement{
+		Channels: []shtpraw.ChannelInfo{
+			{App: "SHTP", Name: "control", Number: 0},
+			{App: "executable", Name: "device", Number: 1},
+			{App: "sensorhub", Name: "control", Number: 2},
+			{App: "sensorhub", Name: "inputNormal", Number: 3},
+			{App: "sensorhub", Name: "inputWake", Number: 4, Wake: true},
+			{App: "sensorhub", Name: "inputGyroRv", Number: 5},
+		},
+		SHTPVersion:      "1.0.0",
+		SH2Version:       "1.0.1",
+		MaxCargoWrite:    256,
+		MaxCargoRead:     0x7FFF,
+		MaxTransferWrite: 256,
+		MaxTransferRead:  0x7FFF,
+		ReportLengths: []shtpraw.AdvertisedReport{
+			{ID: 1, Length: 10}, {ID: 2, Length: 10}, {ID: 3, Length: 10},
+			{ID: 4, Length: 10}, {ID: 5, Length: 14}, {ID: 6, Length: 10},
+		},
+	}
+	if !reflect.DeepEqual(adv, want) {
+		t.Errorf("ParseAdvertisement =\n%+v\nwant\n%+v", adv, want)
+	}
+
+	// The advertised lengths agree with the table DecodeInput uses
+	for _, r := range adv.ReportLengths {
+		if got := shtpraw.ReportLength(r.ID); got != int(r.Length) {
+			t.Errorf("ReportLength(%#02x) = %d, advertised %d", r.ID, got, r.Length)
+		}
+	}
+
+	// Channel finds the first of the two "control" channels
+	for name, want := range map[string]uint8{"control": 0, "device": 1, "inputNormal": 3, "inputWake": 4, "inputGyroRv": 5} {
+		if n, ok := adv.Channel(name); !ok || n != want {
+			t.Errorf("Channel(%q) = %d, %v, want %d", name, n, ok, want)
+		}
+	}
+	if _, ok := adv.Channel("missing"); ok {
+		t.Error(`Channel("missing") found`)
+	}
+}
+
+func TestParseAdvertisementTruncated(t *testing.T) {
+	cargo := advertCargo(t)
+
+	// A cut-off TLV ends the parse without an error, keeping what came
+	// before it
+	adv, err := shtpraw.ParseAdvertisement(cargo[:60])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(adv.Channels) != 1 || adv.Channels[0].Name != "control" {
+		t.Errorf("channels from truncated advertisement = %+v", adv.Channels)
+	}
+
+	for _, cargo := range [][]byte{nil, {0x01, 0x04, 0, 0, 0, 0}} {
+		if _, err := shtpraw.ParseAdvertisement(cargo); err != shtpraw.ErrNotAdvertisement {
+			t.Errorf("ParseAdvertisement(% X) error = %v, want ErrNotAdvertisement", cargo, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package shtpraw
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Control channel report IDs for sensor configuration.
+const (
+	ReportGetFeatureResponse = 0xFC
+	ReportSetFeature         = 0xFD
+	ReportGetFeatureRequest  = 0xFE
+	ReportForceFlush         = 0xF0
+)
+
+// FeatureLen is the size of a Set Feature command and of a Get Feature
+// response.
+const FeatureLen = 17
+
+var ErrNotFeature = errors.New("shtpraw: not a Get Feature response")
+
+// Feature flags.
+const (
+	FlagSensitivityRelative = 1 << 0 // Sensitivity is a change from the last report, not a threshold
+	FlagSensitivityEnabled  = 1 << 1 // Report only changes of at least Sensitivity
+	FlagWakeup              = 1 << 2 // Reports wake the host, on the wake channel
+	FlagAlwaysOn            = 1 << 3 // Keep running while the host sleeps
+)
+
+// Feature is the configuration of one sensor as carried by Set Feature
+// and Get Feature Response. Intervals are in microseconds; a report
+// interval of zero means the sensor is off.
+type Feature struct {
+	Sensor        uint8
+	Flags         uint8
+	Sensitivity   uint16
+	Interval      uint32
+	BatchInterval uint32
+	Specific      uint32
+}
+
+// AppendSetFeature appends a Set Feature command for f to b.
+func AppendSetFeature(b []byte, f Feature) []byte {
+	var cmd [FeatureLen]byte
+	cmd[0] = ReportSetFeature
+	f.put(cmd[:])
+	return append(b, cmd[:]...)
+}
+
+// AppendGetFeature appends a Get Feature request for sensor to b. The
+// sensor answers with a Get Feature response, as it does unprompted after
+// every Set Feature.
+func AppendGetFeature(b []byte, sensor uint8) []byte {
+	return append(b, ReportGetFeatureRequest, sensor)
+}
+
+// AppendForceFlush appends a Force Sensor Flush command for sensor to b.
+// The sensor hub sends every report it has batched for the sensor at once,
+// followed by a Flush Completed report.
+func AppendForceFlush(b []byte, sensor uint8) []byte {
+	return append(b, ReportForceFlush, sensor)
+}
+
+// ParseFeature decodes a Get Feature response from control channel cargo.
+func ParseFeature(cargo []byte) (Feature, error) {
+	if len(cargo) < FeatureLen || cargo[0] != ReportGetFeatureResponse {
+		return Feature{}, ErrNotFeature
+	}
+	return Feature{
+		Sensor:        cargo[1],
+		Flags:         cargo[2],
+		Sensitivity:   binary.LittleEndian.Uint16(cargo[3:5]),
+		Interval:      binary.LittleEndian.Uint32(cargo[5:9]),
+		BatchInterval: binary.LittleEndian.Uint32(cargo[9:13]),
+		Specific:      binary.LittleEndian.Uint32(cargo[13:17]),
+	}, nil
+}
+
+// put writes f after the report ID byte of b.
+func (f Feature) put(b []byte) {
+	b[1] = f.Sensor
+	b[2] = f.Flags
+	binary.LittleEndian.PutUint16(b[3:5], f.Sensitivity)
+	binary.LittleEndian.PutUint32(b[5:9], f.Interval)
+	binary.LittleEndian.PutUint32(b[9:13], f.BatchInterval)
+	binary.LittleEndian.PutUint32(b[13:17], f.Specific)
+}
@@ -0,0 +1,54 @@
+package shtpraw
+
+// Any matches every value in a Filter field.
+const Any = -1
+
+// Filter selects packets by channel, top-level report ID and sensor ID, so
+// that a sniffer can follow one report without being drowned by the
+// rotation vectors streaming at 100Hz.
+type Filter struct {
+	Channel  int // SHTP channel, or Any
+	ReportID int // First cargo byte, or Any
+	SensorID int // Sensor ID carried in the cargo, or Any
+}
+
+// MatchAll is a filter that lets every packet through.
+var MatchAll = Filter{Channel: Any, ReportID: Any, SensorID: Any}
+
+// Match reports whether the packet passes the filter. SensorID matches any
+// sensor report inside an input cargo, and the sensor ID field of Get
+// Feature responses on the control channel.
+func (f Filter) Match(p Packet) bool {
+	if f.Channel != Any && int(p.Channel) != f.Channel {
+		return false
+	}
+	cargo := p.Cargo()
+	if f.ReportID != Any && (len(cargo) == 0 || int(cargo[0]) != f.ReportID) {
+		return false
+	}
+	if f.SensorID != Any {
+		return containsSensor(p.Channel, cargo, uint8(f.SensorID))
+	}
+	return true
+}
+
+// containsSensor reports whether cargo carries a report for sensor id.
+func containsSensor(channel uint8, cargo []byte, id uint8) bool {
+	switch channel {
+	case ChannelInputNormal, ChannelInputWake:
+		for cursor := 0; cursor < len(cargo); {
+			if cargo[cursor] == id {
+				return true
+			}
+			length := ReportLength(cargo[cursor])
+			if length == 0 {
+				return false
+			}
+			cursor += length
+		}
+	case ChannelControl:
+		// Get Feature response: report ID, then sensor ID
+		return len(cargo) > 1 && cargo[0] == ReportGetFeatureResponse && cargo[1] == id
+	}
+	return false
+}
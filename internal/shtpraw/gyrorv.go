@@ -0,0 +1,47 @@
+package shtpraw
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// GyroRVLen is the size of a Gyro-Integrated Rotation Vector report on
+// ChannelGyroRV.
+const GyroRVLen = 14
+
+// Fixed-point scales of the Gyro-Integrated Rotation Vector fields.
+const (
+	gyroRVQuatScale = 1 << 14 // Q14
+	gyroRVRateScale = 1 << 10 // Q10, rad/s
+)
+
+var ErrNotGyroRV = errors.New("shtpraw: cargo too short for a gyro-integrated rotation vector")
+
+// GyroRV is one Gyro-Integrated Rotation Vector report. The sensor sends
+// it on its own channel with none of the framing of the input channels:
+// no timestamp, report ID or status, just the orientation and the
+// angular velocity it was integrated from, so it can be read and acted
+// on with the least delay.
+type GyroRV struct {
+	I, J, K, Real float32 // Orientation quaternion
+	X, Y, Z       float32 // Angular velocity in rad/s
+}
+
+// ParseGyroRV decodes the cargo of a ChannelGyroRV packet.
+func ParseGyroRV(cargo []byte) (GyroRV, error) {
+	if len(cargo) < GyroRVLen {
+		return GyroRV{}, ErrNotGyroRV
+	}
+	field := func(i int, scale float32) float32 {
+		return float32(int16(binary.LittleEndian.Uint16(cargo[2*i:]))) / scale
+	}
+	return GyroRV{
+		I:    field(0, gyroRVQuatScale),
+		J:    field(1, gyroRVQuatScale),
+		K:    field(2, gyroRVQuatScale),
+		Real: field(3, gyroRVQuatScale),
+		X:    field(4, gyroRVRateScale),
+		Y:    field(5, gyroRVRateScale),
+		Z:    field(6, gyroRVRateScale),
+	}, nil
+}
@@ -0,0 +1,59 @@
+package shtpraw
+
+import "github.com/intermernet/bno08xPrograms/internal/hexdump"
+
+// DefaultDumps is the number of malformed cargos a Quarantine prints in
+// hex when Dumps is zero.
+const DefaultDumps = 3
+
+// Quarantine decodes input cargo, setting aside any that fails
+// ValidateInput instead of passing on reports decoded from it. It counts
+// the rejected cargos by cause and prints the first few in hex, so the
+// truncated transfers behind them can be examined.
+type Quarantine struct {
+	Dumps int // Malformed cargos printed in hex, DefaultDumps if zero; negative for none
+
+	NoTimestamp uint32 // Cargos not starting with a base timestamp
+	Unknown     uint32 // Cargos reaching an unknown report ID
+	Truncated   uint32 // Cargos ending partway through a report
+
+	dumped int
+}
+
+// DecodeInput calls the package DecodeInput, quarantining the cargo if it
+// is malformed.
+func (q *Quarantine) DecodeInput(cargo []byte, hostTime int64, fn func(Report)) error {
+	err := DecodeInput(cargo, hostTime, fn)
+	switch err {
+	case nil:
+		return nil
+	case ErrNoBaseTimestamp:
+		q.NoTimestamp++
+	case ErrUnknownReport:
+		q.Unknown++
+	case ErrTruncatedReport:
+		q.Truncated++
+	}
+
+	limit := q.Dumps
+	if limit == 0 {
+		limit = DefaultDumps
+	}
+	if q.dumped < limit {
+		q.dumped++
+		println("Quarantined input cargo,", len(cargo), "bytes:", err.Error())
+		hexdump.Print(cargo)
+	}
+	return err
+}
+
+// Total returns the number of cargos quarantined.
+func (q *Quarantine) Total() uint32 {
+	return q.NoTimestamp + q.Unknown + q.Truncated
+}
+
+// Print prints the quarantine counts.
+func (q *Quarantine) Print() {
+	println("Malformed input cargo:", q.Total(), "| no base timestamp:", q.NoTimestamp,
+		"unknown report:", q.Unknown, "truncated:", q.Truncated)
+}
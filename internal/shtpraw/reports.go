@@ -0,0 +1,188 @@
+package shtpraw
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Non-sensor report IDs that appear in input report cargo.
+const (
+	ReportFlushCompleted  = 0xEF
+	ReportTimestampRebase = 0xFA
+	ReportBaseTimestamp   = 0xFB
+)
+
+// ReportHeaderLen is the size of the common header at the start of every
+// sensor report: report ID, sequence number, status and delay.
+const ReportHeaderLen = 4
+
+var (
+	ErrUnknownReport   = errors.New("shtpraw: unknown report ID in cargo")
+	ErrTruncatedReport = errors.New("shtpraw: report truncated by end of cargo")
+	ErrNoBaseTimestamp = errors.New("shtpraw: input cargo does not start with a base timestamp")
+)
+
+// reportLengths holds the length in bytes of each SH-2 input report,
+// including the 4-byte report header. Zero means unknown.
+var reportLengths = [256]uint8{
+	0x01: 10, // Accelerometer
+	0x02: 10, // Gyroscope
+	0x03: 10, // Magnetic Field
+	0x04: 10, // Linear Acceleration
+	0x05: 14, // Rotation Vector
+	0x06: 10, // Gravity
+	0x07: 16, // Gyroscope Uncalibrated
+	0x08: 12, // Game Rotation Vector
+	0x09: 14, // Geomagnetic Rotation Vector
+	0x0A: 8,  // Pressure
+	0x0B: 8,  // Ambient Light
+	0x0C: 6,  // Humidity
+	0x0D: 6,  // Proximity
+	0x0E: 6,  // Temperature
+	0x0F: 16, // Magnetic Field Uncalibrated
+	0x10: 5,  // Tap Detector
+	0x11: 12, // Step Counter
+	0x12: 6,  // Significant Motion
+	0x13: 6,  // Stability Classifier
+	0x14: 16, // Raw Accelerometer
+	0x15: 16, // Raw Gyroscope
+	0x16: 16, // Raw Magnetometer
+	0x17: 14, // SAR
+	0x18: 8,  // Step Detector
+	0x19: 6,  // Shake Detector
+	0x1A: 6,  // Flip Detector
+	0x1B: 6,  // Pickup Detector
+	0x1C: 6,  // Stability Detector
+	0x1E: 16, // Personal Activity Classifier
+	0x1F: 6,  // Sleep Detector
+	0x20: 6,  // Tilt Detector
+	0x21: 6,  // Pocket Detector
+	0x22: 6,  // Circle Detector
+	0x23: 6,  // Heart Rate Monitor
+	0x28: 14, // ARVR Stabilized Rotation Vector
+	0x29: 12, // ARVR Stabilized Game Rotation Vector
+	0x2A: 14, // Gyro-Integrated Rotation Vector
+	0x2B: 6,  // IZRO Motion Request
+
+	ReportFlushCompleted:  2,
+	ReportTimestampRebase: 5,
+	ReportBaseTimestamp:   5,
+}
+
+// ReportLength returns the length in bytes of the report with the given ID,
+// or 0 if the ID is unknown.
+func ReportLength(id uint8) int {
+	return int(reportLengths[id])
+}
+
+// Report is one sensor report decoded from an input cargo.
+type Report struct {
+	ID        uint8
+	Sequence  uint8
+	Status    uint8 // Accuracy in bits 1:0, upper delay bits in 7:2
+	Delay     uint16
+	Timestamp int64  // Sample time in microseconds on the caller's timebase
+	Data      []byte // Whole report including the report header
+}
+
+// Accuracy returns the 2-bit accuracy field of the report status.
+func (r Report) Accuracy() uint8 {
+	return r.Status & 0x03
+}
+
+// DelayMicros returns the report's delay field in microseconds: how long
+// after the cargo's time reference the sample was taken. The field counts
+// in 100µs ticks.
+func (r Report) DelayMicros() int64 {
+	return int64(r.Delay) * 100
+}
+
+// SequenceGap returns how many reports of one sensor, or packets on one
+// channel, were lost between sequence numbers prev and next, allowing for
+// the 8-bit wrap.
+func SequenceGap(prev, next uint8) int {
+	return int(next - prev - 1)
+}
+
+// DecodeInput walks the cargo of a packet from ChannelInputNormal or
+// ChannelInputWake and calls fn for every sensor report it contains.
+//
+// A cargo starts with a base timestamp record (0xFB) giving how long before
+// the interrupt the reports were sampled. When the sensor batches reports the
+// cargo may hold many samples, interleaved with timestamp rebase records
+// (0xFA) that move the reference for the reports that follow. Each report's
+// own delay field is then added to the reference, so every batched sample
+// gets its original sampling time rather than the time the batch was read.
+//
+// hostTime is the time, in microseconds on any monotonic timebase, at which
+// the packet became available (ideally the INT edge, otherwise the time of
+// the read). Timestamps are reported on the same timebase.
+//
+// The whole cargo is checked with ValidateInput before any report is
+// delivered, so a malformed cargo returns its error and calls fn for
+// nothing, rather than passing on reports read from the wrong offsets.
+func DecodeInput(cargo []byte, hostTime int64, fn func(Report)) error {
+	if err := ValidateInput(cargo); err != nil {
+		return err
+	}
+
+	// Reference offset from hostTime in 100µs ticks, as in the SH-2 library
+	var reference int64
+	cursor := 0
+	for cursor < len(cargo) {
+		id := cargo[cursor]
+		length := ReportLength(id)
+		report := cargo[cursor : cursor+length]
+		cursor += length
+
+		switch id {
+		case ReportBaseTimestamp:
+			reference = -int64(binary.LittleEndian.Uint32(report[1:5]))
+		case ReportTimestampRebase:
+			reference += int64(int32(binary.LittleEndian.Uint32(report[1:5])))
+		case ReportFlushCompleted:
+			// Marks the end of a flushed batch; carries no sample
+		default:
+			if length < ReportHeaderLen {
+				continue
+			}
+			status := report[2]
+			delay := uint16(status&0xFC)<<6 | uint16(report[3])
+			fn(Report{
+				ID:        id,
+				Sequence:  report[1],
+				Status:    status,
+				Delay:     delay,
+				Timestamp: hostTime + (reference+int64(delay))*100,
+				Data:      report,
+			})
+		}
+	}
+	return nil
+}
+
+// ValidateInput checks that an input cargo starts with a base timestamp
+// and is made up entirely of whole reports of known length. Each report
+// type has a fixed length, so a cargo cut short by a bad transfer, or
+// with a byte dropped or inserted, ends partway through a report or lands
+// on an unknown ID; it returns ErrNoBaseTimestamp, ErrUnknownReport or
+// ErrTruncatedReport for those. An empty cargo is valid.
+func ValidateInput(cargo []byte) error {
+	if len(cargo) == 0 {
+		return nil
+	}
+	if cargo[0] != ReportBaseTimestamp {
+		return ErrNoBaseTimestamp
+	}
+	for cursor := 0; cursor < len(cargo); {
+		length := ReportLength(cargo[cursor])
+		if length == 0 {
+			return ErrUnknownReport
+		}
+		if cursor+length > len(cargo) {
+			return ErrTruncatedReport
+		}
+		cursor += length
+	}
+	return nil
+}
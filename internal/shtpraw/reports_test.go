@@ -0,0 +1,139 @@
+package shtpraw_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport/sim"
+)
+
+// timebase returns a base timestamp (0xFB) or rebase (0xFA) record moving
+// the reference by ticks of 100µs.
+func timebase(id uint8, ticks int32) []byte {
+	b := []byte{id, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(b[1:], uint32(ticks))
+	return b
+}
+
+// report returns a sensor report of its full length with the given
+// sequence number and 14-bit delay, and accuracy 3.
+func report(id, seq uint8, delay uint16) []byte {
+	b := make([]byte, shtpraw.ReportLength(id))
+	b[0] = id
+	b[1] = seq
+	b[2] = uint8(delay>>6)&0xFC | 0x03
+	b[3] = uint8(delay)
+	return b
+}
+
+func cargo(parts ...[]byte) []byte {
+	var c []byte
+	for _, p := range parts {
+		c = append(c, p...)
+	}
+	return c
+}
+
+func TestDecodeInputTimestamps(t *testing.T) {
+	// Each sample is at hostTime + (reference + delay) * 100µs, as in
+	// the SH-2 library: the base timestamp sets the reference to minus
+	// its value and each rebase adds to it.
+	const hostTime = 1_000_000
+	var link sim.Transport
+	link.Queue(shtpraw.ChannelInputNormal, cargo(
+		timebase(shtpraw.ReportBaseTimestamp, 120),   // Reference -120
+		report(0x01, 7, 5),                           // -115: 988500
+		report(0x02, 9, 0),                           // -120: 988000
+		timebase(shtpraw.ReportTimestampRebase, 40),  // Reference -80
+		report(0x01, 8, 261),                         // 181, delay bits in the status: 1018100
+		timebase(shtpraw.ReportTimestampRebase, -10), // Reference -90
+		report(0x08, 200, 0),                         // -90: 991000
+		[]byte{shtpraw.ReportFlushCompleted, 0x01},
+	))
+	packet, err := link.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		id, seq   uint8
+		delay     uint16
+		timestamp int64
+	}{
+		{0x01, 7, 5, 988_500},
+		{0x02, 9, 0, 988_000},
+		{0x01, 8, 261, 1_018_100},
+		{0x08, 200, 0, 991_000},
+	}
+	var got []shtpraw.Report
+	err = shtpraw.DecodeInput(packet.Cargo(), hostTime, func(r shtpraw.Report) {
+		got = append(got, r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d reports, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		r := got[i]
+		if r.ID != w.id || r.Sequence != w.seq || r.Delay != w.delay || r.Timestamp != w.timestamp {
+			t.Errorf("report %d: id %#02x seq %d delay %d at %d, want id %#02x seq %d delay %d at %d",
+				i, r.ID, r.Sequence, r.Delay, r.Timestamp, w.id, w.seq, w.delay, w.timestamp)
+		}
+		if r.Accuracy() != 3 {
+			t.Errorf("report %d: accuracy %d, want 3", i, r.Accuracy())
+		}
+		if r.DelayMicros() != int64(w.delay)*100 {
+			t.Errorf("report %d: DelayMicros = %d", i, r.DelayMicros())
+		}
+		if len(r.Data) != shtpraw.ReportLength(w.id) {
+			t.Errorf("report %d: %d bytes of data", i, len(r.Data))
+		}
+	}
+}
+
+func TestDecodeInputMalformed(t *testing.T) {
+	base := timebase(shtpraw.ReportBaseTimestamp, 0)
+	tests := []struct {
+		name  string
+		cargo []byte
+		want  error
+	}{
+		{"empty", nil, nil},
+		{"no base", cargo(report(0x01, 0, 0)), shtpraw.ErrNoBaseTimestamp},
+		{"unknown", cargo(base, report(0x01, 0, 0), []byte{0x1D, 0, 0, 0}), shtpraw.ErrUnknownReport},
+		{"truncated", cargo(base, report(0x05, 0, 0))[:15], shtpraw.ErrTruncatedReport},
+		{"extra byte", cargo(base, report(0x01, 0, 0), []byte{0x00}), shtpraw.ErrUnknownReport},
+	}
+	for _, tt := range tests {
+		calls := 0
+		err := shtpraw.DecodeInput(tt.cargo, 0, func(shtpraw.Report) { calls++ })
+		if err != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+		// Nothing is delivered from a cargo that fails validation
+		if calls != 0 {
+			t.Errorf("%s: %d reports delivered", tt.name, calls)
+		}
+	}
+}
+
+func TestSequenceGap(t *testing.T) {
+	tests := []struct {
+		prev, next uint8
+		want       int
+	}{
+		{4, 5, 0},
+		{4, 7, 2},
+		{255, 0, 0},
+		{254, 1, 2},
+		{9, 9, 255},
+	}
+	for _, tt := range tests {
+		if got := shtpraw.SequenceGap(tt.prev, tt.next); got != tt.want {
+			t.Errorf("SequenceGap(%d, %d) = %d, want %d", tt.prev, tt.next, got, tt.want)
+		}
+	}
+}
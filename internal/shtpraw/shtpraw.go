@@ -0,0 +1,178 @@
+// Package shtpraw implements just enough of the SHTP transport for the raw
+// diagnostic programs to talk to a BNO08x without the driver: framing cargo
+// with per-channel sequence numbers, reading whole packets off the bus and
+// parsing the channel advertisement.
+package shtpraw
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// DefaultAddress is the BNO08x I2C address with the DI pin pulled low.
+const DefaultAddress = 0x4A
+
+const (
+	// HeaderLen is the size of the SHTP header preceding every cargo.
+	HeaderLen = 4
+
+	// ContinuationBit is set in the length field of a packet that continues
+	// a transfer started by an earlier packet.
+	ContinuationBit = 0x8000
+
+	// MaxPacketLen is the largest packet (header plus cargo) the receive
+	// buffer holds. The advertisement is the biggest message seen in
+	// practice at around 300 bytes.
+	MaxPacketLen = 512
+)
+
+// SHTP channel numbers as assigned by the BNO08x firmware.
+const (
+	ChannelCommand     uint8 = 0 // SHTP command channel (advertisement)
+	ChannelExecutable  uint8 = 1 // Executable / device channel (reset)
+	ChannelControl     uint8 = 2 // SH-2 control channel
+	ChannelInputNormal uint8 = 3 // Normal input reports
+	ChannelInputWake   uint8 = 4 // Wake input reports
+	ChannelGyroRV      uint8 = 5 // Gyro-integrated rotation vector
+
+	NumChannels = 6
+)
+
+var (
+	ErrNoData       = errors.New("shtpraw: no data available")
+	ErrContinuation = errors.New("shtpraw: unexpected continuation packet")
+	ErrTooLarge     = errors.New("shtpraw: packet larger than receive buffer")
+	ErrBadChannel   = errors.New("shtpraw: invalid channel")
+)
+
+// Bus is the subset of *machine.I2C used by Conn.
+type Bus interface {
+	Tx(addr uint16, w, r []byte) error
+}
+
+// Header is a decoded SHTP packet header.
+type Header struct {
+	Length       uint16 // Total packet length including the header
+	Channel      uint8
+	Sequence     uint8
+	Continuation bool
+}
+
+// ParseHeader decodes the first HeaderLen bytes of b.
+func ParseHeader(b []byte) Header {
+	length := binary.LittleEndian.Uint16(b[0:2])
+	return Header{
+		Length:       length &^ ContinuationBit,
+		Channel:      b[2],
+		Sequence:     b[3],
+		Continuation: length&ContinuationBit != 0,
+	}
+}
+
+// Packet is a complete SHTP packet read from the bus. Data includes the
+// header and is only valid until the next read on the same Conn.
+type Packet struct {
+	Header
+	Data []byte
+}
+
+// Cargo returns the packet contents following the header.
+func (p Packet) Cargo() []byte {
+	return p.Data[HeaderLen:]
+}
+
+// Conn is a raw SHTP connection to one BNO08x on an I2C bus. It tracks the
+// outgoing sequence number of each channel.
+type Conn struct {
+	bus  Bus
+	addr uint16
+	seq  [NumChannels]uint8
+	tx   [MaxPacketLen]byte
+	rx   [MaxPacketLen]byte
+}
+
+// New returns a connection to the sensor at addr on bus.
+func New(bus Bus, addr uint16) *Conn {
+	return &Conn{bus: bus, addr: addr}
+}
+
+// Address returns the I2C address of the sensor.
+func (c *Conn) Address() uint16 {
+	return c.addr
+}
+
+// SendOnChannel frames payload with an SHTP header for the given channel,
+// using and then incrementing that channel's sequence number.
+func (c *Conn) SendOnChannel(channel uint8, payload []byte) error {
+	if channel >= NumChannels {
+		return ErrBadChannel
+	}
+	frameLen := HeaderLen + len(payload)
+	if frameLen > len(c.tx) {
+		return ErrTooLarge
+	}
+	frame := c.tx[:frameLen]
+	binary.LittleEndian.PutUint16(frame[0:2], uint16(frameLen))
+	frame[2] = channel
+	frame[3] = c.seq[channel]
+	c.seq[channel]++
+	copy(frame[HeaderLen:], payload)
+	return c.bus.Tx(c.addr, frame, nil)
+}
+
+// SoftReset sends the reset command on the executable channel.
+func (c *Conn) SoftReset() error {
+	return c.SendOnChannel(ChannelExecutable, []byte{1})
+}
+
+// ReadHeader reads only the 4-byte header of the pending packet. On I2C the
+// sensor repeats the header at the start of every read, so a following
+// ReadPacket still returns the whole packet.
+func (c *Conn) ReadHeader() (Header, error) {
+	hdr := c.rx[:HeaderLen]
+	if err := c.bus.Tx(c.addr, nil, hdr); err != nil {
+		return Header{}, err
+	}
+	return ParseHeader(hdr), nil
+}
+
+// ReadPacket reads the header to learn the packet length, then re-reads the
+// full packet including the header, the same way the Arduino library does.
+// It returns ErrNoData if the sensor has nothing queued or either header
+// is too short to hold one, and ErrContinuation if the pending packet is
+// the tail of a transfer this side never started.
+func (c *Conn) ReadPacket() (Packet, error) {
+	hdr, err := c.ReadHeader()
+	if err != nil {
+		return Packet{}, err
+	}
+	if hdr.Continuation {
+		return Packet{}, ErrContinuation
+	}
+	if hdr.Length < HeaderLen {
+		return Packet{}, ErrNoData
+	}
+	if int(hdr.Length) > len(c.rx) {
+		return Packet{}, ErrTooLarge
+	}
+	if hdr.Length == HeaderLen {
+		return Packet{Header: hdr, Data: c.rx[:HeaderLen]}, nil
+	}
+
+	data := c.rx[:hdr.Length]
+	if err := c.bus.Tx(c.addr, nil, data); err != nil {
+		return Packet{}, err
+	}
+	hdr = ParseHeader(data)
+	if hdr.Continuation {
+		return Packet{}, ErrContinuation
+	}
+	if hdr.Length < HeaderLen {
+		// The packet went between the header and the read
+		return Packet{}, ErrNoData
+	}
+	if int(hdr.Length) < len(data) {
+		data = data[:hdr.Length]
+	}
+	return Packet{Header: hdr, Data: data}, nil
+}
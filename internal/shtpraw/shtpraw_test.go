@@ -0,0 +1,105 @@
+package shtpraw_test
+
+import (
+	"testing"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport/sim"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+		want shtpraw.Header
+	}{
+		{"empty", []byte{0x00, 0x00, 0x00, 0x00}, shtpraw.Header{}},
+		{"input", []byte{0x17, 0x00, 0x03, 0x2A}, shtpraw.Header{Length: 23, Channel: 3, Sequence: 42}},
+		{"long", []byte{0x14, 0x01, 0x00, 0x00}, shtpraw.Header{Length: 276}},
+		{"continuation", []byte{0x10, 0x80, 0x02, 0x07}, shtpraw.Header{Length: 16, Channel: 2, Sequence: 7, Continuation: true}},
+		{"continuation only", []byte{0x00, 0x80, 0x00, 0x00}, shtpraw.Header{Continuation: true}},
+		{"largest", []byte{0xFF, 0xFF, 0x05, 0xFF}, shtpraw.Header{Length: 0x7FFF, Channel: 5, Sequence: 255, Continuation: true}},
+	}
+	for _, tt := range tests {
+		if got := shtpraw.ParseHeader(tt.b); got != tt.want {
+			t.Errorf("%s: ParseHeader(% X) = %+v, want %+v", tt.name, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimPackets(t *testing.T) {
+	var link sim.Transport
+	link.Queue(shtpraw.ChannelControl, []byte{0xF8, 0x00})
+	link.Queue(shtpraw.ChannelInputNormal, []byte{0xFB, 0, 0, 0, 0})
+	link.Queue(shtpraw.ChannelControl, []byte{0xF1})
+
+	want := []shtpraw.Header{
+		{Length: 6, Channel: shtpraw.ChannelControl, Sequence: 0},
+		{Length: 9, Channel: shtpraw.ChannelInputNormal, Sequence: 0},
+		{Length: 5, Channel: shtpraw.ChannelControl, Sequence: 1},
+	}
+	for i, w := range want {
+		packet, err := link.Read()
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if packet.Header != w {
+			t.Errorf("packet %d: header %+v, want %+v", i, packet.Header, w)
+		}
+		if len(packet.Data) != int(w.Length) || len(packet.Cargo()) != int(w.Length)-shtpraw.HeaderLen {
+			t.Errorf("packet %d: %d bytes with %d of cargo, want %d", i, len(packet.Data), len(packet.Cargo()), w.Length)
+		}
+	}
+	if _, err := link.Read(); err != shtpraw.ErrNoData {
+		t.Errorf("Read on empty queue = %v, want ErrNoData", err)
+	}
+
+	link.Queue(shtpraw.ChannelControl, []byte{0xF8})
+	link.Reset()
+	if link.Pending() != 0 || link.Resets != 1 {
+		t.Errorf("after Reset: %d pending, %d resets", link.Pending(), link.Resets)
+	}
+	link.Queue(shtpraw.ChannelControl, []byte{0xF8})
+	if packet, _ := link.Read(); packet.Sequence != 0 {
+		t.Errorf("sequence after Reset = %d, want 0", packet.Sequence)
+	}
+}
+
+// scriptBus answers each read with the next of its replies.
+type scriptBus struct {
+	replies [][]byte
+}
+
+func (b *scriptBus) Tx(addr uint16, w, r []byte) error {
+	if len(r) > 0 && len(b.replies) > 0 {
+		copy(r, b.replies[0])
+		b.replies = b.replies[1:]
+	}
+	return nil
+}
+
+func TestReadPacketShortHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies [][]byte
+		want    error
+	}{
+		{"empty", [][]byte{{0x00, 0x00, 0x00, 0x00}}, shtpraw.ErrNoData},
+		{"short first header", [][]byte{{0x02, 0x00, 0x03, 0x00}}, shtpraw.ErrNoData},
+		{"short re-read", [][]byte{{0x09, 0x00, 0x03, 0x00}, {0x02, 0x00, 0x03, 0x00}}, shtpraw.ErrNoData},
+		{"empty re-read", [][]byte{{0x09, 0x00, 0x03, 0x00}, {0x00, 0x00, 0x00, 0x00}}, shtpraw.ErrNoData},
+		{"continuation", [][]byte{{0x09, 0x80, 0x03, 0x00}}, shtpraw.ErrContinuation},
+		{"packet", [][]byte{{0x09, 0x00, 0x03, 0x00}, {0x09, 0x00, 0x03, 0x00, 0xFB, 0, 0, 0, 0}}, nil},
+	}
+	for _, tt := range tests {
+		conn := shtpraw.New(&scriptBus{replies: tt.replies}, shtpraw.DefaultAddress)
+		packet, err := conn.ReadPacket()
+		if err != tt.want {
+			t.Errorf("%s: ReadPacket error %v, want %v", tt.name, err, tt.want)
+			continue
+		}
+		if err == nil && len(packet.Cargo()) != 5 {
+			t.Errorf("%s: %d bytes of cargo, want 5", tt.name, len(packet.Cargo()))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package slimevr encodes the tracker side of the SlimeVR UDP protocol,
+// which the SlimeVR server speaks to its trackers and passes on to
+// SteamVR as virtual trackers. It is the de facto protocol of DIY
+// full-body trackers.
+//
+// Every packet starts with a big-endian int32 packet type and an int64
+// packet number, which counts up so the server can drop stale and
+// reordered packets; floats and other integers are big-endian too. A
+// tracker broadcasts a handshake to Port until the server answers with a
+// packet that ReplyIsHandshake accepts, then sends a sensor info packet
+// for each of its sensors, rotations as they come and a heartbeat about
+// once a second.
+package slimevr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Port is the UDP port the SlimeVR server listens on.
+const Port = 6969
+
+// Packet types sent by a tracker
+const (
+	PacketHeartbeat    = 0
+	PacketHandshake    = 3
+	PacketSensorInfo   = 15
+	PacketRotationData = 17
+)
+
+// Hardware identifiers reported in the handshake and sensor info
+const (
+	BoardCustom   = 4
+	MCUUnknown    = 0
+	IMUBNO085     = 4
+	SensorStateOK = 1
+)
+
+// ProtocolVersion is the firmware build reported in the handshake; the
+// server expects a sensor info packet from builds after 8.
+const ProtocolVersion = 17
+
+// Rotation data types
+const dataTypeNormal = 1
+
+// Reply the server answers a handshake with, after the packet type byte
+var handshakeReply = []byte("Hey OVR =D 5")
+
+// Handshake describes the tracker to the server.
+type Handshake struct {
+	Board    int32
+	IMU      int32
+	MCU      int32
+	Firmware string  // Shown in the server, at most 255 bytes
+	MAC      [6]byte // Identifies the tracker across reconnections
+}
+
+// AppendHandshake appends a handshake packet to dst. Its packet number is
+// always 0.
+func AppendHandshake(dst []byte, h *Handshake) []byte {
+	dst = appendHeader(dst, PacketHandshake, 0)
+	for _, v := range [...]int32{h.Board, h.IMU, h.MCU, 0, 0, 0, ProtocolVersion} {
+		dst = binary.BigEndian.AppendUint32(dst, uint32(v))
+	}
+	fw := h.Firmware
+	if len(fw) > 255 {
+		fw = fw[:255]
+	}
+	dst = append(dst, byte(len(fw)))
+	dst = append(dst, fw...)
+	return append(dst, h.MAC[:]...)
+}
+
+// ReplyIsHandshake reports whether a packet received from the server
+// accepts the handshake.
+func ReplyIsHandshake(b []byte) bool {
+	return len(b) > len(handshakeReply) && b[0] == PacketHandshake && bytes.HasPrefix(b[1:], handshakeReply)
+}
+
+// AppendHeartbeat appends a heartbeat packet to dst.
+func AppendHeartbeat(dst []byte, number uint64) []byte {
+	return appendHeader(dst, PacketHeartbeat, number)
+}
+
+// AppendSensorInfo appends a sensor info packet announcing sensor id of
+// the given IMU type to dst.
+func AppendSensorInfo(dst []byte, number uint64, id uint8, imu uint8) []byte {
+	dst = appendHeader(dst, PacketSensorInfo, number)
+	return append(dst, id, SensorStateOK, imu)
+}
+
+// AppendRotation appends a rotation packet for sensor id to dst. accuracy
+// is the calibration status, 0 (unreliable) to 3 (high).
+func AppendRotation(dst []byte, number uint64, id uint8, q bno08x.Quaternion, accuracy uint8) []byte {
+	dst = appendHeader(dst, PacketRotationData, number)
+	dst = append(dst, id, dataTypeNormal)
+	for _, v := range [...]float32{q.I, q.J, q.K, q.Real} {
+		dst = binary.BigEndian.AppendUint32(dst, math.Float32bits(v))
+	}
+	return append(dst, accuracy)
+}
+
+func appendHeader(dst []byte, packetType int32, number uint64) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(packetType))
+	return binary.BigEndian.AppendUint64(dst, number)
+}
@@ -0,0 +1,182 @@
+// Package statusled shows what a program is doing on the board's LEDs,
+// with the same patterns in every program, so a headless board can be
+// read at a glance:
+//
+//	BusError     red, blinking fast   the sensor cannot be reached
+//	Calibrating  yellow, blinking     running, accuracy still low
+//	Streaming    green, steady        running with good data
+//
+// Both the board's plain LED and its WS2812, where the board has them,
+// show the state; a plain LED shows only the blinking, which tells the
+// states apart as well.
+//
+//	led := statusled.New()
+//	led.Set(statusled.Calibrating)
+//	for {
+//		// ... read the sensor, led.Set as things change ...
+//		led.Update(time.Now())
+//	}
+package statusled
+
+import (
+	"image/color"
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"tinygo.org/x/drivers/bno08x"
+	"tinygo.org/x/drivers/ws2812"
+)
+
+// State is what the LEDs show.
+type State uint8
+
+const (
+	Off State = iota
+	BusError
+	Calibrating
+	Streaming
+	numStates
+)
+
+func (s State) String() string {
+	switch s {
+	case BusError:
+		return "bus error"
+	case Calibrating:
+		return "calibrating"
+	case Streaming:
+		return "streaming"
+	}
+	return "off"
+}
+
+// Brightness of the WS2812, kept low so it reads at a glance without
+// lighting up the room
+const level = 32
+
+// pattern is how a state blinks. A zero period is steady.
+type pattern struct {
+	colour color.RGBA
+	period time.Duration
+	on     time.Duration
+}
+
+var patterns = [numStates]pattern{
+	Off:         {},
+	BusError:    {color.RGBA{R: level}, 250 * time.Millisecond, 125 * time.Millisecond},
+	Calibrating: {color.RGBA{R: level, G: level}, time.Second, 500 * time.Millisecond},
+	Streaming:   {color.RGBA{G: level}, 0, 0},
+}
+
+// ForAccuracy returns the state for an SH-2 report accuracy, 0 to 3:
+// Calibrating below medium, Streaming from medium up.
+func ForAccuracy(accuracy uint8) State {
+	if accuracy < 2 {
+		return Calibrating
+	}
+	return Streaming
+}
+
+// CalibratedAccuracy is the heading accuracy estimate, in radians, at or
+// below which Event counts a rotation vector as calibrated.
+const CalibratedAccuracy = 0.2
+
+// LED drives the status LEDs.
+type LED struct {
+	pin    machine.Pin
+	neo    ws2812.Device
+	hasNeo bool
+	pixel  [1]color.RGBA
+	state  State
+	since  time.Time
+	lit    bool
+	shown  bool // Whether the LEDs show lit yet
+}
+
+// New returns an LED on the board's plain LED and WS2812, with the LEDs
+// off. Either may be missing; with neither, Set and Update do nothing.
+func New() *LED {
+	l := &LED{pin: board.LEDPin()}
+	if l.pin != machine.NoPin {
+		l.pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	}
+	if pin := board.NeoPixelPin(); pin != machine.NoPin {
+		pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		l.neo, l.hasNeo = ws2812.New(pin), true
+	}
+	l.write(false)
+	return l
+}
+
+// State returns the state shown.
+func (l *LED) State() State {
+	return l.state
+}
+
+// Set changes the state shown, restarting its pattern. Setting the state
+// already shown does nothing, so it can be called for every report.
+func (l *LED) Set(s State) {
+	if s == l.state || s >= numStates {
+		return
+	}
+	l.state, l.since, l.shown = s, time.Now(), false
+	l.Update(l.since)
+}
+
+// Event sets the state from an event, for programs that stream what the
+// sensor sends. A rotation vector shows Calibrating until its heading
+// accuracy is within CalibratedAccuracy and Streaming from then on; any
+// other event shows Streaming if nothing has been shown yet.
+func (l *LED) Event(event *bno08x.SensorValue) {
+	switch event.ID() {
+	case bno08x.SensorRotationVector, bno08x.SensorGeomagneticRotationVector:
+		if event.QuaternionAccuracy() <= CalibratedAccuracy {
+			l.Set(Streaming)
+		} else {
+			l.Set(Calibrating)
+		}
+	default:
+		if l.state == Off {
+			l.Set(Streaming)
+		}
+	}
+}
+
+// Update moves the pattern on to now. Call it at least every few tens of
+// milliseconds for the blinking to look even.
+func (l *LED) Update(now time.Time) {
+	p := patterns[l.state]
+	lit := l.state != Off
+	if p.period > 0 {
+		lit = now.Sub(l.since)%p.period < p.on
+	}
+	if l.shown && lit == l.lit {
+		return
+	}
+	l.lit, l.shown = lit, true
+	l.write(lit)
+}
+
+// Halt shows s for good, for a program that cannot go on. It never
+// returns.
+func (l *LED) Halt(s State) {
+	l.Set(s)
+	for {
+		l.Update(time.Now())
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (l *LED) write(lit bool) {
+	if l.pin != machine.NoPin {
+		l.pin.Set(lit)
+	}
+	if l.hasNeo {
+		l.pixel[0] = color.RGBA{}
+		if lit {
+			l.pixel[0] = patterns[l.state].colour
+		}
+		l.neo.WriteColors(l.pixel[:])
+	}
+}
@@ -0,0 +1,167 @@
+// Package store keeps one small settings record per program in the last
+// erase block of the microcontroller's flash, so configuration survives
+// resets and power cycles. Records carry the program name and a CRC, so a
+// record left by another program or torn by a power cut during Save reads
+// back as missing rather than as garbage.
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// headerLen is the size of the record header: magic, name, payload
+// length and CRC-32 of the payload.
+const headerLen = 4 + maxNameLen + 2 + 4
+
+// maxNameLen bounds the program name stored in the header.
+const maxNameLen = 16
+
+// MaxSize is the largest record Save accepts.
+const MaxSize = 1024
+
+// maxWriteBlock is the largest flash write block Save pads a record to,
+// the RP2040's 256-byte page.
+const maxWriteBlock = 256
+
+// bufLen holds the largest record with its header once padded.
+const bufLen = (headerLen + MaxSize + maxWriteBlock - 1) / maxWriteBlock * maxWriteBlock
+
+const magic = "BNOS"
+
+var (
+	ErrEmpty    = errors.New("store: no record saved")
+	ErrOther    = errors.New("store: record belongs to another program")
+	ErrCorrupt  = errors.New("store: record failed its CRC check")
+	ErrTooLarge = errors.New("store: record too large")
+	ErrNoFlash  = errors.New("store: no flash available for a record")
+)
+
+// BlockDevice is the subset of machine.Flash used by Store.
+type BlockDevice interface {
+	ReadAt(p []byte, off int64) (int, error)
+	WriteAt(p []byte, off int64) (int, error)
+	Size() int64
+	WriteBlockSize() int64
+	EraseBlockSize() int64
+	EraseBlocks(start, len int64) error
+}
+
+// Store reads and writes the record of one program.
+type Store struct {
+	dev  BlockDevice
+	name string
+	buf  [bufLen]byte
+}
+
+// New returns a store for the program called name on dev, usually
+// machine.Flash. Names longer than 16 bytes are truncated.
+func New(dev BlockDevice, name string) *Store {
+	if len(name) > maxNameLen {
+		name = name[:maxNameLen]
+	}
+	return &Store{dev: dev, name: name}
+}
+
+// block returns the index of the erase block holding the record.
+func (s *Store) block() (int64, error) {
+	size := s.dev.EraseBlockSize()
+	if size < headerLen+MaxSize || s.dev.Size() < size {
+		return 0, ErrNoFlash
+	}
+	return s.dev.Size()/size - 1, nil
+}
+
+// Load copies the saved record into p and returns its length. It returns
+// ErrEmpty if nothing has been saved, ErrOther if the record belongs to
+// another program and ErrCorrupt if it did not survive intact.
+func (s *Store) Load(p []byte) (int, error) {
+	block, err := s.block()
+	if err != nil {
+		return 0, err
+	}
+	header := s.buf[:headerLen]
+	if _, err := s.dev.ReadAt(header, block*s.dev.EraseBlockSize()); err != nil {
+		return 0, err
+	}
+	if string(header[:4]) != magic {
+		return 0, ErrEmpty
+	}
+	if !s.owns(header[4 : 4+maxNameLen]) {
+		return 0, ErrOther
+	}
+	n := int(binary.LittleEndian.Uint16(header[4+maxNameLen:]))
+	sum := binary.LittleEndian.Uint32(header[4+maxNameLen+2:])
+	if n > MaxSize {
+		return 0, ErrCorrupt
+	}
+	data := s.buf[headerLen : headerLen+n]
+	if _, err := s.dev.ReadAt(data, block*s.dev.EraseBlockSize()+headerLen); err != nil {
+		return 0, err
+	}
+	if crc32.ChecksumIEEE(data) != sum {
+		return 0, ErrCorrupt
+	}
+	if len(p) < n {
+		return 0, ErrTooLarge
+	}
+	return copy(p, data), nil
+}
+
+// Save replaces the record with data.
+func (s *Store) Save(data []byte) error {
+	if len(data) > MaxSize {
+		return ErrTooLarge
+	}
+	block, err := s.block()
+	if err != nil {
+		return err
+	}
+
+	// Pad to whole write blocks with the erased value, checking the
+	// padded record fits before erasing the old one
+	n := headerLen + len(data)
+	if ws := int(s.dev.WriteBlockSize()); ws > 1 && n%ws != 0 {
+		n += ws - n%ws
+	}
+	if n > len(s.buf) {
+		return ErrTooLarge
+	}
+	if err := s.dev.EraseBlocks(block, 1); err != nil {
+		return err
+	}
+	record := s.buf[:n]
+	for i := range record {
+		record[i] = 0xFF
+	}
+	copy(record, magic)
+	name := record[4 : 4+maxNameLen]
+	for i := range name {
+		name[i] = 0
+	}
+	copy(name, s.name)
+	binary.LittleEndian.PutUint16(record[4+maxNameLen:], uint16(len(data)))
+	binary.LittleEndian.PutUint32(record[4+maxNameLen+2:], crc32.ChecksumIEEE(data))
+	copy(record[headerLen:], data)
+	_, err = s.dev.WriteAt(record, block*s.dev.EraseBlockSize())
+	return err
+}
+
+// Clear erases the record, whichever program it belongs to.
+func (s *Store) Clear() error {
+	block, err := s.block()
+	if err != nil {
+		return err
+	}
+	return s.dev.EraseBlocks(block, 1)
+}
+
+// owns reports whether a stored name field matches the store's name.
+func (s *Store) owns(field []byte) bool {
+	n := 0
+	for n < len(field) && field[n] != 0 {
+		n++
+	}
+	return string(field[:n]) == s.name
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+// flash is an in-memory BlockDevice.
+type flash struct {
+	data       []byte
+	writeBlock int64
+	eraseBlock int64
+}
+
+func newFlash(blocks int, writeBlock, eraseBlock int64) *flash {
+	f := &flash{data: make([]byte, int64(blocks)*eraseBlock), writeBlock: writeBlock, eraseBlock: eraseBlock}
+	for i := range f.data {
+		f.data[i] = 0xFF
+	}
+	return f
+}
+
+func (f *flash) ReadAt(p []byte, off int64) (int, error)  { return copy(p, f.data[off:]), nil }
+func (f *flash) WriteAt(p []byte, off int64) (int, error) { return copy(f.data[off:], p), nil }
+func (f *flash) Size() int64                              { return int64(len(f.data)) }
+func (f *flash) WriteBlockSize() int64                    { return f.writeBlock }
+func (f *flash) EraseBlockSize() int64                    { return f.eraseBlock }
+
+func (f *flash) EraseBlocks(start, n int64) error {
+	for i := start * f.eraseBlock; i < (start+n)*f.eraseBlock; i++ {
+		f.data[i] = 0xFF
+	}
+	return nil
+}
+
+func TestSaveLoad(t *testing.T) {
+	for _, writeBlock := range []int64{1, 4, 256} {
+		dev := newFlash(4, writeBlock, 4096)
+		s := New(dev, "store_test")
+		var p [MaxSize]byte
+		if _, err := s.Load(p[:]); err != ErrEmpty {
+			t.Errorf("write block %d: Load before Save = %v, want ErrEmpty", writeBlock, err)
+		}
+
+		// Up to the largest record, which pads past headerLen+MaxSize
+		for _, size := range []int{0, 1, 200, MaxSize - 1, MaxSize} {
+			data := make([]byte, size)
+			for i := range data {
+				data[i] = byte(i*7 + size)
+			}
+			if err := s.Save(data); err != nil {
+				t.Fatalf("write block %d: Save %d bytes: %v", writeBlock, size, err)
+			}
+			n, err := s.Load(p[:])
+			if err != nil || !bytes.Equal(p[:n], data) {
+				t.Errorf("write block %d: Load after saving %d bytes = %d, %v", writeBlock, size, n, err)
+			}
+		}
+	}
+}
+
+func TestSaveErrors(t *testing.T) {
+	dev := newFlash(2, 4, 4096)
+	s := New(dev, "store_test")
+	if err := s.Save([]byte("kept")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(make([]byte, MaxSize+1)); err != ErrTooLarge {
+		t.Errorf("Save %d bytes = %v, want ErrTooLarge", MaxSize+1, err)
+	}
+
+	// A write block too big to pad into the buffer is refused before the
+	// old record is erased
+	dev.writeBlock = 2048
+	if err := s.Save(make([]byte, MaxSize)); err != ErrTooLarge {
+		t.Errorf("Save with 2048-byte write blocks = %v, want ErrTooLarge", err)
+	}
+	var p [8]byte
+	if n, err := s.Load(p[:]); err != nil || string(p[:n]) != "kept" {
+		t.Errorf("Load after refused Save = %q, %v", p[:n], err)
+	}
+
+	if _, err := New(dev, "other").Load(p[:]); err != ErrOther {
+		t.Errorf("Load by another program = %v, want ErrOther", err)
+	}
+	dev.data[dev.Size()-dev.eraseBlock+headerLen] ^= 0xFF
+	if _, err := s.Load(p[:]); err != ErrCorrupt {
+		t.Errorf("Load of damaged record = %v, want ErrCorrupt", err)
+	}
+	if err := s.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load(p[:]); err != ErrEmpty {
+		t.Errorf("Load after Clear = %v, want ErrEmpty", err)
+	}
+}
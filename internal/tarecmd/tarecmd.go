@@ -0,0 +1,94 @@
+// Package tarecmd builds the SH-2 Tare commands, which move the zero of
+// the sensor's rotation vectors inside the sensor hub rather than on the
+// host, so every output and every program sees the tared orientation.
+//
+// Tare Now takes the current orientation as the new zero, for all axes
+// or only some; a heading-only tare keeps tilt relative to gravity. Set
+// Reorientation gives the rotation to apply directly. Either lasts until
+// the sensor resets unless Persist Tare then stores it in flash.
+//
+// None of the Tare commands is answered with a Command Response.
+package tarecmd
+
+import (
+	"encoding/binary"
+
+	"github.com/intermernet/bno08xPrograms/internal/sh2cmd"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Tare subcommands
+const (
+	subTareNow          = 0x00
+	subPersistTare      = 0x01
+	subSetReorientation = 0x02
+)
+
+// Axes selects the axes a Tare Now zeroes.
+type Axes uint8
+
+const (
+	AxisX       Axes = 1 << 0
+	AxisY       Axes = 1 << 1
+	AxisZ       Axes = 1 << 2
+	AllAxes          = AxisX | AxisY | AxisZ
+	HeadingOnly      = AxisZ
+)
+
+// Basis is the rotation vector whose current orientation Tare Now takes.
+type Basis uint8
+
+const (
+	BasisRotationVector            Basis = 0
+	BasisGameRotationVector        Basis = 1
+	BasisGeomagneticRotationVector Basis = 2
+	BasisGyroIntegratedRV          Basis = 3
+	BasisARVRRotationVector        Basis = 4
+	BasisARVRGameRotationVector    Basis = 5
+)
+
+// AppendTareNow appends a Tare Now request to b, zeroing axes at the
+// current orientation of basis.
+func AppendTareNow(b []byte, seq uint8, axes Axes, basis Basis) []byte {
+	return sh2cmd.AppendRequest(b, seq, sh2cmd.CommandTare, subTareNow, byte(axes), byte(basis))
+}
+
+// AppendPersistTare appends a Persist Tare request to b, storing the
+// current tare in flash so it survives a reset.
+func AppendPersistTare(b []byte, seq uint8) []byte {
+	return sh2cmd.AppendRequest(b, seq, sh2cmd.CommandTare, subPersistTare)
+}
+
+// AppendSetReorientation appends a Set Reorientation request to b,
+// replacing the tare with the rotation q.
+func AppendSetReorientation(b []byte, seq uint8, q bno08x.Quaternion) []byte {
+	var p [9]byte
+	p[0] = subSetReorientation
+	for i, v := range [...]float32{q.I, q.J, q.K, q.Real} {
+		binary.LittleEndian.PutUint16(p[1+2*i:], uint16(toQ14(v)))
+	}
+	return sh2cmd.AppendRequest(b, seq, sh2cmd.CommandTare, p[:]...)
+}
+
+// AppendClearTare appends a request to b that removes the tare: a Set
+// Reorientation of all zeros, which the hub takes as none. Follow it with
+// Persist Tare to clear the stored tare too.
+func AppendClearTare(b []byte, seq uint8) []byte {
+	return sh2cmd.AppendRequest(b, seq, sh2cmd.CommandTare, subSetReorientation)
+}
+
+// toQ14 converts a quaternion component to fixed point with 14 fractional
+// bits, saturating outside ±2.
+func toQ14(v float32) int16 {
+	f := v * (1 << 14)
+	switch {
+	case f >= 32767:
+		return 32767
+	case f <= -32768:
+		return -32768
+	}
+	if f < 0 {
+		return int16(f - 0.5)
+	}
+	return int16(f + 0.5)
+}
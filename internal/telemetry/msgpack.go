@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// MessagePack type bytes used by MsgPack.
+const (
+	mpFixArray = 0x90
+	mpFloat32  = 0xCA
+	mpUint8    = 0xCC
+	mpUint16   = 0xCD
+	mpUint32   = 0xCE
+	mpUint64   = 0xCF
+	mpInt8     = 0xD0
+	mpInt16    = 0xD1
+	mpInt32    = 0xD2
+	mpInt64    = 0xD3
+)
+
+// MaxMsgPackLen bounds the length of a message: the longest, an
+// uncalibrated vector, is an array header, two integers of up to 9 bytes
+// and six float32 values.
+const MaxMsgPackLen = 1 + 2*9 + 6*5
+
+// MsgPack writes events as MessagePack arrays, for LoRa and BLE links
+// where a JSON line costs too many bytes but the reader should not need
+// the frame layout. Each event is one self-delimiting array, so a reader
+// decodes the stream, or one event per packet, with any MessagePack
+// library:
+//
+//	[id, t, values...]
+//
+// t is microseconds since the encoder was created. The values are those
+// of sensorinfo.Values in order, the keys of internal/jsonout: x, y, z for
+// vectors, then bx, by, bz for the uncalibrated ones; i, j, k, real for
+// rotation vectors, then the heading accuracy in radians for those that
+// report it; x, y, z and the sensor timestamp for raw sensors; one value
+// for single-value sensors and detectors, two for the step counter
+// (count, latency) and the activity classifier (state, confidence). Measurements are float32 and
+// counts integers in their shortest form, so a game rotation vector takes
+// 27 bytes against about 70 as a JSON line. Sensors without values, such
+// as significant motion, are sent as [id, t].
+type MsgPack struct {
+	w      io.Writer
+	start  time.Time
+	buf    [MaxMsgPackLen]byte
+	msg    []byte
+	n      uint8 // Values in msg
+	values sensorinfo.Values
+
+	// Messages counts messages written and Errors failed writes.
+	Messages uint32
+	Errors   uint32
+}
+
+// NewMsgPack returns a MessagePack encoder writing to w. Timestamps count
+// from now.
+func NewMsgPack(w io.Writer) *MsgPack {
+	return &MsgPack{w: w, start: time.Now()}
+}
+
+// Encode writes the message for event.
+func (m *MsgPack) Encode(event *bno08x.SensorValue) error {
+	b := m.Append(m.buf[:0], event)
+	if _, err := m.w.Write(b); err != nil {
+		m.Errors++
+		return err
+	}
+	m.Messages++
+	return nil
+}
+
+// Send encodes event, counting rather than returning write errors, so a
+// MsgPack is an output.Sink.
+func (m *MsgPack) Send(event *bno08x.SensorValue) {
+	m.Encode(event)
+}
+
+// Append appends the message for event to dst, for links that send one
+// packet per event rather than a stream.
+func (m *MsgPack) Append(dst []byte, event *bno08x.SensorValue) []byte {
+	m.values.Read(event)
+	return m.appendValues(dst, uint8(event.ID()), uint64(time.Since(m.start).Microseconds()), m.values.Slice())
+}
+
+// appendValues appends the message [id, t, values...] to dst.
+func (m *MsgPack) appendValues(dst []byte, id uint8, t uint64, values []sensorinfo.Value) []byte {
+	// The array length is patched in once the values are known
+	start := len(dst)
+	m.msg, m.n = append(dst, mpFixArray), 0
+	m.unsigned(uint64(id))
+	m.unsigned(t)
+	for _, v := range values {
+		if v.Integer {
+			m.msg = appendMsgPackInt(m.msg, v.Int)
+		} else {
+			m.msg = append(m.msg, mpFloat32)
+			m.msg = binary.BigEndian.AppendUint32(m.msg, math.Float32bits(v.Float))
+		}
+		m.n++
+	}
+
+	m.msg[start] = mpFixArray | m.n
+	return m.msg
+}
+
+func (m *MsgPack) unsigned(v uint64) {
+	m.msg = appendMsgPackUint(m.msg, v)
+	m.n++
+}
+
+// appendMsgPackUint appends v in the shortest unsigned form.
+func appendMsgPackUint(dst []byte, v uint64) []byte {
+	switch {
+	case v < 0x80:
+		return append(dst, uint8(v))
+	case v <= math.MaxUint8:
+		return append(dst, mpUint8, uint8(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, mpUint16), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, mpUint32), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, mpUint64), v)
+}
+
+// appendMsgPackInt appends v in the shortest form, unsigned if it is not
+// negative.
+func appendMsgPackInt(dst []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgPackUint(dst, uint64(v))
+	case v >= -32:
+		// Negative fixint
+		return append(dst, uint8(v))
+	case v >= math.MinInt8:
+		return append(dst, mpInt8, uint8(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(dst, mpInt16), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(dst, mpInt32), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, mpInt64), uint64(v))
+}
@@ -0,0 +1,169 @@
+package telemetry
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/vmihailenco/msgpack/v5"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+func floats(keys string, values ...float32) []sensorinfo.Value {
+	v := make([]sensorinfo.Value, len(values))
+	for i, f := range values {
+		v[i] = sensorinfo.Value{Key: keys[i : i+1], Float: f}
+	}
+	return v
+}
+
+func ints(values ...int64) []sensorinfo.Value {
+	v := make([]sensorinfo.Value, len(values))
+	for i, n := range values {
+		v[i] = sensorinfo.Value{Int: n, Integer: true}
+	}
+	return v
+}
+
+// decode reads one message with the reference decoder, returning each
+// element as int64, uint64 or float64.
+func decode(t *testing.T, b []byte) []interface{} {
+	t.Helper()
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		t.Fatalf("array length: %v", err)
+	}
+	out := make([]interface{}, n)
+	for i := range out {
+		if out[i], err = dec.DecodeInterfaceLoose(); err != nil {
+			t.Fatalf("element %d: %v", i, err)
+		}
+	}
+	if _, err := dec.DecodeInterfaceLoose(); err == nil {
+		t.Errorf("bytes left after the array in % x", b)
+	}
+	return out
+}
+
+// number returns a decoded element as float64, whatever its type.
+func number(v interface{}) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return math.NaN()
+}
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     bno08x.SensorID
+		values []sensorinfo.Value
+	}{
+		{"vector", bno08x.SensorAccelerometer, floats("xyz", 0.012, -0.034, 9.807)},
+		{"uncalibrated", bno08x.SensorGyroscopeUncalibrated, floats("xyzabc", 0.1, -0.2, 0.3, 0.001, -0.002, 0.003)},
+		{"rotation vector", bno08x.SensorRotationVector, floats("ijkra", 0.01, 0.02, 0.707, 0.707, 0.05)},
+		{"game rotation vector", bno08x.SensorGameRotationVector, floats("ijkr", 0, 0, 0, 1)},
+		{"raw", bno08x.SensorRawAccelerometer, ints(-1200, 33, -33, 0x12345678)},
+		{"single value", bno08x.SensorPressure, floats("v", 1013.25)},
+		{"step counter", bno08x.SensorStepCounter, ints(0x7F, 0x100)},
+		{"activity", bno08x.SensorPersonalActivityClassifier, ints(4, 0x80)},
+		{"no value", bno08x.SensorSignificantMotion, nil},
+	}
+	m := NewMsgPack(nil)
+	for _, tt := range tests {
+		// A prefix checks the array header is patched where the message
+		// starts, not at the start of dst
+		prefix := []byte{0xAA, 0xBB}
+		b := m.appendValues(append([]byte(nil), prefix...), uint8(tt.id), 123456, tt.values)
+		if !bytes.Equal(b[:2], prefix) {
+			t.Fatalf("%s: prefix overwritten: % x", tt.name, b[:2])
+		}
+		b = b[2:]
+		if want := byte(mpFixArray | (2 + len(tt.values))); b[0] != want {
+			t.Errorf("%s: array header %#02x, want %#02x", tt.name, b[0], want)
+		}
+		if len(b) > MaxMsgPackLen {
+			t.Errorf("%s: %d bytes, longer than MaxMsgPackLen", tt.name, len(b))
+		}
+
+		got := decode(t, b)
+		if len(got) != 2+len(tt.values) {
+			t.Fatalf("%s: %d elements, want %d", tt.name, len(got), 2+len(tt.values))
+		}
+		if number(got[0]) != float64(tt.id) || number(got[1]) != 123456 {
+			t.Errorf("%s: id and t = %v %v", tt.name, got[0], got[1])
+		}
+		for i, v := range tt.values {
+			want := float64(v.Float)
+			if v.Integer {
+				want = float64(v.Int)
+			} else if _, ok := got[2+i].(float64); !ok {
+				t.Errorf("%s: value %d decoded as %T, want a float", tt.name, i, got[2+i])
+			}
+			if number(got[2+i]) != want {
+				t.Errorf("%s: value %d = %v, want %v", tt.name, i, got[2+i], want)
+			}
+		}
+	}
+}
+
+func TestMsgPackAppendEvent(t *testing.T) {
+	// An event the encoder has no values for is sent as [id, t]
+	m := NewMsgPack(nil)
+	b := m.Append([]byte{0x01}, &bno08x.SensorValue{})
+	if b[0] != 0x01 || b[1] != mpFixArray|2 {
+		t.Fatalf("message % x", b)
+	}
+	if got := decode(t, b[1:]); len(got) != 2 || number(got[0]) != 0 {
+		t.Errorf("decoded %v, want [0 t]", got)
+	}
+}
+
+func TestMsgPackShortestInts(t *testing.T) {
+	tests := []struct {
+		v    int64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{mpUint8, 0x80}},
+		{0xFF, []byte{mpUint8, 0xFF}},
+		{0x100, []byte{mpUint16, 0x01, 0x00}},
+		{0xFFFF, []byte{mpUint16, 0xFF, 0xFF}},
+		{0x10000, []byte{mpUint32, 0x00, 0x01, 0x00, 0x00}},
+		{0xFFFFFFFF, []byte{mpUint32, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{0x100000000, []byte{mpUint64, 0, 0, 0, 1, 0, 0, 0, 0}},
+		{-1, []byte{0xFF}},
+		{-32, []byte{0xE0}},
+		{-33, []byte{mpInt8, 0xDF}},
+		{-128, []byte{mpInt8, 0x80}},
+		{-129, []byte{mpInt16, 0xFF, 0x7F}},
+		{-32768, []byte{mpInt16, 0x80, 0x00}},
+		{-32769, []byte{mpInt32, 0xFF, 0xFF, 0x7F, 0xFF}},
+		{math.MinInt32, []byte{mpInt32, 0x80, 0, 0, 0}},
+		{math.MinInt32 - 1, []byte{mpInt64, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F, 0xFF, 0xFF, 0xFF}},
+	}
+	for _, tt := range tests {
+		b := appendMsgPackInt(nil, tt.v)
+		if !bytes.Equal(b, tt.want) {
+			t.Errorf("%d: % x, want % x", tt.v, b, tt.want)
+			continue
+		}
+		var got int64
+		if err := msgpack.Unmarshal(b, &got); err != nil || got != tt.v {
+			t.Errorf("%d: decoded %d, %v", tt.v, got, err)
+		}
+	}
+
+	var got uint64
+	if err := msgpack.Unmarshal(appendMsgPackUint(nil, math.MaxUint64), &got); err != nil || got != math.MaxUint64 {
+		t.Errorf("MaxUint64: decoded %d, %v", got, err)
+	}
+}
@@ -0,0 +1,119 @@
+// Package telemetry streams sensor events as binary frames, which keep up
+// at report rates where printing text drops samples. The frames and the
+// session header are built by pkg/protocol, which documents the layout,
+// so host tools decode the stream with protocol.Parser.
+//
+// A sample payload is the event's accuracy status, 0 unreliable to 3
+// high, followed by up to four float32 values.
+// Vectors carry x, y, z; rotation vectors i, j, k, real; uncalibrated
+// sensors their uncalibrated x, y, z without the bias; raw sensors their
+// ADC counts; single-value sensors and detectors one value, the step
+// counter its count and latency.
+//
+// MsgPack encodes events as MessagePack arrays instead, for radio links
+// whose readers would rather use a MessagePack library than the frame
+// layout.
+package telemetry
+
+import (
+	"io"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/pkg/protocol"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Encoder writes frames to w.
+type Encoder struct {
+	w        io.Writer
+	start    time.Time
+	sequence uint8
+	frame    [protocol.MaxFrameLen]byte
+	payload  [1 + 4*4]byte
+	values   sensorinfo.Values
+
+	// Frames counts frames written and Errors failed writes.
+	Frames uint32
+	Errors uint32
+}
+
+// New returns an encoder writing to w. Timestamps count from now.
+func New(w io.Writer) *Encoder {
+	return &Encoder{w: w, start: time.Now()}
+}
+
+// Session writes the session header. Send it once before the first frame
+// so a host knows what produced the stream.
+func (e *Encoder) Session(program, board, revision string) error {
+	// Keep the header within the frame buffer
+	fields := [...]string{program, board, revision}
+	for i, f := range fields {
+		if len(f) > 20 {
+			fields[i] = f[:20]
+		}
+	}
+	b := protocol.AppendSessionHeader(e.frame[:0], protocol.NewSessionHeader(fields[0], fields[1], fields[2]))
+	_, err := e.w.Write(b)
+	return err
+}
+
+// Encode writes a sample frame for event.
+func (e *Encoder) Encode(event *bno08x.SensorValue) error {
+	sample := protocol.Sample{Accuracy: event.Status() & 3}
+	e.values.Read(event)
+	for _, v := range e.values.Slice() {
+		// Bias, accuracy and the like do not fit in four values
+		if v.Extra || int(sample.N) == len(sample.Values) {
+			continue
+		}
+		f := v.Float
+		if v.Integer {
+			f = float32(v.Int)
+		}
+		sample.Values[sample.N] = f
+		sample.N++
+	}
+	p := protocol.AppendSample(e.payload[:0], sample)
+	return e.writeFrame(protocol.FrameSample, uint8(event.ID()), p)
+}
+
+// Send encodes event, counting rather than returning write errors, so an
+// Encoder is an output.Sink.
+func (e *Encoder) Send(event *bno08x.SensorValue) {
+	e.Encode(event)
+}
+
+// Text writes a status or log line as a text frame, the only way to print
+// once the binary stream has started.
+func (e *Encoder) Text(s string) error {
+	if len(s) > protocol.MaxPayload {
+		s = s[:protocol.MaxPayload]
+	}
+	return e.writeFrame(protocol.FrameText, 0, []byte(s))
+}
+
+func (e *Encoder) writeFrame(typ protocol.FrameType, sensor uint8, payload []byte) error {
+	b, err := protocol.AppendFrame(e.frame[:0], protocol.Frame{
+		Type:      typ,
+		Sensor:    sensor,
+		Sequence:  e.sequence,
+		Timestamp: uint32(time.Since(e.start).Microseconds()),
+		Payload:   payload,
+	})
+	if err != nil {
+		e.Errors++
+		return err
+	}
+	e.sequence++
+	return e.write(b)
+}
+
+func (e *Encoder) write(b []byte) error {
+	if _, err := e.w.Write(b); err != nil {
+		e.Errors++
+		return err
+	}
+	e.Frames++
+	return nil
+}
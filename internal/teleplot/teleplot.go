@@ -0,0 +1,79 @@
+// Package teleplot prints sensor values in the serial format of the
+// Teleplot VS Code extension, one named value per line, so live plots need
+// no host code:
+//
+//	>roll:12.3
+//	>accel_z:9.807
+//
+// Teleplot timestamps each value as it arrives and plots every name as a
+// series; other text on the console is shown in its log pane.
+package teleplot
+
+import (
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Writer prints values with a fixed number of decimal places.
+type Writer struct {
+	decimals int
+}
+
+// New returns a writer printing values with the given number of decimal
+// places.
+func New(decimals int) *Writer {
+	return &Writer{decimals: decimals}
+}
+
+// Value prints one value.
+func (w *Writer) Value(name string, v float32) {
+	println(">" + name + ":" + fmtutil.Float(v, w.decimals))
+}
+
+// Euler prints roll, pitch and yaw in degrees.
+func (w *Writer) Euler(roll, pitch, yaw float32) {
+	w.Value("roll", roll)
+	w.Value("pitch", pitch)
+	w.Value("yaw", yaw)
+}
+
+// Quaternion prints the components of q as q_i, q_j, q_k and q_real.
+func (w *Writer) Quaternion(q bno08x.Quaternion) {
+	w.Value("q_i", q.I)
+	w.Value("q_j", q.J)
+	w.Value("q_k", q.K)
+	w.Value("q_real", q.Real)
+}
+
+// Vector prints the axes of v as name_x, name_y and name_z.
+func (w *Writer) Vector(name string, v bno08x.Vector3) {
+	w.Value(name+"_x", v.X)
+	w.Value(name+"_y", v.Y)
+	w.Value(name+"_z", v.Z)
+}
+
+// Send prints the values of event: Euler angles and quaternion components
+// for the rotation vectors and the axes of the vector sensors, named
+// accel, gyro, mag, linear and gravity. Other sensors are skipped. It
+// fits output.Sink.
+func (w *Writer) Send(event *bno08x.SensorValue) {
+	switch event.ID() {
+	case bno08x.SensorRotationVector, bno08x.SensorGameRotationVector, bno08x.SensorGeomagneticRotationVector:
+		q := event.Quaternion()
+		roll, pitch, yaw := quat.ToEuler(q)
+		w.Euler(units.RadiansToDegrees(roll), units.RadiansToDegrees(pitch), units.RadiansToDegrees(yaw))
+		w.Quaternion(q)
+	case bno08x.SensorAccelerometer:
+		w.Vector("accel", event.Accelerometer())
+	case bno08x.SensorGyroscope:
+		w.Vector("gyro", event.Gyroscope())
+	case bno08x.SensorMagneticField:
+		w.Vector("mag", event.MagneticField())
+	case bno08x.SensorLinearAcceleration:
+		w.Vector("linear", event.LinearAcceleration())
+	case bno08x.SensorGravity:
+		w.Vector("gravity", event.Gravity())
+	}
+}
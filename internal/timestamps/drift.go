@@ -0,0 +1,58 @@
+package timestamps
+
+// DriftEstimator fits a straight line through pairs of MCU and sensor
+// timestamps and reports the slope as a clock rate difference. Individual
+// pairs carry the jitter of reading the bus, but over minutes to hours
+// the least-squares fit averages it away.
+type DriftEstimator struct {
+	n              int
+	host0, sensor0 int64
+	meanX, meanY   float64
+	cxx, cxy       float64
+	lastX          float64
+}
+
+// Add records a sensor timestamp and the MCU time it was received, both
+// in microseconds.
+func (d *DriftEstimator) Add(host, sensor int64) {
+	if d.n == 0 {
+		d.host0, d.sensor0 = host, sensor
+	}
+	// Offsets from the first pair keep the sums small and precise
+	x := float64(host - d.host0)
+	y := float64(sensor - d.sensor0)
+
+	// Welford's online update of the means and co-moments
+	d.n++
+	dx := x - d.meanX
+	d.meanX += dx / float64(d.n)
+	d.meanY += (y - d.meanY) / float64(d.n)
+	d.cxx += dx * (x - d.meanX)
+	d.cxy += dx * (y - d.meanY)
+	d.lastX = x
+}
+
+// Samples returns the number of pairs recorded.
+func (d *DriftEstimator) Samples() int {
+	return d.n
+}
+
+// SpanMicros returns the MCU time covered by the recorded pairs.
+func (d *DriftEstimator) SpanMicros() int64 {
+	return int64(d.lastX)
+}
+
+// PPM returns the estimated drift of the sensor clock relative to the MCU
+// in parts per million, positive when the sensor runs fast. It returns 0
+// until at least two pairs at different times have been added.
+func (d *DriftEstimator) PPM() float64 {
+	if d.n < 2 || d.cxx == 0 {
+		return 0
+	}
+	return (d.cxy/d.cxx - 1) * 1e6
+}
+
+// Reset discards all pairs.
+func (d *DriftEstimator) Reset() {
+	*d = DriftEstimator{}
+}
@@ -0,0 +1,50 @@
+package timestamps
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Edge latches the MCU time of the INT falling edge that announced a
+// packet. The base timestamp at the start of every input cargo counts back
+// from that edge, so passing its time as the hostTime of
+// shtpraw.DecodeInput gives each sample its time on the MCU clock to
+// within the interrupt latency, where the time of the read would add the
+// whole wait for the bus.
+//
+// Call Start once, Mark from the INT pin interrupt handler, and Take after
+// each packet has been read.
+type Edge struct {
+	start time.Time
+	at    uint32 // Microseconds since start plus one; zero when no edge waits
+}
+
+// Start sets the timebase to zero now and forgets any edge.
+func (e *Edge) Start() {
+	e.start = time.Now()
+	atomic.StoreUint32(&e.at, 0)
+}
+
+// Now returns the MCU time in microseconds on the Edge's timebase.
+func (e *Edge) Now() int64 {
+	return time.Since(e.start).Microseconds()
+}
+
+// Mark records an edge now. It is safe to call from an interrupt handler.
+func (e *Edge) Mark() {
+	atomic.StoreUint32(&e.at, uint32(e.Now())+1)
+}
+
+// Take returns the time of the last edge and clears it, or the current
+// time and false if no edge was marked since the last Take; that happens
+// when INT stayed low from one packet to the next.
+func (e *Edge) Take() (int64, bool) {
+	now := e.Now()
+	at := atomic.SwapUint32(&e.at, 0)
+	if at == 0 {
+		return now, false
+	}
+	// Modular arithmetic keeps the age right across the 32-bit wrap
+	age := uint32(now) + 1 - at
+	return now - int64(age), true
+}
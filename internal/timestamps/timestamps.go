@@ -0,0 +1,61 @@
+// Package timestamps puts sensor sample times on the MCU clock.
+//
+// The BNO08x runs from its own oscillator, which differs from the MCU's by
+// tens of ppm. That is invisible in short captures but adds up to seconds
+// over a multi-hour log. DriftEstimator measures the rate difference and
+// Timebase applies it when mapping sensor timestamps to MCU time. Edge
+// gives raw SHTP programs the INT edge time each input cargo's timestamps
+// count from.
+package timestamps
+
+// Unwrapper extends the sensor's 32-bit microsecond counter, which wraps
+// about every 71.6 minutes, to 64 bits. Feed it timestamps in order.
+type Unwrapper struct {
+	last   uint32
+	high   int64
+	primed bool
+}
+
+// Unwrap returns t extended to 64 bits.
+func (u *Unwrapper) Unwrap(t uint32) int64 {
+	if u.primed && t < u.last {
+		u.high += 1 << 32
+	}
+	u.last, u.primed = t, true
+	return u.high + int64(t)
+}
+
+// Reset forgets the previous timestamp and wrap count.
+func (u *Unwrapper) Reset() {
+	*u = Unwrapper{}
+}
+
+// Timebase maps sensor timestamps in microseconds onto the MCU clock,
+// correcting for the relative drift between the two oscillators.
+type Timebase struct {
+	// DriftPPM is how fast the sensor clock runs relative to the MCU, in
+	// parts per million; positive means the sensor clock is fast. Measure
+	// it with DriftEstimator or the clockdrift program.
+	DriftPPM float64
+
+	hostRef   int64
+	sensorRef int64
+	synced    bool
+}
+
+// Sync pairs a sensor timestamp with the MCU time, in microseconds, at
+// which it was taken. Later mappings are made relative to this pair.
+func (t *Timebase) Sync(host, sensor int64) {
+	t.hostRef, t.sensorRef, t.synced = host, sensor, true
+}
+
+// Synced reports whether Sync has been called.
+func (t *Timebase) Synced() bool {
+	return t.synced
+}
+
+// Host converts a sensor timestamp to MCU time in microseconds.
+func (t *Timebase) Host(sensor int64) int64 {
+	elapsed := float64(sensor-t.sensorRef) / (1 + t.DriftPPM*1e-6)
+	return t.hostRef + int64(elapsed)
+}
@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"encoding/binary"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+)
+
+// Bus makes a Transport look like the I2C bus the bno08x driver expects,
+// so programs built on the driver run over SPI too. Writes carry whole
+// SHTP packets and go out on the channel named in their header; reads
+// hand back the waiting packet the way the sensor does over I2C.
+type Bus struct {
+	t      Transport
+	packet [shtpraw.MaxPacketLen]byte
+	n      int // Length of the packet being read, 0 for none
+	off    int // Bytes of it already returned
+}
+
+// NewBus returns a Bus over t.
+func NewBus(t Transport) *Bus {
+	return &Bus{t: t}
+}
+
+// Tx implements the driver's I2C interface; the address is ignored. As on
+// I2C, reading only a header leaves the packet pending, and a longer read
+// returns it from the start. A packet read in chunks gets a header at the
+// start of every chunk after the first, which the reader skips.
+func (b *Bus) Tx(addr uint16, w, r []byte) error {
+	if len(w) > 0 {
+		if len(w) < shtpraw.HeaderLen {
+			return ErrShortWrite
+		}
+		return b.t.Write(w[2], w[shtpraw.HeaderLen:])
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	if b.n == 0 {
+		packet, err := b.t.Read()
+		if err == shtpraw.ErrNoData {
+			// A zero length tells the reader nothing is waiting
+			clear(r)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b.n = copy(b.packet[:], packet.Data)
+		b.off = 0
+	}
+	switch {
+	case b.off == 0 && len(r) <= shtpraw.HeaderLen:
+		copy(r, b.packet[:b.n])
+		if b.n <= shtpraw.HeaderLen {
+			// Nothing follows the header
+			b.n = 0
+		}
+		return nil
+	case b.off == 0:
+		b.off = copy(r, b.packet[:b.n])
+	default:
+		var header [shtpraw.HeaderLen]byte
+		binary.LittleEndian.PutUint16(header[0:2], uint16(b.n-b.off+shtpraw.HeaderLen)|shtpraw.ContinuationBit)
+		header[2] = b.packet[2]
+		header[3] = b.packet[3]
+		n := copy(r, header[:])
+		b.off += copy(r[n:], b.packet[b.off:b.n])
+	}
+	if b.off >= b.n {
+		b.n = 0
+	}
+	return nil
+}
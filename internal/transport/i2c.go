@@ -0,0 +1,60 @@
+package transport
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+)
+
+// I2C is the Transport over an I2C bus, built on shtpraw.Conn.
+type I2C struct {
+	conn     *shtpraw.Conn
+	irq, rst machine.Pin
+}
+
+// NewI2C returns an I2C transport for the sensor at addr. The INT and RST
+// pins are optional; pass machine.NoPin for either if it is not wired.
+func NewI2C(bus shtpraw.Bus, addr uint16, irq, rst machine.Pin) *I2C {
+	if irq != machine.NoPin {
+		irq.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	}
+	if rst != machine.NoPin {
+		rst.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		rst.High()
+	}
+	return &I2C{conn: shtpraw.New(bus, addr), irq: irq, rst: rst}
+}
+
+// Conn returns the underlying connection, for I2C-only operations.
+func (t *I2C) Conn() *shtpraw.Conn {
+	return t.conn
+}
+
+// Read implements Transport.
+func (t *I2C) Read() (shtpraw.Packet, error) {
+	return t.conn.ReadPacket()
+}
+
+// Write implements Transport.
+func (t *I2C) Write(channel uint8, cargo []byte) error {
+	return t.conn.SendOnChannel(channel, cargo)
+}
+
+// Reset implements Transport, falling back to a soft reset on the
+// executable channel when RST is not wired.
+func (t *I2C) Reset() error {
+	if t.rst == machine.NoPin {
+		return t.conn.SoftReset()
+	}
+	pulseReset(t.rst)
+	return nil
+}
+
+// WaitInterrupt implements Transport.
+func (t *I2C) WaitInterrupt(timeout time.Duration) bool {
+	if t.irq == machine.NoPin {
+		return true
+	}
+	return waitLow(t.irq, timeout)
+}
@@ -0,0 +1,31 @@
+//go:build !bno08x_spi
+
+package transport
+
+import (
+	"machine"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Open configures the board's IMU bus at 400kHz and returns an I2C
+// transport for the sensor at the default address.
+func Open() (Transport, error) {
+	bus := board.IMUBus()
+	if err := bus.Configure(machine.I2CConfig{Frequency: 400 * machine.KHz}); err != nil {
+		return nil, err
+	}
+	return NewI2C(bus, shtpraw.DefaultAddress, board.IntPin(), board.ResetPin()), nil
+}
+
+// OpenSensorBus configures the board's IMU bus at 400kHz and returns the
+// driver for the sensor on it, ready for Configure, along with the bus.
+func OpenSensorBus() (*bno08x.Device, shtpraw.Bus, error) {
+	bus := board.IMUBus()
+	if err := bus.Configure(machine.I2CConfig{Frequency: 400 * machine.KHz}); err != nil {
+		return nil, nil, err
+	}
+	return bno08x.NewI2C(bus), bus, nil
+}
@@ -0,0 +1,40 @@
+//go:build bno08x_spi
+
+package transport
+
+import (
+	"machine"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Open configures SPI0 and returns an SPI transport using the CS, INT,
+// RST and WAKE pins from internal/board.
+func Open() (Transport, error) {
+	bus := machine.SPI0
+	err := bus.Configure(machine.SPIConfig{
+		Frequency: SPIFrequency,
+		Mode:      SPIMode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t, err := NewSPI(bus, board.CSPin(), board.IntPin(), board.ResetPin(), board.WakePin())
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// OpenSensorBus opens the SPI transport and returns the driver running
+// over it, ready for Configure, along with the Bus it reads through.
+func OpenSensorBus() (*bno08x.Device, shtpraw.Bus, error) {
+	t, err := Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	bus := NewBus(t)
+	return bno08x.NewI2C(bus), bus, nil
+}
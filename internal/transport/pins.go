@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"machine"
+	"time"
+)
+
+// waitLow polls an active-low interrupt pin until it falls or the timeout
+// expires.
+func waitLow(pin machine.Pin, timeout time.Duration) bool {
+	start := time.Now()
+	for pin.Get() {
+		if time.Since(start) > timeout {
+			return false
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+	return true
+}
+
+// pulseReset holds RST low for resetPulse and releases it.
+func pulseReset(rst machine.Pin) {
+	rst.Low()
+	time.Sleep(resetPulse)
+	rst.High()
+}
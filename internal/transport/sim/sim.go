@@ -0,0 +1,84 @@
+// Package sim provides an in-memory transport.Transport for running SHTP
+// code without a sensor. It imports no machine package, unlike the real
+// transports, so host tests can use it:
+//
+//	var link sim.Transport
+//	link.Queue(shtpraw.ChannelCommand, advert)
+//	packet, err := link.Read()
+package sim
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+)
+
+// Transport is an in-memory transport.Transport. Packets queued with Queue
+// are returned by Read in order; writes are passed to OnWrite, which can
+// queue replies to emulate the sensor.
+type Transport struct {
+	// OnWrite is called for every Write; nil ignores writes.
+	OnWrite func(channel uint8, cargo []byte)
+	// OnReset is called for every Reset, after the queue is cleared.
+	OnReset func()
+	// Resets counts calls to Reset.
+	Resets int
+
+	pending [][]byte
+	seq     [shtpraw.NumChannels]uint8
+}
+
+// Queue adds a packet carrying cargo on channel, as if sent by the sensor.
+func (s *Transport) Queue(channel uint8, cargo []byte) {
+	packet := make([]byte, shtpraw.HeaderLen+len(cargo))
+	binary.LittleEndian.PutUint16(packet[0:2], uint16(len(packet)))
+	packet[2] = channel
+	packet[3] = s.seq[channel%shtpraw.NumChannels]
+	s.seq[channel%shtpraw.NumChannels]++
+	copy(packet[shtpraw.HeaderLen:], cargo)
+	s.pending = append(s.pending, packet)
+}
+
+// Pending returns the number of queued packets.
+func (s *Transport) Pending() int {
+	return len(s.pending)
+}
+
+// Read implements transport.Transport.
+func (s *Transport) Read() (shtpraw.Packet, error) {
+	if len(s.pending) == 0 {
+		return shtpraw.Packet{}, shtpraw.ErrNoData
+	}
+	data := s.pending[0]
+	s.pending = s.pending[1:]
+	return shtpraw.Packet{Header: shtpraw.ParseHeader(data), Data: data}, nil
+}
+
+// Write implements transport.Transport.
+func (s *Transport) Write(channel uint8, cargo []byte) error {
+	if channel >= shtpraw.NumChannels {
+		return shtpraw.ErrBadChannel
+	}
+	if s.OnWrite != nil {
+		s.OnWrite(channel, cargo)
+	}
+	return nil
+}
+
+// Reset implements transport.Transport, dropping all queued packets.
+func (s *Transport) Reset() error {
+	s.pending = nil
+	s.seq = [shtpraw.NumChannels]uint8{}
+	s.Resets++
+	if s.OnReset != nil {
+		s.OnReset()
+	}
+	return nil
+}
+
+// WaitInterrupt implements transport.Transport. Simulated time does not pass; it
+// reports whether a packet is queued.
+func (s *Transport) WaitInterrupt(timeout time.Duration) bool {
+	return len(s.pending) > 0
+}
@@ -0,0 +1,154 @@
+package transport
+
+import (
+	"encoding/binary"
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+)
+
+// SPIBus is the subset of machine.SPI used by the SPI transport.
+type SPIBus interface {
+	Configure(config machine.SPIConfig) error
+	Tx(w, r []byte) error
+}
+
+// SPIMode and SPIFrequency are the BNO08x SPI settings: mode 3, up to 3MHz.
+const (
+	SPIMode      = 3
+	SPIFrequency = 3 * machine.MHz
+)
+
+// SPI is the Transport over SPI. Unlike I2C, the host may only clock a
+// transfer after the sensor has pulled INT low. To send, the host first
+// pulls PS0/WAKE low, which makes the sensor assert INT when it is ready.
+// All four control pins are required.
+type SPI struct {
+	bus                SPIBus
+	cs, irq, rst, wake machine.Pin
+	seq                [shtpraw.NumChannels]uint8
+	tx, rx             [shtpraw.MaxPacketLen]byte
+	held               [shtpraw.MaxPacketLen]byte // Packet clocked out during a Write
+	heldLen            int
+}
+
+// NewSPI returns an SPI transport on a bus already configured for SPIMode
+// and configures the control pins.
+func NewSPI(bus SPIBus, cs, irq, rst, wake machine.Pin) (*SPI, error) {
+	if cs == machine.NoPin || irq == machine.NoPin || rst == machine.NoPin || wake == machine.NoPin {
+		return nil, ErrNotWired
+	}
+	for _, pin := range []machine.Pin{cs, rst, wake} {
+		pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		pin.High()
+	}
+	irq.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	return &SPI{bus: bus, cs: cs, irq: irq, rst: rst, wake: wake}, nil
+}
+
+// Read implements Transport, returning the packet kept by the last Write
+// if there is one, or else clocking out one packet in a single transfer if
+// INT is asserted.
+func (t *SPI) Read() (shtpraw.Packet, error) {
+	if t.heldLen > 0 {
+		n := t.heldLen
+		t.heldLen = 0
+		return shtpraw.Packet{Header: shtpraw.ParseHeader(t.held[:]), Data: t.held[:n]}, nil
+	}
+	if t.irq.Get() {
+		return shtpraw.Packet{}, shtpraw.ErrNoData
+	}
+	t.cs.Low()
+	defer t.cs.High()
+
+	zero := t.tx[:shtpraw.HeaderLen]
+	for i := range zero {
+		zero[i] = 0
+	}
+	if err := t.bus.Tx(zero, t.rx[:shtpraw.HeaderLen]); err != nil {
+		return shtpraw.Packet{}, err
+	}
+	hdr := shtpraw.ParseHeader(t.rx[:shtpraw.HeaderLen])
+	if hdr.Length == 0 {
+		return shtpraw.Packet{}, shtpraw.ErrNoData
+	}
+	if int(hdr.Length) > len(t.rx) {
+		return shtpraw.Packet{}, shtpraw.ErrTooLarge
+	}
+	if hdr.Length > shtpraw.HeaderLen {
+		body := t.tx[shtpraw.HeaderLen:hdr.Length]
+		for i := range body {
+			body[i] = 0
+		}
+		if err := t.bus.Tx(body, t.rx[shtpraw.HeaderLen:hdr.Length]); err != nil {
+			return shtpraw.Packet{}, err
+		}
+	}
+	return shtpraw.Packet{Header: hdr, Data: t.rx[:hdr.Length]}, nil
+}
+
+// Write implements Transport. SPI is full duplex, so the sensor may clock
+// out a packet of its own while the frame goes in; its header arrives
+// with the first bytes, the rest is read in the same transfer and the
+// packet is kept for the next Read. Only one is kept, so callers read
+// before writing again.
+func (t *SPI) Write(channel uint8, cargo []byte) error {
+	if channel >= shtpraw.NumChannels {
+		return shtpraw.ErrBadChannel
+	}
+	frameLen := shtpraw.HeaderLen + len(cargo)
+	if frameLen > len(t.tx) {
+		return shtpraw.ErrTooLarge
+	}
+	t.wake.Low()
+	ok := waitLow(t.irq, 200*time.Millisecond)
+	t.wake.High()
+	if !ok {
+		return ErrIntTimeout
+	}
+
+	frame := t.tx[:frameLen]
+	binary.LittleEndian.PutUint16(frame[0:2], uint16(frameLen))
+	frame[2] = channel
+	frame[3] = t.seq[channel]
+	t.seq[channel]++
+	copy(frame[shtpraw.HeaderLen:], cargo)
+
+	t.cs.Low()
+	defer t.cs.High()
+	if err := t.bus.Tx(frame, t.rx[:frameLen]); err != nil {
+		return err
+	}
+	hdr := shtpraw.ParseHeader(t.rx[:shtpraw.HeaderLen])
+	if hdr.Length < shtpraw.HeaderLen || int(hdr.Length) > len(t.rx) {
+		// Nothing sent, or MISO idling high
+		return nil
+	}
+	if int(hdr.Length) > frameLen {
+		rest := t.tx[frameLen:hdr.Length]
+		for i := range rest {
+			rest[i] = 0
+		}
+		if err := t.bus.Tx(rest, t.rx[frameLen:hdr.Length]); err != nil {
+			return err
+		}
+	}
+	t.heldLen = copy(t.held[:], t.rx[:hdr.Length])
+	return nil
+}
+
+// Reset implements Transport. WAKE is held high so the sensor samples PS0
+// high at boot and comes up in SPI mode.
+func (t *SPI) Reset() error {
+	t.wake.High()
+	pulseReset(t.rst)
+	t.seq = [shtpraw.NumChannels]uint8{}
+	t.heldLen = 0
+	return nil
+}
+
+// WaitInterrupt implements Transport.
+func (t *SPI) WaitInterrupt(timeout time.Duration) bool {
+	return waitLow(t.irq, timeout)
+}
@@ -0,0 +1,100 @@
+// Package transport abstracts how SHTP packets reach the BNO08x, so code
+// that speaks raw SHTP runs unchanged over I2C, SPI or the in-memory
+// simulator in internal/transport/sim.
+//
+// Programs normally call Open, which sets up the bus described by
+// internal/board and returns the transport chosen at build time: I2C by
+// default, SPI when built with "-tags bno08x_spi". Programs built on the
+// bno08x driver call OpenSensor instead, which runs the driver over the
+// same transport.
+package transport
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Transport moves SHTP packets to and from the sensor.
+type Transport interface {
+	// Read returns the next packet from the sensor, or shtpraw.ErrNoData
+	// if none is waiting. The packet data is only valid until the next
+	// call to Read.
+	Read() (shtpraw.Packet, error)
+
+	// Write sends cargo on an SHTP channel. The transport adds the header
+	// and keeps the per-channel sequence numbers.
+	Write(channel uint8, cargo []byte) error
+
+	// Reset restarts the sensor: a pulse on RST if it is wired, otherwise
+	// a soft reset where the transport allows one. The sensor announces
+	// itself with an advertisement once it has booted.
+	Reset() error
+
+	// WaitInterrupt waits up to timeout for the sensor to signal that a
+	// packet is ready, and reports whether it did. Transports without an
+	// interrupt line return true at once and leave Read to find out.
+	WaitInterrupt(timeout time.Duration) bool
+}
+
+var (
+	ErrIntTimeout = errors.New("transport: timed out waiting for INT")
+	ErrNotWired   = errors.New("transport: required pin not assigned in internal/board")
+	ErrShortWrite = errors.New("transport: write shorter than an SHTP header")
+)
+
+// resetPulse is how long RST is held low; the datasheet asks for at least
+// 10ns, but slow rise times on breakout boards need far longer.
+const resetPulse = 10 * time.Millisecond
+
+// Next waits up to timeout for a packet and returns it, polling when the
+// transport has no interrupt line. It returns shtpraw.ErrNoData if nothing
+// arrived in time.
+func Next(t Transport, timeout time.Duration) (shtpraw.Packet, error) {
+	start := time.Now()
+	for {
+		remaining := timeout - time.Since(start)
+		if remaining <= 0 {
+			return shtpraw.Packet{}, shtpraw.ErrNoData
+		}
+		if t.WaitInterrupt(remaining) {
+			packet, err := t.Read()
+			if err != shtpraw.ErrNoData {
+				return packet, err
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// OpenSensor returns the bno08x driver over the transport chosen at build
+// time, ready for Configure, so programs built on the driver run over I2C
+// or SPI alike.
+func OpenSensor() (*bno08x.Device, error) {
+	sensor, _, err := OpenSensorBus()
+	return sensor, err
+}
+
+// ResetAndDrain resets the sensor and drains what it sends as it boots,
+// so the advertisement and reset messages are out of the way before the
+// caller configures anything.
+func ResetAndDrain(t Transport) error {
+	if err := t.Reset(); err != nil {
+		return err
+	}
+	Drain(t)
+	return nil
+}
+
+// Drain waits for the sensor to boot and reads until it goes quiet, for
+// resets the transport did not make, such as a command or the RST button.
+func Drain(t Transport) {
+	time.Sleep(300 * time.Millisecond)
+	for {
+		if _, err := Next(t, 200*time.Millisecond); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package units converts the SI values reported by the BNO08x into the
+// units people usually want to read: g, degrees per second, gauss and
+// altitude in meters or feet.
+package units
+
+import "math"
+
+const (
+	// StandardGravity is one g in m/s².
+	StandardGravity = 9.80665
+	// SeaLevelPressure is the standard atmosphere at sea level in hPa.
+	SeaLevelPressure = 1013.25
+	// FeetPerMeter converts meters to feet.
+	FeetPerMeter = 3.28084
+)
+
+// MetersPerSecondSquaredToG converts an acceleration to g.
+func MetersPerSecondSquaredToG(v float32) float32 {
+	return v / StandardGravity
+}
+
+// RadiansToDegrees converts an angle, or an angular rate in rad/s to °/s.
+func RadiansToDegrees(v float32) float32 {
+	return v * 180 / math.Pi
+}
+
+// DegreesToRadians converts an angle in degrees to radians.
+func DegreesToRadians(v float32) float32 {
+	return v * math.Pi / 180
+}
+
+// MicroteslaToGauss converts a magnetic flux density to gauss.
+func MicroteslaToGauss(v float32) float32 {
+	return v / 100
+}
+
+// CelsiusToFahrenheit converts a temperature to °F.
+func CelsiusToFahrenheit(v float32) float32 {
+	return v*9/5 + 32
+}
+
+// MetersToFeet converts a length to feet.
+func MetersToFeet(v float32) float32 {
+	return v * FeetPerMeter
+}
+
+// PressureAltitude returns the altitude in meters for a pressure in hPa,
+// using the international barometric formula with the given sea level
+// pressure. Pass SeaLevelPressure if the local value is unknown.
+func PressureAltitude(hPa, seaLevel float32) float32 {
+	return float32(44330.77 * (1 - math.Pow(float64(hPa/seaLevel), 0.190263)))
+}
+
+// System selects the units used when printing values.
+type System uint8
+
+const (
+	SI       System = iota // m/s², rad/s, µT, °C, m
+	Imperial               // g, °/s, gauss, °F, ft
+)
+
+// Acceleration converts an acceleration in m/s² and returns its unit.
+func (s System) Acceleration(v float32) (float32, string) {
+	if s == Imperial {
+		return MetersPerSecondSquaredToG(v), "g"
+	}
+	return v, "m/s²"
+}
+
+// AngularRate converts an angular rate in rad/s and returns its unit.
+func (s System) AngularRate(v float32) (float32, string) {
+	if s == Imperial {
+		return RadiansToDegrees(v), "°/s"
+	}
+	return v, "rad/s"
+}
+
+// MagneticField converts a magnetic field in µT and returns its unit.
+func (s System) MagneticField(v float32) (float32, string) {
+	if s == Imperial {
+		return MicroteslaToGauss(v), "G"
+	}
+	return v, "µT"
+}
+
+// Temperature converts a temperature in °C and returns its unit.
+func (s System) Temperature(v float32) (float32, string) {
+	if s == Imperial {
+		return CelsiusToFahrenheit(v), "°F"
+	}
+	return v, "°C"
+}
+
+// Altitude converts a pressure in hPa to a standard-atmosphere altitude and
+// returns its unit.
+func (s System) Altitude(hPa float32) (float32, string) {
+	m := PressureAltitude(hPa, SeaLevelPressure)
+	if s == Imperial {
+		return MetersToFeet(m), "ft"
+	}
+	return m, "m"
+}
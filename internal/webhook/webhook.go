@@ -0,0 +1,263 @@
+// Package webhook delivers alarm events to an HTTP endpoint as small JSON
+// documents, so alarms reach a phone through services such as ntfy or
+// IFTTT:
+//
+//	{"event":"tilt","value":32.5,"limit":30.0,"uptime_s":1234,"seq":7}
+//
+// Events wait in a queue until they are delivered. A failed post is
+// retried with exponential backoff, and while posts are failing the queue
+// is saved to flash with internal/store, so alarms raised while the
+// network is down survive a reset. Flash is written once per failed
+// attempt that finds new events, once per event raised during a backoff
+// and once more when the queue empties, never while posts go through.
+// "uptime_s" is the time since start-up of the boot that raised the
+// event; "seq" carries on from the last saved queue after a reset, so a
+// receiver can drop an event it sees twice.
+//
+// Programs pass a Poster; Connect returns one posting with net/http over
+// the board's WiFi module in builds with "-tags wifi", and one printing
+// the events on the console otherwise.
+package webhook
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+)
+
+// QueueLen is the number of undelivered events kept. When it is full the
+// oldest event is dropped.
+const QueueLen = 16
+
+// Retry delays after a failed post, doubling up to MaxBackoff.
+const (
+	MinBackoff = 2 * time.Second
+	MaxBackoff = 5 * time.Minute
+)
+
+// maxKind bounds the stored event name.
+const maxKind = 16
+
+// Saved queue layout: sequence number, dropped count and event count,
+// then fixed-size events of kind, value, limit, uptime and sequence.
+const (
+	recordHeader = 4 + 4 + 1
+	eventLen     = maxKind + 4 + 4 + 4 + 4
+	recordLen    = recordHeader + QueueLen*eventLen
+)
+
+// Event is one alarm notification.
+type Event struct {
+	Kind   string        // Event name, such as "tilt" or "tilt_clear"; up to 16 bytes
+	Value  float32       // Measured value that raised the event
+	Limit  float32       // Threshold it crossed
+	Uptime time.Duration // Time since start-up when raised
+	Seq    uint32        // Set by Notify
+}
+
+// Poster sends one JSON body to url, returning an error unless the server
+// accepted it.
+type Poster interface {
+	Post(url string, body []byte) error
+}
+
+// Notifier queues events and delivers them in order.
+type Notifier struct {
+	URL string
+
+	poster  Poster
+	store   *store.Store // nil keeps the queue in RAM only
+	queue   [QueueLen]Event
+	head    int
+	count   int
+	seq     uint32
+	dropped uint32
+	backoff time.Duration
+	next    time.Time
+	dirty   bool // Queue changed since it was last saved
+	saved   bool // Flash holds undelivered events
+	body    [160]byte
+	record  [recordLen]byte
+}
+
+// New returns a notifier posting to url through p, saving its queue to s
+// if s is not nil. Call Restore to pick up events saved before a reset.
+func New(url string, p Poster, s *store.Store) *Notifier {
+	return &Notifier{URL: url, poster: p, store: s}
+}
+
+// Restore loads the queue saved by an earlier boot and returns the number
+// of events waiting. A missing record, or one left by another program,
+// is an empty queue rather than an error.
+func (n *Notifier) Restore() (int, error) {
+	if n.store == nil {
+		return 0, nil
+	}
+	size, err := n.store.Load(n.record[:])
+	switch {
+	case err == store.ErrEmpty || err == store.ErrOther:
+		return 0, nil
+	case err != nil:
+		return 0, err
+	case size != recordLen:
+		return 0, store.ErrCorrupt
+	}
+
+	r := n.record[:]
+	n.seq = binary.LittleEndian.Uint32(r)
+	n.dropped = binary.LittleEndian.Uint32(r[4:])
+	n.head, n.count = 0, int(r[8])
+	if n.count > QueueLen {
+		n.count = 0
+		return 0, store.ErrCorrupt
+	}
+	for i := 0; i < n.count; i++ {
+		e := r[recordHeader+i*eventLen:]
+		k := 0
+		for k < maxKind && e[k] != 0 {
+			k++
+		}
+		n.queue[i] = Event{
+			Kind:   string(e[:k]),
+			Value:  math.Float32frombits(binary.LittleEndian.Uint32(e[maxKind:])),
+			Limit:  math.Float32frombits(binary.LittleEndian.Uint32(e[maxKind+4:])),
+			Uptime: time.Duration(binary.LittleEndian.Uint32(e[maxKind+8:])) * time.Second,
+			Seq:    binary.LittleEndian.Uint32(e[maxKind+12:]),
+		}
+	}
+	n.saved = n.count > 0
+	return n.count, nil
+}
+
+// Notify queues ev for delivery, numbering it, and tries to send it on the
+// next Poll unless a retry is already waiting. During a backoff the queue
+// is saved at once, returning any error from the save; otherwise it is
+// only saved if that post fails.
+func (n *Notifier) Notify(ev Event) error {
+	if len(ev.Kind) > maxKind {
+		ev.Kind = ev.Kind[:maxKind]
+	}
+	n.seq++
+	ev.Seq = n.seq
+	if n.count == QueueLen {
+		n.head = (n.head + 1) % QueueLen
+		n.count--
+		n.dropped++
+	}
+	n.queue[(n.head+n.count)%QueueLen] = ev
+	n.count++
+	n.dirty = true
+	if n.backoff > 0 {
+		return n.save()
+	}
+	return nil
+}
+
+// Poll posts the oldest queued event if it is due, returning the post
+// error if it fails. A failure schedules the next attempt after the
+// current backoff and saves the queue if it changed; a save that fails is
+// tried again on the next failure.
+func (n *Notifier) Poll(now time.Time) error {
+	if n.count == 0 || now.Before(n.next) {
+		return nil
+	}
+	body := AppendJSON(n.body[:0], &n.queue[n.head])
+	if err := n.poster.Post(n.URL, body); err != nil {
+		if n.backoff == 0 {
+			n.backoff = MinBackoff
+		} else if n.backoff *= 2; n.backoff > MaxBackoff {
+			n.backoff = MaxBackoff
+		}
+		n.next = now.Add(n.backoff)
+		if n.dirty {
+			n.save()
+		}
+		return err
+	}
+	n.backoff, n.next = 0, time.Time{}
+	n.queue[n.head] = Event{}
+	n.head = (n.head + 1) % QueueLen
+	n.count--
+	n.dirty = true
+	// Clear the saved events once all are sent, so a reset does not
+	// send them again
+	if n.count == 0 && n.saved {
+		return n.save()
+	}
+	return nil
+}
+
+// Pending returns the number of undelivered events.
+func (n *Notifier) Pending() int {
+	return n.count
+}
+
+// Dropped returns the number of events lost to a full queue.
+func (n *Notifier) Dropped() uint32 {
+	return n.dropped
+}
+
+// Backoff returns the delay before the next retry, or zero if the last
+// post succeeded.
+func (n *Notifier) Backoff() time.Duration {
+	return n.backoff
+}
+
+// save writes the queue to flash, noting what flash then holds.
+func (n *Notifier) save() error {
+	if n.store == nil {
+		return nil
+	}
+	r := n.record[:]
+	for i := range r {
+		r[i] = 0
+	}
+	binary.LittleEndian.PutUint32(r, n.seq)
+	binary.LittleEndian.PutUint32(r[4:], n.dropped)
+	r[8] = uint8(n.count)
+	for i := 0; i < n.count; i++ {
+		ev := &n.queue[(n.head+i)%QueueLen]
+		e := r[recordHeader+i*eventLen:]
+		copy(e[:maxKind], ev.Kind)
+		binary.LittleEndian.PutUint32(e[maxKind:], math.Float32bits(ev.Value))
+		binary.LittleEndian.PutUint32(e[maxKind+4:], math.Float32bits(ev.Limit))
+		binary.LittleEndian.PutUint32(e[maxKind+8:], uint32(ev.Uptime/time.Second))
+		binary.LittleEndian.PutUint32(e[maxKind+12:], ev.Seq)
+	}
+	if err := n.store.Save(r); err != nil {
+		return err
+	}
+	n.dirty, n.saved = false, n.count > 0
+	return nil
+}
+
+// AppendJSON appends the JSON document for ev to dst.
+func AppendJSON(dst []byte, ev *Event) []byte {
+	dst = append(dst, `{"event":"`...)
+	for i := 0; i < len(ev.Kind); i++ {
+		// Event names are program constants; drop anything needing escapes
+		if c := ev.Kind[i]; c >= 0x20 && c != '"' && c != '\\' {
+			dst = append(dst, c)
+		}
+	}
+	dst = append(dst, `","value":`...)
+	dst = appendNumber(dst, ev.Value)
+	dst = append(dst, `,"limit":`...)
+	dst = appendNumber(dst, ev.Limit)
+	dst = append(dst, `,"uptime_s":`...)
+	dst = fmtutil.AppendInt(dst, int64(ev.Uptime/time.Second))
+	dst = append(dst, `,"seq":`...)
+	dst = fmtutil.AppendInt(dst, int64(ev.Seq))
+	return append(dst, '}')
+}
+
+// appendNumber writes v with one decimal, or null if it is not finite.
+func appendNumber(dst []byte, v float32) []byte {
+	if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+		return append(dst, "null"...)
+	}
+	return fmtutil.AppendFloat(dst, v, 1)
+}
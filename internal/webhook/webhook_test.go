@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/store"
+)
+
+// flash is an in-memory store.BlockDevice counting erases.
+type flash struct {
+	data   [4 * 4096]byte
+	erases int
+}
+
+func (f *flash) ReadAt(p []byte, off int64) (int, error)  { return copy(p, f.data[off:]), nil }
+func (f *flash) WriteAt(p []byte, off int64) (int, error) { return copy(f.data[off:], p), nil }
+func (f *flash) Size() int64                              { return int64(len(f.data)) }
+func (f *flash) WriteBlockSize() int64                    { return 256 }
+func (f *flash) EraseBlockSize() int64                    { return 4096 }
+
+func (f *flash) EraseBlocks(start, n int64) error {
+	f.erases++
+	for i := start * 4096; i < (start+n)*4096; i++ {
+		f.data[i] = 0xFF
+	}
+	return nil
+}
+
+// poster fails while down and records what it sent.
+type poster struct {
+	down bool
+	sent []string
+}
+
+func (p *poster) Post(url string, body []byte) error {
+	if p.down {
+		return errors.New("network down")
+	}
+	p.sent = append(p.sent, string(body))
+	return nil
+}
+
+func TestSaveOnlyWhileOffline(t *testing.T) {
+	dev := &flash{}
+	p := &poster{}
+	n := New("http://example", p, store.New(dev, "webhook_test"))
+	now := time.Unix(0, 0)
+
+	// Online: events are posted without touching flash
+	for i := 0; i < 5; i++ {
+		n.Notify(Event{Kind: "tilt"})
+		if err := n.Poll(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dev.erases != 0 || len(p.sent) != 5 {
+		t.Fatalf("online: %d saves, %d sent; want 0, 5", dev.erases, len(p.sent))
+	}
+
+	// The first failure saves; retries without new events do not
+	p.down = true
+	n.Notify(Event{Kind: "tilt"})
+	for i := 0; i < 3; i++ {
+		n.Poll(now)
+		now = now.Add(MaxBackoff)
+	}
+	if dev.erases != 1 {
+		t.Fatalf("after failed retries: %d saves, want 1", dev.erases)
+	}
+
+	// Events raised during the backoff are saved at once
+	n.Notify(Event{Kind: "tilt_clear"})
+	if dev.erases != 2 {
+		t.Fatalf("after notify while offline: %d saves, want 2", dev.erases)
+	}
+
+	// A reset now restores both
+	restored := New("http://example", p, store.New(dev, "webhook_test"))
+	if count, err := restored.Restore(); err != nil || count != 2 {
+		t.Fatalf("Restore = %d, %v; want 2", count, err)
+	}
+
+	// Back online: the first delivery does not save, emptying the queue
+	// clears the record
+	p.down = false
+	if err := n.Poll(now); err != nil {
+		t.Fatal(err)
+	}
+	if dev.erases != 2 {
+		t.Fatalf("after first delivery: %d saves, want 2", dev.erases)
+	}
+	if err := n.Poll(now); err != nil {
+		t.Fatal(err)
+	}
+	if dev.erases != 3 || n.Pending() != 0 {
+		t.Fatalf("after emptying: %d saves, %d pending; want 3, 0", dev.erases, n.Pending())
+	}
+	restored = New("http://example", p, store.New(dev, "webhook_test"))
+	if count, err := restored.Restore(); err != nil || count != 0 {
+		t.Fatalf("Restore after emptying = %d, %v; want 0", count, err)
+	}
+
+	// Further online events leave flash alone again
+	n.Notify(Event{Kind: "tilt"})
+	n.Poll(now)
+	if dev.erases != 3 {
+		t.Fatalf("online again: %d saves, want 3", dev.erases)
+	}
+	if want := `{"event":"tilt","value":0.0,"limit":0.0,"uptime_s":0,"seq":8}`; p.sent[len(p.sent)-1] != want {
+		t.Errorf("last post %s, want %s", p.sent[len(p.sent)-1], want)
+	}
+}
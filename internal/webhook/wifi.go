@@ -0,0 +1,60 @@
+//go:build wifi
+
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"tinygo.org/x/drivers/netlink"
+	"tinygo.org/x/drivers/netlink/probe"
+)
+
+// httpPoster posts events with net/http over the board's WiFi module,
+// rejoining the network before a post if the last attempt failed.
+type httpPoster struct {
+	link       netlink.Netlinker
+	ssid       string
+	passphrase string
+	connected  bool
+}
+
+func (p *httpPoster) Post(url string, body []byte) error {
+	if !p.connected {
+		if err := p.join(); err != nil {
+			return err
+		}
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		p.connected = false
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.New("webhook: server returned " + resp.Status)
+	}
+	return nil
+}
+
+func (p *httpPoster) join() error {
+	err := p.link.NetConnect(&netlink.ConnectParams{Ssid: p.ssid, Passphrase: p.passphrase})
+	p.connected = err == nil
+	return err
+}
+
+// Connect joins the WiFi network ssid and returns a Poster using it. A
+// failure to join is printed rather than returned: events queue up and are
+// sent once a retry joins.
+func Connect(ssid, passphrase string) Poster {
+	link, _ := probe.Probe()
+	p := &httpPoster{link: link, ssid: ssid, passphrase: passphrase}
+	println("Joining WiFi network", ssid+"...")
+	if err := p.join(); err != nil {
+		println("WiFi failed:", err.Error())
+	} else {
+		println("WiFi connected")
+	}
+	return p
+}
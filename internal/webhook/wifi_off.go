@@ -0,0 +1,19 @@
+//go:build !wifi
+
+package webhook
+
+// consolePoster prints events instead of posting them, on boards without
+// WiFi or builds without "-tags wifi".
+type consolePoster struct{}
+
+func (consolePoster) Post(url string, body []byte) error {
+	println("webhook", url, string(body))
+	return nil
+}
+
+// Connect returns a Poster printing each event on the console, since the
+// build has no WiFi.
+func Connect(ssid, passphrase string) Poster {
+	println("Built without -tags wifi: events are printed, not posted")
+	return consolePoster{}
+}
@@ -0,0 +1,193 @@
+// Package websocket is the server side of RFC 6455 reduced to what a
+// board needs to push readings to a browser: reading the HTTP request,
+// the upgrade handshake, and unfragmented frames. A connection is served
+// as plain HTTP when it is not an upgrade request, so one listener serves
+// both the page and its WebSocket.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+)
+
+// Frame opcodes.
+const (
+	OpText   = 0x1
+	OpBinary = 0x2
+	OpClose  = 0x8
+	OpPing   = 0x9
+	OpPong   = 0xA
+)
+
+// MaxHeaderLen bounds a request line or header line.
+const MaxHeaderLen = 512
+
+// guid is appended to the client's key to derive the accept key.
+const guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	ErrBadRequest = errors.New("websocket: malformed HTTP request")
+	ErrTooLong    = errors.New("websocket: header line too long")
+)
+
+// Request is the part of an HTTP request a board server looks at.
+type Request struct {
+	Method string
+	Path   string
+	// Key is the Sec-WebSocket-Key of an upgrade request, empty otherwise.
+	Key string
+}
+
+// Upgrade reports whether the request asks for a WebSocket.
+func (r *Request) Upgrade() bool {
+	return r.Key != ""
+}
+
+// ReadRequest reads a request line and its headers, keeping the method,
+// path and WebSocket key. A request body is not read.
+func ReadRequest(r *bufio.Reader) (Request, error) {
+	var req Request
+	line, err := readLine(r)
+	if err != nil {
+		return req, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 3 || !strings.HasPrefix(fields[2], "HTTP/") {
+		return req, ErrBadRequest
+	}
+	req.Method, req.Path = fields[0], fields[1]
+
+	upgrade := false
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return req, err
+		}
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return req, ErrBadRequest
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(name) {
+		case "upgrade":
+			upgrade = strings.EqualFold(value, "websocket")
+		case "sec-websocket-key":
+			req.Key = value
+		}
+	}
+	if !upgrade {
+		req.Key = ""
+	}
+	return req, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull || len(line) > MaxHeaderLen {
+		return "", ErrTooLong
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// AcceptKey returns the Sec-WebSocket-Accept value answering key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + guid))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WriteHandshake accepts the upgrade request with the given key.
+func WriteHandshake(w io.Writer, key string) error {
+	_, err := io.WriteString(w, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: "+AcceptKey(key)+"\r\n\r\n")
+	return err
+}
+
+// WriteResponse writes a complete HTTP response with the given status,
+// such as "200 OK". It announces "Connection: close", so the caller
+// closes the connection afterwards.
+func WriteResponse(w io.Writer, status, contentType, body string) error {
+	_, err := io.WriteString(w, "HTTP/1.1 "+status+"\r\n"+
+		"Content-Type: "+contentType+"\r\n"+
+		"Content-Length: "+fmtutil.Int(len(body))+"\r\n"+
+		"Connection: close\r\n\r\n"+body)
+	return err
+}
+
+// AppendFrame appends a final, unmasked frame, as a server sends, to dst.
+func AppendFrame(dst []byte, opcode uint8, payload []byte) []byte {
+	dst = append(dst, 0x80|opcode)
+	switch n := len(payload); {
+	case n < 126:
+		dst = append(dst, uint8(n))
+	case n <= 0xFFFF:
+		dst = append(dst, 126)
+		dst = binary.BigEndian.AppendUint16(dst, uint16(n))
+	default:
+		dst = append(dst, 127)
+		dst = binary.BigEndian.AppendUint64(dst, uint64(n))
+	}
+	return append(dst, payload...)
+}
+
+// ReadFrame reads one frame from a client, unmasking its payload into buf.
+// A payload longer than buf is read in full but cut to len(buf).
+func ReadFrame(r io.Reader, buf []byte) (opcode uint8, payload []byte, err error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:2]); err != nil {
+		return 0, nil, err
+	}
+	opcode = hdr[0] & 0x0F
+	masked := hdr[1]&0x80 != 0
+	n := uint64(hdr[1] & 0x7F)
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(r, hdr[:2]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(hdr[:2]))
+	case 127:
+		if _, err := io.ReadFull(r, hdr[:8]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(hdr[:8])
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	keep := n
+	if keep > uint64(len(buf)) {
+		keep = uint64(len(buf))
+	}
+	payload = buf[:keep]
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(n-keep)); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
@@ -0,0 +1,183 @@
+// Package main streams every enabled sensor as one compact JSON object per
+// line, for host scripts that would rather parse JSON than the console
+// format:
+//
+//	{"t":1200345,"id":1,"x":0.012,"y":-0.034,"z":9.807}
+//	{"t":1200391,"id":8,"i":0.010,"j":0.021,"k":0.707,"r":0.707}
+//
+// "t" is microseconds since start and "id" the sensor ID; internal/jsonout
+// lists the other keys. Start-up messages are printed before the stream
+// begins, so a reader skips lines that do not start with "{".
+//
+// Every 10 seconds the stream also carries a stats object, with no "id",
+// giving the events written per second, the writes that failed and, with
+// the queue described below, how full it got and what it dropped:
+//
+//	{"stats":{"events_s":349,"write_errors":0,"queue_most":3,"queue_size":64,"dropped":0}}
+//
+// Failed writes are only counted, since a message about them would land in
+// the stream.
+//
+// Set msgpackOutput to stream MessagePack arrays instead, [id, t,
+// values...] as internal/telemetry describes, at under half the bytes of
+// JSON for links such as LoRa or BLE UART bridges. The start-up messages
+// still come first, so a reader discards the text up to the line
+// "Sensor initialized successfully" before decoding.
+//
+// On an RP2040 the sensor is serviced on one goroutine and the events are
+// encoded and written on another, handed over through internal/eventq's
+// lock-free queue. Built with "-scheduler=cores" TinyGo runs the two on
+// separate cores, so a slow USB write never holds up the bus and reports
+// keep coming at the full rate:
+//
+//	tinygo flash -target=pico -scheduler=cores ./json_stream
+//
+// Other boards read and write on the one goroutine, as an RP2040 does with
+// singleGoroutine set, for comparing the two.
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/eventq"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/jsonout"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"github.com/intermernet/bno08xPrograms/internal/telemetry"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Decimal places printed for sensor values
+const decimals = 4
+
+// Set to true to stream MessagePack instead of JSON lines
+const msgpackOutput = false
+
+// Report interval for the motion sensors in microseconds. Seven sensors at
+// 50Hz is about 25kB/s of JSON, which USB serial keeps up with; raise it
+// on a UART.
+const interval = 20000 // 50Hz
+
+// How often a stats object reports throughput
+const statsInterval = 10 * time.Second
+
+// Set to true to read and write on one goroutine on an RP2040 too, to
+// compare its throughput with the queue's
+const singleGoroutine = false
+
+// encoder writes one event in the chosen format.
+type encoder interface {
+	Encode(event *bno08x.SensorValue) error
+}
+
+// Sensors streamed. Detectors and environmental sensors can be added here;
+// they report at their sensorinfo default rate.
+var sensors = []bno08x.SensorID{
+	bno08x.SensorAccelerometer,
+	bno08x.SensorGyroscope,
+	bno08x.SensorMagneticField,
+	bno08x.SensorLinearAcceleration,
+	bno08x.SensorGravity,
+	bno08x.SensorRotationVector,
+	bno08x.SensorGameRotationVector,
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("json_stream")
+	led := statusled.New()
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range sensors {
+		us := uint32(interval)
+		if sensorinfo.DefaultIntervalMicros(id) > us {
+			us = sensorinfo.DefaultIntervalMicros(id)
+		}
+		if err := sensor.EnableReport(id, us); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			led.Halt(statusled.BusError)
+		}
+	}
+
+	var enc encoder
+	if msgpackOutput {
+		enc = telemetry.NewMsgPack(os.Stdout)
+	} else {
+		enc = jsonout.New(os.Stdout, decimals)
+	}
+	stream(sensor, enc, led)
+}
+
+// throughput counts the events written for the stats objects.
+type throughput struct {
+	written int
+	failed  int // Since start-up
+	start   time.Time
+	line    [160]byte
+}
+
+// write encodes one event, counting it written or failed, and shows it on
+// the status LED.
+func (t *throughput) write(enc encoder, led *statusled.LED, event *bno08x.SensorValue) {
+	led.Event(event)
+	if err := enc.Encode(event); err != nil {
+		t.failed++
+		return
+	}
+	t.written++
+}
+
+// due reports whether a stats object is due at now. MessagePack streams
+// carry only events, so it is never due for them.
+func (t *throughput) due(now time.Time) bool {
+	if t.start.IsZero() {
+		t.start = now
+	}
+	return !msgpackOutput && now.Sub(t.start) >= statsInterval
+}
+
+// rate returns the events written per second since the last call and
+// starts counting afresh.
+func (t *throughput) rate(now time.Time) int {
+	n := int(float64(t.written) / now.Sub(t.start).Seconds())
+	t.written, t.start = 0, now
+	return n
+}
+
+// report writes the stats object for the time since the last one, with
+// the queue's figures if q is not nil.
+func (t *throughput) report(now time.Time, q *eventq.Queue) {
+	b := append(t.line[:0], `{"stats":{"events_s":`...)
+	b = fmtutil.AppendInt(b, int64(t.rate(now)))
+	b = append(b, `,"write_errors":`...)
+	b = fmtutil.AppendInt(b, int64(t.failed))
+	if q != nil {
+		b = append(b, `,"queue_most":`...)
+		b = fmtutil.AppendInt(b, int64(q.HighWater()))
+		b = append(b, `,"queue_size":`...)
+		b = fmtutil.AppendInt(b, eventq.Size)
+		b = append(b, `,"dropped":`...)
+		b = fmtutil.AppendInt(b, int64(q.Dropped()))
+	}
+	b = append(b, "}}\n"...)
+	os.Stdout.Write(b)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// single reads and writes events on the one goroutine.
+func single(sensor *bno08x.Device, enc encoder, led *statusled.LED) {
+	var t throughput
+	for {
+		now := time.Now()
+		led.Update(now)
+		if t.due(now) {
+			t.report(now, nil)
+		}
+		if event, ok := sensor.GetSensorEvent(); ok {
+			t.write(enc, led, &event)
+			continue
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
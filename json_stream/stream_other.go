@@ -0,0 +1,13 @@
+//go:build !rp2040
+
+package main
+
+import (
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// stream reads and writes events on the one goroutine.
+func stream(sensor *bno08x.Device, enc encoder, led *statusled.LED) {
+	single(sensor, enc, led)
+}
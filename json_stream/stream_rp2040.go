@@ -0,0 +1,55 @@
+//go:build rp2040
+
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/eventq"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// How long each side waits when it finds nothing to do. Short, since with
+// a core each the wait only hands the core to the scheduler.
+const idleWait = 100 * time.Microsecond
+
+// stream services the sensor on its own goroutine, which owns the bus
+// from here on, and encodes and writes on this one. With "-scheduler=cores"
+// each keeps a core busy, so a write stalled on USB only fills the queue.
+// With singleGoroutine set it reads and writes on this one instead.
+func stream(sensor *bno08x.Device, enc encoder, led *statusled.LED) {
+	if singleGoroutine {
+		single(sensor, enc, led)
+		return
+	}
+
+	var q eventq.Queue
+	go service(sensor, &q)
+
+	var t throughput
+	var event bno08x.SensorValue
+	for {
+		now := time.Now()
+		led.Update(now)
+		if t.due(now) {
+			t.report(now, &q)
+		}
+		if q.Pop(&event) {
+			t.write(enc, led, &event)
+			continue
+		}
+		time.Sleep(idleWait)
+	}
+}
+
+// service moves events from the sensor into q as fast as they come.
+func service(sensor *bno08x.Device, q *eventq.Queue) {
+	for {
+		if event, ok := sensor.GetSensorEvent(); ok {
+			q.Push(&event)
+			continue
+		}
+		time.Sleep(idleWait)
+	}
+}
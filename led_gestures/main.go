@@ -0,0 +1,359 @@
+// Package main grows the led example into a gesture-controlled NeoPixel
+// strip. Shaking the board switches the animation, drawing a circle with
+// it steps the hue round the colour wheel, tilting it sets the brightness
+// and picking it up wakes the strip after it has gone dark.
+//
+// The strip is a small state machine driven by the BNO08x's gesture
+// detectors, set out in the transitions table:
+//
+//	asleep   --pickup-->   running   strip lit
+//	running  --shake-->    running   next animation
+//	running  --circle-->   running   next hue
+//	running  --tilt-->     dimming   gravity on
+//	dimming  --settled-->  running   gravity off, brightness kept
+//	running  --idle-->     asleep    strip dark
+//
+// While dimming, the brightness follows the angle of the board's Z axis
+// from vertical, from minBrightness flat to maxBrightness on edge; holding
+// the board still for dimHold keeps it. Inputs a state has no transition
+// for are ignored, so shakes while asleep or circles while dimming do
+// nothing. The strip sleeps after sleepAfter without a gesture.
+package main
+
+import (
+	"image/color"
+	"machine"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+	"tinygo.org/x/drivers/ws2812"
+)
+
+const (
+	// Pixels on the strip
+	numPixels = 12
+	// Brightness range set by tilting, out of 255
+	minBrightness = 4
+	maxBrightness = 128
+	// Hue step of a circle gesture, degrees
+	hueStep = 45
+	// How long the board must be held still to keep a brightness
+	dimHold = 2 * time.Second
+	// Brightness change that counts as moving while dimming
+	dimBand = 4
+	// Time without a gesture before the strip goes dark
+	sleepAfter = 5 * time.Minute
+	// Gravity report interval while dimming, in microseconds
+	gravityInterval = 40000 // 25Hz
+	// Interval between animation frames
+	frameInterval = 20 * time.Millisecond
+)
+
+// stripState is the state of the strip state machine.
+type stripState uint8
+
+const (
+	asleep  stripState = iota // Dark, waiting to be picked up
+	running                   // Animating
+	dimming                   // Animating, brightness following the tilt
+)
+
+var stateNames = [...]string{asleep: "asleep", running: "running", dimming: "dimming"}
+
+// input is an event fed to the state machine.
+type input uint8
+
+const (
+	inPickup  input = iota // Pickup detector
+	inShake                // Shake detector
+	inCircle               // Circle detector
+	inTilt                 // Tilt detector
+	inSettled              // Brightness held for dimHold while dimming
+	inIdle                 // No gesture for sleepAfter
+)
+
+var inputNames = [...]string{
+	inPickup:  "pickup",
+	inShake:   "shake",
+	inCircle:  "circle",
+	inTilt:    "tilt",
+	inSettled: "settled",
+	inIdle:    "idle",
+}
+
+// transition moves the strip from one state to another on an input,
+// running action on the way.
+type transition struct {
+	from   stripState
+	on     input
+	to     stripState
+	action func(*strip) error
+}
+
+var transitions = []transition{
+	{asleep, inPickup, running, (*strip).wake},
+	{running, inShake, running, (*strip).nextMode},
+	{running, inCircle, running, (*strip).nextHue},
+	{running, inTilt, dimming, (*strip).startDimming},
+	{dimming, inSettled, running, (*strip).stopDimming},
+	{running, inIdle, asleep, (*strip).sleep},
+}
+
+// mode is an animation.
+type mode uint8
+
+const (
+	modeSolid   mode = iota // Every pixel the hue
+	modeBreathe             // The hue fading in and out
+	modeRainbow             // The colour wheel turning along the strip
+	modeChase               // A pixel of the hue running along the strip
+	numModes
+)
+
+var modeNames = [...]string{
+	modeSolid:   "solid",
+	modeBreathe: "breathe",
+	modeRainbow: "rainbow",
+	modeChase:   "chase",
+}
+
+// strip is the state machine and the animation settings it changes.
+type strip struct {
+	sensor     *bno08x.Device
+	state      stripState
+	mode       mode
+	hue        float32 // Degrees
+	brightness float32 // Out of 255
+	lit        bool
+
+	heldLevel float32   // Brightness when the board last moved while dimming
+	heldSince time.Time // When it last moved
+}
+
+// feed applies an input to the state machine, reporting whether a
+// transition took it.
+func (s *strip) feed(in input) bool {
+	for _, t := range transitions {
+		if t.from != s.state || t.on != in {
+			continue
+		}
+		if err := t.action(s); err != nil {
+			println("  "+inputNames[in]+":", err.Error())
+			return false
+		}
+		if t.to != s.state {
+			println("  "+stateNames[s.state], "->", stateNames[t.to], "on", inputNames[in])
+		}
+		s.state = t.to
+		return true
+	}
+	return false
+}
+
+func (s *strip) wake() error {
+	s.lit = true
+	println("Awake:", modeNames[s.mode])
+	return nil
+}
+
+func (s *strip) sleep() error {
+	s.lit = false
+	println("Asleep: pick the board up to wake it")
+	return nil
+}
+
+func (s *strip) nextMode() error {
+	s.mode = (s.mode + 1) % numModes
+	println("Animation:", modeNames[s.mode])
+	return nil
+}
+
+func (s *strip) nextHue() error {
+	s.hue = float32(math.Mod(float64(s.hue+hueStep), 360))
+	println("Hue:", int(s.hue))
+	return nil
+}
+
+func (s *strip) startDimming() error {
+	if err := s.sensor.EnableReport(bno08x.SensorGravity, gravityInterval); err != nil {
+		return err
+	}
+	s.heldLevel, s.heldSince = s.brightness, time.Now()
+	println("Dimming: tilt to set the brightness, then hold still")
+	return nil
+}
+
+func (s *strip) stopDimming() error {
+	s.sensor.EnableReport(bno08x.SensorGravity, 0)
+	println("Brightness:", int(s.brightness))
+	return nil
+}
+
+// tilt sets the brightness from a gravity report while dimming.
+func (s *strip) tilt(g bno08x.Vector3, now time.Time) {
+	n := math.Sqrt(float64(g.X*g.X + g.Y*g.Y + g.Z*g.Z))
+	if n == 0 {
+		return
+	}
+	// 0 flat, 1 on edge or beyond
+	t := float32(math.Acos(math.Max(-1, math.Min(1, float64(g.Z)/n))) / (math.Pi / 2))
+	if t > 1 {
+		t = 1
+	}
+	s.brightness = minBrightness + t*(maxBrightness-minBrightness)
+	if d := s.brightness - s.heldLevel; d > dimBand || d < -dimBand {
+		s.heldLevel, s.heldSince = s.brightness, now
+	}
+}
+
+// settled reports whether the brightness has been held for dimHold.
+func (s *strip) settled(now time.Time) bool {
+	return s.state == dimming && now.Sub(s.heldSince) >= dimHold
+}
+
+// render draws the animation at time t into pixels.
+func (s *strip) render(pixels []color.RGBA, t time.Duration) {
+	if !s.lit {
+		for i := range pixels {
+			pixels[i] = color.RGBA{}
+		}
+		return
+	}
+	sec := float32(t.Seconds())
+	for i := range pixels {
+		switch s.mode {
+		case modeSolid:
+			pixels[i] = hsv(s.hue, s.brightness)
+		case modeBreathe:
+			level := (1 - float32(math.Cos(float64(sec)*math.Pi))) / 2 // 2s period
+			pixels[i] = hsv(s.hue, s.brightness*level)
+		case modeRainbow:
+			pixels[i] = hsv(s.hue+sec*60+float32(i)*360/float32(len(pixels)), s.brightness)
+		case modeChase:
+			// Head at one pixel per 100ms with a fading tail
+			behind := (int(sec*10) - i) % len(pixels)
+			if behind < 0 {
+				behind += len(pixels)
+			}
+			pixels[i] = hsv(s.hue, s.brightness/float32(int(1)<<min(behind*2, 8)))
+		}
+	}
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("led_gestures")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range []bno08x.SensorID{
+		bno08x.SensorPickupDetector,
+		bno08x.SensorShakeDetector,
+		bno08x.SensorCircleDetector,
+		bno08x.SensorTiltDetector,
+	} {
+		if err := sensor.EnableReport(id, sensorinfo.DefaultIntervalMicros(id)); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	// Initialize NeoPixels
+	pixelPin := board.NeoPixelPin()
+	pixelPin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	neo := ws2812.New(pixelPin)
+	pixels := make([]color.RGBA, numPixels)
+
+	s := &strip{sensor: sensor, brightness: maxBrightness / 4}
+	s.feed(inPickup)
+	println("Shake: animation | circle: hue | tilt: brightness | pickup: wake")
+
+	start := time.Now()
+	lastGesture, lastFrame := start, start
+
+	for {
+		now := time.Now()
+
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorPickupDetector:
+				s.feed(inPickup)
+				lastGesture = now
+			case bno08x.SensorShakeDetector:
+				s.feed(inShake)
+				lastGesture = now
+			case bno08x.SensorCircleDetector:
+				s.feed(inCircle)
+				lastGesture = now
+			case bno08x.SensorTiltDetector:
+				s.feed(inTilt)
+				lastGesture = now
+			case bno08x.SensorGravity:
+				if s.state == dimming {
+					s.tilt(event.Gravity(), now)
+				}
+			}
+		} else {
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		if s.settled(now) {
+			s.feed(inSettled)
+			lastGesture = now
+		}
+		if now.Sub(lastGesture) >= sleepAfter {
+			s.feed(inIdle)
+			lastGesture = now
+		}
+
+		if now.Sub(lastFrame) >= frameInterval {
+			lastFrame = now
+			s.render(pixels, now.Sub(start))
+			neo.WriteColors(pixels)
+		}
+	}
+}
+
+// hsv returns the fully saturated colour of hue h in degrees at value v
+// out of 255.
+func hsv(h, v float32) color.RGBA {
+	h = float32(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+	sector := int(h / 60)
+	f := h/60 - float32(sector)
+	hi, rise, fall := uint8(v), uint8(v*f), uint8(v*(1-f))
+	switch sector {
+	case 0:
+		return color.RGBA{R: hi, G: rise}
+	case 1:
+		return color.RGBA{R: fall, G: hi}
+	case 2:
+		return color.RGBA{G: hi, B: rise}
+	case 3:
+		return color.RGBA{G: fall, B: hi}
+	case 4:
+		return color.RGBA{R: rise, B: hi}
+	}
+	return color.RGBA{R: hi, B: fall}
+}
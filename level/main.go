@@ -0,0 +1,300 @@
+// Package main is a digital spirit level. It shows the bubble on a NeoPixel
+// strip or ring on board.NeoPixelPin and prints roll and pitch to 0.1° on
+// the serial console, building on the led example.
+//
+// On a strip, laid along the board's X axis with the first pixel at the X-
+// end, one pixel is the bubble and moves towards the high end as that end
+// rises, fullScale degrees reaching the last pixel. On a ring, with
+// ringLayout set, the first pixel towards X+ and the rest clockwise seen
+// from above, the bubble sits on the high side, with its neighbours lit
+// while the tilt is small. The bubble is green within levelTolerance, amber
+// within ten times that and red beyond. Angles come from the gravity sensor,
+// averaged so the readout is steady.
+//
+// No sensor sits perfectly square on its board. Put the level on a surface
+// known to be flat and type "flat" to capture the offset, which is kept in
+// flash; "flip" refines it by also measuring with the level turned end for
+// end on any surface, so the surface's own slope cancels out.
+//
+//	flat    capture the offset on a flat surface
+//	flip    two-position calibration on any surface
+//	clear   forget the offset
+package main
+
+import (
+	"encoding/binary"
+	"image/color"
+	"machine"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/filter"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+	"tinygo.org/x/drivers/ws2812"
+)
+
+const (
+	// Pixels on the strip or ring
+	numPixels = 12
+	// Pixels form a ring rather than a strip
+	ringLayout = false
+	// Tilt at which the bubble reaches the end of the strip or the edge of
+	// the ring, degrees
+	fullScale = 5
+	// Tilt shown as level, degrees
+	levelTolerance = 0.1
+	// Pixel brightness, out of 255
+	brightness = 32
+)
+
+const (
+	// Gravity report rate
+	rateHz = 50
+	// Cutoff frequency of the angle smoothing filter
+	smoothingCutoffHz = 1
+	// Samples averaged by a calibration
+	calibrationSamples = 2 * rateHz
+	// Interval between readings on the console
+	printInterval = 200 * time.Millisecond
+	// Decimal places printed for the angles, 0.1° resolution
+	decimals = 1
+)
+
+// offset is the calibration, subtracted from measured angles.
+type offset struct {
+	roll, pitch float32 // Degrees
+}
+
+// calibration is a capture in progress.
+type calibration struct {
+	step       int // 0 none, 1 flat or first flip position, 2 second flip position
+	flip       bool
+	sum        [2]float64
+	n          int
+	first      offset
+	waitReturn bool // Waiting for "flip" again with the level turned round
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("level")
+
+	settings := store.New(machine.Flash, "level")
+	off := loadOffset(settings)
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	err = sensor.EnableReport(bno08x.SensorGravity, 1000000/rateHz)
+	if err != nil {
+		println("Failed to enable gravity:", err.Error())
+		return
+	}
+
+	// Initialize NeoPixels
+	ledPin := board.NeoPixelPin()
+	ledPin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	neo := ws2812.New(ledPin)
+	pixels := make([]color.RGBA, numPixels)
+
+	var cal calibration
+	var sh shell.Shell
+	sh.Register("flat", "flat", "Capture the offset on a flat surface", func(args []string) error {
+		cal = calibration{step: 1}
+		println("Hold still...")
+		return nil
+	})
+	sh.Register("flip", "flip", "Two-position calibration on any surface", func(args []string) error {
+		if cal.waitReturn {
+			cal.step, cal.waitReturn = 2, false
+		} else {
+			cal = calibration{step: 1, flip: true}
+		}
+		println("Hold still...")
+		return nil
+	})
+	sh.Register("clear", "clear", "Forget the offset", func(args []string) error {
+		off = offset{}
+		if err := settings.Clear(); err != nil {
+			return err
+		}
+		println("Offset cleared")
+		return nil
+	})
+
+	println("Format: Roll Pitch (degrees). Type 'help' for commands")
+
+	smooth := filter.EMA3{Alpha: filter.Alpha(smoothingCutoffHz, rateHz)}
+	var roll, pitch float32
+	lastPrint := time.Now()
+
+	for {
+		sh.Poll()
+
+		event, ok := sensor.GetSensorEvent()
+		if !ok || event.ID() != bno08x.SensorGravity {
+			time.Sleep(2 * time.Millisecond)
+			continue
+		}
+		g := event.Gravity()
+		gx, gy, gz := smooth.Update(g.X, g.Y, g.Z)
+		rawRoll, rawPitch := tilt(gx, gy, gz)
+
+		if cal.step != 0 && !cal.waitReturn {
+			// Calibrate on unsmoothed samples so the capture is not lagged
+			r, p := tilt(g.X, g.Y, g.Z)
+			cal.sum[0] += float64(r)
+			cal.sum[1] += float64(p)
+			cal.n++
+			if cal.n >= calibrationSamples {
+				mean := offset{roll: float32(cal.sum[0] / float64(cal.n)), pitch: float32(cal.sum[1] / float64(cal.n))}
+				cal.sum, cal.n = [2]float64{}, 0
+				switch {
+				case cal.flip && cal.step == 1:
+					cal.first, cal.waitReturn = mean, true
+					println("Turn the level end for end on the same spot and type 'flip' again")
+					continue
+				case cal.flip:
+					// Turned round, the surface's slope changes sign and the
+					// sensor's offset does not
+					mean = offset{roll: (cal.first.roll + mean.roll) / 2, pitch: (cal.first.pitch + mean.pitch) / 2}
+				}
+				off, cal = mean, calibration{}
+				if err := saveOffset(settings, off); err != nil {
+					println("Failed to save offset:", err.Error())
+				}
+				println("Offset roll", fmtutil.Float(off.roll, 2), "pitch", fmtutil.Float(off.pitch, 2), "degrees")
+			}
+			continue
+		}
+
+		roll, pitch = rawRoll-off.roll, rawPitch-off.pitch
+		show(pixels, roll, pitch)
+		neo.WriteColors(pixels)
+
+		if time.Since(lastPrint) >= printInterval {
+			lastPrint = time.Now()
+			marker := ""
+			if abs(roll) < levelTolerance && abs(pitch) < levelTolerance {
+				marker = " LEVEL"
+			}
+			println(fmtutil.FloatWidth(roll, decimals, 6), fmtutil.FloatWidth(pitch, decimals, 6)+marker)
+		}
+	}
+}
+
+// tilt returns roll and pitch in degrees from a gravity vector.
+func tilt(gx, gy, gz float32) (roll, pitch float32) {
+	roll = float32(math.Atan2(float64(gy), float64(gz)))
+	pitch = float32(math.Atan2(float64(-gx), math.Hypot(float64(gy), float64(gz))))
+	return units.RadiansToDegrees(roll), units.RadiansToDegrees(pitch)
+}
+
+// show draws the bubble into pixels.
+func show(pixels []color.RGBA, roll, pitch float32) {
+	for i := range pixels {
+		pixels[i] = color.RGBA{}
+	}
+	n := len(pixels)
+	if !ringLayout {
+		// The bubble rises: the X+ end going up is negative pitch
+		pos := (-pitch/fullScale + 1) / 2 * float32(n-1)
+		i := int(clamp(pos, 0, float32(n-1)) + 0.5)
+		pixels[i] = bubbleColour(abs(pitch))
+		return
+	}
+	amount := float32(math.Hypot(float64(roll), float64(pitch)))
+	c := bubbleColour(amount)
+	if amount < levelTolerance {
+		for i := range pixels {
+			pixels[i] = c
+		}
+		return
+	}
+	// Direction of the high side, clockwise from X+. The X+ end rising is
+	// negative pitch; the Y+ side, anticlockwise from X+, rising is
+	// positive roll
+	dir := math.Atan2(float64(-roll), float64(-pitch))
+	i := int(math.Round(dir/(2*math.Pi)*float64(n))+float64(n)) % n
+	pixels[i] = c
+	// Near level the neighbours glow too, so the bubble looks centred
+	if amount < fullScale/4 {
+		pixels[(i+1)%n], pixels[(i+n-1)%n] = dim(c), dim(c)
+	}
+}
+
+// bubbleColour returns green when level, amber when close and red beyond.
+func bubbleColour(angle float32) color.RGBA {
+	switch {
+	case angle < levelTolerance:
+		return color.RGBA{G: brightness}
+	case angle < 10*levelTolerance:
+		return color.RGBA{R: brightness, G: brightness / 2}
+	}
+	return color.RGBA{R: brightness}
+}
+
+func dim(c color.RGBA) color.RGBA {
+	return color.RGBA{R: c.R / 4, G: c.G / 4, B: c.B / 4}
+}
+
+func clamp(v, lo, hi float32) float32 {
+	switch {
+	case v < lo:
+		return lo
+	case v > hi:
+		return hi
+	}
+	return v
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// loadOffset returns the offset stored in flash, or none.
+func loadOffset(settings *store.Store) offset {
+	var buf [8]byte
+	n, err := settings.Load(buf[:])
+	if err != nil || n != len(buf) {
+		if err != nil && err != store.ErrEmpty {
+			println("Stored offset unusable:", err.Error())
+		}
+		return offset{}
+	}
+	return offset{
+		roll:  math.Float32frombits(binary.LittleEndian.Uint32(buf[0:])),
+		pitch: math.Float32frombits(binary.LittleEndian.Uint32(buf[4:])),
+	}
+}
+
+// saveOffset stores off in flash.
+func saveOffset(settings *store.Store, off offset) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint32(buf[0:], math.Float32bits(off.roll))
+	binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(off.pitch))
+	return settings.Save(buf[:])
+}
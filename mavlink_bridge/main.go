@@ -0,0 +1,157 @@
+// Package main feeds BNO08x data to a MAVLink ground station or flight
+// controller companion over a UART: ATTITUDE_QUATERNION from the rotation
+// vector and HIGHRES_IMU from the accelerometer, gyroscope and
+// magnetometer, with a HEARTBEAT every second so the component shows up.
+// Connect the TX pin of board.LinkUART to the receiver's RX, or to a
+// telemetry radio, at baudRate.
+//
+// The sensor reports in east, north, up world axes and, with the board's
+// X axis forward, a forward, left, up body frame; MAVLink wants north,
+// east, down and forward, right, down, so everything is rotated on the
+// way out. Mount the board with X towards the vehicle's nose.
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/mavlink"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// UART speed; 50Hz of both messages needs about 60kbit/s
+	baudRate = 115200
+	// MAVLink system and component IDs. Use the vehicle's system ID when
+	// feeding a flight controller's companion link.
+	systemID    = 1
+	componentID = mavlink.ComponentIMU
+	// Report interval in microseconds, and so the message rate
+	interval = 20000 // 50Hz
+	// Interval between heartbeats and console status lines
+	heartbeatInterval = time.Second
+	statusInterval    = 5 * time.Second
+)
+
+var (
+	// enuToNED turns east, north, up world axes into north, east, down
+	enuToNED = bno08x.Quaternion{Real: 0, I: math.Sqrt2 / 2, J: math.Sqrt2 / 2, K: 0}
+	// fluToFRD turns a forward, left, up body frame into forward, right, down
+	fluToFRD = bno08x.Quaternion{Real: 0, I: 1, J: 0, K: 0}
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("mavlink_bridge")
+	start := time.Now()
+
+	uart, config := board.LinkUART()
+	if uart == nil {
+		println("No link UART assigned for the " + board.Name + " board, see internal/board")
+		return
+	}
+	config.BaudRate = baudRate
+	if err := uart.Configure(config); err != nil {
+		println("Failed to configure UART:", err.Error())
+		return
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range []bno08x.SensorID{
+		bno08x.SensorRotationVector,
+		bno08x.SensorAccelerometer,
+		bno08x.SensorGyroscope,
+		bno08x.SensorMagneticField,
+	} {
+		if err := sensor.EnableReport(id, interval); err != nil {
+			println("Failed to enable report", uint8(id), err.Error())
+			return
+		}
+	}
+
+	enc := mavlink.Encoder{System: systemID, Component: componentID}
+	var packet [mavlink.MaxPacketLen]byte
+	send := func(b []byte) {
+		if _, err := uart.Write(b); err != nil {
+			println("UART write failed:", err.Error())
+		}
+	}
+
+	var imu mavlink.HighresIMU
+	var attitudes, imus uint32
+	lastHeartbeat := time.Time{}
+	lastStatus := time.Now()
+
+	println("Sending MAVLink at", baudRate, "baud")
+	for {
+		now := time.Now()
+		if now.Sub(lastHeartbeat) >= heartbeatInterval {
+			lastHeartbeat = now
+			send(enc.AppendHeartbeat(packet[:0], mavlink.Heartbeat{
+				Type:         mavlink.TypeGeneric,
+				Autopilot:    mavlink.AutopilotInvalid,
+				SystemStatus: mavlink.StateActive,
+			}))
+		}
+
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorRotationVector:
+				q := quat.Multiply(quat.Multiply(enuToNED, event.Quaternion()), fluToFRD)
+				send(enc.AppendAttitudeQuaternion(packet[:0], mavlink.AttitudeQuaternion{
+					TimeBootMs: uint32(now.Sub(start).Milliseconds()),
+					Q1:         q.Real, Q2: q.I, Q3: q.J, Q4: q.K,
+					RollSpeed: imu.XGyro, PitchSpeed: imu.YGyro, YawSpeed: imu.ZGyro,
+				}))
+				attitudes++
+			case bno08x.SensorAccelerometer:
+				a := event.Accelerometer()
+				imu.XAcc, imu.YAcc, imu.ZAcc = a.X, -a.Y, -a.Z
+				imu.FieldsUpdated |= mavlink.UpdatedAccel
+			case bno08x.SensorMagneticField:
+				m := event.MagneticField()
+				imu.XMag = units.MicroteslaToGauss(m.X)
+				imu.YMag = units.MicroteslaToGauss(-m.Y)
+				imu.ZMag = units.MicroteslaToGauss(-m.Z)
+				imu.FieldsUpdated |= mavlink.UpdatedMag
+			case bno08x.SensorGyroscope:
+				// The gyroscope paces HIGHRES_IMU; the other fields carry
+				// their latest values, flagged if they changed since
+				g := event.Gyroscope()
+				imu.XGyro, imu.YGyro, imu.ZGyro = g.X, -g.Y, -g.Z
+				imu.FieldsUpdated |= mavlink.UpdatedGyro
+				imu.TimeUsec = uint64(now.Sub(start).Microseconds())
+				send(enc.AppendHighresIMU(packet[:0], imu))
+				imu.FieldsUpdated = 0
+				imus++
+			}
+		}
+
+		if now.Sub(lastStatus) >= statusInterval {
+			lastStatus = now
+			println("Sent", attitudes, "ATTITUDE_QUATERNION,", imus, "HIGHRES_IMU")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,243 @@
+// Package main turns conducting into a tempo. Each beat of the hand, a
+// sharp downward stroke, is a peak in the vertical linear acceleration;
+// the median of the last few beat intervals is the tempo, which drives a
+// MIDI clock on the USB MIDI port, so a DAW or drum machine set to follow
+// external clock plays along, and flashes the LED on every quarter note.
+//
+// The clock starts (MIDI Start) once startBeats beats in a row fall within
+// minBPM and maxBPM, runs at 24 pulses per quarter note, and stops (MIDI
+// Stop) when no beat has come for stopBeats quarter notes. Between beats
+// it free-runs at the last tempo; each beat pulls its phase halfway
+// towards the beat, so the music follows the conductor without the
+// pulses bunching up.
+//
+// Vertical is taken in the world frame with the game rotation vector, so
+// the baton or hand can be held at any angle.
+package main
+
+import (
+	"machine"
+	"machine/usb/adc/midi"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/filter"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Linear acceleration and game rotation vector report interval in
+	// microseconds
+	interval = 5000 // 200Hz
+	// Downward acceleration that counts as a beat, m/s²
+	beatThreshold = 15
+	// Tempo range followed; beats outside it start a new run
+	minBPM = 40
+	maxBPM = 200
+	// Beats in a row before the clock starts
+	startBeats = 3
+	// Beat intervals the tempo is the median of
+	tempoWindow = 5
+	// Quarter notes without a beat before the clock stops
+	stopBeats = 4
+	// Time the LED stays lit for each quarter note
+	flashTime = 60 * time.Millisecond
+)
+
+const (
+	// MIDI cable (0-15)
+	midiCable = 0
+	// MIDI clock pulses per quarter note
+	pulsesPerQuarter = 24
+)
+
+// MIDI system real-time messages
+const (
+	midiClock = 0xF8
+	midiStart = 0xFA
+	midiStop  = 0xFC
+)
+
+// beatDetector finds the peaks of downward acceleration.
+type beatDetector struct {
+	armed    bool    // Below half the threshold since the last beat
+	tracking bool    // Above the threshold, waiting for the peak
+	peak     float32 // Largest downward acceleration of the stroke, m/s²
+}
+
+// update feeds a downward acceleration and reports whether a beat just
+// peaked.
+func (d *beatDetector) update(down float32) bool {
+	switch {
+	case d.tracking:
+		if down > d.peak {
+			d.peak = down
+			return false
+		}
+		// Falling from the peak: the beat
+		d.tracking = false
+		return true
+	case down >= beatThreshold && d.armed:
+		d.armed, d.tracking, d.peak = false, true, down
+	case down < beatThreshold/2:
+		d.armed = true
+	}
+	return false
+}
+
+// clock generates the MIDI clock.
+type clock struct {
+	running bool
+	quarter time.Duration // Length of a quarter note
+	next    time.Time     // Time of the next pulse
+	pulse   int           // Pulses sent in the current quarter note
+	start   time.Time     // Time of the current quarter note's first pulse
+}
+
+// beat follows a beat at now, with the tempo's quarter note length.
+func (c *clock) beat(now time.Time, quarter time.Duration) {
+	c.quarter = quarter
+	if !c.running {
+		c.running = true
+		c.next, c.pulse = now, 0
+		send(midiStart)
+		return
+	}
+	// Error of the beat from the nearest quarter note, pulled in halfway
+	err := now.Sub(c.start)
+	if err > quarter/2 {
+		err -= quarter
+	}
+	c.next = c.next.Add(err / 2)
+	c.start = c.start.Add(err / 2)
+}
+
+// stop stops the clock.
+func (c *clock) stop() {
+	if c.running {
+		c.running = false
+		send(midiStop)
+	}
+}
+
+// poll sends the pulses that are due and reports whether one started a
+// quarter note.
+func (c *clock) poll(now time.Time) bool {
+	quarterStarted := false
+	for c.running && !now.Before(c.next) {
+		send(midiClock)
+		if c.pulse == 0 {
+			c.start, quarterStarted = c.next, true
+		}
+		c.pulse = (c.pulse + 1) % pulsesPerQuarter
+		c.next = c.next.Add(c.quarter / pulsesPerQuarter)
+	}
+	return quarterStarted
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("metronome")
+
+	led := board.LEDPin()
+	if led != machine.NoPin {
+		led.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		led.Low()
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range []bno08x.SensorID{bno08x.SensorLinearAcceleration, bno08x.SensorGameRotationVector} {
+		if err := sensor.EnableReport(id, interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	println("Conduct: beat", startBeats, "times to start the MIDI clock")
+
+	var detector beatDetector
+	var clk clock
+	tempo := filter.Median{Size: tempoWindow}
+	orientation := quat.Identity
+	var lastBeat, flashStart time.Time
+	run := 0 // Beats in a row within the tempo range
+
+	for {
+		now := time.Now()
+
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorGameRotationVector:
+				orientation = event.Quaternion()
+			case bno08x.SensorLinearAcceleration:
+				a := event.LinearAcceleration()
+				_, _, up := quat.Rotate(orientation, a.X, a.Y, a.Z)
+				if !detector.update(-up) {
+					break
+				}
+				gap := now.Sub(lastBeat)
+				lastBeat = now
+				if gap < time.Minute/maxBPM || gap > time.Minute/minBPM {
+					// Too fast to be a tempo, or the first beat of a new run
+					tempo.Reset()
+					run = 1
+					continue
+				}
+				run++
+				ms := tempo.Update(float32(gap.Milliseconds()))
+				quarter := time.Duration(ms) * time.Millisecond
+				if run >= startBeats {
+					if !clk.running {
+						println("Clock started")
+					}
+					clk.beat(now, quarter)
+				}
+				println("Beat", run, "| tempo", fmtutil.Float(float32(time.Minute)/float32(quarter), 1), "bpm")
+			}
+		}
+
+		if clk.running && now.Sub(lastBeat) > stopBeats*clk.quarter {
+			clk.stop()
+			run = 0
+			println("Clock stopped")
+		}
+		if clk.poll(now) {
+			flashStart = now
+		}
+		if led != machine.NoPin {
+			led.Set(clk.running && now.Sub(flashStart) < flashTime)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// send writes a one-byte system real-time message as a USB MIDI event
+// packet: code index 0xF, the message, and two unused bytes.
+func send(status byte) {
+	midi.Port().Write([]byte{midiCable<<4 | 0x0F, status, 0, 0})
+}
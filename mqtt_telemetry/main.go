@@ -0,0 +1,193 @@
+// Package main publishes the sensor to an MQTT broker over WiFi, one
+// topic per sensor with an internal/jsonout object as the payload:
+//
+//	imu/quat    rotation vector, every quatInterval
+//	imu/accel   accelerometer, every accelInterval
+//	imu/steps   step counter, on every step
+//	imu/status  retained, on connect and every statusInterval
+//
+// The status payload is {"state":"online","uptime_s":..,"published":..,
+// "dropped":..,"connects":..}; if the board drops off, the broker replaces
+// it with {"state":"offline"}, the client's last will. A lost connection
+// is retried with backoff, and messages published meanwhile are dropped
+// and counted.
+//
+// Build with "-tags wifi" for a board with a netdev WiFi driver, such as
+// the Pico W, after setting the network and broker in wifi.go. Other
+// builds print the messages on the console instead.
+//
+// Typing "selftest" on the serial console runs the health checks in place;
+// nothing is published while they run.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dispatch"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/jsonout"
+	"github.com/intermernet/bno08xPrograms/internal/output"
+	"github.com/intermernet/bno08xPrograms/internal/selftest"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/statusled"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Topics
+const (
+	quatTopic   = "imu/quat"
+	accelTopic  = "imu/accel"
+	stepsTopic  = "imu/steps"
+	statusTopic = "imu/status"
+)
+
+// Publish intervals
+const (
+	quatInterval   = 100 * time.Millisecond
+	accelInterval  = 500 * time.Millisecond
+	statusInterval = 30 * time.Second
+)
+
+// Client ID and keep-alive presented to the broker
+const (
+	clientID  = "bno08x-" + board.Name
+	keepAlive = 60 * time.Second
+)
+
+// Retained status the broker publishes if the board drops off
+const offlineStatus = `{"state":"offline"}`
+
+// Decimal places in the JSON payloads
+const decimals = 4
+
+// publisher sends messages to the broker.
+type publisher interface {
+	// Poll keeps the connection up and reports whether a new one started.
+	Poll(now time.Time) (bool, error)
+	Publish(topic string, payload []byte, retain bool) error
+}
+
+// Message counts for the status topic
+var published, dropped uint32
+
+// topicSink publishes each event it is sent on one topic.
+type topicSink struct {
+	pub   publisher
+	topic string
+	enc   *jsonout.Encoder
+	buf   [256]byte
+}
+
+// Send implements output.Sink.
+func (t *topicSink) Send(event *bno08x.SensorValue) {
+	if err := t.pub.Publish(t.topic, t.enc.Append(t.buf[:0], event), false); err != nil {
+		dropped++
+		return
+	}
+	published++
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("mqtt_telemetry")
+	led := statusled.New()
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, bus, err := transport.OpenSensorBus()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		led.Halt(statusled.BusError)
+	}
+
+	println("Sensor initialized successfully")
+
+	reports := []struct {
+		id       bno08x.SensorID
+		interval uint32
+	}{
+		{bno08x.SensorRotationVector, uint32(quatInterval / time.Microsecond)},
+		{bno08x.SensorAccelerometer, uint32(accelInterval / time.Microsecond)},
+		{bno08x.SensorStepCounter, sensorinfo.DefaultIntervalMicros(bno08x.SensorStepCounter)},
+	}
+	for _, r := range reports {
+		if err := sensor.EnableReport(r.id, r.interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(r.id)+":", err.Error())
+			led.Halt(statusled.BusError)
+		}
+	}
+
+	pub := connect()
+	enc := jsonout.New(nil, decimals)
+
+	var mux output.Mux
+	mux.Add(&topicSink{pub: pub, topic: quatTopic, enc: enc}, quatInterval, bno08x.SensorRotationVector)
+	mux.Add(&topicSink{pub: pub, topic: accelTopic, enc: enc}, accelInterval, bno08x.SensorAccelerometer)
+	mux.Add(&topicSink{pub: pub, topic: stepsTopic, enc: enc}, 0, bno08x.SensorStepCounter)
+
+	events := dispatch.New()
+	events.HandleDefault(func(event *bno08x.SensorValue) {
+		led.Event(event)
+		mux.Send(event)
+	})
+
+	var sh shell.Shell
+	sh.Register(selftest.Command, selftest.Command, "Run the sensor health checks", func(args []string) error {
+		result := selftest.Run(bus, sensor, 2*time.Second)
+		result.Print()
+		return nil
+	})
+
+	start := time.Now()
+	var connects uint32
+	var status [128]byte
+	lastStatus := time.Now()
+
+	for {
+		sh.Poll()
+		now := time.Now()
+		led.Update(now)
+		started, err := pub.Poll(now)
+		if err != nil {
+			println("MQTT:", err.Error())
+		}
+		if started {
+			connects++
+			println("Connected to broker")
+		}
+		if started || now.Sub(lastStatus) >= statusInterval {
+			lastStatus = now
+			b := appendStatus(status[:0], now.Sub(start), connects)
+			if err := pub.Publish(statusTopic, b, true); err != nil {
+				println("Status not published:", err.Error())
+			}
+		}
+
+		if !events.Poll(sensor) {
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+}
+
+// appendStatus appends the online status payload to dst.
+func appendStatus(dst []byte, uptime time.Duration, connects uint32) []byte {
+	dst = append(dst, `{"state":"online","uptime_s":`...)
+	dst = fmtutil.AppendInt(dst, int64(uptime/time.Second))
+	dst = append(dst, `,"published":`...)
+	dst = fmtutil.AppendInt(dst, int64(published))
+	dst = append(dst, `,"dropped":`...)
+	dst = fmtutil.AppendInt(dst, int64(dropped))
+	dst = append(dst, `,"connects":`...)
+	dst = fmtutil.AppendInt(dst, int64(connects))
+	return append(dst, '}')
+}
@@ -0,0 +1,47 @@
+//go:build wifi
+
+package main
+
+import (
+	"net"
+
+	"github.com/intermernet/bno08xPrograms/internal/mqtt"
+	"tinygo.org/x/drivers/netlink"
+	"tinygo.org/x/drivers/netlink/probe"
+)
+
+// WiFi network to join and broker address
+const (
+	ssid       = ""
+	passphrase = ""
+	broker     = "192.168.1.10:1883"
+)
+
+func connect() publisher {
+	link, _ := probe.Probe()
+	joined := false
+	dial := func() (mqtt.Conn, error) {
+		if !joined {
+			println("Joining WiFi network", ssid+"...")
+			err := link.NetConnect(&netlink.ConnectParams{Ssid: ssid, Passphrase: passphrase})
+			if err != nil {
+				return nil, err
+			}
+			joined = true
+		}
+		conn, err := net.Dial("tcp", broker)
+		if err != nil {
+			// Rejoin on the next attempt in case the network went away
+			joined = false
+			return nil, err
+		}
+		return conn, nil
+	}
+	println("Publishing to", broker)
+	return mqtt.New(dial, mqtt.Options{
+		ClientID:    clientID,
+		KeepAlive:   keepAlive,
+		WillTopic:   statusTopic,
+		WillMessage: offlineStatus,
+	})
+}
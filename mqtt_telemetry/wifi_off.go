@@ -0,0 +1,29 @@
+//go:build !wifi
+
+package main
+
+import "time"
+
+// consolePublisher prints messages instead of publishing them, on boards
+// without WiFi or builds without "-tags wifi".
+type consolePublisher struct {
+	started bool
+}
+
+func (p *consolePublisher) Poll(now time.Time) (bool, error) {
+	if p.started {
+		return false, nil
+	}
+	p.started = true
+	return true, nil
+}
+
+func (p *consolePublisher) Publish(topic string, payload []byte, retain bool) error {
+	println(topic, string(payload))
+	return nil
+}
+
+func connect() publisher {
+	println("Built without -tags wifi: messages are printed, not published")
+	return &consolePublisher{}
+}
@@ -1,29 +1,59 @@
 // Package main demonstrates reading multiple sensor types simultaneously
 // including accelerometer, gyroscope, and magnetometer data.
+// Set csvOutput to log every sample as a CSV row instead, or teleplotOutput
+// to plot every axis live in the Teleplot VS Code extension.
+//
+// The sensor is read when its INT line signals data through
+// internal/irqpoll, falling back to polling every 10ms without INT wired;
+// the summary includes how soon after the INT edge each read started. Set
+// comparePolling to measure polling every 10ms on the same board too.
+//
+// Typing "selftest" on the serial console runs the health checks in place.
 package main
 
 import (
-	"machine"
 	"time"
 
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/csvout"
+	"github.com/intermernet/bno08xPrograms/internal/irqpoll"
+	"github.com/intermernet/bno08xPrograms/internal/selftest"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/teleplot"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 	"tinygo.org/x/drivers/bno08x"
 )
 
+// Set to true to print every sample as a CSV row with a timestamp and
+// sensor ID, after a header row, instead of a rate-limited summary
+const csvOutput = false
+
+// Set to true to print every sample in the Teleplot format, as accel_x,
+// gyro_x, mag_x and so on; csvOutput takes precedence
+const teleplotOutput = false
+
+// Decimal places in CSV rows
+const decimals = 4
+
+// Set to true to switch between waking on INT and polling every
+// irqpoll.PollInterval after each latency line, so the lines measure both
+const comparePolling = false
+
+// Interval between latency lines in the summary
+const latencyInterval = 5 * time.Second
+
 func main() {
-	// Initialize I2C bus
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{
-		Frequency: 400 * machine.KHz,
-	})
-	if err != nil {
-		println("Failed to configure I2C:", err.Error())
-		return
-	}
+	buildinfo.Banner("multi_sensor")
 
 	println("Initializing BNO08x sensor...")
 
 	// Create and configure sensor
-	sensor := bno08x.New(i2c)
+	sensor, bus, err := transport.OpenSensorBus()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
 	err = sensor.Configure(bno08x.Config{})
 	if err != nil {
 		println("Failed to configure sensor:", err.Error())
@@ -47,40 +77,95 @@ func main() {
 		}
 	}
 
-	println("Reading sensor data...")
+	irq, err := irqpoll.New(board.IntPin())
+	if err != nil {
+		println("No INT interrupt, polling instead:", err.Error())
+	}
+
+	csv := csvout.New(decimals, "x", "y", "z")
+	plot := teleplot.New(decimals)
+	if csvOutput {
+		csv.Header()
+	} else if !teleplotOutput {
+		println("Reading sensor data...")
+	}
 
 	// Track last print time for each sensor
 	lastPrint := make(map[bno08x.SensorID]time.Time)
 	printInterval := 500 * time.Millisecond
 
-	// Main loop - read and display sensor data
+	var sh shell.Shell
+	sh.Register(selftest.Command, selftest.Command, "Run the sensor health checks", func(args []string) error {
+		result := selftest.Run(bus, sensor, 2*time.Second)
+		result.Print()
+		return nil
+	})
+
+	lastLatency := time.Now()
+	polling := false
+
+	// Main loop - wait for data, then read and display all of it
 	for {
-		event, ok := sensor.GetSensorEvent()
-		if !ok {
-			time.Sleep(time.Millisecond)
-			continue
+		sh.Poll()
+		if !csvOutput && !teleplotOutput && irq.Interrupts() && time.Since(lastLatency) >= latencyInterval {
+			lastLatency = time.Now()
+			println(irq.Latency().String())
+			if comparePolling {
+				polling = !polling
+				irq.SetPolling(polling)
+			}
 		}
-
-		// Rate limit printing for each sensor type
-		now := time.Now()
-		if now.Sub(lastPrint[event.ID]) < printInterval {
+		if !irq.Wait(100 * time.Millisecond) {
 			continue
 		}
-		lastPrint[event.ID] = now
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+
+			if csvOutput {
+				var v bno08x.Vector3
+				switch event.ID() {
+				case bno08x.SensorAccelerometer:
+					v = event.Accelerometer()
+				case bno08x.SensorGyroscope:
+					v = event.Gyroscope()
+				case bno08x.SensorMagneticField:
+					v = event.MagneticField()
+				default:
+					continue
+				}
+				csv.Row(event.ID(), v.X, v.Y, v.Z)
+				continue
+			}
+			if teleplotOutput {
+				plot.Send(&event)
+				continue
+			}
+
+			// Rate limit printing for each sensor type
+			now := time.Now()
+			if now.Sub(lastPrint[event.ID()]) < printInterval {
+				continue
+			}
+			lastPrint[event.ID()] = now
 
-		// Display data based on sensor type
-		switch event.ID() {
-		case bno08x.SensorAccelerometer:
-			a := event.Accelerometer()
-			println("Accel (m/s²):", a.X, a.Y, a.Z)
+			// Display data based on sensor type
+			switch event.ID() {
+			case bno08x.SensorAccelerometer:
+				a := event.Accelerometer()
+				println("Accel (m/s²):", a.X, a.Y, a.Z)
 
-		case bno08x.SensorGyroscope:
-			g := event.Gyroscope()
-			println("Gyro (rad/s):", g.X, g.Y, g.Z)
+			case bno08x.SensorGyroscope:
+				g := event.Gyroscope()
+				println("Gyro (rad/s):", g.X, g.Y, g.Z)
 
-		case bno08x.SensorMagneticField:
-			m := event.MagneticField()
-			println("Mag (µT):   ", m.X, m.Y, m.Z)
+			case bno08x.SensorMagneticField:
+				m := event.MagneticField()
+				println("Mag (µT):   ", m.X, m.Y, m.Z)
+			}
 		}
+		irq.Done()
 	}
 }
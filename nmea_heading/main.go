@@ -0,0 +1,127 @@
+// Package main makes the BNO08x a heading sensor for chartplotters and
+// autopilots, sending NMEA 0183 $HEHDT (true heading) and $HCHDM
+// (magnetic heading) sentences from the rotation vector on the TX pin of
+// board.LinkUART at 4800 baud. Mount the board level with its X axis
+// towards the bow.
+//
+// Set the local magnetic declination in the constant below, or at runtime
+// with "decl <degrees>"; it turns the magnetic heading into the true one.
+// Each sentence is echoed on the console every echoInterval.
+//
+//	decl <degrees>  set the magnetic declination, east positive
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/heading"
+	"github.com/intermernet/bno08xPrograms/internal/nmea"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Magnetic declination in degrees, east positive
+const declination = 0.0
+
+const (
+	// Interval between sentence pairs; 10Hz fits 4800 baud
+	sentenceInterval = 100 * time.Millisecond
+	// Interval between sentences echoed on the console
+	echoInterval = time.Second
+)
+
+// Decimal places printed for the declination
+const decimals = 1
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("nmea_heading")
+
+	uart, config := board.LinkUART()
+	if uart == nil {
+		println("No link UART assigned for the " + board.Name + " board, see internal/board")
+		return
+	}
+	config.BaudRate = nmea.BaudRate
+	if err := uart.Configure(config); err != nil {
+		println("Failed to configure UART:", err.Error())
+		return
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The rotation vector, unlike the game rotation vector, is referenced
+	// to magnetic north
+	err = sensor.EnableReport(bno08x.SensorRotationVector, 20000) // 50Hz
+	if err != nil {
+		println("Failed to enable rotation vector:", err.Error())
+		return
+	}
+
+	compass := heading.Compass{Declination: declination}
+
+	var sh shell.Shell
+	sh.Register("decl", "decl <degrees>", "Set the magnetic declination, east positive", func(args []string) error {
+		if len(args) != 1 {
+			return shell.ErrUsage
+		}
+		d, err := strconv.ParseFloat(args[0], 32)
+		if err != nil {
+			return shell.ErrUsage
+		}
+		compass.Declination = float32(d)
+		println("Declination set to", fmtutil.Float(compass.Declination, decimals), "degrees")
+		return nil
+	})
+
+	println("Sending HDT and HDM at", nmea.BaudRate, "baud")
+	var buf [2 * nmea.MaxLen]byte
+	var magnetic float32
+	haveHeading := false
+	lastSentence := time.Now()
+	lastEcho := time.Now()
+
+	for {
+		sh.Poll()
+
+		if event, ok := sensor.GetSensorEvent(); ok && event.ID() == bno08x.SensorRotationVector {
+			magnetic = heading.Magnetic(event.Quaternion())
+			haveHeading = true
+		}
+
+		if haveHeading && time.Since(lastSentence) >= sentenceInterval {
+			lastSentence = time.Now()
+			b := nmea.AppendHDT(buf[:0], "HE", compass.True(magnetic))
+			b = nmea.AppendHDM(b, "HC", magnetic)
+			if _, err := uart.Write(b); err != nil {
+				println("UART write failed:", err.Error())
+			}
+			if time.Since(lastEcho) >= echoInterval {
+				lastEcho = time.Now()
+				// Sentences end in CR LF already
+				print(string(b))
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,82 @@
+// Package main detects yes and no head gestures: a nod (pitch going up and
+// down) prints YES, a head shake (yaw going side to side) prints NO. Mount
+// the board on a headband or cap with the sensor's X axis pointing forward.
+//
+// The classification lives in internal/gesture.NodDetector; this program
+// only feeds it angles, and prints the swing settings so they can be tuned.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Classifier settings; see gesture.NodDetector
+const (
+	swingThreshold = 10 // Degrees
+	gestureWindow  = 1500 * time.Millisecond
+	minSwings      = 3
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("nod_detect")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The game rotation vector is smooth and free of magnetic jumps, and
+	// only short-term changes in yaw matter here
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 20000) // 50Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	println("Swing threshold:", swingThreshold, "deg | Window:", gestureWindow.Milliseconds(), "ms | Swings:", minSwings)
+	println("Nod for yes, shake your head for no")
+
+	detector := gesture.NodDetector{
+		Threshold: swingThreshold,
+		Window:    gestureWindow,
+		MinSwings: minSwings,
+	}
+
+	for {
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		if event.ID() != bno08x.SensorGameRotationVector {
+			continue
+		}
+
+		_, pitch, yaw := quat.ToEuler(event.Quaternion())
+		switch detector.Add(units.RadiansToDegrees(pitch), units.RadiansToDegrees(yaw), time.Now()) {
+		case gesture.Nod:
+			println("YES (nod)")
+		case gesture.Shake:
+			println("NO (shake)")
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package main sends the orientation as Open Sound Control over WiFi UDP,
+// for TouchDesigner, Max/MSP, SuperCollider and other tools that take OSC
+// input. Every message period a bundle carries two messages:
+//
+//	/imu/quat   w x y z           unit quaternion
+//	/imu/euler  roll pitch yaw    degrees
+//
+// all float32 arguments. Set the rate below and the destination in wifi.go.
+//
+// Build with "-tags wifi" for a board with a netdev WiFi driver, such as
+// the Pico W. Other builds print the angles on the console instead.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/osc"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Bundles per second
+const messageRate = 50
+
+// OSC addresses of the two messages
+const (
+	quatAddress  = "/imu/quat"
+	eulerAddress = "/imu/euler"
+)
+
+// Length of a bundle: header, then each message's length, padded address,
+// type tags and arguments
+const bundleLen = 16 + (4 + 12 + 8 + 16) + (4 + 12 + 8 + 12)
+
+// sender delivers bundles to the OSC host.
+type sender interface {
+	Send(packet []byte) error
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("osc_control")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// Sampled at twice the message rate so every bundle carries a fresh
+	// orientation
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, uint32(time.Second/time.Microsecond/messageRate/2))
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	out, err := dial()
+	if err != nil {
+		println("Failed to open UDP socket:", err.Error())
+		return
+	}
+
+	var q bno08x.Quaternion
+	haveQuat := false
+	var packet [bundleLen]byte
+	var sendErrors uint32
+	period := time.Second / messageRate
+	next := time.Now()
+
+	for {
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			if event.ID() == bno08x.SensorGameRotationVector {
+				q, haveQuat = event.Quaternion(), true
+			}
+		}
+
+		if now := time.Now(); haveQuat && !now.Before(next) {
+			next = now.Add(period)
+			roll, pitch, yaw := quat.ToEuler(q)
+			b := osc.AppendBundle(packet[:0], osc.Immediately)
+			b = osc.AppendBundleMessage(b, quatAddress, q.Real, q.I, q.J, q.K)
+			b = osc.AppendBundleMessage(b, eulerAddress,
+				units.RadiansToDegrees(roll), units.RadiansToDegrees(pitch), units.RadiansToDegrees(yaw))
+			if err := out.Send(b); err != nil {
+				sendErrors++
+				// Report the first failure and then every hundredth
+				if sendErrors%100 == 1 {
+					println("Send failed:", err.Error(), "-", sendErrors, "failures so far")
+				}
+			}
+		}
+
+		time.Sleep(2 * time.Millisecond)
+	}
+}
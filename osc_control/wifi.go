@@ -0,0 +1,44 @@
+//go:build wifi
+
+package main
+
+import (
+	"net"
+
+	"tinygo.org/x/drivers/netlink"
+	"tinygo.org/x/drivers/netlink/probe"
+)
+
+// WiFi network to join, and the host and port listening for OSC. The
+// subnet's broadcast address reaches every host on the network, where
+// the network allows it.
+const (
+	ssid        = ""
+	passphrase  = ""
+	destination = "192.168.1.255:9000"
+)
+
+// udpSender sends datagrams on a connected UDP socket.
+type udpSender struct {
+	conn net.Conn
+}
+
+func (u *udpSender) Send(packet []byte) error {
+	_, err := u.conn.Write(packet)
+	return err
+}
+
+func dial() (sender, error) {
+	link, _ := probe.Probe()
+	println("Joining WiFi network", ssid+"...")
+	err := link.NetConnect(&netlink.ConnectParams{Ssid: ssid, Passphrase: passphrase})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("udp", destination)
+	if err != nil {
+		return nil, err
+	}
+	println("Sending OSC to", destination)
+	return &udpSender{conn: conn}, nil
+}
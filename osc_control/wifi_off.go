@@ -0,0 +1,39 @@
+//go:build !wifi
+
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+)
+
+// Interval between lines printed by consoleSender
+const printInterval = time.Second
+
+// consoleSender prints the Euler angles of each bundle instead of sending
+// it, on boards without WiFi or builds without "-tags wifi".
+type consoleSender struct {
+	lastPrint time.Time
+}
+
+func (c *consoleSender) Send(packet []byte) error {
+	if time.Since(c.lastPrint) < printInterval {
+		return nil
+	}
+	c.lastPrint = time.Now()
+	// The /imu/euler arguments end the bundle
+	args := packet[len(packet)-12:]
+	angle := func(i int) string {
+		return fmtutil.Float(math.Float32frombits(binary.BigEndian.Uint32(args[i*4:])), 1)
+	}
+	println("Roll:", angle(0), "Pitch:", angle(1), "Yaw:", angle(2))
+	return nil
+}
+
+func dial() (sender, error) {
+	println("Built without -tags wifi: angles are printed, not sent")
+	return &consoleSender{}, nil
+}
@@ -0,0 +1,238 @@
+// Package main is a pedometer and activity tracker. It counts steps from
+// the step counter into hourly buckets, estimates the distance from the
+// stride length, follows the personal activity classifier to log when the
+// wearer starts walking, running, cycling or sits still, and prints a
+// summary of the day at midnight.
+//
+// The totals are saved to flash every saveInterval, at midnight and on
+// "save", so a reset loses at most the last few minutes. The boards have
+// no real-time clock: the time of day runs from the uptime counter and,
+// after a reset, carries on from the time of the last save, so set it with
+// "time" once the board is running.
+//
+//	today          print today's totals
+//	stride <cm>    set the stride length used for distance
+//	time <hh:mm>   set the time of day
+//	save           save the totals to flash now
+//	clear          start today's totals over
+package main
+
+import (
+	"machine"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Stride length until one is set, centimetres
+	defaultStrideCM = 75
+	// Shortest and longest stride that can be set, centimetres
+	minStrideCM = 30
+	maxStrideCM = 250
+	// Interval between saves of changed totals; flash wears out, so not
+	// much more often
+	saveInterval = 10 * time.Minute
+	// Confidence the classifier needs before a change of activity is
+	// logged, percent
+	minConfidence = 60
+	// More steps than this in one step counter report means the sensor
+	// was reset and its count started again
+	maxStepsPerReport = 1000
+)
+
+const minutesPerDay = 24 * 60
+
+// clock keeps the time of day from the uptime counter.
+type clock struct {
+	base       time.Time
+	baseMinute int
+}
+
+// set makes now the given minute after midnight.
+func (c *clock) set(minute int, now time.Time) {
+	c.base, c.baseMinute = now, minute
+}
+
+// minute returns the minutes after midnight at now.
+func (c *clock) minute(now time.Time) int {
+	return (c.baseMinute + int(now.Sub(c.base)/time.Minute)) % minutesPerDay
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("pedometer")
+
+	settings := store.New(machine.Flash, "pedometer")
+	t := &totals{strideCM: defaultStrideCM}
+	var record [recordLen]byte
+	if n, err := settings.Load(record[:]); err == nil && t.unmarshal(record[:n]) {
+		println("Restored", t.steps, "steps from flash")
+	} else if err != nil && err != store.ErrEmpty {
+		println("Stored totals unusable:", err.Error())
+	}
+
+	var clk clock
+	clk.set(int(t.minute)%minutesPerDay, time.Now())
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range []bno08x.SensorID{bno08x.SensorStepCounter, bno08x.SensorPersonalActivityClassifier} {
+		if err := sensor.EnableReport(id, sensorinfo.DefaultIntervalMicros(id)); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	dirty := false
+	lastHour := clk.minute(time.Now()) / 60
+	save := func(now time.Time) error {
+		t.minute = uint16(clk.minute(now))
+		if err := settings.Save(t.marshal(record[:0])); err != nil {
+			return err
+		}
+		dirty = false
+		return nil
+	}
+
+	var sh shell.Shell
+	sh.Register("today", "today", "Print today's totals", func(args []string) error {
+		t.print("Today at " + clockString(clk.minute(time.Now())))
+		return nil
+	})
+	sh.Register("stride", "stride <cm>", "Set the stride length", func(args []string) error {
+		if len(args) != 1 {
+			return shell.ErrUsage
+		}
+		cm, err := strconv.Atoi(args[0])
+		if err != nil || cm < minStrideCM || cm > maxStrideCM {
+			return shell.ErrUsage
+		}
+		t.strideCM, dirty = uint16(cm), true
+		println("Stride", cm, "cm")
+		return nil
+	})
+	sh.Register("time", "time <hh:mm>", "Set the time of day", func(args []string) error {
+		if len(args) != 1 {
+			return shell.ErrUsage
+		}
+		minute, ok := parseClock(args[0])
+		if !ok {
+			return shell.ErrUsage
+		}
+		clk.set(minute, time.Now())
+		lastHour = minute / 60 // Not a new day
+		println("Time set to", clockString(minute))
+		return nil
+	})
+	sh.Register("save", "save", "Save the totals to flash now", func(args []string) error {
+		if err := save(time.Now()); err != nil {
+			return err
+		}
+		println("Saved")
+		return nil
+	})
+	sh.Register("clear", "clear", "Start today's totals over", func(args []string) error {
+		*t = totals{strideCM: t.strideCM, yesterday: t.yesterday}
+		dirty = true
+		println("Cleared")
+		return nil
+	})
+
+	println("Time of day", clockString(clk.minute(time.Now())), "- type 'time hh:mm' to set it, 'help' for commands")
+
+	var lastCount uint16
+	haveCount := false
+	activity := uint8(sensorinfo.ActivityUnknown)
+	lastActivity := time.Now()
+	lastSave := time.Now()
+
+	for {
+		now := time.Now()
+		sh.Poll()
+		minute := clk.minute(now)
+		hour := minute / 60
+
+		if hour < lastHour {
+			t.print("Summary of the day")
+			t.newDay()
+			dirty = true
+			lastSave = time.Time{} // Save the new day at once
+		}
+		lastHour = hour
+
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorStepCounter:
+				count := event.StepCounter().Count
+				steps := uint32(count - lastCount) // Wraps with the counter
+				if haveCount && steps > 0 && steps <= maxStepsPerReport {
+					t.addSteps(steps, hour)
+					dirty = true
+				}
+				lastCount, haveCount = count, true
+			case bno08x.SensorPersonalActivityClassifier:
+				pac := event.PersonalActivityClassifier()
+				secs := now.Sub(lastActivity) / time.Second
+				if activity < sensorinfo.NumActivities {
+					t.active[activity] += uint32(secs)
+				}
+				lastActivity = lastActivity.Add(secs * time.Second)
+				state := pac.MostLikelyState
+				if state != activity && int(state) < len(pac.Confidence) && pac.Confidence[state] >= minConfidence {
+					println(clockString(minute), sensorinfo.ActivityName(activity), "->",
+						sensorinfo.ActivityName(state), "("+strconv.Itoa(int(pac.Confidence[state]))+"%)")
+					activity = state
+				}
+			}
+		}
+
+		if dirty && now.Sub(lastSave) >= saveInterval {
+			lastSave = now
+			if err := save(now); err != nil {
+				println("Failed to save totals:", err.Error())
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// parseClock parses a time of day as hh:mm into minutes after midnight.
+func parseClock(s string) (int, bool) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, false
+	}
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if err1 != nil || err2 != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+func clockString(minute int) string {
+	return twoDigits(minute/60) + ":" + twoDigits(minute%60)
+}
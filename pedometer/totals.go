@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+)
+
+// Version of the flash record; records of another version are ignored.
+const recordVersion = 1
+
+// Length of the flash record
+const recordLen = 1 + 2 + 2 + 4 + 4 + 24*2 + sensorinfo.NumActivities*4
+
+// totals is the day so far, kept in flash.
+type totals struct {
+	strideCM  uint16                           // Stride length in centimetres
+	minute    uint16                           // Time of day when saved, minutes after midnight
+	steps     uint32                           // Steps today
+	yesterday uint32                           // Steps the day before
+	hourly    [24]uint16                       // Steps in each hour of the day
+	active    [sensorinfo.NumActivities]uint32 // Seconds spent in each activity today
+}
+
+// marshal appends the flash record to dst.
+func (t *totals) marshal(dst []byte) []byte {
+	dst = append(dst, recordVersion)
+	dst = binary.LittleEndian.AppendUint16(dst, t.strideCM)
+	dst = binary.LittleEndian.AppendUint16(dst, t.minute)
+	dst = binary.LittleEndian.AppendUint32(dst, t.steps)
+	dst = binary.LittleEndian.AppendUint32(dst, t.yesterday)
+	for _, n := range t.hourly {
+		dst = binary.LittleEndian.AppendUint16(dst, n)
+	}
+	for _, s := range t.active {
+		dst = binary.LittleEndian.AppendUint32(dst, s)
+	}
+	return dst
+}
+
+// unmarshal reads a flash record, reporting false if b is not one.
+func (t *totals) unmarshal(b []byte) bool {
+	if len(b) != recordLen || b[0] != recordVersion {
+		return false
+	}
+	b = b[1:]
+	t.strideCM = binary.LittleEndian.Uint16(b)
+	t.minute = binary.LittleEndian.Uint16(b[2:])
+	t.steps = binary.LittleEndian.Uint32(b[4:])
+	t.yesterday = binary.LittleEndian.Uint32(b[8:])
+	b = b[12:]
+	for i := range t.hourly {
+		t.hourly[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	b = b[48:]
+	for i := range t.active {
+		t.active[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return true
+}
+
+// addSteps counts n steps taken in the given hour.
+func (t *totals) addSteps(n uint32, hour int) {
+	t.steps += n
+	if h := uint32(t.hourly[hour]) + n; h <= 0xFFFF {
+		t.hourly[hour] = uint16(h)
+	} else {
+		t.hourly[hour] = 0xFFFF
+	}
+}
+
+// newDay starts a new day, keeping the stride and yesterday's steps.
+func (t *totals) newDay() {
+	*t = totals{strideCM: t.strideCM, minute: t.minute, yesterday: t.steps}
+}
+
+// distance returns the distance walked today in metres.
+func (t *totals) distance() float32 {
+	return float32(t.steps) * float32(t.strideCM) / 100
+}
+
+// print prints the day's totals under title.
+func (t *totals) print(title string) {
+	println(title)
+	println("  Steps:    ", t.steps, "(yesterday", t.yesterday, ")")
+	println("  Distance: ", fmtutil.Float(t.distance()/1000, 2), "km at", t.strideCM, "cm per step")
+	for hour, n := range t.hourly {
+		if n != 0 {
+			println("  "+twoDigits(hour)+":00", fmtutil.PadLeft(fmtutil.Int(int(n)), 6), "steps")
+		}
+	}
+	for state, s := range t.active {
+		if s >= 60 && state != sensorinfo.ActivityUnknown {
+			println("  "+fmtutil.PadRight(sensorinfo.ActivityName(uint8(state))+":", 12), s/60, "min")
+		}
+	}
+}
+
+func twoDigits(n int) string {
+	return string([]byte{byte('0' + n/10), byte('0' + n%10)})
+}
@@ -0,0 +1,111 @@
+// Package main estimates the rate of slow periodic motion: a rocking
+// chair or cradle, or breathing with the board strapped to the chest. It
+// prints the dominant frequency once a second, in Hz and per minute.
+//
+// The accelerometer is low-passed and decimated to 10Hz, and each axis
+// goes through its own dsp.Periodic estimator; the axis whose period
+// correlates best wins, so the board can be worn in any orientation.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dsp"
+	"github.com/intermernet/bno08xPrograms/internal/filter"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	sensorRate  = 50 // Hz
+	analysisHz  = 10 // Rate the estimators run at after decimation
+	cutoffHz    = 2  // Low-pass cutoff, below the analysis Nyquist rate
+	minHz       = 0.1
+	maxHz       = 1.5
+	printPeriod = time.Second
+)
+
+// Decimal places printed for the rates
+const decimals = 2
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("periodic_freq")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The accelerometer includes gravity, whose direction is what rocking
+	// and breathing change
+	err = sensor.EnableReport(bno08x.SensorAccelerometer, 1000000/sensorRate)
+	if err != nil {
+		println("Failed to enable accelerometer:", err.Error())
+		return
+	}
+
+	println("Collecting", dsp.MaxPeriodicWindow/analysisHz, "seconds of motion before the first estimate...")
+
+	lowpass := filter.EMA3{Alpha: filter.Alpha(cutoffHz, sensorRate)}
+	var axes [3]dsp.Periodic
+	for i := range axes {
+		axes[i] = dsp.Periodic{SampleRate: analysisHz, MinHz: minHz, MaxHz: maxHz}
+	}
+	samples := 0
+	lastPrint := time.Now()
+
+	for {
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(2 * time.Millisecond)
+			continue
+		}
+		if event.ID() != bno08x.SensorAccelerometer {
+			continue
+		}
+
+		a := event.Accelerometer()
+		x, y, z := lowpass.Update(a.X, a.Y, a.Z)
+		samples++
+		if samples%(sensorRate/analysisHz) != 0 {
+			continue
+		}
+		axes[0].Add(x)
+		axes[1].Add(y)
+		axes[2].Add(z)
+
+		if !axes[0].Full() || time.Since(lastPrint) < printPeriod {
+			continue
+		}
+		lastPrint = time.Now()
+
+		bestHz, bestConfidence, bestAxis := float32(0), float32(0), -1
+		for i := range axes {
+			hz, confidence, ok := axes[i].Estimate()
+			if ok && confidence > bestConfidence {
+				bestHz, bestConfidence, bestAxis = hz, confidence, i
+			}
+		}
+		if bestAxis < 0 {
+			println("No steady rhythm")
+			continue
+		}
+		println("Rate:", fmtutil.Float(bestHz, decimals), "Hz |",
+			fmtutil.Float(bestHz*60, 1), "per minute | Axis:", string(rune('X'+bestAxis)),
+			"| Confidence:", fmtutil.Float(bestConfidence, decimals))
+	}
+}
@@ -0,0 +1,22 @@
+package protocol
+
+// CRC16 returns the CRC-16/CCITT-FALSE of b: polynomial 0x1021, initial
+// value 0xFFFF, no reflection. "123456789" gives 0x29B1.
+func CRC16(b []byte) uint16 {
+	return UpdateCRC16(0xFFFF, b)
+}
+
+// UpdateCRC16 continues a CRC16 over more bytes.
+func UpdateCRC16(crc uint16, b []byte) uint16 {
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,186 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Binary frame layout, all fields little-endian:
+//
+//	0-1   0xA5 0x5A sync
+//	2     frame type
+//	3     sensor ID
+//	4     sequence, incremented per frame
+//	5-8   timestamp in microseconds since the session started, uint32
+//	9     payload length n
+//	10..  payload
+//	10+n  CRC16 of bytes 2 to 9+n, uint16
+const (
+	Sync0 = 0xA5
+	Sync1 = 0x5A
+
+	// FrameHeaderLen is the length of a frame before its payload.
+	FrameHeaderLen = 10
+	// MaxPayload is the longest payload a frame carries.
+	MaxPayload = 64
+	// MaxFrameLen is the length of a frame with the longest payload.
+	MaxFrameLen = FrameHeaderLen + MaxPayload + 2
+)
+
+// FrameType says what a frame's payload holds.
+type FrameType uint8
+
+const (
+	FrameSample FrameType = 1 // A Sample
+	FrameEvent  FrameType = 2 // Detector report bytes as sent by the sensor
+	FrameText   FrameType = 3 // A status or log line
+)
+
+// Frame is one binary frame. Payload may alias the decoded buffer.
+type Frame struct {
+	Type      FrameType
+	Sensor    uint8
+	Sequence  uint8
+	Timestamp uint32
+	Payload   []byte
+}
+
+// AppendFrame appends the binary form of f to dst.
+func AppendFrame(dst []byte, f Frame) ([]byte, error) {
+	if len(f.Payload) > MaxPayload {
+		return dst, ErrTooLarge
+	}
+	start := len(dst)
+	dst = append(dst, Sync0, Sync1, uint8(f.Type), f.Sensor, f.Sequence)
+	dst = binary.LittleEndian.AppendUint32(dst, f.Timestamp)
+	dst = append(dst, uint8(len(f.Payload)))
+	dst = append(dst, f.Payload...)
+	return binary.LittleEndian.AppendUint16(dst, CRC16(dst[start+2:])), nil
+}
+
+// DecodeFrame parses a frame at the start of b and returns it with the
+// number of bytes it took. The payload aliases b.
+func DecodeFrame(b []byte) (Frame, int, error) {
+	if len(b) < FrameHeaderLen+2 {
+		return Frame{}, 0, ErrShort
+	}
+	if b[0] != Sync0 || b[1] != Sync1 {
+		return Frame{}, 0, ErrSync
+	}
+	n := int(b[9])
+	if n > MaxPayload {
+		return Frame{}, 0, ErrTooLarge
+	}
+	total := FrameHeaderLen + n + 2
+	if len(b) < total {
+		return Frame{}, 0, ErrShort
+	}
+	if CRC16(b[2:FrameHeaderLen+n]) != binary.LittleEndian.Uint16(b[FrameHeaderLen+n:]) {
+		return Frame{}, 0, ErrCRC
+	}
+	return Frame{
+		Type:      FrameType(b[2]),
+		Sensor:    b[3],
+		Sequence:  b[4],
+		Timestamp: binary.LittleEndian.Uint32(b[5:9]),
+		Payload:   b[FrameHeaderLen : FrameHeaderLen+n],
+	}, total, nil
+}
+
+// Parser finds frames in a byte stream, resynchronising on the sync bytes
+// after noise or a CRC failure, and keeps statistics for diagnostics.
+type Parser struct {
+	buf [MaxFrameLen]byte
+	n   int
+
+	// Frames counts valid frames and BadFrames rejected ones.
+	Frames    uint32
+	BadFrames uint32
+	// Dropped counts frames missing from the sequence.
+	Dropped uint32
+
+	lastSequence uint8
+}
+
+// Feed adds one received byte and returns a frame when it completes one.
+// The frame's payload is only valid until the next call.
+func (p *Parser) Feed(c byte) (Frame, bool) {
+	// Hunt for the sync bytes before buffering the rest
+	switch {
+	case p.n == 0 && c != Sync0:
+		return Frame{}, false
+	case p.n == 1 && c != Sync1:
+		p.n = 0
+		if c == Sync0 {
+			p.buf[0], p.n = c, 1
+		}
+		return Frame{}, false
+	}
+	p.buf[p.n] = c
+	p.n++
+	if p.n >= FrameHeaderLen && p.buf[9] > MaxPayload {
+		p.reject()
+		return Frame{}, false
+	}
+	if p.n < FrameHeaderLen || p.n < FrameHeaderLen+int(p.buf[9])+2 {
+		return Frame{}, false
+	}
+
+	f, _, err := DecodeFrame(p.buf[:p.n])
+	if err != nil {
+		p.reject()
+		return Frame{}, false
+	}
+	p.n = 0
+	if p.Frames > 0 {
+		p.Dropped += uint32(f.Sequence - p.lastSequence - 1)
+	}
+	p.lastSequence = f.Sequence
+	p.Frames++
+	return f, true
+}
+
+// reject drops a bad frame and rescans its bytes for the next sync.
+func (p *Parser) reject() {
+	p.BadFrames++
+	rest := p.buf[1:p.n]
+	for i := range rest {
+		if rest[i] == Sync0 && (i+1 == len(rest) || rest[i+1] == Sync1) {
+			p.n = copy(p.buf[:], rest[i:])
+			return
+		}
+	}
+	p.n = 0
+}
+
+// Sample is a sensor reading: up to four values and the sensor's accuracy
+// estimate. Its payload is the accuracy byte followed by each value as a
+// float32.
+type Sample struct {
+	Values   [4]float32
+	N        uint8 // Values in use
+	Accuracy uint8 // 0 unreliable to 3 high
+}
+
+// AppendSample appends the payload of s to dst.
+func AppendSample(dst []byte, s Sample) []byte {
+	dst = append(dst, s.Accuracy)
+	for i := 0; i < int(s.N) && i < len(s.Values); i++ {
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(s.Values[i]))
+	}
+	return dst
+}
+
+// DecodeSample parses a FrameSample payload.
+func DecodeSample(payload []byte) (Sample, error) {
+	var s Sample
+	if len(payload) < 1 || (len(payload)-1)%4 != 0 || len(payload) > 1+4*len(s.Values) {
+		return s, ErrPayload
+	}
+	s.Accuracy = payload[0]
+	s.N = uint8((len(payload) - 1) / 4)
+	for i := 0; i < int(s.N); i++ {
+		s.Values[i] = math.Float32frombits(binary.LittleEndian.Uint32(payload[1+4*i:]))
+	}
+	return s, nil
+}
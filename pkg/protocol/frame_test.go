@@ -0,0 +1,187 @@
+package protocol_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/intermernet/bno08xPrograms/pkg/protocol"
+)
+
+// sampleFrame returns a sample frame for the game rotation vector with the
+// given sequence number.
+func sampleFrame(t *testing.T, sequence uint8) []byte {
+	t.Helper()
+	s := protocol.Sample{Values: [4]float32{0.5, -0.25, 0.125, 0.8}, N: 4, Accuracy: 3}
+	b, err := protocol.AppendFrame(nil, protocol.Frame{
+		Type:      protocol.FrameSample,
+		Sensor:    0x08,
+		Sequence:  sequence,
+		Timestamp: 1200345,
+		Payload:   protocol.AppendSample(nil, s),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// feed passes b to p a byte at a time and returns the frames completed,
+// with their payloads copied.
+func feed(p *protocol.Parser, b []byte) []protocol.Frame {
+	var frames []protocol.Frame
+	for _, c := range b {
+		if f, ok := p.Feed(c); ok {
+			f.Payload = append([]byte(nil), f.Payload...)
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+func TestCRC16(t *testing.T) {
+	if got := protocol.CRC16([]byte("123456789")); got != 0x29B1 {
+		t.Errorf("CRC16(123456789) = %#04x, want 0x29b1", got)
+	}
+	// Continuing over a split gives the same result
+	if got := protocol.UpdateCRC16(protocol.CRC16([]byte("1234")), []byte("56789")); got != 0x29B1 {
+		t.Errorf("UpdateCRC16 over a split = %#04x, want 0x29b1", got)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	b := sampleFrame(t, 7)
+	if len(b) != protocol.FrameHeaderLen+1+4*4+2 {
+		t.Fatalf("frame is %d bytes, want %d", len(b), protocol.FrameHeaderLen+1+4*4+2)
+	}
+	n := len(b)
+	if crc := binary.LittleEndian.Uint16(b[n-2:]); crc != protocol.CRC16(b[2:n-2]) {
+		t.Errorf("CRC field %#04x, want %#04x", crc, protocol.CRC16(b[2:n-2]))
+	}
+
+	var p protocol.Parser
+	frames := feed(&p, b)
+	if len(frames) != 1 {
+		t.Fatalf("parsed %d frames, want 1", len(frames))
+	}
+	f := frames[0]
+	if f.Type != protocol.FrameSample || f.Sensor != 0x08 || f.Sequence != 7 || f.Timestamp != 1200345 {
+		t.Errorf("header = %+v", f)
+	}
+	s, err := protocol.DecodeSample(f.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := protocol.Sample{Values: [4]float32{0.5, -0.25, 0.125, 0.8}, N: 4, Accuracy: 3}
+	if s != want {
+		t.Errorf("sample = %+v, want %+v", s, want)
+	}
+	if p.Frames != 1 || p.BadFrames != 0 || p.Dropped != 0 {
+		t.Errorf("stats frames %d bad %d dropped %d, want 1 0 0", p.Frames, p.BadFrames, p.Dropped)
+	}
+}
+
+func TestDecodeFrame(t *testing.T) {
+	b := sampleFrame(t, 1)
+	f, n, err := protocol.DecodeFrame(append(b, 0xEE))
+	if err != nil || n != len(b) || f.Sequence != 1 {
+		t.Errorf("DecodeFrame = %+v, %d, %v; want sequence 1, %d bytes", f, n, err, len(b))
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+		err  error
+	}{
+		{"short header", b[:5], protocol.ErrShort},
+		{"short payload", b[:len(b)-1], protocol.ErrShort},
+		{"sync", append([]byte{0x00}, b[1:]...), protocol.ErrSync},
+		{"crc", append(append([]byte(nil), b[:len(b)-1]...), b[len(b)-1]^0xFF), protocol.ErrCRC},
+		{"too large", append(append([]byte(nil), b[:9]...), protocol.MaxPayload+1, 0, 0), protocol.ErrTooLarge},
+	}
+	for _, tt := range tests {
+		if _, _, err := protocol.DecodeFrame(tt.b); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	if _, err := protocol.AppendFrame(nil, protocol.Frame{Payload: make([]byte, protocol.MaxPayload+1)}); !errors.Is(err, protocol.ErrTooLarge) {
+		t.Errorf("AppendFrame of an oversized payload: err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestParserCorruptedCRC(t *testing.T) {
+	bad := sampleFrame(t, 1)
+	bad[protocol.FrameHeaderLen+2] ^= 0x40 // Flip a bit of the first value
+
+	var p protocol.Parser
+	frames := feed(&p, append(bad, sampleFrame(t, 2)...))
+	if len(frames) != 1 || frames[0].Sequence != 2 {
+		t.Fatalf("parsed %+v, want only the frame after the corrupted one", frames)
+	}
+	if p.BadFrames != 1 {
+		t.Errorf("BadFrames = %d, want 1", p.BadFrames)
+	}
+}
+
+func TestParserResync(t *testing.T) {
+	// Noise, a lone sync byte, a false sync pair with an impossible
+	// length, then a frame cut short by the next one
+	var stream bytes.Buffer
+	stream.Write([]byte{0x00, 0x13, protocol.Sync0, 0x42})
+	stream.Write([]byte{protocol.Sync0, protocol.Sync1, 1, 8, 0, 0, 0, 0, 0, 0xFF})
+	cut := sampleFrame(t, 1)
+	stream.Write(cut[:len(cut)-6])
+	stream.Write(sampleFrame(t, 2))
+	stream.Write(sampleFrame(t, 3))
+
+	var p protocol.Parser
+	frames := feed(&p, stream.Bytes())
+	if len(frames) != 2 || frames[0].Sequence != 2 || frames[1].Sequence != 3 {
+		t.Fatalf("parsed %+v, want sequences 2 and 3", frames)
+	}
+	if p.BadFrames < 2 {
+		t.Errorf("BadFrames = %d, want at least 2", p.BadFrames)
+	}
+	if _, err := protocol.DecodeSample(frames[1].Payload); err != nil {
+		t.Errorf("payload after resync: %v", err)
+	}
+}
+
+func TestParserDropped(t *testing.T) {
+	var stream []byte
+	for _, seq := range []uint8{254, 255, 0, 3} {
+		stream = append(stream, sampleFrame(t, seq)...)
+	}
+	var p protocol.Parser
+	if frames := feed(&p, stream); len(frames) != 4 {
+		t.Fatalf("parsed %d frames, want 4", len(frames))
+	}
+	// Wrapping from 255 to 0 loses nothing; 0 to 3 loses two
+	if p.Dropped != 2 {
+		t.Errorf("Dropped = %d, want 2", p.Dropped)
+	}
+}
+
+func TestDecodeSample(t *testing.T) {
+	for _, n := range []uint8{0, 1, 3, 4} {
+		s := protocol.Sample{Values: [4]float32{1, 2, 3, 4}, N: n, Accuracy: 2}
+		got, err := protocol.DecodeSample(protocol.AppendSample(nil, s))
+		if err != nil {
+			t.Fatalf("%d values: %v", n, err)
+		}
+		want := s
+		for i := n; i < 4; i++ {
+			want.Values[i] = 0
+		}
+		if got != want {
+			t.Errorf("%d values: got %+v, want %+v", n, got, want)
+		}
+	}
+	for _, b := range [][]byte{nil, {0, 1, 2}, make([]byte, 1+5*4)} {
+		if _, err := protocol.DecodeSample(b); !errors.Is(err, protocol.ErrPayload) {
+			t.Errorf("DecodeSample(% x): err = %v, want ErrPayload", b, err)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/intermernet/bno08xPrograms/pkg/protocol
+
+go 1.21
@@ -0,0 +1,25 @@
+// Package protocol defines the formats the programs in this repository
+// stream to a host: a session header sent once at start, binary frames
+// protected by a CRC-16, and the equivalent JSON records. Host tools
+// import it rather than copying the layouts by hand.
+//
+// It is a Go module of its own so desktop applications can depend on it
+// without the TinyGo programs, and it only uses packages TinyGo supports
+// so the programs share the same definitions.
+package protocol
+
+import "errors"
+
+// Version is the protocol version carried in the session header. It
+// changes whenever a layout in this package does.
+const Version = 1
+
+var (
+	ErrShort    = errors.New("protocol: buffer too short")
+	ErrMagic    = errors.New("protocol: bad session magic")
+	ErrSync     = errors.New("protocol: missing frame sync")
+	ErrCRC      = errors.New("protocol: CRC mismatch")
+	ErrTooLarge = errors.New("protocol: payload too large")
+	ErrPayload  = errors.New("protocol: malformed payload")
+	ErrVersion  = errors.New("protocol: unsupported version")
+)
@@ -0,0 +1,48 @@
+package protocol
+
+// Record types, the "type" field of every JSON line.
+const (
+	RecordSession = "session"
+	RecordSample  = "sample"
+)
+
+// SampleRecord is the JSON form of a sample, one per line:
+//
+//	{"type":"sample","t":1200345,"id":8,"sensor":"Game Rotation Vector","v":[0.99,0.01,0.02,0.1],"acc":3}
+type SampleRecord struct {
+	Type      string    `json:"type"` // RecordSample
+	Timestamp uint32    `json:"t"`    // Microseconds since the session started
+	Sensor    uint8     `json:"id"`
+	Name      string    `json:"sensor,omitempty"`
+	Values    []float32 `json:"v"`
+	Accuracy  uint8     `json:"acc"`
+}
+
+// Record returns the JSON form of a FrameSample frame.
+func (f Frame) Record() (SampleRecord, error) {
+	s, err := DecodeSample(f.Payload)
+	if err != nil {
+		return SampleRecord{}, err
+	}
+	return SampleRecord{
+		Type:      RecordSample,
+		Timestamp: f.Timestamp,
+		Sensor:    f.Sensor,
+		Values:    append([]float32(nil), s.Values[:s.N]...),
+		Accuracy:  s.Accuracy,
+	}, nil
+}
+
+// Frame returns the binary form of r with the given sequence number.
+// Values beyond the fourth are dropped.
+func (r SampleRecord) Frame(sequence uint8) Frame {
+	s := Sample{Accuracy: r.Accuracy}
+	s.N = uint8(copy(s.Values[:], r.Values))
+	return Frame{
+		Type:      FrameSample,
+		Sensor:    r.Sensor,
+		Sequence:  sequence,
+		Timestamp: r.Timestamp,
+		Payload:   AppendSample(nil, s),
+	}
+}
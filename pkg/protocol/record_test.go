@@ -0,0 +1,72 @@
+package protocol_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/intermernet/bno08xPrograms/pkg/protocol"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	r := protocol.SampleRecord{
+		Type:      protocol.RecordSample,
+		Timestamp: 1200345,
+		Sensor:    0x01,
+		Values:    []float32{0.012, -0.034, 9.807},
+		Accuracy:  2,
+	}
+
+	// Record to binary frame and back through the parser
+	b, err := protocol.AppendFrame(nil, r.Frame(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p protocol.Parser
+	frames := feed(&p, b)
+	if len(frames) != 1 {
+		t.Fatalf("parsed %d frames, want 1", len(frames))
+	}
+	got, err := frames[0].Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, r) {
+		t.Errorf("got %+v, want %+v", got, r)
+	}
+
+	// And through JSON
+	line, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back protocol.SampleRecord
+	if err := json.Unmarshal(line, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, r) {
+		t.Errorf("JSON %s decoded to %+v", line, back)
+	}
+}
+
+func TestRecordJSONKeys(t *testing.T) {
+	line, err := json.Marshal(protocol.SampleRecord{Type: protocol.RecordSample, Timestamp: 5, Sensor: 8, Values: []float32{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"sample","t":5,"id":8,"v":[1],"acc":0}`
+	if string(line) != want {
+		t.Errorf("got %s, want %s", line, want)
+	}
+}
+
+func TestRecordExtraValuesDropped(t *testing.T) {
+	r := protocol.SampleRecord{Type: protocol.RecordSample, Values: []float32{1, 2, 3, 4, 5, 6}}
+	s, err := protocol.DecodeSample(r.Frame(0).Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.N != 4 || s.Values != [4]float32{1, 2, 3, 4} {
+		t.Errorf("sample = %+v, want the first four values", s)
+	}
+}
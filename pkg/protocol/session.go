@@ -0,0 +1,76 @@
+package protocol
+
+// SessionMagic starts every binary session header.
+var SessionMagic = [4]byte{'B', 'N', 'O', '8'}
+
+// SessionHeader identifies the program and board behind a stream. It is
+// sent once when streaming starts, before any frames or records:
+//
+//	0-3  "BNO8"
+//	4    protocol version
+//	5..  program, board and revision, each a length byte and that many
+//	     bytes of text
+type SessionHeader struct {
+	Type     string `json:"type"` // RecordSession in JSON streams
+	Version  uint8  `json:"version"`
+	Program  string `json:"program"`
+	Board    string `json:"board"`
+	Revision string `json:"revision"`
+}
+
+// NewSessionHeader returns a header for the current protocol version.
+func NewSessionHeader(program, board, revision string) SessionHeader {
+	return SessionHeader{
+		Type:     RecordSession,
+		Version:  Version,
+		Program:  program,
+		Board:    board,
+		Revision: revision,
+	}
+}
+
+// AppendSessionHeader appends the binary form of h to dst. Text fields
+// are cut to 255 bytes.
+func AppendSessionHeader(dst []byte, h SessionHeader) []byte {
+	dst = append(dst, SessionMagic[:]...)
+	dst = append(dst, h.Version)
+	for _, s := range []string{h.Program, h.Board, h.Revision} {
+		if len(s) > 255 {
+			s = s[:255]
+		}
+		dst = append(dst, uint8(len(s)))
+		dst = append(dst, s...)
+	}
+	return dst
+}
+
+// DecodeSessionHeader parses a binary session header at the start of b and
+// returns it with the number of bytes it took.
+func DecodeSessionHeader(b []byte) (SessionHeader, int, error) {
+	var h SessionHeader
+	if len(b) < len(SessionMagic)+1 {
+		return h, 0, ErrShort
+	}
+	if string(b[:4]) != string(SessionMagic[:]) {
+		return h, 0, ErrMagic
+	}
+	h.Type = RecordSession
+	h.Version = b[4]
+	if h.Version != Version {
+		return h, 0, ErrVersion
+	}
+	n := 5
+	for _, field := range []*string{&h.Program, &h.Board, &h.Revision} {
+		if n >= len(b) {
+			return h, 0, ErrShort
+		}
+		length := int(b[n])
+		n++
+		if n+length > len(b) {
+			return h, 0, ErrShort
+		}
+		*field = string(b[n : n+length])
+		n += length
+	}
+	return h, n, nil
+}
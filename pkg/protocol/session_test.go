@@ -0,0 +1,66 @@
+package protocol_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/intermernet/bno08xPrograms/pkg/protocol"
+)
+
+func TestSessionHeaderRoundTrip(t *testing.T) {
+	h := protocol.NewSessionHeader("binlog_stream", "pico", "31217f1")
+	b := protocol.AppendSessionHeader(nil, h)
+	if string(b[:4]) != "BNO8" || b[4] != protocol.Version {
+		t.Fatalf("header starts % x", b[:5])
+	}
+
+	// Frames follow the header in the same stream
+	got, n, err := protocol.DecodeSessionHeader(append(b, protocol.Sync0, protocol.Sync1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(b) {
+		t.Errorf("took %d bytes, want %d", n, len(b))
+	}
+	if got != h {
+		t.Errorf("got %+v, want %+v", got, h)
+	}
+}
+
+func TestSessionHeaderLongField(t *testing.T) {
+	long := make([]byte, 300)
+	for i := range long {
+		long[i] = 'a'
+	}
+	b := protocol.AppendSessionHeader(nil, protocol.NewSessionHeader(string(long), "", ""))
+	got, _, err := protocol.DecodeSessionHeader(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Program) != 255 {
+		t.Errorf("program is %d bytes, want it cut to 255", len(got.Program))
+	}
+}
+
+func TestDecodeSessionHeaderErrors(t *testing.T) {
+	good := protocol.AppendSessionHeader(nil, protocol.NewSessionHeader("p", "b", "r"))
+	badVersion := append([]byte(nil), good...)
+	badVersion[4] = protocol.Version + 1
+
+	tests := []struct {
+		name string
+		b    []byte
+		err  error
+	}{
+		{"empty", nil, protocol.ErrShort},
+		{"magic", append([]byte("BNO9"), good[4:]...), protocol.ErrMagic},
+		{"version", badVersion, protocol.ErrVersion},
+		{"missing field", good[:len(good)-2], protocol.ErrShort},
+		{"field past end", good[:len(good)-1], protocol.ErrShort},
+	}
+	for _, tt := range tests {
+		if _, _, err := protocol.DecodeSessionHeader(tt.b); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package main helps aim a dish, antenna or telescope at a target azimuth
+// and elevation typed over serial. Mount the board with its X axis along
+// the boresight.
+//
+// The azimuth is the true heading of the X axis and the elevation its
+// angle above the horizon, both from the geomagnetic rotation vector,
+// which needs no gyroscope and so does not drift while the mount sits
+// still. The console says which way to turn and tilt, and the NeoPixels
+// on board.NeoPixelPin fade from red to green as the pointing error
+// shrinks, turning fully green within onTarget. The board LED lights once
+// the stability detector reports the mount has settled, so a reading is
+// only trusted after the wobble from the last adjustment has died down.
+//
+// The magnetometer must be calibrated, away from the mount's metal where
+// possible, and the local declination set for true bearings; the
+// estimated heading accuracy is printed with each reading.
+//
+//	target <az> <el>  set the target azimuth and elevation in degrees
+//	decl <deg>        set the magnetic declination, positive east
+package main
+
+import (
+	"image/color"
+	"machine"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/heading"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+	"tinygo.org/x/drivers/ws2812"
+)
+
+const (
+	// Pixels lit with the proximity colour
+	numPixels = 8
+	// Pixel brightness, out of 255
+	brightness = 32
+	// Pointing error shown fully green, degrees
+	onTarget = 1
+	// Pointing error shown fully red, degrees
+	farOff = 30
+	// Geomagnetic rotation vector report interval in microseconds
+	interval = 20000 // 50Hz
+	// Interval between readings on the console
+	printInterval = 200 * time.Millisecond
+)
+
+// Stability detector events
+const (
+	stableEntered = 1
+	stableExited  = 2
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("pointing")
+
+	led := board.LEDPin()
+	if led != machine.NoPin {
+		led.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		led.Low()
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, r := range []struct {
+		id       bno08x.SensorID
+		interval uint32
+	}{
+		{bno08x.SensorGeomagneticRotationVector, interval},
+		{bno08x.SensorStabilityDetector, sensorinfo.Interval10Hz},
+	} {
+		if err := sensor.EnableReport(r.id, r.interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(r.id)+":", err.Error())
+			return
+		}
+	}
+
+	// Initialize NeoPixels
+	pixelPin := board.NeoPixelPin()
+	pixelPin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	neo := ws2812.New(pixelPin)
+	pixels := make([]color.RGBA, numPixels)
+
+	var compass heading.Compass
+	var targetAz, targetEl float32
+	haveTarget := false
+
+	var sh shell.Shell
+	sh.Register("target", "target <az> <el>", "Set the target azimuth and elevation in degrees", func(args []string) error {
+		if len(args) != 2 {
+			return shell.ErrUsage
+		}
+		az, err1 := strconv.ParseFloat(args[0], 32)
+		el, err2 := strconv.ParseFloat(args[1], 32)
+		if err1 != nil || err2 != nil || el < -90 || el > 90 {
+			return shell.ErrUsage
+		}
+		targetAz, targetEl, haveTarget = heading.Normalize(float32(az)), float32(el), true
+		println("Target azimuth", fmtutil.Float(targetAz, 1), "elevation", fmtutil.Float(targetEl, 1))
+		return nil
+	})
+	sh.Register("decl", "decl <deg>", "Set the magnetic declination, positive east", func(args []string) error {
+		if len(args) != 1 {
+			return shell.ErrUsage
+		}
+		d, err := strconv.ParseFloat(args[0], 32)
+		if err != nil || d < -180 || d > 180 {
+			return shell.ErrUsage
+		}
+		compass.Declination = float32(d)
+		println("Declination", fmtutil.Float(compass.Declination, 1))
+		return nil
+	})
+
+	println("Type 'target <az> <el>' to aim, 'help' for commands")
+
+	settled := false
+	lastPrint := time.Now()
+
+	for {
+		sh.Poll()
+
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(2 * time.Millisecond)
+			continue
+		}
+		if event.ID() == bno08x.SensorStabilityDetector {
+			switch event.StabilityDetector() {
+			case stableEntered:
+				settled = true
+			case stableExited:
+				settled = false
+			}
+			if led != machine.NoPin {
+				led.Set(settled)
+			}
+			continue
+		}
+		if event.ID() != bno08x.SensorGeomagneticRotationVector {
+			continue
+		}
+
+		q := event.Quaternion()
+		az := compass.FromQuaternion(q)
+		el := elevation(q)
+
+		pointingErr := float32(-1)
+		if haveTarget {
+			pointingErr = separation(az, el, targetAz, targetEl)
+		}
+		fill(pixels, proximity(pointingErr))
+		neo.WriteColors(pixels)
+
+		if time.Since(lastPrint) < printInterval {
+			continue
+		}
+		lastPrint = time.Now()
+
+		line := "Az " + fmtutil.FloatWidth(az, 1, 5) + " El " + fmtutil.FloatWidth(el, 1, 5) +
+			" (±" + fmtutil.Float(units.RadiansToDegrees(event.QuaternionAccuracy()), 1) + ")"
+		if haveTarget {
+			turn := heading.Normalize(targetAz-az+180) - 180
+			line += " | " + direction(turn, "right", "left") + " " + direction(targetEl-el, "up", "down") +
+				" | error " + fmtutil.Float(pointingErr, 1)
+		}
+		if settled {
+			line += " | settled"
+		}
+		println(line)
+	}
+}
+
+// elevation returns the angle of the sensor's X axis above the horizon in
+// degrees.
+func elevation(q bno08x.Quaternion) float32 {
+	x, y, z := quat.Rotate(q, 1, 0, 0)
+	return units.RadiansToDegrees(float32(math.Atan2(float64(z), math.Hypot(float64(x), float64(y)))))
+}
+
+// separation returns the angle in degrees between two directions given
+// as azimuth and elevation.
+func separation(az1, el1, az2, el2 float32) float32 {
+	rad := func(deg float32) float64 { return float64(deg) * math.Pi / 180 }
+	cos := math.Sin(rad(el1))*math.Sin(rad(el2)) + math.Cos(rad(el1))*math.Cos(rad(el2))*math.Cos(rad(az1-az2))
+	return units.RadiansToDegrees(float32(math.Acos(math.Max(-1, math.Min(1, cos)))))
+}
+
+// proximity returns the pixel colour for a pointing error in degrees, red
+// far off through to green on target, or off with no target.
+func proximity(errDeg float32) color.RGBA {
+	if errDeg < 0 {
+		return color.RGBA{}
+	}
+	near := 1 - (errDeg-onTarget)/(farOff-onTarget)
+	if near < 0 {
+		near = 0
+	} else if near > 1 {
+		near = 1
+	}
+	return color.RGBA{R: uint8(brightness * (1 - near)), G: uint8(brightness * near)}
+}
+
+func fill(pixels []color.RGBA, c color.RGBA) {
+	for i := range pixels {
+		pixels[i] = c
+	}
+}
+
+// direction returns how far to move and which way: positive is pos.
+func direction(deg float32, pos, neg string) string {
+	if deg < 0 {
+		return neg + " " + fmtutil.Float(-deg, 1)
+	}
+	return pos + " " + fmtutil.Float(deg, 1)
+}
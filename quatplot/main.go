@@ -1,36 +1,80 @@
 // An example of using the BNO08x driver
 // to read rotation vector (quaternion) data from the sensor.
+//
+// Each sample is printed as "i,j,k,real" for the plotting tool. Set
+// csvOutput to print timestamped CSV rows with a header instead, or
+// teleplotOutput to plot the components in the Teleplot VS Code extension.
+//
+// The sensor is read when its INT line signals data through
+// internal/irqpoll, falling back to polling every 10ms without INT wired.
+// Set showLatency to print how soon after the INT edge each read started,
+// and comparePolling to measure polling every 10ms on the same board too.
+//
+// Typing "selftest" on the serial console runs the health checks in place.
+// The report interrupts the samples, so the plotting tool shows a gap.
 package main
 
 import (
 	"machine"
 	"time"
 
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/csvout"
+	"github.com/intermernet/bno08xPrograms/internal/irqpoll"
+	"github.com/intermernet/bno08xPrograms/internal/selftest"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/teleplot"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 	"tinygo.org/x/drivers/bno08x"
 )
 
+// Set to true to print each sample as a CSV row with a timestamp and sensor
+// ID, after a header row
+const csvOutput = false
+
+// Set to true to print the components in the Teleplot format; csvOutput
+// takes precedence
+const teleplotOutput = false
+
+// Decimal places in CSV rows
+const decimals = 6
+
+// Set to true to print the INT to read latency every latencyInterval, on
+// a line starting with "#"
+const showLatency = false
+
+// Set to true, with showLatency, to switch between waking on INT and
+// polling every irqpoll.PollInterval after each latency line, so the lines
+// measure both
+const comparePolling = false
+
+// Interval between latency lines
+const latencyInterval = 5 * time.Second
+
+// Watchdog timeout, and the self test window, which must fit inside it
+const (
+	watchdogTimeout = time.Second
+	selftestWindow  = 500 * time.Millisecond
+)
+
 func main() {
 	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("quatplot")
 
 	// Configure watchdog to reset if main loop stalls
 	wdc := machine.WatchdogConfig{
-		TimeoutMillis: 1000,
+		TimeoutMillis: uint32(watchdogTimeout / time.Millisecond),
 	}
 	machine.Watchdog.Configure(wdc)
 	machine.Watchdog.Start()
 
-	// Initialize I2C bus
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{
-		Frequency: 400 * machine.KHz,
-	})
+	// Create and configure sensor
+	sensor, bus, err := transport.OpenSensorBus()
 	if err != nil {
-		println("Failed to configure I2C:", err.Error())
+		println("Failed to open sensor:", err.Error())
 		return
 	}
-
-	// Create and configure sensor
-	sensor := bno08x.New(i2c)
 	err = sensor.Configure(bno08x.Config{})
 	if err != nil {
 		println("Failed to configure sensor:", err.Error())
@@ -44,26 +88,67 @@ func main() {
 		return
 	}
 
-	// Add a delay after enabling reports
-	time.Sleep(100 * time.Millisecond)
+	irq, err := irqpoll.New(board.IntPin())
+	if err != nil {
+		println("No INT interrupt, polling instead:", err.Error())
+	}
+
+	csv := csvout.New(decimals, "i", "j", "k", "real")
+	plot := teleplot.New(decimals)
+	if csvOutput {
+		csv.Header()
+	}
 
-	// Main loop - read and display quaternion data
+	var sh shell.Shell
+	sh.Register(selftest.Command, selftest.Command, "Run the sensor health checks", func(args []string) error {
+		machine.Watchdog.Update()
+		result := selftest.Run(bus, sensor, selftestWindow)
+		machine.Watchdog.Update()
+		result.Print()
+		return nil
+	})
+
+	// Main loop - wait for data, then read and display quaternion data
+	lastLatency := time.Now()
+	polling := false
 	for {
 		// Reset watchdog timer
 		machine.Watchdog.Update()
-		event, ok := sensor.GetSensorEvent()
-		if ok && event.ID() == bno08x.SensorGameRotationVector {
+		sh.Poll()
+		if showLatency && time.Since(lastLatency) >= latencyInterval {
+			lastLatency = time.Now()
+			println("#", irq.Latency().String())
+			if comparePolling {
+				polling = !polling
+				irq.SetPolling(polling)
+			}
+		}
+		if !irq.Wait(100 * time.Millisecond) {
+			continue
+		}
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			if event.ID() != bno08x.SensorGameRotationVector {
+				continue
+			}
 			q := event.Quaternion()
-			print(q.I)
-			print(",")
-			print(q.J)
-			print(",")
-			print(q.K)
-			print(",")
-			println(q.Real)
+			if csvOutput {
+				csv.Row(event.ID(), q.I, q.J, q.K, q.Real)
+			} else if teleplotOutput {
+				plot.Quaternion(q)
+			} else {
+				print(q.I)
+				print(",")
+				print(q.J)
+				print(",")
+				print(q.K)
+				print(",")
+				println(q.Real)
+			}
 		}
-
-		// 10ms delay in loop
-		time.Sleep(10 * time.Millisecond)
+		irq.Done()
 	}
 }
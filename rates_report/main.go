@@ -0,0 +1,188 @@
+// Package main enables every sensor in turn over raw SHTP and prints a
+// table of the report and batch intervals it asked for against those the
+// firmware granted in its Get Feature response, and whether reports then
+// really arrived batched. Run it once per part and firmware version to see
+// which rates an application can count on.
+//
+// Runs over I2C by default, or over SPI when built with "-tags bno08x_spi".
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Batch interval requested, as a multiple of the report interval
+	batchFactor = 5
+	// Longest to wait for a Get Feature response
+	featureTimeout = 500 * time.Millisecond
+	// How long reports are watched for batching after each enable
+	observeTime = 2 * time.Second
+)
+
+// probe is the outcome of enabling one sensor.
+type probe struct {
+	requested  shtpraw.Feature
+	granted    shtpraw.Feature
+	answered   bool
+	reports    int
+	packets    int // Input packets carrying the sensor's reports
+	mostPerPkt int // Most of its reports seen in one packet
+}
+
+// Malformed input cargo is counted but not dumped, which would break up
+// the table
+var quarantine = shtpraw.Quarantine{Dumps: -1}
+
+// batching describes whether the sensor's reports arrived batched.
+func (p *probe) batching() string {
+	switch {
+	case p.reports == 0:
+		return "-"
+	case p.mostPerPkt > 1:
+		return "yes"
+	}
+	return "no"
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("rates_report")
+	println("=== BNO08x Granted Report Rates ===")
+	println()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Probing", len(sensorinfo.All), "sensors, about", int(observeTime.Seconds()), "seconds each...")
+	println()
+	println(fmtutil.PadRight("ID", 6) + fmtutil.PadRight("Sensor", 30) + fmtutil.PadLeft("Interval us", 12) +
+		fmtutil.PadLeft("Granted us", 13) + fmtutil.PadLeft("Batch us", 12) + fmtutil.PadLeft("Granted us", 12) +
+		fmtutil.PadLeft("Reports", 9) + "  Batched")
+
+	supported := 0
+	for _, id := range sensorinfo.All {
+		p := probeSensor(link, id)
+		if p.answered && p.granted.Interval != 0 {
+			supported++
+		}
+		printRow(id, &p)
+	}
+
+	println()
+	println(supported, "of", len(sensorinfo.All), "sensors granted a report interval")
+	if quarantine.Total() > 0 {
+		quarantine.Print()
+	}
+	println("Granted intervals are what the firmware chose; rates it cannot run")
+	println("are rounded to the nearest one it can. A sensor that granted a batch")
+	println("interval but shows no batching delivers each report on its own.")
+}
+
+// probeSensor enables sensor id with batching, reads back the granted
+// configuration, watches its reports for observeTime and disables it.
+func probeSensor(link transport.Transport, id bno08x.SensorID) probe {
+	interval := sensorinfo.DefaultIntervalMicros(id)
+	p := probe{requested: shtpraw.Feature{
+		Sensor:        uint8(id),
+		Interval:      interval,
+		BatchInterval: interval * batchFactor,
+	}}
+
+	var cmd [shtpraw.FeatureLen]byte
+	if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], p.requested)); err != nil {
+		println("  Set Feature failed for", sensorinfo.Name(id)+":", err.Error())
+		return p
+	}
+
+	// The sensor answers every Set Feature with a Get Feature response;
+	// ask explicitly if that one went missing
+	watch(link, &p, featureTimeout)
+	if !p.answered {
+		if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendGetFeature(cmd[:0], uint8(id))); err == nil {
+			watch(link, &p, featureTimeout)
+		}
+	}
+	watch(link, &p, observeTime)
+
+	// Turn the sensor off again and drain what is left
+	off := shtpraw.Feature{Sensor: uint8(id)}
+	link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], off))
+	var ignored probe
+	ignored.requested.Sensor = uint8(id)
+	watch(link, &ignored, featureTimeout)
+	return p
+}
+
+// watch reads packets for d, recording the Get Feature response and the
+// input reports for the probed sensor.
+func watch(link transport.Transport, p *probe, d time.Duration) {
+	id := p.requested.Sensor
+	start := time.Now()
+	for time.Since(start) < d {
+		packet, err := transport.Next(link, d-time.Since(start))
+		if err != nil {
+			continue
+		}
+		switch packet.Channel {
+		case shtpraw.ChannelControl:
+			if f, err := shtpraw.ParseFeature(packet.Cargo()); err == nil && f.Sensor == id {
+				p.granted, p.answered = f, true
+			}
+		case shtpraw.ChannelInputNormal, shtpraw.ChannelInputWake:
+			n := 0
+			quarantine.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+				if r.ID == id {
+					n++
+				}
+			})
+			if n == 0 {
+				continue
+			}
+			p.reports += n
+			p.packets++
+			if n > p.mostPerPkt {
+				p.mostPerPkt = n
+			}
+		}
+	}
+}
+
+// printRow prints one line of the table.
+func printRow(id bno08x.SensorID, p *probe) {
+	line := fmtutil.PadRight(formatID(uint8(id)), 6) + fmtutil.PadRight(sensorinfo.Name(id), 30) +
+		fmtutil.PadLeft(fmtutil.Int(int(p.requested.Interval)), 12)
+	if !p.answered {
+		println(line + "  no response")
+		return
+	}
+	if p.granted.Interval == 0 {
+		println(line + "  not supported")
+		return
+	}
+	println(line + fmtutil.PadLeft(fmtutil.Int(int(p.granted.Interval)), 13) +
+		fmtutil.PadLeft(fmtutil.Int(int(p.requested.BatchInterval)), 12) +
+		fmtutil.PadLeft(fmtutil.Int(int(p.granted.BatchInterval)), 12) +
+		fmtutil.PadLeft(fmtutil.Int(p.reports), 9) + "  " + p.batching())
+}
+
+func formatID(id uint8) string {
+	const hex = "0123456789ABCDEF"
+	return "0x" + string([]byte{hex[id>>4], hex[id&0x0F]})
+}
@@ -0,0 +1,75 @@
+// Package main reads a BNO08x strapped for UART-RVC mode (PS0 high, PS1
+// low), where the sensor streams heading, pitch, roll and acceleration at
+// 100Hz over a plain UART with no host commands needed.
+//
+// Wire the sensor's SDA/TX pin to the RX pin given by board.RVCUART.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/rvc"
+)
+
+// Interval between printed frames; the rest are parsed and counted only
+const printInterval = 100 * time.Millisecond
+
+// Decimal places printed for angles and accelerations
+const decimals = 2
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("rvc_reader")
+
+	uart, config := board.RVCUART()
+	if uart == nil {
+		println("No RVC UART assigned for the " + board.Name + " board, see internal/board")
+		return
+	}
+	config.BaudRate = rvc.BaudRate
+	if err := uart.Configure(config); err != nil {
+		println("Failed to configure UART:", err.Error())
+		return
+	}
+
+	println("Listening for UART-RVC frames at 115200 baud...")
+	println("Format: Index Yaw Pitch Roll (deg) | AccelX AccelY AccelZ (m/s²)")
+
+	var parser rvc.Parser
+	lastPrint := time.Now()
+	lastStats := time.Now()
+
+	for {
+		for uart.Buffered() > 0 {
+			b, err := uart.ReadByte()
+			if err != nil {
+				break
+			}
+			frame, ok := parser.Feed(b)
+			if !ok || time.Since(lastPrint) < printInterval {
+				continue
+			}
+			lastPrint = time.Now()
+			println(frame.Index,
+				fmtutil.Float(frame.Yaw, decimals),
+				fmtutil.Float(frame.Pitch, decimals),
+				fmtutil.Float(frame.Roll, decimals),
+				"|", fmtutil.Float(frame.AccelX, decimals),
+				fmtutil.Float(frame.AccelY, decimals),
+				fmtutil.Float(frame.AccelZ, decimals))
+		}
+
+		if time.Since(lastStats) >= 10*time.Second {
+			lastStats = time.Now()
+			println("Frames:", parser.Frames, "bad checksums:", parser.BadChecksums, "dropped:", parser.Dropped)
+			if parser.Frames == 0 {
+				println("No valid frames yet: check PS0/PS1 strapping and the RX wire")
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,195 @@
+// Package main shows how much precision the SH-2 timebase gives sample
+// timestamps. Every input cargo starts with a base timestamp counting back
+// from the INT edge that announced it, and every report carries a delay
+// from that reference; shtpraw.DecodeInput combines them with the edge
+// time latched by timestamps.Edge into the MCU time each sample was taken.
+//
+// The accelerometer runs at 100Hz and each sample is timestamped three
+// ways: from the INT edge and the delay fields, from the time of the read
+// and the delay fields, and with the time of the read alone, as a program
+// calling time.Now for each event would. Every few seconds the spacing
+// between consecutive samples is summarised for each: the closer the
+// jitter is to zero, the closer the timestamps are to when the samples
+// were really taken.
+//
+// Needs the INT pin wired for the first method. Runs over raw SHTP, to
+// see the timebase records. Uses I2C by default, or SPI when built with
+// "-tags bno08x_spi".
+package main
+
+import (
+	"machine"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/timestamps"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	sensor = bno08x.SensorAccelerometer
+	// Report interval in microseconds
+	reportInterval = sensorinfo.Interval100Hz
+	// Interval between summaries
+	summaryInterval = 5 * time.Second
+)
+
+// Timestamping methods compared
+const (
+	methodEdge = iota
+	methodRead
+	methodNow
+	numMethods
+)
+
+var methodNames = [numMethods]string{
+	"INT edge + delays",
+	"Read time + delays",
+	"Read time only",
+}
+
+// spacing summarises the gaps between consecutive sample timestamps.
+type spacing struct {
+	last     int64
+	primed   bool
+	n        int
+	mean, m2 float64
+	min, max int64
+}
+
+// add records the timestamp of the next sample.
+func (s *spacing) add(t int64) {
+	if !s.primed {
+		s.last, s.primed = t, true
+		return
+	}
+	gap := t - s.last
+	s.last = t
+	if s.n == 0 || gap < s.min {
+		s.min = gap
+	}
+	if s.n == 0 || gap > s.max {
+		s.max = gap
+	}
+	// Welford's online mean and variance
+	s.n++
+	d := float64(gap) - s.mean
+	s.mean += d / float64(s.n)
+	s.m2 += d * (float64(gap) - s.mean)
+}
+
+// jitter returns the standard deviation of the gaps in microseconds.
+func (s *spacing) jitter() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.n-1))
+}
+
+// restart clears the summary but keeps the last timestamp, so the next
+// gap is still measured.
+func (s *spacing) restart() {
+	*s = spacing{last: s.last, primed: s.primed}
+}
+
+// Set from the INT pin interrupt
+var edge timestamps.Edge
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("sample_times")
+	println("=== BNO08x Sample Timestamps ===")
+	println()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	edge.Start()
+	haveEdge := false
+	if intPin := board.IntPin(); intPin != machine.NoPin {
+		// The transport configured the pin as an input with pull-up
+		err := intPin.SetInterrupt(machine.PinFalling, func(machine.Pin) { edge.Mark() })
+		if err != nil {
+			println("Could not enable the INT interrupt:", err.Error())
+		}
+		haveEdge = err == nil
+	}
+	if !haveEdge {
+		println("No INT interrupt; only the read time methods are measured")
+	}
+
+	var cmd [shtpraw.FeatureLen]byte
+	f := shtpraw.Feature{Sensor: uint8(sensor), Interval: reportInterval}
+	if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], f)); err != nil {
+		println("Failed to enable", sensorinfo.Name(sensor)+":", err.Error())
+		return
+	}
+	println(sensorinfo.Name(sensor), "at", 1000000/reportInterval, "Hz; first summary in",
+		int(summaryInterval/time.Second), "seconds")
+	println()
+
+	var stats [numMethods]spacing
+	packets, noEdge := 0, 0
+	lastSummary := time.Now()
+	for {
+		packet, err := transport.Next(link, 100*time.Millisecond)
+		if err == nil && packet.Channel == shtpraw.ChannelInputNormal {
+			edgeTime, marked := edge.Take()
+			readTime := edge.Now()
+			packets++
+			if !marked {
+				noEdge++
+			}
+			cargo := packet.Cargo()
+			shtpraw.DecodeInput(cargo, edgeTime, func(r shtpraw.Report) {
+				if r.ID == uint8(sensor) && haveEdge {
+					stats[methodEdge].add(r.Timestamp)
+				}
+			})
+			shtpraw.DecodeInput(cargo, readTime, func(r shtpraw.Report) {
+				if r.ID == uint8(sensor) {
+					stats[methodRead].add(r.Timestamp)
+					stats[methodNow].add(readTime)
+				}
+			})
+		}
+
+		if time.Since(lastSummary) < summaryInterval {
+			continue
+		}
+		lastSummary = time.Now()
+		println(fmtutil.PadRight("Method", 20) + fmtutil.PadLeft("Samples", 8) + fmtutil.PadLeft("Mean ms", 9) +
+			fmtutil.PadLeft("Jitter us", 11) + fmtutil.PadLeft("Min us", 8) + fmtutil.PadLeft("Max us", 8))
+		for m := range stats {
+			s := &stats[m]
+			if s.n == 0 {
+				continue
+			}
+			println(fmtutil.PadRight(methodNames[m], 20) + fmtutil.PadLeft(fmtutil.Int(s.n), 8) +
+				fmtutil.FloatWidth(float32(s.mean/1000), 3, 9) +
+				fmtutil.FloatWidth(float32(s.jitter()), 1, 11) +
+				fmtutil.PadLeft(fmtutil.Int64(s.min), 8) + fmtutil.PadLeft(fmtutil.Int64(s.max), 8))
+			s.restart()
+		}
+		if haveEdge && noEdge > 0 {
+			println(noEdge, "of", packets, "packets had no INT edge of their own and used the read time")
+		}
+		println()
+		packets, noEdge = 0, 0
+	}
+}
@@ -0,0 +1,239 @@
+// Package main reads the metadata record every sensor has in the
+// BNO08x's Flash Record System and prints a capability table for the
+// attached part: each sensor's range and resolution, the current it
+// draws, the fastest and slowest report rates it runs at and the batch
+// FIFO entries kept for it. Sensors without a record are not on this
+// part or firmware. Run it before enabling anything to see which sensors
+// and rates the silicon really supports; BNO080, BNO085 and BNO086 parts
+// differ in both.
+//
+// Runs over I2C by default, or over SPI when built with "-tags bno08x_spi".
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/firmware"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/frs"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+)
+
+// Longest to wait for the whole of one record, or the product IDs
+const readTimeout = time.Second
+
+var errTimeout = errors.New("no complete answer in time")
+
+// Sensors with no ID in the driver, listed after sensorinfo.All
+var extra = []struct {
+	id   uint8
+	name string
+}{
+	{0x28, "AR/VR Rotation Vector"},
+	{0x29, "AR/VR Game Rotation Vector"},
+	{0x2A, "Gyro-Integrated RV"},
+}
+
+// row is one sensor of the table.
+type row struct {
+	id      uint8
+	name    string
+	unit    string
+	meta    frs.Metadata
+	present bool
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("sensor_metadata")
+	println("=== BNO08x Sensor Capabilities ===")
+	println()
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	part, version, err := productID(link)
+	switch {
+	case err != nil:
+		println("Product ID: not read,", err.Error())
+	case firmware.PartName(part) != "":
+		println("Part:", firmware.PartName(part), "(software part", fmtutil.Int(int(part))+", firmware", version.String()+")")
+	default:
+		println("Part: unknown software part", fmtutil.Int(int(part))+", firmware", version.String())
+	}
+	println()
+
+	var rows []row
+	for _, id := range sensorinfo.All {
+		rows = append(rows, row{id: uint8(id), name: sensorinfo.Name(id), unit: sensorinfo.Unit(id)})
+	}
+	for _, e := range extra {
+		rows = append(rows, row{id: e.id, name: e.name})
+	}
+
+	var a frs.Assembler
+	for i := range rows {
+		r := &rows[i]
+		t, ok := frs.MetadataRecord(r.id)
+		if !ok {
+			continue
+		}
+		if err := read(link, &a, t); err != nil && err != frs.ErrUnknownType {
+			println("  Reading", r.name, "failed:", err.Error())
+			continue
+		}
+		if !a.Empty && a.Err() == nil {
+			r.meta, r.present = frs.ParseMetadata(a.Words)
+		}
+	}
+
+	println(fmtutil.PadRight("ID", 6) + fmtutil.PadRight("Sensor", 30) + fmtutil.PadLeft("Range", 11) +
+		fmtutil.PadLeft("Resolution", 12) + "  " + fmtutil.PadRight("Unit", 6) + fmtutil.PadLeft("mA", 7) +
+		fmtutil.PadLeft("Max Hz", 9) + fmtutil.PadLeft("Min Hz", 9) + fmtutil.PadLeft("FIFO", 6))
+	present := 0
+	for i := range rows {
+		if rows[i].present {
+			present++
+		}
+		printRow(&rows[i])
+	}
+
+	println()
+	println(present, "of", len(rows), "sensors present on this part")
+	if vendor := vendors(rows); vendor != "" {
+		println("Sensing elements:", vendor)
+	}
+	for _, r := range rows {
+		if r.id == 0x2A && !r.present {
+			println("No gyro-integrated rotation vector: BNO080 firmware, or older than 3.2")
+			break
+		}
+	}
+	println("Max Hz is the fastest rate the firmware runs the sensor at; asking")
+	println("for more is rounded down to it. Min Hz is the slowest, where given.")
+}
+
+// productID asks for the product IDs and returns the software part number
+// and version of the application firmware, the first entry.
+func productID(link transport.Transport) (uint32, firmware.Version, error) {
+	if err := link.Write(shtpraw.ChannelControl, []byte{0xF9, 0x00}); err != nil {
+		return 0, firmware.Version{}, err
+	}
+	start := time.Now()
+	for time.Since(start) < readTimeout {
+		packet, err := transport.Next(link, readTimeout-time.Since(start))
+		if err != nil || packet.Channel != shtpraw.ChannelControl {
+			continue
+		}
+		cargo := packet.Cargo()
+		if len(cargo) >= 16 && cargo[0] == 0xF8 {
+			v := firmware.Version{Major: cargo[2], Minor: cargo[3], Patch: binary.LittleEndian.Uint16(cargo[12:14])}
+			return binary.LittleEndian.Uint32(cargo[4:8]), v, nil
+		}
+	}
+	return 0, firmware.Version{}, errTimeout
+}
+
+// read fetches the whole of a record into a.
+func read(link transport.Transport, a *frs.Assembler, recordType uint16) error {
+	a.Start(recordType)
+	var req [frs.ReadRequestLen]byte
+	if err := link.Write(shtpraw.ChannelControl, frs.AppendReadRequest(req[:0], recordType, 0, 0)); err != nil {
+		return err
+	}
+	start := time.Now()
+	done := false
+	for !done && time.Since(start) < readTimeout {
+		packet, err := transport.Next(link, readTimeout-time.Since(start))
+		if err != nil || packet.Channel != shtpraw.ChannelControl {
+			continue
+		}
+		frs.ParseReadResponses(packet.Cargo(), func(r frs.ReadResponse) {
+			done = a.Add(r) || done
+		})
+	}
+	if !done {
+		return errTimeout
+	}
+	return a.Err()
+}
+
+// printRow prints one line of the table.
+func printRow(r *row) {
+	line := fmtutil.PadRight(formatID(r.id), 6) + fmtutil.PadRight(r.name, 30)
+	if !r.present {
+		println(line + fmtutil.PadLeft("-", 11) + "  not on this part")
+		return
+	}
+	m := r.meta
+	line += fmtutil.PadLeft(formatValue(m.RangeValue()), 11) + fmtutil.PadLeft(formatValue(m.ResolutionValue()), 12) +
+		"  " + fmtutil.PadRight(r.unit, 6) + fmtutil.PadLeft(fmtutil.Float(m.PowerMilliamps(), 2), 7)
+	line += fmtutil.PadLeft(formatRate(m.MinPeriod), 9) + fmtutil.PadLeft(formatRate(m.MaxPeriod), 9)
+	println(line + fmtutil.PadLeft(fmtutil.Int(int(m.FIFOMax)), 6))
+}
+
+// formatValue prints v with enough decimals to show small resolutions.
+func formatValue(v float32) string {
+	switch {
+	case v == 0:
+		return "0"
+	case v < 0.001:
+		return fmtutil.Float(v, 6)
+	case v < 1:
+		return fmtutil.Float(v, 4)
+	}
+	return fmtutil.Float(v, 1)
+}
+
+// formatRate converts a report interval in microseconds to a rate, or "-"
+// if it is not given.
+func formatRate(period uint32) string {
+	if period == 0 {
+		return "-"
+	}
+	return fmtutil.Float(1e6/float32(period), 1)
+}
+
+// vendors lists the distinct vendor IDs of the present sensors.
+func vendors(rows []row) string {
+	var seen []string
+	for _, r := range rows {
+		if !r.present || r.meta.VendorID == "" {
+			continue
+		}
+		known := false
+		for _, s := range seen {
+			known = known || s == r.meta.VendorID
+		}
+		if !known {
+			seen = append(seen, r.meta.VendorID)
+		}
+	}
+	list := ""
+	for i, s := range seen {
+		if i > 0 {
+			list += ", "
+		}
+		list += s
+	}
+	return list
+}
+
+func formatID(id uint8) string {
+	const hex = "0123456789ABCDEF"
+	return "0x" + string([]byte{hex[id>>4], hex[id&0x0F]})
+}
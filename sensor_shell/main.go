@@ -0,0 +1,310 @@
+// Package main is an interactive shell over USB serial for reconfiguring
+// the sensor at runtime, without reflashing. Type "help" for the commands:
+//
+//	enable 0x08 10000   enable the game rotation vector at 100Hz
+//	disable 0x08        stop it again
+//	show 0x08           print its values twice a second ("show off" stops)
+//	ids                 print the product IDs
+//	stats               print event counts and rates per sensor
+//	tare                zero the orientation at the current pose
+//	reset               re-initialize the sensor and restore the reports
+//	suspend [slow]      quiesce the reports while the bus is shared
+//	resume              restore them after "suspend"
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/dispatch"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/reports"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Interval between lines printed by "show"
+const showInterval = 500 * time.Millisecond
+
+// Decimal places printed for values
+const decimals = 3
+
+var errNoOrientation = errors.New("no rotation vector received yet; enable 0x05 or 0x08 first")
+
+// session holds the state the commands work on.
+type session struct {
+	sensor  *bno08x.Device
+	events  *dispatch.Dispatcher
+	reports *reports.Set // Enabled reports, restored by reset and resume
+
+	show     bno08x.SensorID
+	showing  bool
+	lastShow time.Time
+
+	orientation bno08x.Quaternion // Latest rotation vector
+	haveOrient  bool
+	tare        bno08x.Quaternion
+	tared       bool
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("sensor_shell")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+	println("No reports enabled. Type 'help' for commands.")
+
+	s := &session{sensor: sensor, events: dispatch.New(), reports: reports.New(sensor)}
+	s.events.HandleDefault(s.handle)
+
+	var sh shell.Shell
+	sh.Register("enable", "enable <id> <interval_us>", "Enable a report", s.enable)
+	sh.Register("disable", "disable <id>", "Disable a report", s.disable)
+	sh.Register("show", "show <id>|off", "Print a report's values twice a second", s.showCmd)
+	sh.Register("ids", "ids", "Print the product IDs", s.ids)
+	sh.Register("stats", "stats", "Print event counts and rates", s.stats)
+	sh.Register("tare", "tare [clear]", "Zero the orientation at the current pose", s.tareCmd)
+	sh.Register("reset", "reset", "Re-initialize the sensor and restore reports", s.reset)
+	sh.Register("suspend", "suspend [slow]", "Turn reports off, or to 1Hz, while the bus is shared", s.suspend)
+	sh.Register("resume", "resume", "Restore the reports after suspend", s.resume)
+
+	for {
+		sh.Poll()
+		if !s.events.Poll(sensor) {
+			time.Sleep(2 * time.Millisecond)
+		}
+	}
+}
+
+// handle keeps the latest orientation and prints the shown sensor.
+func (s *session) handle(event *bno08x.SensorValue) {
+	id := event.ID()
+	if id == bno08x.SensorRotationVector || id == bno08x.SensorGameRotationVector {
+		s.orientation, s.haveOrient = event.Quaternion(), true
+	}
+	if !s.showing || id != s.show || time.Since(s.lastShow) < showInterval {
+		return
+	}
+	s.lastShow = time.Now()
+
+	switch id {
+	case bno08x.SensorRotationVector, bno08x.SensorGameRotationVector, bno08x.SensorGeomagneticRotationVector:
+		q := event.Quaternion()
+		if s.tared {
+			q = quat.Relative(s.tare, q)
+		}
+		roll, pitch, yaw := quat.ToEuler(q)
+		println(sensorinfo.Name(id)+": roll", fmtutil.Float(units.RadiansToDegrees(roll), 1),
+			"pitch", fmtutil.Float(units.RadiansToDegrees(pitch), 1),
+			"yaw", fmtutil.Float(units.RadiansToDegrees(yaw), 1), "deg")
+	case bno08x.SensorAccelerometer:
+		v := event.Accelerometer()
+		printVector(id, v.X, v.Y, v.Z)
+	case bno08x.SensorLinearAcceleration:
+		v := event.LinearAcceleration()
+		printVector(id, v.X, v.Y, v.Z)
+	case bno08x.SensorGravity:
+		v := event.Gravity()
+		printVector(id, v.X, v.Y, v.Z)
+	case bno08x.SensorGyroscope:
+		v := event.Gyroscope()
+		printVector(id, v.X, v.Y, v.Z)
+	case bno08x.SensorMagneticField:
+		v := event.MagneticField()
+		printVector(id, v.X, v.Y, v.Z)
+	default:
+		println(sensorinfo.Name(id) + ": event received")
+	}
+}
+
+func printVector(id bno08x.SensorID, x, y, z float32) {
+	println(sensorinfo.Name(id)+":", fmtutil.Float(x, decimals), fmtutil.Float(y, decimals),
+		fmtutil.Float(z, decimals), sensorinfo.Unit(id))
+}
+
+// parseID parses a sensor ID argument and checks that it is known.
+func parseID(arg string) (bno08x.SensorID, error) {
+	v, err := shell.ParseUint(arg, 8)
+	if err != nil {
+		return 0, shell.ErrUsage
+	}
+	id := bno08x.SensorID(v)
+	if !sensorinfo.Known(id) {
+		return 0, errors.New("unknown sensor ID " + arg)
+	}
+	return id, nil
+}
+
+func (s *session) enable(args []string) error {
+	if len(args) != 2 {
+		return shell.ErrUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	interval, err := shell.ParseUint(args[1], 32)
+	if err != nil || interval == 0 {
+		return shell.ErrUsage
+	}
+	if err := s.reports.Enable(id, uint32(interval)); err != nil {
+		return err
+	}
+	if s.reports.Suspended() {
+		println("Enabled", sensorinfo.Name(id), "every", interval, "us on resume")
+		return nil
+	}
+	println("Enabled", sensorinfo.Name(id), "every", interval, "us")
+	return nil
+}
+
+func (s *session) disable(args []string) error {
+	if len(args) != 1 {
+		return shell.ErrUsage
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	if err := s.reports.Disable(id); err != nil {
+		return err
+	}
+	println("Disabled", sensorinfo.Name(id))
+	return nil
+}
+
+func (s *session) showCmd(args []string) error {
+	if len(args) != 1 {
+		return shell.ErrUsage
+	}
+	if args[0] == "off" {
+		s.showing = false
+		return nil
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return err
+	}
+	if s.reports.Interval(id) == 0 {
+		println("Note:", sensorinfo.Name(id), "is not enabled")
+	}
+	s.show, s.showing = id, true
+	return nil
+}
+
+func (s *session) ids(args []string) error {
+	ids := s.sensor.ProductIDs()
+	if ids.NumEntries == 0 {
+		println("No product IDs available")
+		return nil
+	}
+	for i := 0; i < int(ids.NumEntries); i++ {
+		id := ids.Entries[i]
+		println("  Part", id.PartNumber, "version", id.VersionMajor, ".", id.VersionMinor, ".", id.VersionPatch,
+			"build", id.BuildNumber, "reset cause", id.ResetCause)
+	}
+	return nil
+}
+
+func (s *session) stats(args []string) error {
+	if s.events.Total() == 0 {
+		println("No events yet")
+		return nil
+	}
+	s.events.ForEach(func(id bno08x.SensorID, count uint32) {
+		println("  "+fmtutil.PadRight(sensorinfo.Name(id), 30), fmtutil.PadLeft(fmtutil.Int(int(count)), 8),
+			"events", fmtutil.Float(s.events.Rate(id), 1), "Hz")
+	})
+	println("  Total:", s.events.Total())
+	return nil
+}
+
+func (s *session) tareCmd(args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "clear":
+		s.tared = false
+		println("Tare cleared")
+		return nil
+	case len(args) != 0:
+		return shell.ErrUsage
+	case !s.haveOrient:
+		return errNoOrientation
+	}
+	s.tare, s.tared = s.orientation, true
+	println("Orientation zeroed at the current pose")
+	return nil
+}
+
+func (s *session) reset(args []string) error {
+	if err := s.sensor.Configure(bno08x.Config{}); err != nil {
+		return err
+	}
+	s.events.Reset()
+	s.haveOrient = false
+	// A reset sensor starts with every report off, so a suspended set
+	// stays quiet until resume
+	if s.reports.Suspended() {
+		println("Sensor re-initialized, reports stay suspended until resume")
+		return nil
+	}
+	restored, err := s.reports.Restore()
+	if err != nil {
+		println("  Could not restore every report:", err.Error())
+	}
+	println("Sensor re-initialized,", restored, "report(s) restored")
+	return nil
+}
+
+func (s *session) suspend(args []string) error {
+	mode := reports.Off
+	switch {
+	case len(args) == 1 && args[0] == "slow":
+		mode = reports.Slow
+	case len(args) != 0:
+		return shell.ErrUsage
+	}
+	if s.reports.Suspended() {
+		println("Reports are already suspended")
+		return nil
+	}
+	if err := s.reports.Suspend(mode); err != nil {
+		return err
+	}
+	if mode == reports.Slow {
+		println("Reports slowed to 1Hz; type 'resume' to restore them")
+	} else {
+		println("Reports off; type 'resume' to restore them")
+	}
+	return nil
+}
+
+func (s *session) resume(args []string) error {
+	if !s.reports.Suspended() {
+		println("Reports are not suspended")
+		return nil
+	}
+	if err := s.reports.Resume(); err != nil {
+		return err
+	}
+	println("Reports restored")
+	return nil
+}
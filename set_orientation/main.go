@@ -0,0 +1,254 @@
+// Package main writes the System Orientation FRS record, the rotation the
+// sensor hub applies from the sensor's axes to the device's before any
+// output, so a board mounted upside down or on its side reports the
+// device's orientation with no correction on the host. The record lives
+// in the sensor's flash and is read at boot: reset the sensor after a
+// change.
+//
+// The rotation is given as a quaternion, normalised before it is written.
+// Some common mounts:
+//
+//	set 1 0 0 0            the default: sensor axes are device axes
+//	set 0 1 0 0            upside down, turned over about X
+//	set 0.7071 0 0 0.7071  turned a quarter turn about Z
+//
+// Runs over raw SHTP, to read and write the record. Uses I2C by default,
+// or SPI when built with "-tags bno08x_spi".
+//
+//	set <w> <x> <y> <z>  write the record
+//	clear                delete the record, going back to the default
+//	read                 print the record
+//	reset                reset the sensor so a new record takes effect
+//	show [off]           print the orientation twice a second
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/frs"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Rotation vector report interval in microseconds
+	interval = 20000 // 50Hz
+	// Interval between lines printed by "show"
+	showInterval = 500 * time.Millisecond
+	// Longest to wait for a read or write to finish
+	frsTimeout = 2 * time.Second
+)
+
+// Rotation vector quaternion scale, Q14
+const quatScale = 1 << 14
+
+var errTimeout = errors.New("no complete answer in time")
+
+// session holds the state the commands work on.
+type session struct {
+	link       transport.Transport
+	q          bno08x.Quaternion // Latest rotation vector
+	haveOrient bool
+	showing    bool
+	lastShow   time.Time
+	buf        [frs.WriteDataRequestLen]byte
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("set_orientation")
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	s := &session{link: link, showing: true}
+	if err := s.restart(); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	var sh shell.Shell
+	sh.Register("set", "set <w> <x> <y> <z>", "Write the System Orientation record", s.set)
+	sh.Register("clear", "clear", "Delete the record, going back to the default", func(args []string) error {
+		if err := s.write(nil); err != nil {
+			return err
+		}
+		println("Record deleted; type 'reset' to apply")
+		return nil
+	})
+	sh.Register("read", "read", "Print the record", func(args []string) error {
+		return s.read()
+	})
+	sh.Register("reset", "reset", "Reset the sensor so a new record takes effect", func(args []string) error {
+		return s.restart()
+	})
+	sh.Register("show", "show [off]", "Print the orientation twice a second", func(args []string) error {
+		s.showing = len(args) == 0 || args[0] != "off"
+		return nil
+	})
+
+	if err := s.read(); err != nil {
+		println("Could not read the record:", err.Error())
+	}
+	println("Type 'set <w> <x> <y> <z>' to change the mounting, 'help' for commands")
+
+	for {
+		sh.Poll()
+		packet, err := transport.Next(link, 10*time.Millisecond)
+		if err == nil {
+			s.input(packet)
+		}
+
+		if now := time.Now(); s.showing && s.haveOrient && now.Sub(s.lastShow) >= showInterval {
+			s.lastShow = now
+			roll, pitch, yaw := quat.ToEuler(s.q)
+			println("Roll:", fmtutil.FloatWidth(units.RadiansToDegrees(roll), 1, 6),
+				"| Pitch:", fmtutil.FloatWidth(units.RadiansToDegrees(pitch), 1, 6),
+				"| Yaw:", fmtutil.FloatWidth(units.RadiansToDegrees(yaw), 1, 6))
+		}
+	}
+}
+
+// restart resets the sensor, lets the advertisement and reset messages go
+// by and enables the rotation vector.
+func (s *session) restart() error {
+	println("Resetting sensor...")
+	if err := s.link.Reset(); err != nil {
+		return err
+	}
+	s.haveOrient = false
+	time.Sleep(300 * time.Millisecond)
+	for {
+		if _, err := transport.Next(s.link, 200*time.Millisecond); err != nil {
+			break
+		}
+	}
+	rv := shtpraw.Feature{Sensor: uint8(bno08x.SensorRotationVector), Interval: interval}
+	return s.link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(s.buf[:0], rv))
+}
+
+func (s *session) set(args []string) error {
+	if len(args) != 4 {
+		return shell.ErrUsage
+	}
+	var v [4]float32
+	for i, arg := range args {
+		f, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return shell.ErrUsage
+		}
+		v[i] = float32(f)
+	}
+	q := bno08x.Quaternion{Real: v[0], I: v[1], J: v[2], K: v[3]}
+	if quat.Dot(q, q) == 0 {
+		return errors.New("the quaternion must not be zero")
+	}
+	q = quat.Normalize(q)
+	words := frs.QuaternionWords(q)
+	if err := s.write(words[:]); err != nil {
+		return err
+	}
+	println("Written:", formatQuat(q)+"; type 'reset' to apply")
+	return nil
+}
+
+// write writes words to the System Orientation record, deleting it if
+// words is empty.
+func (s *session) write(words []uint32) error {
+	var w frs.Writer
+	if err := s.link.Write(shtpraw.ChannelControl, w.Start(s.buf[:0], frs.SystemOrientation, words)); err != nil {
+		return err
+	}
+	start := time.Now()
+	for time.Since(start) < frsTimeout {
+		packet, err := transport.Next(s.link, frsTimeout-time.Since(start))
+		if err != nil {
+			continue
+		}
+		r, err := frs.ParseWriteResponse(packet.Cargo())
+		if packet.Channel != shtpraw.ChannelControl || err != nil {
+			s.input(packet)
+			continue
+		}
+		req, done, err := w.Next(s.buf[:0], r)
+		if done {
+			return err
+		}
+		if len(req) > 0 {
+			if err := s.link.Write(shtpraw.ChannelControl, req); err != nil {
+				return err
+			}
+		}
+	}
+	return errTimeout
+}
+
+// read prints the System Orientation record.
+func (s *session) read() error {
+	var a frs.Assembler
+	a.Start(frs.SystemOrientation)
+	var req [frs.ReadRequestLen]byte
+	if err := s.link.Write(shtpraw.ChannelControl, frs.AppendReadRequest(req[:0], frs.SystemOrientation, 0, 0)); err != nil {
+		return err
+	}
+	start := time.Now()
+	done := false
+	for !done && time.Since(start) < frsTimeout {
+		packet, err := transport.Next(s.link, frsTimeout-time.Since(start))
+		if err != nil {
+			continue
+		}
+		if packet.Channel != shtpraw.ChannelControl {
+			s.input(packet)
+			continue
+		}
+		frs.ParseReadResponses(packet.Cargo(), func(r frs.ReadResponse) {
+			done = a.Add(r) || done
+		})
+	}
+	switch {
+	case !done:
+		return errTimeout
+	case a.Err() != nil:
+		return a.Err()
+	}
+	if q, ok := frs.Quaternion(a.Words); ok && !a.Empty {
+		println("System orientation:", formatQuat(q))
+	} else {
+		println("System orientation: not set, sensor axes are device axes")
+	}
+	return nil
+}
+
+// input records the rotation vector from an input packet.
+func (s *session) input(packet shtpraw.Packet) {
+	if packet.Channel != shtpraw.ChannelInputNormal && packet.Channel != shtpraw.ChannelInputWake {
+		return
+	}
+	shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+		if bno08x.SensorID(r.ID) != bno08x.SensorRotationVector || len(r.Data) < 12 {
+			return
+		}
+		v := func(i int) float32 {
+			return float32(int16(binary.LittleEndian.Uint16(r.Data[4+2*i:]))) / quatScale
+		}
+		s.q, s.haveOrient = bno08x.Quaternion{I: v(0), J: v(1), K: v(2), Real: v(3)}, true
+	})
+}
+
+func formatQuat(q bno08x.Quaternion) string {
+	return "w " + fmtutil.Float(q.Real, 4) + " x " + fmtutil.Float(q.I, 4) +
+		" y " + fmtutil.Float(q.J, 4) + " z " + fmtutil.Float(q.K, 4)
+}
@@ -2,18 +2,22 @@
 package main
 
 import (
-	"encoding/binary"
 	"machine"
 	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
 )
 
 func main() {
 	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("setfeature_test")
 	println("=== BNO08x SetFeature Command Test ===")
 	println()
 
 	// Initialize I2C
-	i2c := machine.I2C0
+	i2c := board.IMUBus()
 	err := i2c.Configure(machine.I2CConfig{
 		Frequency: 400 * machine.KHz,
 	})
@@ -22,12 +26,11 @@ func main() {
 		return
 	}
 
-	address := uint16(0x4A)
+	conn := shtpraw.New(i2c, shtpraw.DefaultAddress)
 
 	// Send soft reset
 	println("Sending soft reset...")
-	softReset := []byte{5, 0, 1, 0, 1}
-	err = i2c.Tx(address, softReset, nil)
+	err = conn.SoftReset()
 	if err != nil {
 		println("FAILED:", err.Error())
 		return
@@ -37,36 +40,29 @@ func main() {
 	// Drain any responses
 	println("Draining initial responses...")
 	for i := 0; i < 5; i++ {
-		header := make([]byte, 4)
-		i2c.Tx(address, nil, header)
-		length := binary.LittleEndian.Uint16(header[0:2])
-		channel := header[2]
-
-		if length > 0 && length < 1000 && (length&0x8000) == 0 {
-			println("  Got packet, length:", length, "channel:", channel)
-
-			// If it's channel 0, this is an advertisement - let's read and parse it
-			if channel == 0 && length > 4 {
-				payload := make([]byte, length-4)
-				err = i2c.Tx(address, nil, payload)
-				if err == nil {
-					println("  Advertisement payload (first 50 bytes):")
-					for j := 0; j < 50 && j < len(payload); j += 10 {
-						end := j + 10
-						if end > len(payload) {
-							end = len(payload)
-						}
-						print("    ")
-						for k := j; k < end; k++ {
-							print(payload[k], " ")
-						}
-						println()
+		packet, err := conn.ReadPacket()
+		if err == nil {
+			println("  Got packet, length:", packet.Length, "channel:", packet.Channel)
+
+			// If it's channel 0, this is an advertisement - let's parse it
+			if packet.Channel == shtpraw.ChannelCommand && packet.Length > shtpraw.HeaderLen {
+				payload := packet.Cargo()
+				println("  Advertisement payload (first 50 bytes):")
+				for j := 0; j < 50 && j < len(payload); j += 10 {
+					end := j + 10
+					if end > len(payload) {
+						end = len(payload)
 					}
-
-					// Parse TLV (Tag-Length-Value) format
-					println("  Parsing advertisement TLV tags:")
-					parseAdvertisement(payload)
+					print("    ")
+					for k := j; k < end; k++ {
+						print(payload[k], " ")
+					}
+					println()
 				}
+
+				// Parse TLV (Tag-Length-Value) format
+				println("  Parsing advertisement TLV tags:")
+				printAdvertisement(payload)
 			}
 		}
 		time.Sleep(50 * time.Millisecond)
@@ -82,14 +78,7 @@ func main() {
 		0x01,                                                 // Subcommand: System
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // Padding
 	}
-	initFrameLen := 4 + len(initPayload)
-	initFrame := make([]byte, initFrameLen)
-	binary.LittleEndian.PutUint16(initFrame[0:2], uint16(initFrameLen))
-	initFrame[2] = 2 // Channel: Control
-	initFrame[3] = 0 // Sequence
-	copy(initFrame[4:], initPayload)
-
-	err = i2c.Tx(address, initFrame, nil)
+	err = conn.SendOnChannel(shtpraw.ChannelControl, initPayload)
 	if err != nil {
 		println("FAILED to send initialize:", err.Error())
 		return
@@ -113,18 +102,10 @@ func main() {
 		0x00, 0x00, 0x00, 0x00, // Sensor specific: 0
 	}
 
-	// Build SHTP frame
-	frameLen := 4 + len(payload) // header + payload
-	frame := make([]byte, frameLen)
-	binary.LittleEndian.PutUint16(frame[0:2], uint16(frameLen))
-	frame[2] = 2 // Channel: Control
-	frame[3] = 0 // Sequence: 0
-	copy(frame[4:], payload)
-
-	println("  Frame length:", frameLen)
+	println("  Frame length:", shtpraw.HeaderLen+len(payload))
 	println("  Payload:", payload)
 
-	err = i2c.Tx(address, frame, nil)
+	err = conn.SendOnChannel(shtpraw.ChannelControl, payload)
 	if err != nil {
 		println("FAILED to send:", err.Error())
 		return
@@ -136,38 +117,23 @@ func main() {
 	// Poll for responses
 	println("Polling for sensor reports (30 attempts)...")
 	for i := 0; i < 30; i++ {
-		header := make([]byte, 4)
-		err = i2c.Tx(address, nil, header)
-		if err != nil {
-			println("  Attempt", i+1, "- Read error:", err.Error())
+		packet, err := conn.ReadPacket()
+		if err == shtpraw.ErrNoData || err == shtpraw.ErrContinuation {
+			// No data
 			time.Sleep(100 * time.Millisecond)
 			continue
 		}
-
-		length := binary.LittleEndian.Uint16(header[0:2])
-		channel := header[2]
-		seq := header[3]
-
-		// Check for continuation bit
-		if length&0x8000 != 0 {
-			// No data
+		if err != nil {
+			println("  Attempt", i+1, "- Read error:", err.Error())
 			time.Sleep(100 * time.Millisecond)
 			continue
 		}
 
-		if length > 0 && length < 1000 {
-			println("  Attempt", i+1, "- Length:", length, "Channel:", channel, "Seq:", seq)
-
-			// Read full packet
-			if length > 4 {
-				remaining := make([]byte, length-4)
-				err = i2c.Tx(address, nil, remaining)
-				if err == nil {
-					println("    Payload[0]:", remaining[0], "- might be sensor ID")
-					if channel == 3 || channel == 4 || channel == 5 {
-						println("    This is a sensor report channel!")
-					}
-				}
+		println("  Attempt", i+1, "- Length:", packet.Length, "Channel:", packet.Channel, "Seq:", packet.Sequence)
+		if packet.Length > shtpraw.HeaderLen {
+			println("    Payload[0]:", packet.Cargo()[0], "- might be sensor ID")
+			if packet.Channel >= shtpraw.ChannelInputNormal {
+				println("    This is a sensor report channel!")
 			}
 		}
 
@@ -178,39 +144,25 @@ func main() {
 	println("Test complete")
 }
 
-func parseAdvertisement(payload []byte) {
-	// Advertisement uses TLV format: Tag (1 byte), Length (1 byte), Value (Length bytes)
-	// Looking for channel tags (6=normal channel, 7=wake channel)
-	i := 0
-	for i < len(payload)-2 {
-		tag := payload[i]
-		length := int(payload[i+1])
-		i += 2
-
-		if i+length > len(payload) {
-			break
-		}
-
-		value := payload[i : i+length]
-		i += length
-
-		if tag == 6 {
-			// Normal channel
-			if length > 1 {
-				chanNum := value[0]
-				name := string(value[1:])
-				println("    Channel", chanNum, "=", name)
-			}
-		} else if tag == 7 {
-			// Wake channel
-			if length > 1 {
-				chanNum := value[0]
-				name := string(value[1:])
-				println("    Wake Channel", chanNum, "=", name)
-			}
-		} else if tag == 0x80 {
-			// Version string
-			println("    Version:", string(value))
+// printAdvertisement prints the channels and versions listed in an
+// advertisement payload.
+func printAdvertisement(payload []byte) {
+	adv, err := shtpraw.ParseAdvertisement(payload)
+	if err != nil {
+		println("    Parse error:", err.Error())
+		return
+	}
+	for _, ch := range adv.Channels {
+		if ch.Wake {
+			println("    Wake Channel", ch.Number, "=", ch.App+"/"+ch.Name)
+		} else {
+			println("    Channel", ch.Number, "=", ch.App+"/"+ch.Name)
 		}
 	}
+	if adv.SHTPVersion != "" {
+		println("    SHTP Version:", adv.SHTPVersion)
+	}
+	if adv.SH2Version != "" {
+		println("    SH-2 Version:", adv.SH2Version)
+	}
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/binary"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+)
+
+// Version of the flash record; records of another version are ignored.
+const recordVersion = 1
+
+// Entries the log holds; once full, later events are dropped. An entry's
+// worth of the record is left spare, so a full log stays clear of
+// store.MaxSize and its count fits the one-byte field.
+const maxEntries = (store.MaxSize - recordHeaderLen - entryLen) / entryLen
+
+// Length of the record header: version, full flag and entry count
+const recordHeaderLen = 3
+
+// Length of a log entry: minute, kind and value
+const entryLen = 4
+
+// Kinds of log entry
+const (
+	kindSleepState = iota + 1 // The sleep detector changed state; value is the new state
+	kindStillStart            // A low-motion period began
+	kindStillEnd              // A low-motion period ended
+	kindReset                 // The board restarted; the time since the last entry is lost
+)
+
+// entry is one timestamped event.
+type entry struct {
+	minute uint16 // Minutes since the log was started
+	kind   uint8
+	value  uint8
+}
+
+// sleepLog is the night's events, kept in flash.
+type sleepLog struct {
+	entries []entry
+	full    bool // Events were dropped for lack of room
+}
+
+// add appends an event, reporting false if the log is full.
+func (l *sleepLog) add(minute int, kind, value uint8) bool {
+	if len(l.entries) >= maxEntries {
+		l.full = true
+		return false
+	}
+	if minute > 0xFFFF {
+		minute = 0xFFFF
+	}
+	l.entries = append(l.entries, entry{minute: uint16(minute), kind: kind, value: value})
+	return true
+}
+
+// lastMinute returns the minute of the last entry, or 0.
+func (l *sleepLog) lastMinute() int {
+	if len(l.entries) == 0 {
+		return 0
+	}
+	return int(l.entries[len(l.entries)-1].minute)
+}
+
+// marshal appends the flash record to dst.
+func (l *sleepLog) marshal(dst []byte) []byte {
+	full := byte(0)
+	if l.full {
+		full = 1
+	}
+	dst = append(dst, recordVersion, full, byte(len(l.entries)))
+	for _, e := range l.entries {
+		dst = binary.LittleEndian.AppendUint16(dst, e.minute)
+		dst = append(dst, e.kind, e.value)
+	}
+	return dst
+}
+
+// unmarshal reads a flash record, reporting false if b is not one.
+func (l *sleepLog) unmarshal(b []byte) bool {
+	if len(b) < recordHeaderLen || b[0] != recordVersion || len(b) != recordHeaderLen+int(b[2])*entryLen {
+		return false
+	}
+	l.full = b[1] != 0
+	l.entries = l.entries[:0]
+	for b = b[recordHeaderLen:]; len(b) >= entryLen; b = b[entryLen:] {
+		l.entries = append(l.entries, entry{minute: binary.LittleEndian.Uint16(b), kind: b[2], value: b[3]})
+	}
+	return true
+}
+
+// print lists the entries.
+func (l *sleepLog) print() {
+	for _, e := range l.entries {
+		t := fmtutil.Elapsed(minutes(int(e.minute)))
+		switch e.kind {
+		case kindSleepState:
+			println(t, "sleep state", e.value)
+		case kindStillStart:
+			println(t, "low motion began")
+		case kindStillEnd:
+			println(t, "low motion ended")
+		case kindReset:
+			println(t, "board restarted")
+		}
+	}
+	if l.full {
+		println("Log full: later events were dropped")
+	}
+}
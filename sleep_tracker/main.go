@@ -0,0 +1,268 @@
+// Package main tracks a night's sleep with the board on the wrist or the
+// mattress. It logs each change of the sleep detector's state and each
+// low-motion period, a stretch of at least minStill in which the personal
+// activity classifier is confident the wearer is still, with the minutes
+// since the log was started. The log is saved to flash every saveInterval,
+// so it survives a flat battery; after a reset it carries on, noting the
+// restart, since the time the board was off is unknown.
+//
+// Pressing the button on board.ButtonPin, or typing "summary", prints a
+// histogram of the time spent in each sleep state and of the minutes of
+// low motion in each hour. The sleep detector's states are numbered as
+// the sensor reports them.
+//
+//	summary  print the histograms
+//	log      list the logged events
+//	clear    start a new night
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/store"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Shortest low-motion period logged
+	minStill = 5 * time.Minute
+	// Confidence the classifier needs in "still", percent
+	minConfidence = 60
+	// Interval between saves of a changed log
+	saveInterval = 10 * time.Minute
+	// Time the button must stay down to count as a press
+	debounce = 50 * time.Millisecond
+)
+
+// Histogram layout
+const (
+	// Sleep states counted separately; higher ones share the last bar
+	numStates = 8
+	// Hours of low motion shown
+	numHours = 16
+	// Minutes per bar character
+	stateScale = 10
+	hourScale  = 3
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("sleep_tracker")
+
+	button := board.ButtonPin()
+	if button != machine.NoPin {
+		button.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	}
+
+	settings := store.New(machine.Flash, "sleep_tracker")
+	var night sleepLog
+	var record [store.MaxSize]byte
+	baseMinute := 0
+	if n, err := settings.Load(record[:]); err == nil && night.unmarshal(record[:n]) {
+		baseMinute = night.lastMinute() + 1
+		night.add(baseMinute, kindReset, 0)
+		println("Restored", len(night.entries), "logged events")
+	} else if err != nil && err != store.ErrEmpty {
+		println("Stored log unusable:", err.Error())
+	}
+	start := time.Now()
+	minuteNow := func() int {
+		return baseMinute + int(time.Since(start)/time.Minute)
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range []bno08x.SensorID{bno08x.SensorSleepDetector, bno08x.SensorPersonalActivityClassifier} {
+		if err := sensor.EnableReport(id, sensorinfo.DefaultIntervalMicros(id)); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	dirty := false
+	save := func() error {
+		if err := settings.Save(night.marshal(record[:0])); err != nil {
+			return err
+		}
+		dirty = false
+		return nil
+	}
+	still, haveState := false, false
+	var state uint8
+	var stillSince time.Time
+
+	var sh shell.Shell
+	sh.Register("summary", "summary", "Print the histograms", func(args []string) error {
+		summarize(&night, minuteNow())
+		return nil
+	})
+	sh.Register("log", "log", "List the logged events", func(args []string) error {
+		night.print()
+		return nil
+	})
+	sh.Register("clear", "clear", "Start a new night", func(args []string) error {
+		night = sleepLog{}
+		baseMinute, start, haveState = 0, time.Now(), false
+		if still {
+			stillSince = start
+		}
+		if err := settings.Clear(); err != nil {
+			return err
+		}
+		println("Log cleared")
+		return nil
+	})
+
+	println("Tracking. Press the button or type 'summary' for the night so far, 'help' for commands")
+
+	lastSave := time.Now()
+	pressed := false
+	var downSince time.Time
+
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		if button != machine.NoPin {
+			switch down := !button.Get(); {
+			case !down:
+				downSince, pressed = time.Time{}, false
+			case downSince.IsZero():
+				downSince = now
+			case !pressed && now.Sub(downSince) >= debounce:
+				pressed = true
+				summarize(&night, minuteNow())
+			}
+		}
+
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorSleepDetector:
+				if s := event.SleepDetector(); !haveState || s != state {
+					state, haveState = s, true
+					dirty = night.add(minuteNow(), kindSleepState, s) || dirty
+					println(fmtutil.Elapsed(minutes(minuteNow())), "sleep state", s)
+				}
+			case bno08x.SensorPersonalActivityClassifier:
+				pac := event.PersonalActivityClassifier()
+				isStill := pac.MostLikelyState == sensorinfo.ActivityStill &&
+					pac.Confidence[sensorinfo.ActivityStill] >= minConfidence
+				switch {
+				case isStill && !still:
+					stillSince = now
+				case !isStill && still:
+					if d := now.Sub(stillSince); d >= minStill {
+						end := minuteNow()
+						night.add(end-int(d/time.Minute), kindStillStart, 0)
+						night.add(end, kindStillEnd, 0)
+						dirty = true
+						println(fmtutil.Elapsed(minutes(end)), "low motion for", int(d/time.Minute), "min")
+					}
+				}
+				still = isStill
+			}
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if dirty && now.Sub(lastSave) >= saveInterval {
+			lastSave = now
+			if err := save(); err != nil {
+				println("Failed to save log:", err.Error())
+			}
+		}
+	}
+}
+
+// summarize prints histograms of the time in each sleep state and the
+// minutes of low motion in each hour, up to minute now.
+func summarize(night *sleepLog, now int) {
+	var stateMinutes [numStates]int
+	var stillMinutes [numHours]int
+	state, stateFrom := -1, 0
+	stillFrom := -1
+
+	closeState := func(at int) {
+		if state >= 0 {
+			stateMinutes[min(state, numStates-1)] += at - stateFrom
+		}
+	}
+	closeStill := func(at int) {
+		for m := stillFrom; stillFrom >= 0 && m < at; m++ {
+			if h := m / 60; h < numHours {
+				stillMinutes[h]++
+			}
+		}
+		stillFrom = -1
+	}
+	for _, e := range night.entries {
+		at := int(e.minute)
+		switch e.kind {
+		case kindSleepState:
+			closeState(at)
+			state, stateFrom = int(e.value), at
+		case kindStillStart:
+			stillFrom = at
+		case kindStillEnd:
+			closeStill(at)
+		case kindReset:
+			// Nothing is known about the time the board was off
+			closeState(at)
+			closeStill(at)
+			state = -1
+		}
+	}
+	closeState(now)
+
+	println("Night so far:", fmtutil.Elapsed(minutes(now)))
+	println("Time in each sleep state (# =", stateScale, "min):")
+	for s, m := range stateMinutes {
+		if m == 0 {
+			continue
+		}
+		label := "state " + fmtutil.Int(s)
+		if s == numStates-1 {
+			label += "+"
+		}
+		println("  "+fmtutil.PadRight(label, 9), fmtutil.PadLeft(fmtutil.Int(m), 4), "min", bar(m, stateScale))
+	}
+	println("Low motion per hour (# =", hourScale, "min):")
+	for h := 0; h < numHours && h*60 <= now; h++ {
+		println("  hour", fmtutil.PadLeft(fmtutil.Int(h+1), 2), fmtutil.PadLeft(fmtutil.Int(stillMinutes[h]), 3), "min", bar(stillMinutes[h], hourScale))
+	}
+}
+
+// bar returns one '#' per scale, rounded up so short times still show.
+func bar(n, scale int) string {
+	b := make([]byte, (n+scale-1)/scale)
+	for i := range b {
+		b[i] = '#'
+	}
+	return string(b)
+}
+
+// minutes returns n minutes as a duration.
+func minutes(n int) time.Duration {
+	return time.Duration(n) * time.Minute
+}
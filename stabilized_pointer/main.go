@@ -0,0 +1,212 @@
+// Package main keeps a laser pointer, or anything else on a pan/tilt servo
+// mount, aimed at a fixed direction in the world while the platform under
+// it moves. Mount the pan servo so it turns about the board's Z axis, with
+// the tilt servo on top and the pointer along the board's X axis when both
+// are centred.
+//
+// The direction the pointer faces at start-up is held. Each rotation
+// vector sample takes that world direction into the platform's frame with
+// quat.Rotate, and the pan and tilt angles follow from it. Commands go
+// through the safety interlock and a slew limit: tilting the platform past
+// maxTilt, spinning it faster than maxRate or losing sensor data freezes
+// the servos until "reset" is typed. The interlock starts faulted, so
+// nothing moves until the first "reset" either.
+//
+//	aim     hold the direction the pointer faces now
+//	reset   clear a safety fault
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/safety"
+	"github.com/intermernet/bno08xPrograms/internal/servo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Platform tilt beyond which the mount cannot compensate, degrees
+	maxTilt = 45
+	// Platform rotation rate beyond which tracking stops, degrees/second
+	maxRate = 360
+	// Fastest the servos are commanded to move, degrees/second
+	maxSlew = 240
+	// Longest gap in sensor data before the servos freeze
+	dataTimeout = 200 * time.Millisecond
+	// Interval between status lines
+	statusInterval = 500 * time.Millisecond
+)
+
+// Decimal places printed for angles
+const decimals = 1
+
+// pointer holds the aim and the safety state of the mount.
+type pointer struct {
+	target     [3]float32 // World direction to hold, unit vector
+	aimed      bool
+	interlock  *safety.Interlock
+	pan, tilt  safety.RateLimiter
+	panServo   *servo.Servo
+	tiltServo  *servo.Servo
+	q          bno08x.Quaternion // Latest platform orientation
+	haveOrient bool
+	rate       float32 // Latest rotation rate, degrees/second
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("stabilized_pointer")
+
+	pwm, panPin, tiltPin := board.ServoPWM()
+	if pwm == nil {
+		println("No servo PWM set for the " + board.Name + " board in internal/board")
+		return
+	}
+	if err := servo.Configure(pwm); err != nil {
+		println("Failed to configure PWM:", err.Error())
+		return
+	}
+	panServo, err := servo.New(pwm, panPin)
+	if err != nil {
+		println("Failed to set up pan servo:", err.Error())
+		return
+	}
+	tiltServo, err := servo.New(pwm, tiltPin)
+	if err != nil {
+		println("Failed to set up tilt servo:", err.Error())
+		return
+	}
+	panServo.SetAngle(0)
+	tiltServo.SetAngle(0)
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The game rotation vector ignores the magnetometer, which the servo
+	// motors would disturb; its slow yaw drift moves the aim a little
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 10000) // 100Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+	err = sensor.EnableReport(bno08x.SensorGyroscope, 10000)
+	if err != nil {
+		println("Failed to enable gyroscope:", err.Error())
+		return
+	}
+
+	p := &pointer{
+		interlock: safety.New(safety.Limits{MaxTilt: maxTilt, MaxRate: maxRate, DataTimeout: dataTimeout}),
+		pan:       safety.RateLimiter{MaxRate: maxSlew},
+		tilt:      safety.RateLimiter{MaxRate: maxSlew},
+		panServo:  panServo,
+		tiltServo: tiltServo,
+	}
+
+	var sh shell.Shell
+	sh.Register("aim", "aim", "Hold the direction the pointer faces now", func(args []string) error {
+		p.aim(time.Now())
+		return nil
+	})
+	sh.Register("reset", "reset", "Clear a safety fault", func(args []string) error {
+		if p.interlock.Reset() {
+			println("Safety interlock cleared")
+		} else {
+			println("Still outside limits:", p.interlock.Fault().String())
+		}
+		return nil
+	})
+
+	println("Type 'reset' to start tracking, 'help' for commands")
+	lastStatus := time.Now()
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorGameRotationVector:
+				p.q, p.haveOrient = event.Quaternion(), true
+				roll, pitch, _ := quat.ToEuler(p.q)
+				p.interlock.Update(units.RadiansToDegrees(roll), units.RadiansToDegrees(pitch), p.rate, now)
+				if !p.aimed {
+					p.aim(now)
+				}
+				p.track(now)
+			case bno08x.SensorGyroscope:
+				g := event.Gyroscope()
+				p.rate = units.RadiansToDegrees(float32(math.Sqrt(float64(g.X*g.X + g.Y*g.Y + g.Z*g.Z))))
+			}
+		}
+
+		if !p.interlock.Check(now) && p.aimed {
+			// Hold still; resume from the current position once cleared
+			p.pan.Hold(p.panServo.Angle(), now)
+			p.tilt.Hold(p.tiltServo.Angle(), now)
+		}
+
+		if now.Sub(lastStatus) >= statusInterval && p.aimed {
+			lastStatus = now
+			status := "ok"
+			if p.interlock.Faulted() {
+				status = "FAULT " + p.interlock.Fault().String() + " (type 'reset')"
+			}
+			println("Pan", fmtutil.Float(p.panServo.Angle(), decimals), "Tilt", fmtutil.Float(p.tiltServo.Angle(), decimals),
+				"deg |", status)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// aim holds the direction the pointer faces now: the platform's X axis
+// turned by the current servo angles, in world coordinates.
+func (p *pointer) aim(now time.Time) {
+	if !p.haveOrient {
+		println("No orientation yet")
+		return
+	}
+	pan := float64(units.DegreesToRadians(p.panServo.Angle()))
+	tilt := float64(units.DegreesToRadians(p.tiltServo.Angle()))
+	bx := float32(math.Cos(tilt) * math.Cos(pan))
+	by := float32(math.Cos(tilt) * math.Sin(pan))
+	bz := float32(math.Sin(tilt))
+	x, y, z := quat.Rotate(p.q, bx, by, bz)
+	p.target = [3]float32{x, y, z}
+	p.aimed = true
+	println("Holding direction", fmtutil.Float(x, 3), fmtutil.Float(y, 3), fmtutil.Float(z, 3))
+}
+
+// track turns the held world direction into the platform frame and
+// commands the servos towards it, if the interlock allows.
+func (p *pointer) track(now time.Time) {
+	if !p.aimed || p.interlock.Faulted() {
+		return
+	}
+	x, y, z := quat.Rotate(quat.Conjugate(p.q), p.target[0], p.target[1], p.target[2])
+	pan := units.RadiansToDegrees(float32(math.Atan2(float64(y), float64(x))))
+	tilt := units.RadiansToDegrees(float32(math.Atan2(float64(z), math.Hypot(float64(x), float64(y)))))
+	p.panServo.SetAngle(p.pan.Step(pan, now))
+	p.tiltServo.SetAngle(p.tilt.Step(tilt, now))
+}
@@ -0,0 +1,143 @@
+// Package main is a stopwatch driven by tapping the board: a double tap
+// starts and stops it, a single tap while running records a lap. Times are
+// printed on the serial console, and the full lap log is printed each time
+// the stopwatch stops. Typing "reset" clears it.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Most laps kept in the log
+const maxLaps = 99
+
+// Interval between running time updates on the console
+const tickInterval = time.Second
+
+// stopwatch accumulates running time across start/stop cycles.
+type stopwatch struct {
+	running bool
+	started time.Time     // Start of the current run
+	banked  time.Duration // Time from earlier runs
+	lastLap time.Duration
+	laps    []time.Duration // Lap lengths
+}
+
+// elapsed returns the total running time at now.
+func (s *stopwatch) elapsed(now time.Time) time.Duration {
+	if s.running {
+		return s.banked + now.Sub(s.started)
+	}
+	return s.banked
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("stopwatch")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The tap detector reports on event, the interval is not used
+	err = sensor.EnableReport(bno08x.SensorTapDetector, 0)
+	if err != nil {
+		println("Failed to enable tap detector:", err.Error())
+		return
+	}
+
+	println("Double tap: start/stop | Single tap: lap | Type 'reset' to clear")
+
+	sw := stopwatch{laps: make([]time.Duration, 0, maxLaps)}
+	var taps gesture.TapFilter
+	var commands shell.LineReader
+	lastTick := time.Now()
+
+	for {
+		now := time.Now()
+
+		if line, ok := commands.Poll(); ok {
+			if line == "reset" {
+				sw = stopwatch{laps: sw.laps[:0]}
+				println("Reset")
+			} else {
+				println("Unknown command. Commands: reset")
+			}
+		}
+
+		kind, at := taps.Poll(now)
+		if event, ok := sensor.GetSensorEvent(); ok && event.ID() == bno08x.SensorTapDetector {
+			if k, t := taps.Add(gesture.DecodeTap(event.TapDetector().Flags), now); k != gesture.NoTap {
+				kind, at = k, t
+			}
+		}
+
+		switch kind {
+		case gesture.DoubleTap:
+			if sw.running {
+				sw.banked = sw.elapsed(at)
+				sw.running = false
+				println("Stop", fmtutil.Elapsed(sw.banked))
+				printLog(&sw)
+			} else {
+				sw.started = at
+				sw.running = true
+				println("Start", fmtutil.Elapsed(sw.banked))
+			}
+		case gesture.SingleTap:
+			if sw.running {
+				recordLap(&sw, sw.elapsed(at))
+			}
+		}
+
+		if sw.running && now.Sub(lastTick) >= tickInterval {
+			lastTick = now
+			println(" ", fmtutil.Elapsed(sw.elapsed(now)))
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// recordLap logs a lap ending at total running time t.
+func recordLap(sw *stopwatch, t time.Duration) {
+	lap := t - sw.lastLap
+	sw.lastLap = t
+	if len(sw.laps) < maxLaps {
+		sw.laps = append(sw.laps, lap)
+	}
+	println("Lap", len(sw.laps), fmtutil.Elapsed(lap), "total", fmtutil.Elapsed(t))
+}
+
+// printLog prints every lap with its split time.
+func printLog(sw *stopwatch) {
+	if len(sw.laps) == 0 {
+		return
+	}
+	println("--- Laps ---")
+	var split time.Duration
+	for i, lap := range sw.laps {
+		split += lap
+		println(fmtutil.PadLeft(fmtutil.Int(i+1), 3), fmtutil.PadLeft(fmtutil.Elapsed(lap), 10), fmtutil.PadLeft(fmtutil.Elapsed(split), 10))
+	}
+	println("------------")
+}
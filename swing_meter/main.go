@@ -0,0 +1,262 @@
+// Package main analyses boxing punches and tennis, golf or bat swings with
+// the board strapped to the wrist or the handle.
+//
+// A swing starts when the calibrated gyroscope turns faster than the
+// sport's start rate and ends once it has stayed below the end rate for
+// settleTime. While it lasts the peak angular velocity and the peak linear
+// acceleration are tracked, and the game rotation vector at the peak
+// acceleration is kept as the orientation at impact. Each swing prints:
+//
+//   - speed: the peak angular velocity times the sport's lever arm, the
+//     speed of the fist or the sweet spot turning about the shoulder;
+//   - impact: the peak linear acceleration in g, and when it came;
+//   - orientation at impact: roll and pitch, and the yaw turned since the
+//     swing started;
+//   - score out of 100: speedPoints for reaching the sport's target speed
+//     and impactPoints for reaching its target impact.
+//
+// Swings shorter than minSwing are knocks and ignored, and for holdOff
+// after a swing the follow-through cannot start another.
+//
+//	sport [name]  show or choose the sport: boxing, tennis, golf or bat
+//	session       show the number of swings, best and mean score
+//	reset         start the session over
+package main
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// sport holds the segmentation thresholds and scoring targets of a sport.
+type sport struct {
+	name         string
+	leverArm     float32 // Distance from the centre of rotation to the fist or sweet spot, metres
+	startDPS     float32 // Rotation rate that starts a swing, degrees per second
+	endDPS       float32 // Rotation rate below which a swing ends, degrees per second
+	targetKMH    float32 // Speed scoring full points, km/h
+	targetImpact float32 // Linear acceleration scoring full points, g
+}
+
+var sports = []sport{
+	{name: "boxing", leverArm: 0.6, startDPS: 400, endDPS: 120, targetKMH: 35, targetImpact: 12},
+	{name: "tennis", leverArm: 1.3, startDPS: 300, endDPS: 90, targetKMH: 150, targetImpact: 8},
+	{name: "golf", leverArm: 1.6, startDPS: 300, endDPS: 90, targetKMH: 160, targetImpact: 10},
+	{name: "bat", leverArm: 1.2, startDPS: 300, endDPS: 90, targetKMH: 110, targetImpact: 8},
+}
+
+const (
+	// Report interval of the gyroscope, linear acceleration and game
+	// rotation vector in microseconds
+	interval = 5000 // 200Hz
+	// Time below the end rate that ends a swing
+	settleTime = 60 * time.Millisecond
+	// Shortest swing reported; shorter ones are knocks
+	minSwing = 80 * time.Millisecond
+	// Longest swing; the rotation has not stopped, so it is not a swing
+	maxSwing = 1500 * time.Millisecond
+	// Time after a swing during which no new one starts, so the follow
+	// through is not counted as a second swing
+	holdOff = 400 * time.Millisecond
+)
+
+// Points of the score
+const (
+	speedPoints  = 60
+	impactPoints = 40
+)
+
+// swing is the swing in progress.
+type swing struct {
+	start     time.Time
+	startQ    bno08x.Quaternion // Orientation when the swing started
+	lastFast  time.Time         // Last sample above the end rate
+	peakRate  float32           // Peak angular velocity, rad/s
+	peakAccel float32           // Peak linear acceleration, m/s²
+	impactAt  time.Duration     // Time of the peak linear acceleration after the start
+	impactQ   bno08x.Quaternion // Orientation at the peak linear acceleration
+}
+
+// session collects the swings since the last reset.
+type session struct {
+	count int
+	best  int
+	total int
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("swing_meter")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	for _, id := range []bno08x.SensorID{
+		bno08x.SensorGyroscope,
+		bno08x.SensorLinearAcceleration,
+		bno08x.SensorGameRotationVector,
+	} {
+		if err := sensor.EnableReport(id, interval); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+
+	sp := sports[0]
+	var stats session
+
+	var sh shell.Shell
+	sh.Register("sport", "sport [name]", "Show or choose the sport", func(args []string) error {
+		if len(args) > 1 {
+			return shell.ErrUsage
+		}
+		if len(args) == 1 {
+			found := false
+			for _, s := range sports {
+				if strings.EqualFold(s.name, args[0]) {
+					sp, found = s, true
+				}
+			}
+			if !found {
+				return shell.ErrUsage
+			}
+		}
+		println("Sport:", sp.name, "| lever", fmtutil.Float(sp.leverArm, 1), "m | start",
+			fmtutil.Float(sp.startDPS, 0), "dps | target", fmtutil.Float(sp.targetKMH, 0), "km/h,",
+			fmtutil.Float(sp.targetImpact, 0), "g")
+		return nil
+	})
+	sh.Register("session", "session", "Show the number of swings, best and mean score", func(args []string) error {
+		if stats.count == 0 {
+			println("No swings yet")
+			return nil
+		}
+		println("Swings:", stats.count, "| best", stats.best, "| mean", stats.total/stats.count)
+		return nil
+	})
+	sh.Register("reset", "reset", "Start the session over", func(args []string) error {
+		stats = session{}
+		println("Session reset")
+		return nil
+	})
+
+	println("Sport:", sp.name, "- swing away. Type 'help' for commands")
+
+	var sw swing
+	swinging := false
+	orientation := quat.Identity
+	var lastEnd time.Time
+
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			switch event.ID() {
+			case bno08x.SensorGameRotationVector:
+				orientation = event.Quaternion()
+			case bno08x.SensorGyroscope:
+				g := event.Gyroscope()
+				rate := length(g)
+				dps := units.RadiansToDegrees(rate)
+				if !swinging {
+					if dps >= sp.startDPS && now.Sub(lastEnd) >= holdOff {
+						swinging = true
+						sw = swing{start: now, startQ: orientation, lastFast: now, peakRate: rate, impactQ: orientation}
+					}
+					break
+				}
+				if rate > sw.peakRate {
+					sw.peakRate = rate
+				}
+				if dps >= sp.endDPS {
+					sw.lastFast = now
+				}
+			case bno08x.SensorLinearAcceleration:
+				if a := length(event.LinearAcceleration()); swinging && a > sw.peakAccel {
+					sw.peakAccel, sw.impactAt, sw.impactQ = a, now.Sub(sw.start), orientation
+				}
+			}
+		}
+
+		if swinging {
+			duration := now.Sub(sw.start)
+			switch {
+			case duration >= maxSwing:
+				swinging, lastEnd = false, now
+				println("Rotation did not stop, ignored")
+			case now.Sub(sw.lastFast) >= settleTime:
+				swinging, lastEnd = false, now
+				if duration = sw.lastFast.Sub(sw.start); duration >= minSwing {
+					s := report(&sw, &sp, duration)
+					stats.count++
+					stats.total += s
+					if s > stats.best {
+						stats.best = s
+					}
+				}
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// report prints a finished swing and returns its score.
+func report(sw *swing, sp *sport, duration time.Duration) int {
+	kmh := sw.peakRate * sp.leverArm * 3.6
+	impact := units.MetersPerSecondSquaredToG(sw.peakAccel)
+	roll, pitch, _ := quat.ToEuler(sw.impactQ)
+	_, _, turned := quat.ToEuler(quat.Relative(sw.startQ, sw.impactQ))
+	score := int(speedPoints*clamp01(kmh/sp.targetKMH) + impactPoints*clamp01(impact/sp.targetImpact) + 0.5)
+
+	println("Swing:", duration.Milliseconds(), "ms")
+	println("  Speed: ", fmtutil.Float(kmh, 1), "km/h (peak", fmtutil.Float(units.RadiansToDegrees(sw.peakRate), 0), "dps)")
+	println("  Impact:", fmtutil.Float(impact, 1), "g at", sw.impactAt.Milliseconds(), "ms")
+	println("  At impact: roll", fmtutil.Float(units.RadiansToDegrees(roll), 0), "pitch",
+		fmtutil.Float(units.RadiansToDegrees(pitch), 0), "turned", fmtutil.Float(units.RadiansToDegrees(turned), 0), "degrees")
+	println("  Score: ", score, "/ 100")
+	return score
+}
+
+// length returns the magnitude of v.
+func length(v bno08x.Vector3) float32 {
+	return float32(math.Sqrt(float64(v.X*v.X + v.Y*v.Y + v.Z*v.Z)))
+}
+
+func clamp01(v float32) float32 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
@@ -1,106 +1,413 @@
-// Package main debugs tap detector issues by showing all sensor events
+// Package main debugs and tunes the tap detector. It prints every tap as
+// it arrives, with the accelerometer enabled as a control so a silent tap
+// detector can be told from a silent sensor, and takes serial commands to
+// change the detector's settings at run time and count the taps each
+// setting catches, so sensitivity can be tuned by trying rather than by
+// reflashing.
+//
+// "sens" changes the change-sensitivity field of the tap detector's Set
+// Feature command. "config" reads and edits the Tap Detector
+// Configuration FRS record word by word, as its layout is not published;
+// the record is read at boot, so "reset" applies it. Every change starts a
+// new trial, and "results" lists the taps each trial caught.
+//
+// Runs over raw SHTP, to set the sensitivity and the record. Uses I2C by
+// default, or SPI when built with "-tags bno08x_spi".
+//
+//	sens <value> [rel]     set the change sensitivity, 0 to turn it off
+//	config                 print the tap detector configuration record
+//	config <word> <value>  change one word of the record
+//	config clear           delete the record, going back to the defaults
+//	reset                  reset the sensor so a new record takes effect
+//	results                list the taps caught by each trial
+//	status                 count the events received by sensor
 package main
 
 import (
-	"machine"
+	"errors"
+	"strconv"
 	"time"
 
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/frs"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
 	"tinygo.org/x/drivers/bno08x"
 )
 
+const (
+	// Accelerometer control interval in microseconds
+	controlInterval = sensorinfo.Interval10Hz
+	// Longest to wait for a record read or write to finish
+	frsTimeout = 2 * time.Second
+)
+
+var errTimeout = errors.New("no complete answer in time")
+
+// trial is one tap detector setting and the taps caught while it ran.
+type trial struct {
+	sensitivity uint16
+	relative    bool
+	config      string // Record changes in force, "" for the record as found
+	singles     int
+	doubles     int
+	start       time.Time
+	end         time.Time // Zero while running
+}
+
+// session holds the state the commands work on.
+type session struct {
+	link    transport.Transport
+	tap     shtpraw.Feature
+	written string // Record changes written, in force after the next reset
+	applied string // Record changes in force
+	trials  []trial
+	counts  [256]uint32 // Events by sensor ID
+	total   uint32
+	buf     [shtpraw.FeatureLen]byte // Longest of the requests sent
+	words   [frs.MaxWords]uint32
+	nwords  int
+}
+
 func main() {
 	time.Sleep(2 * time.Second)
+	buildinfo.Banner("tap_debug")
 
 	println("BNO08x Tap Detector Debug")
 	println("=========================")
 	println()
 
-	// Initialize I2C
-	i2c := machine.I2C0
-	err := i2c.Configure(machine.I2CConfig{
-		Frequency: 400 * machine.KHz,
-	})
+	link, err := transport.Open()
 	if err != nil {
-		println("I2C error:", err.Error())
+		println("FAILED:", err.Error())
 		return
 	}
 
-	// Create sensor
-	sensor := bno08x.New(i2c)
-	err = sensor.Configure(bno08x.Config{})
-	if err != nil {
-		println("Configure error:", err.Error())
+	s := &session{
+		link: link,
+		tap: shtpraw.Feature{
+			Sensor:   uint8(bno08x.SensorTapDetector),
+			Interval: sensorinfo.DefaultIntervalMicros(bno08x.SensorTapDetector),
+		},
+	}
+	if err := s.restart(); err != nil {
+		println("FAILED:", err.Error())
 		return
 	}
 
-	println("Sensor initialized")
+	var sh shell.Shell
+	sh.Register("sens", "sens <value> [rel]", "Set the change sensitivity, 0 to turn it off", s.sens)
+	sh.Register("config", "config [<word> <value> | clear]", "Print or change the tap detector configuration record", s.configure)
+	sh.Register("reset", "reset", "Reset the sensor so a new record takes effect", func(args []string) error {
+		return s.restart()
+	})
+	sh.Register("results", "results", "List the taps caught by each trial", s.results)
+	sh.Register("status", "status", "Count the events received by sensor", s.status)
+
+	println()
+	println("Waiting for sensor events...")
+	println("(Tap detector ID: 0x10, Accelerometer ID: 0x01)")
+	println("Tap away, then type 'sens <value>' or 'config' to try another setting, 'help' for commands")
 	println()
 
-	// Try enabling tap detector with different intervals
-	println("Enabling tap detector...")
-	err = sensor.EnableReport(bno08x.SensorTapDetector, 0)
-	if err != nil {
-		println("EnableReport error:", err.Error())
-		return
+	lastCheck := time.Now()
+	for {
+		sh.Poll()
+		if packet, err := transport.Next(link, 10*time.Millisecond); err == nil {
+			s.input(packet)
+		}
+
+		// Silence from the control too means nothing arrives at all
+		if time.Since(lastCheck) > 2*time.Second {
+			if s.counts[bno08x.SensorAccelerometer] == 0 {
+				println("No accelerometer events either: check the wiring and the sensor")
+			}
+			lastCheck = time.Now()
+		}
 	}
+}
 
-	// Also enable accelerometer as a control to see if ANY events come through
+// restart resets the sensor, lets the advertisement and reset messages go
+// by, and enables the tap detector and the accelerometer control. It
+// starts a new trial.
+func (s *session) restart() error {
+	println("Resetting sensor...")
+	if err := s.link.Reset(); err != nil {
+		return err
+	}
+	time.Sleep(300 * time.Millisecond)
+	for {
+		if _, err := transport.Next(s.link, 200*time.Millisecond); err != nil {
+			break
+		}
+	}
+	s.applied = s.written
+
+	println("Enabling tap detector...")
+	if err := s.enableTap(); err != nil {
+		return err
+	}
 	println("Enabling accelerometer as control...")
-	err = sensor.EnableReport(bno08x.SensorAccelerometer, 100000) // 10Hz
-	if err != nil {
+	control := shtpraw.Feature{Sensor: uint8(bno08x.SensorAccelerometer), Interval: controlInterval}
+	if err := s.link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(s.buf[:0], control)); err != nil {
 		println("Accelerometer error:", err.Error())
 	}
+	return nil
+}
 
-	time.Sleep(100 * time.Millisecond)
+// enableTap sends the tap detector's Set Feature and starts a trial of
+// its settings.
+func (s *session) enableTap() error {
+	if err := s.link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(s.buf[:0], s.tap)); err != nil {
+		return err
+	}
+	now := time.Now()
+	if n := len(s.trials); n > 0 && s.trials[n-1].end.IsZero() {
+		s.trials[n-1].end = now
+	}
+	s.trials = append(s.trials, trial{
+		sensitivity: s.tap.Sensitivity,
+		relative:    s.tap.Flags&shtpraw.FlagSensitivityRelative != 0,
+		config:      s.applied,
+		start:       now,
+	})
+	println("Trial "+fmtutil.Int(len(s.trials))+":", describe(&s.trials[len(s.trials)-1]))
+	return nil
+}
 
-	println()
-	println("Waiting for sensor events...")
-	println("(Tap detector ID: 0x10, Accelerometer ID: 0x01)")
-	println()
+func (s *session) sens(args []string) error {
+	if len(args) < 1 || len(args) > 2 || len(args) == 2 && args[1] != "rel" {
+		return shell.ErrUsage
+	}
+	v, err := strconv.ParseUint(args[0], 0, 16)
+	if err != nil {
+		return shell.ErrUsage
+	}
+	s.tap.Sensitivity = uint16(v)
+	s.tap.Flags = 0
+	if v != 0 {
+		s.tap.Flags |= shtpraw.FlagSensitivityEnabled
+		if len(args) == 2 {
+			s.tap.Flags |= shtpraw.FlagSensitivityRelative
+		}
+	}
+	return s.enableTap()
+}
 
-	eventCount := 0
-	tapCount := 0
-	accelCount := 0
-	otherSensors := make(map[uint8]int)
+func (s *session) configure(args []string) error {
+	switch {
+	case len(args) == 0:
+		return s.printConfig()
+	case len(args) == 1 && args[0] == "clear":
+		if err := s.write(nil); err != nil {
+			return err
+		}
+		s.written = "cleared"
+		println("Record deleted; type 'reset' to apply")
+		return nil
+	case len(args) != 2:
+		return shell.ErrUsage
+	}
+	index, err1 := strconv.Atoi(args[0])
+	value, err2 := strconv.ParseUint(args[1], 0, 32)
+	if err1 != nil || err2 != nil || index < 0 || index >= frs.MaxWords {
+		return shell.ErrUsage
+	}
+	if err := s.read(); err != nil {
+		return err
+	}
+	if index >= s.nwords {
+		println("The record has", s.nwords, "words; the words between are written as 0")
+		for i := s.nwords; i <= index; i++ {
+			s.words[i] = 0
+		}
+		s.nwords = index + 1
+	}
+	s.words[index] = uint32(value)
+	if err := s.write(s.words[:s.nwords]); err != nil {
+		return err
+	}
+	if s.written != "" {
+		s.written += ", "
+	}
+	s.written += "w" + fmtutil.Int(index) + "=" + hex(uint32(value))
+	println("Written; type 'reset' to apply")
+	return nil
+}
+
+// printConfig prints the record's words.
+func (s *session) printConfig() error {
+	if err := s.read(); err != nil {
+		return err
+	}
+	if s.nwords == 0 {
+		println("Tap detector config: not set, the built-in defaults apply")
+		return nil
+	}
+	println("Tap detector config,", s.nwords, "words:")
+	for i := 0; i < s.nwords; i++ {
+		println("  "+fmtutil.PadLeft(fmtutil.Int(i), 2)+":", hex(s.words[i]), int32(s.words[i]))
+	}
+	if s.written != s.applied {
+		println("Written since the last reset; type 'reset' to apply")
+	}
+	return nil
+}
+
+func (s *session) results(args []string) error {
+	if len(s.trials) == 0 {
+		println("No trials yet")
+		return nil
+	}
+	println("  #  " + fmtutil.PadRight("Setting", 40) + "  Minutes  Single  Double  Per min")
+	for i := range s.trials {
+		t := &s.trials[i]
+		end := t.end
+		if end.IsZero() {
+			end = time.Now()
+		}
+		minutes := float32(end.Sub(t.start).Seconds()) / 60
+		perMin := "-"
+		if minutes > 0 {
+			perMin = fmtutil.Float(float32(t.singles+t.doubles)/minutes, 1)
+		}
+		println(fmtutil.PadLeft(fmtutil.Int(i+1), 3) + "  " + fmtutil.PadRight(describe(t), 40) +
+			fmtutil.PadLeft(fmtutil.Float(minutes, 1), 9) + fmtutil.PadLeft(fmtutil.Int(t.singles), 8) +
+			fmtutil.PadLeft(fmtutil.Int(t.doubles), 8) + fmtutil.PadLeft(perMin, 9))
+	}
+	return nil
+}
 
-	lastPrint := time.Now()
+func (s *session) status(args []string) error {
+	println("--- Event Summary ---")
+	println("Total events:", s.total)
+	println("Tap events:", s.counts[bno08x.SensorTapDetector])
+	println("Accel events:", s.counts[bno08x.SensorAccelerometer])
+	println("Other sensor IDs:")
+	for id, count := range s.counts {
+		if count == 0 || id == int(bno08x.SensorTapDetector) || id == int(bno08x.SensorAccelerometer) {
+			continue
+		}
+		println("  Sensor", id, "("+sensorinfo.Name(bno08x.SensorID(id))+"):", count, "events")
+	}
+	return nil
+}
 
-	// Main loop
-	for {
-		event, ok := sensor.GetSensorEvent()
-		if ok {
-			eventCount++
-
-			switch event.ID() {
-			case bno08x.SensorTapDetector:
-				tapCount++
-				tap := event.TapDetector()
-				println("[TAP EVENT!] Flags:", tap.Flags, "Count:", tapCount)
-
-			case bno08x.SensorAccelerometer:
-				accelCount++
-				// Don't print every accel event, just count them
-
-			default:
-				otherSensors[uint8(event.ID())]++
+// input counts the reports in an input packet and prints each tap.
+func (s *session) input(packet shtpraw.Packet) {
+	if packet.Channel != shtpraw.ChannelInputNormal && packet.Channel != shtpraw.ChannelInputWake {
+		return
+	}
+	shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+		s.counts[r.ID]++
+		s.total++
+		if bno08x.SensorID(r.ID) != bno08x.SensorTapDetector || len(r.Data) < 5 {
+			return
+		}
+		tap := gesture.DecodeTap(r.Data[4])
+		if n := len(s.trials); n > 0 {
+			if tap.Double {
+				s.trials[n-1].doubles++
+			} else {
+				s.trials[n-1].singles++
 			}
+		}
+		println("[TAP EVENT!]", tap.String()+", flags:", r.Data[4], "count:", s.counts[r.ID])
+	})
+}
+
+// read fetches the tap detector configuration record into s.words.
+func (s *session) read() error {
+	var a frs.Assembler
+	a.Start(frs.TapConfig)
+	if err := s.link.Write(shtpraw.ChannelControl, frs.AppendReadRequest(s.buf[:0], frs.TapConfig, 0, 0)); err != nil {
+		return err
+	}
+	start := time.Now()
+	done := false
+	for !done && time.Since(start) < frsTimeout {
+		packet, err := transport.Next(s.link, frsTimeout-time.Since(start))
+		if err != nil {
+			continue
+		}
+		if packet.Channel != shtpraw.ChannelControl {
+			s.input(packet)
+			continue
+		}
+		frs.ParseReadResponses(packet.Cargo(), func(r frs.ReadResponse) {
+			done = a.Add(r) || done
+		})
+	}
+	switch {
+	case !done:
+		return errTimeout
+	case a.Err() != nil:
+		return a.Err()
+	}
+	s.nwords = 0
+	if !a.Empty {
+		s.nwords = copy(s.words[:], a.Words)
+	}
+	return nil
+}
 
-			// Print summary every 2 seconds
-			if time.Since(lastPrint) > 2*time.Second {
-				println()
-				println("--- Event Summary ---")
-				println("Total events:", eventCount)
-				println("Tap events:", tapCount)
-				println("Accel events:", accelCount)
-				println("Other sensor IDs:")
-				for id, count := range otherSensors {
-					println("  Sensor", id, ":", count, "events")
-				}
-				println()
-				lastPrint = time.Now()
+// write writes words to the tap detector configuration record, deleting
+// it if words is empty.
+func (s *session) write(words []uint32) error {
+	var w frs.Writer
+	if err := s.link.Write(shtpraw.ChannelControl, w.Start(s.buf[:0], frs.TapConfig, words)); err != nil {
+		return err
+	}
+	start := time.Now()
+	for time.Since(start) < frsTimeout {
+		packet, err := transport.Next(s.link, frsTimeout-time.Since(start))
+		if err != nil {
+			continue
+		}
+		r, err := frs.ParseWriteResponse(packet.Cargo())
+		if packet.Channel != shtpraw.ChannelControl || err != nil {
+			s.input(packet)
+			continue
+		}
+		req, done, err := w.Next(s.buf[:0], r)
+		if done {
+			return err
+		}
+		if len(req) > 0 {
+			if err := s.link.Write(shtpraw.ChannelControl, req); err != nil {
+				return err
 			}
 		}
+	}
+	return errTimeout
+}
+
+// describe summarises a trial's settings.
+func describe(t *trial) string {
+	line := "sensitivity off"
+	if t.sensitivity != 0 {
+		line = "sensitivity " + fmtutil.Int(int(t.sensitivity))
+		if t.relative {
+			line += " rel"
+		}
+	}
+	if t.config == "" {
+		return line + ", record as found"
+	}
+	return line + ", " + t.config
+}
 
-		time.Sleep(10 * time.Millisecond)
+// hex formats v as "0x" and eight hex digits.
+func hex(v uint32) string {
+	const digits = "0123456789ABCDEF"
+	b := []byte("0x00000000")
+	for i := len(b) - 1; i >= 2; i-- {
+		b[i] = digits[v&0x0F]
+		v >>= 4
 	}
+	return string(b)
 }
@@ -0,0 +1,191 @@
+// Package main zeroes the sensor's orientation at the current pose with
+// the SH-2 Tare commands, so the rotation vector reads level and facing
+// forward however the board is mounted. Set the board in its resting
+// pose and press the button on board.ButtonPin, or type "tare".
+//
+// The tare is applied inside the sensor hub and stored in its flash with
+// Persist Tare, so it survives resets and power cycles and every program
+// run afterwards sees it, until "clear". A heading-only tare zeroes the
+// yaw and leaves roll and pitch relative to gravity.
+//
+// Runs over raw SHTP, to send the commands. Uses I2C by default, or SPI
+// when built with "-tags bno08x_spi".
+//
+//	tare [heading]  zero all axes, or only the heading, and store it
+//	clear           remove the tare and the stored one
+//	show [off]      print the orientation twice a second
+package main
+
+import (
+	"encoding/binary"
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/sh2cmd"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/tarecmd"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Rotation vector report interval in microseconds
+	interval = 20000 // 50Hz
+	// Interval between lines printed by "show"
+	showInterval = 500 * time.Millisecond
+	// Time the button must stay down to count as a press
+	debounce = 50 * time.Millisecond
+)
+
+// Rotation vector quaternion scale, Q14
+const quatScale = 1 << 14
+
+// commander sends Tare commands with increasing sequence numbers.
+type commander struct {
+	link transport.Transport
+	seq  uint8
+	buf  [sh2cmd.RequestLen]byte
+}
+
+// send builds a request with fn and writes it on the control channel.
+func (c *commander) send(fn func(b []byte, seq uint8) []byte) error {
+	c.seq++
+	return c.link.Write(shtpraw.ChannelControl, fn(c.buf[:0], c.seq))
+}
+
+// tare zeroes axes at the current orientation and stores the tare.
+func (c *commander) tare(axes tarecmd.Axes) error {
+	err := c.send(func(b []byte, seq uint8) []byte {
+		return tarecmd.AppendTareNow(b, seq, axes, tarecmd.BasisRotationVector)
+	})
+	if err != nil {
+		return err
+	}
+	return c.send(tarecmd.AppendPersistTare)
+}
+
+// clear removes the tare and the stored one.
+func (c *commander) clear() error {
+	if err := c.send(tarecmd.AppendClearTare); err != nil {
+		return err
+	}
+	return c.send(tarecmd.AppendPersistTare)
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("tare")
+
+	button := board.ButtonPin()
+	if button != machine.NoPin {
+		button.Configure(machine.PinConfig{Mode: machine.PinInputPullup})
+	}
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	var cmd [shtpraw.FeatureLen]byte
+	rv := shtpraw.Feature{Sensor: uint8(bno08x.SensorRotationVector), Interval: interval}
+	if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], rv)); err != nil {
+		println("Failed to enable rotation vector:", err.Error())
+		return
+	}
+
+	c := &commander{link: link}
+	var q bno08x.Quaternion
+	haveOrient, showing := false, true
+
+	var sh shell.Shell
+	sh.Register("tare", "tare [heading]", "Zero all axes, or only the heading, and store it", func(args []string) error {
+		axes := tarecmd.AllAxes
+		switch {
+		case len(args) == 1 && args[0] == "heading":
+			axes = tarecmd.HeadingOnly
+		case len(args) != 0:
+			return shell.ErrUsage
+		}
+		if err := c.tare(axes); err != nil {
+			return err
+		}
+		println("Orientation zeroed at the current pose and stored")
+		return nil
+	})
+	sh.Register("clear", "clear", "Remove the tare and the stored one", func(args []string) error {
+		if err := c.clear(); err != nil {
+			return err
+		}
+		println("Tare cleared")
+		return nil
+	})
+	sh.Register("show", "show [off]", "Print the orientation twice a second", func(args []string) error {
+		showing = len(args) == 0 || args[0] != "off"
+		return nil
+	})
+
+	println("Press the button or type 'tare' to zero the orientation, 'help' for commands")
+
+	lastShow := time.Now()
+	pressed := false
+	var downSince time.Time
+
+	for {
+		now := time.Now()
+		sh.Poll()
+
+		if button != machine.NoPin {
+			switch down := !button.Get(); {
+			case !down:
+				downSince, pressed = time.Time{}, false
+			case downSince.IsZero():
+				downSince = now
+			case !pressed && now.Sub(downSince) >= debounce:
+				pressed = true
+				if err := c.tare(tarecmd.AllAxes); err != nil {
+					println("Tare failed:", err.Error())
+				} else {
+					println("Orientation zeroed at the current pose and stored")
+				}
+			}
+		}
+
+		packet, err := transport.Next(link, 10*time.Millisecond)
+		if err == nil && (packet.Channel == shtpraw.ChannelInputNormal || packet.Channel == shtpraw.ChannelInputWake) {
+			shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+				if bno08x.SensorID(r.ID) == bno08x.SensorRotationVector && len(r.Data) >= 12 {
+					q, haveOrient = rotation(r.Data), true
+				}
+			})
+		}
+
+		if showing && haveOrient && now.Sub(lastShow) >= showInterval {
+			lastShow = now
+			roll, pitch, yaw := quat.ToEuler(q)
+			println("Roll:", fmtutil.FloatWidth(units.RadiansToDegrees(roll), 1, 6),
+				"| Pitch:", fmtutil.FloatWidth(units.RadiansToDegrees(pitch), 1, 6),
+				"| Yaw:", fmtutil.FloatWidth(units.RadiansToDegrees(yaw), 1, 6))
+		}
+	}
+}
+
+// rotation decodes the quaternion of a rotation vector report.
+func rotation(data []byte) bno08x.Quaternion {
+	v := func(i int) float32 {
+		return float32(int16(binary.LittleEndian.Uint16(data[4+2*i:]))) / quatScale
+	}
+	return bno08x.Quaternion{I: v(0), J: v(1), K: v(2), Real: v(3)}
+}
@@ -0,0 +1,101 @@
+// Package main demonstrates smartwatch-style raise-to-wake. Wear the board
+// like a watch face, Z axis out of the face: raising the wrist to look at
+// it, or picking the board up, turns the "display" on, and lowering the
+// arm or waiting turns it off again.
+//
+// The board LED stands in for the display. Wearable programs that drive a
+// screen or radio use gesture.WristRaise the same way, powering it up on
+// Wake and down on Sleep.
+package main
+
+import (
+	"machine"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/gesture"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// How long the display stays on after a raise
+const awakeTime = 5 * time.Second
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("tilt_to_wake")
+
+	led := board.LEDPin()
+	if led != machine.NoPin {
+		led.Configure(machine.PinConfig{Mode: machine.PinOutput})
+		led.Low()
+	}
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// Gravity at a low rate is enough to follow the wrist; the pickup
+	// detector reports on event, so its interval is not used
+	err = sensor.EnableReport(bno08x.SensorGravity, 40000) // 25Hz
+	if err != nil {
+		println("Failed to enable gravity:", err.Error())
+		return
+	}
+	err = sensor.EnableReport(bno08x.SensorPickupDetector, 0)
+	if err != nil {
+		println("Failed to enable pickup detector:", err.Error())
+		return
+	}
+
+	println("Raise your wrist to wake, lower it to sleep")
+
+	wrist := gesture.WristRaise{AwakeTime: awakeTime}
+
+	for {
+		now := time.Now()
+		change := wrist.Poll(now)
+
+		if event, ok := sensor.GetSensorEvent(); ok {
+			switch event.ID() {
+			case bno08x.SensorGravity:
+				g := event.Gravity()
+				if c := wrist.Gravity(g.X, g.Y, g.Z, now); c != gesture.NoWakeChange {
+					change = c
+				}
+			case bno08x.SensorPickupDetector:
+				if c := wrist.Pickup(now); c != gesture.NoWakeChange {
+					change = c
+				}
+			}
+		} else {
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		switch change {
+		case gesture.Wake:
+			println("WAKE: display on")
+			if led != machine.NoPin {
+				led.High()
+			}
+		case gesture.Sleep:
+			println("SLEEP: display off")
+			if led != machine.NoPin {
+				led.Low()
+			}
+		}
+	}
+}
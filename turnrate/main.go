@@ -0,0 +1,85 @@
+// Package main measures vehicle turn rate: the angular velocity about the
+// vertical axis, in degrees per second, however the board is mounted.
+//
+// The gyroscope measures rotation about the sensor's own axes. Its Z axis
+// only reads the turn rate when the board lies flat; tilted or mounted on
+// its side, part of the turn shows up on X and Y instead. Rotating the gyro
+// vector into the world frame with the current orientation fixes this, and
+// is what this example prints next to the naive body Z reading.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"github.com/intermernet/bno08xPrograms/internal/units"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Decimal places printed for the rates
+const decimals = 1
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("turnrate")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The game rotation vector gives the tilt without magnetic disturbance
+	// from the vehicle; heading drift does not matter for a rate about Z
+	sensors := []bno08x.SensorID{
+		bno08x.SensorGameRotationVector,
+		bno08x.SensorGyroscope,
+	}
+	for _, id := range sensors {
+		err = sensor.EnableReport(id, 10000) // 100Hz
+		if err != nil {
+			println("Failed to enable sensor:", uint8(id), err.Error())
+			return
+		}
+	}
+
+	println("Format: World yaw rate | Body Z rate (deg/s)")
+
+	orientation := quat.Identity
+	lastPrint := time.Now()
+
+	for {
+		event, ok := sensor.GetSensorEvent()
+		if !ok {
+			time.Sleep(2 * time.Millisecond)
+			continue
+		}
+
+		switch event.ID() {
+		case bno08x.SensorGameRotationVector:
+			orientation = event.Quaternion()
+		case bno08x.SensorGyroscope:
+			if time.Since(lastPrint) < 100*time.Millisecond {
+				break
+			}
+			lastPrint = time.Now()
+			g := event.Gyroscope()
+			_, _, yawRate := quat.WorldAngularVelocity(orientation, g.X, g.Y, g.Z)
+			println("Yaw rate:", fmtutil.Float(units.RadiansToDegrees(yawRate), decimals),
+				"| Body Z:", fmtutil.Float(units.RadiansToDegrees(g.Z), decimals))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package main streams the orientation for the common 3D cube and teapot
+// visualizers, so a rotating model of the board needs no host code. Each
+// line is the quaternion, real part first, after a fixed prefix:
+//
+//	quat,0.7071,0.0000,0.0000,0.7071
+//
+// which Processing sketches in the FreeIMU cube style read by splitting on
+// commas. Set pyteapotFormat for the letter-delimited lines of the
+// PyTeapot script instead:
+//
+//	w0.7071wa0.0000ab0.0000bc0.7071c
+//
+// Lines are sent every sendInterval from the latest game rotation vector,
+// so the visualizer sees a steady rate whatever the sensor delivers.
+// Start-up messages come first; both readers skip lines they cannot
+// parse. Visualizers draw their model with their own axes, so a model
+// that turns the wrong way needs its axes swapped in the sketch.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Set to true to print PyTeapot lines instead of prefixed CSV
+const pyteapotFormat = false
+
+// Prefix of the CSV lines
+const prefix = "quat"
+
+// Interval between lines
+const sendInterval = 20 * time.Millisecond // 50Hz
+
+// Decimal places printed for the components
+const decimals = 4
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("visualizer_stream")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The game rotation vector does not jump when the magnetometer is
+	// disturbed, which suits a model on the desk. Sampling faster than
+	// sendInterval keeps the latest orientation fresh.
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 10000) // 100Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	var q bno08x.Quaternion
+	haveQuat := false
+	lastSend := time.Now()
+
+	for {
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			if event.ID() == bno08x.SensorGameRotationVector {
+				q, haveQuat = event.Quaternion(), true
+			}
+		}
+
+		if haveQuat && time.Since(lastSend) >= sendInterval {
+			lastSend = lastSend.Add(sendInterval)
+			// Skip missed lines rather than bursting after a stall
+			if time.Since(lastSend) >= sendInterval {
+				lastSend = time.Now()
+			}
+			send(q)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// send prints one line for q in the selected format.
+func send(q bno08x.Quaternion) {
+	w := fmtutil.Float(q.Real, decimals)
+	x := fmtutil.Float(q.I, decimals)
+	y := fmtutil.Float(q.J, decimals)
+	z := fmtutil.Float(q.K, decimals)
+	if pyteapotFormat {
+		println("w" + w + "wa" + x + "ab" + y + "bc" + z + "c")
+		return
+	}
+	println(prefix + "," + w + "," + x + "," + y + "," + z)
+}
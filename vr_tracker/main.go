@@ -0,0 +1,185 @@
+// Package main is a wireless rotation tracker for SteamVR, streaming the
+// orientation over WiFi UDP in one of two formats:
+//
+//   - formatSlimeVR, the SlimeVR tracker protocol (internal/slimevr): the
+//     SlimeVR server pairs with the board by handshake and turns it into a
+//     SteamVR tracker for full-body tracking. Its packet number is the
+//     sequence number; the protocol has no timestamp field.
+//   - formatPlain, for bridges and OpenVR drivers of one's own: each
+//     datagram is a little-endian uint32 sequence number, a uint32
+//     timestamp in microseconds since start-up, wrapping after about 71
+//     minutes, and the quaternion w, x, y, z as float32, 24 bytes in all.
+//
+// Either way a gap in the sequence numbers is a dropped datagram. The
+// orientation is the game rotation vector relative to the pose at
+// start-up; stand in the calibration pose and type "center" to re-center.
+//
+// Build with "-tags wifi" for a board with a netdev WiFi driver, such as
+// the Pico W, after setting the network and the server address in
+// wifi.go. Other builds print the datagrams on the console instead.
+//
+//	center  make the current pose the reference
+//	stats   show the datagrams sent and failed
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/quat"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/slimevr"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Output formats
+const (
+	formatSlimeVR = iota
+	formatPlain
+)
+
+// Format of the datagrams sent
+const format = formatSlimeVR
+
+const (
+	// Game rotation vector report interval in microseconds
+	interval = 5000 // 200Hz
+	// Interval between SlimeVR heartbeats
+	heartbeatInterval = time.Second
+	// UDP port of formatPlain datagrams
+	plainPort = 6970
+	// Length of a formatPlain datagram
+	plainLen = 4 + 4 + 4*4
+)
+
+// SlimeVR sensor number of the BNO08x; a tracker can carry several
+const sensorID = 0
+
+var errNoOrientation = errors.New("no rotation vector received yet")
+
+// sender delivers datagrams to the server.
+type sender interface {
+	Send(packet []byte) error
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("vr_tracker")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	// The game rotation vector ignores the magnetometer, which the metal
+	// around a play space would disturb; SlimeVR's resets handle its slow
+	// yaw drift
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, interval)
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	out, err := dial()
+	if err != nil {
+		println("Failed to open UDP socket:", err.Error())
+		return
+	}
+
+	var q, center bno08x.Quaternion
+	haveQuat, centered := false, false
+	sequence := uint64(1) // Number of the next datagram; SlimeVR's handshake is 0
+	var sent, sendErrors uint32
+
+	var sh shell.Shell
+	sh.Register("center", "center", "Make the current pose the reference", func(args []string) error {
+		if len(args) != 0 {
+			return shell.ErrUsage
+		}
+		if !haveQuat {
+			return errNoOrientation
+		}
+		center, centered = q, true
+		println("Centered")
+		return nil
+	})
+	sh.Register("stats", "stats", "Show the datagrams sent and failed", func(args []string) error {
+		println("Sent:", sent, "| failed:", sendErrors, "| next sequence:", sequence)
+		return nil
+	})
+
+	var packet [64]byte
+	send := func(b []byte) {
+		sequence++
+		if err := out.Send(b); err != nil {
+			sendErrors++
+			// Report the first failure and then every hundredth
+			if sendErrors%100 == 1 {
+				println("Send failed:", err.Error(), "-", sendErrors, "failures so far")
+			}
+			return
+		}
+		sent++
+	}
+
+	if format == formatSlimeVR {
+		send(slimevr.AppendSensorInfo(packet[:0], sequence, sensorID, slimevr.IMUBNO085))
+	}
+
+	start := time.Now()
+	lastHeartbeat := start
+
+	for {
+		sh.Poll()
+
+		now := time.Now()
+		if format == formatSlimeVR && now.Sub(lastHeartbeat) >= heartbeatInterval {
+			lastHeartbeat = now
+			send(slimevr.AppendHeartbeat(packet[:0], sequence))
+		}
+
+		event, ok := sensor.GetSensorEvent()
+		if !ok || event.ID() != bno08x.SensorGameRotationVector {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		q, haveQuat = event.Quaternion(), true
+		if !centered {
+			center, centered = q, true
+			println("Centered at start-up pose")
+		}
+
+		rel := quat.Relative(center, q)
+		if format == formatSlimeVR {
+			send(slimevr.AppendRotation(packet[:0], sequence, sensorID, rel, event.Status()&3))
+		} else {
+			micros := uint32(now.Sub(start) / time.Microsecond)
+			send(appendPlain(packet[:0], uint32(sequence), micros, rel))
+		}
+	}
+}
+
+// appendPlain appends a formatPlain datagram.
+func appendPlain(dst []byte, sequence, micros uint32, q bno08x.Quaternion) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, sequence)
+	dst = binary.LittleEndian.AppendUint32(dst, micros)
+	for _, v := range [...]float32{q.Real, q.I, q.J, q.K} {
+		dst = binary.LittleEndian.AppendUint32(dst, math.Float32bits(v))
+	}
+	return dst
+}
@@ -0,0 +1,93 @@
+//go:build wifi
+
+package main
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/slimevr"
+	"tinygo.org/x/drivers/netlink"
+	"tinygo.org/x/drivers/netlink/probe"
+)
+
+// WiFi network to join, and the address of the PC running the SlimeVR
+// server or bridge. The subnet's broadcast address reaches it without
+// knowing the PC's address, where the network allows it.
+const (
+	ssid       = ""
+	passphrase = ""
+	server     = "192.168.1.255"
+)
+
+// Interval between handshakes while the SlimeVR server does not answer
+const handshakeRetry = time.Second
+
+// udpSender sends datagrams on a connected UDP socket.
+type udpSender struct {
+	conn net.Conn
+}
+
+func (u *udpSender) Send(packet []byte) error {
+	_, err := u.conn.Write(packet)
+	return err
+}
+
+func dial() (sender, error) {
+	link, _ := probe.Probe()
+	println("Joining WiFi network", ssid+"...")
+	err := link.NetConnect(&netlink.ConnectParams{Ssid: ssid, Passphrase: passphrase})
+	if err != nil {
+		return nil, err
+	}
+	port := plainPort
+	if format == formatSlimeVR {
+		port = slimevr.Port
+	}
+	addr := net.JoinHostPort(server, strconv.Itoa(port))
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if format == formatSlimeVR {
+		h := slimevr.Handshake{
+			Board:    slimevr.BoardCustom,
+			IMU:      slimevr.IMUBNO085,
+			MCU:      slimevr.MCUUnknown,
+			Firmware: "bno08xPrograms",
+		}
+		if mac, err := link.GetHardwareAddr(); err == nil {
+			copy(h.MAC[:], mac)
+		}
+		if err := handshake(conn, &h); err != nil {
+			return nil, err
+		}
+	}
+	println("Sending to", addr)
+	return &udpSender{conn: conn}, nil
+}
+
+// handshake announces the tracker until the SlimeVR server answers.
+func handshake(conn net.Conn, h *slimevr.Handshake) error {
+	packet := slimevr.AppendHandshake(nil, h)
+	reply := make([]byte, 64)
+	println("Waiting for the SlimeVR server...")
+	for {
+		if _, err := conn.Write(packet); err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(handshakeRetry))
+		n, err := conn.Read(reply)
+		var timeout net.Error
+		switch {
+		case err == nil && slimevr.ReplyIsHandshake(reply[:n]):
+			conn.SetReadDeadline(time.Time{})
+			println("SlimeVR server answered")
+			return nil
+		case err != nil && !(errors.As(err, &timeout) && timeout.Timeout()):
+			return err
+		}
+	}
+}
@@ -0,0 +1,59 @@
+//go:build !wifi
+
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/slimevr"
+)
+
+// Interval between lines printed by consoleSender
+const printInterval = time.Second
+
+// consoleSender prints the sequence number and quaternion of a rotation
+// datagram now and then instead of sending it, on boards without WiFi or
+// builds without "-tags wifi".
+type consoleSender struct {
+	lastPrint time.Time
+}
+
+func (c *consoleSender) Send(packet []byte) error {
+	if time.Since(c.lastPrint) < printInterval {
+		return nil
+	}
+	var sequence uint64
+	var values []byte
+	switch {
+	case format == formatPlain && len(packet) == plainLen:
+		sequence, values = uint64(binary.LittleEndian.Uint32(packet)), packet[8:]
+	case format == formatSlimeVR && len(packet) >= 30 && binary.BigEndian.Uint32(packet) == slimevr.PacketRotationData:
+		sequence, values = binary.BigEndian.Uint64(packet[4:]), packet[14:]
+	default:
+		return nil
+	}
+	c.lastPrint = time.Now()
+	value := func(i int) string {
+		var bits uint32
+		if format == formatPlain {
+			bits = binary.LittleEndian.Uint32(values[4*i:])
+		} else {
+			bits = binary.BigEndian.Uint32(values[4*i:])
+		}
+		return fmtutil.Float(math.Float32frombits(bits), 3)
+	}
+	if format == formatPlain {
+		println("Seq:", sequence, "W:", value(0), "X:", value(1), "Y:", value(2), "Z:", value(3))
+	} else {
+		println("Seq:", sequence, "X:", value(0), "Y:", value(1), "Z:", value(2), "W:", value(3))
+	}
+	return nil
+}
+
+func dial() (sender, error) {
+	println("Built without -tags wifi: datagrams are printed, not sent")
+	return &consoleSender{}, nil
+}
@@ -0,0 +1,196 @@
+// Package main demonstrates the low-power half of SHTP: wake reports. A
+// sensor enabled with the wake-up flag sends its reports on the wake
+// input channel and raises INT at once, while ordinary reports can be
+// batched in the sensor hub's FIFO for batchInterval without disturbing
+// the host. The microcontroller here sleeps until the sensor pulls INT
+// low, reads everything waiting, and goes back to sleep.
+//
+// The tap and shake detectors are the wake sensors; tap or shake the
+// board to wake it. The accelerometer runs as an ordinary sensor with a
+// long batch interval, so each wake also shows the reports the hub held
+// back, and a wake with no wake report shows the batch filling up. The
+// sleep is the scheduler's idle, the deepest TinyGo reaches on every
+// target: the core waits for an interrupt with nothing scheduled, and
+// looks at the flag the INT interrupt sets every idleCheck.
+//
+// Needs the INT pin wired. Runs over raw SHTP, to set the wake-up flag.
+// Uses I2C by default, or SPI when built with "-tags bno08x_spi".
+//
+//	status   show the wakes, reports and time asleep so far
+package main
+
+import (
+	"machine"
+	"sync/atomic"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/board"
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/sensorinfo"
+	"github.com/intermernet/bno08xPrograms/internal/shell"
+	"github.com/intermernet/bno08xPrograms/internal/shtpraw"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+const (
+	// Report interval of the wake detectors in microseconds; they report
+	// on event, so a long interval only saves power
+	wakeInterval = sensorinfo.Interval1Hz
+	// Ordinary accelerometer report interval in microseconds
+	batchedInterval = sensorinfo.Interval10Hz
+	// Longest the hub holds ordinary reports before raising INT
+	batchInterval = 30 * time.Second
+	// Interval at which the interrupt flag and the console are looked at
+	idleCheck = 100 * time.Millisecond
+	// Time to keep reading after a wake, for the rest of a flushed batch
+	awakeTime = 50 * time.Millisecond
+)
+
+// Sensors enabled with the wake-up flag
+var wakeSensors = []bno08x.SensorID{
+	bno08x.SensorTapDetector,
+	bno08x.SensorShakeDetector,
+}
+
+// intPending is set by the INT pin interrupt when the sensor has data.
+var intPending uint32
+
+// stats counts what the wakes brought.
+type stats struct {
+	wakes     int
+	wakeByID  [256]uint32 // Wake channel reports by sensor ID
+	batched   uint32      // Normal channel reports
+	asleep    time.Duration
+	awake     time.Duration
+	lastWake  time.Time
+	startedAt time.Time
+}
+
+func main() {
+	time.Sleep(2 * time.Second)
+	buildinfo.Banner("wake_sleep")
+
+	intPin := board.IntPin()
+	if intPin == machine.NoPin {
+		println("No INT pin set for the " + board.Name + " board in internal/board; this demo needs it")
+		return
+	}
+
+	link, err := transport.Open()
+	if err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	println("Resetting sensor...")
+	if err := transport.ResetAndDrain(link); err != nil {
+		println("FAILED:", err.Error())
+		return
+	}
+
+	var cmd [shtpraw.FeatureLen]byte
+	for _, id := range wakeSensors {
+		f := shtpraw.Feature{Sensor: uint8(id), Flags: shtpraw.FlagWakeup, Interval: wakeInterval}
+		if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], f)); err != nil {
+			println("Failed to enable", sensorinfo.Name(id)+":", err.Error())
+			return
+		}
+	}
+	accel := shtpraw.Feature{
+		Sensor:        uint8(bno08x.SensorAccelerometer),
+		Interval:      batchedInterval,
+		BatchInterval: uint32(batchInterval.Microseconds()),
+	}
+	if err := link.Write(shtpraw.ChannelControl, shtpraw.AppendSetFeature(cmd[:0], accel)); err != nil {
+		println("Failed to enable", sensorinfo.Name(bno08x.SensorAccelerometer)+":", err.Error())
+		return
+	}
+
+	// The transport configured the pin as an input with pull-up
+	err = intPin.SetInterrupt(machine.PinFalling, func(machine.Pin) {
+		atomic.StoreUint32(&intPending, 1)
+	})
+	if err != nil {
+		println("Could not enable the INT interrupt:", err.Error())
+		return
+	}
+
+	var st stats
+	st.startedAt = time.Now()
+	st.lastWake = st.startedAt
+
+	var sh shell.Shell
+	sh.Register("status", "status", "Show the wakes, reports and time asleep so far", func(args []string) error {
+		st.print()
+		return nil
+	})
+
+	println("Sleeping; tap or shake the board to wake it, type 'status' for totals")
+
+	for {
+		sh.Poll()
+
+		// INT stays low if data arrived while reading; no new edge comes
+		if atomic.SwapUint32(&intPending, 0) == 0 && intPin.Get() {
+			time.Sleep(idleCheck)
+			continue
+		}
+
+		woke := time.Now()
+		st.asleep += woke.Sub(st.lastWake)
+		st.wakes++
+		var wake [256]uint32
+		batched := uint32(0)
+		for {
+			packet, err := transport.Next(link, awakeTime)
+			if err != nil {
+				break
+			}
+			if packet.Channel != shtpraw.ChannelInputNormal && packet.Channel != shtpraw.ChannelInputWake {
+				continue
+			}
+			onWake := packet.Channel == shtpraw.ChannelInputWake
+			shtpraw.DecodeInput(packet.Cargo(), 0, func(r shtpraw.Report) {
+				if onWake {
+					wake[r.ID]++
+				} else {
+					batched++
+				}
+			})
+		}
+		st.batched += batched
+
+		line := fmtutil.Elapsed(woke.Sub(st.startedAt)) + " Woke after " + fmtutil.Float(float32(woke.Sub(st.lastWake).Seconds()), 1) + "s:"
+		reason := ""
+		for id, n := range wake {
+			if n == 0 {
+				continue
+			}
+			st.wakeByID[id] += n
+			reason += " " + sensorinfo.Name(bno08x.SensorID(id))
+		}
+		if reason == "" {
+			reason = " batch full"
+		}
+		println(line+reason+",", batched, "batched reports")
+
+		st.lastWake = time.Now()
+		st.awake += st.lastWake.Sub(woke)
+	}
+}
+
+// print shows the totals so far.
+func (st *stats) print() {
+	println("Wakes:", st.wakes, "| batched reports:", st.batched)
+	for id, n := range st.wakeByID {
+		if n > 0 {
+			println("  " + sensorinfo.Name(bno08x.SensorID(id)) + ": " + fmtutil.Int(int(n)) + " wake reports")
+		}
+	}
+	total := st.asleep + st.awake
+	if total > 0 {
+		println("Asleep", fmtutil.Float(float32(st.asleep)*100/float32(total), 1)+"% of the time")
+	}
+}
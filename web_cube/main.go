@@ -0,0 +1,102 @@
+// Package main serves a live 3D view of the board's orientation to a
+// browser. The board runs a tiny HTTP server on port 80: "/" returns the
+// page in page.go, a rotating box drawn on a canvas, and "/ws" is a
+// WebSocket streaming the game rotation vector as JSON text messages:
+//
+//	{"w":0.7071,"x":0.0000,"y":0.0000,"z":0.7071}
+//
+// Build with "-tags wifi" for a board with a netdev WiFi driver, such as
+// the Pico W, after setting the network in wifi.go, then open the address
+// it prints. The page needs no internet access. Other builds print the
+// messages on the console instead.
+package main
+
+import (
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/buildinfo"
+	"github.com/intermernet/bno08xPrograms/internal/fmtutil"
+	"github.com/intermernet/bno08xPrograms/internal/transport"
+	"tinygo.org/x/drivers/bno08x"
+)
+
+// Interval between messages
+const sendInterval = 50 * time.Millisecond // 20Hz
+
+// Decimal places sent for the components
+const decimals = 4
+
+// streamer sends a message to every connected browser.
+type streamer interface {
+	Send(msg []byte)
+}
+
+func main() {
+	time.Sleep(2 * time.Second) // Wait for sensor to power up
+	buildinfo.Banner("web_cube")
+
+	println("Initializing BNO08x sensor...")
+
+	// Create and configure sensor
+	sensor, err := transport.OpenSensor()
+	if err != nil {
+		println("Failed to open sensor:", err.Error())
+		return
+	}
+	err = sensor.Configure(bno08x.Config{})
+	if err != nil {
+		println("Failed to configure sensor:", err.Error())
+		return
+	}
+
+	println("Sensor initialized successfully")
+
+	err = sensor.EnableReport(bno08x.SensorGameRotationVector, 10000) // 100Hz
+	if err != nil {
+		println("Failed to enable game rotation vector:", err.Error())
+		return
+	}
+
+	stream, err := serve()
+	if err != nil {
+		println("Failed to start server:", err.Error())
+		return
+	}
+
+	var q bno08x.Quaternion
+	haveQuat := false
+	var msg [96]byte
+	lastSend := time.Now()
+
+	for {
+		for {
+			event, ok := sensor.GetSensorEvent()
+			if !ok {
+				break
+			}
+			if event.ID() == bno08x.SensorGameRotationVector {
+				q, haveQuat = event.Quaternion(), true
+			}
+		}
+
+		if haveQuat && time.Since(lastSend) >= sendInterval {
+			lastSend = time.Now()
+			stream.Send(appendQuaternion(msg[:0], q))
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// appendQuaternion appends q as a JSON object to dst.
+func appendQuaternion(dst []byte, q bno08x.Quaternion) []byte {
+	dst = append(dst, `{"w":`...)
+	dst = fmtutil.AppendFloat(dst, q.Real, decimals)
+	dst = append(dst, `,"x":`...)
+	dst = fmtutil.AppendFloat(dst, q.I, decimals)
+	dst = append(dst, `,"y":`...)
+	dst = fmtutil.AppendFloat(dst, q.J, decimals)
+	dst = append(dst, `,"z":`...)
+	dst = fmtutil.AppendFloat(dst, q.K, decimals)
+	return append(dst, '}')
+}
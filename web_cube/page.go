@@ -0,0 +1,75 @@
+package main
+
+// page is served at "/". It draws the board as a box seen from behind and
+// above, the front (+X) face red, the left (+Y) face green and the top
+// (+Z) face blue, rotated by each quaternion from "/ws", and reconnects
+// when the board restarts.
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width">
+<title>BNO08x orientation</title>
+<style>
+body{margin:0;background:#222;color:#ccc;font:14px sans-serif;text-align:center}
+canvas{display:block;margin:auto;max-width:100%}
+</style>
+</head>
+<body>
+<p id="s">Connecting...</p>
+<canvas id="c" width="480" height="480"></canvas>
+<script>
+const cv=document.getElementById('c'),g=cv.getContext('2d'),st=document.getElementById('s');
+// Box corners, index = 4*x + 2*y + z with each 0 for negative, 1 for positive
+const V=[];
+for(const x of[-1,1])for(const y of[-.6,.6])for(const z of[-.15,.15])V.push([x,y,z]);
+// Faces as corner indices and colour: +X, -X, +Y, -Y, +Z, -Z
+const F=[[4,5,7,6,'#d33'],[0,1,3,2,'#733'],[2,3,7,6,'#3a3'],[0,1,5,4,'#363'],[1,3,7,5,'#36d'],[0,2,6,4,'#236']];
+let q={w:1,x:0,y:0,z:0};
+// Camera yaw and elevation
+const cb=Math.cos(.5),sb=Math.sin(.5),ca=Math.cos(.45),sa=Math.sin(.45);
+
+// rot rotates v by q: v + w*t + q x t, with t = 2 q x v
+function rot([x,y,z]){
+  const{w,x:a,y:b,z:c}=q;
+  const tx=2*(b*z-c*y),ty=2*(c*x-a*z),tz=2*(a*y-b*x);
+  return[x+w*tx+b*tz-c*ty,y+w*ty+c*tx-a*tz,z+w*tz+a*ty-b*tx];
+}
+
+// view returns screen right, up and depth towards the viewer, looking
+// forward along +X from behind and above
+function view([x,y,z]){
+  let r=-y,u=z,t=-x;
+  [r,t]=[r*cb+t*sb,t*cb-r*sb];
+  [u,t]=[u*ca-t*sa,t*ca+u*sa];
+  return[r,u,t];
+}
+
+function draw(){
+  const P=V.map(v=>view(rot(v))),s=cv.width/4,c=cv.width/2;
+  g.clearRect(0,0,cv.width,cv.height);
+  // Painter's algorithm: farthest faces first
+  F.map(f=>[f,P[f[0]][2]+P[f[1]][2]+P[f[2]][2]+P[f[3]][2]])
+    .sort((a,b)=>a[1]-b[1])
+    .forEach(([f])=>{
+      g.beginPath();
+      for(let i=0;i<4;i++){const p=P[f[i]];g.lineTo(c+p[0]*s,c-p[1]*s);}
+      g.closePath();
+      g.fillStyle=f[4];g.fill();
+      g.strokeStyle='#111';g.stroke();
+    });
+}
+
+function connect(){
+  const ws=new WebSocket('ws://'+location.host+'/ws');
+  ws.onopen=()=>st.textContent='Live';
+  ws.onmessage=e=>{q=JSON.parse(e.data);draw();};
+  ws.onclose=()=>{st.textContent='Reconnecting...';setTimeout(connect,1000);};
+}
+
+draw();
+connect();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,138 @@
+//go:build wifi
+
+package main
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/intermernet/bno08xPrograms/internal/websocket"
+	"tinygo.org/x/drivers/netlink"
+	"tinygo.org/x/drivers/netlink/probe"
+)
+
+// WiFi network to join
+const (
+	ssid       = ""
+	passphrase = ""
+)
+
+// Browsers streamed to at once; later ones are turned away
+const maxClients = 4
+
+// wsServer serves the page and streams to its WebSocket clients.
+type wsServer struct {
+	mu      sync.Mutex
+	clients []net.Conn
+	frame   [128]byte
+}
+
+func serve() (streamer, error) {
+	link, _ := probe.Probe()
+	println("Joining WiFi network", ssid+"...")
+	err := link.NetConnect(&netlink.ConnectParams{Ssid: ssid, Passphrase: passphrase})
+	if err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", ":80")
+	if err != nil {
+		return nil, err
+	}
+	println("Serving on port 80; open the board's address in a browser")
+	s := &wsServer{}
+	go s.accept(ln)
+	return s, nil
+}
+
+func (s *wsServer) accept(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers one connection: the page, or a WebSocket kept open until
+// the browser closes it.
+func (s *wsServer) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	req, err := websocket.ReadRequest(r)
+	switch {
+	case err != nil:
+		conn.Close()
+	case req.Path == "/ws" && req.Upgrade():
+		if !s.add(conn, req.Key) {
+			websocket.WriteResponse(conn, "503 Service Unavailable", "text/plain", "Too many viewers\n")
+			conn.Close()
+			return
+		}
+		// Browsers only send a close frame in practice; read until it
+		// arrives or the connection drops
+		var buf [125]byte
+		for {
+			op, _, err := websocket.ReadFrame(r, buf[:])
+			if err != nil || op == websocket.OpClose {
+				break
+			}
+		}
+		s.remove(conn)
+	case req.Path == "/":
+		websocket.WriteResponse(conn, "200 OK", "text/html; charset=utf-8", page)
+		conn.Close()
+	default:
+		websocket.WriteResponse(conn, "404 Not Found", "text/plain", "Not found\n")
+		conn.Close()
+	}
+}
+
+// add completes the handshake and starts streaming to conn, unless
+// maxClients are already connected.
+func (s *wsServer) add(conn net.Conn, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.clients) >= maxClients {
+		return false
+	}
+	if err := websocket.WriteHandshake(conn, key); err != nil {
+		conn.Close()
+		return true
+	}
+	s.clients = append(s.clients, conn)
+	println("Viewer connected,", len(s.clients), "watching")
+	return true
+}
+
+func (s *wsServer) remove(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop(conn)
+}
+
+// drop closes conn and forgets it; s.mu must be held.
+func (s *wsServer) drop(conn net.Conn) {
+	for i, c := range s.clients {
+		if c == conn {
+			s.clients = append(s.clients[:i], s.clients[i+1:]...)
+			conn.Close()
+			println("Viewer left,", len(s.clients), "watching")
+			return
+		}
+	}
+}
+
+// Send implements streamer, dropping clients whose writes fail.
+func (s *wsServer) Send(msg []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frame := websocket.AppendFrame(s.frame[:0], websocket.OpText, msg)
+	for i := len(s.clients) - 1; i >= 0; i-- {
+		if _, err := s.clients[i].Write(frame); err != nil {
+			s.drop(s.clients[i])
+		}
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !wifi
+
+package main
+
+import "time"
+
+// Interval between messages printed by consoleStreamer
+const printInterval = time.Second
+
+// consoleStreamer prints messages instead of streaming them, on boards
+// without WiFi or builds without "-tags wifi".
+type consoleStreamer struct {
+	lastPrint time.Time
+}
+
+func (c *consoleStreamer) Send(msg []byte) {
+	if time.Since(c.lastPrint) < printInterval {
+		return
+	}
+	c.lastPrint = time.Now()
+	println(string(msg))
+}
+
+func serve() (streamer, error) {
+	println("Built without -tags wifi: messages are printed, not served")
+	return &consoleStreamer{}, nil
+}